@@ -56,7 +56,7 @@ func NewDatasetInformer(client client.Interface, namespace string, resyncPeriod
 // one. This reduces memory footprint and number of connections to the server.
 func NewFilteredDatasetInformer(client client.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers, tweakListOptions internalinterfaces.TweakListOptionsFunc) cache.SharedIndexInformer {
 	return cache.NewSharedIndexInformer(
-		&cache.ListWatch{
+		cache.ToListWatcherWithWatchListSemantics(&cache.ListWatch{
 			ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
 				if tweakListOptions != nil {
 					tweakListOptions(&options)
@@ -81,7 +81,7 @@ func NewFilteredDatasetInformer(client client.Interface, namespace string, resyn
 				}
 				return client.DatasetV1alpha1().Datasets(namespace).Watch(ctx, options)
 			},
-		},
+		}, client),
 		&apidatasetv1alpha1.Dataset{},
 		resyncPeriod,
 		indexers,
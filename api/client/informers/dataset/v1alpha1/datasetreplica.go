@@ -0,0 +1,101 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by informer-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	context "context"
+	time "time"
+
+	client "github.com/BaizeAI/dataset/api/client"
+	internalinterfaces "github.com/BaizeAI/dataset/api/client/informers/internalinterfaces"
+	datasetv1alpha1 "github.com/BaizeAI/dataset/api/client/listers/dataset/v1alpha1"
+	apidatasetv1alpha1 "github.com/BaizeAI/dataset/api/dataset/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	watch "k8s.io/apimachinery/pkg/watch"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// DatasetReplicaInformer provides access to a shared informer and lister for
+// DatasetReplicas.
+type DatasetReplicaInformer interface {
+	Informer() cache.SharedIndexInformer
+	Lister() datasetv1alpha1.DatasetReplicaLister
+}
+
+type datasetReplicaInformer struct {
+	factory          internalinterfaces.SharedInformerFactory
+	tweakListOptions internalinterfaces.TweakListOptionsFunc
+	namespace        string
+}
+
+// NewDatasetReplicaInformer constructs a new informer for DatasetReplica type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewDatasetReplicaInformer(client client.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers) cache.SharedIndexInformer {
+	return NewFilteredDatasetReplicaInformer(client, namespace, resyncPeriod, indexers, nil)
+}
+
+// NewFilteredDatasetReplicaInformer constructs a new informer for DatasetReplica type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewFilteredDatasetReplicaInformer(client client.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers, tweakListOptions internalinterfaces.TweakListOptionsFunc) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		cache.ToListWatcherWithWatchListSemantics(&cache.ListWatch{
+			ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.DatasetV1alpha1().DatasetReplicas(namespace).List(context.Background(), options)
+			},
+			WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.DatasetV1alpha1().DatasetReplicas(namespace).Watch(context.Background(), options)
+			},
+			ListWithContextFunc: func(ctx context.Context, options v1.ListOptions) (runtime.Object, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.DatasetV1alpha1().DatasetReplicas(namespace).List(ctx, options)
+			},
+			WatchFuncWithContext: func(ctx context.Context, options v1.ListOptions) (watch.Interface, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.DatasetV1alpha1().DatasetReplicas(namespace).Watch(ctx, options)
+			},
+		}, client),
+		&apidatasetv1alpha1.DatasetReplica{},
+		resyncPeriod,
+		indexers,
+	)
+}
+
+func (f *datasetReplicaInformer) defaultInformer(client client.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	return NewFilteredDatasetReplicaInformer(client, f.namespace, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, f.tweakListOptions)
+}
+
+func (f *datasetReplicaInformer) Informer() cache.SharedIndexInformer {
+	return f.factory.InformerFor(&apidatasetv1alpha1.DatasetReplica{}, f.defaultInformer)
+}
+
+func (f *datasetReplicaInformer) Lister() datasetv1alpha1.DatasetReplicaLister {
+	return datasetv1alpha1.NewDatasetReplicaLister(f.Informer().GetIndexer())
+}
@@ -25,6 +25,14 @@ import (
 type Interface interface {
 	// Datasets returns a DatasetInformer.
 	Datasets() DatasetInformer
+	// DatasetExports returns a DatasetExportInformer.
+	DatasetExports() DatasetExportInformer
+	// DatasetPolicies returns a DatasetPolicyInformer.
+	DatasetPolicies() DatasetPolicyInformer
+	// DatasetReplicas returns a DatasetReplicaInformer.
+	DatasetReplicas() DatasetReplicaInformer
+	// DatasetShareGrants returns a DatasetShareGrantInformer.
+	DatasetShareGrants() DatasetShareGrantInformer
 }
 
 type version struct {
@@ -42,3 +50,23 @@ func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakList
 func (v *version) Datasets() DatasetInformer {
 	return &datasetInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
+
+// DatasetExports returns a DatasetExportInformer.
+func (v *version) DatasetExports() DatasetExportInformer {
+	return &datasetExportInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
+// DatasetPolicies returns a DatasetPolicyInformer.
+func (v *version) DatasetPolicies() DatasetPolicyInformer {
+	return &datasetPolicyInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
+// DatasetReplicas returns a DatasetReplicaInformer.
+func (v *version) DatasetReplicas() DatasetReplicaInformer {
+	return &datasetReplicaInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
+// DatasetShareGrants returns a DatasetShareGrantInformer.
+func (v *version) DatasetShareGrants() DatasetShareGrantInformer {
+	return &datasetShareGrantInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
@@ -54,6 +54,14 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 	// Group=dataset, Version=v1alpha1
 	case v1alpha1.SchemeGroupVersion.WithResource("datasets"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Dataset().V1alpha1().Datasets().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("datasetexports"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Dataset().V1alpha1().DatasetExports().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("datasetpolicies"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Dataset().V1alpha1().DatasetPolicies().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("datasetreplicas"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Dataset().V1alpha1().DatasetReplicas().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("datasetsharegrants"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Dataset().V1alpha1().DatasetShareGrants().Informer()}, nil
 
 	}
 
@@ -0,0 +1,69 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	datasetv1alpha1 "github.com/BaizeAI/dataset/api/dataset/v1alpha1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	listers "k8s.io/client-go/listers"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// DatasetExportLister helps list DatasetExports.
+// All objects returned here must be treated as read-only.
+type DatasetExportLister interface {
+	// List lists all DatasetExports in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*datasetv1alpha1.DatasetExport, err error)
+	// DatasetExports returns an object that can list and get DatasetExports.
+	DatasetExports(namespace string) DatasetExportNamespaceLister
+	DatasetExportListerExpansion
+}
+
+// datasetExportLister implements the DatasetExportLister interface.
+type datasetExportLister struct {
+	listers.ResourceIndexer[*datasetv1alpha1.DatasetExport]
+}
+
+// NewDatasetExportLister returns a new DatasetExportLister.
+func NewDatasetExportLister(indexer cache.Indexer) DatasetExportLister {
+	return &datasetExportLister{listers.New[*datasetv1alpha1.DatasetExport](indexer, datasetv1alpha1.Resource("datasetexport"))}
+}
+
+// DatasetExports returns an object that can list and get DatasetExports.
+func (s *datasetExportLister) DatasetExports(namespace string) DatasetExportNamespaceLister {
+	return datasetExportNamespaceLister{listers.NewNamespaced[*datasetv1alpha1.DatasetExport](s.ResourceIndexer, namespace)}
+}
+
+// DatasetExportNamespaceLister helps list and get DatasetExports.
+// All objects returned here must be treated as read-only.
+type DatasetExportNamespaceLister interface {
+	// List lists all DatasetExports in the indexer for a given namespace.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*datasetv1alpha1.DatasetExport, err error)
+	// Get retrieves the DatasetExport from the indexer for a given namespace and name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*datasetv1alpha1.DatasetExport, error)
+	DatasetExportNamespaceListerExpansion
+}
+
+// datasetExportNamespaceLister implements the DatasetExportNamespaceLister
+// interface.
+type datasetExportNamespaceLister struct {
+	listers.ResourceIndexer[*datasetv1alpha1.DatasetExport]
+}
@@ -0,0 +1,47 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	datasetv1alpha1 "github.com/BaizeAI/dataset/api/dataset/v1alpha1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	listers "k8s.io/client-go/listers"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// DatasetPolicyLister helps list DatasetPolicies.
+// All objects returned here must be treated as read-only.
+type DatasetPolicyLister interface {
+	// List lists all DatasetPolicies in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*datasetv1alpha1.DatasetPolicy, err error)
+	// Get retrieves the DatasetPolicy from the index for a given name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*datasetv1alpha1.DatasetPolicy, error)
+	DatasetPolicyListerExpansion
+}
+
+// datasetPolicyLister implements the DatasetPolicyLister interface.
+type datasetPolicyLister struct {
+	listers.ResourceIndexer[*datasetv1alpha1.DatasetPolicy]
+}
+
+// NewDatasetPolicyLister returns a new DatasetPolicyLister.
+func NewDatasetPolicyLister(indexer cache.Indexer) DatasetPolicyLister {
+	return &datasetPolicyLister{listers.New[*datasetv1alpha1.DatasetPolicy](indexer, datasetv1alpha1.Resource("datasetpolicy"))}
+}
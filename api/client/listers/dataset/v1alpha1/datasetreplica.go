@@ -0,0 +1,69 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	datasetv1alpha1 "github.com/BaizeAI/dataset/api/dataset/v1alpha1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	listers "k8s.io/client-go/listers"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// DatasetReplicaLister helps list DatasetReplicas.
+// All objects returned here must be treated as read-only.
+type DatasetReplicaLister interface {
+	// List lists all DatasetReplicas in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*datasetv1alpha1.DatasetReplica, err error)
+	// DatasetReplicas returns an object that can list and get DatasetReplicas.
+	DatasetReplicas(namespace string) DatasetReplicaNamespaceLister
+	DatasetReplicaListerExpansion
+}
+
+// datasetReplicaLister implements the DatasetReplicaLister interface.
+type datasetReplicaLister struct {
+	listers.ResourceIndexer[*datasetv1alpha1.DatasetReplica]
+}
+
+// NewDatasetReplicaLister returns a new DatasetReplicaLister.
+func NewDatasetReplicaLister(indexer cache.Indexer) DatasetReplicaLister {
+	return &datasetReplicaLister{listers.New[*datasetv1alpha1.DatasetReplica](indexer, datasetv1alpha1.Resource("datasetreplica"))}
+}
+
+// DatasetReplicas returns an object that can list and get DatasetReplicas.
+func (s *datasetReplicaLister) DatasetReplicas(namespace string) DatasetReplicaNamespaceLister {
+	return datasetReplicaNamespaceLister{listers.NewNamespaced[*datasetv1alpha1.DatasetReplica](s.ResourceIndexer, namespace)}
+}
+
+// DatasetReplicaNamespaceLister helps list and get DatasetReplicas.
+// All objects returned here must be treated as read-only.
+type DatasetReplicaNamespaceLister interface {
+	// List lists all DatasetReplicas in the indexer for a given namespace.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*datasetv1alpha1.DatasetReplica, err error)
+	// Get retrieves the DatasetReplica from the indexer for a given namespace and name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*datasetv1alpha1.DatasetReplica, error)
+	DatasetReplicaNamespaceListerExpansion
+}
+
+// datasetReplicaNamespaceLister implements the DatasetReplicaNamespaceLister
+// interface.
+type datasetReplicaNamespaceLister struct {
+	listers.ResourceIndexer[*datasetv1alpha1.DatasetReplica]
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	datasetv1alpha1 "github.com/BaizeAI/dataset/api/dataset/v1alpha1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	listers "k8s.io/client-go/listers"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// DatasetShareGrantLister helps list DatasetShareGrants.
+// All objects returned here must be treated as read-only.
+type DatasetShareGrantLister interface {
+	// List lists all DatasetShareGrants in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*datasetv1alpha1.DatasetShareGrant, err error)
+	// DatasetShareGrants returns an object that can list and get DatasetShareGrants.
+	DatasetShareGrants(namespace string) DatasetShareGrantNamespaceLister
+	DatasetShareGrantListerExpansion
+}
+
+// datasetShareGrantLister implements the DatasetShareGrantLister interface.
+type datasetShareGrantLister struct {
+	listers.ResourceIndexer[*datasetv1alpha1.DatasetShareGrant]
+}
+
+// NewDatasetShareGrantLister returns a new DatasetShareGrantLister.
+func NewDatasetShareGrantLister(indexer cache.Indexer) DatasetShareGrantLister {
+	return &datasetShareGrantLister{listers.New[*datasetv1alpha1.DatasetShareGrant](indexer, datasetv1alpha1.Resource("datasetsharegrant"))}
+}
+
+// DatasetShareGrants returns an object that can list and get DatasetShareGrants.
+func (s *datasetShareGrantLister) DatasetShareGrants(namespace string) DatasetShareGrantNamespaceLister {
+	return datasetShareGrantNamespaceLister{listers.NewNamespaced[*datasetv1alpha1.DatasetShareGrant](s.ResourceIndexer, namespace)}
+}
+
+// DatasetShareGrantNamespaceLister helps list and get DatasetShareGrants.
+// All objects returned here must be treated as read-only.
+type DatasetShareGrantNamespaceLister interface {
+	// List lists all DatasetShareGrants in the indexer for a given namespace.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*datasetv1alpha1.DatasetShareGrant, err error)
+	// Get retrieves the DatasetShareGrant from the indexer for a given namespace and name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*datasetv1alpha1.DatasetShareGrant, error)
+	DatasetShareGrantNamespaceListerExpansion
+}
+
+// datasetShareGrantNamespaceLister implements the DatasetShareGrantNamespaceLister
+// interface.
+type datasetShareGrantNamespaceLister struct {
+	listers.ResourceIndexer[*datasetv1alpha1.DatasetShareGrant]
+}
@@ -24,3 +24,31 @@ type DatasetListerExpansion interface{}
 // DatasetNamespaceListerExpansion allows custom methods to be added to
 // DatasetNamespaceLister.
 type DatasetNamespaceListerExpansion interface{}
+
+// DatasetExportListerExpansion allows custom methods to be added to
+// DatasetExportLister.
+type DatasetExportListerExpansion interface{}
+
+// DatasetExportNamespaceListerExpansion allows custom methods to be added to
+// DatasetExportNamespaceLister.
+type DatasetExportNamespaceListerExpansion interface{}
+
+// DatasetPolicyListerExpansion allows custom methods to be added to
+// DatasetPolicyLister.
+type DatasetPolicyListerExpansion interface{}
+
+// DatasetReplicaListerExpansion allows custom methods to be added to
+// DatasetReplicaLister.
+type DatasetReplicaListerExpansion interface{}
+
+// DatasetReplicaNamespaceListerExpansion allows custom methods to be added to
+// DatasetReplicaNamespaceLister.
+type DatasetReplicaNamespaceListerExpansion interface{}
+
+// DatasetShareGrantListerExpansion allows custom methods to be added to
+// DatasetShareGrantLister.
+type DatasetShareGrantListerExpansion interface{}
+
+// DatasetShareGrantNamespaceListerExpansion allows custom methods to be added to
+// DatasetShareGrantNamespaceLister.
+type DatasetShareGrantNamespaceListerExpansion interface{}
@@ -28,6 +28,10 @@ import (
 type DatasetV1alpha1Interface interface {
 	RESTClient() rest.Interface
 	DatasetsGetter
+	DatasetExportsGetter
+	DatasetPoliciesGetter
+	DatasetReplicasGetter
+	DatasetShareGrantsGetter
 }
 
 // DatasetV1alpha1Client is used to interact with features provided by the dataset group.
@@ -39,6 +43,22 @@ func (c *DatasetV1alpha1Client) Datasets(namespace string) DatasetInterface {
 	return newDatasets(c, namespace)
 }
 
+func (c *DatasetV1alpha1Client) DatasetExports(namespace string) DatasetExportInterface {
+	return newDatasetExports(c, namespace)
+}
+
+func (c *DatasetV1alpha1Client) DatasetPolicies() DatasetPolicyInterface {
+	return newDatasetPolicies(c)
+}
+
+func (c *DatasetV1alpha1Client) DatasetReplicas(namespace string) DatasetReplicaInterface {
+	return newDatasetReplicas(c, namespace)
+}
+
+func (c *DatasetV1alpha1Client) DatasetShareGrants(namespace string) DatasetShareGrantInterface {
+	return newDatasetShareGrants(c, namespace)
+}
+
 // NewForConfig creates a new DatasetV1alpha1Client for the given config.
 // NewForConfig is equivalent to NewForConfigAndClient(c, httpClient),
 // where httpClient was generated with rest.HTTPClientFor(c).
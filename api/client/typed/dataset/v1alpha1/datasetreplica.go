@@ -0,0 +1,69 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	context "context"
+
+	scheme "github.com/BaizeAI/dataset/api/client/scheme"
+	datasetv1alpha1 "github.com/BaizeAI/dataset/api/dataset/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	gentype "k8s.io/client-go/gentype"
+)
+
+// DatasetReplicasGetter has a method to return a DatasetReplicaInterface.
+// A group's client should implement this interface.
+type DatasetReplicasGetter interface {
+	DatasetReplicas(namespace string) DatasetReplicaInterface
+}
+
+// DatasetReplicaInterface has methods to work with DatasetReplica resources.
+type DatasetReplicaInterface interface {
+	Create(ctx context.Context, datasetReplica *datasetv1alpha1.DatasetReplica, opts v1.CreateOptions) (*datasetv1alpha1.DatasetReplica, error)
+	Update(ctx context.Context, datasetReplica *datasetv1alpha1.DatasetReplica, opts v1.UpdateOptions) (*datasetv1alpha1.DatasetReplica, error)
+	// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+	UpdateStatus(ctx context.Context, datasetReplica *datasetv1alpha1.DatasetReplica, opts v1.UpdateOptions) (*datasetv1alpha1.DatasetReplica, error)
+	Delete(ctx context.Context, name string, opts v1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error
+	Get(ctx context.Context, name string, opts v1.GetOptions) (*datasetv1alpha1.DatasetReplica, error)
+	List(ctx context.Context, opts v1.ListOptions) (*datasetv1alpha1.DatasetReplicaList, error)
+	Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *datasetv1alpha1.DatasetReplica, err error)
+	DatasetReplicaExpansion
+}
+
+// datasetReplicas implements DatasetReplicaInterface
+type datasetReplicas struct {
+	*gentype.ClientWithList[*datasetv1alpha1.DatasetReplica, *datasetv1alpha1.DatasetReplicaList]
+}
+
+// newDatasetReplicas returns a DatasetReplicas
+func newDatasetReplicas(c *DatasetV1alpha1Client, namespace string) *datasetReplicas {
+	return &datasetReplicas{
+		gentype.NewClientWithList[*datasetv1alpha1.DatasetReplica, *datasetv1alpha1.DatasetReplicaList](
+			"datasetreplicas",
+			c.RESTClient(),
+			scheme.ParameterCodec,
+			namespace,
+			func() *datasetv1alpha1.DatasetReplica { return &datasetv1alpha1.DatasetReplica{} },
+			func() *datasetv1alpha1.DatasetReplicaList { return &datasetv1alpha1.DatasetReplicaList{} },
+		),
+	}
+}
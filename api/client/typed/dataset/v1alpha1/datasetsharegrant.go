@@ -0,0 +1,69 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	context "context"
+
+	scheme "github.com/BaizeAI/dataset/api/client/scheme"
+	datasetv1alpha1 "github.com/BaizeAI/dataset/api/dataset/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	gentype "k8s.io/client-go/gentype"
+)
+
+// DatasetShareGrantsGetter has a method to return a DatasetShareGrantInterface.
+// A group's client should implement this interface.
+type DatasetShareGrantsGetter interface {
+	DatasetShareGrants(namespace string) DatasetShareGrantInterface
+}
+
+// DatasetShareGrantInterface has methods to work with DatasetShareGrant resources.
+type DatasetShareGrantInterface interface {
+	Create(ctx context.Context, datasetShareGrant *datasetv1alpha1.DatasetShareGrant, opts v1.CreateOptions) (*datasetv1alpha1.DatasetShareGrant, error)
+	Update(ctx context.Context, datasetShareGrant *datasetv1alpha1.DatasetShareGrant, opts v1.UpdateOptions) (*datasetv1alpha1.DatasetShareGrant, error)
+	// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+	UpdateStatus(ctx context.Context, datasetShareGrant *datasetv1alpha1.DatasetShareGrant, opts v1.UpdateOptions) (*datasetv1alpha1.DatasetShareGrant, error)
+	Delete(ctx context.Context, name string, opts v1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error
+	Get(ctx context.Context, name string, opts v1.GetOptions) (*datasetv1alpha1.DatasetShareGrant, error)
+	List(ctx context.Context, opts v1.ListOptions) (*datasetv1alpha1.DatasetShareGrantList, error)
+	Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *datasetv1alpha1.DatasetShareGrant, err error)
+	DatasetShareGrantExpansion
+}
+
+// datasetShareGrants implements DatasetShareGrantInterface
+type datasetShareGrants struct {
+	*gentype.ClientWithList[*datasetv1alpha1.DatasetShareGrant, *datasetv1alpha1.DatasetShareGrantList]
+}
+
+// newDatasetShareGrants returns a DatasetShareGrants
+func newDatasetShareGrants(c *DatasetV1alpha1Client, namespace string) *datasetShareGrants {
+	return &datasetShareGrants{
+		gentype.NewClientWithList[*datasetv1alpha1.DatasetShareGrant, *datasetv1alpha1.DatasetShareGrantList](
+			"datasetsharegrants",
+			c.RESTClient(),
+			scheme.ParameterCodec,
+			namespace,
+			func() *datasetv1alpha1.DatasetShareGrant { return &datasetv1alpha1.DatasetShareGrant{} },
+			func() *datasetv1alpha1.DatasetShareGrantList { return &datasetv1alpha1.DatasetShareGrantList{} },
+		),
+	}
+}
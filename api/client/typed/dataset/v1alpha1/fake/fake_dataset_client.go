@@ -31,6 +31,22 @@ func (c *FakeDatasetV1alpha1) Datasets(namespace string) v1alpha1.DatasetInterfa
 	return newFakeDatasets(c, namespace)
 }
 
+func (c *FakeDatasetV1alpha1) DatasetExports(namespace string) v1alpha1.DatasetExportInterface {
+	return newFakeDatasetExports(c, namespace)
+}
+
+func (c *FakeDatasetV1alpha1) DatasetPolicies() v1alpha1.DatasetPolicyInterface {
+	return newFakeDatasetPolicies(c)
+}
+
+func (c *FakeDatasetV1alpha1) DatasetReplicas(namespace string) v1alpha1.DatasetReplicaInterface {
+	return newFakeDatasetReplicas(c, namespace)
+}
+
+func (c *FakeDatasetV1alpha1) DatasetShareGrants(namespace string) v1alpha1.DatasetShareGrantInterface {
+	return newFakeDatasetShareGrants(c, namespace)
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeDatasetV1alpha1) RESTClient() rest.Interface {
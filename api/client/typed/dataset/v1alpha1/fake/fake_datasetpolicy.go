@@ -0,0 +1,51 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	datasetv1alpha1 "github.com/BaizeAI/dataset/api/client/typed/dataset/v1alpha1"
+	v1alpha1 "github.com/BaizeAI/dataset/api/dataset/v1alpha1"
+	gentype "k8s.io/client-go/gentype"
+)
+
+// fakeDatasetPolicies implements DatasetPolicyInterface
+type fakeDatasetPolicies struct {
+	*gentype.FakeClientWithList[*v1alpha1.DatasetPolicy, *v1alpha1.DatasetPolicyList]
+	Fake *FakeDatasetV1alpha1
+}
+
+func newFakeDatasetPolicies(fake *FakeDatasetV1alpha1) datasetv1alpha1.DatasetPolicyInterface {
+	return &fakeDatasetPolicies{
+		gentype.NewFakeClientWithList[*v1alpha1.DatasetPolicy, *v1alpha1.DatasetPolicyList](
+			fake.Fake,
+			"",
+			v1alpha1.SchemeGroupVersion.WithResource("datasetpolicies"),
+			v1alpha1.SchemeGroupVersion.WithKind("DatasetPolicy"),
+			func() *v1alpha1.DatasetPolicy { return &v1alpha1.DatasetPolicy{} },
+			func() *v1alpha1.DatasetPolicyList { return &v1alpha1.DatasetPolicyList{} },
+			func(dst, src *v1alpha1.DatasetPolicyList) { dst.ListMeta = src.ListMeta },
+			func(list *v1alpha1.DatasetPolicyList) []*v1alpha1.DatasetPolicy {
+				return gentype.ToPointerSlice(list.Items)
+			},
+			func(list *v1alpha1.DatasetPolicyList, items []*v1alpha1.DatasetPolicy) {
+				list.Items = gentype.FromPointerSlice(items)
+			},
+		),
+		fake,
+	}
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	datasetv1alpha1 "github.com/BaizeAI/dataset/api/client/typed/dataset/v1alpha1"
+	v1alpha1 "github.com/BaizeAI/dataset/api/dataset/v1alpha1"
+	gentype "k8s.io/client-go/gentype"
+)
+
+// fakeDatasetReplicas implements DatasetReplicaInterface
+type fakeDatasetReplicas struct {
+	*gentype.FakeClientWithList[*v1alpha1.DatasetReplica, *v1alpha1.DatasetReplicaList]
+	Fake *FakeDatasetV1alpha1
+}
+
+func newFakeDatasetReplicas(fake *FakeDatasetV1alpha1, namespace string) datasetv1alpha1.DatasetReplicaInterface {
+	return &fakeDatasetReplicas{
+		gentype.NewFakeClientWithList[*v1alpha1.DatasetReplica, *v1alpha1.DatasetReplicaList](
+			fake.Fake,
+			namespace,
+			v1alpha1.SchemeGroupVersion.WithResource("datasetreplicas"),
+			v1alpha1.SchemeGroupVersion.WithKind("DatasetReplica"),
+			func() *v1alpha1.DatasetReplica { return &v1alpha1.DatasetReplica{} },
+			func() *v1alpha1.DatasetReplicaList { return &v1alpha1.DatasetReplicaList{} },
+			func(dst, src *v1alpha1.DatasetReplicaList) { dst.ListMeta = src.ListMeta },
+			func(list *v1alpha1.DatasetReplicaList) []*v1alpha1.DatasetReplica {
+				return gentype.ToPointerSlice(list.Items)
+			},
+			func(list *v1alpha1.DatasetReplicaList, items []*v1alpha1.DatasetReplica) {
+				list.Items = gentype.FromPointerSlice(items)
+			},
+		),
+		fake,
+	}
+}
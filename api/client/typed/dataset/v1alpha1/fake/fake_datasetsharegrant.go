@@ -0,0 +1,51 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	datasetv1alpha1 "github.com/BaizeAI/dataset/api/client/typed/dataset/v1alpha1"
+	v1alpha1 "github.com/BaizeAI/dataset/api/dataset/v1alpha1"
+	gentype "k8s.io/client-go/gentype"
+)
+
+// fakeDatasetShareGrants implements DatasetShareGrantInterface
+type fakeDatasetShareGrants struct {
+	*gentype.FakeClientWithList[*v1alpha1.DatasetShareGrant, *v1alpha1.DatasetShareGrantList]
+	Fake *FakeDatasetV1alpha1
+}
+
+func newFakeDatasetShareGrants(fake *FakeDatasetV1alpha1, namespace string) datasetv1alpha1.DatasetShareGrantInterface {
+	return &fakeDatasetShareGrants{
+		gentype.NewFakeClientWithList[*v1alpha1.DatasetShareGrant, *v1alpha1.DatasetShareGrantList](
+			fake.Fake,
+			namespace,
+			v1alpha1.SchemeGroupVersion.WithResource("datasetsharegrants"),
+			v1alpha1.SchemeGroupVersion.WithKind("DatasetShareGrant"),
+			func() *v1alpha1.DatasetShareGrant { return &v1alpha1.DatasetShareGrant{} },
+			func() *v1alpha1.DatasetShareGrantList { return &v1alpha1.DatasetShareGrantList{} },
+			func(dst, src *v1alpha1.DatasetShareGrantList) { dst.ListMeta = src.ListMeta },
+			func(list *v1alpha1.DatasetShareGrantList) []*v1alpha1.DatasetShareGrant {
+				return gentype.ToPointerSlice(list.Items)
+			},
+			func(list *v1alpha1.DatasetShareGrantList, items []*v1alpha1.DatasetShareGrant) {
+				list.Items = gentype.FromPointerSlice(items)
+			},
+		),
+		fake,
+	}
+}
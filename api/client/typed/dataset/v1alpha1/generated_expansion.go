@@ -18,3 +18,11 @@ limitations under the License.
 package v1alpha1
 
 type DatasetExpansion interface{}
+
+type DatasetExportExpansion interface{}
+
+type DatasetPolicyExpansion interface{}
+
+type DatasetReplicaExpansion interface{}
+
+type DatasetShareGrantExpansion interface{}
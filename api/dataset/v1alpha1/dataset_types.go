@@ -18,6 +18,7 @@ package v1alpha1
 
 import (
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -36,6 +37,9 @@ const (
 	DatasetTypeModelScope  DatasetType = "MODEL_SCOPE"
 	DatasetTypeDatabase    DatasetType = "DATABASE"
 	DatasetTypeHadoop      DatasetType = "HADOOP"
+	DatasetTypePixi        DatasetType = "PIXI"
+	DatasetTypePythonVenv  DatasetType = "PYTHON_VENV"
+	DatasetTypeOCI         DatasetType = "OCI"
 	DatasetTypeManual      DatasetType = "MANUAL"
 
 	// must be same as apis/management-api/dataset/v1alpha1/dataset.proto
@@ -43,16 +47,20 @@ const (
 	DatasetStatusPhaseReady      DatasetStatusPhase = "READY"
 	DatasetStatusPhaseProcessing DatasetStatusPhase = "PROCESSING"
 	DatasetStatusPhaseFailed     DatasetStatusPhase = "FAILED"
+	// DatasetStatusPhaseQueued is the phase of the datasets waiting for the
+	// limits of the concurrent data loader jobs.
+	DatasetStatusPhaseQueued DatasetStatusPhase = "QUEUED"
 
 	// avoid unused error
 	_ = DatasetStatusPhasePending
 	_ = DatasetStatusPhaseReady
 	_ = DatasetStatusPhaseProcessing
 	_ = DatasetStatusPhaseFailed
+	_ = DatasetStatusPhaseQueued
 )
 
 type DatasetSource struct {
-	// +kubebuilder:validation:Enum=GIT;S3;HTTP;PVC;NFS;CONDA;REFERENCE;HUGGING_FACE;MODEL_SCOPE;DATABASE;HADOOP;MANUAL
+	// +kubebuilder:validation:Enum=GIT;S3;HTTP;PVC;NFS;CONDA;REFERENCE;HUGGING_FACE;MODEL_SCOPE;DATABASE;HADOOP;PIXI;PYTHON_VENV;OCI;MANUAL
 	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="Value is immutable"
 	Type DatasetType `json:"type"`
 	// +kubebuilder:validation:Required
@@ -64,12 +72,15 @@ type DatasetSource struct {
 	// - PVC: pvc://<name>/<path/to/directory>
 	// - NFS: nfs://<host>/<path/to/directory>
 	// - CONDA: conda://<name>?[python=<python_version>]
-	// - REFERENCE: dataset://<namespace>/<dataset>
+	// - REFERENCE: dataset://<namespace>/<dataset>[/<subpath>], only the subpath of the dataset is referenced when it is set
 	// - HUGGING_FACE: huggingface://<repoName>?[repoType=<repoType>]
 	// - MODEL_SCOPE: modelscope://<namespace>/<model>
 	// - DATABASE: database://<ip>:<port>
-	// - HADOOP: hdfs://<ip>:<port>
-	// - MANUAL: manual://
+	// - HADOOP: hdfs://<ip>:<port>, or webhdfs://<host>[:<port>] and swebhdfs://<host>[:<port>] to download through the WebHDFS REST API of the NameNode or an HttpFS gateway without the Hadoop client
+	// - PIXI: pixi://<name>
+	// - PYTHON_VENV: venv://<name>
+	// - OCI: oci://<registry>/<repository>[:<tag>][@<digest>], the digest is verified when it is set
+	// - MANUAL: manual://, files are uploaded into the pvc through the upload API of the controller
 	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="Value is immutable"
 	URI string `json:"uri"`
 	// +kubebuilder:validation:Optional
@@ -77,21 +88,119 @@ type DatasetSource struct {
 	// options is a map of key-value pairs that can be used to specify additional options for the dataset source, e.g. {"branch": "master"}
 	// supported keys for each type of dataset source are:
 	// - GIT: branch, commit, depth, submodules
-	// - S3: region, endpoint, provider, syncMode
+	// - S3: region, endpoint, provider, syncMode, replicaRound(the round of a replica store at the uri to load, set by the Secondary DatasetReplica of the dataset)
 	// - HTTP: any key-value pair will be passed to the underlying http client as http headers
 	// - PVC:
 	// - NFS:
-	// - CONDA: requirements.txt, environment.yaml
+	// - CONDA: requirements.txt, environment.yaml, or condaLockYml(content of conda-lock.yml) and condaExplicitTxt(content of an @EXPLICIT spec file)
+	//   to install the locked packages without solving, platform(the platform rendered from conda-lock.yml, defaults to the one of the data loader).
+	//   the manifest of the resolved packages is written to <mount root>/conda/envs/<name>.manifest.json, and its digest is reported in syncRoundStatuses
+	//   channels(comma separated, replaces the channels of environment.yaml), channelPriority(strict, flexible or disabled),
+	//   pipIndexUrl, pipExtraIndexUrl and pipExtraIndexUrls(comma separated), pipTrustedHosts(comma separated, the hosts of the indexes are always trusted),
+	//   channelAuth and pipIndexAuth(comma separated <url prefix>=token[:<secret key>] or <url prefix>=basic[:<username key>:<password key>], the keys default to token, username and password of secretRef).
+	//   tokens of channels are sent as /t/<token>, tokens of pip indexes as the password of __token__
 	// - REFERENCE:
 	// - HUGGING_FACE: repo, repoType, endpoint, include, exclude, revision
 	// - MODEL_SCOPE: repo, repoType, include, exclude, revision
 	// * Note: syncMode can be "sync" (default) or "copy". "sync" removes files in destination that don't exist in source, "copy" only adds/updates files without removing existing ones.
-	// - DATABASE: type(currently only support MySQL, other database types may be supported in the future.), host, port, dbName, tables(in the dbName), exportFormat(currently only support csv)
-	// - HADOOP: coreSiteXml and hdfsSiteXml, sourcePath, username
+	// - DATABASE: type(mysql, postgresql, clickhouse or sqlserver, defaults to mysql), host, port, dbName, tables(in the dbName, optionally qualified with the schema), exportFormat(csv, parquet or jsonl, defaults to csv),
+	//   batchSize(rows per query, defaults to 10000), syncMode("sync" removes the exports of tables and queries which are not listed anymore), tls(disable, require, verify-ca or verify-full, defaults to disable), tlsServerName. ca.crt, tls.crt and tls.key in secretRef are used as CA and client certificate,
+	//   query.<name>(a custom query exported like a table named <name>, query is the same as query.query),
+	//   columns, where(an SQL condition) and watermarkColumn, which can be suffixed with .<table or query name> to only apply to it.
+	//   every table is exported to <dbName>.<table>.<exportFormat> with its schema in <dbName>.<table>.schema.json, NULL is written as an empty field in csv.
+	//   with watermarkColumn, only the rows with a greater watermark than the last sync are exported to <dbName>.<table>.part-<n>.<exportFormat>,
+	//   and the last watermark is kept in <dbName>.<table>.watermark.json
+	// - HADOOP: hdfsConfigName(ConfigMap of the files below), coreSiteXml and hdfsSiteXml, krb5Conf(mounted as /etc/krb5.conf), sourcePath, username, syncMode,
+	//   include and exclude(comma separated globs matched against paths relative to sourcePath, webhdfs only), concurrency(parallel downloads, webhdfs only, defaults to 4).
+	//   keytab and principal in secretRef enable Kerberos authentication, ca.crt in secretRef is trusted by swebhdfs
+	// - PIXI: pixiToml and pixiLock(content of pixi.toml and pixi.lock, pixi.lock must satisfy pixi.toml unless frozen is true), environment(defaults to default),
+	//   channels(comma separated, replaces the channels of pixi.toml), channelMirrors(comma separated <channel>=<mirror>), pypiIndexUrl, pypiExtraIndexUrls(comma separated).
+	//   the workspace is installed into <mount root>/pixi, and the environment into <mount root>/pixi/.pixi/envs/<environment>,
+	//   it must be used with the dataset mounted at /opt/baize-runtime-env/<name>
+	// - PYTHON_VENV: pipRequirementsTxt, or pyprojectToml and uvLock(content of requirements.txt, pyproject.toml and uv.lock, without uv.lock pyproject.toml is resolved when syncing),
+	//   python(a version installed by uv, or the absolute path of an interpreter of the data loader image), pipIndexUrl, pipExtraIndexUrls and pipTrustedHosts(comma separated), pipIndexAuth(the same as CONDA).
+	//   the virtualenv is installed into <mount root>/venv and the interpreter into <mount root>/python,
+	//   it must be used with the dataset mounted at /opt/baize-runtime-env/<name>
+	// - OCI: platform(selected from image indexes, defaults to linux/<architecture of the data loader>), raw(true to place every layer as a file without extracting tar layers),
+	//   plainHttp(true for registries without HTTPS), syncMode. layers titled by ORAS are placed as files unless they are packed directories, other tar layers are extracted,
+	//   the others are placed by the filepath annotation of ModelPack, or as blobs/<algorithm>/<hex>. the digest of the pulled manifest is reported in syncRoundStatuses.
+	//   .dockerconfigjson, username and password, or token, in secretRef authenticate to the registry, and ca.crt in secretRef is trusted
 	// - MANUAL:
 	Options map[string]string `json:"options,omitempty"`
 }
 
+type DatasetSubSource struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MaxLength=40
+	// +kubebuilder:validation:Pattern="^[a-z0-9]([-a-z0-9]*[a-z0-9])?$"
+	// name identifies the source in the dataset and in its status.
+	Name string `json:"name"`
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=GIT;S3;HTTP;HUGGING_FACE;MODEL_SCOPE;DATABASE;OCI
+	// type is the type of the source, the types which need volumes or files
+	// of their own, e.g. CONDA and HADOOP, must be datasets of their own.
+	Type DatasetType `json:"type"`
+	// +kubebuilder:validation:Required
+	// uri is the location of the source, in the same format as the uri of
+	// the source of a dataset.
+	URI string `json:"uri"`
+	// +kubebuilder:validation:Optional
+	// options is the same as the options of the source of a dataset.
+	Options map[string]string `json:"options,omitempty"`
+	// +kubebuilder:validation:Optional
+	// secretRef is the name of the secret that contains credentials for
+	// accessing the source, defaults to the secretRef of the dataset.
+	SecretRef string `json:"secretRef,omitempty"`
+	// +kubebuilder:validation:Optional
+	// path is the subdirectory of the dataset the source is loaded into, the
+	// paths of the sources must not overlap.
+	Path string `json:"path,omitempty"`
+}
+
+type PostProcessStepType string
+
+const (
+	PostProcessStepTypeExtract   PostProcessStepType = "EXTRACT"
+	PostProcessStepTypeChecksum  PostProcessStepType = "CHECKSUM"
+	PostProcessStepTypeConvert   PostProcessStepType = "CONVERT"
+	PostProcessStepTypeValidate  PostProcessStepType = "VALIDATE"
+	PostProcessStepTypeDelete    PostProcessStepType = "DELETE"
+	PostProcessStepTypeContainer PostProcessStepType = "CONTAINER"
+)
+
+// +kubebuilder:validation:XValidation:rule="(self.type == 'CONTAINER') == has(self.container)",message="container must be set if and only if the type is CONTAINER"
+type PostProcessStep struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MaxLength=40
+	// +kubebuilder:validation:Pattern="^[a-z0-9]([-a-z0-9]*[a-z0-9])?$"
+	// name identifies the step in the status of the rounds.
+	Name string `json:"name"`
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=EXTRACT;CHECKSUM;CONVERT;VALIDATE;DELETE;CONTAINER
+	// type is the type of the step:
+	// - EXTRACT: extracts the tar, tar.gz and zip archives matching path
+	// - CHECKSUM: verifies the files listed in the manifest at path, in the format of sha256sum
+	// - CONVERT: converts the files matching path to another format, e.g. CSV to Parquet
+	// - VALIDATE: checks the files matching path are well-formed, e.g. the shards of safetensors models
+	// - DELETE: deletes the files and directories matching path
+	// - CONTAINER: runs container with the dataset mounted at $DATASET_PATH, it must be the last step
+	Type PostProcessStepType `json:"type"`
+	// +kubebuilder:validation:Optional
+	// path is relative to the dataset, it is a glob pattern for EXTRACT,
+	// CONVERT, VALIDATE and DELETE.
+	Path string `json:"path,omitempty"`
+	// +kubebuilder:validation:Optional
+	// options is a map of key-value pairs of the step:
+	// - EXTRACT: destination(defaults to the directory of each archive), remove
+	// - CHECKSUM: algorithm(sha256 by default, sha512, sha1 or md5)
+	// - CONVERT: from(defaults to csv), to(defaults to parquet), remove
+	// - VALIDATE: format(defaults to safetensors)
+	Options map[string]string `json:"options,omitempty"`
+	// +kubebuilder:validation:Optional
+	// container is the container run by a CONTAINER step.
+	Container *v1.Container `json:"container,omitempty"`
+}
+
 type MountOptions struct {
 	// +kubebuilder:validation:Optional
 	// +kubebuilder:default="/"
@@ -115,7 +224,17 @@ type MountOptions struct {
 	GID int64 `json:"gid,omitempty"`
 }
 
+type DatasetDeletionPolicy string
+
+const (
+	DatasetDeletionPolicyBlock   DatasetDeletionPolicy = "Block"
+	DatasetDeletionPolicyCascade DatasetDeletionPolicy = "Cascade"
+	DatasetDeletionPolicyOrphan  DatasetDeletionPolicy = "Orphan"
+)
+
 // DatasetSpec defines the desired state of Dataset
+// +kubebuilder:validation:XValidation:rule="has(self.source) != has(self.sources)",message="exactly one of source and sources must be set"
+// +kubebuilder:validation:XValidation:rule="has(self.source) == has(oldSelf.source)",message="source and sources cannot be switched"
 type DatasetSpec struct {
 	// Share indicates whether the model is shareable with others.
 	// When set to true, the model can be shared according to the specified selector.
@@ -126,9 +245,53 @@ type DatasetSpec struct {
 	// If Share is true and ShareToNamespaceSelector is empty, that means all namespaces can access this.
 	// +kubebuilder:validation:Optional
 	ShareToNamespaceSelector *metav1.LabelSelector `json:"shareToNamespaceSelector,omitempty"`
-	// +kubebuilder:validation:Required
-	// source is the source of the dataset.
-	Source DatasetSource `json:"source"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxItems=32
+	// shareSubPaths restricts the paths relative to the dataset which
+	// REFERENCE datasets can reference, a REFERENCE dataset must reference one
+	// of them or a path inside of one. the whole dataset is shareable when it
+	// is empty.
+	ShareSubPaths []string `json:"shareSubPaths,omitempty"`
+	// +kubebuilder:validation:Optional
+	// shareGrantRequired requires the namespaces of REFERENCE datasets to be
+	// approved by an unexpired DatasetShareGrant of the dataset, besides
+	// matching shareToNamespaceSelector. the REFERENCE datasets fail and
+	// their volumes are released once the approval is revoked.
+	ShareGrantRequired bool `json:"shareGrantRequired,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=Block;Cascade;Orphan
+	// deletionPolicy decides what happens to the REFERENCE datasets of the
+	// dataset when it is deleted:
+	// - Block: the deletion waits until there are no REFERENCE datasets
+	// - Cascade: the REFERENCE datasets are deleted along with it
+	// - Orphan: the REFERENCE datasets are left as they are
+	// it defaults to Cascade when enable_cascading_deletion is set in the
+	// controller config, otherwise Orphan.
+	DeletionPolicy DatasetDeletionPolicy `json:"deletionPolicy,omitempty"`
+	// +kubebuilder:validation:Optional
+	// source is the source of the dataset, it is required unless sources is set.
+	Source DatasetSource `json:"source,omitzero"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxItems=16
+	// +listType=map
+	// +listMapKey=name
+	// sources are loaded into the subdirectories of the dataset one after
+	// another in each data sync round, instead of source.
+	Sources []DatasetSubSource `json:"sources,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxItems=10
+	// +listType=map
+	// +listMapKey=name
+	// postProcess is run in order on the dataset once the data is loaded in
+	// each data sync round, a failed step fails the round.
+	PostProcess []PostProcessStep `json:"postProcess,omitempty"`
+	// +kubebuilder:validation:Optional
+	// verifySchedule is the interval to verify the data of the dataset
+	// against the manifest recorded in each data sync round, e.g. 24h. the
+	// Verified condition is false and the drifted files are listed in the
+	// status when the data changed. the dataset is not verified when it is
+	// not set.
+	VerifySchedule *metav1.Duration `json:"verifySchedule,omitempty"`
 	// +kubebuilder:validation:Optional
 	// secretRef is the name of the secret that contains credentials for accessing the dataset source.
 	SecretRef string `json:"secretRef,omitempty"`
@@ -145,11 +308,100 @@ type DatasetSpec struct {
 	// +kubebuilder:validation:Optional
 	// volumeClaimRef is the reference to an existing PVC.
 	VolumeClaimRef *VolumeClaimRef `json:"volumeClaimRef,omitempty"`
-	// DataWarmUpResources is the resources required for data warmUp.
+	// +kubebuilder:validation:Optional
+	// podOverrides are merged onto the pod template of the dataset_job_spec_yaml
+	// of the controller config with strategic merge semantics, for the pods of
+	// the data loader, the post process steps, the verifications and the
+	// warm-ups of the dataset.
+	PodOverrides *LoaderPodOverrides `json:"podOverrides,omitempty"`
+	// +kubebuilder:validation:Optional
+	// priority orders the datasets queued by the limits of the concurrent
+	// data loader jobs of the controller config and the DatasetPolicies, the
+	// ones with higher priority are loaded first, and the ones of the same
+	// priority in the order they are queued.
+	Priority int32 `json:"priority,omitempty"`
+	// +kubebuilder:validation:Optional
+	// warmup copies the dataset onto the local storage of the selected nodes
+	// after each data sync round.
+	Warmup *DatasetWarmup `json:"warmup,omitempty"`
+	// DataWarmUpResources is the resources required for data warmUp, i.e.
+	// the resources of the containers copying the dataset onto the nodes
+	// selected by warmup.
 	// +kubebuilder:validation:Optional
 	DataWarmUpResources v1.ResourceRequirements `json:"resources,omitempty"`
 }
 
+// LoaderPodOverrides are the fields of the pods of the data loader a dataset
+// can override.
+type LoaderPodOverrides struct {
+	// +kubebuilder:validation:Optional
+	Labels map[string]string `json:"labels,omitempty"`
+	// +kubebuilder:validation:Optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// +kubebuilder:validation:Optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// +kubebuilder:validation:Optional
+	// tolerations replace the tolerations of the template.
+	Tolerations []v1.Toleration `json:"tolerations,omitempty"`
+	// +kubebuilder:validation:Optional
+	Affinity *v1.Affinity `json:"affinity,omitempty"`
+	// +kubebuilder:validation:Optional
+	// serviceAccountName must be one of pod_overrides_service_accounts of
+	// the controller config.
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+	// +kubebuilder:validation:Optional
+	ImagePullSecrets []v1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+	// +kubebuilder:validation:Optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+	// +kubebuilder:validation:Optional
+	// env is merged by name into the env of the data loader containers.
+	Env []v1.EnvVar `json:"env,omitempty"`
+	// +kubebuilder:validation:Optional
+	// volumes are added to the pods, their names must not start with
+	// dataset-, which are the volumes of the controller. they are limited to
+	// configMap, secret, emptyDir, persistentVolumeClaim and projected
+	// volumes, and hostPath ones under pod_overrides_host_paths of the
+	// controller config.
+	Volumes []v1.Volume `json:"volumes,omitempty"`
+	// +kubebuilder:validation:Optional
+	// volumeMounts of the volumes in the data loader containers.
+	VolumeMounts []v1.VolumeMount `json:"volumeMounts,omitempty"`
+}
+
+// DatasetWarmup selects the nodes a dataset is copied onto and where it is
+// copied to on each node.
+// +kubebuilder:validation:XValidation:rule="(has(self.hostPath) && self.hostPath) != has(self.storageClassName)",message="exactly one of hostPath and storageClassName must be set"
+type DatasetWarmup struct {
+	// +kubebuilder:validation:Optional
+	// nodeSelector selects the nodes the dataset is copied onto, all the
+	// nodes when it is empty.
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// +kubebuilder:validation:Optional
+	// tolerations of the pods copying the dataset, for the selected nodes
+	// with taints.
+	Tolerations []v1.Toleration `json:"tolerations,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	// tolerationSeconds is how long the pods copying the dataset tolerate
+	// their node being not ready or unreachable.
+	TolerationSeconds *int64 `json:"tolerationSeconds,omitempty"`
+	// +kubebuilder:validation:Optional
+	// hostPath copies the dataset into the directory on the nodes set by the
+	// warmup_host_path of the controller config, under
+	// <warmup_host_path>/<namespace>/<name>. workloads mount it with a
+	// hostPath volume.
+	HostPath bool `json:"hostPath,omitempty"`
+	// +kubebuilder:validation:Optional
+	// storageClassName is the class of the local PVs, e.g. of a local-path
+	// provisioner with volumeBindingMode WaitForFirstConsumer, the dataset is
+	// copied into a pvc of its own on each node.
+	StorageClassName *string `json:"storageClassName,omitempty"`
+	// +kubebuilder:validation:Optional
+	// size of the pvc on each node, defaults to the storage requested by the
+	// volumeClaimTemplate of the dataset.
+	Size *resource.Quantity `json:"size,omitempty"`
+}
+
 type VolumeClaimRef struct {
 	// +kubebuilder:validation:Required
 	// name is the name of the pvc.
@@ -171,6 +423,63 @@ type DataLoadStatus struct {
 	EndTime metav1.Time `json:"endTime,omitempty"`
 	// +kubebuilder:validation:Optional
 	Succeed bool `json:"succeed,omitempty"`
+	// +kubebuilder:validation:Optional
+	// digest identifies the content loaded in the round when the data loader
+	// reports one, e.g. the sha256 of the manifest of the packages resolved in
+	// a CONDA environment. Equal digests mean identical environments.
+	Digest string `json:"digest,omitempty"`
+	// +kubebuilder:validation:Optional
+	// blobCacheRecorded is whether the controller counted what the blob cache
+	// did for the job of the round into its metrics, so that they are counted
+	// once however many times the job is handled.
+	BlobCacheRecorded bool `json:"blobCacheRecorded,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// sources is the status of each source of a dataset with sources.
+	Sources []SourceLoadStatus `json:"sources,omitempty"`
+	// +kubebuilder:validation:Optional
+	// postProcess is the status of each post process step of the round.
+	PostProcess []PostProcessStepStatus `json:"postProcess,omitempty"`
+}
+
+type PostProcessStepStatus struct {
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+	// +kubebuilder:validation:Optional
+	Succeed bool `json:"succeed,omitempty"`
+	// +kubebuilder:validation:Optional
+	// message is the error of a failed step or a summary of what the step
+	// did.
+	Message string `json:"message,omitempty"`
+}
+
+type SourceLoadStatus struct {
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+	// +kubebuilder:validation:Optional
+	Succeed bool `json:"succeed,omitempty"`
+	// +kubebuilder:validation:Optional
+	// digest identifies the content loaded from the source when the data
+	// loader reports one.
+	Digest string `json:"digest,omitempty"`
+}
+
+type UploadStatus struct {
+	// +kubebuilder:validation:Required
+	// path is the file, or the directory a tar stream is extracted into,
+	// relative to the dataset.
+	Path string `json:"path"`
+	// +kubebuilder:validation:Optional
+	// size is the number of bytes of the uploaded file or tar stream.
+	Size int64 `json:"size,omitempty"`
+	// +kubebuilder:validation:Optional
+	// digest is the sha256 of the uploaded file or tar stream.
+	Digest string `json:"digest,omitempty"`
+	// +kubebuilder:validation:Optional
+	// user is the name of the user who uploaded.
+	User string `json:"user,omitempty"`
+	// +kubebuilder:validation:Optional
+	CompletionTime metav1.Time `json:"completionTime,omitempty"`
 }
 
 // DatasetStatus defines the observed state of Dataset
@@ -199,6 +508,132 @@ type DatasetStatus struct {
 	// readOnly indicates whether the dataset is mounted as read-only.
 	ReadOnly     bool        `json:"readOnly,omitempty"`
 	LastSyncTime metav1.Time `json:"lastSyncTime,omitempty"`
+	// +kubebuilder:validation:Optional
+	// uploads is a list of the files and tar streams uploaded into a MANUAL
+	// dataset through the upload API, we only keep the last 10 uploads.
+	Uploads []UploadStatus `json:"uploads,omitempty"`
+	// +kubebuilder:validation:Optional
+	// verification is the status of the verification of the data of a
+	// dataset with verifySchedule.
+	Verification *VerificationStatus `json:"verification,omitempty"`
+	// +kubebuilder:validation:Optional
+	// consumers are the REFERENCE datasets with the access to a shared
+	// dataset.
+	Consumers []DatasetConsumer `json:"consumers,omitempty"`
+	// +kubebuilder:validation:Optional
+	// sourcePhase is the phase of the source dataset of a REFERENCE dataset,
+	// the data is stale while it is not READY.
+	SourcePhase DatasetStatusPhase `json:"sourcePhase,omitempty"`
+	// +kubebuilder:validation:Optional
+	// sourceRound is the lastSucceedRound of the source dataset of a
+	// REFERENCE dataset.
+	SourceRound int32 `json:"sourceRound,omitempty"`
+	// +kubebuilder:validation:Optional
+	// sourceDigest is the digest of the content loaded in the sourceRound of
+	// the source dataset of a REFERENCE dataset, when the data loader reports
+	// one.
+	SourceDigest string `json:"sourceDigest,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +listType=map
+	// +listMapKey=nodeName
+	// warmup is the status of the copy of the dataset on each node selected
+	// by warmup.
+	Warmup []WarmupNodeStatus `json:"warmup,omitempty"`
+	// +kubebuilder:validation:Optional
+	// policies are the names of the DatasetPolicies applied to the dataset,
+	// the Policy condition is false when it violates any of them.
+	Policies []string `json:"policies,omitempty"`
+	// +kubebuilder:validation:Optional
+	// queuePosition is the position of the dataset in the queue of the data
+	// sync rounds waiting for the limits of the concurrent data loader jobs,
+	// starting at 1. it is 0 when the dataset is not queued.
+	QueuePosition int32 `json:"queuePosition,omitempty"`
+	// +kubebuilder:validation:Optional
+	// queuedAt is when the pending data sync round is queued.
+	QueuedAt *metav1.Time `json:"queuedAt,omitempty"`
+}
+
+type WarmupPhase string
+
+const (
+	WarmupPhaseRunning WarmupPhase = "Running"
+	WarmupPhaseReady   WarmupPhase = "Ready"
+	WarmupPhaseFailed  WarmupPhase = "Failed"
+)
+
+type WarmupNodeStatus struct {
+	// +kubebuilder:validation:Required
+	NodeName string `json:"nodeName"`
+	// +kubebuilder:validation:Optional
+	// round is the data sync round copied, or being copied, onto the node.
+	Round int32 `json:"round,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=Running;Ready;Failed
+	Phase WarmupPhase `json:"phase,omitempty"`
+	// +kubebuilder:validation:Optional
+	// jobName is the name of the running job copying the dataset.
+	JobName string `json:"jobName,omitempty"`
+	// +kubebuilder:validation:Optional
+	// path is the directory on the node the dataset is copied into.
+	Path string `json:"path,omitempty"`
+	// +kubebuilder:validation:Optional
+	// claimName is the name of the local pvc the dataset is copied into.
+	ClaimName string `json:"claimName,omitempty"`
+	// +kubebuilder:validation:Optional
+	// copiedFiles is the number of files copied in the round, the others
+	// were already on the node.
+	CopiedFiles int64 `json:"copiedFiles,omitempty"`
+	// +kubebuilder:validation:Optional
+	// copiedBytes is the size of the files copied in the round.
+	CopiedBytes int64 `json:"copiedBytes,omitempty"`
+	// +kubebuilder:validation:Optional
+	// message is the error of a failed copy.
+	Message string `json:"message,omitempty"`
+	// +kubebuilder:validation:Optional
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+}
+
+type DatasetConsumer struct {
+	// +kubebuilder:validation:Required
+	Namespace string `json:"namespace"`
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+	// +kubebuilder:validation:Optional
+	// subPath is the subpath of the dataset referenced.
+	SubPath string `json:"subPath,omitempty"`
+	// +kubebuilder:validation:Optional
+	// grant is the name of the DatasetShareGrant approving the access.
+	Grant string `json:"grant,omitempty"`
+}
+
+type VerificationStatus struct {
+	// +kubebuilder:validation:Optional
+	// jobName is the name of the running verification job.
+	JobName string `json:"jobName,omitempty"`
+	// +kubebuilder:validation:Optional
+	// round is the data sync round whose manifest is verified.
+	Round int32 `json:"round,omitempty"`
+	// +kubebuilder:validation:Optional
+	LastVerifyTime metav1.Time `json:"lastVerifyTime,omitempty"`
+	// +kubebuilder:validation:Optional
+	// files is the number of files verified.
+	Files int32 `json:"files,omitempty"`
+	// +kubebuilder:validation:Optional
+	// driftedFilesCount is the number of files which drifted from the
+	// manifest.
+	DriftedFilesCount int32 `json:"driftedFilesCount,omitempty"`
+	// +kubebuilder:validation:Optional
+	// driftedFiles is the first of the drifted files.
+	DriftedFiles []DriftedFile `json:"driftedFiles,omitempty"`
+}
+
+type DriftedFile struct {
+	// +kubebuilder:validation:Required
+	// path is relative to the dataset.
+	Path string `json:"path"`
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=MODIFIED;MISSING;ADDED
+	Reason string `json:"reason"`
 }
 
 // Dataset is the Schema for the datasets API
@@ -209,6 +644,8 @@ type DatasetStatus struct {
 // +kubebuilder:printcolumn:name="type",type=string,JSONPath=`.spec.source.type`
 // +kubebuilder:printcolumn:name="uri",type=string,JSONPath=`.spec.source.uri`
 // +kubebuilder:printcolumn:name="phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="queue-position",type=integer,JSONPath=`.status.queuePosition`,priority=1
+// +kubebuilder:printcolumn:name="source-round",type=integer,JSONPath=`.status.sourceRound`,priority=1
 type Dataset struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -0,0 +1,123 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type DatasetExportPhase string
+
+const (
+	DatasetExportPhasePending   DatasetExportPhase = "PENDING"
+	DatasetExportPhaseRunning   DatasetExportPhase = "RUNNING"
+	DatasetExportPhaseSucceeded DatasetExportPhase = "SUCCEEDED"
+	DatasetExportPhaseFailed    DatasetExportPhase = "FAILED"
+)
+
+type DatasetExportTarget struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=GIT;S3;HUGGING_FACE;MODEL_SCOPE
+	// type is the type of the data source to export to.
+	Type DatasetType `json:"type"`
+	// +kubebuilder:validation:Required
+	// uri is the location to export to, in the same format as the uri of the
+	// dataset source of the type:
+	// - GIT: the branch of the repository is committed to, and created when it does not exist
+	// - S3: the objects under the path are replaced by the exported files
+	// - HUGGING_FACE and MODEL_SCOPE: the files are uploaded to the root of the repository
+	URI string `json:"uri"`
+	// +kubebuilder:validation:Optional
+	// options is a map of key-value pairs of the data source, the same as the
+	// options of the dataset source of the type, plus:
+	// - GIT: branch(defaults to main), commitMessage, authorName, authorEmail
+	// - S3: syncMode(defaults to "copy", "sync" removes the objects which are not exported)
+	// - HUGGING_FACE: revision(defaults to main), commitMessage
+	// - MODEL_SCOPE: revision(defaults to master), commitMessage
+	Options map[string]string `json:"options,omitempty"`
+}
+
+// DatasetExportSpec defines the desired state of DatasetExport
+// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="Value is immutable"
+type DatasetExportSpec struct {
+	// +kubebuilder:validation:Required
+	// datasetName is the name of the dataset in the same namespace to export
+	// the data of, the dataset must be READY.
+	DatasetName string `json:"datasetName"`
+	// +kubebuilder:validation:Optional
+	// path is the directory relative to the dataset to export, the whole
+	// dataset is exported when it is empty.
+	Path string `json:"path,omitempty"`
+	// +kubebuilder:validation:Required
+	// target is the data source to export to.
+	Target DatasetExportTarget `json:"target"`
+	// +kubebuilder:validation:Optional
+	// secretRef is the name of the secret that contains credentials for
+	// accessing the target, with the same keys as the secretRef of a dataset.
+	SecretRef string `json:"secretRef,omitempty"`
+}
+
+// DatasetExportStatus defines the observed state of DatasetExport
+type DatasetExportStatus struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=PENDING
+	Phase DatasetExportPhase `json:"phase,omitempty"`
+	// +kubebuilder:validation:Optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// +kubebuilder:validation:Optional
+	// jobName is the name of the job which exports the data.
+	JobName string `json:"jobName,omitempty"`
+	// +kubebuilder:validation:Optional
+	StartTime metav1.Time `json:"startTime,omitempty"`
+	// +kubebuilder:validation:Optional
+	CompletionTime metav1.Time `json:"completionTime,omitempty"`
+	// +kubebuilder:validation:Optional
+	// revision is the pushed revision of the target, e.g. the commit of the
+	// branch of GIT and HUGGING_FACE. it is empty for S3.
+	Revision string `json:"revision,omitempty"`
+}
+
+// DatasetExport is the Schema for the datasetexports API, it exports the data
+// of a dataset to a data source once.
+// +genclient
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="dataset",type=string,JSONPath=`.spec.datasetName`
+// +kubebuilder:printcolumn:name="type",type=string,JSONPath=`.spec.target.type`
+// +kubebuilder:printcolumn:name="uri",type=string,JSONPath=`.spec.target.uri`
+// +kubebuilder:printcolumn:name="phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="revision",type=string,JSONPath=`.status.revision`
+type DatasetExport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DatasetExportSpec   `json:"spec,omitempty"`
+	Status DatasetExportStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// DatasetExportList contains a list of DatasetExport
+type DatasetExportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DatasetExport `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DatasetExport{}, &DatasetExportList{})
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DatasetPolicySpec defines the desired state of DatasetPolicy
+type DatasetPolicySpec struct {
+	// +kubebuilder:validation:Optional
+	// namespaceSelector selects the namespaces whose datasets the policy is
+	// applied to, the policy is applied to all namespaces when it is not set.
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxItems=16
+	// allowedTypes are the types of the sources the datasets may use, all
+	// types are allowed when it is empty.
+	AllowedTypes []DatasetType `json:"allowedTypes,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxItems=64
+	// allowedURIHosts are the glob patterns of the hosts the sources may be
+	// loaded from, e.g. git.example.com or *.s3.example.com. the hosts of S3
+	// and HUGGING_FACE sources are the ones of their endpoint option, those
+	// of CONDA, PIXI and PYTHON_VENV sources the ones of their channels and
+	// index urls options, along with the endpoints and indexes set in the env
+	// of podOverrides, e.g. HF_ENDPOINT, MODELSCOPE_DOMAIN and PIP_INDEX_URL.
+	// the sources whose hosts can not be determined, e.g. CONDA sources
+	// without the channels option, are denied. sources of the types without
+	// a host, e.g. PVC, are not checked. all hosts are allowed when it is
+	// empty.
+	AllowedURIHosts []string `json:"allowedURIHosts,omitempty"`
+	// +kubebuilder:validation:Optional
+	// maxPVCSize is the max storage size the pvcs created for the datasets
+	// may request, including the local pvcs of warmup.
+	MaxPVCSize *resource.Quantity `json:"maxPVCSize,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// maxConcurrentJobs is the max number of datasets of a namespace loading
+	// their data at the same time, the others are queued until one of them
+	// is done, like with max_concurrent_jobs_per_namespace of the controller
+	// config.
+	MaxConcurrentJobs *int32 `json:"maxConcurrentJobs,omitempty"`
+	// +kubebuilder:validation:Optional
+	// allowShare is whether the datasets may set share, it defaults to true.
+	AllowShare *bool `json:"allowShare,omitempty"`
+	// +kubebuilder:validation:Optional
+	// allowPodOverrides is whether the datasets may set podOverrides, it
+	// defaults to true.
+	AllowPodOverrides *bool `json:"allowPodOverrides,omitempty"`
+}
+
+// DatasetPolicy is the Schema for the datasetpolicies API, it restricts the
+// datasets of the namespaces it selects. a dataset must comply with all of the
+// policies applied to its namespace.
+// +genclient
+// +genclient:nonNamespaced
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="types",type=string,JSONPath=`.spec.allowedTypes`
+// +kubebuilder:printcolumn:name="max-pvc-size",type=string,JSONPath=`.spec.maxPVCSize`
+// +kubebuilder:printcolumn:name="max-jobs",type=integer,JSONPath=`.spec.maxConcurrentJobs`
+// +kubebuilder:printcolumn:name="share",type=boolean,JSONPath=`.spec.allowShare`
+type DatasetPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec DatasetPolicySpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// DatasetPolicyList contains a list of DatasetPolicy
+type DatasetPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DatasetPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DatasetPolicy{}, &DatasetPolicyList{})
+}
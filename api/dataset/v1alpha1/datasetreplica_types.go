@@ -0,0 +1,135 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type DatasetReplicaRole string
+
+const (
+	// DatasetReplicaRolePrimary publishes each round of the dataset to the
+	// store.
+	DatasetReplicaRolePrimary DatasetReplicaRole = "Primary"
+	// DatasetReplicaRoleSecondary loads the dataset from the rounds
+	// published to the store.
+	DatasetReplicaRoleSecondary DatasetReplicaRole = "Secondary"
+)
+
+type DatasetReplicaStore struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=S3
+	// type is the type of the store.
+	Type DatasetType `json:"type"`
+	// +kubebuilder:validation:Required
+	// uri is the location of the store, e.g. s3://bucket/replicas/models. the
+	// rounds are published under rounds/<round> of it.
+	URI string `json:"uri"`
+	// +kubebuilder:validation:Optional
+	// options is a map of key-value pairs of the store, the same as the
+	// options of the dataset source of the type.
+	Options map[string]string `json:"options,omitempty"`
+	// +kubebuilder:validation:Optional
+	// secretRef is the name of the secret that contains credentials for
+	// accessing the store, with the same keys as the secretRef of a dataset.
+	SecretRef string `json:"secretRef,omitempty"`
+}
+
+// DatasetReplicaSpec defines the desired state of DatasetReplica
+// +kubebuilder:validation:XValidation:rule="(self.role == 'Primary') == has(self.store)",message="store must be set if and only if the role is Primary"
+type DatasetReplicaSpec struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=Primary;Secondary
+	// role is Primary in the cluster the dataset is loaded from its data
+	// source, and Secondary in the clusters it is replicated to.
+	Role DatasetReplicaRole `json:"role"`
+	// +kubebuilder:validation:Required
+	// datasetName is the name of the dataset in the same namespace to
+	// replicate. the dataset of a Secondary replica is the store: its source
+	// must be of type S3 with the uri of the store, a new round of it is
+	// started with the replicaRound option whenever a round is published.
+	DatasetName string `json:"datasetName"`
+	// +kubebuilder:validation:Optional
+	// store is where a Primary replica publishes the rounds to.
+	Store *DatasetReplicaStore `json:"store,omitempty"`
+	// +kubebuilder:validation:Optional
+	// pollInterval is how often a Secondary replica looks for a new round in
+	// the store, defaults to 5m.
+	PollInterval *metav1.Duration `json:"pollInterval,omitempty"`
+}
+
+// DatasetReplicaStatus defines the observed state of DatasetReplica
+type DatasetReplicaStatus struct {
+	// +kubebuilder:validation:Optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// +kubebuilder:validation:Optional
+	// jobName is the name of the job publishing a round of a Primary replica,
+	// or reading the last round published of a Secondary replica.
+	JobName string `json:"jobName,omitempty"`
+	// +kubebuilder:validation:Optional
+	// round is the round of the primary dataset last published by a Primary
+	// replica, or mirrored by the dataset of a Secondary replica.
+	Round int32 `json:"round,omitempty"`
+	// +kubebuilder:validation:Optional
+	// digest is the digest of the content of the round reported by the data
+	// loader of the primary dataset, if any.
+	Digest string `json:"digest,omitempty"`
+	// +kubebuilder:validation:Optional
+	// lastSyncTime is when the round was published or mirrored.
+	LastSyncTime metav1.Time `json:"lastSyncTime,omitempty"`
+	// +kubebuilder:validation:Optional
+	// latestRound is the last round published to the store seen by a
+	// Secondary replica, the dataset is being loaded from it when it is not
+	// the round.
+	LatestRound int32 `json:"latestRound,omitempty"`
+	// +kubebuilder:validation:Optional
+	LatestDigest string `json:"latestDigest,omitempty"`
+	// +kubebuilder:validation:Optional
+	// lastPollTime is when a Secondary replica last read the store.
+	LastPollTime metav1.Time `json:"lastPollTime,omitempty"`
+}
+
+// DatasetReplica is the Schema for the datasetreplicas API, it replicates a
+// dataset across clusters through a store.
+// +genclient
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="role",type=string,JSONPath=`.spec.role`
+// +kubebuilder:printcolumn:name="dataset",type=string,JSONPath=`.spec.datasetName`
+// +kubebuilder:printcolumn:name="round",type=integer,JSONPath=`.status.round`
+// +kubebuilder:printcolumn:name="digest",type=string,JSONPath=`.status.digest`,priority=1
+type DatasetReplica struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DatasetReplicaSpec   `json:"spec,omitempty"`
+	Status DatasetReplicaStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// DatasetReplicaList contains a list of DatasetReplica
+type DatasetReplicaList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DatasetReplica `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DatasetReplica{}, &DatasetReplicaList{})
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type DatasetShareGrantPhase string
+
+const (
+	DatasetShareGrantPhaseActive  DatasetShareGrantPhase = "ACTIVE"
+	DatasetShareGrantPhaseExpired DatasetShareGrantPhase = "EXPIRED"
+)
+
+// DatasetShareGrantSpec defines the desired state of DatasetShareGrant
+// +kubebuilder:validation:XValidation:rule="(has(self.namespaces) && size(self.namespaces) > 0) || (has(self.serviceAccounts) && size(self.serviceAccounts) > 0)",message="namespaces or serviceAccounts must be set"
+type DatasetShareGrantSpec struct {
+	// +kubebuilder:validation:Required
+	// datasetName is the name of the shared dataset in the same namespace the
+	// grant approves referencing.
+	DatasetName string `json:"datasetName"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxItems=64
+	// namespaces are the consumer namespaces approved to create REFERENCE
+	// datasets of the dataset.
+	Namespaces []string `json:"namespaces,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxItems=64
+	// serviceAccounts are the service accounts, as <namespace>/<name>,
+	// approved to reference the dataset. a REFERENCE dataset is approved by
+	// the service account its pods run as, i.e. the serviceAccountName of its
+	// podOverrides or of the dataset_job_spec_yaml of the controller config,
+	// the default service account of its namespace otherwise.
+	ServiceAccounts []string `json:"serviceAccounts,omitempty"`
+	// +kubebuilder:validation:Optional
+	// expiresAt is when the grant expires, the REFERENCE datasets approved by
+	// it lose the access to the dataset then. the grant never expires when it
+	// is not set.
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+	// +kubebuilder:validation:Optional
+	// reason records why the access is granted, for auditing.
+	Reason string `json:"reason,omitempty"`
+}
+
+// DatasetShareGrantStatus defines the observed state of DatasetShareGrant
+type DatasetShareGrantStatus struct {
+	// +kubebuilder:validation:Optional
+	Phase DatasetShareGrantPhase `json:"phase,omitempty"`
+}
+
+// DatasetShareGrant is the Schema for the datasetsharegrants API, it approves
+// namespaces or service accounts to reference a dataset which has
+// shareGrantRequired set.
+// +genclient
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="dataset",type=string,JSONPath=`.spec.datasetName`
+// +kubebuilder:printcolumn:name="namespaces",type=string,JSONPath=`.spec.namespaces`
+// +kubebuilder:printcolumn:name="service-accounts",type=string,JSONPath=`.spec.serviceAccounts`,priority=1
+// +kubebuilder:printcolumn:name="expires",type=string,JSONPath=`.spec.expiresAt`
+// +kubebuilder:printcolumn:name="phase",type=string,JSONPath=`.status.phase`
+type DatasetShareGrant struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DatasetShareGrantSpec   `json:"spec,omitempty"`
+	Status DatasetShareGrantStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// DatasetShareGrantList contains a list of DatasetShareGrant
+type DatasetShareGrantList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DatasetShareGrant `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DatasetShareGrant{}, &DatasetShareGrantList{})
+}
@@ -21,7 +21,8 @@ limitations under the License.
 package v1alpha1
 
 import (
-	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -30,39 +31,482 @@ func (in *DataLoadStatus) DeepCopyInto(out *DataLoadStatus) {
 	*out = *in
 	in.StartTime.DeepCopyInto(&out.StartTime)
 	in.EndTime.DeepCopyInto(&out.EndTime)
+	if in.Sources != nil {
+		in, out := &in.Sources, &out.Sources
+		*out = make([]SourceLoadStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.PostProcess != nil {
+		in, out := &in.PostProcess, &out.PostProcess
+		*out = make([]PostProcessStepStatus, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataLoadStatus.
+func (in *DataLoadStatus) DeepCopy() *DataLoadStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DataLoadStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Dataset) DeepCopyInto(out *Dataset) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Dataset.
+func (in *Dataset) DeepCopy() *Dataset {
+	if in == nil {
+		return nil
+	}
+	out := new(Dataset)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Dataset) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatasetConsumer) DeepCopyInto(out *DatasetConsumer) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatasetConsumer.
+func (in *DatasetConsumer) DeepCopy() *DatasetConsumer {
+	if in == nil {
+		return nil
+	}
+	out := new(DatasetConsumer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatasetExport) DeepCopyInto(out *DatasetExport) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatasetExport.
+func (in *DatasetExport) DeepCopy() *DatasetExport {
+	if in == nil {
+		return nil
+	}
+	out := new(DatasetExport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DatasetExport) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatasetExportList) DeepCopyInto(out *DatasetExportList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]DatasetExport, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatasetExportList.
+func (in *DatasetExportList) DeepCopy() *DatasetExportList {
+	if in == nil {
+		return nil
+	}
+	out := new(DatasetExportList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DatasetExportList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatasetExportSpec) DeepCopyInto(out *DatasetExportSpec) {
+	*out = *in
+	in.Target.DeepCopyInto(&out.Target)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatasetExportSpec.
+func (in *DatasetExportSpec) DeepCopy() *DatasetExportSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DatasetExportSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatasetExportStatus) DeepCopyInto(out *DatasetExportStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	in.CompletionTime.DeepCopyInto(&out.CompletionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatasetExportStatus.
+func (in *DatasetExportStatus) DeepCopy() *DatasetExportStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DatasetExportStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatasetExportTarget) DeepCopyInto(out *DatasetExportTarget) {
+	*out = *in
+	if in.Options != nil {
+		in, out := &in.Options, &out.Options
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatasetExportTarget.
+func (in *DatasetExportTarget) DeepCopy() *DatasetExportTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(DatasetExportTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatasetList) DeepCopyInto(out *DatasetList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Dataset, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatasetList.
+func (in *DatasetList) DeepCopy() *DatasetList {
+	if in == nil {
+		return nil
+	}
+	out := new(DatasetList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DatasetList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatasetPolicy) DeepCopyInto(out *DatasetPolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatasetPolicy.
+func (in *DatasetPolicy) DeepCopy() *DatasetPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(DatasetPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DatasetPolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatasetPolicyList) DeepCopyInto(out *DatasetPolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]DatasetPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatasetPolicyList.
+func (in *DatasetPolicyList) DeepCopy() *DatasetPolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(DatasetPolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DatasetPolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatasetPolicySpec) DeepCopyInto(out *DatasetPolicySpec) {
+	*out = *in
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AllowedTypes != nil {
+		in, out := &in.AllowedTypes, &out.AllowedTypes
+		*out = make([]DatasetType, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedURIHosts != nil {
+		in, out := &in.AllowedURIHosts, &out.AllowedURIHosts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MaxPVCSize != nil {
+		in, out := &in.MaxPVCSize, &out.MaxPVCSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.MaxConcurrentJobs != nil {
+		in, out := &in.MaxConcurrentJobs, &out.MaxConcurrentJobs
+		*out = new(int32)
+		**out = **in
+	}
+	if in.AllowShare != nil {
+		in, out := &in.AllowShare, &out.AllowShare
+		*out = new(bool)
+		**out = **in
+	}
+	if in.AllowPodOverrides != nil {
+		in, out := &in.AllowPodOverrides, &out.AllowPodOverrides
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatasetPolicySpec.
+func (in *DatasetPolicySpec) DeepCopy() *DatasetPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DatasetPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatasetReplica) DeepCopyInto(out *DatasetReplica) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatasetReplica.
+func (in *DatasetReplica) DeepCopy() *DatasetReplica {
+	if in == nil {
+		return nil
+	}
+	out := new(DatasetReplica)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DatasetReplica) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatasetReplicaList) DeepCopyInto(out *DatasetReplicaList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]DatasetReplica, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatasetReplicaList.
+func (in *DatasetReplicaList) DeepCopy() *DatasetReplicaList {
+	if in == nil {
+		return nil
+	}
+	out := new(DatasetReplicaList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DatasetReplicaList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatasetReplicaSpec) DeepCopyInto(out *DatasetReplicaSpec) {
+	*out = *in
+	if in.Store != nil {
+		in, out := &in.Store, &out.Store
+		*out = new(DatasetReplicaStore)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PollInterval != nil {
+		in, out := &in.PollInterval, &out.PollInterval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatasetReplicaSpec.
+func (in *DatasetReplicaSpec) DeepCopy() *DatasetReplicaSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DatasetReplicaSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatasetReplicaStatus) DeepCopyInto(out *DatasetReplicaStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.LastSyncTime.DeepCopyInto(&out.LastSyncTime)
+	in.LastPollTime.DeepCopyInto(&out.LastPollTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatasetReplicaStatus.
+func (in *DatasetReplicaStatus) DeepCopy() *DatasetReplicaStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DatasetReplicaStatus)
+	in.DeepCopyInto(out)
+	return out
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataLoadStatus.
-func (in *DataLoadStatus) DeepCopy() *DataLoadStatus {
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatasetReplicaStore) DeepCopyInto(out *DatasetReplicaStore) {
+	*out = *in
+	if in.Options != nil {
+		in, out := &in.Options, &out.Options
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatasetReplicaStore.
+func (in *DatasetReplicaStore) DeepCopy() *DatasetReplicaStore {
 	if in == nil {
 		return nil
 	}
-	out := new(DataLoadStatus)
+	out := new(DatasetReplicaStore)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Dataset) DeepCopyInto(out *Dataset) {
+func (in *DatasetShareGrant) DeepCopyInto(out *DatasetShareGrant) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	in.Status.DeepCopyInto(&out.Status)
+	out.Status = in.Status
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Dataset.
-func (in *Dataset) DeepCopy() *Dataset {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatasetShareGrant.
+func (in *DatasetShareGrant) DeepCopy() *DatasetShareGrant {
 	if in == nil {
 		return nil
 	}
-	out := new(Dataset)
+	out := new(DatasetShareGrant)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *Dataset) DeepCopyObject() runtime.Object {
+func (in *DatasetShareGrant) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -70,37 +514,81 @@ func (in *Dataset) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *DatasetList) DeepCopyInto(out *DatasetList) {
+func (in *DatasetShareGrantList) DeepCopyInto(out *DatasetShareGrantList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]Dataset, len(*in))
+		*out = make([]DatasetShareGrant, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatasetList.
-func (in *DatasetList) DeepCopy() *DatasetList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatasetShareGrantList.
+func (in *DatasetShareGrantList) DeepCopy() *DatasetShareGrantList {
 	if in == nil {
 		return nil
 	}
-	out := new(DatasetList)
+	out := new(DatasetShareGrantList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *DatasetList) DeepCopyObject() runtime.Object {
+func (in *DatasetShareGrantList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatasetShareGrantSpec) DeepCopyInto(out *DatasetShareGrantSpec) {
+	*out = *in
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ServiceAccounts != nil {
+		in, out := &in.ServiceAccounts, &out.ServiceAccounts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExpiresAt != nil {
+		in, out := &in.ExpiresAt, &out.ExpiresAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatasetShareGrantSpec.
+func (in *DatasetShareGrantSpec) DeepCopy() *DatasetShareGrantSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DatasetShareGrantSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatasetShareGrantStatus) DeepCopyInto(out *DatasetShareGrantStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatasetShareGrantStatus.
+func (in *DatasetShareGrantStatus) DeepCopy() *DatasetShareGrantStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DatasetShareGrantStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DatasetSource) DeepCopyInto(out *DatasetSource) {
 	*out = *in
@@ -128,10 +616,34 @@ func (in *DatasetSpec) DeepCopyInto(out *DatasetSpec) {
 	*out = *in
 	if in.ShareToNamespaceSelector != nil {
 		in, out := &in.ShareToNamespaceSelector, &out.ShareToNamespaceSelector
-		*out = new(v1.LabelSelector)
+		*out = new(metav1.LabelSelector)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ShareSubPaths != nil {
+		in, out := &in.ShareSubPaths, &out.ShareSubPaths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	in.Source.DeepCopyInto(&out.Source)
+	if in.Sources != nil {
+		in, out := &in.Sources, &out.Sources
+		*out = make([]DatasetSubSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PostProcess != nil {
+		in, out := &in.PostProcess, &out.PostProcess
+		*out = make([]PostProcessStep, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.VerifySchedule != nil {
+		in, out := &in.VerifySchedule, &out.VerifySchedule
+		*out = new(metav1.Duration)
+		**out = **in
+	}
 	out.MountOptions = in.MountOptions
 	in.VolumeClaimTemplate.DeepCopyInto(&out.VolumeClaimTemplate)
 	if in.VolumeClaimRef != nil {
@@ -139,6 +651,17 @@ func (in *DatasetSpec) DeepCopyInto(out *DatasetSpec) {
 		*out = new(VolumeClaimRef)
 		**out = **in
 	}
+	if in.PodOverrides != nil {
+		in, out := &in.PodOverrides, &out.PodOverrides
+		*out = new(LoaderPodOverrides)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Warmup != nil {
+		in, out := &in.Warmup, &out.Warmup
+		*out = new(DatasetWarmup)
+		(*in).DeepCopyInto(*out)
+	}
+	in.DataWarmUpResources.DeepCopyInto(&out.DataWarmUpResources)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatasetSpec.
@@ -156,7 +679,7 @@ func (in *DatasetStatus) DeepCopyInto(out *DatasetStatus) {
 	*out = *in
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
-		*out = make([]v1.Condition, len(*in))
+		*out = make([]metav1.Condition, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -169,6 +692,39 @@ func (in *DatasetStatus) DeepCopyInto(out *DatasetStatus) {
 		}
 	}
 	in.LastSyncTime.DeepCopyInto(&out.LastSyncTime)
+	if in.Uploads != nil {
+		in, out := &in.Uploads, &out.Uploads
+		*out = make([]UploadStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Verification != nil {
+		in, out := &in.Verification, &out.Verification
+		*out = new(VerificationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Consumers != nil {
+		in, out := &in.Consumers, &out.Consumers
+		*out = make([]DatasetConsumer, len(*in))
+		copy(*out, *in)
+	}
+	if in.Warmup != nil {
+		in, out := &in.Warmup, &out.Warmup
+		*out = make([]WarmupNodeStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Policies != nil {
+		in, out := &in.Policies, &out.Policies
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.QueuedAt != nil {
+		in, out := &in.QueuedAt, &out.QueuedAt
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatasetStatus.
@@ -181,6 +737,161 @@ func (in *DatasetStatus) DeepCopy() *DatasetStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatasetSubSource) DeepCopyInto(out *DatasetSubSource) {
+	*out = *in
+	if in.Options != nil {
+		in, out := &in.Options, &out.Options
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatasetSubSource.
+func (in *DatasetSubSource) DeepCopy() *DatasetSubSource {
+	if in == nil {
+		return nil
+	}
+	out := new(DatasetSubSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatasetWarmup) DeepCopyInto(out *DatasetWarmup) {
+	*out = *in
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]v1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.TolerationSeconds != nil {
+		in, out := &in.TolerationSeconds, &out.TolerationSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.StorageClassName != nil {
+		in, out := &in.StorageClassName, &out.StorageClassName
+		*out = new(string)
+		**out = **in
+	}
+	if in.Size != nil {
+		in, out := &in.Size, &out.Size
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatasetWarmup.
+func (in *DatasetWarmup) DeepCopy() *DatasetWarmup {
+	if in == nil {
+		return nil
+	}
+	out := new(DatasetWarmup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DriftedFile) DeepCopyInto(out *DriftedFile) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DriftedFile.
+func (in *DriftedFile) DeepCopy() *DriftedFile {
+	if in == nil {
+		return nil
+	}
+	out := new(DriftedFile)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LoaderPodOverrides) DeepCopyInto(out *LoaderPodOverrides) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]v1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(v1.Affinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ImagePullSecrets != nil {
+		in, out := &in.ImagePullSecrets, &out.ImagePullSecrets
+		*out = make([]v1.LocalObjectReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]v1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Volumes != nil {
+		in, out := &in.Volumes, &out.Volumes
+		*out = make([]v1.Volume, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.VolumeMounts != nil {
+		in, out := &in.VolumeMounts, &out.VolumeMounts
+		*out = make([]v1.VolumeMount, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoaderPodOverrides.
+func (in *LoaderPodOverrides) DeepCopy() *LoaderPodOverrides {
+	if in == nil {
+		return nil
+	}
+	out := new(LoaderPodOverrides)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MountOptions) DeepCopyInto(out *MountOptions) {
 	*out = *in
@@ -196,6 +907,100 @@ func (in *MountOptions) DeepCopy() *MountOptions {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostProcessStep) DeepCopyInto(out *PostProcessStep) {
+	*out = *in
+	if in.Options != nil {
+		in, out := &in.Options, &out.Options
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Container != nil {
+		in, out := &in.Container, &out.Container
+		*out = new(v1.Container)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostProcessStep.
+func (in *PostProcessStep) DeepCopy() *PostProcessStep {
+	if in == nil {
+		return nil
+	}
+	out := new(PostProcessStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostProcessStepStatus) DeepCopyInto(out *PostProcessStepStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostProcessStepStatus.
+func (in *PostProcessStepStatus) DeepCopy() *PostProcessStepStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PostProcessStepStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SourceLoadStatus) DeepCopyInto(out *SourceLoadStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SourceLoadStatus.
+func (in *SourceLoadStatus) DeepCopy() *SourceLoadStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SourceLoadStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UploadStatus) DeepCopyInto(out *UploadStatus) {
+	*out = *in
+	in.CompletionTime.DeepCopyInto(&out.CompletionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UploadStatus.
+func (in *UploadStatus) DeepCopy() *UploadStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(UploadStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VerificationStatus) DeepCopyInto(out *VerificationStatus) {
+	*out = *in
+	in.LastVerifyTime.DeepCopyInto(&out.LastVerifyTime)
+	if in.DriftedFiles != nil {
+		in, out := &in.DriftedFiles, &out.DriftedFiles
+		*out = make([]DriftedFile, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VerificationStatus.
+func (in *VerificationStatus) DeepCopy() *VerificationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VerificationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VolumeClaimRef) DeepCopyInto(out *VolumeClaimRef) {
 	*out = *in
@@ -210,3 +1015,19 @@ func (in *VolumeClaimRef) DeepCopy() *VolumeClaimRef {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WarmupNodeStatus) DeepCopyInto(out *WarmupNodeStatus) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WarmupNodeStatus.
+func (in *WarmupNodeStatus) DeepCopy() *WarmupNodeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(WarmupNodeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
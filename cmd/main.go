@@ -21,18 +21,22 @@ import (
 	config2 "github.com/BaizeAI/dataset/config"
 	"github.com/samber/lo"
 	"os"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	datasetv1alpha1 "github.com/BaizeAI/dataset/api/dataset/v1alpha1"
 
@@ -58,9 +62,25 @@ func main() {
 	var enableLeaderElection bool
 	var probeAddr string
 	var config string
+	var uploadAddr string
+	var uploadCertFile string
+	var uploadKeyFile string
+	var uploadIdleTimeout time.Duration
+	var webhookPort int
+	var webhookCertDir string
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8082", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8083", "The address the probe endpoint binds to.")
 	flag.StringVar(&config, "config", "config/config.yaml", "The path of config file")
+	flag.StringVar(&uploadAddr, "upload-bind-address", ":8084",
+		"The address the upload API of MANUAL datasets binds to. Set it to 0 to disable the upload API.")
+	flag.StringVar(&uploadCertFile, "upload-tls-cert-file", "",
+		"The TLS certificate of the upload API. The upload API is disabled unless both the certificate and the key are set.")
+	flag.StringVar(&uploadKeyFile, "upload-tls-key-file", "", "The TLS private key of the upload API.")
+	flag.DurationVar(&uploadIdleTimeout, "upload-idle-timeout", 10*time.Minute,
+		"How long the helper pod receiving the uploads of a MANUAL dataset is kept without uploads.")
+	flag.IntVar(&webhookPort, "webhook-port", 9443, "The port the validating webhook of datasets binds to.")
+	flag.StringVar(&webhookCertDir, "webhook-cert-dir", "",
+		"The directory of the tls.crt and tls.key of the validating webhook of datasets. The webhook is disabled when empty.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", true,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
@@ -80,12 +100,18 @@ func main() {
 		Scheme:                 scheme,
 		Metrics:                metricsserver.Options{BindAddress: metricsAddr},
 		HealthProbeBindAddress: probeAddr,
+		WebhookServer:          webhook.NewServer(webhook.Options{Port: webhookPort, CertDir: webhookCertDir}),
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "d227e0e2.baizeai.io",
 		LeaderElectionNamespace: lo.CoalesceOrEmpty(os.Getenv("POD_NAMESPACE"), func() string {
 			bs, _ := os.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/namespace")
 			return string(bs)
 		}(), "default"),
+		// the pods of the jobs are only read once the jobs finish, they are
+		// read from the API server rather than caching all pods of the cluster
+		Client: client.Options{
+			Cache: &client.CacheOptions{DisableFor: []client.Object{&corev1.Pod{}}},
+		},
 		// LeaderElectionReleaseOnCancel defines if the leader should step down voluntarily
 		// when the Manager ends. This requires the binary to immediately end when the
 		// Manager is stopped, otherwise, this setting is unsafe. Setting this significantly
@@ -109,6 +135,56 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "Dataset")
 		os.Exit(1)
 	}
+	if err = (&datasetcontroller.DatasetExportReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "DatasetExport")
+		os.Exit(1)
+	}
+	if err = (&datasetcontroller.DatasetShareGrantReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "DatasetShareGrant")
+		os.Exit(1)
+	}
+	if err = (&datasetcontroller.DatasetReplicaReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "DatasetReplica")
+		os.Exit(1)
+	}
+	if webhookCertDir != "" {
+		if err = (&datasetcontroller.DatasetValidator{
+			Client: mgr.GetAPIReader(),
+		}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Dataset")
+			os.Exit(1)
+		}
+	}
+	if uploadAddr != "0" && (uploadCertFile == "") != (uploadKeyFile == "") {
+		setupLog.Error(nil, "both --upload-tls-cert-file and --upload-tls-key-file must be set to serve the upload API")
+		os.Exit(1)
+	}
+	if uploadAddr != "0" && uploadCertFile == "" {
+		// the upload API carries bearer tokens, it is not served over plain HTTP
+		setupLog.Info("the upload API is disabled without a TLS certificate")
+	} else if uploadAddr != "0" {
+		if err = mgr.Add(&datasetcontroller.UploadServer{
+			Client:      mgr.GetClient(),
+			APIReader:   mgr.GetAPIReader(),
+			Authorizer:  &datasetcontroller.SubjectAccessReviewAuthorizer{Client: mgr.GetClient()},
+			BindAddress: uploadAddr,
+			CertFile:    uploadCertFile,
+			KeyFile:     uploadKeyFile,
+			IdleTimeout: uploadIdleTimeout,
+		}); err != nil {
+			setupLog.Error(err, "unable to set up upload server")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -3,10 +3,17 @@ package config
 import (
 	"fmt"
 	"os"
+	"path"
 	"strings"
 
 	"github.com/go-viper/mapstructure/v2"
 	"github.com/spf13/viper"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/yaml"
+
+	"github.com/BaizeAI/dataset/internal/pkg/blobcache"
 )
 
 var (
@@ -22,6 +29,148 @@ type configuration struct {
 	DatasetJobSpecYaml      string `json:"dataset_job_spec_yaml"`
 	EnableCascadingDeletion bool   `json:"enable_cascading_deletion"`
 	DatasetNFSVersion       string `json:"dataset_nfs_version"`
+
+	BlobCacheVolumeYaml string `json:"blob_cache_volume_yaml"`
+	BlobCacheMaxSize    string `json:"blob_cache_max_size"`
+	BlobCacheLinkMode   string `json:"blob_cache_link_mode"`
+
+	MaxConcurrentJobs             int            `json:"max_concurrent_jobs"`
+	MaxConcurrentJobsPerNamespace int            `json:"max_concurrent_jobs_per_namespace"`
+	MaxConcurrentJobsPerHost      int            `json:"max_concurrent_jobs_per_host"`
+	MaxConcurrentJobsPerType      map[string]int `json:"max_concurrent_jobs_per_type"`
+
+	WarmupHostPath string `json:"warmup_host_path"`
+
+	PodOverridesServiceAccounts []string `json:"pod_overrides_service_accounts"`
+	PodOverridesHostPaths       []string `json:"pod_overrides_host_paths"`
+
+	blobCache *BlobCache
+	jobLimits JobLimits
+}
+
+// JobLimits are the limits of the data loader jobs running at the same time,
+// 0 is unlimited. the datasets over the limits are queued.
+type JobLimits struct {
+	// Global limits the jobs of all datasets.
+	Global int
+	// PerNamespace limits the jobs of the datasets of each namespace.
+	PerNamespace int
+	// PerHost limits the jobs loading from each host.
+	PerHost int
+	// PerType limits the jobs loading the sources of each type, keyed by
+	// the dataset types, e.g. HUGGING_FACE.
+	PerType map[string]int
+}
+
+func parseJobLimits(cfg *configuration) (JobLimits, error) {
+	limits := JobLimits{
+		Global:       cfg.MaxConcurrentJobs,
+		PerNamespace: cfg.MaxConcurrentJobsPerNamespace,
+		PerHost:      cfg.MaxConcurrentJobsPerHost,
+	}
+	if limits.Global < 0 || limits.PerNamespace < 0 || limits.PerHost < 0 {
+		return limits, fmt.Errorf("max_concurrent_jobs, max_concurrent_jobs_per_namespace and max_concurrent_jobs_per_host should not be negative")
+	}
+	for typ, limit := range cfg.MaxConcurrentJobsPerType {
+		if limit < 0 {
+			return limits, fmt.Errorf("invalid max_concurrent_jobs_per_type of %s: %d", typ, limit)
+		}
+		if limit == 0 {
+			continue
+		}
+		if limits.PerType == nil {
+			limits.PerType = map[string]int{}
+		}
+		// the keys are lower cased by viper
+		limits.PerType[strings.ToUpper(typ)] = limit
+	}
+
+	return limits, nil
+}
+
+// GetJobLimits returns the limits of the data loader jobs running at the same
+// time.
+func GetJobLimits() JobLimits {
+	if config == nil {
+		return JobLimits{}
+	}
+	return config.jobLimits
+}
+
+// BlobCache is the blob cache shared by the data loader jobs of the
+// HUGGING_FACE, MODEL_SCOPE, S3 and CONDA datasets.
+type BlobCache struct {
+	// Volume is the volume holding the cache, e.g. a PVC or an NFS share.
+	Volume corev1.VolumeSource
+	// MaxSize is the size in bytes the cache is evicted to, 0 is unlimited.
+	MaxSize int64
+	// LinkMode is how the files are restored from the cache.
+	LinkMode blobcache.LinkMode
+}
+
+func parseBlobCache(cfg *configuration) (*BlobCache, error) {
+	if strings.TrimSpace(cfg.BlobCacheVolumeYaml) == "" {
+		return nil, nil
+	}
+
+	cache := &BlobCache{}
+	if err := yaml.UnmarshalStrict([]byte(cfg.BlobCacheVolumeYaml), &cache.Volume); err != nil {
+		return nil, fmt.Errorf("invalid blob_cache_volume_yaml: %w", err)
+	}
+	if cache.Volume == (corev1.VolumeSource{}) {
+		return nil, fmt.Errorf("invalid blob_cache_volume_yaml: no volume source is set")
+	}
+	if cfg.BlobCacheMaxSize != "" {
+		maxSize, err := resource.ParseQuantity(cfg.BlobCacheMaxSize)
+		if err != nil || maxSize.Sign() < 0 {
+			return nil, fmt.Errorf("invalid blob_cache_max_size %q", cfg.BlobCacheMaxSize)
+		}
+		cache.MaxSize = maxSize.Value()
+	}
+	linkMode, err := blobcache.ParseLinkMode(cfg.BlobCacheLinkMode)
+	if err != nil {
+		return nil, err
+	}
+	cache.LinkMode = linkMode
+
+	return cache, nil
+}
+
+// GetBlobCache returns the blob cache shared by the data loader jobs, nil when
+// blob_cache_volume_yaml is not set.
+func GetBlobCache() *BlobCache {
+	if config == nil {
+		return nil
+	}
+	return config.blobCache
+}
+
+// GetWarmupHostPath returns the directory on the nodes the datasets with
+// warmup.hostPath are copied into, empty when it is not allowed.
+func GetWarmupHostPath() string {
+	if config == nil {
+		return ""
+	}
+	return config.WarmupHostPath
+}
+
+// GetPodOverridesServiceAccounts returns the service accounts the podOverrides
+// of the datasets may run the pods as, none when it is empty.
+func GetPodOverridesServiceAccounts() []string {
+	if config == nil {
+		return nil
+	}
+	return config.PodOverridesServiceAccounts
+}
+
+// GetPodOverridesHostPaths returns the directories on the nodes the hostPath
+// volumes of the podOverrides of the datasets may be under, none when it is
+// empty.
+func GetPodOverridesHostPaths() []string {
+	if config == nil {
+		return nil
+	}
+	return config.PodOverridesHostPaths
 }
 
 func validateDatasetNFSVersion(version string) error {
@@ -69,6 +218,23 @@ template:
 	return config.DatasetJobSpecYaml
 }
 
+// validateDatasetJobSpecYaml checks that the job spec is one the data loader
+// jobs can be built from, its first container is the data loader.
+func validateDatasetJobSpecYaml(jobSpecYaml string) error {
+	jobSpec := batchv1.JobSpec{}
+	if err := yaml.Unmarshal([]byte(jobSpecYaml), &jobSpec); err != nil {
+		return fmt.Errorf("invalid dataset_job_spec_yaml: %w", err)
+	}
+	if len(jobSpec.Template.Spec.Containers) == 0 {
+		return fmt.Errorf("invalid dataset_job_spec_yaml: template.spec.containers is empty")
+	}
+	if jobSpec.Template.Spec.Containers[0].Image == "" {
+		return fmt.Errorf("invalid dataset_job_spec_yaml: the image of the first container is empty")
+	}
+
+	return nil
+}
+
 func IsCascadingDeletionEnabled() bool {
 	if config == nil {
 		return false
@@ -118,6 +284,29 @@ func ParseConfigFromFile(configPath string) error {
 	if err := validateDatasetNFSVersion(cfg.DatasetNFSVersion); err != nil {
 		return err
 	}
+	if cfg.DatasetJobSpecYaml != "" {
+		if err := validateDatasetJobSpecYaml(cfg.DatasetJobSpecYaml); err != nil {
+			return err
+		}
+	}
+	cfg.blobCache, err = parseBlobCache(cfg)
+	if err != nil {
+		return err
+	}
+	cfg.jobLimits, err = parseJobLimits(cfg)
+	if err != nil {
+		return err
+	}
+	cfg.WarmupHostPath = strings.TrimSpace(cfg.WarmupHostPath)
+	if cfg.WarmupHostPath != "" && (!path.IsAbs(cfg.WarmupHostPath) || path.Clean(cfg.WarmupHostPath) == "/") {
+		return fmt.Errorf("invalid warmup_host_path %q, must be an absolute path other than /", cfg.WarmupHostPath)
+	}
+	for i, hostPath := range cfg.PodOverridesHostPaths {
+		if !path.IsAbs(hostPath) || path.Clean(hostPath) == "/" {
+			return fmt.Errorf("invalid pod_overrides_host_paths %q, must be an absolute path other than /", hostPath)
+		}
+		cfg.PodOverridesHostPaths[i] = path.Clean(hostPath)
+	}
 	config = cfg
 	return nil
 }
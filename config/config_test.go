@@ -1,10 +1,13 @@
 package config
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/BaizeAI/dataset/internal/pkg/blobcache"
 )
 
 func TestDatasetNFSVersion(t *testing.T) {
@@ -57,3 +60,80 @@ func TestParseConfigRejectsUnsupportedDatasetNFSVersion(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "unsupported dataset NFS version")
 }
+
+func TestBlobCache(t *testing.T) {
+	require.NoError(t, ParseConfigFromFileContent("enable_cascading_deletion: false"))
+	assert.Nil(t, GetBlobCache())
+
+	require.NoError(t, ParseConfigFromFileContent(`
+blob_cache_volume_yaml: |
+  persistentVolumeClaim:
+    claimName: dataset-blob-cache
+blob_cache_max_size: 500Gi
+blob_cache_link_mode: hardlink
+`))
+	cache := GetBlobCache()
+	require.NotNil(t, cache)
+	assert.Equal(t, "dataset-blob-cache", cache.Volume.PersistentVolumeClaim.ClaimName)
+	assert.Equal(t, int64(500<<30), cache.MaxSize)
+	assert.Equal(t, blobcache.LinkModeHardlink, cache.LinkMode)
+
+	for _, content := range []string{
+		"blob_cache_volume_yaml: \"claimName: dataset-blob-cache\"",
+		"blob_cache_volume_yaml: \"emptyDir: {}\"\nblob_cache_max_size: lots",
+		"blob_cache_volume_yaml: \"emptyDir: {}\"\nblob_cache_link_mode: symlink",
+	} {
+		assert.Error(t, ParseConfigFromFileContent(content), content)
+	}
+}
+
+func TestParseConfigValidatesDatasetJobSpecYaml(t *testing.T) {
+	require.NoError(t, ParseConfigFromFileContent(""))
+	require.NoError(t, validateDatasetJobSpecYaml(GetDatasetJobSpecYaml()))
+
+	for _, content := range []string{
+		"dataset_job_spec_yaml: \"template: {spec: {containers: []}}\"",
+		"dataset_job_spec_yaml: \"template: {spec: {containers: [{name: loader}]}}\"",
+		"dataset_job_spec_yaml: \"backoffLimit: many\"",
+	} {
+		assert.Error(t, ParseConfigFromFileContent(content), content)
+	}
+	require.NoError(t, ParseConfigFromFileContent("dataset_job_spec_yaml: \"template: {spec: {containers: [{image: loader}]}}\""))
+}
+
+func TestJobLimits(t *testing.T) {
+	require.NoError(t, ParseConfigFromFileContent(""))
+	assert.Equal(t, JobLimits{}, GetJobLimits())
+
+	require.NoError(t, ParseConfigFromFileContent(`
+max_concurrent_jobs: 20
+max_concurrent_jobs_per_namespace: 5
+max_concurrent_jobs_per_host: 4
+max_concurrent_jobs_per_type:
+  HUGGING_FACE: 2
+  GIT: 0
+`))
+	assert.Equal(t, JobLimits{Global: 20, PerNamespace: 5, PerHost: 4, PerType: map[string]int{"HUGGING_FACE": 2}}, GetJobLimits())
+
+	for _, content := range []string{
+		"max_concurrent_jobs: -1",
+		"max_concurrent_jobs_per_type: {S3: -1}",
+	} {
+		assert.Error(t, ParseConfigFromFileContent(content), content)
+	}
+}
+
+func TestWarmupHostPath(t *testing.T) {
+	t.Cleanup(func() { _ = ParseConfigFromFileContent("") })
+
+	require.NoError(t, ParseConfigFromFileContent(""))
+	assert.Empty(t, GetWarmupHostPath())
+
+	require.NoError(t, ParseConfigFromFileContent("warmup_host_path: /var/lib/datasets"))
+	assert.Equal(t, "/var/lib/datasets", GetWarmupHostPath())
+
+	for _, hostPath := range []string{"var/lib/datasets", "/"} {
+		assert.EqualError(t, ParseConfigFromFileContent("warmup_host_path: "+hostPath),
+			fmt.Sprintf("invalid warmup_host_path %q, must be an absolute path other than /", hostPath))
+	}
+}
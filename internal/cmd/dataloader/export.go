@@ -0,0 +1,61 @@
+package dataloader
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/BaizeAI/dataset/internal/pkg/datasources"
+	"github.com/BaizeAI/dataset/internal/pkg/jobresult"
+	"github.com/BaizeAI/dataset/pkg/log"
+)
+
+func newExportCommand() *cobra.Command {
+	flags := new(CommandFlags)
+
+	cmd := &cobra.Command{
+		Use:   fmt.Sprintf("export [%s|%s|%s|%s] <uri>", datasources.TypeS3, datasources.TypeGit, datasources.TypeHuggingFace, datasources.TypeModelScope),
+		Short: "Export the data of a dataset to a data source",
+	}
+
+	bindCommandFlags(cmd, flags)
+	cmd.Args = newCommandValidateArgsFunc(flags)
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		options, datasourceOptions, secrets, err := parseCommandFlags(flags, args)
+		if err != nil {
+			return err
+		}
+
+		return execExport(options, datasourceOptions, secrets, flags.TerminationMessagePath)
+	}
+
+	return cmd
+}
+
+func execExport(rawOptions map[string]string, datasourceOptions datasources.Options, secrets datasources.Secrets, terminationMessagePath string) error {
+	datasourceLoader, err := newLoader(rawOptions, datasourceOptions, secrets)
+	if err != nil {
+		return err
+	}
+
+	exporter, ok := datasourceLoader.(datasources.Exporter)
+	if !ok {
+		return fmt.Errorf("data source type %s does not support exporting", datasourceOptions.Type)
+	}
+
+	result, err := exporter.Export(filepath.Join(datasourceOptions.Root, datasourceOptions.Path), datasourceOptions.URI)
+	if err != nil {
+		return err
+	}
+
+	if terminationMessagePath != "" {
+		log.WithField("result", result).Info("reporting result of the export")
+		err = jobresult.Write(terminationMessagePath, result)
+		if err != nil {
+			log.Warnf("failed to write result to %s, err: %s", terminationMessagePath, err)
+		}
+	}
+
+	return nil
+}
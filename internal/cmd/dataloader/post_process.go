@@ -0,0 +1,82 @@
+package dataloader
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"github.com/BaizeAI/dataset/internal/pkg/jobresult"
+	"github.com/BaizeAI/dataset/internal/pkg/postprocess"
+	"github.com/BaizeAI/dataset/pkg/log"
+	"github.com/BaizeAI/dataset/pkg/utils"
+)
+
+type PostProcessCommandFlags struct {
+	MountPath string
+	MountMode string
+	MountUID  int
+	MountGID  int
+	MountRoot string
+	Steps     string
+
+	TerminationMessagePath string
+}
+
+func newPostProcessCommand() *cobra.Command {
+	flags := new(PostProcessCommandFlags)
+
+	cmd := &cobra.Command{
+		Use:   "post-process",
+		Short: "Run the post process steps on the loaded data of a dataset",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return execPostProcess(flags)
+		},
+	}
+
+	cmd.Flags().StringVar(&flags.MountPath, "mount-path", "", "Mount path of the dataset to process")
+	cmd.Flags().StringVar(&flags.MountMode, "mount-mode", "0755", "Mode of the processed files")
+	cmd.Flags().IntVar(&flags.MountUID, "mount-uid", 1000, "UID of the processed files")
+	cmd.Flags().IntVar(&flags.MountGID, "mount-gid", 1000, "GID of the processed files")
+	cmd.Flags().StringVar(&flags.MountRoot, "mount-root", "", "Mount root of the dataset to process")
+	cmd.Flags().StringVar(&flags.Steps, "steps", "", "JSON array of the post process steps to run in order")
+	cmd.Flags().StringVar(&flags.TerminationMessagePath, "termination-message-path", jobresult.DefaultPath, "Path to write the results of the steps to for the controller")
+
+	return cmd
+}
+
+func execPostProcess(flags *PostProcessCommandFlags) error {
+	var steps []postprocess.Step
+	if err := json.Unmarshal([]byte(flags.Steps), &steps); err != nil {
+		return fmt.Errorf("invalid steps %s: %w", flags.Steps, err)
+	}
+	fileMode, err := strconv.ParseUint(flags.MountMode, 8, 32)
+	if err != nil {
+		return err
+	}
+	root := filepath.Join(lo.CoalesceOrEmpty(flags.MountRoot, lo.Must(os.Getwd())), filepath.Join(".", flags.MountPath))
+
+	results, runErr := postprocess.Run(log.WithField("action", "post process"), root, steps)
+	if flags.TerminationMessagePath != "" {
+		// the results are reported even if a step failed
+		err = jobresult.Write(flags.TerminationMessagePath, jobresult.Result{PostProcess: results})
+		if err != nil {
+			log.Warnf("failed to write result to %s, err: %s", flags.TerminationMessagePath, err)
+		}
+	}
+	if runErr != nil {
+		return runErr
+	}
+
+	err = utils.ChmodAndChownRecursively(log.WithField("action", "post process"), root, flags.MountUID, flags.MountGID, os.FileMode(fileMode))
+	if err != nil {
+		return fmt.Errorf("failed to perform post chmod and chown operations, err: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,224 @@
+package dataloader
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/BaizeAI/dataset/internal/pkg/datasources"
+	"github.com/BaizeAI/dataset/internal/pkg/jobresult"
+	"github.com/BaizeAI/dataset/internal/pkg/manifest"
+	"github.com/BaizeAI/dataset/internal/pkg/replica"
+	"github.com/BaizeAI/dataset/pkg/log"
+)
+
+func newReplicaCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "replica",
+		Short: "Replicate datasets across clusters through a store",
+	}
+
+	cmd.AddCommand(newReplicaPublishCommand())
+	cmd.AddCommand(newReplicaHeadCommand())
+
+	return cmd
+}
+
+func validateReplicaStoreArgs(args []string) error {
+	if len(args) < 2 || args[0] == "" || args[1] == "" {
+		return fmt.Errorf("arguments <type> and <uri> are required")
+	}
+	if datasources.Type(args[0]) != datasources.TypeS3 {
+		return fmt.Errorf("replica store type %s is not supported, supported types are %s", args[0], datasources.TypeS3)
+	}
+
+	return nil
+}
+
+func newReplicaPublishCommand() *cobra.Command {
+	flags := new(CommandFlags)
+	var round int32
+	var digest string
+
+	cmd := &cobra.Command{
+		Use:   fmt.Sprintf("publish %s <uri>", datasources.TypeS3),
+		Short: "Publish a round of a dataset to a replica store",
+		Long: `Publish a round of a dataset to a replica store.
+
+The content is copied to rounds/<round> of the store along with its manifest,
+then the head of the store is pointed to the round.`,
+	}
+
+	bindCommandFlags(cmd, flags)
+	cmd.Flags().Int32Var(&round, "round", 0, "Round of the dataset to publish")
+	cmd.Flags().StringVar(&digest, "digest", "", "Digest of the content of the round")
+	cmd.Args = func(cmd *cobra.Command, args []string) error {
+		if err := validateReplicaStoreArgs(args); err != nil {
+			return err
+		}
+		if round <= 0 {
+			return fmt.Errorf("flag --round is required")
+		}
+
+		return nil
+	}
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		options, datasourceOptions, secrets, err := parseCommandFlags(flags, args)
+		if err != nil {
+			return err
+		}
+
+		return execReplicaPublish(options, datasourceOptions, secrets, round, digest, flags.TerminationMessagePath)
+	}
+
+	return cmd
+}
+
+// newStoreExporter returns the exporter of the store at the uri, which
+// copies into it or syncs it with the exported directory.
+func newStoreExporter(rawOptions map[string]string, datasourceOptions datasources.Options, secrets datasources.Secrets, uri string, syncMode string) (datasources.Exporter, error) {
+	options := make(map[string]string, len(rawOptions)+1)
+	for k, v := range rawOptions {
+		options[k] = v
+	}
+	options["syncMode"] = syncMode
+	// the uri is already in the store
+	delete(options, "replicaRound")
+	datasourceOptions.URI = uri
+
+	return datasources.NewS3Loader(options, datasourceOptions, secrets)
+}
+
+func execReplicaPublish(rawOptions map[string]string, datasourceOptions datasources.Options, secrets datasources.Secrets, round int32, digest string, terminationMessagePath string) error {
+	logger := log.WithField("action", "replica publish")
+	root := filepath.Join(datasourceOptions.Root, datasourceOptions.Path)
+	roundURI := replica.RoundURI(datasourceOptions.URI, round)
+
+	m, err := manifest.Build(root)
+	if err != nil {
+		return fmt.Errorf("failed to build the manifest of %s: %w", root, err)
+	}
+
+	// the content of the round is identical to the dataset
+	exporter, err := newStoreExporter(rawOptions, datasourceOptions, secrets, roundURI, "sync")
+	if err != nil {
+		return err
+	}
+	if _, err := exporter.Export(root, roundURI); err != nil {
+		return err
+	}
+
+	manifestDir, err := os.MkdirTemp("", "dataset-replica-manifest-")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.RemoveAll(manifestDir) }()
+	if err := manifest.Write(manifestDir, m); err != nil {
+		return err
+	}
+	manifestData, err := os.ReadFile(filepath.Join(manifestDir, manifest.Filename))
+	if err != nil {
+		return err
+	}
+	manifestSum := sha256.Sum256(manifestData)
+	exporter, err = newStoreExporter(rawOptions, datasourceOptions, secrets, roundURI, "copy")
+	if err != nil {
+		return err
+	}
+	if _, err := exporter.Export(manifestDir, roundURI); err != nil {
+		return err
+	}
+
+	// the head is written last, the secondary clusters only see complete rounds
+	headDir, err := os.MkdirTemp("", "dataset-replica-head-")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.RemoveAll(headDir) }()
+	err = replica.WriteHead(headDir, replica.Head{
+		Round:          round,
+		Digest:         digest,
+		ManifestSHA256: hex.EncodeToString(manifestSum[:]),
+		PublishTime:    time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	headURI := replica.HeadURI(datasourceOptions.URI)
+	exporter, err = newStoreExporter(rawOptions, datasourceOptions, secrets, headURI, "copy")
+	if err != nil {
+		return err
+	}
+	if _, err := exporter.Export(headDir, headURI); err != nil {
+		return err
+	}
+	logger.WithField("round", round).WithField("files", len(m.Files)).Info("published the round")
+
+	if terminationMessagePath != "" {
+		err = jobresult.Write(terminationMessagePath, jobresult.Result{Round: round, Digest: digest})
+		if err != nil {
+			// the round is published, the controller knows the round it asked for
+			logger.Warnf("failed to write result to %s, err: %s", terminationMessagePath, err)
+		}
+	}
+
+	return nil
+}
+
+func newReplicaHeadCommand() *cobra.Command {
+	flags := new(CommandFlags)
+
+	cmd := &cobra.Command{
+		Use:   fmt.Sprintf("head %s <uri>", datasources.TypeS3),
+		Short: "Report the last round published to a replica store",
+	}
+
+	bindCommandFlags(cmd, flags)
+	cmd.Args = func(cmd *cobra.Command, args []string) error {
+		return validateReplicaStoreArgs(args)
+	}
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		options, datasourceOptions, secrets, err := parseCommandFlags(flags, args)
+		if err != nil {
+			return err
+		}
+
+		return execReplicaHead(options, datasourceOptions, secrets, flags.TerminationMessagePath)
+	}
+
+	return cmd
+}
+
+func execReplicaHead(rawOptions map[string]string, datasourceOptions datasources.Options, secrets datasources.Secrets, terminationMessagePath string) error {
+	headDir, err := os.MkdirTemp("", "dataset-replica-head-")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.RemoveAll(headDir) }()
+
+	headURI := replica.HeadURI(datasourceOptions.URI)
+	loader, err := newStoreExporter(rawOptions, datasourceOptions, secrets, headURI, "copy")
+	if err != nil {
+		return err
+	}
+	if err := loader.Sync(headURI, headDir); err != nil {
+		return err
+	}
+	head, err := replica.ReadHead(headDir)
+	if errors.Is(err, os.ErrNotExist) {
+		// nothing is published yet, the round is 0
+		head = &replica.Head{}
+	} else if err != nil {
+		return fmt.Errorf("failed to read the head of %s: %w", datasourceOptions.URI, err)
+	}
+	log.WithField("action", "replica head").WithField("round", head.Round).Info("read the head of the store")
+
+	// the head is only reported by the result
+	return jobresult.Write(terminationMessagePath, jobresult.Result{Round: head.Round, Digest: head.Digest})
+}
@@ -11,8 +11,10 @@ import (
 	"github.com/samber/lo"
 	"github.com/spf13/cobra"
 
+	"github.com/BaizeAI/dataset/internal/pkg/blobcache"
 	"github.com/BaizeAI/dataset/internal/pkg/constants"
 	"github.com/BaizeAI/dataset/internal/pkg/datasources"
+	"github.com/BaizeAI/dataset/internal/pkg/jobresult"
 	"github.com/BaizeAI/dataset/pkg/log"
 	"github.com/BaizeAI/dataset/pkg/utils"
 )
@@ -26,23 +28,24 @@ func NewCommand() *cobra.Command {
 	}
 
 	flags := new(CommandFlags)
-
-	rootCmd.Flags().StringVar(&flags.MountPath, "mount-path", "", "Mount path for data source to copy to")
-	rootCmd.Flags().StringVar(&flags.MountMode, "mount-mode", "0755", "Mount mode for data source to copy to")
-	rootCmd.Flags().IntVar(&flags.MountUID, "mount-uid", 1000, "Mount UID for data source to copy to")
-	rootCmd.Flags().IntVar(&flags.MountGID, "mount-gid", 1000, "Mount GID for data source to copy to")
-	rootCmd.Flags().StringVar(&flags.MountRoot, "mount-root", "", "Mount root for data source to copy to")
-	rootCmd.Flags().StringVar(&flags.MountSecrets, "mount-secrets", constants.DatasetJobSecretsMountPath, "Mount secrets for data source to copy to")
-	rootCmd.Flags().StringArrayVarP(&flags.Options, "options", "o", []string{}, "Options for data source to copy from")
+	bindCommandFlags(rootCmd, flags)
+	bindBlobCacheFlags(rootCmd, flags)
 
 	rootCmd.Args = newCommandValidateArgsFunc(flags)
 	rootCmd.Run = newCommandRunEFunc(flags)
 
+	rootCmd.AddCommand(newUploadReceiverCommand())
+	rootCmd.AddCommand(newExportCommand())
+	rootCmd.AddCommand(newPostProcessCommand())
+	rootCmd.AddCommand(newVerifyCommand())
+	rootCmd.AddCommand(newReplicaCommand())
+	rootCmd.AddCommand(newWarmupCommand())
+
 	return rootCmd
 }
 
 var (
-	optionsRegexp = regexp.MustCompile(`^(\w+)=(.*)$`)
+	optionsRegexp = regexp.MustCompile(`^([\w.-]+)=(.*)$`)
 )
 
 type CommandFlags struct {
@@ -53,6 +56,108 @@ type CommandFlags struct {
 	MountRoot    string
 	MountSecrets string
 	Options      []string
+
+	TerminationMessagePath string
+
+	BlobCacheDir      string
+	BlobCacheMaxSize  int64
+	BlobCacheLinkMode string
+}
+
+func bindCommandFlags(cmd *cobra.Command, flags *CommandFlags) {
+	cmd.Flags().StringVar(&flags.MountPath, "mount-path", "", "Mount path for data source to copy to")
+	cmd.Flags().StringVar(&flags.MountMode, "mount-mode", "0755", "Mount mode for data source to copy to")
+	cmd.Flags().IntVar(&flags.MountUID, "mount-uid", 1000, "Mount UID for data source to copy to")
+	cmd.Flags().IntVar(&flags.MountGID, "mount-gid", 1000, "Mount GID for data source to copy to")
+	cmd.Flags().StringVar(&flags.MountRoot, "mount-root", "", "Mount root for data source to copy to")
+	cmd.Flags().StringVar(&flags.MountSecrets, "mount-secrets", constants.DatasetJobSecretsMountPath, "Mount secrets for data source to copy to")
+	cmd.Flags().StringArrayVarP(&flags.Options, "options", "o", []string{}, "Options for data source to copy from")
+	cmd.Flags().StringVar(&flags.TerminationMessagePath, "termination-message-path", jobresult.DefaultPath, "Path to write the result of the sync to for the controller")
+}
+
+func bindBlobCacheFlags(cmd *cobra.Command, flags *CommandFlags) {
+	cmd.Flags().StringVar(&flags.BlobCacheDir, "blob-cache", "", "Directory of the blob cache shared by datasets, disabled when empty")
+	cmd.Flags().Int64Var(&flags.BlobCacheMaxSize, "blob-cache-max-size", 0, "Size in bytes the blob cache is evicted to after the sync, 0 is unlimited")
+	cmd.Flags().StringVar(&flags.BlobCacheLinkMode, "blob-cache-link-mode", string(blobcache.LinkModeReflink), "How files are restored from the blob cache: reflink, hardlink or copy")
+}
+
+// newBlobCache opens the blob cache for the loader, a cache which fails to
+// open is not used.
+func newBlobCache(flags *CommandFlags, loader datasources.Loader) *blobcache.Cache {
+	cacheLoader, ok := loader.(datasources.BlobCacheLoader)
+	if flags.BlobCacheDir == "" || !ok {
+		return nil
+	}
+
+	linkMode, err := blobcache.ParseLinkMode(flags.BlobCacheLinkMode)
+	if err == nil {
+		var cache *blobcache.Cache
+		cache, err = blobcache.New(flags.BlobCacheDir, flags.BlobCacheMaxSize, linkMode)
+		if err == nil {
+			cacheLoader.SetBlobCache(cache)
+			return cache
+		}
+	}
+	log.Warnf("failed to open the blob cache at %s, it is not used: %s", flags.BlobCacheDir, err)
+
+	return nil
+}
+
+// parseCommandFlags parses the flags and the <type> <uri> arguments which are
+// validated by newCommandValidateArgsFunc.
+func parseCommandFlags(flags *CommandFlags, args []string) (map[string]string, datasources.Options, datasources.Secrets, error) {
+	flags.MountPath = filepath.Join(".", flags.MountPath)
+
+	if flags.MountRoot == "" {
+		flags.MountRoot = lo.Must(os.Getwd())
+	}
+
+	options := make(map[string]string)
+	for _, optionStr := range flags.Options {
+		option := optionsRegexp.FindStringSubmatch(optionStr)
+
+		if len(option) == 3 {
+			options[option[1]] = unquoteOptionValue(option[2])
+		} else {
+			options[option[1]] = ""
+		}
+	}
+
+	fileMode, err := strconv.ParseUint(flags.MountMode, 8, 32)
+	if err != nil {
+		return nil, datasources.Options{}, datasources.Secrets{}, err
+	}
+
+	datasourceOptions := datasources.Options{
+		Type: datasources.Type(args[0]),
+		URI:  args[1],
+		Path: flags.MountPath,
+		Root: flags.MountRoot,
+		UID:  flags.MountUID,
+		GID:  flags.MountGID,
+		Mode: os.FileMode(fileMode),
+	}
+
+	secrets, err := datasources.ReadAndParseSecrets(flags.MountSecrets)
+	if err != nil {
+		log.Warnf("failed to read and parse secrets from %s, err: %s", constants.DatasetJobSecretsMountPath, err)
+	}
+
+	return options, datasourceOptions, secrets, nil
+}
+
+// unquoteOptionValue reverts the quoting of the values with whitespaces by
+// the controller.
+func unquoteOptionValue(value string) string {
+	if len(value) < 2 || !strings.HasPrefix(value, `"`) || !strings.HasSuffix(value, `"`) {
+		return value
+	}
+	unquoted, err := strconv.Unquote(value)
+	if err != nil {
+		return value
+	}
+
+	return unquoted
 }
 
 func newCommandValidateArgsFunc(flags *CommandFlags) func(cmd *cobra.Command, args []string) error {
@@ -86,7 +191,7 @@ func execPostCopy(_ map[string]string, datasourceOptions datasources.Options, _
 	return nil
 }
 
-func execCopy(rawOptions map[string]string, datasourceOptions datasources.Options, secrets datasources.Secrets) error {
+func newLoader(rawOptions map[string]string, datasourceOptions datasources.Options, secrets datasources.Secrets) (datasources.Loader, error) {
 	var err error
 	var datasourceLoader datasources.Loader
 
@@ -94,96 +199,118 @@ func execCopy(rawOptions map[string]string, datasourceOptions datasources.Option
 	case datasources.TypeS3:
 		datasourceLoader, err = datasources.NewS3Loader(rawOptions, datasourceOptions, secrets)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	case datasources.TypeHTTP:
 		datasourceLoader, err = datasources.NewHTTPLoader(rawOptions, datasourceOptions, secrets)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	case datasources.TypeGit:
 		datasourceLoader, err = datasources.NewGitLoader(rawOptions, datasourceOptions, secrets)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	case datasources.TypeConda:
 		datasourceLoader, err = datasources.NewCondaLoader(rawOptions, datasourceOptions, secrets)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	case datasources.TypeHuggingFace:
 		datasourceLoader, err = datasources.NewHuggingFaceLoader(rawOptions, datasourceOptions, secrets)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	case datasources.TypeModelScope:
 		datasourceLoader, err = datasources.NewModelScopeLoader(rawOptions, datasourceOptions, secrets)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	case datasources.TypeDatabase:
 		datasourceLoader, err = datasources.NewModelDatabaseLoader(rawOptions, datasourceOptions, secrets)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	case datasources.TypeHadoop:
 		datasourceLoader, err = datasources.NewModelHadoopLoader(rawOptions, datasourceOptions, secrets)
 		if err != nil {
-			return err
+			return nil, err
+		}
+	case datasources.TypePixi:
+		datasourceLoader, err = datasources.NewPixiLoader(rawOptions, datasourceOptions, secrets)
+		if err != nil {
+			return nil, err
+		}
+	case datasources.TypePythonVenv:
+		datasourceLoader, err = datasources.NewPythonVenvLoader(rawOptions, datasourceOptions, secrets)
+		if err != nil {
+			return nil, err
+		}
+	case datasources.TypeOCI:
+		datasourceLoader, err = datasources.NewOCILoader(rawOptions, datasourceOptions, secrets)
+		if err != nil {
+			return nil, err
 		}
 	default:
-		return fmt.Errorf("data source type %s is not supported", datasourceOptions.Type)
+		return nil, fmt.Errorf("data source type %s is not supported", datasourceOptions.Type)
 	}
 
-	err = datasourceLoader.Sync(datasourceOptions.URI, datasourceOptions.Path)
+	return datasourceLoader, nil
+}
+
+func execCopy(rawOptions map[string]string, datasourceOptions datasources.Options, secrets datasources.Secrets, flags *CommandFlags) error {
+	datasourceLoader, err := newLoader(rawOptions, datasourceOptions, secrets)
 	if err != nil {
 		return err
 	}
 
-	return nil
-}
+	cache := newBlobCache(flags, datasourceLoader)
 
-func newCommandRunEFunc(flags *CommandFlags) func(cmd *cobra.Command, args []string) {
-	return func(cmd *cobra.Command, args []string) {
-		flags.MountPath = filepath.Join(".", flags.MountPath)
+	err = datasourceLoader.Sync(datasourceOptions.URI, datasourceOptions.Path)
+	if err != nil {
+		return err
+	}
 
-		if flags.MountRoot == "" {
-			flags.MountRoot = lo.Must(os.Getwd())
+	var result jobresult.Result
+	resultLoader, ok := datasourceLoader.(datasources.ResultLoader)
+	if ok {
+		result = resultLoader.Result()
+	}
+	if cache != nil {
+		if err := cache.Evict(); err != nil {
+			log.Warnf("failed to evict the blob cache at %s, err: %s", flags.BlobCacheDir, err)
 		}
-
-		options := make(map[string]string)
-		for _, optionStr := range flags.Options {
-			option := optionsRegexp.FindStringSubmatch(optionStr)
-
-			if len(option) == 3 {
-				options[option[1]] = option[2]
-			} else {
-				options[option[1]] = ""
-			}
+		stats := cache.Stats()
+		result.BlobCache = &jobresult.BlobCache{
+			Hits:         stats.Hits,
+			Misses:       stats.Misses,
+			HitBytes:     stats.HitBytes,
+			StoredBytes:  stats.StoredBytes,
+			EvictedBytes: stats.EvictedBytes,
 		}
+	}
 
-		fileMode, err := strconv.ParseUint(flags.MountMode, 8, 32)
+	if terminationMessagePath := flags.TerminationMessagePath; (ok || cache != nil) && terminationMessagePath != "" {
+		log.WithField("result", result).Info("reporting result of the sync")
+		err = jobresult.Write(terminationMessagePath, result)
 		if err != nil {
-			handleError(err)
-			return
+			// the data is loaded, only the result is lost, e.g. when running outside of a pod
+			log.Warnf("failed to write result to %s, err: %s", terminationMessagePath, err)
 		}
+	}
 
-		datasourceOptions := datasources.Options{
-			Type: datasources.Type(args[0]),
-			URI:  args[1],
-			Path: flags.MountPath,
-			Root: flags.MountRoot,
-			UID:  flags.MountUID,
-			GID:  flags.MountGID,
-			Mode: os.FileMode(fileMode),
-		}
+	return nil
+}
 
-		secrets, err := datasources.ReadAndParseSecrets(flags.MountSecrets)
+func newCommandRunEFunc(flags *CommandFlags) func(cmd *cobra.Command, args []string) {
+	return func(cmd *cobra.Command, args []string) {
+		options, datasourceOptions, secrets, err := parseCommandFlags(flags, args)
 		if err != nil {
-			log.Warnf("failed to read and parse secrets from %s, err: %s", constants.DatasetJobSecretsMountPath, err)
+			handleError(err)
+			return
 		}
 
-		err = execCopy(options, datasourceOptions, secrets)
+		err = execCopy(options, datasourceOptions, secrets, flags)
 		if err != nil {
 			handleError(err)
 		}
@@ -0,0 +1,56 @@
+package dataloader
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/BaizeAI/dataset/internal/pkg/upload"
+	"github.com/BaizeAI/dataset/pkg/log"
+)
+
+type uploadReceiverFlags struct {
+	Root   string
+	Listen string
+	UID    int
+	GID    int
+}
+
+func newUploadReceiverCommand() *cobra.Command {
+	flags := new(uploadReceiverFlags)
+
+	cmd := &cobra.Command{
+		Use:   "upload-receiver",
+		Short: "Receive the uploads of a MANUAL dataset into its volume",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if flags.Root == "" {
+				return fmt.Errorf("flag --root is required")
+			}
+
+			receiver, err := upload.NewReceiver(flags.Root, os.Getenv(upload.TokenEnv), flags.UID, flags.GID)
+			if err != nil {
+				return err
+			}
+
+			log.Infof("receiving uploads into %s on %s", flags.Root, flags.Listen)
+			server := &http.Server{
+				Addr:              flags.Listen,
+				Handler:           receiver.Handler(),
+				ReadHeaderTimeout: 30 * time.Second,
+			}
+
+			return server.ListenAndServe()
+		},
+	}
+
+	cmd.Flags().StringVar(&flags.Root, "root", "", "Directory of the dataset to write the uploads to")
+	cmd.Flags().StringVar(&flags.Listen, "listen", fmt.Sprintf(":%d", upload.ReceiverPort), "Address to listen on")
+	cmd.Flags().IntVar(&flags.UID, "uid", 1000, "UID of the uploaded files")
+	cmd.Flags().IntVar(&flags.GID, "gid", 1000, "GID of the uploaded files")
+
+	return cmd
+}
@@ -0,0 +1,108 @@
+package dataloader
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/samber/lo"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/BaizeAI/dataset/internal/pkg/jobresult"
+	"github.com/BaizeAI/dataset/internal/pkg/manifest"
+	"github.com/BaizeAI/dataset/pkg/log"
+)
+
+// maxReportedDriftsSize keeps the drifted files reported within the size
+// limit of the termination message.
+const maxReportedDriftsSize = 3000
+
+type VerifyCommandFlags struct {
+	MountPath   string
+	MountRoot   string
+	Record      bool
+	FailOnDrift bool
+
+	TerminationMessagePath string
+}
+
+func newVerifyCommand() *cobra.Command {
+	flags := new(VerifyCommandFlags)
+
+	cmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Verify the data of a dataset against the manifest recorded when it was loaded",
+		Long: fmt.Sprintf(`Verify the data of a dataset against the manifest recorded when it was loaded.
+
+The manifest is %s at the root of the dataset, it lists the size and the SHA256
+of every file. When there is no manifest, the data is recorded as the manifest.`, manifest.Filename),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return execVerify(flags)
+		},
+	}
+
+	cmd.Flags().StringVar(&flags.MountPath, "mount-path", "", "Mount path of the dataset to verify")
+	cmd.Flags().StringVar(&flags.MountRoot, "mount-root", "", "Mount root of the dataset to verify")
+	cmd.Flags().BoolVar(&flags.Record, "record", false, "Record the data as the manifest instead of verifying it")
+	cmd.Flags().BoolVar(&flags.FailOnDrift, "fail-on-drift", true, "Exit with an error when files drifted from the manifest")
+	cmd.Flags().StringVar(&flags.TerminationMessagePath, "termination-message-path", jobresult.DefaultPath, "Path to write the result of the verification to for the controller")
+
+	return cmd
+}
+
+func execVerify(flags *VerifyCommandFlags) error {
+	logger := log.WithField("action", "verify")
+	root := filepath.Join(lo.CoalesceOrEmpty(flags.MountRoot, lo.Must(os.Getwd())), filepath.Join(".", flags.MountPath))
+
+	recorded, err := manifest.Read(root)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	actual, err := manifest.Build(root)
+	if err != nil {
+		return fmt.Errorf("failed to build the manifest of %s: %w", root, err)
+	}
+
+	verification := &jobresult.Verification{Files: len(actual.Files)}
+	if flags.Record || recorded == nil {
+		if err := manifest.Write(root, actual); err != nil {
+			return fmt.Errorf("failed to write the manifest: %w", err)
+		}
+		logger.WithField("files", len(actual.Files)).Info("recorded the manifest")
+		verification.Recorded = true
+	} else {
+		drifts := manifest.Compare(recorded, actual)
+		verification.DriftedCount = len(drifts)
+		var size int
+		for _, drift := range drifts {
+			logger.WithFields(logrus.Fields{
+				"path":   drift.Path,
+				"reason": drift.Reason,
+			}).Warn("file drifted from the manifest")
+
+			size += len(drift.Path) + len(drift.Reason)
+			if size <= maxReportedDriftsSize {
+				verification.Drifted = append(verification.Drifted, jobresult.DriftedFile{Path: drift.Path, Reason: drift.Reason})
+			}
+		}
+		logger.WithFields(logrus.Fields{
+			"files":   len(actual.Files),
+			"drifted": len(drifts),
+		}).Info("verified the manifest")
+	}
+
+	if flags.TerminationMessagePath != "" {
+		err = jobresult.Write(flags.TerminationMessagePath, jobresult.Result{Verification: verification})
+		if err != nil {
+			logger.Warnf("failed to write result to %s, err: %s", flags.TerminationMessagePath, err)
+		}
+	}
+	if flags.FailOnDrift && verification.DriftedCount > 0 {
+		return fmt.Errorf("%d files drifted from the manifest", verification.DriftedCount)
+	}
+
+	return nil
+}
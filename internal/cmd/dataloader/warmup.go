@@ -0,0 +1,83 @@
+package dataloader
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/samber/lo"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/BaizeAI/dataset/internal/pkg/jobresult"
+	"github.com/BaizeAI/dataset/internal/pkg/warmup"
+	"github.com/BaizeAI/dataset/pkg/log"
+)
+
+type WarmupCommandFlags struct {
+	MountPath string
+	MountRoot string
+	Target    string
+	UID       int
+	GID       int
+
+	TerminationMessagePath string
+}
+
+func newWarmupCommand() *cobra.Command {
+	flags := new(WarmupCommandFlags)
+
+	cmd := &cobra.Command{
+		Use:   "warmup",
+		Short: "Copy the data of a dataset onto the local storage of a node",
+		Long: `Copy the data of a dataset onto the local storage of a node.
+
+The files already in the target with the same size and modification time are
+not copied again, the files of the target which are not in the dataset are
+removed.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return execWarmup(flags)
+		},
+	}
+
+	cmd.Flags().StringVar(&flags.MountPath, "mount-path", "", "Mount path of the dataset to copy")
+	cmd.Flags().StringVar(&flags.MountRoot, "mount-root", "", "Mount root of the dataset to copy")
+	cmd.Flags().StringVar(&flags.Target, "target", "", "Directory on the node to copy the dataset into")
+	cmd.Flags().IntVar(&flags.UID, "uid", -1, "UID of the copied files, -1 keeps the current user")
+	cmd.Flags().IntVar(&flags.GID, "gid", -1, "GID of the copied files, -1 keeps the current group")
+	cmd.Flags().StringVar(&flags.TerminationMessagePath, "termination-message-path", jobresult.DefaultPath, "Path to write the result of the copy to for the controller")
+	_ = cmd.MarkFlagRequired("target")
+
+	return cmd
+}
+
+func execWarmup(flags *WarmupCommandFlags) error {
+	logger := log.WithField("action", "warmup")
+	root := filepath.Join(lo.CoalesceOrEmpty(flags.MountRoot, lo.Must(os.Getwd())), filepath.Join(".", flags.MountPath))
+
+	stats, err := warmup.Mirror(logger, root, flags.Target, warmup.Options{UID: flags.UID, GID: flags.GID})
+	if err != nil {
+		return fmt.Errorf("failed to copy %s to %s: %w", root, flags.Target, err)
+	}
+	logger.WithFields(logrus.Fields{
+		"files":        stats.Files,
+		"copiedFiles":  stats.CopiedFiles,
+		"copiedBytes":  stats.CopiedBytes,
+		"removedFiles": stats.RemovedFiles,
+	}).Info("copied the dataset")
+
+	if flags.TerminationMessagePath != "" {
+		err = jobresult.Write(flags.TerminationMessagePath, jobresult.Result{Warmup: &jobresult.Warmup{
+			Files:        stats.Files,
+			CopiedFiles:  stats.CopiedFiles,
+			CopiedBytes:  stats.CopiedBytes,
+			RemovedFiles: stats.RemovedFiles,
+		}})
+		if err != nil {
+			logger.Warnf("failed to write result to %s, err: %s", flags.TerminationMessagePath, err)
+		}
+	}
+
+	return nil
+}
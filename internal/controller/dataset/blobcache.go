@@ -0,0 +1,122 @@
+package dataset
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	datasetv1alpha1 "github.com/BaizeAI/dataset/api/dataset/v1alpha1"
+	"github.com/BaizeAI/dataset/config"
+	"github.com/BaizeAI/dataset/internal/pkg/constants"
+	"github.com/BaizeAI/dataset/internal/pkg/jobresult"
+)
+
+const blobCacheVolumeName = "dataset-blob-cache"
+
+var (
+	blobCacheHits = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "dataset_blob_cache_hits_total",
+		Help: "Number of files restored from the blob cache by the data loader jobs.",
+	}, []string{"type"})
+	blobCacheMisses = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "dataset_blob_cache_misses_total",
+		Help: "Number of files the data loader jobs did not find in the blob cache.",
+	}, []string{"type"})
+	blobCacheHitBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "dataset_blob_cache_hit_bytes_total",
+		Help: "Size of the files restored from the blob cache by the data loader jobs.",
+	}, []string{"type"})
+	blobCacheStoredBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "dataset_blob_cache_stored_bytes_total",
+		Help: "Size of the files stored into the blob cache by the data loader jobs.",
+	}, []string{"type"})
+	blobCacheEvictedBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "dataset_blob_cache_evicted_bytes_total",
+		Help: "Size of the blobs evicted from the blob cache by the data loader jobs.",
+	}, []string{"type"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		blobCacheHits,
+		blobCacheMisses,
+		blobCacheHitBytes,
+		blobCacheStoredBytes,
+		blobCacheEvictedBytes,
+	)
+}
+
+// supportBlobCache returns whether the data loader of the type stores the
+// files it loads into the blob cache.
+func supportBlobCache(sourceType datasetv1alpha1.DatasetType) bool {
+	switch sourceType {
+	case datasetv1alpha1.DatasetTypeHuggingFace,
+		datasetv1alpha1.DatasetTypeModelScope,
+		datasetv1alpha1.DatasetTypeS3,
+		datasetv1alpha1.DatasetTypeConda:
+		return true
+	default:
+		return false
+	}
+}
+
+// withBlobCache mounts the blob cache configured for the controller into the
+// data loader container loading a source of the type, the volume is added to
+// the pod once for all its containers.
+func withBlobCache(podSpec *corev1.PodSpec, container *corev1.Container, sourceType datasetv1alpha1.DatasetType) {
+	cache := config.GetBlobCache()
+	if cache == nil || !supportBlobCache(sourceType) {
+		return
+	}
+
+	hasVolume := false
+	for _, volume := range podSpec.Volumes {
+		if volume.Name == blobCacheVolumeName {
+			hasVolume = true
+			break
+		}
+	}
+	if !hasVolume {
+		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+			Name:         blobCacheVolumeName,
+			VolumeSource: *cache.Volume.DeepCopy(),
+		})
+	}
+
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+		Name:      blobCacheVolumeName,
+		MountPath: constants.DatasetJobBlobCacheMountPath,
+	})
+	container.Args = append(container.Args,
+		fmt.Sprintf("--blob-cache=%s", constants.DatasetJobBlobCacheMountPath),
+		fmt.Sprintf("--blob-cache-max-size=%d", cache.MaxSize),
+		fmt.Sprintf("--blob-cache-link-mode=%s", cache.LinkMode),
+	)
+}
+
+// recordBlobCacheMetrics records what the blob cache did for the data loader
+// containers of the job by their results.
+func recordBlobCacheMetrics(ds *datasetv1alpha1.Dataset, results map[string]jobresult.Result) {
+	types := map[string]datasetv1alpha1.DatasetType{
+		"dataset-loader": ds.Spec.Source.Type,
+	}
+	for _, source := range ds.Spec.Sources {
+		types[sourceContainerName(source.Name)] = source.Type
+	}
+
+	for name, result := range results {
+		sourceType, ok := types[name]
+		if !ok || result.BlobCache == nil {
+			continue
+		}
+
+		label := string(sourceType)
+		blobCacheHits.WithLabelValues(label).Add(float64(result.BlobCache.Hits))
+		blobCacheMisses.WithLabelValues(label).Add(float64(result.BlobCache.Misses))
+		blobCacheHitBytes.WithLabelValues(label).Add(float64(result.BlobCache.HitBytes))
+		blobCacheStoredBytes.WithLabelValues(label).Add(float64(result.BlobCache.StoredBytes))
+		blobCacheEvictedBytes.WithLabelValues(label).Add(float64(result.BlobCache.EvictedBytes))
+	}
+}
@@ -0,0 +1,135 @@
+package dataset
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	datasetv1alpha1 "github.com/BaizeAI/dataset/api/dataset/v1alpha1"
+	"github.com/BaizeAI/dataset/config"
+	"github.com/BaizeAI/dataset/internal/pkg/jobresult"
+)
+
+func TestDatasetReconciler_reconcileJobWithBlobCache(t *testing.T) {
+	require.NoError(t, config.ParseConfigFromFileContent(`
+blob_cache_volume_yaml: |
+  persistentVolumeClaim:
+    claimName: dataset-blob-cache
+blob_cache_max_size: 1Gi
+`))
+	t.Cleanup(func() { _ = config.ParseConfigFromFileContent("") })
+
+	ds := &datasetv1alpha1.Dataset{
+		ObjectMeta: metav1.ObjectMeta{Name: "training", Namespace: "default"},
+		Spec: datasetv1alpha1.DatasetSpec{
+			Sources: []datasetv1alpha1.DatasetSubSource{
+				{Name: "model", Type: datasetv1alpha1.DatasetTypeHuggingFace, URI: "huggingface://ns/model", Path: "model"},
+				{Name: "configs", Type: datasetv1alpha1.DatasetTypeGit, URI: "https://github.com/example/configs.git", Path: "configs"},
+				{Name: "eval", Type: datasetv1alpha1.DatasetTypeS3, URI: "s3://bucket/eval", Path: "eval"},
+			},
+			DataSyncRound: 1,
+		},
+		Status: datasetv1alpha1.DatasetStatus{PVCName: "training"},
+	}
+	c := newReplicaTestClient(t)
+	r := &DatasetReconciler{Client: c, Scheme: c.Scheme()}
+	ctx := context.Background()
+
+	require.NoError(t, r.reconcileJob(ctx, ds))
+	job := &batchv1.Job{}
+	require.NoError(t, c.Get(ctx, types.NamespacedName{Namespace: "default", Name: genJobName(ds.Name, 1)}, job))
+
+	// the volume is shared by the loaders supporting the cache
+	podSpec := job.Spec.Template.Spec
+	volumes := 0
+	for _, volume := range podSpec.Volumes {
+		if volume.Name == blobCacheVolumeName {
+			volumes++
+			assert.Equal(t, "dataset-blob-cache", volume.PersistentVolumeClaim.ClaimName)
+		}
+	}
+	assert.Equal(t, 1, volumes)
+
+	mount := corev1.VolumeMount{Name: blobCacheVolumeName, MountPath: "/run/dataset/blob-cache"}
+	args := []string{
+		"--blob-cache=/run/dataset/blob-cache",
+		"--blob-cache-max-size=1073741824",
+		"--blob-cache-link-mode=reflink",
+	}
+	assert.Contains(t, podSpec.InitContainers[0].VolumeMounts, mount)
+	assert.Subset(t, podSpec.InitContainers[0].Args, args)
+	assert.NotContains(t, podSpec.InitContainers[1].VolumeMounts, mount)
+	assert.NotContains(t, podSpec.InitContainers[1].Args, args[0])
+	assert.Contains(t, podSpec.Containers[0].VolumeMounts, mount)
+	assert.Subset(t, podSpec.Containers[0].Args, args)
+}
+
+func TestRecordBlobCacheMetrics(t *testing.T) {
+	ds := &datasetv1alpha1.Dataset{
+		Spec: datasetv1alpha1.DatasetSpec{
+			Source: datasetv1alpha1.DatasetSource{Type: datasetv1alpha1.DatasetTypeConda},
+		},
+	}
+	hits := testutil.ToFloat64(blobCacheHits.WithLabelValues("CONDA"))
+	hitBytes := testutil.ToFloat64(blobCacheHitBytes.WithLabelValues("CONDA"))
+
+	recordBlobCacheMetrics(ds, map[string]jobresult.Result{
+		"dataset-loader": {BlobCache: &jobresult.BlobCache{Hits: 3, Misses: 1, HitBytes: 300}},
+	})
+	assert.InDelta(t, hits+3, testutil.ToFloat64(blobCacheHits.WithLabelValues("CONDA")), 0)
+	assert.InDelta(t, hitBytes+300, testutil.ToFloat64(blobCacheHitBytes.WithLabelValues("CONDA")), 0)
+}
+
+func TestDatasetReconciler_reconcileJobStatusRecordsBlobCacheOnce(t *testing.T) {
+	ds := &datasetv1alpha1.Dataset{
+		ObjectMeta: metav1.ObjectMeta{Name: "cached", Namespace: "default"},
+		Spec: datasetv1alpha1.DatasetSpec{
+			Source:        datasetv1alpha1.DatasetSource{Type: datasetv1alpha1.DatasetTypeModelScope, URI: "modelscope://org/model"},
+			DataSyncRound: 1,
+		},
+		Status: datasetv1alpha1.DatasetStatus{InProcessing: true, InProcessingRound: 1},
+	}
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: genJobName(ds.Name, 1), Namespace: "default"},
+		Status:     batchv1.JobStatus{Succeeded: 1},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      job.Name + "-abcde",
+			Namespace: "default",
+			Labels:    map[string]string{batchv1.JobNameLabel: job.Name},
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name: "dataset-loader",
+				State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+					Message: `{"blobCache":{"hits":2,"misses":1}}`,
+				}},
+			}},
+		},
+	}
+	c := newReplicaTestClient(t, ds, job, pod)
+	r := &DatasetReconciler{Client: c, Scheme: c.Scheme()}
+	ctx := context.Background()
+	hits := testutil.ToFloat64(blobCacheHits.WithLabelValues("MODEL_SCOPE"))
+
+	require.NoError(t, r.reconcileJobStatus(ctx, ds))
+	assert.InDelta(t, hits+2, testutil.ToFloat64(blobCacheHits.WithLabelValues("MODEL_SCOPE")), 0)
+	require.Len(t, ds.Status.SyncRoundStatuses, 1)
+	assert.True(t, ds.Status.SyncRoundStatuses[0].BlobCacheRecorded)
+
+	// the status of the job is handled again by another controller, e.g.
+	// after a restart, with the status recorded before
+	ds.Status.InProcessing = true
+	ds.Status.InProcessingRound = 1
+	r = &DatasetReconciler{Client: c, Scheme: c.Scheme()}
+	require.NoError(t, r.reconcileJobStatus(ctx, ds))
+	assert.InDelta(t, hits+2, testutil.ToFloat64(blobCacheHits.WithLabelValues("MODEL_SCOPE")), 0)
+}
@@ -35,6 +35,12 @@ func (r *DatasetReconciler) getConfigMap(ctx context.Context, ds *datasetv1alpha
 type condaOptions struct {
 	environmentYAML *string
 	requirementsTxt *string
+	condaLockYAML   *string
+	explicitTxt     *string
+	pixiToml        *string
+	pixiLock        *string
+	pyprojectToml   *string
+	uvLock          *string
 }
 
 type condaOption func(*condaOptions)
@@ -51,6 +57,42 @@ func withPipRequirementsTxt(txt string) condaOption {
 	}
 }
 
+func withCondaLockYAML(yaml string) condaOption {
+	return func(o *condaOptions) {
+		o.condaLockYAML = &yaml
+	}
+}
+
+func withCondaExplicitTxt(txt string) condaOption {
+	return func(o *condaOptions) {
+		o.explicitTxt = &txt
+	}
+}
+
+func withPixiToml(toml string) condaOption {
+	return func(o *condaOptions) {
+		o.pixiToml = &toml
+	}
+}
+
+func withPixiLock(lock string) condaOption {
+	return func(o *condaOptions) {
+		o.pixiLock = &lock
+	}
+}
+
+func withPyprojectToml(toml string) condaOption {
+	return func(o *condaOptions) {
+		o.pyprojectToml = &toml
+	}
+}
+
+func withUvLock(lock string) condaOption {
+	return func(o *condaOptions) {
+		o.uvLock = &lock
+	}
+}
+
 func (r *DatasetReconciler) createConfigMap(ctx context.Context, ds *datasetv1alpha1.Dataset, opts ...condaOption) (*corev1.ConfigMap, error) {
 	defaultOpts := new(condaOptions)
 	for _, opt := range opts {
@@ -74,6 +116,24 @@ func (r *DatasetReconciler) createConfigMap(ctx context.Context, ds *datasetv1al
 	if defaultOpts.requirementsTxt != nil {
 		cm.Data[constants.DatasetJobCondaPipRequirementsTxtFilename] = *defaultOpts.requirementsTxt
 	}
+	if defaultOpts.condaLockYAML != nil {
+		cm.Data[constants.DatasetJobCondaLockYAMLFilename] = *defaultOpts.condaLockYAML
+	}
+	if defaultOpts.explicitTxt != nil {
+		cm.Data[constants.DatasetJobCondaExplicitTxtFilename] = *defaultOpts.explicitTxt
+	}
+	if defaultOpts.pixiToml != nil {
+		cm.Data[constants.DatasetJobPixiManifestFilename] = *defaultOpts.pixiToml
+	}
+	if defaultOpts.pixiLock != nil {
+		cm.Data[constants.DatasetJobPixiLockFilename] = *defaultOpts.pixiLock
+	}
+	if defaultOpts.pyprojectToml != nil {
+		cm.Data[constants.DatasetJobPythonVenvPyprojectTomlFilename] = *defaultOpts.pyprojectToml
+	}
+	if defaultOpts.uvLock != nil {
+		cm.Data[constants.DatasetJobPythonVenvUvLockFilename] = *defaultOpts.uvLock
+	}
 
 	err := r.Create(ctx, cm)
 	if err != nil {
@@ -98,6 +158,24 @@ func (r *DatasetReconciler) updateConfigMap(ctx context.Context, cm *corev1.Conf
 	if defaultOpts.requirementsTxt != nil {
 		cm.Data[constants.DatasetJobCondaPipRequirementsTxtFilename] = *defaultOpts.requirementsTxt
 	}
+	if defaultOpts.condaLockYAML != nil {
+		cm.Data[constants.DatasetJobCondaLockYAMLFilename] = *defaultOpts.condaLockYAML
+	}
+	if defaultOpts.explicitTxt != nil {
+		cm.Data[constants.DatasetJobCondaExplicitTxtFilename] = *defaultOpts.explicitTxt
+	}
+	if defaultOpts.pixiToml != nil {
+		cm.Data[constants.DatasetJobPixiManifestFilename] = *defaultOpts.pixiToml
+	}
+	if defaultOpts.pixiLock != nil {
+		cm.Data[constants.DatasetJobPixiLockFilename] = *defaultOpts.pixiLock
+	}
+	if defaultOpts.pyprojectToml != nil {
+		cm.Data[constants.DatasetJobPythonVenvPyprojectTomlFilename] = *defaultOpts.pyprojectToml
+	}
+	if defaultOpts.uvLock != nil {
+		cm.Data[constants.DatasetJobPythonVenvUvLockFilename] = *defaultOpts.uvLock
+	}
 
 	err := r.Update(ctx, cm)
 	if err != nil {
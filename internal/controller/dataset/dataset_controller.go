@@ -26,7 +26,6 @@ import (
 	"time"
 
 	"github.com/BaizeAI/dataset/pkg/kubeutils"
-	"k8s.io/apimachinery/pkg/labels"
 
 	"github.com/samber/lo"
 	batchv1 "k8s.io/api/batch/v1"
@@ -37,6 +36,7 @@ import (
 
 	"github.com/BaizeAI/dataset/config"
 	"github.com/BaizeAI/dataset/internal/pkg/constants"
+	"github.com/BaizeAI/dataset/internal/pkg/jobresult"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -44,7 +44,10 @@ import (
 
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	datasetv1alpha1 "github.com/BaizeAI/dataset/api/dataset/v1alpha1"
 )
@@ -58,6 +61,14 @@ const (
 	condTypeJobStatus = "JobStatus"
 	condTypeJob       = "Job"
 	condTypeConfigMap = "ConfigMap"
+	condTypeDeletion  = "Deletion"
+
+	// datasetPVCMountPath is where the pvc of the dataset is mounted in the
+	// pods of the data loader.
+	datasetPVCMountPath = "/baize/dataset/data"
+	// defaultPVCSize is the storage size of the pvcs of the datasets whose
+	// volumeClaimTemplate requests none.
+	defaultPVCSize = "100Ti"
 
 	nfsPersistentVolumeTemplate = `
 apiVersion: v1
@@ -81,6 +92,8 @@ spec:
 type DatasetReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+
+	queue jobQueue
 }
 
 type reconciler struct {
@@ -94,6 +107,8 @@ type reconciler struct {
 //+kubebuilder:rbac:groups="",resources=persistentvolumes,verbs=get;list;watch;delete
 //+kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
+//+kubebuilder:rbac:groups=dataset.baizeai.io,resources=datasetsharegrants,verbs=get;list;watch
 
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.16.3/pkg/reconcile
@@ -109,7 +124,7 @@ func (r *DatasetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 	var reconcilers []reconciler
 	if kubeutils.IsDeleted(ds) {
 		reconcilers = []reconciler{
-			{typ: "CascadingDeletion", rec: r.reconcileCascadingDeletion},
+			{typ: condTypeDeletion, rec: r.reconcileCascadingDeletion},
 			// {typ: "Job", rec: r.reconcileJob},  // 同样可以加上清理 job 的逻辑
 			{typ: condTypePVC, rec: r.reconcilePVC},
 			{typ: "", rec: r.reconcileFinalizer},
@@ -117,11 +132,21 @@ func (r *DatasetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 	} else {
 		reconcilers = []reconciler{
 			{typ: condTypeConfig, rec: r.validate},
+			// the Policy condition is only set when any DatasetPolicy is applied
+			{typ: "", rec: r.reconcilePolicy},
+			// the Share condition is only set on REFERENCE datasets
+			{typ: "", rec: r.reconcileShareAccess},
+			{typ: "", rec: r.reconcileSourceStatus},
+			{typ: "", rec: r.reconcileConsumers},
 			{typ: "", rec: r.reconcileFinalizer},
 			{typ: condTypePVC, rec: r.reconcilePVC},
 			{typ: condTypeConfigMap, rec: r.reconcileConfigMap},
 			{typ: condTypeJob, rec: r.reconcileJob},
 			{typ: condTypeJobStatus, rec: r.reconcileJobStatus},
+			// the Verified condition is only set by the outcome of the verifications
+			{typ: "", rec: r.reconcileVerification},
+			// the WarmedUp condition is only set when warmup is set
+			{typ: "", rec: r.reconcileWarmup},
 		}
 	}
 
@@ -153,10 +178,22 @@ func (r *DatasetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 	}
 
 	switch ds.Status.Phase {
-	case datasetv1alpha1.DatasetStatusPhaseReady, datasetv1alpha1.DatasetStatusPhaseFailed:
+	case datasetv1alpha1.DatasetStatusPhaseReady:
+		requeueAfter := verifyRequeueAfter(ds)
+		if warmupRequeueAfter := warmupRequeueAfter(ds); warmupRequeueAfter > 0 && (requeueAfter == 0 || warmupRequeueAfter < requeueAfter) {
+			requeueAfter = warmupRequeueAfter
+		}
+		if requeueAfter > 0 {
+			return ctrl.Result{RequeueAfter: requeueAfter}, nil
+		}
+		return resOk, nil
+	case datasetv1alpha1.DatasetStatusPhaseFailed:
 		return resOk, nil
 	case datasetv1alpha1.DatasetStatusPhaseProcessing:
 		return res5sec, nil
+	case datasetv1alpha1.DatasetStatusPhaseQueued:
+		// the queue positions are refreshed as the other jobs are done
+		return ctrl.Result{RequeueAfter: queueRequeueAfter}, nil
 	default:
 		return res30sec, nil
 	}
@@ -172,6 +209,9 @@ func (r *DatasetReconciler) updateStatus(ctx context.Context, ds *datasetv1alpha
 }
 
 func supportPreload(ds *datasetv1alpha1.Dataset) bool {
+	if len(ds.Spec.Sources) > 0 {
+		return true
+	}
 	switch ds.Spec.Source.Type {
 	case datasetv1alpha1.DatasetTypeGit,
 		datasetv1alpha1.DatasetTypeS3,
@@ -180,7 +220,10 @@ func supportPreload(ds *datasetv1alpha1.Dataset) bool {
 		datasetv1alpha1.DatasetTypeHuggingFace,
 		datasetv1alpha1.DatasetTypeModelScope,
 		datasetv1alpha1.DatasetTypeDatabase,
-		datasetv1alpha1.DatasetTypeHadoop:
+		datasetv1alpha1.DatasetTypeHadoop,
+		datasetv1alpha1.DatasetTypePixi,
+		datasetv1alpha1.DatasetTypePythonVenv,
+		datasetv1alpha1.DatasetTypeOCI:
 		return true
 	default:
 		return false
@@ -229,7 +272,7 @@ func (r *DatasetReconciler) reconcilePVC(ctx context.Context, ds *datasetv1alpha
 	case datasetv1alpha1.DatasetTypeReference:
 		if kubeutils.IsDeleted(ds) {
 			// Enhanced cleanup for reference datasets - also handle retained PVs
-			if config.IsCascadingDeletionEnabled() {
+			if deletionPolicy(ds) != datasetv1alpha1.DatasetDeletionPolicyOrphan {
 				// Find and delete the associated retained PV
 				if err := r.cleanupRetainedPV(ctx, ds); err != nil {
 					log.Errorf("Failed to cleanup retained PV for dataset %s/%s: %v", ds.Namespace, ds.Name, err)
@@ -267,8 +310,18 @@ func (r *DatasetReconciler) reconcilePVC(ctx context.Context, ds *datasetv1alpha
 			return fmt.Errorf("get pv %s for source dataset %s/%s error: %v",
 				pvc.Spec.VolumeName, srcDs.Namespace, srcDs.Name, err)
 		}
+		_, _, subPath, err := parseReferenceURI(ds.Spec.Source.URI)
+		if err != nil {
+			return err
+		}
 		// 克隆一个新的 pv 给当前 ds
 		newPv := pv.DeepCopy()
+		// the references of the whole source dataset get the same pv as it
+		if subPath != "" {
+			if err := pointPVAtSubPath(newPv, referenceSubPath(srcDs, subPath)); err != nil {
+				return fmt.Errorf("reference source dataset %s/%s error: %v", srcDs.Namespace, srcDs.Name, err)
+			}
+		}
 		newPv.OwnerReferences = datasetOwnerRef(ds)
 		newPv.Name = fmt.Sprintf("dataset-%s-%s-%s", ds.Namespace, ds.Name, ds.UID[:12])
 		if newPv.Labels == nil {
@@ -463,7 +516,7 @@ func (r *DatasetReconciler) reconcilePVC(ctx context.Context, ds *datasetv1alpha
 		}
 		quantity := spec.Resources.Requests[corev1.ResourceStorage]
 		if quantity.IsZero() {
-			spec.Resources.Requests[corev1.ResourceStorage] = resource.MustParse("100Ti")
+			spec.Resources.Requests[corev1.ResourceStorage] = resource.MustParse(defaultPVCSize)
 		}
 		if forceStorageClass != "" {
 			// nfs 强制使用 nfs storageclass
@@ -517,7 +570,11 @@ func (r *DatasetReconciler) reconcileClaimPVC(ctx context.Context, ds *datasetv1
 }
 
 func (r *DatasetReconciler) reconcileConfigMap(ctx context.Context, ds *datasetv1alpha1.Dataset) error {
-	if ds.Spec.Source.Type != datasetv1alpha1.DatasetTypeConda {
+	switch ds.Spec.Source.Type {
+	case datasetv1alpha1.DatasetTypeConda,
+		datasetv1alpha1.DatasetTypePixi,
+		datasetv1alpha1.DatasetTypePythonVenv:
+	default:
 		return nil
 	}
 
@@ -527,11 +584,37 @@ func (r *DatasetReconciler) reconcileConfigMap(ctx context.Context, ds *datasetv
 	}
 
 	configMapOptions := make([]condaOption, 0, 2)
-	if yamlData, ok := ds.Spec.Source.Options["condaEnvironmentYml"]; ok && strings.TrimSpace(yamlData) != "" {
-		configMapOptions = append(configMapOptions, withCondaEnvironmentYAML(yamlData))
-	}
-	if txt, ok := ds.Spec.Source.Options["pipRequirementsTxt"]; ok && strings.TrimSpace(txt) != "" {
-		configMapOptions = append(configMapOptions, withPipRequirementsTxt(txt))
+	switch ds.Spec.Source.Type {
+	case datasetv1alpha1.DatasetTypeConda:
+		if yamlData, ok := ds.Spec.Source.Options["condaEnvironmentYml"]; ok && strings.TrimSpace(yamlData) != "" {
+			configMapOptions = append(configMapOptions, withCondaEnvironmentYAML(yamlData))
+		}
+		if txt, ok := ds.Spec.Source.Options["pipRequirementsTxt"]; ok && strings.TrimSpace(txt) != "" {
+			configMapOptions = append(configMapOptions, withPipRequirementsTxt(txt))
+		}
+		if yamlData, ok := ds.Spec.Source.Options["condaLockYml"]; ok && strings.TrimSpace(yamlData) != "" {
+			configMapOptions = append(configMapOptions, withCondaLockYAML(yamlData))
+		}
+		if txt, ok := ds.Spec.Source.Options["condaExplicitTxt"]; ok && strings.TrimSpace(txt) != "" {
+			configMapOptions = append(configMapOptions, withCondaExplicitTxt(txt))
+		}
+	case datasetv1alpha1.DatasetTypePixi:
+		if toml, ok := ds.Spec.Source.Options["pixiToml"]; ok && strings.TrimSpace(toml) != "" {
+			configMapOptions = append(configMapOptions, withPixiToml(toml))
+		}
+		if lock, ok := ds.Spec.Source.Options["pixiLock"]; ok && strings.TrimSpace(lock) != "" {
+			configMapOptions = append(configMapOptions, withPixiLock(lock))
+		}
+	case datasetv1alpha1.DatasetTypePythonVenv:
+		if txt, ok := ds.Spec.Source.Options["pipRequirementsTxt"]; ok && strings.TrimSpace(txt) != "" {
+			configMapOptions = append(configMapOptions, withPipRequirementsTxt(txt))
+		}
+		if toml, ok := ds.Spec.Source.Options["pyprojectToml"]; ok && strings.TrimSpace(toml) != "" {
+			configMapOptions = append(configMapOptions, withPyprojectToml(toml))
+		}
+		if lock, ok := ds.Spec.Source.Options["uvLock"]; ok && strings.TrimSpace(lock) != "" {
+			configMapOptions = append(configMapOptions, withUvLock(lock))
+		}
 	}
 
 	if existingCm == nil {
@@ -584,61 +667,29 @@ func (r *DatasetReconciler) reconcileJob(ctx context.Context, ds *datasetv1alpha
 
 	// 若 dataSyncRound > lastSucceedRound，则需要创建新的 job
 	if ds.Spec.DataSyncRound > ds.Status.LastSucceedRound {
+		if !ds.Status.InProcessing || ds.Status.InProcessingRound != ds.Spec.DataSyncRound {
+			admitted, err := r.admitJob(ctx, ds)
+			if err != nil || !admitted {
+				return err
+			}
+		}
 		ds.Status.InProcessing = true
 		ds.Status.InProcessingRound = ds.Spec.DataSyncRound
 		jobName := genJobName(ds.Name, ds.Status.InProcessingRound)
 
-		jobSpec := batchv1.JobSpec{}
-		err := yaml.Unmarshal([]byte(config.GetDatasetJobSpecYaml()), &jobSpec)
+		jobSpec, err := loaderJobSpec(ds.Spec.PodOverrides)
 		if err != nil {
-			log.Errorf("unmarshal dataset job spec yaml failed: %v", err)
+			return err
 		}
 
 		container := &jobSpec.Template.Spec.Containers[0]
-		container.Name = "dataset-loader"
-
-		// 预留资源请求
-		containerRequests := make(corev1.ResourceList)
-		containerLimits := make(corev1.ResourceList)
+		template := *container.DeepCopy()
 
-		switch ds.Spec.Source.Type {
-		case datasetv1alpha1.DatasetTypeConda:
-			containerRequests[corev1.ResourceCPU] = resource.MustParse("2")
-			containerRequests[corev1.ResourceMemory] = resource.MustParse("2Gi")
-			containerLimits[corev1.ResourceCPU] = resource.MustParse("4")
-			containerLimits[corev1.ResourceMemory] = resource.MustParse("4Gi")
-
-		case datasetv1alpha1.DatasetTypeHuggingFace,
-			datasetv1alpha1.DatasetTypeModelScope:
-			containerRequests[corev1.ResourceCPU] = resource.MustParse("2")
-			containerRequests[corev1.ResourceMemory] = resource.MustParse("2Gi")
-			containerLimits[corev1.ResourceCPU] = resource.MustParse("4")
-			containerLimits[corev1.ResourceMemory] = resource.MustParse("8Gi")
-		}
-
-		// 如果有 GPU 需求
-		if gpuType, ok := ds.Spec.Source.Options["gpuType"]; ok {
-			switch gpuType {
-			case "nvidia-gpu":
-				containerRequests["nvidia.com/gpu"] = resource.MustParse("1")
-				containerLimits["nvidia.com/gpu"] = resource.MustParse("1")
-			case "nvidia-vgpu":
-				containerRequests["nvidia.com/vgpu"] = resource.MustParse("1")
-				containerRequests["nvidia.com/gpumem"] = resource.MustParse("500")
-				containerLimits["nvidia.com/vgpu"] = resource.MustParse("1")
-				containerLimits["nvidia.com/gpumem"] = resource.MustParse("500")
-			case "metax-gpu":
-				containerRequests["metax-tech.com/gpu"] = resource.MustParse("1")
-				containerLimits["metax-tech.com/gpu"] = resource.MustParse("1")
-			}
+		if len(ds.Spec.Sources) > 0 {
+			return r.createJob(ctx, ds, jobName, withManifestRecord(ds, withPostProcess(ds, withSources(ds, jobSpec), template), template))
 		}
 
-		if len(containerRequests) > 0 {
-			container.Resources.Requests = containerRequests
-		}
-		if len(containerLimits) > 0 {
-			container.Resources.Limits = containerLimits
-		}
+		setLoaderResources(container, ds.Spec.Source.Type, ds.Spec.Source.Options)
 
 		options := make(map[string]string)
 		for k, v := range ds.Spec.Source.Options {
@@ -647,9 +698,10 @@ func (r *DatasetReconciler) reconcileJob(ctx context.Context, ds *datasetv1alpha
 
 		podSpec := &jobSpec.Template.Spec
 
-		// conda 类型需要将 ConfigMap mount 到容器
+		// conda、pixi 和 python venv 类型需要将 ConfigMap mount 到容器
 		condaKeyItems := make([]corev1.KeyToPath, 0, 2)
 		condaPodVolumeName := "dataset-config-conda"
+		condaConfigDir := constants.DatasetJobCondaConfigDir
 
 		switch ds.Spec.Source.Type {
 		case datasetv1alpha1.DatasetTypeConda:
@@ -667,9 +719,62 @@ func (r *DatasetReconciler) reconcileJob(ctx context.Context, ds *datasetv1alpha
 					Path: constants.DatasetJobCondaPipRequirementsTxtFilename,
 				})
 			}
+			if yamlData, ok := options["condaLockYml"]; ok && strings.TrimSpace(yamlData) != "" {
+				delete(options, "condaLockYml")
+				condaKeyItems = append(condaKeyItems, corev1.KeyToPath{
+					Key:  constants.DatasetJobCondaLockYAMLFilename,
+					Path: constants.DatasetJobCondaLockYAMLFilename,
+				})
+			}
+			if txt, ok := options["condaExplicitTxt"]; ok && strings.TrimSpace(txt) != "" {
+				delete(options, "condaExplicitTxt")
+				condaKeyItems = append(condaKeyItems, corev1.KeyToPath{
+					Key:  constants.DatasetJobCondaExplicitTxtFilename,
+					Path: constants.DatasetJobCondaExplicitTxtFilename,
+				})
+			}
+		case datasetv1alpha1.DatasetTypePixi:
+			condaConfigDir = constants.DatasetJobPixiConfigDir
+			if toml, ok := options["pixiToml"]; ok && strings.TrimSpace(toml) != "" {
+				delete(options, "pixiToml")
+				condaKeyItems = append(condaKeyItems, corev1.KeyToPath{
+					Key:  constants.DatasetJobPixiManifestFilename,
+					Path: constants.DatasetJobPixiManifestFilename,
+				})
+			}
+			if lock, ok := options["pixiLock"]; ok && strings.TrimSpace(lock) != "" {
+				delete(options, "pixiLock")
+				condaKeyItems = append(condaKeyItems, corev1.KeyToPath{
+					Key:  constants.DatasetJobPixiLockFilename,
+					Path: constants.DatasetJobPixiLockFilename,
+				})
+			}
+		case datasetv1alpha1.DatasetTypePythonVenv:
+			condaConfigDir = constants.DatasetJobPythonVenvConfigDir
+			if txt, ok := options["pipRequirementsTxt"]; ok && strings.TrimSpace(txt) != "" {
+				delete(options, "pipRequirementsTxt")
+				condaKeyItems = append(condaKeyItems, corev1.KeyToPath{
+					Key:  constants.DatasetJobCondaPipRequirementsTxtFilename,
+					Path: constants.DatasetJobCondaPipRequirementsTxtFilename,
+				})
+			}
+			if toml, ok := options["pyprojectToml"]; ok && strings.TrimSpace(toml) != "" {
+				delete(options, "pyprojectToml")
+				condaKeyItems = append(condaKeyItems, corev1.KeyToPath{
+					Key:  constants.DatasetJobPythonVenvPyprojectTomlFilename,
+					Path: constants.DatasetJobPythonVenvPyprojectTomlFilename,
+				})
+			}
+			if lock, ok := options["uvLock"]; ok && strings.TrimSpace(lock) != "" {
+				delete(options, "uvLock")
+				condaKeyItems = append(condaKeyItems, corev1.KeyToPath{
+					Key:  constants.DatasetJobPythonVenvUvLockFilename,
+					Path: constants.DatasetJobPythonVenvUvLockFilename,
+				})
+			}
 		}
 
-		if ds.Spec.Source.Type == datasetv1alpha1.DatasetTypeConda && len(condaKeyItems) > 0 {
+		if len(condaKeyItems) > 0 {
 			podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
 				Name: condaPodVolumeName,
 				VolumeSource: corev1.VolumeSource{
@@ -683,7 +788,7 @@ func (r *DatasetReconciler) reconcileJob(ctx context.Context, ds *datasetv1alpha
 			})
 			container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
 				Name:      condaPodVolumeName,
-				MountPath: constants.DatasetJobCondaConfigDir,
+				MountPath: condaConfigDir,
 				ReadOnly:  true,
 			})
 		}
@@ -706,24 +811,9 @@ func (r *DatasetReconciler) reconcileJob(ctx context.Context, ds *datasetv1alpha
 		}
 
 		// 绑定 PVC
-		pvcMountPath := "/baize/dataset/data"
-		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
-			Name: "dataset-pvc",
-			VolumeSource: corev1.VolumeSource{
-				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
-					ClaimName: ds.Status.PVCName,
-				},
-			},
-		})
-
-		volumeMount := corev1.VolumeMount{
-			Name:      "dataset-pvc",
-			MountPath: pvcMountPath,
-		}
-		if ds.Spec.VolumeClaimRef != nil && ds.Spec.VolumeClaimRef.SubPath != "" {
-			volumeMount.SubPath = ds.Spec.VolumeClaimRef.SubPath
-		}
-		container.VolumeMounts = append(container.VolumeMounts, volumeMount)
+		pvcVolume, pvcVolumeMount := datasetPVCVolume(ds)
+		podSpec.Volumes = append(podSpec.Volumes, pvcVolume)
+		container.VolumeMounts = append(container.VolumeMounts, pvcVolumeMount)
 
 		// 构造命令行参数
 		switch ds.Spec.Source.Type {
@@ -736,13 +826,7 @@ func (r *DatasetReconciler) reconcileJob(ctx context.Context, ds *datasetv1alpha
 			string(ds.Spec.Source.Type),
 			ds.Spec.Source.URI,
 		}
-		for k, v := range options {
-			if regexp.MustCompile(`\s`).MatchString(v) {
-				args = append(args, fmt.Sprintf("--options=%s=%q", k, v))
-			} else {
-				args = append(args, fmt.Sprintf("--options=%s=%s", k, v))
-			}
-		}
+		args = append(args, loaderOptionArgs(options)...)
 		if ds.Spec.MountOptions.Path != "" {
 			args = append(args, fmt.Sprintf("--mount-path=%s", ds.Spec.MountOptions.Path))
 		}
@@ -751,24 +835,17 @@ func (r *DatasetReconciler) reconcileJob(ctx context.Context, ds *datasetv1alpha
 		}
 		args = append(args, fmt.Sprintf("--mount-uid=%d", ds.Spec.MountOptions.UID))
 		args = append(args, fmt.Sprintf("--mount-gid=%d", ds.Spec.MountOptions.GID))
-		args = append(args, fmt.Sprintf("--mount-root=%s", pvcMountPath))
+		args = append(args, fmt.Sprintf("--mount-root=%s", datasetPVCMountPath))
+		if container.TerminationMessagePath != "" {
+			args = append(args, fmt.Sprintf("--termination-message-path=%s", container.TerminationMessagePath))
+		}
 
 		container.Args = args
+		withBlobCache(podSpec, container, ds.Spec.Source.Type)
 
 		// 最终创建 Job
-		job := &batchv1.Job{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      jobName,
-				Namespace: ds.Namespace,
-				Labels: lo.Assign(ds.Labels, map[string]string{
-					constants.DatasetNameLabel: ds.Name,
-				}),
-				Annotations:     ds.Annotations,
-				OwnerReferences: datasetOwnerRef(ds),
-			},
-			Spec: changeDefinitionForHadoop(ds.Spec.Source.Type, jobSpec, options),
-		}
-		if err := r.Create(ctx, job); err != nil && !k8serrors.IsAlreadyExists(err) {
+		jobSpec = changeDefinitionForHadoop(ds.Spec.Source.Type, jobSpec, options)
+		if err := r.createJob(ctx, ds, jobName, withManifestRecord(ds, withPostProcess(ds, jobSpec, template), template)); err != nil {
 			return err
 		}
 	}
@@ -776,6 +853,118 @@ func (r *DatasetReconciler) reconcileJob(ctx context.Context, ds *datasetv1alpha
 	return nil
 }
 
+func (r *DatasetReconciler) createJob(ctx context.Context, ds *datasetv1alpha1.Dataset, jobName string, jobSpec batchv1.JobSpec) error {
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      jobName,
+			Namespace: ds.Namespace,
+			Labels: lo.Assign(ds.Labels, map[string]string{
+				constants.DatasetNameLabel: ds.Name,
+			}),
+			Annotations:     ds.Annotations,
+			OwnerReferences: datasetOwnerRef(ds),
+		},
+		Spec: jobSpec,
+	}
+	if err := r.Create(ctx, job); err != nil && !k8serrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	return nil
+}
+
+// datasetPVCVolume returns the volume of the pvc of the dataset and its mount
+// in the data loader container.
+func datasetPVCVolume(ds *datasetv1alpha1.Dataset) (corev1.Volume, corev1.VolumeMount) {
+	volume := corev1.Volume{
+		Name: "dataset-pvc",
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: ds.Status.PVCName,
+			},
+		},
+	}
+	volumeMount := corev1.VolumeMount{
+		Name:      "dataset-pvc",
+		MountPath: datasetPVCMountPath,
+		SubPath:   datasetSubPath(ds),
+	}
+
+	return volume, volumeMount
+}
+
+// setLoaderResources reserves the resources of the data loader container by
+// the type and the gpuType option of the source.
+func setLoaderResources(container *corev1.Container, sourceType datasetv1alpha1.DatasetType, options map[string]string) {
+	// 预留资源请求
+	containerRequests := make(corev1.ResourceList)
+	containerLimits := make(corev1.ResourceList)
+
+	switch sourceType {
+	case datasetv1alpha1.DatasetTypeConda,
+		datasetv1alpha1.DatasetTypePixi:
+		containerRequests[corev1.ResourceCPU] = resource.MustParse("2")
+		containerRequests[corev1.ResourceMemory] = resource.MustParse("2Gi")
+		containerLimits[corev1.ResourceCPU] = resource.MustParse("4")
+		containerLimits[corev1.ResourceMemory] = resource.MustParse("4Gi")
+
+	case datasetv1alpha1.DatasetTypePythonVenv:
+		containerRequests[corev1.ResourceCPU] = resource.MustParse("500m")
+		containerRequests[corev1.ResourceMemory] = resource.MustParse("512Mi")
+		containerLimits[corev1.ResourceCPU] = resource.MustParse("2")
+		containerLimits[corev1.ResourceMemory] = resource.MustParse("2Gi")
+
+	case datasetv1alpha1.DatasetTypeHuggingFace,
+		datasetv1alpha1.DatasetTypeModelScope:
+		containerRequests[corev1.ResourceCPU] = resource.MustParse("2")
+		containerRequests[corev1.ResourceMemory] = resource.MustParse("2Gi")
+		containerLimits[corev1.ResourceCPU] = resource.MustParse("4")
+		containerLimits[corev1.ResourceMemory] = resource.MustParse("8Gi")
+	}
+
+	// 如果有 GPU 需求
+	if gpuType, ok := options["gpuType"]; ok {
+		switch gpuType {
+		case "nvidia-gpu":
+			containerRequests["nvidia.com/gpu"] = resource.MustParse("1")
+			containerLimits["nvidia.com/gpu"] = resource.MustParse("1")
+		case "nvidia-vgpu":
+			containerRequests["nvidia.com/vgpu"] = resource.MustParse("1")
+			containerRequests["nvidia.com/gpumem"] = resource.MustParse("500")
+			containerLimits["nvidia.com/vgpu"] = resource.MustParse("1")
+			containerLimits["nvidia.com/gpumem"] = resource.MustParse("500")
+		case "metax-gpu":
+			containerRequests["metax-tech.com/gpu"] = resource.MustParse("1")
+			containerLimits["metax-tech.com/gpu"] = resource.MustParse("1")
+		}
+	}
+
+	if len(containerRequests) > 0 {
+		container.Resources.Requests = containerRequests
+	}
+	if len(containerLimits) > 0 {
+		container.Resources.Limits = containerLimits
+	}
+}
+
+// loaderOptionArgs returns the --options flags of the data loader.
+func loaderOptionArgs(options map[string]string) []string {
+	args := make([]string, 0, len(options))
+	for k, v := range options {
+		if regexp.MustCompile(`\s`).MatchString(v) {
+			args = append(args, fmt.Sprintf("--options=%s=%q", k, v))
+		} else {
+			args = append(args, fmt.Sprintf("--options=%s=%s", k, v))
+		}
+	}
+
+	return args
+}
+
+// krb5ConfPath is where the krb5.conf of HADOOP datasets is mounted, both
+// kinit and the WebHDFS transport of the data loader read it.
+const krb5ConfPath = "/etc/krb5.conf"
+
 func changeDefinitionForHadoop(sourceType datasetv1alpha1.DatasetType, jobSpec batchv1.JobSpec, options map[string]string) batchv1.JobSpec {
 	if sourceType != datasetv1alpha1.DatasetTypeHadoop {
 		return jobSpec
@@ -784,6 +973,7 @@ func changeDefinitionForHadoop(sourceType datasetv1alpha1.DatasetType, jobSpec b
 	cmName := options["hdfsConfigName"]
 	coreSiteXMLName := options["coreSiteXml"]
 	hdfsSiteXMLName := options["hdfsSiteXml"]
+	krb5ConfName := options["krb5Conf"]
 	username := options["username"]
 	if jobSpec.Template.Spec.Volumes == nil {
 		jobSpec.Template.Spec.Volumes = make([]corev1.Volume, 0)
@@ -827,6 +1017,23 @@ func changeDefinitionForHadoop(sourceType datasetv1alpha1.DatasetType, jobSpec b
 			},
 		)
 	}
+	if krb5ConfName != "" {
+		c.VolumeMounts = append(
+			c.VolumeMounts,
+			corev1.VolumeMount{
+				Name:      "hadoop-conf",
+				MountPath: krb5ConfPath,
+				SubPath:   krb5ConfName,
+			},
+		)
+		c.Env = append(
+			c.Env,
+			corev1.EnvVar{
+				Name:  "KRB5_CONFIG",
+				Value: krb5ConfPath,
+			},
+		)
+	}
 	c.Env = append(
 		c.Env,
 		corev1.EnvVar{
@@ -893,6 +1100,18 @@ func (r *DatasetReconciler) reconcileJobStatus(ctx context.Context, ds *datasetv
 		loader.EndTime = lo.FromPtrOr(job.Status.CompletionTime, metav1.Time{Time: time.Now()})
 		ds.Status.LastSyncTime = lo.FromPtrOr(job.Status.CompletionTime, metav1.Time{Time: time.Now()})
 		loader.Succeed = true
+		results, err := getJobResults(ctx, r.Client, job)
+		if err != nil {
+			// the data is loaded, only the digest is not reported
+			log.Warnf("failed to get the result of job %s/%s: %v", job.Namespace, job.Name, err)
+		}
+		loader.Digest = results["dataset-loader"].Digest
+		loader.Sources = sourceLoadStatuses(ds, results)
+		loader.PostProcess = getPostProcessStatuses(ctx, r.Client, ds, job)
+		if !loader.BlobCacheRecorded {
+			recordBlobCacheMetrics(ds, results)
+			loader.BlobCacheRecorded = true
+		}
 		ds.Status.InProcessing = false
 		ds.Status.LastSucceedRound = ds.Status.InProcessingRound
 		ds.Status.InProcessingRound = 0
@@ -902,6 +1121,16 @@ func (r *DatasetReconciler) reconcileJobStatus(ctx context.Context, ds *datasetv
 		ds.Status.InProcessing = false
 		ds.Status.InProcessingRound = 0
 		loader.Succeed = false
+		if len(ds.Spec.Sources) > 0 {
+			// the sources loaded before the failed one
+			results, err := getJobResults(ctx, r.Client, job)
+			if err != nil {
+				log.Warnf("failed to get the results of job %s/%s: %v", job.Namespace, job.Name, err)
+			}
+			loader.Sources = sourceLoadStatuses(ds, results)
+		}
+		// the steps run before the failed one
+		loader.PostProcess = getPostProcessStatuses(ctx, r.Client, ds, job)
 	}
 
 	// 滚动清理过期的历史记录
@@ -911,16 +1140,79 @@ func (r *DatasetReconciler) reconcileJobStatus(ctx context.Context, ds *datasetv
 	return nil
 }
 
+// getJobResult reads the result the data loader reported in the termination
+// message of its container, from the pod of the job which succeeded.
+func getJobResult(ctx context.Context, c client.Reader, job *batchv1.Job) (jobresult.Result, error) {
+	results, err := getJobResults(ctx, c, job)
+	return results["dataset-loader"], err
+}
+
+// getJobResults reads the results of the init and regular containers of the
+// pods of the job which exited successfully, by the name of the container.
+// The containers with an invalid result have an empty one.
+func getJobResults(ctx context.Context, c client.Reader, job *batchv1.Job) (map[string]jobresult.Result, error) {
+	states, err := getTerminatedContainers(ctx, c, job)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(map[string]jobresult.Result)
+	var parseErr error
+	for name, state := range states {
+		if state.ExitCode != 0 {
+			continue
+		}
+		result, err := jobresult.Parse(state.Message)
+		if err != nil {
+			parseErr = err
+		}
+		results[name] = result
+	}
+
+	return results, parseErr
+}
+
+// getTerminatedContainers returns the states of the terminated init and
+// regular containers of the pods of the job by the name of the container, the
+// state of a container which exited successfully in any pod is preferred.
+func getTerminatedContainers(ctx context.Context, c client.Reader, job *batchv1.Job) (map[string]*corev1.ContainerStateTerminated, error) {
+	pods := &corev1.PodList{}
+	err := c.List(ctx, pods, client.InNamespace(job.Namespace), client.MatchingLabels{batchv1.JobNameLabel: job.Name})
+	if err != nil {
+		return nil, err
+	}
+
+	states := make(map[string]*corev1.ContainerStateTerminated)
+	for _, pod := range pods.Items {
+		for _, status := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+			if status.State.Terminated == nil {
+				continue
+			}
+			if state, ok := states[status.Name]; ok && state.ExitCode == 0 {
+				continue
+			}
+			states[status.Name] = status.State.Terminated
+		}
+	}
+
+	return states, nil
+}
+
 func (r *DatasetReconciler) reconcilePhase(_ context.Context, ds *datasetv1alpha1.Dataset) error {
 	var phase datasetv1alpha1.DatasetStatusPhase
 	switch ds.Spec.Source.Type {
 	case datasetv1alpha1.DatasetTypeReference:
 		if _, ok := lo.Find(ds.Status.Conditions, func(c metav1.Condition) bool {
-			return c.Status == metav1.ConditionFalse
+			return c.Status == metav1.ConditionFalse && c.Type != condTypeSourceReady
 		}); ok {
 			ds.Status.Phase = datasetv1alpha1.DatasetStatusPhaseFailed
 			return nil
 		}
+		if ds.Status.SourcePhase != "" && ds.Status.SourcePhase != datasetv1alpha1.DatasetStatusPhaseReady {
+			// the data of the source dataset is being loaded or failed to
+			ds.Status.Phase = ds.Status.SourcePhase
+			return nil
+		}
 	case datasetv1alpha1.DatasetTypeManual:
 		if _, ok := lo.Find(ds.Status.Conditions, func(c metav1.Condition) bool {
 			return c.Status == metav1.ConditionFalse
@@ -937,6 +1229,8 @@ func (r *DatasetReconciler) reconcilePhase(_ context.Context, ds *datasetv1alpha
 
 	if ds.Spec.Source.Type == datasetv1alpha1.DatasetTypePVC {
 		phase = datasetv1alpha1.DatasetStatusPhaseReady
+	} else if ds.Status.QueuePosition > 0 {
+		phase = datasetv1alpha1.DatasetStatusPhaseQueued
 	} else if ds.Status.InProcessing {
 		phase = datasetv1alpha1.DatasetStatusPhaseProcessing
 	} else if ds.Status.LastSucceedRound != ds.Spec.DataSyncRound {
@@ -952,12 +1246,12 @@ func (r *DatasetReconciler) reconcilePhase(_ context.Context, ds *datasetv1alpha
 }
 
 func (r *DatasetReconciler) getSourceDataset(ctx context.Context, ds *datasetv1alpha1.Dataset) (*datasetv1alpha1.Dataset, error) {
-	u, err := url.Parse(ds.Spec.Source.URI)
+	namespace, name, _, err := parseReferenceURI(ds.Spec.Source.URI)
 	if err != nil {
 		return nil, err
 	}
 	sourceDs := &datasetv1alpha1.Dataset{}
-	if err := r.Get(ctx, client.ObjectKey{Namespace: u.Host, Name: strings.Trim(u.Path, "/")}, sourceDs); err != nil {
+	if err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, sourceDs); err != nil {
 		return nil, fmt.Errorf("fetch source dataset %s error: %v", ds.Spec.Source.URI, err)
 	}
 	return sourceDs, nil
@@ -969,29 +1263,37 @@ func (r *DatasetReconciler) validate(ctx context.Context, ds *datasetv1alpha1.Da
 	}
 
 	if ds.Spec.Source.Type == datasetv1alpha1.DatasetTypeReference {
-		sourceDs, err := r.getSourceDataset(ctx, ds)
+		_, _, subPath, err := parseReferenceURI(ds.Spec.Source.URI)
 		if err != nil {
 			return err
 		}
-		if !sourceDs.Spec.Share {
-			return fmt.Errorf("source dataset %s is not shared", ds.Spec.Source.URI)
+		if err := validateSubPath(subPath); err != nil {
+			return err
 		}
-		if sourceDs.Spec.ShareToNamespaceSelector != nil {
-			// 获取当前 Dataset 所在的 Namespace
-			currNS := &corev1.Namespace{}
-			if err := r.Get(ctx, client.ObjectKey{Name: ds.Namespace}, currNS); err != nil {
-				return fmt.Errorf("fetch current namespace %s error: %v", ds.Namespace, err)
-			}
-			s, err := metav1.LabelSelectorAsSelector(sourceDs.Spec.ShareToNamespaceSelector)
-			if err != nil {
-				return fmt.Errorf("parse share to namespace selector error: %v", err)
-			}
-			if !s.Matches(labels.Set(currNS.Labels)) {
-				return fmt.Errorf("source dataset %s is not shared to current namespace", ds.Spec.Source.URI)
-			}
+	}
+
+	for _, subPath := range ds.Spec.ShareSubPaths {
+		if err := validateSubPath(subPath); err != nil {
+			return fmt.Errorf("invalid shareSubPaths: %w", err)
 		}
 	}
 
+	if err := validateSources(ds); err != nil {
+		return err
+	}
+	if err := validatePostProcess(ds); err != nil {
+		return err
+	}
+	if err := validateVerification(ds); err != nil {
+		return err
+	}
+	if err := validateWarmup(ds); err != nil {
+		return err
+	}
+	if err := validatePodOverrides(ds); err != nil {
+		return err
+	}
+
 	if ds.Spec.VolumeClaimRef != nil && !reflect.DeepEqual(ds.Spec.VolumeClaimTemplate, corev1.PersistentVolumeClaim{}) {
 		return fmt.Errorf("volumeClaimRef and volumeClaimTemplate cannot be both set")
 	}
@@ -1010,9 +1312,22 @@ func (r *DatasetReconciler) validate(ctx context.Context, ds *datasetv1alpha1.Da
 	return nil
 }
 
+// deletionPolicy returns the deletion policy of the dataset, defaulting to
+// the global enable_cascading_deletion.
+func deletionPolicy(ds *datasetv1alpha1.Dataset) datasetv1alpha1.DatasetDeletionPolicy {
+	if ds.Spec.DeletionPolicy != "" {
+		return ds.Spec.DeletionPolicy
+	}
+	if config.IsCascadingDeletionEnabled() {
+		return datasetv1alpha1.DatasetDeletionPolicyCascade
+	}
+
+	return datasetv1alpha1.DatasetDeletionPolicyOrphan
+}
+
 func (r *DatasetReconciler) reconcileCascadingDeletion(ctx context.Context, ds *datasetv1alpha1.Dataset) error {
-	// Only perform cascading deletion if enabled in configuration
-	if !config.IsCascadingDeletionEnabled() {
+	policy := deletionPolicy(ds)
+	if policy == datasetv1alpha1.DatasetDeletionPolicyOrphan {
 		return nil
 	}
 
@@ -1022,6 +1337,20 @@ func (r *DatasetReconciler) reconcileCascadingDeletion(ctx context.Context, ds *
 		return fmt.Errorf("failed to find referencing datasets: %v", err)
 	}
 
+	if policy == datasetv1alpha1.DatasetDeletionPolicyBlock {
+		if len(referencingDatasets) == 0 {
+			return nil
+		}
+		// the finalizer is kept until the referencing datasets are deleted
+		names := lo.Map(referencingDatasets, func(item datasetv1alpha1.Dataset, _ int) string {
+			return item.Namespace + "/" + item.Name
+		})
+		if len(names) > 10 {
+			names = append(names[:10], "...")
+		}
+		return fmt.Errorf("deletion is blocked by %d referencing datasets: %s", len(referencingDatasets), strings.Join(names, ", "))
+	}
+
 	// Delete all referencing datasets
 	for _, refDs := range referencingDatasets {
 		log.Infof("Cascading deletion: deleting referencing dataset %s/%s", refDs.Namespace, refDs.Name)
@@ -1034,16 +1363,16 @@ func (r *DatasetReconciler) reconcileCascadingDeletion(ctx context.Context, ds *
 }
 
 func (r *DatasetReconciler) findReferencingDatasets(ctx context.Context, sourceDs *datasetv1alpha1.Dataset) ([]datasetv1alpha1.Dataset, error) {
-	// List all datasets across all namespaces
-	allDatasets := &datasetv1alpha1.DatasetList{}
-	if err := r.List(ctx, allDatasets); err != nil {
+	// List the datasets referencing the source dataset by the index
+	datasets := &datasetv1alpha1.DatasetList{}
+	err := r.List(ctx, datasets, client.MatchingFields{referenceSourceField: sourceDs.Namespace + "/" + sourceDs.Name})
+	if err != nil {
 		return nil, fmt.Errorf("failed to list datasets: %v", err)
 	}
 
 	var referencingDatasets []datasetv1alpha1.Dataset
-	expectedURI := fmt.Sprintf("dataset://%s/%s", sourceDs.Namespace, sourceDs.Name)
 
-	for _, ds := range allDatasets.Items {
+	for _, ds := range datasets.Items {
 		// Skip the source dataset itself
 		if ds.Namespace == sourceDs.Namespace && ds.Name == sourceDs.Name {
 			continue
@@ -1054,10 +1383,7 @@ func (r *DatasetReconciler) findReferencingDatasets(ctx context.Context, sourceD
 			continue
 		}
 
-		// Check if this dataset references the source dataset
-		if ds.Spec.Source.Type == datasetv1alpha1.DatasetTypeReference && ds.Spec.Source.URI == expectedURI {
-			referencingDatasets = append(referencingDatasets, ds)
-		}
+		referencingDatasets = append(referencingDatasets, ds)
 	}
 
 	return referencingDatasets, nil
@@ -1094,7 +1420,17 @@ func (r *DatasetReconciler) cleanupRetainedPV(ctx context.Context, ds *datasetv1
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *DatasetReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	err := mgr.GetFieldIndexer().IndexField(context.Background(), &datasetv1alpha1.Dataset{}, referenceSourceField, indexReferenceSource)
+	if err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&datasetv1alpha1.Dataset{}).
+		Watches(&datasetv1alpha1.Dataset{}, handler.EnqueueRequestsFromMapFunc(r.requestsOfSharing)).
+		Watches(&datasetv1alpha1.DatasetShareGrant{}, handler.EnqueueRequestsFromMapFunc(r.requestsOfSharing)).
+		Watches(&datasetv1alpha1.DatasetPolicy{}, handler.EnqueueRequestsFromMapFunc(r.requestsOfPolicy)).
+		Watches(&corev1.Namespace{}, handler.EnqueueRequestsFromMapFunc(r.requestsOfSharing)).
+		Watches(&corev1.Node{}, handler.EnqueueRequestsFromMapFunc(r.requestsOfWarmupNode), builder.WithPredicates(predicate.LabelChangedPredicate{})).
 		Complete(r)
 }
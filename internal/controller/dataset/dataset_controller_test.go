@@ -23,7 +23,9 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -100,6 +102,7 @@ func TestDatasetReconciler_findReferencingDatasets(t *testing.T) {
 
 	fakeClient := fake.NewClientBuilder().
 		WithScheme(scheme).
+		WithIndex(&datasetv1alpha1.Dataset{}, referenceSourceField, indexReferenceSource).
 		WithObjects(sourceDs, refDs1, refDs2, nonRefDs).
 		Build()
 
@@ -153,6 +156,7 @@ func TestDatasetReconciler_reconcileCascadingDeletion_Disabled(t *testing.T) {
 
 	fakeClient := fake.NewClientBuilder().
 		WithScheme(scheme).
+		WithIndex(&datasetv1alpha1.Dataset{}, referenceSourceField, indexReferenceSource).
 		WithObjects(sourceDs).
 		Build()
 
@@ -208,6 +212,7 @@ func TestDatasetReconciler_reconcileCascadingDeletion_Enabled(t *testing.T) {
 
 	fakeClient := fake.NewClientBuilder().
 		WithScheme(scheme).
+		WithIndex(&datasetv1alpha1.Dataset{}, referenceSourceField, indexReferenceSource).
 		WithObjects(sourceDs, refDs).
 		Build()
 
@@ -234,6 +239,66 @@ func TestDatasetReconciler_reconcileCascadingDeletion_Enabled(t *testing.T) {
 	}
 }
 
+func TestDatasetReconciler_reconcileCascadingDeletion_Policy(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, datasetv1alpha1.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	require.NoError(t, config.ParseConfigFromFileContent("enable_cascading_deletion: true"))
+
+	sourceDs := &datasetv1alpha1.Dataset{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "source-dataset",
+			Namespace:         "default",
+			DeletionTimestamp: &metav1.Time{Time: time.Now()},
+			Finalizers:        []string{"dataset-controller"},
+		},
+		Spec: datasetv1alpha1.DatasetSpec{
+			Share: true,
+			Source: datasetv1alpha1.DatasetSource{
+				Type: datasetv1alpha1.DatasetTypeGit,
+				URI:  "https://github.com/example/repo.git",
+			},
+		},
+	}
+	refDs := &datasetv1alpha1.Dataset{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ref-dataset",
+			Namespace: "namespace1",
+		},
+		Spec: datasetv1alpha1.DatasetSpec{
+			Source: datasetv1alpha1.DatasetSource{
+				Type: datasetv1alpha1.DatasetTypeReference,
+				URI:  "dataset://default/source-dataset",
+			},
+		},
+	}
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithIndex(&datasetv1alpha1.Dataset{}, referenceSourceField, indexReferenceSource).
+		WithObjects(sourceDs, refDs).
+		Build()
+	reconciler := &DatasetReconciler{
+		Client: fakeClient,
+		Scheme: scheme,
+	}
+	ctx := context.Background()
+
+	// the policy of the dataset overrides the global config
+	sourceDs.Spec.DeletionPolicy = datasetv1alpha1.DatasetDeletionPolicyOrphan
+	require.NoError(t, reconciler.reconcileCascadingDeletion(ctx, sourceDs))
+	require.NoError(t, fakeClient.Get(ctx, client.ObjectKeyFromObject(refDs), &datasetv1alpha1.Dataset{}))
+
+	sourceDs.Spec.DeletionPolicy = datasetv1alpha1.DatasetDeletionPolicyBlock
+	err := reconciler.reconcileCascadingDeletion(ctx, sourceDs)
+	require.EqualError(t, err, "deletion is blocked by 1 referencing datasets: namespace1/ref-dataset")
+	require.NoError(t, fakeClient.Get(ctx, client.ObjectKeyFromObject(refDs), &datasetv1alpha1.Dataset{}))
+
+	// the deletion goes on once the referencing datasets are deleted
+	require.NoError(t, fakeClient.Delete(ctx, refDs))
+	require.NoError(t, reconciler.reconcileCascadingDeletion(ctx, sourceDs))
+}
+
 func TestDatasetReconciler_cleanupRetainedPV(t *testing.T) {
 	scheme := runtime.NewScheme()
 	require.NoError(t, datasetv1alpha1.AddToScheme(scheme))
@@ -289,6 +354,59 @@ func TestDatasetReconciler_cleanupRetainedPV(t *testing.T) {
 	assert.True(t, client.IgnoreNotFound(err) == nil, "PV should be deleted")
 }
 
+func TestDatasetReconciler_reconcilePVCDeletesRetainedPV(t *testing.T) {
+	require.NoError(t, config.ParseConfigFromFileContent("enable_cascading_deletion: false"))
+	t.Cleanup(func() { _ = config.ParseConfigFromFileContent("") })
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, datasetv1alpha1.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	for _, testCase := range []struct {
+		name      string
+		policy    datasetv1alpha1.DatasetDeletionPolicy
+		wantExist bool
+	}{
+		{name: "default", policy: "", wantExist: true},
+		{name: "orphan", policy: datasetv1alpha1.DatasetDeletionPolicyOrphan, wantExist: true},
+		{name: "cascade", policy: datasetv1alpha1.DatasetDeletionPolicyCascade},
+		{name: "block", policy: datasetv1alpha1.DatasetDeletionPolicyBlock},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			ds := &datasetv1alpha1.Dataset{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "test-dataset",
+					Namespace:         "default",
+					UID:               "0123456789abcdef",
+					DeletionTimestamp: &metav1.Time{Time: time.Now()},
+				},
+				Spec: datasetv1alpha1.DatasetSpec{
+					Source:         datasetv1alpha1.DatasetSource{Type: datasetv1alpha1.DatasetTypeReference, URI: "dataset://other/source-dataset"},
+					DeletionPolicy: testCase.policy,
+				},
+			}
+			pv := &corev1.PersistentVolume{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "dataset-default-test-dataset-0123456789ab",
+					Labels: map[string]string{constants.DatasetNameLabel: "test-dataset"},
+				},
+				Spec: corev1.PersistentVolumeSpec{PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimRetain},
+			}
+			fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(pv).Build()
+			reconciler := &DatasetReconciler{Client: fakeClient, Scheme: scheme}
+			ctx := context.Background()
+
+			require.NoError(t, reconciler.reconcilePVC(ctx, ds))
+			err := fakeClient.Get(ctx, client.ObjectKeyFromObject(pv), &corev1.PersistentVolume{})
+			if testCase.wantExist {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, k8serrors.IsNotFound(err), "PV should be deleted")
+			}
+		})
+	}
+}
+
 func TestDatasetReconciler_reconcileClaimPVC(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -725,3 +843,222 @@ func TestDatasetReconciler_validateManualURI(t *testing.T) {
 		})
 	}
 }
+
+func TestChangeDefinitionForHadoop(t *testing.T) {
+	jobSpec := batchv1.JobSpec{
+		Template: corev1.PodTemplateSpec{
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "data-loader"}},
+			},
+		},
+	}
+
+	jobSpec = changeDefinitionForHadoop(datasetv1alpha1.DatasetTypeHadoop, jobSpec, map[string]string{
+		"hdfsConfigName": "hadoop-conf",
+		"coreSiteXml":    "core-site.xml",
+		"krb5Conf":       "krb5.conf",
+	})
+
+	require.Len(t, jobSpec.Template.Spec.Volumes, 1)
+	assert.Equal(t, "hadoop-conf", jobSpec.Template.Spec.Volumes[0].ConfigMap.Name)
+	c := jobSpec.Template.Spec.Containers[0]
+	assert.Contains(t, c.VolumeMounts, corev1.VolumeMount{
+		Name:      "hadoop-conf",
+		MountPath: "/opt/hadoop/etc/hadoop/core-site.xml",
+		SubPath:   "core-site.xml",
+	})
+	assert.Contains(t, c.VolumeMounts, corev1.VolumeMount{
+		Name:      "hadoop-conf",
+		MountPath: "/etc/krb5.conf",
+		SubPath:   "krb5.conf",
+	})
+	assert.Contains(t, c.Env, corev1.EnvVar{Name: "KRB5_CONFIG", Value: "/etc/krb5.conf"})
+}
+
+func TestDatasetReconciler_reconcilePixi(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, datasetv1alpha1.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+	require.NoError(t, batchv1.AddToScheme(scheme))
+
+	ds := &datasetv1alpha1.Dataset{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pixi-env",
+			Namespace: "default",
+		},
+		Spec: datasetv1alpha1.DatasetSpec{
+			Source: datasetv1alpha1.DatasetSource{
+				Type: datasetv1alpha1.DatasetTypePixi,
+				URI:  "pixi://pixi-env",
+				Options: map[string]string{
+					"pixiToml":    "[workspace]\nname = \"pixi-env\"\n",
+					"pixiLock":    "version: 6\n",
+					"environment": "default",
+				},
+			},
+			DataSyncRound: 1,
+		},
+		Status: datasetv1alpha1.DatasetStatus{
+			PVCName: "pixi-env",
+		},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+	reconciler := &DatasetReconciler{Client: fakeClient, Scheme: scheme}
+	ctx := context.Background()
+
+	require.NoError(t, reconciler.reconcileConfigMap(ctx, ds))
+	cm := &corev1.ConfigMap{}
+	require.NoError(t, fakeClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: datasetConfigMapName(ds)}, cm))
+	assert.Equal(t, map[string]string{
+		constants.DatasetJobPixiManifestFilename: "[workspace]\nname = \"pixi-env\"\n",
+		constants.DatasetJobPixiLockFilename:     "version: 6\n",
+	}, cm.Data)
+
+	require.NoError(t, reconciler.reconcileJob(ctx, ds))
+	job := &batchv1.Job{}
+	require.NoError(t, fakeClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: genJobName(ds.Name, 1)}, job))
+	container := job.Spec.Template.Spec.Containers[0]
+	assert.Contains(t, container.VolumeMounts, corev1.VolumeMount{
+		Name:      "dataset-config-conda",
+		MountPath: constants.DatasetJobPixiConfigDir,
+		ReadOnly:  true,
+	})
+	assert.Contains(t, container.Args, "--options=environment=default")
+	for _, arg := range container.Args {
+		assert.NotContains(t, arg, "pixiToml")
+		assert.NotContains(t, arg, "pixiLock")
+	}
+}
+
+func TestDatasetReconciler_reconcilePythonVenv(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, datasetv1alpha1.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+	require.NoError(t, batchv1.AddToScheme(scheme))
+
+	ds := &datasetv1alpha1.Dataset{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "venv-env",
+			Namespace: "default",
+		},
+		Spec: datasetv1alpha1.DatasetSpec{
+			Source: datasetv1alpha1.DatasetSource{
+				Type: datasetv1alpha1.DatasetTypePythonVenv,
+				URI:  "venv://venv-env",
+				Options: map[string]string{
+					"pyprojectToml": "[project]\nname = \"venv-env\"\n",
+					"uvLock":        "version = 1\n",
+					"python":        "3.12",
+				},
+			},
+			DataSyncRound: 1,
+		},
+		Status: datasetv1alpha1.DatasetStatus{
+			PVCName: "venv-env",
+		},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+	reconciler := &DatasetReconciler{Client: fakeClient, Scheme: scheme}
+	ctx := context.Background()
+
+	require.NoError(t, reconciler.reconcileConfigMap(ctx, ds))
+	cm := &corev1.ConfigMap{}
+	require.NoError(t, fakeClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: datasetConfigMapName(ds)}, cm))
+	assert.Equal(t, map[string]string{
+		constants.DatasetJobPythonVenvPyprojectTomlFilename: "[project]\nname = \"venv-env\"\n",
+		constants.DatasetJobPythonVenvUvLockFilename:        "version = 1\n",
+	}, cm.Data)
+
+	require.NoError(t, reconciler.reconcileJob(ctx, ds))
+	job := &batchv1.Job{}
+	require.NoError(t, fakeClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: genJobName(ds.Name, 1)}, job))
+	container := job.Spec.Template.Spec.Containers[0]
+	assert.Contains(t, container.VolumeMounts, corev1.VolumeMount{
+		Name:      "dataset-config-conda",
+		MountPath: constants.DatasetJobPythonVenvConfigDir,
+		ReadOnly:  true,
+	})
+	assert.Equal(t, resource.MustParse("512Mi"), container.Resources.Requests[corev1.ResourceMemory])
+	assert.Contains(t, container.Args, "--options=python=3.12")
+	for _, arg := range container.Args {
+		assert.NotContains(t, arg, "pyprojectToml")
+		assert.NotContains(t, arg, "uvLock")
+	}
+}
+
+func TestDatasetReconciler_reconcileCondaLock(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, datasetv1alpha1.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+	require.NoError(t, batchv1.AddToScheme(scheme))
+
+	ds := &datasetv1alpha1.Dataset{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "conda-env",
+			Namespace: "default",
+		},
+		Spec: datasetv1alpha1.DatasetSpec{
+			Source: datasetv1alpha1.DatasetSource{
+				Type: datasetv1alpha1.DatasetTypeConda,
+				URI:  "conda://conda-env",
+				Options: map[string]string{
+					"condaLockYml": "version: 1\n",
+					"platform":     "linux-64",
+				},
+			},
+			DataSyncRound: 1,
+		},
+		Status: datasetv1alpha1.DatasetStatus{
+			PVCName:           "conda-env",
+			InProcessing:      true,
+			InProcessingRound: 1,
+		},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+	reconciler := &DatasetReconciler{Client: fakeClient, Scheme: scheme}
+	ctx := context.Background()
+
+	require.NoError(t, reconciler.reconcileConfigMap(ctx, ds))
+	cm := &corev1.ConfigMap{}
+	require.NoError(t, fakeClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: datasetConfigMapName(ds)}, cm))
+	assert.Equal(t, map[string]string{
+		constants.DatasetJobCondaLockYAMLFilename: "version: 1\n",
+	}, cm.Data)
+
+	require.NoError(t, reconciler.reconcileJob(ctx, ds))
+	job := &batchv1.Job{}
+	require.NoError(t, fakeClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: genJobName(ds.Name, 1)}, job))
+	assert.Contains(t, job.Spec.Template.Spec.Volumes[0].ConfigMap.Items, corev1.KeyToPath{
+		Key:  constants.DatasetJobCondaLockYAMLFilename,
+		Path: constants.DatasetJobCondaLockYAMLFilename,
+	})
+	for _, arg := range job.Spec.Template.Spec.Containers[0].Args {
+		assert.NotContains(t, arg, "condaLockYml")
+	}
+
+	// the data loader reports the digest of the environment in its termination message
+	job.Status.Succeeded = 1
+	require.NoError(t, fakeClient.Status().Update(ctx, job))
+	require.NoError(t, fakeClient.Create(ctx, &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      job.Name + "-abcde",
+			Namespace: "default",
+			Labels:    map[string]string{batchv1.JobNameLabel: job.Name},
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name: "dataset-loader",
+				State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+					ExitCode: 0,
+					Message:  `{"digest":"sha256:0123"}`,
+				}},
+			}},
+		},
+	}))
+
+	require.NoError(t, reconciler.reconcileJobStatus(ctx, ds))
+	require.Len(t, ds.Status.SyncRoundStatuses, 1)
+	assert.True(t, ds.Status.SyncRoundStatuses[0].Succeed)
+	assert.Equal(t, "sha256:0123", ds.Status.SyncRoundStatuses[0].Digest)
+	assert.Equal(t, int32(1), ds.Status.LastSucceedRound)
+}
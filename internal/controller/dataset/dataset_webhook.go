@@ -0,0 +1,58 @@
+package dataset
+
+import (
+	"context"
+	"reflect"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	datasetv1alpha1 "github.com/BaizeAI/dataset/api/dataset/v1alpha1"
+	"github.com/BaizeAI/dataset/pkg/kubeutils"
+)
+
+//+kubebuilder:webhook:path=/validate-dataset-baizeai-io-v1alpha1-dataset,mutating=false,failurePolicy=fail,sideEffects=None,groups=dataset.baizeai.io,resources=datasets,verbs=create;update,versions=v1alpha1,name=vdataset.baizeai.io,admissionReviewVersions=v1
+
+// DatasetValidator rejects the datasets violating the DatasetPolicies applied
+// to their namespaces on admission, the same policies are checked when the
+// datasets are reconciled.
+type DatasetValidator struct {
+	Client client.Reader
+}
+
+var _ admission.Validator[*datasetv1alpha1.Dataset] = &DatasetValidator{}
+
+// SetupWebhookWithManager registers the validating webhook of datasets.
+func (v *DatasetValidator) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr, &datasetv1alpha1.Dataset{}).
+		WithValidator(v).
+		Complete()
+}
+
+func (v *DatasetValidator) ValidateCreate(ctx context.Context, ds *datasetv1alpha1.Dataset) (admission.Warnings, error) {
+	return nil, v.validate(ctx, ds)
+}
+
+func (v *DatasetValidator) ValidateUpdate(ctx context.Context, oldDs, ds *datasetv1alpha1.Dataset) (admission.Warnings, error) {
+	// the datasets created before a policy are not blocked from being
+	// deleted, or from the updates of their metadata, e.g. finalizers
+	if kubeutils.IsDeleted(ds) || reflect.DeepEqual(oldDs.Spec, ds.Spec) {
+		return nil, nil
+	}
+
+	return nil, v.validate(ctx, ds)
+}
+
+func (v *DatasetValidator) ValidateDelete(context.Context, *datasetv1alpha1.Dataset) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (v *DatasetValidator) validate(ctx context.Context, ds *datasetv1alpha1.Dataset) error {
+	policies, err := policiesOfNamespace(ctx, v.Client, ds.Namespace)
+	if err != nil {
+		return err
+	}
+
+	return checkPolicies(policies, ds)
+}
@@ -0,0 +1,264 @@
+package dataset
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	datasetv1alpha1 "github.com/BaizeAI/dataset/api/dataset/v1alpha1"
+	"github.com/BaizeAI/dataset/internal/pkg/constants"
+	"github.com/BaizeAI/dataset/pkg/kubeutils"
+	"github.com/BaizeAI/dataset/pkg/log"
+)
+
+const (
+	condTypeDataset = "Dataset"
+)
+
+// DatasetExportReconciler reconciles a DatasetExport object
+type DatasetExportReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+type exportReconciler struct {
+	typ string
+	rec func(ctx context.Context, export *datasetv1alpha1.DatasetExport) error
+}
+
+//+kubebuilder:rbac:groups=dataset.baizeai.io,resources=datasetexports,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=dataset.baizeai.io,resources=datasetexports/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
+
+func (r *DatasetExportReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	export := &datasetv1alpha1.DatasetExport{}
+	err := r.Get(ctx, req.NamespacedName, export)
+	if err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if kubeutils.IsDeleted(export) {
+		return ctrl.Result{}, nil
+	}
+	switch export.Status.Phase {
+	case datasetv1alpha1.DatasetExportPhaseSucceeded, datasetv1alpha1.DatasetExportPhaseFailed:
+		// an export runs once
+		return ctrl.Result{}, nil
+	}
+
+	prevStatus := export.Status.DeepCopy()
+	reconcilers := []exportReconciler{
+		{typ: condTypeConfig, rec: r.validate},
+		{typ: condTypeDataset, rec: r.reconcileDataset},
+		{typ: condTypeJob, rec: r.reconcileJob},
+		{typ: condTypeJobStatus, rec: r.reconcileJobStatus},
+	}
+	for _, rr := range reconcilers {
+		err := rr.rec(ctx, export)
+		export.Status.Conditions = kubeutils.SetCondition(export.Status.Conditions, rr.typ, err)
+		if err != nil {
+			log.Errorf("error reconciling dataset export for %s/%s: %v", export.Namespace, export.Name, err)
+			break
+		}
+	}
+
+	r.reconcilePhase(export)
+
+	if !reflect.DeepEqual(export.Status, *prevStatus) {
+		statusBase := export.DeepCopy()
+		statusBase.Status = *prevStatus
+		err := r.Status().Patch(ctx, export, client.MergeFrom(statusBase))
+		if err != nil {
+			return ctrl.Result{RequeueAfter: time.Second * 30}, err
+		}
+	}
+
+	switch export.Status.Phase {
+	case datasetv1alpha1.DatasetExportPhaseSucceeded, datasetv1alpha1.DatasetExportPhaseFailed:
+		return ctrl.Result{}, nil
+	case datasetv1alpha1.DatasetExportPhaseRunning:
+		// the job is watched
+		return ctrl.Result{}, nil
+	default:
+		return ctrl.Result{RequeueAfter: time.Second * 30}, nil
+	}
+}
+
+func genExportJobName(exportName string) string {
+	return fmt.Sprintf("dataset-export-%s", exportName)
+}
+
+func (r *DatasetExportReconciler) validate(_ context.Context, export *datasetv1alpha1.DatasetExport) error {
+	if path.IsAbs(export.Spec.Path) {
+		return fmt.Errorf("path %s must be relative to the dataset", export.Spec.Path)
+	}
+	if lo.Contains(strings.Split(export.Spec.Path, "/"), "..") {
+		return fmt.Errorf("path %s must not contain ..", export.Spec.Path)
+	}
+
+	return nil
+}
+
+// reconcileDataset waits for the dataset to be ready, the export keeps pending
+// until then.
+func (r *DatasetExportReconciler) reconcileDataset(ctx context.Context, export *datasetv1alpha1.DatasetExport) error {
+	if export.Status.JobName != "" {
+		return nil
+	}
+
+	ds := &datasetv1alpha1.Dataset{}
+	err := r.Get(ctx, client.ObjectKey{Namespace: export.Namespace, Name: export.Spec.DatasetName}, ds)
+	if err != nil {
+		return err
+	}
+	if ds.Status.Phase != datasetv1alpha1.DatasetStatusPhaseReady || ds.Status.PVCName == "" {
+		return fmt.Errorf("dataset %s is %s, waiting for it to be %s", ds.Name, ds.Status.Phase, datasetv1alpha1.DatasetStatusPhaseReady)
+	}
+
+	return nil
+}
+
+func (r *DatasetExportReconciler) reconcileJob(ctx context.Context, export *datasetv1alpha1.DatasetExport) error {
+	if export.Status.JobName != "" {
+		return nil
+	}
+
+	ds := &datasetv1alpha1.Dataset{}
+	err := r.Get(ctx, client.ObjectKey{Namespace: export.Namespace, Name: export.Spec.DatasetName}, ds)
+	if err != nil {
+		return err
+	}
+
+	jobSpec, err := loaderJobSpec(ds.Spec.PodOverrides)
+	if err != nil {
+		return err
+	}
+
+	podSpec := &jobSpec.Template.Spec
+	container := &podSpec.Containers[0]
+
+	if export.Spec.SecretRef != "" {
+		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+			Name: "dataset-secret",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: export.Spec.SecretRef,
+				},
+			},
+		})
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      "dataset-secret",
+			MountPath: constants.DatasetJobSecretsMountPath,
+			ReadOnly:  true,
+		})
+	}
+
+	// the data is only read
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name: "dataset-pvc",
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: ds.Status.PVCName,
+				ReadOnly:  true,
+			},
+		},
+	})
+	volumeMount := corev1.VolumeMount{
+		Name:      "dataset-pvc",
+		MountPath: datasetPVCMountPath,
+		SubPath:   datasetSubPath(ds),
+		ReadOnly:  true,
+	}
+	container.VolumeMounts = append(container.VolumeMounts, volumeMount)
+
+	args := []string{
+		"export",
+		string(export.Spec.Target.Type),
+		export.Spec.Target.URI,
+	}
+	args = append(args, loaderOptionArgs(export.Spec.Target.Options)...)
+	args = append(args, fmt.Sprintf("--mount-path=%s", path.Join(ds.Spec.MountOptions.Path, export.Spec.Path)))
+	args = append(args, fmt.Sprintf("--mount-root=%s", datasetPVCMountPath))
+	if container.TerminationMessagePath != "" {
+		args = append(args, fmt.Sprintf("--termination-message-path=%s", container.TerminationMessagePath))
+	}
+	container.Args = args
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      genExportJobName(export.Name),
+			Namespace: export.Namespace,
+			Labels: map[string]string{
+				constants.DatasetNameLabel: ds.Name,
+			},
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(export, datasetv1alpha1.GroupVersion.WithKind("DatasetExport"))},
+		},
+		Spec: jobSpec,
+	}
+	if err := r.Create(ctx, job); err != nil && !k8serrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	export.Status.JobName = job.Name
+	export.Status.StartTime = metav1.Time{Time: time.Now()}
+
+	return nil
+}
+
+func (r *DatasetExportReconciler) reconcileJobStatus(ctx context.Context, export *datasetv1alpha1.DatasetExport) error {
+	job := &batchv1.Job{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: export.Namespace, Name: export.Status.JobName}, job); err != nil {
+		return err
+	}
+
+	if job.Status.Succeeded > 0 {
+		export.Status.StartTime = lo.FromPtrOr(job.Status.StartTime, export.Status.StartTime)
+		export.Status.CompletionTime = lo.FromPtrOr(job.Status.CompletionTime, metav1.Time{Time: time.Now()})
+		result, err := getJobResult(ctx, r.Client, job)
+		if err != nil {
+			// the data is exported, only the revision is not reported
+			log.Warnf("failed to get the result of job %s/%s: %v", job.Namespace, job.Name, err)
+		}
+		export.Status.Revision = result.Revision
+		export.Status.Phase = datasetv1alpha1.DatasetExportPhaseSucceeded
+	} else if lo.ContainsBy(job.Status.Conditions, func(item batchv1.JobCondition) bool {
+		return item.Type == batchv1.JobFailed && item.Status == corev1.ConditionTrue
+	}) {
+		export.Status.CompletionTime = metav1.Time{Time: time.Now()}
+		export.Status.Phase = datasetv1alpha1.DatasetExportPhaseFailed
+		return fmt.Errorf("job %s failed", job.Name)
+	}
+
+	return nil
+}
+
+func (r *DatasetExportReconciler) reconcilePhase(export *datasetv1alpha1.DatasetExport) {
+	switch {
+	case export.Status.Phase == datasetv1alpha1.DatasetExportPhaseSucceeded,
+		export.Status.Phase == datasetv1alpha1.DatasetExportPhaseFailed:
+	case !kubeutils.IsConditionReady(export.Status.Conditions, condTypeConfig):
+		export.Status.Phase = datasetv1alpha1.DatasetExportPhaseFailed
+	case export.Status.JobName != "":
+		export.Status.Phase = datasetv1alpha1.DatasetExportPhaseRunning
+	default:
+		export.Status.Phase = datasetv1alpha1.DatasetExportPhasePending
+	}
+}
+
+func (r *DatasetExportReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&datasetv1alpha1.DatasetExport{}).
+		Owns(&batchv1.Job{}).
+		Complete(r)
+}
@@ -0,0 +1,178 @@
+package dataset
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	datasetv1alpha1 "github.com/BaizeAI/dataset/api/dataset/v1alpha1"
+	"github.com/BaizeAI/dataset/config"
+	"github.com/BaizeAI/dataset/pkg/kubeutils"
+)
+
+func TestDatasetExportReconciler(t *testing.T) {
+	require.NoError(t, config.ParseConfigFromFileContent(""))
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, datasetv1alpha1.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+	require.NoError(t, batchv1.AddToScheme(scheme))
+
+	ds := &datasetv1alpha1.Dataset{
+		ObjectMeta: metav1.ObjectMeta{Name: "trained", Namespace: "default"},
+		Spec: datasetv1alpha1.DatasetSpec{
+			Source:         datasetv1alpha1.DatasetSource{Type: datasetv1alpha1.DatasetTypeManual, URI: "manual://"},
+			MountOptions:   datasetv1alpha1.MountOptions{Path: "/models"},
+			VolumeClaimRef: &datasetv1alpha1.VolumeClaimRef{Name: "shared", SubPath: "team"},
+		},
+		Status: datasetv1alpha1.DatasetStatus{Phase: datasetv1alpha1.DatasetStatusPhasePending},
+	}
+	export := &datasetv1alpha1.DatasetExport{
+		ObjectMeta: metav1.ObjectMeta{Name: "publish", Namespace: "default", UID: "uid"},
+		Spec: datasetv1alpha1.DatasetExportSpec{
+			DatasetName: ds.Name,
+			Path:        "checkpoints",
+			SecretRef:   "hf-token",
+			Target: datasetv1alpha1.DatasetExportTarget{
+				Type:    datasetv1alpha1.DatasetTypeHuggingFace,
+				URI:     "huggingface://ns/model",
+				Options: map[string]string{"commitMessage": "publish checkpoints"},
+			},
+		},
+	}
+	invalid := &datasetv1alpha1.DatasetExport{
+		ObjectMeta: metav1.ObjectMeta{Name: "invalid", Namespace: "default"},
+		Spec: datasetv1alpha1.DatasetExportSpec{
+			DatasetName: ds.Name,
+			Path:        "../other",
+			Target:      datasetv1alpha1.DatasetExportTarget{Type: datasetv1alpha1.DatasetTypeS3, URI: "s3://bucket/path"},
+		},
+	}
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(ds, export, invalid).
+		WithStatusSubresource(&datasetv1alpha1.Dataset{}, &datasetv1alpha1.DatasetExport{}, &batchv1.Job{}).
+		Build()
+	r := &DatasetExportReconciler{Client: fakeClient, Scheme: scheme}
+
+	reconcile := func(name string) *datasetv1alpha1.DatasetExport {
+		_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: name}})
+		require.NoError(t, err)
+		got := &datasetv1alpha1.DatasetExport{}
+		require.NoError(t, fakeClient.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: name}, got))
+		return got
+	}
+
+	got := reconcile(invalid.Name)
+	assert.Equal(t, datasetv1alpha1.DatasetExportPhaseFailed, got.Status.Phase)
+	assert.False(t, kubeutils.IsConditionReady(got.Status.Conditions, condTypeConfig))
+
+	// the export waits for the dataset to be ready
+	got = reconcile(export.Name)
+	assert.Equal(t, datasetv1alpha1.DatasetExportPhasePending, got.Status.Phase)
+	assert.False(t, kubeutils.IsConditionReady(got.Status.Conditions, condTypeDataset))
+	assert.Empty(t, got.Status.JobName)
+
+	ds.Status.Phase = datasetv1alpha1.DatasetStatusPhaseReady
+	ds.Status.PVCName = "shared"
+	require.NoError(t, fakeClient.Status().Update(context.Background(), ds))
+
+	got = reconcile(export.Name)
+	assert.Equal(t, datasetv1alpha1.DatasetExportPhaseRunning, got.Status.Phase)
+	assert.Equal(t, "dataset-export-publish", got.Status.JobName)
+
+	job := &batchv1.Job{}
+	require.NoError(t, fakeClient.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: got.Status.JobName}, job))
+	assert.Equal(t, export.Name, job.OwnerReferences[0].Name)
+	container := job.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, []string{
+		"export",
+		"HUGGING_FACE",
+		"huggingface://ns/model",
+		`--options=commitMessage="publish checkpoints"`,
+		"--mount-path=/models/checkpoints",
+		"--mount-root=/baize/dataset/data",
+	}, container.Args)
+	assert.Contains(t, container.VolumeMounts, corev1.VolumeMount{Name: "dataset-pvc", MountPath: "/baize/dataset/data", SubPath: "team", ReadOnly: true})
+	assert.Contains(t, container.VolumeMounts, corev1.VolumeMount{Name: "dataset-secret", MountPath: "/run/dataset/secrets", ReadOnly: true})
+
+	// the revision is read from the termination message of the loader
+	require.NoError(t, fakeClient.Create(context.Background(), &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      job.Name + "-abcde",
+			Namespace: job.Namespace,
+			Labels:    map[string]string{batchv1.JobNameLabel: job.Name},
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name: "dataset-loader",
+				State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+					Message: `{"revision":"0123abcd"}`,
+				}},
+			}},
+		},
+	}))
+	job.Status.Succeeded = 1
+	require.NoError(t, fakeClient.Status().Update(context.Background(), job))
+
+	got = reconcile(export.Name)
+	assert.Equal(t, datasetv1alpha1.DatasetExportPhaseSucceeded, got.Status.Phase)
+	assert.Equal(t, "0123abcd", got.Status.Revision)
+	assert.False(t, got.Status.CompletionTime.IsZero())
+
+	// a finished export is not run again
+	require.NoError(t, fakeClient.Delete(context.Background(), job))
+	got = reconcile(export.Name)
+	assert.Equal(t, datasetv1alpha1.DatasetExportPhaseSucceeded, got.Status.Phase)
+	assert.Error(t, fakeClient.Get(context.Background(), client.ObjectKeyFromObject(job), &batchv1.Job{}))
+}
+
+func TestDatasetExportReconcilerJobFailed(t *testing.T) {
+	require.NoError(t, config.ParseConfigFromFileContent(""))
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, datasetv1alpha1.AddToScheme(scheme))
+	require.NoError(t, batchv1.AddToScheme(scheme))
+
+	export := &datasetv1alpha1.DatasetExport{
+		ObjectMeta: metav1.ObjectMeta{Name: "publish", Namespace: "default"},
+		Spec: datasetv1alpha1.DatasetExportSpec{
+			DatasetName: "trained",
+			Target:      datasetv1alpha1.DatasetExportTarget{Type: datasetv1alpha1.DatasetTypeGit, URI: "https://example.com/repo.git"},
+		},
+		Status: datasetv1alpha1.DatasetExportStatus{
+			Phase:   datasetv1alpha1.DatasetExportPhaseRunning,
+			JobName: "dataset-export-publish",
+		},
+	}
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "dataset-export-publish", Namespace: "default"},
+		Status: batchv1.JobStatus{
+			Conditions: []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}},
+		},
+	}
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(export, job).
+		WithStatusSubresource(&datasetv1alpha1.DatasetExport{}).
+		Build()
+	r := &DatasetExportReconciler{Client: fakeClient, Scheme: scheme}
+
+	_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(export)})
+	require.NoError(t, err)
+
+	got := &datasetv1alpha1.DatasetExport{}
+	require.NoError(t, fakeClient.Get(context.Background(), client.ObjectKeyFromObject(export), got))
+	assert.Equal(t, datasetv1alpha1.DatasetExportPhaseFailed, got.Status.Phase)
+	assert.False(t, kubeutils.IsConditionReady(got.Status.Conditions, condTypeJobStatus))
+}
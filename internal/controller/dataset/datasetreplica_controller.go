@@ -0,0 +1,430 @@
+package dataset
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/samber/lo"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	datasetv1alpha1 "github.com/BaizeAI/dataset/api/dataset/v1alpha1"
+	"github.com/BaizeAI/dataset/internal/pkg/constants"
+	"github.com/BaizeAI/dataset/pkg/kubeutils"
+	"github.com/BaizeAI/dataset/pkg/log"
+)
+
+const (
+	condTypeReplica = "Replica"
+
+	defaultReplicaPollInterval = 5 * time.Minute
+)
+
+func genReplicaPublishJobName(replicaName string, round int32) string {
+	return fmt.Sprintf("dataset-replica-%s-publish-%d", replicaName, round)
+}
+
+func genReplicaHeadJobName(replicaName string, t time.Time) string {
+	return fmt.Sprintf("dataset-replica-%s-head-%d", replicaName, t.Unix())
+}
+
+func replicaPollInterval(replica *datasetv1alpha1.DatasetReplica) time.Duration {
+	if replica.Spec.PollInterval == nil {
+		return defaultReplicaPollInterval
+	}
+
+	return replica.Spec.PollInterval.Duration
+}
+
+// DatasetReplicaReconciler reconciles a DatasetReplica object
+type DatasetReplicaReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+type replicaReconciler struct {
+	typ string
+	rec func(ctx context.Context, replica *datasetv1alpha1.DatasetReplica) error
+}
+
+//+kubebuilder:rbac:groups=dataset.baizeai.io,resources=datasetreplicas,verbs=get;list;watch
+//+kubebuilder:rbac:groups=dataset.baizeai.io,resources=datasetreplicas/status,verbs=get;update;patch
+
+func (r *DatasetReplicaReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	replica := &datasetv1alpha1.DatasetReplica{}
+	err := r.Get(ctx, req.NamespacedName, replica)
+	if err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if kubeutils.IsDeleted(replica) {
+		return ctrl.Result{}, nil
+	}
+
+	prevStatus := replica.Status.DeepCopy()
+	reconcilers := []replicaReconciler{
+		{typ: condTypeConfig, rec: r.validate},
+	}
+	if replica.Spec.Role == datasetv1alpha1.DatasetReplicaRolePrimary {
+		reconcilers = append(reconcilers, replicaReconciler{typ: condTypeReplica, rec: r.reconcilePrimary})
+	} else {
+		reconcilers = append(reconcilers, replicaReconciler{typ: condTypeReplica, rec: r.reconcileSecondary})
+	}
+	for _, rr := range reconcilers {
+		err := rr.rec(ctx, replica)
+		replica.Status.Conditions = kubeutils.SetCondition(replica.Status.Conditions, rr.typ, err)
+		if err != nil {
+			log.Errorf("error reconciling dataset replica for %s/%s: %v", replica.Namespace, replica.Name, err)
+			break
+		}
+	}
+
+	if !reflect.DeepEqual(replica.Status, *prevStatus) {
+		statusBase := replica.DeepCopy()
+		statusBase.Status = *prevStatus
+		err := r.Status().Patch(ctx, replica, client.MergeFrom(statusBase))
+		if err != nil {
+			return ctrl.Result{RequeueAfter: time.Second * 30}, err
+		}
+	}
+
+	switch {
+	case replica.Status.JobName != "":
+		// the job is watched
+		return ctrl.Result{}, nil
+	case replica.Spec.Role == datasetv1alpha1.DatasetReplicaRoleSecondary:
+		return ctrl.Result{RequeueAfter: max(time.Until(replica.Status.LastPollTime.Add(replicaPollInterval(replica))), time.Second)}, nil
+	case !kubeutils.IsConditionReady(replica.Status.Conditions, condTypeReplica):
+		return ctrl.Result{RequeueAfter: time.Second * 30}, nil
+	default:
+		// the dataset is watched
+		return ctrl.Result{}, nil
+	}
+}
+
+func (r *DatasetReplicaReconciler) validate(_ context.Context, replica *datasetv1alpha1.DatasetReplica) error {
+	if replica.Spec.PollInterval != nil && replica.Spec.PollInterval.Duration < time.Minute {
+		return fmt.Errorf("pollInterval should be at least 1m, got: %s", replica.Spec.PollInterval.Duration)
+	}
+	if replica.Spec.Store != nil {
+		return validateReplicaStoreURI(replica.Spec.Store.URI)
+	}
+
+	return nil
+}
+
+func validateReplicaStoreURI(uri string) error {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "s3" || u.Host == "" {
+		return fmt.Errorf("invalid store uri %s, must be s3://<bucket>/<path/to/directory>", uri)
+	}
+
+	return nil
+}
+
+// newReplicaJobSpec returns the spec of a job running the data loader with
+// the credentials of the store.
+func newReplicaJobSpec(secretRef string) (batchv1.JobSpec, error) {
+	jobSpec, err := loaderJobSpec(nil)
+	if err != nil {
+		return jobSpec, err
+	}
+
+	podSpec := &jobSpec.Template.Spec
+	container := &podSpec.Containers[0]
+	container.Name = "dataset-loader"
+	if secretRef != "" {
+		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+			Name: "dataset-secret",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: secretRef,
+				},
+			},
+		})
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      "dataset-secret",
+			MountPath: constants.DatasetJobSecretsMountPath,
+			ReadOnly:  true,
+		})
+	}
+
+	return jobSpec, nil
+}
+
+func (r *DatasetReplicaReconciler) createJob(ctx context.Context, replica *datasetv1alpha1.DatasetReplica, jobName string, jobSpec batchv1.JobSpec) error {
+	container := &jobSpec.Template.Spec.Containers[0]
+	if container.TerminationMessagePath != "" {
+		container.Args = append(container.Args, fmt.Sprintf("--termination-message-path=%s", container.TerminationMessagePath))
+	}
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      jobName,
+			Namespace: replica.Namespace,
+			Labels: map[string]string{
+				constants.DatasetNameLabel: replica.Spec.DatasetName,
+			},
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(replica, datasetv1alpha1.GroupVersion.WithKind("DatasetReplica"))},
+		},
+		Spec: jobSpec,
+	}
+	if err := r.Create(ctx, job); err != nil && !k8serrors.IsAlreadyExists(err) {
+		return err
+	}
+	replica.Status.JobName = jobName
+
+	return nil
+}
+
+// getReplicaJob returns the job of the replica, nil when it is gone.
+func (r *DatasetReplicaReconciler) getReplicaJob(ctx context.Context, replica *datasetv1alpha1.DatasetReplica) (*batchv1.Job, error) {
+	job := &batchv1.Job{}
+	err := r.Get(ctx, client.ObjectKey{Namespace: replica.Namespace, Name: replica.Status.JobName}, job)
+	if k8serrors.IsNotFound(err) {
+		replica.Status.JobName = ""
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+func jobFailed(job *batchv1.Job) bool {
+	return lo.ContainsBy(job.Status.Conditions, func(item batchv1.JobCondition) bool {
+		return item.Type == batchv1.JobFailed && item.Status == corev1.ConditionTrue
+	})
+}
+
+func (r *DatasetReplicaReconciler) deleteJob(ctx context.Context, replica *datasetv1alpha1.DatasetReplica, job *batchv1.Job) error {
+	err := r.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground))
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+	replica.Status.JobName = ""
+
+	return nil
+}
+
+// reconcilePrimary publishes each round loaded by the dataset to the store.
+// a failed round is published again once a new round is loaded.
+func (r *DatasetReplicaReconciler) reconcilePrimary(ctx context.Context, replica *datasetv1alpha1.DatasetReplica) error {
+	ds := &datasetv1alpha1.Dataset{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: replica.Namespace, Name: replica.Spec.DatasetName}, ds); err != nil {
+		return err
+	}
+	round, digest := sourceRoundAndDigest(ds)
+
+	if replica.Status.JobName != "" {
+		job, err := r.getReplicaJob(ctx, replica)
+		if err != nil || job == nil {
+			return err
+		}
+		switch {
+		case job.Status.Succeeded > 0:
+			result, err := getJobResult(ctx, r.Client, job)
+			if err != nil || result.Round == 0 {
+				log.Warnf("failed to get the round published by job %s/%s: %v", job.Namespace, job.Name, err)
+				if job.Name == genReplicaPublishJobName(replica.Name, round) {
+					// the job published the current round of the dataset
+					result.Round, result.Digest = round, digest
+				}
+			}
+			if result.Round != 0 {
+				replica.Status.Round = result.Round
+				replica.Status.Digest = result.Digest
+				replica.Status.LastSyncTime = metav1.Time{Time: time.Now()}
+			}
+			if err := r.deleteJob(ctx, replica, job); err != nil {
+				return err
+			}
+		case jobFailed(job):
+			if job.Name == genReplicaPublishJobName(replica.Name, round) {
+				// the failed job is kept until a new round is loaded
+				return fmt.Errorf("job %s publishing round %d failed", job.Name, round)
+			}
+			if err := r.deleteJob(ctx, replica, job); err != nil {
+				return err
+			}
+		default:
+			return nil
+		}
+	}
+
+	if ds.Status.Phase != datasetv1alpha1.DatasetStatusPhaseReady || ds.Status.PVCName == "" || round == 0 {
+		// the round is published once it is loaded
+		return nil
+	}
+	if round == replica.Status.Round && digest == replica.Status.Digest {
+		return nil
+	}
+
+	store := replica.Spec.Store
+	jobSpec, err := newReplicaJobSpec(store.SecretRef)
+	if err != nil {
+		return err
+	}
+	podSpec := &jobSpec.Template.Spec
+	container := &podSpec.Containers[0]
+
+	// the data is only read
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name: "dataset-pvc",
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: ds.Status.PVCName,
+				ReadOnly:  true,
+			},
+		},
+	})
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+		Name:      "dataset-pvc",
+		MountPath: datasetPVCMountPath,
+		SubPath:   datasetSubPath(ds),
+		ReadOnly:  true,
+	})
+
+	args := []string{
+		"replica",
+		"publish",
+		string(store.Type),
+		store.URI,
+		fmt.Sprintf("--round=%d", round),
+	}
+	if digest != "" {
+		args = append(args, fmt.Sprintf("--digest=%s", digest))
+	}
+	args = append(args, loaderOptionArgs(store.Options)...)
+	args = append(args, fmt.Sprintf("--mount-path=%s", lo.CoalesceOrEmpty(ds.Spec.MountOptions.Path, "/")))
+	args = append(args, fmt.Sprintf("--mount-root=%s", datasetPVCMountPath))
+	container.Args = args
+
+	return r.createJob(ctx, replica, genReplicaPublishJobName(replica.Name, round), jobSpec)
+}
+
+// reconcileSecondary polls the head of the store, which is the source of the
+// dataset, and starts a new round of the dataset loading the last round
+// published.
+func (r *DatasetReplicaReconciler) reconcileSecondary(ctx context.Context, replica *datasetv1alpha1.DatasetReplica) error {
+	ds := &datasetv1alpha1.Dataset{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: replica.Namespace, Name: replica.Spec.DatasetName}, ds); err != nil {
+		return err
+	}
+	if ds.Spec.Source.Type != datasetv1alpha1.DatasetTypeS3 {
+		return fmt.Errorf("the source of dataset %s must be of type %s, got: %s", ds.Name, datasetv1alpha1.DatasetTypeS3, ds.Spec.Source.Type)
+	}
+	if err := validateReplicaStoreURI(ds.Spec.Source.URI); err != nil {
+		return err
+	}
+
+	latest := strconv.Itoa(int(replica.Status.LatestRound))
+	if replica.Status.LatestRound > 0 && replica.Status.Round != replica.Status.LatestRound &&
+		ds.Spec.Source.Options["replicaRound"] == latest && ds.Status.Phase == datasetv1alpha1.DatasetStatusPhaseReady &&
+		ds.Status.LastSucceedRound == ds.Spec.DataSyncRound {
+		// the dataset loaded the last round published
+		replica.Status.Round = replica.Status.LatestRound
+		replica.Status.Digest = replica.Status.LatestDigest
+		replica.Status.LastSyncTime = metav1.Time{Time: time.Now()}
+	}
+
+	if replica.Status.JobName != "" {
+		job, err := r.getReplicaJob(ctx, replica)
+		if err != nil {
+			return err
+		}
+		switch {
+		case job == nil:
+		case job.Status.Succeeded > 0:
+			result, resultErr := getJobResult(ctx, r.Client, job)
+			if err := r.deleteJob(ctx, replica, job); err != nil {
+				return err
+			}
+			replica.Status.LastPollTime = metav1.Time{Time: time.Now()}
+			if resultErr != nil {
+				return fmt.Errorf("failed to get the head of the store read by job %s: %v", job.Name, resultErr)
+			}
+			replica.Status.LatestRound = result.Round
+			replica.Status.LatestDigest = result.Digest
+		case jobFailed(job):
+			if err := r.deleteJob(ctx, replica, job); err != nil {
+				return err
+			}
+			replica.Status.LastPollTime = metav1.Time{Time: time.Now()}
+			return fmt.Errorf("job %s reading the head of the store failed", job.Name)
+		default:
+			return nil
+		}
+	} else if now := time.Now(); !now.Before(replica.Status.LastPollTime.Add(replicaPollInterval(replica))) {
+		jobSpec, err := newReplicaJobSpec(ds.Spec.SecretRef)
+		if err != nil {
+			return err
+		}
+		container := &jobSpec.Template.Spec.Containers[0]
+		container.Args = append([]string{
+			"replica",
+			"head",
+			string(ds.Spec.Source.Type),
+			ds.Spec.Source.URI,
+		}, loaderOptionArgs(ds.Spec.Source.Options)...)
+		return r.createJob(ctx, replica, genReplicaHeadJobName(replica.Name, now), jobSpec)
+	}
+
+	latest = strconv.Itoa(int(replica.Status.LatestRound))
+	if replica.Status.LatestRound == 0 || replica.Status.LatestRound == replica.Status.Round ||
+		ds.Spec.Source.Options["replicaRound"] == latest || ds.Status.InProcessing {
+		return nil
+	}
+
+	// a new round of the dataset loads the last round published
+	base := ds.DeepCopy()
+	ds.Spec.Source.Options = lo.Assign(ds.Spec.Source.Options, map[string]string{"replicaRound": latest})
+	ds.Spec.DataSyncRound++
+	if err := r.Patch(ctx, ds, client.MergeFrom(base)); err != nil {
+		return fmt.Errorf("failed to start round %d of dataset %s: %w", ds.Spec.DataSyncRound, ds.Name, err)
+	}
+	log.Infof("dataset %s/%s loads round %d of the replica store in round %d", ds.Namespace, ds.Name, replica.Status.LatestRound, ds.Spec.DataSyncRound)
+
+	return nil
+}
+
+// requestsOfDataset maps the changes of datasets to their replicas.
+func (r *DatasetReplicaReconciler) requestsOfDataset(ctx context.Context, obj client.Object) []reconcile.Request {
+	replicas := &datasetv1alpha1.DatasetReplicaList{}
+	if err := r.List(ctx, replicas, client.InNamespace(obj.GetNamespace())); err != nil {
+		log.Errorf("failed to list dataset replicas of namespace %s: %v", obj.GetNamespace(), err)
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, replica := range replicas.Items {
+		if replica.Spec.DatasetName == obj.GetName() {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&replica)})
+		}
+	}
+
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *DatasetReplicaReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&datasetv1alpha1.DatasetReplica{}).
+		Owns(&batchv1.Job{}).
+		Watches(&datasetv1alpha1.Dataset{}, handler.EnqueueRequestsFromMapFunc(r.requestsOfDataset)).
+		Complete(r)
+}
@@ -0,0 +1,209 @@
+package dataset
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	datasetv1alpha1 "github.com/BaizeAI/dataset/api/dataset/v1alpha1"
+	"github.com/BaizeAI/dataset/config"
+	"github.com/BaizeAI/dataset/pkg/kubeutils"
+)
+
+func newReplicaTestClient(t *testing.T, objs ...client.Object) client.Client {
+	scheme := runtime.NewScheme()
+	require.NoError(t, datasetv1alpha1.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+	require.NoError(t, batchv1.AddToScheme(scheme))
+
+	return fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(objs...).
+		WithIndex(&datasetv1alpha1.Dataset{}, referenceSourceField, indexReferenceSource).
+		WithStatusSubresource(&datasetv1alpha1.Dataset{}, &datasetv1alpha1.DatasetReplica{}, &batchv1.Job{}).
+		Build()
+}
+
+func succeedReplicaJob(t *testing.T, c client.Client, job *batchv1.Job, message string) {
+	require.NoError(t, c.Create(context.Background(), &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      job.Name + "-abcde",
+			Namespace: job.Namespace,
+			Labels:    map[string]string{batchv1.JobNameLabel: job.Name},
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name: "dataset-loader",
+				State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+					Message: message,
+				}},
+			}},
+		},
+	}))
+	job.Status.Succeeded = 1
+	require.NoError(t, c.Status().Update(context.Background(), job))
+}
+
+func TestDatasetReplicaReconcilerPrimary(t *testing.T) {
+	require.NoError(t, config.ParseConfigFromFileContent(""))
+
+	ds := &datasetv1alpha1.Dataset{
+		ObjectMeta: metav1.ObjectMeta{Name: "models", Namespace: "default"},
+		Spec: datasetv1alpha1.DatasetSpec{
+			Source:        datasetv1alpha1.DatasetSource{Type: datasetv1alpha1.DatasetTypeHuggingFace, URI: "huggingface://ns/model"},
+			MountOptions:  datasetv1alpha1.MountOptions{Path: "/models"},
+			DataSyncRound: 2,
+		},
+		Status: datasetv1alpha1.DatasetStatus{
+			Phase:            datasetv1alpha1.DatasetStatusPhaseProcessing,
+			PVCName:          "dataset-models",
+			LastSucceedRound: 1,
+		},
+	}
+	replica := &datasetv1alpha1.DatasetReplica{
+		ObjectMeta: metav1.ObjectMeta{Name: "models", Namespace: "default", UID: "uid"},
+		Spec: datasetv1alpha1.DatasetReplicaSpec{
+			Role:        datasetv1alpha1.DatasetReplicaRolePrimary,
+			DatasetName: ds.Name,
+			Store: &datasetv1alpha1.DatasetReplicaStore{
+				Type:      datasetv1alpha1.DatasetTypeS3,
+				URI:       "s3://replicas/models",
+				SecretRef: "store-credentials",
+			},
+		},
+	}
+	c := newReplicaTestClient(t, ds, replica)
+	r := &DatasetReplicaReconciler{Client: c, Scheme: c.Scheme()}
+
+	reconcile := func() *datasetv1alpha1.DatasetReplica {
+		_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(replica)})
+		require.NoError(t, err)
+		got := &datasetv1alpha1.DatasetReplica{}
+		require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(replica), got))
+		return got
+	}
+
+	// the round is published once it is loaded
+	got := reconcile()
+	assert.Empty(t, got.Status.JobName)
+	assert.True(t, kubeutils.IsConditionReady(got.Status.Conditions, condTypeReplica))
+
+	ds.Status.Phase = datasetv1alpha1.DatasetStatusPhaseReady
+	ds.Status.LastSucceedRound = 2
+	ds.Status.SyncRoundStatuses = []datasetv1alpha1.DataLoadStatus{{Round: 2, Succeed: true, Digest: "sha256:abc"}}
+	require.NoError(t, c.Status().Update(context.Background(), ds))
+
+	got = reconcile()
+	assert.Equal(t, "dataset-replica-models-publish-2", got.Status.JobName)
+	job := &batchv1.Job{}
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: got.Status.JobName}, job))
+	assert.Equal(t, replica.Name, job.OwnerReferences[0].Name)
+	container := job.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, []string{
+		"replica",
+		"publish",
+		"S3",
+		"s3://replicas/models",
+		"--round=2",
+		"--digest=sha256:abc",
+		"--mount-path=/models",
+		"--mount-root=/baize/dataset/data",
+	}, container.Args)
+	assert.Contains(t, container.VolumeMounts, corev1.VolumeMount{Name: "dataset-pvc", MountPath: "/baize/dataset/data", ReadOnly: true})
+	assert.Contains(t, container.VolumeMounts, corev1.VolumeMount{Name: "dataset-secret", MountPath: "/run/dataset/secrets", ReadOnly: true})
+
+	succeedReplicaJob(t, c, job, `{"round":2,"digest":"sha256:abc"}`)
+	got = reconcile()
+	assert.Empty(t, got.Status.JobName)
+	assert.Equal(t, int32(2), got.Status.Round)
+	assert.Equal(t, "sha256:abc", got.Status.Digest)
+	assert.False(t, got.Status.LastSyncTime.IsZero())
+	assert.Error(t, c.Get(context.Background(), client.ObjectKeyFromObject(job), &batchv1.Job{}))
+
+	// a published round is not published again
+	got = reconcile()
+	assert.Empty(t, got.Status.JobName)
+}
+
+func TestDatasetReplicaReconcilerSecondary(t *testing.T) {
+	require.NoError(t, config.ParseConfigFromFileContent(""))
+
+	ds := &datasetv1alpha1.Dataset{
+		ObjectMeta: metav1.ObjectMeta{Name: "models", Namespace: "default"},
+		Spec: datasetv1alpha1.DatasetSpec{
+			Source: datasetv1alpha1.DatasetSource{
+				Type:    datasetv1alpha1.DatasetTypeS3,
+				URI:     "s3://replicas/models",
+				Options: map[string]string{"endpoint": "https://s3.example.com"},
+			},
+			DataSyncRound: 1,
+		},
+		Status: datasetv1alpha1.DatasetStatus{
+			Phase:            datasetv1alpha1.DatasetStatusPhaseReady,
+			LastSucceedRound: 1,
+		},
+	}
+	replica := &datasetv1alpha1.DatasetReplica{
+		ObjectMeta: metav1.ObjectMeta{Name: "models", Namespace: "default", UID: "uid"},
+		Spec: datasetv1alpha1.DatasetReplicaSpec{
+			Role:        datasetv1alpha1.DatasetReplicaRoleSecondary,
+			DatasetName: ds.Name,
+		},
+	}
+	c := newReplicaTestClient(t, ds, replica)
+	r := &DatasetReplicaReconciler{Client: c, Scheme: c.Scheme()}
+
+	reconcile := func() (*datasetv1alpha1.DatasetReplica, ctrl.Result) {
+		res, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(replica)})
+		require.NoError(t, err)
+		got := &datasetv1alpha1.DatasetReplica{}
+		require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(replica), got))
+		return got, res
+	}
+
+	// the head of the store is read by a job
+	got, _ := reconcile()
+	require.NotEmpty(t, got.Status.JobName)
+	job := &batchv1.Job{}
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: got.Status.JobName}, job))
+	assert.Equal(t, []string{
+		"replica",
+		"head",
+		"S3",
+		"s3://replicas/models",
+		"--options=endpoint=https://s3.example.com",
+	}, job.Spec.Template.Spec.Containers[0].Args)
+
+	// a new round of the dataset loads the last round published
+	succeedReplicaJob(t, c, job, `{"round":3,"digest":"sha256:abc"}`)
+	got, res := reconcile()
+	assert.Empty(t, got.Status.JobName)
+	assert.Equal(t, int32(3), got.Status.LatestRound)
+	assert.Zero(t, got.Status.Round)
+	assert.InDelta(t, defaultReplicaPollInterval, res.RequeueAfter, float64(time.Minute))
+	gotDs := &datasetv1alpha1.Dataset{}
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(ds), gotDs))
+	assert.Equal(t, int32(2), gotDs.Spec.DataSyncRound)
+	assert.Equal(t, map[string]string{"endpoint": "https://s3.example.com", "replicaRound": "3"}, gotDs.Spec.Source.Options)
+
+	// the round is mirrored once the dataset loaded it
+	got, _ = reconcile()
+	assert.Zero(t, got.Status.Round)
+	gotDs.Status.LastSucceedRound = 2
+	require.NoError(t, c.Status().Update(context.Background(), gotDs))
+	got, _ = reconcile()
+	assert.Equal(t, int32(3), got.Status.Round)
+	assert.Equal(t, "sha256:abc", got.Status.Digest)
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(ds), gotDs))
+	assert.Equal(t, int32(2), gotDs.Spec.DataSyncRound)
+}
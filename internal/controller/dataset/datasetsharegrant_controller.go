@@ -0,0 +1,59 @@
+package dataset
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	datasetv1alpha1 "github.com/BaizeAI/dataset/api/dataset/v1alpha1"
+	"github.com/BaizeAI/dataset/pkg/log"
+)
+
+// DatasetShareGrantReconciler reconciles a DatasetShareGrant object, it
+// expires the grants. the datasets whose share is affected by the grants are
+// reconciled by DatasetReconciler.
+type DatasetShareGrantReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+//+kubebuilder:rbac:groups=dataset.baizeai.io,resources=datasetsharegrants,verbs=get;list;watch
+//+kubebuilder:rbac:groups=dataset.baizeai.io,resources=datasetsharegrants/status,verbs=get;update;patch
+
+func (r *DatasetShareGrantReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	grant := &datasetv1alpha1.DatasetShareGrant{}
+	err := r.Get(ctx, req.NamespacedName, grant)
+	if err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	now := time.Now()
+	phase := datasetv1alpha1.DatasetShareGrantPhaseActive
+	if shareGrantExpired(grant, now) {
+		phase = datasetv1alpha1.DatasetShareGrantPhaseExpired
+	}
+	if grant.Status.Phase != phase {
+		statusBase := grant.DeepCopy()
+		grant.Status.Phase = phase
+		if err := r.Status().Patch(ctx, grant, client.MergeFrom(statusBase)); err != nil {
+			log.Errorf("error update status for share grant %s/%s: %v", grant.Namespace, grant.Name, err)
+			return ctrl.Result{RequeueAfter: time.Second * 30}, err
+		}
+	}
+
+	if phase == datasetv1alpha1.DatasetShareGrantPhaseActive && grant.Spec.ExpiresAt != nil {
+		return ctrl.Result{RequeueAfter: grant.Spec.ExpiresAt.Sub(now)}, nil
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *DatasetShareGrantReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&datasetv1alpha1.DatasetShareGrant{}).
+		Complete(r)
+}
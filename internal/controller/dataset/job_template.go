@@ -0,0 +1,178 @@
+package dataset
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/samber/lo"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"sigs.k8s.io/yaml"
+
+	datasetv1alpha1 "github.com/BaizeAI/dataset/api/dataset/v1alpha1"
+	"github.com/BaizeAI/dataset/config"
+)
+
+// loaderContainerName is the name of the data loader container of the job
+// template, the other containers of the jobs are copies of it.
+const loaderContainerName = "dataset-loader"
+
+// loaderJobSpec returns the job spec of the dataset_job_spec_yaml of the
+// controller config, with the overrides merged onto its pod template.
+func loaderJobSpec(overrides *datasetv1alpha1.LoaderPodOverrides) (batchv1.JobSpec, error) {
+	jobSpec := batchv1.JobSpec{}
+	err := yaml.Unmarshal([]byte(config.GetDatasetJobSpecYaml()), &jobSpec)
+	if err != nil {
+		return jobSpec, fmt.Errorf("unmarshal dataset job spec yaml failed: %w", err)
+	}
+	if len(jobSpec.Template.Spec.Containers) == 0 {
+		return jobSpec, fmt.Errorf("dataset job spec has no container")
+	}
+	jobSpec.Template.Spec.Containers[0].Name = loaderContainerName
+
+	if overrides == nil {
+		return jobSpec, nil
+	}
+	template, err := mergePodOverrides(jobSpec.Template, overrides)
+	if err != nil {
+		return jobSpec, fmt.Errorf("merge podOverrides failed: %w", err)
+	}
+	jobSpec.Template = template
+
+	return jobSpec, nil
+}
+
+// datasetServiceAccount returns the name of the service account the pods of
+// the dataset run as.
+func datasetServiceAccount(ds *datasetv1alpha1.Dataset) (string, error) {
+	jobSpec, err := loaderJobSpec(ds.Spec.PodOverrides)
+	if err != nil {
+		return "", err
+	}
+
+	return lo.CoalesceOrEmpty(jobSpec.Template.Spec.ServiceAccountName, "default"), nil
+}
+
+// mergePodOverrides merges the overrides onto the pod template with strategic
+// merge semantics, e.g. env and volumes are merged by name.
+func mergePodOverrides(template corev1.PodTemplateSpec, overrides *datasetv1alpha1.LoaderPodOverrides) (corev1.PodTemplateSpec, error) {
+	metadata := map[string]any{}
+	if len(overrides.Labels) > 0 {
+		metadata["labels"] = overrides.Labels
+	}
+	if len(overrides.Annotations) > 0 {
+		metadata["annotations"] = overrides.Annotations
+	}
+
+	spec := map[string]any{}
+	if len(overrides.NodeSelector) > 0 {
+		spec["nodeSelector"] = overrides.NodeSelector
+	}
+	if len(overrides.Tolerations) > 0 {
+		spec["tolerations"] = overrides.Tolerations
+	}
+	if overrides.Affinity != nil {
+		spec["affinity"] = overrides.Affinity
+	}
+	if overrides.ServiceAccountName != "" {
+		spec["serviceAccountName"] = overrides.ServiceAccountName
+	}
+	if len(overrides.ImagePullSecrets) > 0 {
+		spec["imagePullSecrets"] = overrides.ImagePullSecrets
+	}
+	if overrides.PriorityClassName != "" {
+		spec["priorityClassName"] = overrides.PriorityClassName
+	}
+	if len(overrides.Volumes) > 0 {
+		spec["volumes"] = overrides.Volumes
+	}
+	container := map[string]any{"name": loaderContainerName}
+	if len(overrides.Env) > 0 {
+		container["env"] = overrides.Env
+	}
+	if len(overrides.VolumeMounts) > 0 {
+		container["volumeMounts"] = overrides.VolumeMounts
+	}
+	if len(container) > 1 {
+		spec["containers"] = []any{container}
+	}
+
+	original, err := json.Marshal(template)
+	if err != nil {
+		return template, err
+	}
+	patch, err := json.Marshal(map[string]any{"metadata": metadata, "spec": spec})
+	if err != nil {
+		return template, err
+	}
+	merged, err := strategicpatch.StrategicMergePatch(original, patch, corev1.PodTemplateSpec{})
+	if err != nil {
+		return template, err
+	}
+
+	result := corev1.PodTemplateSpec{}
+	if err := json.Unmarshal(merged, &result); err != nil {
+		return template, err
+	}
+
+	return result, nil
+}
+
+// validatePodOverrides checks that the volumes of the overrides do not take
+// the names of the volumes of the controller, and that the volume mounts are
+// of the volumes of the overrides. the pods of the controller must not give
+// the authors of the datasets more than the pods they can create, the volumes
+// are limited to those of the namespace, and the service accounts and the
+// hostPath volumes to those the controller config allows.
+func validatePodOverrides(ds *datasetv1alpha1.Dataset) error {
+	overrides := ds.Spec.PodOverrides
+	if overrides == nil {
+		return nil
+	}
+
+	if overrides.ServiceAccountName != "" && !lo.Contains(config.GetPodOverridesServiceAccounts(), overrides.ServiceAccountName) {
+		return fmt.Errorf("podOverrides.serviceAccountName: service account %s is not allowed by pod_overrides_service_accounts of the controller config", overrides.ServiceAccountName)
+	}
+	volumes := make(map[string]bool, len(overrides.Volumes))
+	for _, volume := range overrides.Volumes {
+		if strings.HasPrefix(volume.Name, "dataset-") {
+			return fmt.Errorf("podOverrides.volumes: name %s is reserved, it should not start with dataset-", volume.Name)
+		}
+		if err := validateOverrideVolume(volume); err != nil {
+			return fmt.Errorf("podOverrides.volumes: %w", err)
+		}
+		volumes[volume.Name] = true
+	}
+	for _, mount := range overrides.VolumeMounts {
+		if !volumes[mount.Name] {
+			return fmt.Errorf("podOverrides.volumeMounts: volume %s is not in podOverrides.volumes", mount.Name)
+		}
+	}
+
+	return nil
+}
+
+// validateOverrideVolume checks that the volume is a configMap, secret,
+// emptyDir, persistentVolumeClaim or projected one, or a hostPath one under
+// pod_overrides_host_paths of the controller config.
+func validateOverrideVolume(volume corev1.Volume) error {
+	source := volume.VolumeSource
+	switch {
+	case source.ConfigMap != nil, source.Secret != nil, source.EmptyDir != nil,
+		source.PersistentVolumeClaim != nil, source.Projected != nil:
+		return nil
+	case source.HostPath != nil:
+		hostPath := path.Clean(source.HostPath.Path)
+		if path.IsAbs(hostPath) && lo.ContainsBy(config.GetPodOverridesHostPaths(), func(root string) bool {
+			return hostPath == root || strings.HasPrefix(hostPath, root+"/")
+		}) {
+			return nil
+		}
+		return fmt.Errorf("hostPath %s of volume %s is not under pod_overrides_host_paths of the controller config", source.HostPath.Path, volume.Name)
+	default:
+		return fmt.Errorf("volume %s should be a configMap, secret, emptyDir, persistentVolumeClaim or projected volume", volume.Name)
+	}
+}
@@ -0,0 +1,185 @@
+package dataset
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	datasetv1alpha1 "github.com/BaizeAI/dataset/api/dataset/v1alpha1"
+	"github.com/BaizeAI/dataset/config"
+)
+
+func TestLoaderJobSpec(t *testing.T) {
+	require.NoError(t, config.ParseConfigFromFileContent(`
+dataset_job_spec_yaml: |
+  template:
+    metadata:
+      labels:
+        app: data-loader
+    spec:
+      nodeSelector:
+        pool: default
+      tolerations:
+      - key: default
+        operator: Exists
+      imagePullSecrets:
+      - name: registry
+      containers:
+      - image: data-loader:latest
+        env:
+        - name: HTTP_PROXY
+          value: http://proxy:3128
+        - name: LOG_LEVEL
+          value: info
+`))
+	t.Cleanup(func() { _ = config.ParseConfigFromFileContent("") })
+
+	jobSpec, err := loaderJobSpec(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "dataset-loader", jobSpec.Template.Spec.Containers[0].Name)
+
+	jobSpec, err = loaderJobSpec(&datasetv1alpha1.LoaderPodOverrides{
+		Labels:             map[string]string{"team": "research"},
+		NodeSelector:       map[string]string{"zone": "a"},
+		Tolerations:        []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpExists}},
+		ServiceAccountName: "loader",
+		ImagePullSecrets:   []corev1.LocalObjectReference{{Name: "private"}},
+		PriorityClassName:  "low",
+		Env:                []corev1.EnvVar{{Name: "LOG_LEVEL", Value: "debug"}},
+		Volumes:            []corev1.Volume{{Name: "ca", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: "ca"}}}}},
+		VolumeMounts:       []corev1.VolumeMount{{Name: "ca", MountPath: "/etc/ssl/certs"}},
+	})
+	require.NoError(t, err)
+	template := jobSpec.Template
+	assert.Equal(t, map[string]string{"app": "data-loader", "team": "research"}, template.Labels)
+	assert.Equal(t, map[string]string{"pool": "default", "zone": "a"}, template.Spec.NodeSelector)
+	assert.Equal(t, []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpExists}}, template.Spec.Tolerations)
+	assert.Equal(t, "loader", template.Spec.ServiceAccountName)
+	assert.ElementsMatch(t, []corev1.LocalObjectReference{{Name: "registry"}, {Name: "private"}}, template.Spec.ImagePullSecrets)
+	assert.Equal(t, "low", template.Spec.PriorityClassName)
+	assert.Equal(t, "ca", template.Spec.Volumes[0].Name)
+	require.Len(t, template.Spec.Containers, 1)
+	container := template.Spec.Containers[0]
+	assert.Equal(t, "data-loader:latest", container.Image)
+	assert.ElementsMatch(t, []corev1.EnvVar{
+		{Name: "HTTP_PROXY", Value: "http://proxy:3128"},
+		{Name: "LOG_LEVEL", Value: "debug"},
+	}, container.Env)
+	assert.Equal(t, []corev1.VolumeMount{{Name: "ca", MountPath: "/etc/ssl/certs"}}, container.VolumeMounts)
+}
+
+func TestValidatePodOverrides(t *testing.T) {
+	require.NoError(t, config.ParseConfigFromFileContent(""))
+
+	configMap := corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: "ca"}}}
+	ds := &datasetv1alpha1.Dataset{Spec: datasetv1alpha1.DatasetSpec{PodOverrides: &datasetv1alpha1.LoaderPodOverrides{
+		Volumes:      []corev1.Volume{{Name: "ca", VolumeSource: configMap}},
+		VolumeMounts: []corev1.VolumeMount{{Name: "ca", MountPath: "/etc/ssl/certs"}},
+	}}}
+	assert.NoError(t, validatePodOverrides(ds))
+
+	ds.Spec.PodOverrides.VolumeMounts = append(ds.Spec.PodOverrides.VolumeMounts, corev1.VolumeMount{Name: "cache", MountPath: "/cache"})
+	assert.EqualError(t, validatePodOverrides(ds), "podOverrides.volumeMounts: volume cache is not in podOverrides.volumes")
+
+	ds.Spec.PodOverrides.Volumes = append(ds.Spec.PodOverrides.Volumes, corev1.Volume{Name: "dataset-pvc", VolumeSource: configMap})
+	assert.EqualError(t, validatePodOverrides(ds), "podOverrides.volumes: name dataset-pvc is reserved, it should not start with dataset-")
+}
+
+func TestValidatePodOverridesPrivileges(t *testing.T) {
+	t.Cleanup(func() { _ = config.ParseConfigFromFileContent("") })
+
+	hostPath := func(p string) *datasetv1alpha1.LoaderPodOverrides {
+		return &datasetv1alpha1.LoaderPodOverrides{Volumes: []corev1.Volume{{
+			Name:         "host",
+			VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: p}},
+		}}}
+	}
+	for _, testCase := range []struct {
+		name      string
+		config    string
+		overrides *datasetv1alpha1.LoaderPodOverrides
+		wantErr   string
+	}{
+		{
+			name:      "service account not allowed",
+			overrides: &datasetv1alpha1.LoaderPodOverrides{ServiceAccountName: "admin"},
+			wantErr:   "podOverrides.serviceAccountName: service account admin is not allowed by pod_overrides_service_accounts of the controller config",
+		},
+		{
+			name:      "service account allowed",
+			config:    "pod_overrides_service_accounts: [loader]",
+			overrides: &datasetv1alpha1.LoaderPodOverrides{ServiceAccountName: "loader"},
+		},
+		{
+			name:      "host path not allowed",
+			overrides: hostPath("/var/lib/kubelet"),
+			wantErr:   "podOverrides.volumes: hostPath /var/lib/kubelet of volume host is not under pod_overrides_host_paths of the controller config",
+		},
+		{
+			name:      "host path escaping the allowed directory",
+			config:    "pod_overrides_host_paths: [/data/cache]",
+			overrides: hostPath("/data/cache/../../etc"),
+			wantErr:   "podOverrides.volumes: hostPath /data/cache/../../etc of volume host is not under pod_overrides_host_paths of the controller config",
+		},
+		{
+			name:      "host path allowed",
+			config:    "pod_overrides_host_paths: [/data/cache]",
+			overrides: hostPath("/data/cache/models"),
+		},
+		{
+			name: "other volume types",
+			overrides: &datasetv1alpha1.LoaderPodOverrides{Volumes: []corev1.Volume{{
+				Name:         "nfs",
+				VolumeSource: corev1.VolumeSource{NFS: &corev1.NFSVolumeSource{Server: "nfs", Path: "/"}},
+			}}},
+			wantErr: "podOverrides.volumes: volume nfs should be a configMap, secret, emptyDir, persistentVolumeClaim or projected volume",
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			require.NoError(t, config.ParseConfigFromFileContent(testCase.config))
+			err := validatePodOverrides(&datasetv1alpha1.Dataset{Spec: datasetv1alpha1.DatasetSpec{PodOverrides: testCase.overrides}})
+			if testCase.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, testCase.wantErr)
+			}
+		})
+	}
+}
+
+func TestDatasetReconciler_reconcileJobWithPodOverrides(t *testing.T) {
+	require.NoError(t, config.ParseConfigFromFileContent(""))
+
+	ds := &datasetv1alpha1.Dataset{
+		ObjectMeta: metav1.ObjectMeta{Name: "models", Namespace: "default"},
+		Spec: datasetv1alpha1.DatasetSpec{
+			Source:        datasetv1alpha1.DatasetSource{Type: datasetv1alpha1.DatasetTypeHuggingFace, URI: "huggingface://ns/model"},
+			DataSyncRound: 1,
+			PodOverrides: &datasetv1alpha1.LoaderPodOverrides{
+				ServiceAccountName: "loader",
+				Env:                []corev1.EnvVar{{Name: "HF_ENDPOINT", Value: "https://hf-mirror.example.com"}},
+			},
+			PostProcess: []datasetv1alpha1.PostProcessStep{{Name: "cleanup", Type: datasetv1alpha1.PostProcessStepTypeDelete, Path: "*.bin"}},
+		},
+		Status: datasetv1alpha1.DatasetStatus{PVCName: "dataset-models"},
+	}
+	c := newReplicaTestClient(t)
+	r := &DatasetReconciler{Client: c, Scheme: c.Scheme()}
+	ctx := context.Background()
+
+	require.NoError(t, r.reconcileJob(ctx, ds))
+	job := &batchv1.Job{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "default", Name: genJobName(ds.Name, 1)}, job))
+	podSpec := job.Spec.Template.Spec
+	assert.Equal(t, "loader", podSpec.ServiceAccountName)
+	// the containers copied from the data loader get the overrides too
+	for _, container := range append(podSpec.InitContainers, podSpec.Containers...) {
+		assert.Contains(t, container.Env, corev1.EnvVar{Name: "HF_ENDPOINT", Value: "https://hf-mirror.example.com"}, container.Name)
+	}
+}
@@ -0,0 +1,352 @@
+package dataset
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	datasetv1alpha1 "github.com/BaizeAI/dataset/api/dataset/v1alpha1"
+	"github.com/BaizeAI/dataset/pkg/kubeutils"
+	"github.com/BaizeAI/dataset/pkg/log"
+)
+
+const (
+	condTypePolicy = "Policy"
+)
+
+//+kubebuilder:rbac:groups=dataset.baizeai.io,resources=datasetpolicies,verbs=get;list;watch
+
+// policiesOfNamespace returns the DatasetPolicies applied to the datasets of
+// the namespace.
+func policiesOfNamespace(ctx context.Context, c client.Reader, namespace string) ([]datasetv1alpha1.DatasetPolicy, error) {
+	policies := &datasetv1alpha1.DatasetPolicyList{}
+	if err := c.List(ctx, policies); err != nil {
+		return nil, fmt.Errorf("list dataset policies error: %v", err)
+	}
+	if len(policies.Items) == 0 {
+		return nil, nil
+	}
+
+	ns := &corev1.Namespace{}
+	if err := c.Get(ctx, client.ObjectKey{Name: namespace}, ns); err != nil {
+		return nil, fmt.Errorf("fetch namespace %s error: %v", namespace, err)
+	}
+	var applied []datasetv1alpha1.DatasetPolicy
+	for _, policy := range policies.Items {
+		if policy.Spec.NamespaceSelector != nil {
+			s, err := metav1.LabelSelectorAsSelector(policy.Spec.NamespaceSelector)
+			if err != nil {
+				return nil, fmt.Errorf("parse namespace selector of dataset policy %s error: %v", policy.Name, err)
+			}
+			if !s.Matches(labels.Set(ns.Labels)) {
+				continue
+			}
+		}
+		applied = append(applied, policy)
+	}
+
+	return applied, nil
+}
+
+// checkPolicies returns an error listing how the dataset violates the
+// policies, nil when it complies with all of them.
+func checkPolicies(policies []datasetv1alpha1.DatasetPolicy, ds *datasetv1alpha1.Dataset) error {
+	var messages []string
+	for i := range policies {
+		violations := policyViolations(&policies[i], ds)
+		if len(violations) > 0 {
+			messages = append(messages, fmt.Sprintf("dataset policy %s: %s", policies[i].Name, strings.Join(violations, ", ")))
+		}
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("violates %s", strings.Join(messages, "; "))
+}
+
+// policyViolations returns how the dataset violates the policy, the limit of
+// maxConcurrentJobs is not a violation and is not checked here.
+func policyViolations(policy *datasetv1alpha1.DatasetPolicy, ds *datasetv1alpha1.Dataset) []string {
+	var violations []string
+	spec := policy.Spec
+	for _, source := range datasetSources(ds) {
+		if len(spec.AllowedTypes) > 0 && !lo.Contains(spec.AllowedTypes, source.Type) {
+			violations = append(violations, fmt.Sprintf("source type %s is not allowed", source.Type))
+			continue
+		}
+		if len(spec.AllowedURIHosts) == 0 {
+			continue
+		}
+		hosts, _ := sourceHosts(source, ds.Spec.PodOverrides)
+		for _, host := range hosts {
+			if host == "" {
+				violations = append(violations, fmt.Sprintf("host of source %s can not be determined", source.URI))
+				continue
+			}
+			if !lo.ContainsBy(spec.AllowedURIHosts, func(pattern string) bool {
+				matched, _ := path.Match(pattern, host)
+				return matched
+			}) {
+				violations = append(violations, fmt.Sprintf("host %q of source %s is not allowed", host, source.URI))
+			}
+		}
+	}
+	if ds.Spec.PodOverrides != nil && spec.AllowPodOverrides != nil && !*spec.AllowPodOverrides {
+		violations = append(violations, "podOverrides is not allowed")
+	}
+	if spec.MaxPVCSize != nil {
+		if size, ok := datasetPVCSize(ds); ok && size.Cmp(*spec.MaxPVCSize) > 0 {
+			violations = append(violations, fmt.Sprintf("pvc size %s exceeds %s", size.String(), spec.MaxPVCSize.String()))
+		}
+		if ds.Spec.Warmup != nil && ds.Spec.Warmup.StorageClassName != nil {
+			if size, ok := warmupClaimSize(ds); ok && size.Cmp(*spec.MaxPVCSize) > 0 {
+				violations = append(violations, fmt.Sprintf("warmup pvc size %s exceeds %s", size.String(), spec.MaxPVCSize.String()))
+			}
+		}
+	}
+	if ds.Spec.Share && spec.AllowShare != nil && !*spec.AllowShare {
+		violations = append(violations, "share is not allowed")
+	}
+
+	return violations
+}
+
+// datasetSources returns the sources of the dataset, either source or the
+// sources.
+func datasetSources(ds *datasetv1alpha1.Dataset) []datasetv1alpha1.DatasetSubSource {
+	if len(ds.Spec.Sources) > 0 {
+		return ds.Spec.Sources
+	}
+
+	return []datasetv1alpha1.DatasetSubSource{{
+		Type:    ds.Spec.Source.Type,
+		URI:     ds.Spec.Source.URI,
+		Options: ds.Spec.Source.Options,
+	}}
+}
+
+// sourceHosts returns the hosts the source is loaded from, by the options of
+// the source and the env of the podOverrides of the dataset, false when the
+// data of the type is not loaded from hosts. an empty host is one which can
+// not be determined, e.g. the channels of the environment.yaml of a CONDA
+// source without the channels option.
+func sourceHosts(source datasetv1alpha1.DatasetSubSource, overrides *datasetv1alpha1.LoaderPodOverrides) ([]string, bool) {
+	var uris []string
+	switch source.Type {
+	case datasetv1alpha1.DatasetTypeS3:
+		uris = []string{lo.CoalesceOrEmpty(source.Options["endpoint"], "https://s3.amazonaws.com")}
+	case datasetv1alpha1.DatasetTypeHuggingFace:
+		endpoint, ok := overrideEnv(overrides, "HF_ENDPOINT")
+		if !ok {
+			endpoint = "https://huggingface.co"
+		}
+		uris = []string{lo.CoalesceOrEmpty(source.Options["endpoint"], endpoint)}
+	case datasetv1alpha1.DatasetTypeModelScope:
+		domain, ok := overrideEnv(overrides, "MODELSCOPE_DOMAIN")
+		if !ok {
+			domain = "modelscope.cn"
+		}
+		uris = []string{domain}
+	case datasetv1alpha1.DatasetTypeGit:
+		// scp-like urls, e.g. git@github.com:BaizeAI/dataset.git
+		if !strings.Contains(source.URI, "://") {
+			if user, rest, ok := strings.Cut(source.URI, "@"); ok && !strings.Contains(user, "/") {
+				host, _, _ := strings.Cut(rest, ":")
+				return []string{host}, true
+			}
+		}
+		uris = []string{source.URI}
+	case datasetv1alpha1.DatasetTypeHTTP,
+		datasetv1alpha1.DatasetTypeNFS,
+		datasetv1alpha1.DatasetTypeDatabase,
+		datasetv1alpha1.DatasetTypeHadoop,
+		datasetv1alpha1.DatasetTypeOCI:
+		uris = []string{source.URI}
+	case datasetv1alpha1.DatasetTypeConda:
+		uris = append(channelURIs(source.Options["channels"]),
+			indexURIs(source.Options["pipIndexUrl"], source.Options["pipExtraIndexUrl"]+","+source.Options["pipExtraIndexUrls"], overrides)...)
+	case datasetv1alpha1.DatasetTypePixi:
+		uris = append(channelURIs(source.Options["channels"]),
+			indexURIs(source.Options["pypiIndexUrl"], source.Options["pypiExtraIndexUrls"], nil)...)
+		for _, mirror := range splitList(source.Options["channelMirrors"]) {
+			_, mirrorURL, _ := strings.Cut(mirror, "=")
+			uris = append(uris, mirrorURL)
+		}
+	case datasetv1alpha1.DatasetTypePythonVenv:
+		uris = indexURIs(source.Options["pipIndexUrl"], source.Options["pipExtraIndexUrls"], overrides)
+	default:
+		return nil, false
+	}
+
+	hosts := lo.Map(uris, func(uri string, _ int) string {
+		if uri != "" && !strings.Contains(uri, "://") {
+			// a domain, e.g. MODELSCOPE_DOMAIN
+			uri = "https://" + uri
+		}
+		u, err := url.Parse(uri)
+		if err != nil {
+			return ""
+		}
+		return u.Hostname()
+	})
+
+	return lo.Uniq(hosts), true
+}
+
+// overrideEnv returns the value of the env of the podOverrides and whether it
+// is set, the value is empty when it is set from a source which is not known
+// to the controller.
+func overrideEnv(overrides *datasetv1alpha1.LoaderPodOverrides, name string) (string, bool) {
+	if overrides == nil {
+		return "", false
+	}
+	env, ok := lo.Find(overrides.Env, func(item corev1.EnvVar) bool {
+		return item.Name == name
+	})
+
+	return env.Value, ok
+}
+
+// indexURIs returns the urls of the python package indexes of the index url
+// and the comma separated extra index urls options, along with those of the
+// env of pip and uv in the podOverrides. the index url defaults to PyPI.
+func indexURIs(indexURL, extraIndexURLs string, overrides *datasetv1alpha1.LoaderPodOverrides) []string {
+	uris := splitList(extraIndexURLs)
+	for _, name := range []string{"PIP_EXTRA_INDEX_URL", "UV_INDEX", "UV_EXTRA_INDEX_URL"} {
+		if value, ok := overrideEnv(overrides, name); ok {
+			uris = append(uris, envURIs(value)...)
+		}
+	}
+	if indexURL != "" {
+		return append(uris, indexURL)
+	}
+
+	defaultIndex := true
+	for _, name := range []string{"PIP_INDEX_URL", "UV_DEFAULT_INDEX", "UV_INDEX_URL"} {
+		if value, ok := overrideEnv(overrides, name); ok {
+			uris = append(uris, envURIs(value)...)
+			defaultIndex = false
+		}
+	}
+	if defaultIndex {
+		uris = append(uris, "https://pypi.org")
+	}
+
+	return uris
+}
+
+// envURIs splits the space separated urls of an env, the url of an env set
+// from an unknown source is empty.
+func envURIs(value string) []string {
+	if value == "" {
+		return []string{""}
+	}
+
+	return strings.Fields(value)
+}
+
+// channelURIs returns the urls of the comma separated conda channels, the
+// channels given by name are of anaconda.org. an empty url is returned when
+// there is no channel, as they are those of the environment then.
+func channelURIs(channels string) []string {
+	names := splitList(channels)
+	if len(names) == 0 {
+		return []string{""}
+	}
+
+	return lo.Map(names, func(channel string, _ int) string {
+		switch {
+		case strings.Contains(channel, "://"):
+			return channel
+		case channel == "defaults":
+			return "https://repo.anaconda.com"
+		default:
+			return "https://conda.anaconda.org"
+		}
+	})
+}
+
+func splitList(list string) []string {
+	return lo.Compact(lo.Map(strings.Split(list, ","), func(item string, _ int) string {
+		return strings.TrimSpace(item)
+	}))
+}
+
+// datasetPVCSize returns the storage size of the pvc created for the dataset,
+// false when the controller does not create one.
+func datasetPVCSize(ds *datasetv1alpha1.Dataset) (resource.Quantity, bool) {
+	switch {
+	case ds.Spec.Source.Type == datasetv1alpha1.DatasetTypeReference,
+		ds.Spec.Source.Type == datasetv1alpha1.DatasetTypePVC,
+		ds.Spec.VolumeClaimRef != nil:
+		return resource.Quantity{}, false
+	}
+	size := ds.Spec.VolumeClaimTemplate.Spec.Resources.Requests[corev1.ResourceStorage]
+	if size.IsZero() {
+		return resource.MustParse(defaultPVCSize), true
+	}
+
+	return size, true
+}
+
+// reconcilePolicy checks the dataset against the DatasetPolicies applied to
+// its namespace, the dataset is not reconciled any further when it violates
+// any of them.
+func (r *DatasetReconciler) reconcilePolicy(ctx context.Context, ds *datasetv1alpha1.Dataset) error {
+	policies, err := policiesOfNamespace(ctx, r.Client, ds.Namespace)
+	if err != nil {
+		return err
+	}
+	if len(policies) == 0 {
+		ds.Status.Policies = nil
+		meta.RemoveStatusCondition(&ds.Status.Conditions, condTypePolicy)
+		return nil
+	}
+
+	ds.Status.Policies = lo.Map(policies, func(item datasetv1alpha1.DatasetPolicy, _ int) string {
+		return item.Name
+	})
+	err = checkPolicies(policies, ds)
+	ds.Status.Conditions = kubeutils.SetCondition(ds.Status.Conditions, condTypePolicy, err)
+
+	return err
+}
+
+// policyJobLimit returns the least maxConcurrentJobs of the policies, 0 when
+// none of them sets it.
+func policyJobLimit(policies []datasetv1alpha1.DatasetPolicy) int {
+	limit := 0
+	for _, policy := range policies {
+		if policy.Spec.MaxConcurrentJobs != nil && (limit == 0 || int(*policy.Spec.MaxConcurrentJobs) < limit) {
+			limit = int(*policy.Spec.MaxConcurrentJobs)
+		}
+	}
+
+	return limit
+}
+
+// requestsOfPolicy maps the changes of a DatasetPolicy to all datasets, as
+// the namespaces it no longer selects are affected as well.
+func (r *DatasetReconciler) requestsOfPolicy(ctx context.Context, _ client.Object) []reconcile.Request {
+	datasets := &datasetv1alpha1.DatasetList{}
+	if err := r.List(ctx, datasets); err != nil {
+		log.Errorf("failed to list datasets: %v", err)
+		return nil
+	}
+
+	return lo.Map(datasets.Items, func(item datasetv1alpha1.Dataset, _ int) reconcile.Request {
+		return reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&item)}
+	})
+}
@@ -0,0 +1,200 @@
+package dataset
+
+import (
+	"context"
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	datasetv1alpha1 "github.com/BaizeAI/dataset/api/dataset/v1alpha1"
+)
+
+func TestPolicyViolations(t *testing.T) {
+	policy := &datasetv1alpha1.DatasetPolicy{Spec: datasetv1alpha1.DatasetPolicySpec{
+		AllowedTypes: []datasetv1alpha1.DatasetType{
+			datasetv1alpha1.DatasetTypeGit,
+			datasetv1alpha1.DatasetTypeS3,
+			datasetv1alpha1.DatasetTypeConda,
+			datasetv1alpha1.DatasetTypeHuggingFace,
+			datasetv1alpha1.DatasetTypeModelScope,
+			datasetv1alpha1.DatasetTypePythonVenv,
+		},
+		AllowedURIHosts: []string{"git.example.com", "*.s3.example.com", "conda.example.com", "pypi.example.com", "hf.example.com"},
+		MaxPVCSize:      lo.ToPtr(resource.MustParse("100Gi")),
+		AllowShare:      lo.ToPtr(false),
+	}}
+	withSize := func(size string) datasetv1alpha1.DatasetSpec {
+		spec := datasetv1alpha1.DatasetSpec{Source: datasetv1alpha1.DatasetSource{Type: datasetv1alpha1.DatasetTypeGit, URI: "https://git.example.com/a/b.git"}}
+		spec.VolumeClaimTemplate.Spec.Resources.Requests = corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(size)}
+		return spec
+	}
+	withSource := func(source datasetv1alpha1.DatasetSource) datasetv1alpha1.DatasetSpec {
+		spec := withSize("10Gi")
+		spec.Source = source
+		return spec
+	}
+
+	for _, testCase := range []struct {
+		name       string
+		spec       datasetv1alpha1.DatasetSpec
+		violations []string
+	}{
+		{
+			name: "compliant",
+			spec: withSize("10Gi"),
+		},
+		{
+			name: "scp-like git url",
+			spec: func() datasetv1alpha1.DatasetSpec {
+				spec := withSize("10Gi")
+				spec.Source.URI = "git@github.com:BaizeAI/dataset.git"
+				return spec
+			}(),
+			violations: []string{`host "github.com" of source git@github.com:BaizeAI/dataset.git is not allowed`},
+		},
+		{
+			name: "type not allowed",
+			spec: func() datasetv1alpha1.DatasetSpec {
+				spec := withSize("10Gi")
+				spec.Source = datasetv1alpha1.DatasetSource{Type: datasetv1alpha1.DatasetTypeHTTP, URI: "https://git.example.com/a.tar"}
+				return spec
+			}(),
+			violations: []string{"source type HTTP is not allowed"},
+		},
+		{
+			name: "s3 endpoint",
+			spec: datasetv1alpha1.DatasetSpec{Sources: []datasetv1alpha1.DatasetSubSource{
+				{Name: "a", Type: datasetv1alpha1.DatasetTypeS3, URI: "s3://bucket/a", Options: map[string]string{"endpoint": "https://us.s3.example.com:9000"}},
+				{Name: "b", Type: datasetv1alpha1.DatasetTypeS3, URI: "s3://bucket/b"},
+			}},
+			violations: []string{
+				`host "s3.amazonaws.com" of source s3://bucket/b is not allowed`,
+				"pvc size 100Ti exceeds 100Gi",
+			},
+		},
+		{
+			name: "conda without channels",
+			spec: withSource(datasetv1alpha1.DatasetSource{Type: datasetv1alpha1.DatasetTypeConda, URI: "conda://python"}),
+			violations: []string{
+				"host of source conda://python can not be determined",
+				`host "pypi.org" of source conda://python is not allowed`,
+			},
+		},
+		{
+			name: "conda channels and indexes",
+			spec: withSource(datasetv1alpha1.DatasetSource{Type: datasetv1alpha1.DatasetTypeConda, URI: "conda://python", Options: map[string]string{
+				"channels":    "https://conda.example.com/main, conda-forge",
+				"pipIndexUrl": "https://pypi.example.com/simple",
+			}}),
+			violations: []string{`host "conda.anaconda.org" of source conda://python is not allowed`},
+		},
+		{
+			name: "hugging face endpoint of pod overrides",
+			spec: func() datasetv1alpha1.DatasetSpec {
+				spec := withSource(datasetv1alpha1.DatasetSource{Type: datasetv1alpha1.DatasetTypeHuggingFace, URI: "huggingface://org/model"})
+				spec.PodOverrides = &datasetv1alpha1.LoaderPodOverrides{Env: []corev1.EnvVar{{Name: "HF_ENDPOINT", Value: "https://hf-mirror.com"}}}
+				return spec
+			}(),
+			violations: []string{`host "hf-mirror.com" of source huggingface://org/model is not allowed`},
+		},
+		{
+			name:       "model scope",
+			spec:       withSource(datasetv1alpha1.DatasetSource{Type: datasetv1alpha1.DatasetTypeModelScope, URI: "modelscope://org/model"}),
+			violations: []string{`host "modelscope.cn" of source modelscope://org/model is not allowed`},
+		},
+		{
+			name: "python venv index of pod overrides from a secret",
+			spec: func() datasetv1alpha1.DatasetSpec {
+				spec := withSource(datasetv1alpha1.DatasetSource{Type: datasetv1alpha1.DatasetTypePythonVenv, URI: "venv://app"})
+				spec.PodOverrides = &datasetv1alpha1.LoaderPodOverrides{Env: []corev1.EnvVar{{
+					Name:      "PIP_INDEX_URL",
+					ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{Key: "url"}},
+				}}}
+				return spec
+			}(),
+			violations: []string{"host of source venv://app can not be determined"},
+		},
+		{
+			name: "pvc size and share",
+			spec: func() datasetv1alpha1.DatasetSpec {
+				spec := withSize("1Ti")
+				spec.Share = true
+				return spec
+			}(),
+			violations: []string{"pvc size 1Ti exceeds 100Gi", "share is not allowed"},
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			ds := &datasetv1alpha1.Dataset{Spec: testCase.spec}
+			assert.Equal(t, testCase.violations, policyViolations(policy, ds))
+		})
+	}
+}
+
+func TestPolicyViolationsPodOverrides(t *testing.T) {
+	policy := &datasetv1alpha1.DatasetPolicy{Spec: datasetv1alpha1.DatasetPolicySpec{AllowPodOverrides: lo.ToPtr(false)}}
+	ds := &datasetv1alpha1.Dataset{Spec: datasetv1alpha1.DatasetSpec{
+		Source: datasetv1alpha1.DatasetSource{Type: datasetv1alpha1.DatasetTypeGit, URI: "https://git.example.com/a/b.git"},
+	}}
+	assert.Empty(t, policyViolations(policy, ds))
+
+	ds.Spec.PodOverrides = &datasetv1alpha1.LoaderPodOverrides{NodeSelector: map[string]string{"zone": "a"}}
+	assert.Equal(t, []string{"podOverrides is not allowed"}, policyViolations(policy, ds))
+}
+
+func TestDatasetReconciler_reconcilePolicy(t *testing.T) {
+	ds := &datasetv1alpha1.Dataset{
+		ObjectMeta: metav1.ObjectMeta{Name: "models", Namespace: "team-a"},
+		Spec: datasetv1alpha1.DatasetSpec{
+			Source: datasetv1alpha1.DatasetSource{Type: datasetv1alpha1.DatasetTypeHTTP, URI: "https://example.com/a.tar"},
+		},
+	}
+	c := newReplicaTestClient(t,
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: map[string]string{"tier": "restricted"}}},
+		&datasetv1alpha1.DatasetPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "restricted"},
+			Spec: datasetv1alpha1.DatasetPolicySpec{
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "restricted"}},
+				AllowedTypes:      []datasetv1alpha1.DatasetType{datasetv1alpha1.DatasetTypeGit},
+			},
+		},
+		&datasetv1alpha1.DatasetPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "other"},
+			Spec: datasetv1alpha1.DatasetPolicySpec{
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "other"}},
+			},
+		},
+	)
+	r := &DatasetReconciler{Client: c, Scheme: c.Scheme()}
+	ctx := context.Background()
+
+	err := r.reconcilePolicy(ctx, ds)
+	assert.EqualError(t, err, "violates dataset policy restricted: source type HTTP is not allowed")
+	assert.Equal(t, []string{"restricted"}, ds.Status.Policies)
+	cond := meta.FindStatusCondition(ds.Status.Conditions, condTypePolicy)
+	require.NotNil(t, cond)
+	assert.Equal(t, metav1.ConditionFalse, cond.Status)
+
+	// the admission path checks the same policies
+	validator := &DatasetValidator{Client: c}
+	_, err = validator.ValidateCreate(ctx, ds)
+	assert.EqualError(t, err, "violates dataset policy restricted: source type HTTP is not allowed")
+	_, err = validator.ValidateUpdate(ctx, ds, ds.DeepCopy())
+	assert.NoError(t, err, "the updates not changing the spec are admitted")
+
+	ds.Spec.Source = datasetv1alpha1.DatasetSource{Type: datasetv1alpha1.DatasetTypeGit, URI: "https://example.com/a.git"}
+	require.NoError(t, r.reconcilePolicy(ctx, ds))
+	assert.True(t, meta.IsStatusConditionTrue(ds.Status.Conditions, condTypePolicy))
+
+	ds.Namespace = "default"
+	require.NoError(t, c.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}}))
+	require.NoError(t, r.reconcilePolicy(ctx, ds))
+	assert.Empty(t, ds.Status.Policies)
+	assert.Nil(t, meta.FindStatusCondition(ds.Status.Conditions, condTypePolicy))
+}
@@ -0,0 +1,170 @@
+package dataset
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/samber/lo"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	datasetv1alpha1 "github.com/BaizeAI/dataset/api/dataset/v1alpha1"
+	"github.com/BaizeAI/dataset/internal/pkg/jobresult"
+	"github.com/BaizeAI/dataset/internal/pkg/postprocess"
+	"github.com/BaizeAI/dataset/pkg/log"
+)
+
+// postProcessContainerName is the name of the data loader container running
+// the built-in post process steps.
+const postProcessContainerName = "dataset-post-process"
+
+// postProcessStepContainerName is the name of the container of a CONTAINER
+// post process step.
+func postProcessStepContainerName(stepName string) string {
+	return "dataset-post-process-" + stepName
+}
+
+// validatePostProcess checks that the post process steps stay inside the
+// dataset and only the last step runs a container.
+func validatePostProcess(ds *datasetv1alpha1.Dataset) error {
+	if len(ds.Spec.PostProcess) > 0 && !supportPreload(ds) {
+		return fmt.Errorf("postProcess is not supported by datasets of type %s", ds.Spec.Source.Type)
+	}
+
+	for i, step := range ds.Spec.PostProcess {
+		if strings.HasPrefix(step.Path, "/") {
+			return fmt.Errorf("path of post process step %s should not start with '/', got: %s", step.Name, step.Path)
+		}
+		if lo.Contains(strings.Split(step.Path, "/"), "..") {
+			return fmt.Errorf("path of post process step %s should not contain '..', got: %s", step.Name, step.Path)
+		}
+		if step.Type != datasetv1alpha1.PostProcessStepTypeContainer {
+			continue
+		}
+		if step.Container == nil {
+			return fmt.Errorf("container of post process step %s is required", step.Name)
+		}
+		if i != len(ds.Spec.PostProcess)-1 {
+			return fmt.Errorf("post process step %s of type %s must be the last step", step.Name, step.Type)
+		}
+	}
+
+	return nil
+}
+
+// withPostProcess makes the job run the post process steps of the dataset once
+// the data is loaded: the loader containers become init containers, followed
+// by a data loader container running the built-in steps and the container of
+// the CONTAINER step, the last of which is the main container.
+func withPostProcess(ds *datasetv1alpha1.Dataset, jobSpec batchv1.JobSpec, template corev1.Container) batchv1.JobSpec {
+	if len(ds.Spec.PostProcess) == 0 {
+		return jobSpec
+	}
+
+	podSpec := &jobSpec.Template.Spec
+	podSpec.InitContainers = append(podSpec.InitContainers, podSpec.Containers...)
+	podSpec.Containers = nil
+
+	// the pvc volume is added along with the loader containers
+	_, pvcVolumeMount := datasetPVCVolume(ds)
+
+	steps := make([]postprocess.Step, 0, len(ds.Spec.PostProcess))
+	var stepContainer *corev1.Container
+	for _, step := range ds.Spec.PostProcess {
+		if step.Type == datasetv1alpha1.PostProcessStepTypeContainer {
+			stepContainer = step.Container.DeepCopy()
+			stepContainer.Name = postProcessStepContainerName(step.Name)
+			stepContainer.VolumeMounts = append(stepContainer.VolumeMounts, pvcVolumeMount)
+			stepContainer.Env = append(stepContainer.Env, corev1.EnvVar{
+				Name:  "DATASET_PATH",
+				Value: path.Join(datasetPVCMountPath, ds.Spec.MountOptions.Path),
+			})
+			continue
+		}
+		steps = append(steps, postprocess.Step{
+			Name:    step.Name,
+			Type:    postprocess.Type(step.Type),
+			Path:    step.Path,
+			Options: step.Options,
+		})
+	}
+
+	if len(steps) > 0 {
+		container := *template.DeepCopy()
+		container.Name = postProcessContainerName
+		container.VolumeMounts = append(container.VolumeMounts, pvcVolumeMount)
+
+		args := []string{
+			"post-process",
+			fmt.Sprintf("--steps=%s", lo.Must(json.Marshal(steps))),
+			fmt.Sprintf("--mount-path=%s", lo.CoalesceOrEmpty(ds.Spec.MountOptions.Path, "/")),
+		}
+		if ds.Spec.MountOptions.Mode != "" {
+			args = append(args, fmt.Sprintf("--mount-mode=%s", ds.Spec.MountOptions.Mode))
+		}
+		args = append(args, fmt.Sprintf("--mount-uid=%d", ds.Spec.MountOptions.UID))
+		args = append(args, fmt.Sprintf("--mount-gid=%d", ds.Spec.MountOptions.GID))
+		args = append(args, fmt.Sprintf("--mount-root=%s", datasetPVCMountPath))
+		if container.TerminationMessagePath != "" {
+			args = append(args, fmt.Sprintf("--termination-message-path=%s", container.TerminationMessagePath))
+		}
+		container.Args = args
+
+		podSpec.Containers = append(podSpec.Containers, container)
+	}
+	if stepContainer != nil {
+		if len(podSpec.Containers) > 0 {
+			podSpec.InitContainers = append(podSpec.InitContainers, podSpec.Containers...)
+		}
+		podSpec.Containers = []corev1.Container{*stepContainer}
+	}
+
+	return jobSpec
+}
+
+// getPostProcessStatuses returns the status of each post process step of the
+// dataset by the containers of the job, the built-in steps report their
+// results in the termination message of their container even if one failed.
+func getPostProcessStatuses(ctx context.Context, c client.Reader, ds *datasetv1alpha1.Dataset, job *batchv1.Job) []datasetv1alpha1.PostProcessStepStatus {
+	if len(ds.Spec.PostProcess) == 0 {
+		return nil
+	}
+
+	states, err := getTerminatedContainers(ctx, c, job)
+	if err != nil {
+		log.Warnf("failed to get the containers of job %s/%s: %v", job.Namespace, job.Name, err)
+	}
+	var results map[string]jobresult.StepResult
+	if state, ok := states[postProcessContainerName]; ok {
+		result, err := jobresult.Parse(state.Message)
+		if err != nil {
+			log.Warnf("failed to get the post process result of job %s/%s: %v", job.Namespace, job.Name, err)
+		}
+		results = lo.KeyBy(result.PostProcess, func(item jobresult.StepResult) string {
+			return item.Name
+		})
+	}
+
+	statuses := make([]datasetv1alpha1.PostProcessStepStatus, 0, len(ds.Spec.PostProcess))
+	for _, step := range ds.Spec.PostProcess {
+		status := datasetv1alpha1.PostProcessStepStatus{Name: step.Name}
+		if step.Type == datasetv1alpha1.PostProcessStepTypeContainer {
+			if state, ok := states[postProcessStepContainerName(step.Name)]; ok {
+				status.Succeed = state.ExitCode == 0
+				if !status.Succeed {
+					status.Message = strings.TrimSpace(fmt.Sprintf("exited with code %d %s", state.ExitCode, state.Reason))
+				}
+			}
+		} else if result, ok := results[step.Name]; ok {
+			status.Succeed = result.Succeed
+			status.Message = result.Message
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses
+}
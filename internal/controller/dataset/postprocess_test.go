@@ -0,0 +1,204 @@
+package dataset
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	datasetv1alpha1 "github.com/BaizeAI/dataset/api/dataset/v1alpha1"
+	"github.com/BaizeAI/dataset/config"
+)
+
+func TestValidatePostProcess(t *testing.T) {
+	container := &corev1.Container{Image: "busybox"}
+	for _, testCase := range []struct {
+		name    string
+		source  datasetv1alpha1.DatasetType
+		steps   []datasetv1alpha1.PostProcessStep
+		wantErr string
+	}{
+		{
+			name:   "built-in steps and a container",
+			source: datasetv1alpha1.DatasetTypeS3,
+			steps: []datasetv1alpha1.PostProcessStep{
+				{Name: "extract", Type: datasetv1alpha1.PostProcessStepTypeExtract, Path: "raw/*.tar.gz"},
+				{Name: "index", Type: datasetv1alpha1.PostProcessStepTypeContainer, Container: container},
+			},
+		},
+		{
+			name:    "not loaded by jobs",
+			source:  datasetv1alpha1.DatasetTypePVC,
+			steps:   []datasetv1alpha1.PostProcessStep{{Name: "cleanup", Type: datasetv1alpha1.PostProcessStepTypeDelete, Path: "tmp"}},
+			wantErr: "postProcess is not supported by datasets of type PVC",
+		},
+		{
+			name:    "absolute path",
+			source:  datasetv1alpha1.DatasetTypeS3,
+			steps:   []datasetv1alpha1.PostProcessStep{{Name: "cleanup", Type: datasetv1alpha1.PostProcessStepTypeDelete, Path: "/tmp"}},
+			wantErr: "path of post process step cleanup should not start with '/', got: /tmp",
+		},
+		{
+			name:    "parent directory",
+			source:  datasetv1alpha1.DatasetTypeS3,
+			steps:   []datasetv1alpha1.PostProcessStep{{Name: "cleanup", Type: datasetv1alpha1.PostProcessStepTypeDelete, Path: "tmp/../.."}},
+			wantErr: "path of post process step cleanup should not contain '..', got: tmp/../..",
+		},
+		{
+			name:    "container without container",
+			source:  datasetv1alpha1.DatasetTypeS3,
+			steps:   []datasetv1alpha1.PostProcessStep{{Name: "index", Type: datasetv1alpha1.PostProcessStepTypeContainer}},
+			wantErr: "container of post process step index is required",
+		},
+		{
+			name:   "container before built-in steps",
+			source: datasetv1alpha1.DatasetTypeS3,
+			steps: []datasetv1alpha1.PostProcessStep{
+				{Name: "index", Type: datasetv1alpha1.PostProcessStepTypeContainer, Container: container},
+				{Name: "cleanup", Type: datasetv1alpha1.PostProcessStepTypeDelete, Path: "tmp"},
+			},
+			wantErr: "post process step index of type CONTAINER must be the last step",
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			ds := &datasetv1alpha1.Dataset{
+				Spec: datasetv1alpha1.DatasetSpec{
+					Source:      datasetv1alpha1.DatasetSource{Type: testCase.source},
+					PostProcess: testCase.steps,
+				},
+			}
+
+			err := validatePostProcess(ds)
+			if testCase.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, testCase.wantErr)
+			}
+		})
+	}
+}
+
+func TestDatasetReconciler_reconcilePostProcess(t *testing.T) {
+	require.NoError(t, config.ParseConfigFromFileContent(""))
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, datasetv1alpha1.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+	require.NoError(t, batchv1.AddToScheme(scheme))
+
+	ds := &datasetv1alpha1.Dataset{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "images",
+			Namespace: "default",
+		},
+		Spec: datasetv1alpha1.DatasetSpec{
+			Source: datasetv1alpha1.DatasetSource{
+				Type: datasetv1alpha1.DatasetTypeS3,
+				URI:  "s3://bucket/images",
+			},
+			PostProcess: []datasetv1alpha1.PostProcessStep{
+				{
+					Name:    "extract",
+					Type:    datasetv1alpha1.PostProcessStepTypeExtract,
+					Path:    "*.tar.gz",
+					Options: map[string]string{"remove": "true"},
+				},
+				{
+					Name: "checksum",
+					Type: datasetv1alpha1.PostProcessStepTypeChecksum,
+					Path: "SHA256SUMS",
+				},
+				{
+					Name: "index",
+					Type: datasetv1alpha1.PostProcessStepTypeContainer,
+					Container: &corev1.Container{
+						Name:    "ignored",
+						Image:   "python:3.12",
+						Command: []string{"python", "-m", "index"},
+					},
+				},
+			},
+			MountOptions:  datasetv1alpha1.MountOptions{Path: "/data", Mode: "0774", UID: 1000, GID: 1000},
+			DataSyncRound: 1,
+		},
+		Status: datasetv1alpha1.DatasetStatus{
+			PVCName: "images",
+		},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&batchv1.Job{}).Build()
+	reconciler := &DatasetReconciler{Client: fakeClient, Scheme: scheme}
+	ctx := context.Background()
+
+	require.NoError(t, reconciler.reconcileJob(ctx, ds))
+	job := &batchv1.Job{}
+	require.NoError(t, fakeClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: genJobName(ds.Name, 1)}, job))
+
+	// the data is loaded, processed by the built-in steps and then by the container
+	podSpec := job.Spec.Template.Spec
+	require.Len(t, podSpec.InitContainers, 2)
+	require.Len(t, podSpec.Containers, 1)
+	assert.Equal(t, "dataset-loader", podSpec.InitContainers[0].Name)
+	assert.Equal(t, "dataset-post-process", podSpec.InitContainers[1].Name)
+	assert.Equal(t, "dataset-post-process-index", podSpec.Containers[0].Name)
+
+	assert.Equal(t, []string{
+		"post-process",
+		`--steps=[{"name":"extract","type":"EXTRACT","path":"*.tar.gz","options":{"remove":"true"}},{"name":"checksum","type":"CHECKSUM","path":"SHA256SUMS"}]`,
+		"--mount-path=/data",
+		"--mount-mode=0774",
+		"--mount-uid=1000",
+		"--mount-gid=1000",
+		"--mount-root=/baize/dataset/data",
+	}, podSpec.InitContainers[1].Args)
+	assert.Equal(t, podSpec.InitContainers[0].Image, podSpec.InitContainers[1].Image)
+	assert.Equal(t, []corev1.VolumeMount{{Name: "dataset-pvc", MountPath: "/baize/dataset/data"}}, podSpec.InitContainers[1].VolumeMounts)
+	assert.Equal(t, "python:3.12", podSpec.Containers[0].Image)
+	assert.Contains(t, podSpec.Containers[0].VolumeMounts, corev1.VolumeMount{Name: "dataset-pvc", MountPath: "/baize/dataset/data"})
+	assert.Contains(t, podSpec.Containers[0].Env, corev1.EnvVar{Name: "DATASET_PATH", Value: "/baize/dataset/data/data"})
+
+	// the checksum step failed, the steps run are reported
+	job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}}
+	require.NoError(t, fakeClient.Status().Update(ctx, job))
+	require.NoError(t, fakeClient.Create(ctx, &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      job.Name + "-abcde",
+			Namespace: "default",
+			Labels:    map[string]string{batchv1.JobNameLabel: job.Name},
+		},
+		Status: corev1.PodStatus{
+			InitContainerStatuses: []corev1.ContainerStatus{
+				{
+					Name:  "dataset-loader",
+					State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0}},
+				},
+				{
+					Name: "dataset-post-process",
+					State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+						ExitCode: 1,
+						Message: `{"postProcess":[` +
+							`{"name":"extract","succeed":true,"message":"extracted 3 files from 1 archives"},` +
+							`{"name":"checksum","message":"checksum of a.jpg mismatched"}]}`,
+					}},
+				},
+			},
+		},
+	}))
+
+	ds.Status.InProcessing = true
+	ds.Status.InProcessingRound = 1
+	require.NoError(t, reconciler.reconcileJobStatus(ctx, ds))
+	require.Len(t, ds.Status.SyncRoundStatuses, 1)
+	assert.False(t, ds.Status.SyncRoundStatuses[0].Succeed)
+	assert.Equal(t, []datasetv1alpha1.PostProcessStepStatus{
+		{Name: "extract", Succeed: true, Message: "extracted 3 files from 1 archives"},
+		{Name: "checksum", Message: "checksum of a.jpg mismatched"},
+		{Name: "index"},
+	}, ds.Status.SyncRoundStatuses[0].PostProcess)
+}
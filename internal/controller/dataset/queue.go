@@ -0,0 +1,254 @@
+package dataset
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+	batchv1 "k8s.io/api/batch/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	datasetv1alpha1 "github.com/BaizeAI/dataset/api/dataset/v1alpha1"
+	"github.com/BaizeAI/dataset/config"
+	"github.com/BaizeAI/dataset/pkg/kubeutils"
+	"github.com/BaizeAI/dataset/pkg/log"
+)
+
+const (
+	// queueRequeueAfter is how often the queued datasets are reconciled to
+	// take the slots of the jobs done.
+	queueRequeueAfter = time.Second * 10
+	// startedJobTTL is how long an admitted dataset is counted as loading its
+	// data before the cache observes it.
+	startedJobTTL = time.Minute
+)
+
+// jobQueue admits the data sync rounds of the datasets under the limits of
+// the concurrent data loader jobs. it remembers the datasets it admitted
+// until the cache observes them InProcessing, so that the datasets reconciled
+// right after are not admitted over the limits.
+type jobQueue struct {
+	mu      sync.Mutex
+	started map[types.NamespacedName]time.Time
+}
+
+// jobKeys are what the data loader job of a dataset is limited by.
+type jobKeys struct {
+	namespace string
+	hosts     []string
+	types     []string
+}
+
+func jobKeysOf(ds *datasetv1alpha1.Dataset) jobKeys {
+	keys := jobKeys{namespace: ds.Namespace}
+	for _, source := range datasetSources(ds) {
+		hosts, _ := sourceHosts(source, ds.Spec.PodOverrides)
+		keys.hosts = append(keys.hosts, lo.Compact(hosts)...)
+		keys.types = append(keys.types, string(source.Type))
+	}
+	keys.hosts = lo.Uniq(keys.hosts)
+	keys.types = lo.Uniq(keys.types)
+
+	return keys
+}
+
+// jobUsage counts the data loader jobs by what they are limited by.
+type jobUsage struct {
+	total      int
+	namespaces map[string]int
+	hosts      map[string]int
+	types      map[string]int
+}
+
+func newJobUsage() *jobUsage {
+	return &jobUsage{
+		namespaces: map[string]int{},
+		hosts:      map[string]int{},
+		types:      map[string]int{},
+	}
+}
+
+func (u *jobUsage) add(keys jobKeys) {
+	u.total++
+	u.namespaces[keys.namespace]++
+	for _, host := range keys.hosts {
+		u.hosts[host]++
+	}
+	for _, typ := range keys.types {
+		u.types[typ]++
+	}
+}
+
+// fits returns whether one more job of the keys is under the limits.
+func (u *jobUsage) fits(keys jobKeys, limits config.JobLimits, namespaceLimit int) bool {
+	if limits.Global > 0 && u.total >= limits.Global {
+		return false
+	}
+	if namespaceLimit > 0 && u.namespaces[keys.namespace] >= namespaceLimit {
+		return false
+	}
+	for _, host := range keys.hosts {
+		if limits.PerHost > 0 && u.hosts[host] >= limits.PerHost {
+			return false
+		}
+	}
+	for _, typ := range keys.types {
+		if limit := limits.PerType[typ]; limit > 0 && u.types[typ] >= limit {
+			return false
+		}
+	}
+
+	return true
+}
+
+func dequeue(ds *datasetv1alpha1.Dataset) {
+	ds.Status.QueuePosition = 0
+	ds.Status.QueuedAt = nil
+}
+
+// waitsInQueue returns whether the dataset is queued and creates its job
+// once admitted. the datasets which failed their validation, a policy or the
+// access to their source dataset since they were queued do not reach their
+// job, so they keep no slot until they are fixed.
+func waitsInQueue(ds *datasetv1alpha1.Dataset) bool {
+	if ds.Status.QueuedAt == nil || kubeutils.IsDeleted(ds) || !supportPreload(ds) ||
+		ds.Spec.DataSyncRound <= ds.Status.LastSucceedRound {
+		return false
+	}
+
+	return !lo.SomeBy([]string{condTypeConfig, condTypePolicy, condTypeShare}, func(typ string) bool {
+		return meta.IsStatusConditionFalse(ds.Status.Conditions, typ)
+	})
+}
+
+// admitJob returns whether the data loader job of the pending data sync round
+// of the dataset can be created under the limits of the controller config and
+// the maxConcurrentJobs of the DatasetPolicies. otherwise the dataset is
+// queued, and its position in the queue is set in the status. the queued
+// datasets are admitted by priority, then in the order they are queued, while
+// the ones over the limits of their own do not block those behind them.
+func (r *DatasetReconciler) admitJob(ctx context.Context, ds *datasetv1alpha1.Dataset) (bool, error) {
+	err := r.Get(ctx, client.ObjectKey{Namespace: ds.Namespace, Name: genJobName(ds.Name, ds.Spec.DataSyncRound)}, &batchv1.Job{})
+	if err == nil {
+		// the job is created already, e.g. it failed
+		dequeue(ds)
+		return true, nil
+	}
+	if !k8serrors.IsNotFound(err) {
+		return false, err
+	}
+
+	limits := config.GetJobLimits()
+	namespaceLimits := map[string]int{}
+	namespaceLimit := func(namespace string) (int, error) {
+		if limit, ok := namespaceLimits[namespace]; ok {
+			return limit, nil
+		}
+		policies, err := policiesOfNamespace(ctx, r.Client, namespace)
+		if err != nil {
+			return 0, err
+		}
+		limit := limits.PerNamespace
+		if policyLimit := policyJobLimit(policies); policyLimit > 0 && (limit == 0 || policyLimit < limit) {
+			limit = policyLimit
+		}
+		namespaceLimits[namespace] = limit
+		return limit, nil
+	}
+
+	keys := jobKeysOf(ds)
+	limit, err := namespaceLimit(ds.Namespace)
+	if err != nil {
+		return false, err
+	}
+	if limits.Global == 0 && limits.PerHost == 0 && limit == 0 && !lo.SomeBy(keys.types, func(typ string) bool {
+		return limits.PerType[typ] > 0
+	}) {
+		dequeue(ds)
+		return true, nil
+	}
+
+	r.queue.mu.Lock()
+	defer r.queue.mu.Unlock()
+	if r.queue.started == nil {
+		r.queue.started = map[types.NamespacedName]time.Time{}
+	}
+	now := time.Now()
+	for key, startedAt := range r.queue.started {
+		if now.Sub(startedAt) > startedJobTTL {
+			delete(r.queue.started, key)
+		}
+	}
+
+	datasets := &datasetv1alpha1.DatasetList{}
+	if err := r.List(ctx, datasets); err != nil {
+		return false, err
+	}
+	if ds.Status.QueuedAt == nil {
+		ds.Status.QueuedAt = &metav1.Time{Time: now}
+	}
+	usage := newJobUsage()
+	candidates := []*datasetv1alpha1.Dataset{ds}
+	for i := range datasets.Items {
+		item := &datasets.Items[i]
+		key := client.ObjectKeyFromObject(item)
+		if key == client.ObjectKeyFromObject(ds) {
+			continue
+		}
+		_, started := r.queue.started[key]
+		switch {
+		case item.Status.InProcessing:
+			delete(r.queue.started, key)
+			usage.add(jobKeysOf(item))
+		case started:
+			usage.add(jobKeysOf(item))
+		case waitsInQueue(item):
+			candidates = append(candidates, item)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.Spec.Priority != b.Spec.Priority {
+			return a.Spec.Priority > b.Spec.Priority
+		}
+		if !a.Status.QueuedAt.Equal(b.Status.QueuedAt) {
+			return a.Status.QueuedAt.Before(b.Status.QueuedAt)
+		}
+		return a.Namespace+"/"+a.Name < b.Namespace+"/"+b.Name
+	})
+
+	var position int32
+	for _, candidate := range candidates {
+		candidateKeys := jobKeysOf(candidate)
+		candidateLimit, err := namespaceLimit(candidate.Namespace)
+		if err != nil {
+			log.Warnf("failed to get the limit of the jobs of namespace %s: %v", candidate.Namespace, err)
+			candidateLimit = limits.PerNamespace
+		}
+		if usage.fits(candidateKeys, limits, candidateLimit) {
+			if candidate == ds {
+				r.queue.started[client.ObjectKeyFromObject(ds)] = now
+				dequeue(ds)
+				return true, nil
+			}
+			// the slot is kept for the candidate ahead, it takes the slot
+			// once it is reconciled
+			usage.add(candidateKeys)
+			continue
+		}
+		position++
+		if candidate == ds {
+			break
+		}
+	}
+	ds.Status.QueuePosition = position
+	log.Infof("the data sync round %d of %s/%s is queued at position %d", ds.Spec.DataSyncRound, ds.Namespace, ds.Name, position)
+
+	return false, nil
+}
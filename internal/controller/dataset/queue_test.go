@@ -0,0 +1,158 @@
+package dataset
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	datasetv1alpha1 "github.com/BaizeAI/dataset/api/dataset/v1alpha1"
+	"github.com/BaizeAI/dataset/config"
+	"github.com/BaizeAI/dataset/pkg/kubeutils"
+)
+
+func newQueueTestDataset(name, uri string, inProcessing bool) *datasetv1alpha1.Dataset {
+	return &datasetv1alpha1.Dataset{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: datasetv1alpha1.DatasetSpec{
+			Source:        datasetv1alpha1.DatasetSource{Type: datasetv1alpha1.DatasetTypeGit, URI: uri},
+			DataSyncRound: 1,
+		},
+		Status: datasetv1alpha1.DatasetStatus{
+			PVCName:           "dataset-" + name,
+			InProcessing:      inProcessing,
+			InProcessingRound: lo.Ternary(inProcessing, int32(1), int32(0)),
+		},
+	}
+}
+
+func assertJobCreated(t *testing.T, c client.Client, ds *datasetv1alpha1.Dataset, created bool) {
+	t.Helper()
+	err := c.Get(context.Background(), client.ObjectKey{Namespace: ds.Namespace, Name: genJobName(ds.Name, ds.Spec.DataSyncRound)}, &batchv1.Job{})
+	if created {
+		assert.NoError(t, err, ds.Name)
+	} else {
+		assert.True(t, k8serrors.IsNotFound(err), ds.Name)
+	}
+}
+
+func TestDatasetReconciler_reconcileJobPolicyLimit(t *testing.T) {
+	require.NoError(t, config.ParseConfigFromFileContent(""))
+
+	running := newQueueTestDataset("running", "https://example.com/a.git", true)
+	waiting := newQueueTestDataset("waiting", "https://example.com/b.git", false)
+	c := newReplicaTestClient(t,
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+		&datasetv1alpha1.DatasetPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "jobs"},
+			Spec:       datasetv1alpha1.DatasetPolicySpec{MaxConcurrentJobs: lo.ToPtr(int32(1))},
+		},
+		running, waiting,
+	)
+	r := &DatasetReconciler{Client: c, Scheme: c.Scheme()}
+	ctx := context.Background()
+
+	require.NoError(t, r.reconcileJob(ctx, waiting))
+	assert.False(t, waiting.Status.InProcessing)
+	assert.Equal(t, int32(1), waiting.Status.QueuePosition)
+	assert.NotNil(t, waiting.Status.QueuedAt)
+	require.NoError(t, r.reconcilePhase(ctx, waiting))
+	assert.Equal(t, datasetv1alpha1.DatasetStatusPhaseQueued, waiting.Status.Phase)
+	assertJobCreated(t, c, waiting, false)
+
+	// the running dataset is not queued behind itself
+	require.NoError(t, r.reconcileJob(ctx, running))
+
+	running.Status.InProcessing = false
+	require.NoError(t, c.Status().Update(ctx, running))
+	require.NoError(t, r.reconcileJob(ctx, waiting))
+	assert.True(t, waiting.Status.InProcessing)
+	assert.Zero(t, waiting.Status.QueuePosition)
+	assert.Nil(t, waiting.Status.QueuedAt)
+	assertJobCreated(t, c, waiting, true)
+}
+
+func TestDatasetReconciler_reconcileJobPriority(t *testing.T) {
+	require.NoError(t, config.ParseConfigFromFileContent("max_concurrent_jobs: 1"))
+	t.Cleanup(func() { _ = config.ParseConfigFromFileContent("") })
+
+	queuedAt := metav1.NewTime(time.Now().Add(-time.Minute))
+	early := newQueueTestDataset("early", "https://example.com/a.git", false)
+	early.Status.QueuedAt = &queuedAt
+	urgent := newQueueTestDataset("urgent", "https://example.com/b.git", false)
+	urgent.Spec.Priority = 10
+	urgent.Status.QueuedAt = lo.ToPtr(metav1.NewTime(queuedAt.Add(time.Second)))
+	c := newReplicaTestClient(t, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}}, early, urgent)
+	r := &DatasetReconciler{Client: c, Scheme: c.Scheme()}
+	ctx := context.Background()
+
+	// the slot is kept for the dataset of higher priority though it is
+	// queued later
+	require.NoError(t, r.reconcileJob(ctx, early))
+	assert.Equal(t, int32(1), early.Status.QueuePosition)
+	assertJobCreated(t, c, early, false)
+
+	require.NoError(t, r.reconcileJob(ctx, urgent))
+	assert.True(t, urgent.Status.InProcessing)
+	assertJobCreated(t, c, urgent, true)
+
+	// the admitted dataset is counted before the cache observes it
+	require.NoError(t, r.reconcileJob(ctx, early))
+	assert.Equal(t, int32(1), early.Status.QueuePosition)
+	assertJobCreated(t, c, early, false)
+}
+
+func TestDatasetReconciler_reconcileJobStaleQueued(t *testing.T) {
+	require.NoError(t, config.ParseConfigFromFileContent("max_concurrent_jobs: 1"))
+	t.Cleanup(func() { _ = config.ParseConfigFromFileContent("") })
+
+	queuedAt := metav1.NewTime(time.Now().Add(-time.Minute))
+	// queued before it violated a policy, it is not reconciled up to its
+	// job any more
+	denied := newQueueTestDataset("denied", "https://example.com/a.git", false)
+	denied.Status.QueuedAt = &queuedAt
+	denied.Status.Conditions = kubeutils.SetCondition(nil, condTypePolicy, errors.New("violates dataset policy jobs"))
+	// queued before its source was changed to one without a job
+	pvc := newQueueTestDataset("pvc", "pvc://data", false)
+	pvc.Spec.Source.Type = datasetv1alpha1.DatasetTypePVC
+	pvc.Status.QueuedAt = &queuedAt
+	waiting := newQueueTestDataset("waiting", "https://example.com/b.git", false)
+	c := newReplicaTestClient(t, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}}, denied, pvc, waiting)
+	r := &DatasetReconciler{Client: c, Scheme: c.Scheme()}
+	ctx := context.Background()
+
+	require.NoError(t, r.reconcileJob(ctx, waiting))
+	assert.True(t, waiting.Status.InProcessing)
+	assert.Nil(t, waiting.Status.QueuedAt)
+	assertJobCreated(t, c, waiting, true)
+}
+
+func TestDatasetReconciler_reconcileJobHostLimit(t *testing.T) {
+	require.NoError(t, config.ParseConfigFromFileContent("max_concurrent_jobs_per_host: 1"))
+	t.Cleanup(func() { _ = config.ParseConfigFromFileContent("") })
+
+	running := newQueueTestDataset("running", "https://git.example.com/a.git", true)
+	sameHost := newQueueTestDataset("same-host", "git@git.example.com:b.git", false)
+	otherHost := newQueueTestDataset("other-host", "https://mirror.example.com/c.git", false)
+	c := newReplicaTestClient(t, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}}, running, sameHost, otherHost)
+	r := &DatasetReconciler{Client: c, Scheme: c.Scheme()}
+	ctx := context.Background()
+
+	require.NoError(t, r.reconcileJob(ctx, sameHost))
+	assert.Equal(t, int32(1), sameHost.Status.QueuePosition)
+	assertJobCreated(t, c, sameHost, false)
+
+	// the datasets of other hosts are not blocked by those ahead of them
+	require.NoError(t, r.reconcileJob(ctx, otherHost))
+	assert.Zero(t, otherHost.Status.QueuePosition)
+	assertJobCreated(t, c, otherHost, true)
+}
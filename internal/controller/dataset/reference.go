@@ -0,0 +1,185 @@
+package dataset
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	datasetv1alpha1 "github.com/BaizeAI/dataset/api/dataset/v1alpha1"
+)
+
+const (
+	// condTypeSourceReady is set on REFERENCE datasets, it is false while the
+	// data of the source dataset is stale. it does not fail the dataset.
+	condTypeSourceReady = "SourceReady"
+
+	nfsCSIDriver = "nfs.csi.k8s.io"
+
+	// referenceSourceField indexes the REFERENCE datasets by the
+	// <namespace>/<name> of their source dataset.
+	referenceSourceField = "spec.source.referenceSource"
+)
+
+// parseReferenceURI parses dataset://<namespace>/<dataset>[/<subpath>] of a
+// REFERENCE dataset.
+func parseReferenceURI(uri string) (namespace, name, subPath string, err error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", "", "", err
+	}
+	name, subPath, _ = strings.Cut(strings.TrimPrefix(u.Path, "/"), "/")
+	if u.Host == "" || name == "" {
+		return "", "", "", fmt.Errorf("invalid reference uri %s, must be dataset://<namespace>/<dataset>[/<subpath>]", uri)
+	}
+
+	return u.Host, name, strings.TrimSuffix(subPath, "/"), nil
+}
+
+// indexReferenceSource is the indexer of referenceSourceField.
+func indexReferenceSource(obj client.Object) []string {
+	ds, ok := obj.(*datasetv1alpha1.Dataset)
+	if !ok || ds.Spec.Source.Type != datasetv1alpha1.DatasetTypeReference {
+		return nil
+	}
+	namespace, name, _, err := parseReferenceURI(ds.Spec.Source.URI)
+	if err != nil {
+		return nil
+	}
+
+	return []string{namespace + "/" + name}
+}
+
+// validateSubPath checks that a path relative to a dataset does not escape it,
+// like the subPath of volumeClaimRef.
+func validateSubPath(subPath string) error {
+	if strings.HasPrefix(subPath, "/") {
+		return fmt.Errorf("subPath should not start with '/', got: %s", subPath)
+	}
+	if lo.Contains(strings.Split(subPath, "/"), "..") {
+		return fmt.Errorf("subPath should not contain '..', got: %s", subPath)
+	}
+
+	return nil
+}
+
+// validateReferenceSubPath checks that the source dataset shares the subpath
+// referenced, by the shareSubPaths of the source dataset.
+func validateReferenceSubPath(sourceDs *datasetv1alpha1.Dataset, subPath string) error {
+	if err := validateSubPath(subPath); err != nil {
+		return err
+	}
+	if len(sourceDs.Spec.ShareSubPaths) == 0 {
+		return nil
+	}
+
+	p := path.Clean("/" + subPath)
+	for _, shared := range sourceDs.Spec.ShareSubPaths {
+		shared = path.Clean("/" + shared)
+		if p == shared || strings.HasPrefix(p, shared+"/") || shared == "/" {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("subpath %s of source dataset %s/%s is not shared, shared subpaths are %s",
+		lo.CoalesceOrEmpty(subPath, "/"), sourceDs.Namespace, sourceDs.Name, strings.Join(sourceDs.Spec.ShareSubPaths, ", "))
+}
+
+// referenceSubPath returns the path inside the pvc of the source dataset of
+// the subpath referenced.
+func referenceSubPath(sourceDs *datasetv1alpha1.Dataset, subPath string) string {
+	var claimSubPath string
+	if sourceDs.Spec.VolumeClaimRef != nil {
+		claimSubPath = sourceDs.Spec.VolumeClaimRef.SubPath
+	}
+
+	return strings.TrimPrefix(path.Join("/", claimSubPath, sourceDs.Spec.MountOptions.Path, subPath), "/")
+}
+
+// pointPVAtSubPath points the pv cloned for a REFERENCE dataset at the path
+// inside its volume, so that the pvc of the dataset gives no access to the
+// rest of the source dataset. an error is returned when the source of the pv
+// can not be pointed at a subdirectory.
+func pointPVAtSubPath(pv *corev1.PersistentVolume, subPath string) error {
+	if subPath == "" {
+		return nil
+	}
+
+	source := &pv.Spec.PersistentVolumeSource
+	switch {
+	case source.NFS != nil:
+		source.NFS.Path = path.Join("/", source.NFS.Path, subPath)
+	case source.CephFS != nil:
+		source.CephFS.Path = path.Join("/", source.CephFS.Path, subPath)
+	case source.HostPath != nil:
+		source.HostPath.Path = path.Join(source.HostPath.Path, subPath)
+	case source.Local != nil:
+		source.Local.Path = path.Join(source.Local.Path, subPath)
+	case source.CSI != nil && source.CSI.Driver == nfsCSIDriver && source.CSI.VolumeAttributes["server"] != "":
+		source.CSI.VolumeAttributes["subdir"] = path.Join("/", source.CSI.VolumeAttributes["subdir"], subPath)
+	default:
+		return fmt.Errorf("pv %s can not be referenced with subpath %s, only the nfs, cephfs, hostPath, local and %s volumes can", pv.Name, subPath, nfsCSIDriver)
+	}
+
+	return nil
+}
+
+// datasetSubPath returns the subPath the pvc of the dataset is mounted with.
+func datasetSubPath(ds *datasetv1alpha1.Dataset) string {
+	if ds.Spec.VolumeClaimRef != nil {
+		return ds.Spec.VolumeClaimRef.SubPath
+	}
+
+	return ""
+}
+
+// sourceRoundAndDigest returns the last succeeded round of a dataset and the
+// digest of the content loaded in it, those of its source dataset for a
+// REFERENCE dataset.
+func sourceRoundAndDigest(ds *datasetv1alpha1.Dataset) (int32, string) {
+	if ds.Spec.Source.Type == datasetv1alpha1.DatasetTypeReference {
+		return ds.Status.SourceRound, ds.Status.SourceDigest
+	}
+	status, _ := lo.Find(ds.Status.SyncRoundStatuses, func(item datasetv1alpha1.DataLoadStatus) bool {
+		return item.Succeed && item.Round == ds.Status.LastSucceedRound
+	})
+
+	return ds.Status.LastSucceedRound, status.Digest
+}
+
+// reconcileSourceStatus mirrors the phase, the round and the digest of the
+// source dataset of a REFERENCE dataset, the SourceReady condition is false
+// while the source dataset is not READY.
+func (r *DatasetReconciler) reconcileSourceStatus(ctx context.Context, ds *datasetv1alpha1.Dataset) error {
+	if ds.Spec.Source.Type != datasetv1alpha1.DatasetTypeReference {
+		return nil
+	}
+
+	sourceDs, err := r.getSourceDataset(ctx, ds)
+	if err != nil {
+		return err
+	}
+	ds.Status.SourcePhase = lo.CoalesceOrEmpty(sourceDs.Status.Phase, datasetv1alpha1.DatasetStatusPhasePending)
+	ds.Status.SourceRound, ds.Status.SourceDigest = sourceRoundAndDigest(sourceDs)
+
+	cond := metav1.Condition{
+		Type:   condTypeSourceReady,
+		Status: metav1.ConditionTrue,
+		Reason: condTypeSourceReady,
+	}
+	if ds.Status.SourcePhase != datasetv1alpha1.DatasetStatusPhaseReady {
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = "Source" + lo.PascalCase(string(ds.Status.SourcePhase))
+		cond.Message = fmt.Sprintf("source dataset %s/%s is %s", sourceDs.Namespace, sourceDs.Name, ds.Status.SourcePhase)
+	}
+	meta.SetStatusCondition(&ds.Status.Conditions, cond)
+
+	return nil
+}
@@ -0,0 +1,325 @@
+package dataset
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	datasetv1alpha1 "github.com/BaizeAI/dataset/api/dataset/v1alpha1"
+	"github.com/BaizeAI/dataset/pkg/kubeutils"
+)
+
+func TestParseReferenceURI(t *testing.T) {
+	for _, testCase := range []struct {
+		uri       string
+		namespace string
+		name      string
+		subPath   string
+		wantErr   bool
+	}{
+		{uri: "dataset://default/models", namespace: "default", name: "models"},
+		{uri: "dataset://default/models/", namespace: "default", name: "models"},
+		{uri: "dataset://default/models/llama-7b", namespace: "default", name: "models", subPath: "llama-7b"},
+		{uri: "dataset://default/models/meta/llama-7b/", namespace: "default", name: "models", subPath: "meta/llama-7b"},
+		{uri: "dataset://default/models/%2E%2E/other", namespace: "default", name: "models", subPath: "../other"},
+		{uri: "dataset://default", wantErr: true},
+		{uri: "dataset:///models", wantErr: true},
+	} {
+		t.Run(testCase.uri, func(t *testing.T) {
+			namespace, name, subPath, err := parseReferenceURI(testCase.uri)
+			if testCase.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testCase.namespace, namespace)
+			assert.Equal(t, testCase.name, name)
+			assert.Equal(t, testCase.subPath, subPath)
+		})
+	}
+}
+
+func TestValidateReferenceSubPath(t *testing.T) {
+	sourceDs := &datasetv1alpha1.Dataset{
+		ObjectMeta: metav1.ObjectMeta{Name: "models", Namespace: "default"},
+	}
+	assert.NoError(t, validateReferenceSubPath(sourceDs, ""))
+	assert.NoError(t, validateReferenceSubPath(sourceDs, "private/weights"))
+	assert.EqualError(t, validateReferenceSubPath(sourceDs, "../other"), "subPath should not contain '..', got: ../other")
+
+	sourceDs.Spec.ShareSubPaths = []string{"meta/llama-7b", "qwen/"}
+	for subPath, wantErr := range map[string]string{
+		"meta/llama-7b":         "",
+		"qwen/qwen2-7b/weights": "",
+		"qwen":                  "",
+		"meta":                  "subpath meta of source dataset default/models is not shared, shared subpaths are meta/llama-7b, qwen/",
+		"meta/llama-7b-chat":    "subpath meta/llama-7b-chat of source dataset default/models is not shared, shared subpaths are meta/llama-7b, qwen/",
+		"":                      "subpath / of source dataset default/models is not shared, shared subpaths are meta/llama-7b, qwen/",
+		"qwen/../meta":          "subPath should not contain '..', got: qwen/../meta",
+	} {
+		t.Run(subPath, func(t *testing.T) {
+			err := validateReferenceSubPath(sourceDs, subPath)
+			if wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, wantErr)
+			}
+		})
+	}
+}
+
+func TestPointPVAtSubPath(t *testing.T) {
+	for _, testCase := range []struct {
+		name    string
+		source  corev1.PersistentVolumeSource
+		subPath string
+		want    corev1.PersistentVolumeSource
+		wantErr string
+	}{
+		{
+			name:   "root",
+			source: corev1.PersistentVolumeSource{AWSElasticBlockStore: &corev1.AWSElasticBlockStoreVolumeSource{VolumeID: "vol"}},
+			want:   corev1.PersistentVolumeSource{AWSElasticBlockStore: &corev1.AWSElasticBlockStoreVolumeSource{VolumeID: "vol"}},
+		},
+		{
+			name:    "nfs",
+			source:  corev1.PersistentVolumeSource{NFS: &corev1.NFSVolumeSource{Server: "nfs", Path: "/exports"}},
+			subPath: "meta/llama-7b",
+			want:    corev1.PersistentVolumeSource{NFS: &corev1.NFSVolumeSource{Server: "nfs", Path: "/exports/meta/llama-7b"}},
+		},
+		{
+			name: "nfs csi",
+			source: corev1.PersistentVolumeSource{CSI: &corev1.CSIPersistentVolumeSource{
+				Driver:           nfsCSIDriver,
+				VolumeAttributes: map[string]string{"server": "nfs", "share": "/", "subdir": "/models"},
+			}},
+			subPath: "meta",
+			want: corev1.PersistentVolumeSource{CSI: &corev1.CSIPersistentVolumeSource{
+				Driver:           nfsCSIDriver,
+				VolumeAttributes: map[string]string{"server": "nfs", "share": "/", "subdir": "/models/meta"},
+			}},
+		},
+		{
+			name:    "other csi",
+			source:  corev1.PersistentVolumeSource{CSI: &corev1.CSIPersistentVolumeSource{Driver: "ebs.csi.aws.com"}},
+			subPath: "meta",
+			wantErr: "pv pv can not be referenced with subpath meta, only the nfs, cephfs, hostPath, local and nfs.csi.k8s.io volumes can",
+		},
+		{
+			name:    "block",
+			source:  corev1.PersistentVolumeSource{AWSElasticBlockStore: &corev1.AWSElasticBlockStoreVolumeSource{VolumeID: "vol"}},
+			subPath: "meta",
+			wantErr: "pv pv can not be referenced with subpath meta, only the nfs, cephfs, hostPath, local and nfs.csi.k8s.io volumes can",
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			pv := &corev1.PersistentVolume{
+				ObjectMeta: metav1.ObjectMeta{Name: "pv"},
+				Spec:       corev1.PersistentVolumeSpec{PersistentVolumeSource: testCase.source},
+			}
+			err := pointPVAtSubPath(pv, testCase.subPath)
+			if testCase.wantErr != "" {
+				assert.EqualError(t, err, testCase.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testCase.want, pv.Spec.PersistentVolumeSource)
+		})
+	}
+}
+
+func TestDatasetReconciler_reconcileReferenceSubPath(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, datasetv1alpha1.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	sourceDs := &datasetv1alpha1.Dataset{
+		ObjectMeta: metav1.ObjectMeta{Name: "models", Namespace: "shared"},
+		Spec: datasetv1alpha1.DatasetSpec{
+			Share:          true,
+			ShareSubPaths:  []string{"meta"},
+			Source:         datasetv1alpha1.DatasetSource{Type: datasetv1alpha1.DatasetTypeS3, URI: "s3://bucket/models"},
+			MountOptions:   datasetv1alpha1.MountOptions{Path: "/hub"},
+			VolumeClaimRef: &datasetv1alpha1.VolumeClaimRef{Name: "team-storage", SubPath: "datasets"},
+		},
+		Status: datasetv1alpha1.DatasetStatus{PVCName: "team-storage"},
+	}
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "team-storage", Namespace: "shared"},
+		Spec:       corev1.PersistentVolumeClaimSpec{VolumeName: "pv-team-storage"},
+	}
+	pv := &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pv-team-storage"},
+		Spec: corev1.PersistentVolumeSpec{
+			PersistentVolumeSource: corev1.PersistentVolumeSource{
+				NFS: &corev1.NFSVolumeSource{Server: "nfs.example.com", Path: "/exports/team"},
+			},
+		},
+	}
+	ds := &datasetv1alpha1.Dataset{
+		ObjectMeta: metav1.ObjectMeta{Name: "llama", Namespace: "default", UID: "0123456789abcdef"},
+		Spec: datasetv1alpha1.DatasetSpec{
+			Source: datasetv1alpha1.DatasetSource{
+				Type: datasetv1alpha1.DatasetTypeReference,
+				URI:  "dataset://shared/models/meta/llama-7b",
+			},
+			DataSyncRound: 1,
+		},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithIndex(&datasetv1alpha1.Dataset{}, referenceSourceField, indexReferenceSource).WithObjects(sourceDs, pvc, pv).Build()
+	reconciler := &DatasetReconciler{Client: fakeClient, Scheme: scheme}
+	ctx := context.Background()
+
+	require.NoError(t, reconciler.validate(ctx, ds))
+	require.NoError(t, reconciler.reconcilePVC(ctx, ds))
+	assert.Equal(t, "llama", ds.Status.PVCName)
+	// the cloned pv points at the referenced data, the rest of the source
+	// dataset is not reachable from the pvc
+	clonedPV := &corev1.PersistentVolume{}
+	require.NoError(t, fakeClient.Get(ctx, client.ObjectKey{Name: "dataset-default-llama-0123456789ab"}, clonedPV))
+	assert.Equal(t, "/exports/team/datasets/hub/meta/llama-7b", clonedPV.Spec.NFS.Path)
+	_, volumeMount := datasetPVCVolume(ds)
+	assert.Empty(t, volumeMount.SubPath)
+
+	// the source dataset does not share the other subpaths
+	ds.Spec.Source.URI = "dataset://shared/models/qwen"
+	_, err := reconciler.checkShareAccess(ctx, ds, sourceDs)
+	assert.EqualError(t, err, "share denied: subpath qwen of source dataset shared/models is not shared, shared subpaths are meta")
+
+	// the referencing datasets are found regardless of their subpaths
+	referencing := ds.DeepCopy()
+	referencing.ResourceVersion = ""
+	require.NoError(t, fakeClient.Create(ctx, referencing))
+	found, err := reconciler.findReferencingDatasets(ctx, sourceDs)
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, "llama", found[0].Name)
+}
+
+func TestDatasetReconciler_reconcilePlainReference(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, datasetv1alpha1.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	sourceDs := &datasetv1alpha1.Dataset{
+		ObjectMeta: metav1.ObjectMeta{Name: "models", Namespace: "shared"},
+		Spec: datasetv1alpha1.DatasetSpec{
+			Share:        true,
+			Source:       datasetv1alpha1.DatasetSource{Type: datasetv1alpha1.DatasetTypeS3, URI: "s3://bucket/models"},
+			MountOptions: datasetv1alpha1.MountOptions{Path: "/hub"},
+		},
+		Status: datasetv1alpha1.DatasetStatus{PVCName: "models"},
+	}
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "models", Namespace: "shared"},
+		Spec:       corev1.PersistentVolumeClaimSpec{VolumeName: "pv-models"},
+	}
+	source := corev1.PersistentVolumeSource{CSI: &corev1.CSIPersistentVolumeSource{Driver: "ebs.csi.aws.com", VolumeHandle: "vol-1"}}
+	pv := &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pv-models"},
+		Spec:       corev1.PersistentVolumeSpec{PersistentVolumeSource: source},
+	}
+	ds := &datasetv1alpha1.Dataset{
+		ObjectMeta: metav1.ObjectMeta{Name: "llama", Namespace: "default", UID: "0123456789abcdef"},
+		Spec: datasetv1alpha1.DatasetSpec{
+			Source:        datasetv1alpha1.DatasetSource{Type: datasetv1alpha1.DatasetTypeReference, URI: "dataset://shared/models"},
+			DataSyncRound: 1,
+		},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(sourceDs, pvc, pv).Build()
+	reconciler := &DatasetReconciler{Client: fakeClient, Scheme: scheme}
+	ctx := context.Background()
+
+	// the pv of a reference of the whole source dataset is cloned as is, even
+	// when it can not be pointed at a subdirectory
+	require.NoError(t, reconciler.reconcilePVC(ctx, ds))
+	clonedPV := &corev1.PersistentVolume{}
+	require.NoError(t, fakeClient.Get(ctx, client.ObjectKey{Name: "dataset-default-llama-0123456789ab"}, clonedPV))
+	assert.Equal(t, source, clonedPV.Spec.PersistentVolumeSource)
+}
+
+func TestDatasetReconciler_reconcileSourceStatus(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, datasetv1alpha1.AddToScheme(scheme))
+
+	sourceDs := &datasetv1alpha1.Dataset{
+		ObjectMeta: metav1.ObjectMeta{Name: "models", Namespace: "shared"},
+		Spec: datasetv1alpha1.DatasetSpec{
+			Share:         true,
+			Source:        datasetv1alpha1.DatasetSource{Type: datasetv1alpha1.DatasetTypeS3, URI: "s3://bucket/models"},
+			DataSyncRound: 2,
+		},
+		Status: datasetv1alpha1.DatasetStatus{
+			Phase:            datasetv1alpha1.DatasetStatusPhaseReady,
+			LastSucceedRound: 2,
+			SyncRoundStatuses: []datasetv1alpha1.DataLoadStatus{
+				{Round: 1, Succeed: true, Digest: "sha256:1"},
+				{Round: 2, Succeed: true, Digest: "sha256:2"},
+			},
+		},
+	}
+	ds := &datasetv1alpha1.Dataset{
+		ObjectMeta: metav1.ObjectMeta{Name: "llama", Namespace: "default"},
+		Spec: datasetv1alpha1.DatasetSpec{
+			Source:        datasetv1alpha1.DatasetSource{Type: datasetv1alpha1.DatasetTypeReference, URI: "dataset://shared/models"},
+			DataSyncRound: 1,
+		},
+		Status: datasetv1alpha1.DatasetStatus{
+			PVCName:          "llama",
+			LastSucceedRound: 1,
+			Conditions:       kubeutils.SetCondition(nil, condTypePVC, nil),
+		},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(sourceDs).Build()
+	reconciler := &DatasetReconciler{Client: fakeClient, Scheme: scheme}
+	ctx := context.Background()
+
+	require.NoError(t, reconciler.reconcileSourceStatus(ctx, ds))
+	require.NoError(t, reconciler.reconcilePhase(ctx, ds))
+	assert.Equal(t, datasetv1alpha1.DatasetStatusPhaseReady, ds.Status.SourcePhase)
+	assert.Equal(t, int32(2), ds.Status.SourceRound)
+	assert.Equal(t, "sha256:2", ds.Status.SourceDigest)
+	assert.True(t, kubeutils.IsConditionReady(ds.Status.Conditions, condTypeSourceReady))
+	assert.Equal(t, datasetv1alpha1.DatasetStatusPhaseReady, ds.Status.Phase)
+
+	// the data is stale while the source dataset is loading a new round
+	sourceDs.Spec.DataSyncRound = 3
+	sourceDs.Status.Phase = datasetv1alpha1.DatasetStatusPhaseProcessing
+	require.NoError(t, fakeClient.Update(ctx, sourceDs))
+	require.NoError(t, reconciler.reconcileSourceStatus(ctx, ds))
+	require.NoError(t, reconciler.reconcilePhase(ctx, ds))
+	assert.Equal(t, int32(2), ds.Status.SourceRound)
+	cond := meta.FindStatusCondition(ds.Status.Conditions, condTypeSourceReady)
+	require.NotNil(t, cond)
+	assert.Equal(t, metav1.ConditionFalse, cond.Status)
+	assert.Equal(t, "SourceProcessing", cond.Reason)
+	assert.Equal(t, "source dataset shared/models is PROCESSING", cond.Message)
+	assert.Equal(t, datasetv1alpha1.DatasetStatusPhaseProcessing, ds.Status.Phase)
+
+	sourceDs.Status.Phase = datasetv1alpha1.DatasetStatusPhaseFailed
+	require.NoError(t, fakeClient.Update(ctx, sourceDs))
+	require.NoError(t, reconciler.reconcileSourceStatus(ctx, ds))
+	require.NoError(t, reconciler.reconcilePhase(ctx, ds))
+	assert.Equal(t, "source dataset shared/models is FAILED", meta.FindStatusCondition(ds.Status.Conditions, condTypeSourceReady).Message)
+	assert.Equal(t, datasetv1alpha1.DatasetStatusPhaseFailed, ds.Status.Phase)
+
+	// the new round is loaded
+	sourceDs.Status.Phase = datasetv1alpha1.DatasetStatusPhaseReady
+	sourceDs.Status.LastSucceedRound = 3
+	sourceDs.Status.SyncRoundStatuses = append(sourceDs.Status.SyncRoundStatuses, datasetv1alpha1.DataLoadStatus{Round: 3, Succeed: true})
+	require.NoError(t, fakeClient.Update(ctx, sourceDs))
+	require.NoError(t, reconciler.reconcileSourceStatus(ctx, ds))
+	require.NoError(t, reconciler.reconcilePhase(ctx, ds))
+	assert.Equal(t, int32(3), ds.Status.SourceRound)
+	assert.Empty(t, ds.Status.SourceDigest)
+	assert.True(t, kubeutils.IsConditionReady(ds.Status.Conditions, condTypeSourceReady))
+	assert.Equal(t, datasetv1alpha1.DatasetStatusPhaseReady, ds.Status.Phase)
+}
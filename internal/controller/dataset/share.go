@@ -0,0 +1,241 @@
+package dataset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	datasetv1alpha1 "github.com/BaizeAI/dataset/api/dataset/v1alpha1"
+	"github.com/BaizeAI/dataset/internal/pkg/constants"
+	"github.com/BaizeAI/dataset/pkg/kubeutils"
+	"github.com/BaizeAI/dataset/pkg/log"
+)
+
+const (
+	condTypeShare = "Share"
+)
+
+// errShareDenied is returned when the source dataset is not shared to a
+// REFERENCE dataset, or not any longer.
+var errShareDenied = errors.New("share denied")
+
+func shareGrantExpired(grant *datasetv1alpha1.DatasetShareGrant, now time.Time) bool {
+	return grant.Spec.ExpiresAt != nil && !now.Before(grant.Spec.ExpiresAt.Time)
+}
+
+// findShareGrant returns the unexpired grant of the source dataset approving
+// the namespace or the service account, nil when there is none.
+func (r *DatasetReconciler) findShareGrant(ctx context.Context, sourceDs *datasetv1alpha1.Dataset, namespace, serviceAccount string) (*datasetv1alpha1.DatasetShareGrant, error) {
+	grants := &datasetv1alpha1.DatasetShareGrantList{}
+	if err := r.List(ctx, grants, client.InNamespace(sourceDs.Namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list share grants: %v", err)
+	}
+
+	now := time.Now()
+	for i := range grants.Items {
+		grant := &grants.Items[i]
+		if grant.Spec.DatasetName != sourceDs.Name || shareGrantExpired(grant, now) {
+			continue
+		}
+		if lo.Contains(grant.Spec.Namespaces, namespace) || lo.Contains(grant.Spec.ServiceAccounts, serviceAccount) {
+			return grant, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// checkShareAccess checks that the source dataset is shared to the REFERENCE
+// dataset, and returns the name of the grant approving it if any. the error
+// wraps errShareDenied when it is not shared.
+func (r *DatasetReconciler) checkShareAccess(ctx context.Context, ds, sourceDs *datasetv1alpha1.Dataset) (string, error) {
+	if !sourceDs.Spec.Share {
+		return "", fmt.Errorf("%w: source dataset %s is not shared", errShareDenied, ds.Spec.Source.URI)
+	}
+	_, _, subPath, err := parseReferenceURI(ds.Spec.Source.URI)
+	if err != nil {
+		return "", err
+	}
+	if err := validateReferenceSubPath(sourceDs, subPath); err != nil {
+		return "", fmt.Errorf("%w: %v", errShareDenied, err)
+	}
+	if sourceDs.Spec.ShareToNamespaceSelector != nil {
+		// 获取当前 Dataset 所在的 Namespace
+		currNS := &corev1.Namespace{}
+		if err := r.Get(ctx, client.ObjectKey{Name: ds.Namespace}, currNS); err != nil {
+			return "", fmt.Errorf("fetch current namespace %s error: %v", ds.Namespace, err)
+		}
+		s, err := metav1.LabelSelectorAsSelector(sourceDs.Spec.ShareToNamespaceSelector)
+		if err != nil {
+			return "", fmt.Errorf("parse share to namespace selector error: %v", err)
+		}
+		if !s.Matches(labels.Set(currNS.Labels)) {
+			return "", fmt.Errorf("%w: source dataset %s is not shared to current namespace", errShareDenied, ds.Spec.Source.URI)
+		}
+	}
+	if !sourceDs.Spec.ShareGrantRequired {
+		return "", nil
+	}
+
+	serviceAccount, err := datasetServiceAccount(ds)
+	if err != nil {
+		return "", err
+	}
+	serviceAccount = ds.Namespace + "/" + serviceAccount
+	grant, err := r.findShareGrant(ctx, sourceDs, ds.Namespace, serviceAccount)
+	if err != nil {
+		return "", err
+	}
+	if grant == nil {
+		return "", fmt.Errorf("%w: no unexpired share grant of source dataset %s approves namespace %s or service account %s",
+			errShareDenied, ds.Spec.Source.URI, ds.Namespace, serviceAccount)
+	}
+
+	return grant.Name, nil
+}
+
+// reconcileShareAccess checks the access of a REFERENCE dataset to its source
+// dataset in the Share condition, and releases the volume of it once the
+// access is revoked.
+func (r *DatasetReconciler) reconcileShareAccess(ctx context.Context, ds *datasetv1alpha1.Dataset) error {
+	if ds.Spec.Source.Type != datasetv1alpha1.DatasetTypeReference {
+		return nil
+	}
+
+	sourceDs, err := r.getSourceDataset(ctx, ds)
+	if err == nil {
+		_, err = r.checkShareAccess(ctx, ds, sourceDs)
+	}
+	if errors.Is(err, errShareDenied) {
+		if releaseErr := r.releaseReference(ctx, ds); releaseErr != nil {
+			log.Errorf("failed to release the volume of dataset %s/%s: %v", ds.Namespace, ds.Name, releaseErr)
+		}
+	}
+	ds.Status.Conditions = kubeutils.SetCondition(ds.Status.Conditions, condTypeShare, err)
+
+	return err
+}
+
+// releaseReference deletes the pvc of a REFERENCE dataset and the pv cloned
+// from the source dataset for it, they are created again once the access is
+// approved again.
+func (r *DatasetReconciler) releaseReference(ctx context.Context, ds *datasetv1alpha1.Dataset) error {
+	if ds.Status.PVCName != "" {
+		pvc := &corev1.PersistentVolumeClaim{}
+		err := r.Get(ctx, client.ObjectKey{Namespace: ds.Namespace, Name: ds.Status.PVCName}, pvc)
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+		if err == nil && pvc.Labels[constants.DatasetNameLabel] == ds.Name {
+			log.Infof("releasing pvc %s/%s of dataset %s whose share is revoked", pvc.Namespace, pvc.Name, ds.Name)
+			if err := r.Delete(ctx, pvc); err != nil && !k8serrors.IsNotFound(err) {
+				return err
+			}
+		}
+	}
+	if err := r.cleanupRetainedPV(ctx, ds); err != nil {
+		return err
+	}
+
+	ds.Status.PVCName = ""
+	meta.RemoveStatusCondition(&ds.Status.Conditions, condTypePVC)
+
+	return nil
+}
+
+// reconcileConsumers lists the REFERENCE datasets with the access to a
+// shared dataset in its status.
+func (r *DatasetReconciler) reconcileConsumers(ctx context.Context, ds *datasetv1alpha1.Dataset) error {
+	if ds.Spec.Source.Type == datasetv1alpha1.DatasetTypeReference || (!ds.Spec.Share && len(ds.Status.Consumers) == 0) {
+		return nil
+	}
+
+	var consumers []datasetv1alpha1.DatasetConsumer
+	if ds.Spec.Share {
+		referencingDatasets, err := r.findReferencingDatasets(ctx, ds)
+		if err != nil {
+			return err
+		}
+		for i := range referencingDatasets {
+			refDs := &referencingDatasets[i]
+			grant, err := r.checkShareAccess(ctx, refDs, ds)
+			if errors.Is(err, errShareDenied) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			_, _, subPath, _ := parseReferenceURI(refDs.Spec.Source.URI)
+			consumers = append(consumers, datasetv1alpha1.DatasetConsumer{
+				Namespace: refDs.Namespace,
+				Name:      refDs.Name,
+				SubPath:   subPath,
+				Grant:     grant,
+			})
+		}
+	}
+	ds.Status.Consumers = consumers
+
+	return nil
+}
+
+// requestsOfSharing maps the changes of datasets, share grants and namespaces
+// to the datasets whose share is affected.
+func (r *DatasetReconciler) requestsOfSharing(ctx context.Context, obj client.Object) []reconcile.Request {
+	var sources []*datasetv1alpha1.Dataset
+	var requests []reconcile.Request
+	switch o := obj.(type) {
+	case *datasetv1alpha1.Dataset:
+		if o.Spec.Source.Type != datasetv1alpha1.DatasetTypeReference {
+			sources = append(sources, o)
+			break
+		}
+		// the consumers of the source dataset
+		namespace, name, _, err := parseReferenceURI(o.Spec.Source.URI)
+		if err == nil {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: namespace, Name: name}})
+		}
+	case *datasetv1alpha1.DatasetShareGrant:
+		sourceDs := &datasetv1alpha1.Dataset{}
+		if err := r.Get(ctx, client.ObjectKey{Namespace: o.Namespace, Name: o.Spec.DatasetName}, sourceDs); err != nil {
+			return nil
+		}
+		sources = append(sources, sourceDs)
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(sourceDs)})
+	case *corev1.Namespace:
+		datasets := &datasetv1alpha1.DatasetList{}
+		if err := r.List(ctx, datasets, client.InNamespace(o.Name)); err != nil {
+			log.Errorf("failed to list datasets of namespace %s: %v", o.Name, err)
+			return nil
+		}
+		for _, ds := range datasets.Items {
+			if ds.Spec.Source.Type == datasetv1alpha1.DatasetTypeReference {
+				requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&ds)})
+			}
+		}
+	}
+
+	for _, sourceDs := range sources {
+		referencingDatasets, err := r.findReferencingDatasets(ctx, sourceDs)
+		if err != nil {
+			log.Errorf("failed to find datasets referencing %s/%s: %v", sourceDs.Namespace, sourceDs.Name, err)
+			continue
+		}
+		for _, refDs := range referencingDatasets {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&refDs)})
+		}
+	}
+
+	return requests
+}
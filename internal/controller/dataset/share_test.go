@@ -0,0 +1,264 @@
+package dataset
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	datasetv1alpha1 "github.com/BaizeAI/dataset/api/dataset/v1alpha1"
+	"github.com/BaizeAI/dataset/config"
+	"github.com/BaizeAI/dataset/pkg/kubeutils"
+)
+
+func TestDatasetReconciler_reconcileShareAccess(t *testing.T) {
+	require.NoError(t, config.ParseConfigFromFileContent(""))
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, datasetv1alpha1.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	sourceDs := &datasetv1alpha1.Dataset{
+		ObjectMeta: metav1.ObjectMeta{Name: "models", Namespace: "shared"},
+		Spec: datasetv1alpha1.DatasetSpec{
+			Share:              true,
+			ShareGrantRequired: true,
+			Source:             datasetv1alpha1.DatasetSource{Type: datasetv1alpha1.DatasetTypeS3, URI: "s3://bucket/models"},
+		},
+		Status: datasetv1alpha1.DatasetStatus{PVCName: "models"},
+	}
+	grant := &datasetv1alpha1.DatasetShareGrant{
+		ObjectMeta: metav1.ObjectMeta{Name: "team-a", Namespace: "shared"},
+		Spec: datasetv1alpha1.DatasetShareGrantSpec{
+			DatasetName: "models",
+			Namespaces:  []string{"team-a"},
+		},
+	}
+	ds := &datasetv1alpha1.Dataset{
+		ObjectMeta: metav1.ObjectMeta{Name: "llama", Namespace: "team-a", UID: "0123456789abcdef"},
+		Spec: datasetv1alpha1.DatasetSpec{
+			Source: datasetv1alpha1.DatasetSource{
+				Type: datasetv1alpha1.DatasetTypeReference,
+				URI:  "dataset://shared/models/llama-7b",
+			},
+		},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithIndex(&datasetv1alpha1.Dataset{}, referenceSourceField, indexReferenceSource).WithObjects(
+		sourceDs,
+		grant,
+		ds.DeepCopy(),
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}},
+		&corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: "models", Namespace: "shared"},
+			Spec:       corev1.PersistentVolumeClaimSpec{VolumeName: "pv-models"},
+		},
+		&corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "pv-models"},
+			Spec: corev1.PersistentVolumeSpec{
+				PersistentVolumeSource: corev1.PersistentVolumeSource{
+					CSI: &corev1.CSIPersistentVolumeSource{
+						Driver:           nfsCSIDriver,
+						VolumeAttributes: map[string]string{"server": "nfs.example.com", "share": "/", "subdir": "/models"},
+					},
+				},
+			},
+		},
+	).Build()
+	reconciler := &DatasetReconciler{Client: fakeClient, Scheme: scheme}
+	ctx := context.Background()
+
+	// the namespace is approved by the grant
+	require.NoError(t, reconciler.reconcileShareAccess(ctx, ds))
+	require.NoError(t, reconciler.reconcilePVC(ctx, ds))
+	ds.Status.Conditions = kubeutils.SetCondition(ds.Status.Conditions, condTypePVC, nil)
+	assert.Equal(t, "llama", ds.Status.PVCName)
+	pvName := "dataset-team-a-llama-0123456789ab"
+	clonedPV := &corev1.PersistentVolume{}
+	require.NoError(t, fakeClient.Get(ctx, client.ObjectKey{Name: pvName}, clonedPV))
+	assert.Equal(t, "/models/llama-7b", clonedPV.Spec.CSI.VolumeAttributes["subdir"])
+
+	require.NoError(t, reconciler.reconcileConsumers(ctx, sourceDs))
+	assert.Equal(t, []datasetv1alpha1.DatasetConsumer{
+		{Namespace: "team-a", Name: "llama", SubPath: "llama-7b", Grant: "team-a"},
+	}, sourceDs.Status.Consumers)
+
+	// the share is revoked once the grant expires
+	grant.Spec.ExpiresAt = &metav1.Time{Time: time.Now().Add(-time.Minute)}
+	require.NoError(t, fakeClient.Update(ctx, grant))
+	err := reconciler.reconcileShareAccess(ctx, ds)
+	assert.EqualError(t, err, "share denied: no unexpired share grant of source dataset dataset://shared/models/llama-7b approves namespace team-a or service account team-a/default")
+	assert.False(t, kubeutils.IsConditionReady(ds.Status.Conditions, condTypeShare))
+	assert.Nil(t, meta.FindStatusCondition(ds.Status.Conditions, condTypePVC))
+	assert.Empty(t, ds.Status.PVCName)
+	assert.Error(t, fakeClient.Get(ctx, client.ObjectKey{Namespace: "team-a", Name: "llama"}, &corev1.PersistentVolumeClaim{}))
+	assert.Error(t, fakeClient.Get(ctx, client.ObjectKey{Name: pvName}, &corev1.PersistentVolume{}))
+	require.NoError(t, reconciler.reconcilePhase(ctx, ds))
+	assert.Equal(t, datasetv1alpha1.DatasetStatusPhaseFailed, ds.Status.Phase)
+
+	require.NoError(t, reconciler.reconcileConsumers(ctx, sourceDs))
+	assert.Empty(t, sourceDs.Status.Consumers)
+
+	// the share is approved again by another grant
+	require.NoError(t, fakeClient.Create(ctx, &datasetv1alpha1.DatasetShareGrant{
+		ObjectMeta: metav1.ObjectMeta{Name: "team-a-renewed", Namespace: "shared"},
+		Spec: datasetv1alpha1.DatasetShareGrantSpec{
+			DatasetName: "models",
+			Namespaces:  []string{"team-a", "team-b"},
+			ExpiresAt:   &metav1.Time{Time: time.Now().Add(time.Hour)},
+		},
+	}))
+	require.NoError(t, reconciler.reconcileShareAccess(ctx, ds))
+	assert.True(t, kubeutils.IsConditionReady(ds.Status.Conditions, condTypeShare))
+	require.NoError(t, reconciler.reconcilePVC(ctx, ds))
+	assert.Equal(t, "llama", ds.Status.PVCName)
+
+	// the share is revoked once the namespace does not match the selector
+	sourceDs.Spec.ShareToNamespaceSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"share": "true"}}
+	require.NoError(t, fakeClient.Update(ctx, sourceDs))
+	assert.EqualError(t, reconciler.reconcileShareAccess(ctx, ds), "share denied: source dataset dataset://shared/models/llama-7b is not shared to current namespace")
+	assert.Empty(t, ds.Status.PVCName)
+}
+
+func TestDatasetReconciler_checkShareAccessServiceAccounts(t *testing.T) {
+	require.NoError(t, config.ParseConfigFromFileContent(""))
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, datasetv1alpha1.AddToScheme(scheme))
+
+	sourceDs := &datasetv1alpha1.Dataset{
+		ObjectMeta: metav1.ObjectMeta{Name: "models", Namespace: "shared"},
+		Spec: datasetv1alpha1.DatasetSpec{
+			Share:              true,
+			ShareGrantRequired: true,
+			Source:             datasetv1alpha1.DatasetSource{Type: datasetv1alpha1.DatasetTypeS3, URI: "s3://bucket/models"},
+		},
+	}
+	for _, testCase := range []struct {
+		name            string
+		serviceAccounts []string
+		overrides       *datasetv1alpha1.LoaderPodOverrides
+		wantErr         string
+	}{
+		{
+			name:            "default service account",
+			serviceAccounts: []string{"team-a/default"},
+		},
+		{
+			name:            "service account of the pod overrides",
+			serviceAccounts: []string{"team-a/loader"},
+			overrides:       &datasetv1alpha1.LoaderPodOverrides{ServiceAccountName: "loader"},
+		},
+		{
+			name:            "other service account",
+			serviceAccounts: []string{"team-a/loader"},
+			wantErr:         "share denied: no unexpired share grant of source dataset dataset://shared/models approves namespace team-a or service account team-a/default",
+		},
+		{
+			name:            "service account of another namespace",
+			serviceAccounts: []string{"team-b/default"},
+			wantErr:         "share denied: no unexpired share grant of source dataset dataset://shared/models approves namespace team-a or service account team-a/default",
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			grant := &datasetv1alpha1.DatasetShareGrant{
+				ObjectMeta: metav1.ObjectMeta{Name: "loaders", Namespace: "shared"},
+				Spec: datasetv1alpha1.DatasetShareGrantSpec{
+					DatasetName:     "models",
+					ServiceAccounts: testCase.serviceAccounts,
+				},
+			}
+			ds := &datasetv1alpha1.Dataset{
+				ObjectMeta: metav1.ObjectMeta{Name: "llama", Namespace: "team-a"},
+				Spec: datasetv1alpha1.DatasetSpec{
+					Source:       datasetv1alpha1.DatasetSource{Type: datasetv1alpha1.DatasetTypeReference, URI: "dataset://shared/models"},
+					PodOverrides: testCase.overrides,
+				},
+			}
+			fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(sourceDs, grant).Build()
+			reconciler := &DatasetReconciler{Client: fakeClient, Scheme: scheme}
+
+			name, err := reconciler.checkShareAccess(context.Background(), ds, sourceDs)
+			if testCase.wantErr != "" {
+				assert.EqualError(t, err, testCase.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "loaders", name)
+		})
+	}
+}
+
+func TestDatasetReconciler_requestsOfSharing(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, datasetv1alpha1.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	sourceDs := &datasetv1alpha1.Dataset{
+		ObjectMeta: metav1.ObjectMeta{Name: "models", Namespace: "shared"},
+		Spec: datasetv1alpha1.DatasetSpec{
+			Share:  true,
+			Source: datasetv1alpha1.DatasetSource{Type: datasetv1alpha1.DatasetTypeS3, URI: "s3://bucket/models"},
+		},
+	}
+	ds := &datasetv1alpha1.Dataset{
+		ObjectMeta: metav1.ObjectMeta{Name: "llama", Namespace: "team-a"},
+		Spec: datasetv1alpha1.DatasetSpec{
+			Source: datasetv1alpha1.DatasetSource{Type: datasetv1alpha1.DatasetTypeReference, URI: "dataset://shared/models"},
+		},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithIndex(&datasetv1alpha1.Dataset{}, referenceSourceField, indexReferenceSource).WithObjects(sourceDs, ds).Build()
+	reconciler := &DatasetReconciler{Client: fakeClient, Scheme: scheme}
+	ctx := context.Background()
+
+	source := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(sourceDs)}
+	consumer := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(ds)}
+	assert.Equal(t, []ctrl.Request{consumer}, reconciler.requestsOfSharing(ctx, sourceDs))
+	assert.Equal(t, []ctrl.Request{source}, reconciler.requestsOfSharing(ctx, ds))
+	assert.Equal(t, []ctrl.Request{source, consumer}, reconciler.requestsOfSharing(ctx, &datasetv1alpha1.DatasetShareGrant{
+		ObjectMeta: metav1.ObjectMeta{Name: "team-a", Namespace: "shared"},
+		Spec:       datasetv1alpha1.DatasetShareGrantSpec{DatasetName: "models"},
+	}))
+	assert.Equal(t, []ctrl.Request{consumer}, reconciler.requestsOfSharing(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}}))
+}
+
+func TestDatasetShareGrantReconciler_Reconcile(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, datasetv1alpha1.AddToScheme(scheme))
+
+	grant := &datasetv1alpha1.DatasetShareGrant{
+		ObjectMeta: metav1.ObjectMeta{Name: "team-a", Namespace: "shared"},
+		Spec: datasetv1alpha1.DatasetShareGrantSpec{
+			DatasetName: "models",
+			Namespaces:  []string{"team-a"},
+			ExpiresAt:   &metav1.Time{Time: time.Now().Add(time.Hour)},
+		},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(grant).
+		WithStatusSubresource(&datasetv1alpha1.DatasetShareGrant{}).Build()
+	reconciler := &DatasetShareGrantReconciler{Client: fakeClient, Scheme: scheme}
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(grant)}
+
+	res, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.InDelta(t, time.Hour, res.RequeueAfter, float64(time.Minute))
+	require.NoError(t, fakeClient.Get(ctx, req.NamespacedName, grant))
+	assert.Equal(t, datasetv1alpha1.DatasetShareGrantPhaseActive, grant.Status.Phase)
+
+	grant.Spec.ExpiresAt = &metav1.Time{Time: time.Now().Add(-time.Second)}
+	require.NoError(t, fakeClient.Update(ctx, grant))
+	res, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Zero(t, res.RequeueAfter)
+	require.NoError(t, fakeClient.Get(ctx, req.NamespacedName, grant))
+	assert.Equal(t, datasetv1alpha1.DatasetShareGrantPhaseExpired, grant.Status.Phase)
+}
@@ -0,0 +1,128 @@
+package dataset
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/samber/lo"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	datasetv1alpha1 "github.com/BaizeAI/dataset/api/dataset/v1alpha1"
+	"github.com/BaizeAI/dataset/internal/pkg/constants"
+	"github.com/BaizeAI/dataset/internal/pkg/jobresult"
+)
+
+// sourceContainerName is the name of the data loader container of a source of
+// a dataset with sources.
+func sourceContainerName(sourceName string) string {
+	return "dataset-loader-" + sourceName
+}
+
+// validateSources checks that the sources of the dataset are loaded into
+// subdirectories of the dataset which do not overlap.
+func validateSources(ds *datasetv1alpha1.Dataset) error {
+	paths := make(map[string]string, len(ds.Spec.Sources))
+	for _, source := range ds.Spec.Sources {
+		if strings.HasPrefix(source.Path, "/") {
+			return fmt.Errorf("path of source %s should not start with '/', got: %s", source.Name, source.Path)
+		}
+		if lo.Contains(strings.Split(source.Path, "/"), "..") {
+			return fmt.Errorf("path of source %s should not contain '..', got: %s", source.Name, source.Path)
+		}
+
+		p := path.Clean(source.Path)
+		for other, otherPath := range paths {
+			if p == otherPath || p == "." || otherPath == "." || strings.HasPrefix(p, otherPath+"/") || strings.HasPrefix(otherPath, p+"/") {
+				return fmt.Errorf("paths of sources %s and %s overlap", other, source.Name)
+			}
+		}
+		paths[source.Name] = p
+	}
+
+	return nil
+}
+
+// withSources makes the job load the sources of the dataset one after another,
+// each source is loaded by a data loader container of its own with its own
+// secret. The sources but the last are loaded by init containers.
+func withSources(ds *datasetv1alpha1.Dataset, jobSpec batchv1.JobSpec) batchv1.JobSpec {
+	podSpec := &jobSpec.Template.Spec
+	template := podSpec.Containers[0]
+
+	pvcVolume, pvcVolumeMount := datasetPVCVolume(ds)
+	podSpec.Volumes = append(podSpec.Volumes, pvcVolume)
+
+	containers := make([]corev1.Container, 0, len(ds.Spec.Sources))
+	for _, source := range ds.Spec.Sources {
+		container := *template.DeepCopy()
+		container.Name = sourceContainerName(source.Name)
+		setLoaderResources(&container, source.Type, source.Options)
+
+		if secretRef := lo.CoalesceOrEmpty(source.SecretRef, ds.Spec.SecretRef); secretRef != "" {
+			volumeName := "dataset-secret-" + source.Name
+			podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+				Name: volumeName,
+				VolumeSource: corev1.VolumeSource{
+					Secret: &corev1.SecretVolumeSource{
+						SecretName: secretRef,
+					},
+				},
+			})
+			container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+				Name:      volumeName,
+				MountPath: constants.DatasetJobSecretsMountPath,
+				ReadOnly:  true,
+			})
+		}
+		container.VolumeMounts = append(container.VolumeMounts, pvcVolumeMount)
+
+		args := []string{
+			string(source.Type),
+			source.URI,
+		}
+		args = append(args, loaderOptionArgs(source.Options)...)
+		args = append(args, fmt.Sprintf("--mount-path=%s", lo.CoalesceOrEmpty(path.Join(ds.Spec.MountOptions.Path, source.Path), "/")))
+		if ds.Spec.MountOptions.Mode != "" {
+			args = append(args, fmt.Sprintf("--mount-mode=%s", ds.Spec.MountOptions.Mode))
+		}
+		args = append(args, fmt.Sprintf("--mount-uid=%d", ds.Spec.MountOptions.UID))
+		args = append(args, fmt.Sprintf("--mount-gid=%d", ds.Spec.MountOptions.GID))
+		args = append(args, fmt.Sprintf("--mount-root=%s", datasetPVCMountPath))
+		if container.TerminationMessagePath != "" {
+			args = append(args, fmt.Sprintf("--termination-message-path=%s", container.TerminationMessagePath))
+		}
+		container.Args = args
+		withBlobCache(podSpec, &container, source.Type)
+
+		containers = append(containers, container)
+	}
+
+	last := len(containers) - 1
+	podSpec.InitContainers = append(podSpec.InitContainers, containers[:last]...)
+	podSpec.Containers[0] = containers[last]
+
+	return jobSpec
+}
+
+// sourceLoadStatuses returns the status of each source of the dataset by the
+// results of the job, a source succeeded when its container exited
+// successfully.
+func sourceLoadStatuses(ds *datasetv1alpha1.Dataset, results map[string]jobresult.Result) []datasetv1alpha1.SourceLoadStatus {
+	if len(ds.Spec.Sources) == 0 {
+		return nil
+	}
+
+	statuses := make([]datasetv1alpha1.SourceLoadStatus, 0, len(ds.Spec.Sources))
+	for _, source := range ds.Spec.Sources {
+		result, ok := results[sourceContainerName(source.Name)]
+		statuses = append(statuses, datasetv1alpha1.SourceLoadStatus{
+			Name:    source.Name,
+			Succeed: ok,
+			Digest:  result.Digest,
+		})
+	}
+
+	return statuses
+}
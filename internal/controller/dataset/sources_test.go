@@ -0,0 +1,182 @@
+package dataset
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	datasetv1alpha1 "github.com/BaizeAI/dataset/api/dataset/v1alpha1"
+	"github.com/BaizeAI/dataset/config"
+)
+
+func TestValidateSources(t *testing.T) {
+	for _, testCase := range []struct {
+		name    string
+		paths   []string
+		wantErr string
+	}{
+		{name: "separate paths", paths: []string{"model", "configs", "eval/data"}},
+		{name: "single source at the root", paths: []string{""}},
+		{name: "absolute path", paths: []string{"/model"}, wantErr: "path of source s0 should not start with '/', got: /model"},
+		{name: "parent directory", paths: []string{"model/../.."}, wantErr: "path of source s0 should not contain '..', got: model/../.."},
+		{name: "same paths", paths: []string{"model", "model/"}, wantErr: "paths of sources s0 and s1 overlap"},
+		{name: "nested paths", paths: []string{"eval", "eval/data"}, wantErr: "paths of sources s0 and s1 overlap"},
+		{name: "root and another path", paths: []string{"model", ""}, wantErr: "paths of sources s0 and s1 overlap"},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			ds := &datasetv1alpha1.Dataset{}
+			for i, p := range testCase.paths {
+				ds.Spec.Sources = append(ds.Spec.Sources, datasetv1alpha1.DatasetSubSource{
+					Name: fmt.Sprintf("s%d", i),
+					Path: p,
+				})
+			}
+
+			err := validateSources(ds)
+			if testCase.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, testCase.wantErr)
+			}
+		})
+	}
+}
+
+func TestDatasetReconciler_reconcileSources(t *testing.T) {
+	require.NoError(t, config.ParseConfigFromFileContent(""))
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, datasetv1alpha1.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+	require.NoError(t, batchv1.AddToScheme(scheme))
+
+	ds := &datasetv1alpha1.Dataset{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "training",
+			Namespace: "default",
+		},
+		Spec: datasetv1alpha1.DatasetSpec{
+			Sources: []datasetv1alpha1.DatasetSubSource{
+				{
+					Name: "model",
+					Type: datasetv1alpha1.DatasetTypeHuggingFace,
+					URI:  "huggingface://ns/model",
+					Path: "model",
+				},
+				{
+					Name:      "configs",
+					Type:      datasetv1alpha1.DatasetTypeGit,
+					URI:       "https://github.com/example/configs.git",
+					Options:   map[string]string{"branch": "main"},
+					SecretRef: "git-credentials",
+					Path:      "configs",
+				},
+				{
+					Name: "eval",
+					Type: datasetv1alpha1.DatasetTypeS3,
+					URI:  "s3://bucket/eval",
+					Path: "eval/data",
+				},
+			},
+			SecretRef:     "default-credentials",
+			MountOptions:  datasetv1alpha1.MountOptions{Path: "/", Mode: "0774", UID: 1000, GID: 1000},
+			DataSyncRound: 1,
+		},
+		Status: datasetv1alpha1.DatasetStatus{
+			PVCName: "training",
+		},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&batchv1.Job{}).Build()
+	reconciler := &DatasetReconciler{Client: fakeClient, Scheme: scheme}
+	ctx := context.Background()
+
+	assert.True(t, supportPreload(ds))
+	require.NoError(t, reconciler.reconcileJob(ctx, ds))
+	job := &batchv1.Job{}
+	require.NoError(t, fakeClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: genJobName(ds.Name, 1)}, job))
+
+	// the sources are loaded in order, the last one by the main container
+	podSpec := job.Spec.Template.Spec
+	require.Len(t, podSpec.InitContainers, 2)
+	require.Len(t, podSpec.Containers, 1)
+	assert.Equal(t, "dataset-loader-model", podSpec.InitContainers[0].Name)
+	assert.Equal(t, "dataset-loader-configs", podSpec.InitContainers[1].Name)
+	assert.Equal(t, "dataset-loader-eval", podSpec.Containers[0].Name)
+
+	assert.Equal(t, []string{
+		"GIT",
+		"https://github.com/example/configs.git",
+		"--options=branch=main",
+		"--mount-path=/configs",
+		"--mount-mode=0774",
+		"--mount-uid=1000",
+		"--mount-gid=1000",
+		"--mount-root=/baize/dataset/data",
+	}, podSpec.InitContainers[1].Args)
+	assert.Equal(t, "--mount-path=/eval/data", podSpec.Containers[0].Args[2])
+	assert.Equal(t, "2", podSpec.InitContainers[0].Resources.Requests.Cpu().String())
+
+	// each source has its own secret, which defaults to the one of the dataset
+	secretOf := func(container corev1.Container) string {
+		for _, mount := range container.VolumeMounts {
+			if mount.MountPath != "/run/dataset/secrets" {
+				continue
+			}
+			for _, volume := range podSpec.Volumes {
+				if volume.Name == mount.Name {
+					return volume.Secret.SecretName
+				}
+			}
+		}
+		return ""
+	}
+	assert.Equal(t, "default-credentials", secretOf(podSpec.InitContainers[0]))
+	assert.Equal(t, "git-credentials", secretOf(podSpec.InitContainers[1]))
+	assert.Equal(t, "default-credentials", secretOf(podSpec.Containers[0]))
+
+	// the second source failed, the status of each source is reported
+	job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}}
+	require.NoError(t, fakeClient.Status().Update(ctx, job))
+	require.NoError(t, fakeClient.Create(ctx, &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      job.Name + "-abcde",
+			Namespace: "default",
+			Labels:    map[string]string{batchv1.JobNameLabel: job.Name},
+		},
+		Status: corev1.PodStatus{
+			InitContainerStatuses: []corev1.ContainerStatus{
+				{
+					Name: "dataset-loader-model",
+					State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+						ExitCode: 0,
+						Message:  `{"digest":"sha256:0123"}`,
+					}},
+				},
+				{
+					Name:  "dataset-loader-configs",
+					State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1}},
+				},
+			},
+		},
+	}))
+
+	ds.Status.InProcessing = true
+	ds.Status.InProcessingRound = 1
+	require.NoError(t, reconciler.reconcileJobStatus(ctx, ds))
+	require.Len(t, ds.Status.SyncRoundStatuses, 1)
+	assert.False(t, ds.Status.SyncRoundStatuses[0].Succeed)
+	assert.Equal(t, []datasetv1alpha1.SourceLoadStatus{
+		{Name: "model", Succeed: true, Digest: "sha256:0123"},
+		{Name: "configs"},
+		{Name: "eval"},
+	}, ds.Status.SyncRoundStatuses[0].Sources)
+}
@@ -0,0 +1,501 @@
+package dataset
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	datasetv1alpha1 "github.com/BaizeAI/dataset/api/dataset/v1alpha1"
+	"github.com/BaizeAI/dataset/internal/pkg/constants"
+	"github.com/BaizeAI/dataset/internal/pkg/upload"
+	"github.com/BaizeAI/dataset/pkg/log"
+	"github.com/BaizeAI/dataset/pkg/utils"
+)
+
+const (
+	// UploadSubresource is the subresource of datasets the users need the
+	// create verb on to upload into a MANUAL dataset.
+	UploadSubresource = "upload"
+
+	uploadAPIPrefix      = "/apis/dataset.baizeai.io/v1alpha1/namespaces/{namespace}/datasets/{name}"
+	uploadTokenKey       = "token"
+	uploadActivityPeriod = time.Minute
+	uploadRetryAfter     = "5"
+	keepUploads          = 10
+)
+
+// UploadAuthorizer authorizes the requests of the upload API, it returns the
+// name of the user, or an API status error.
+type UploadAuthorizer interface {
+	Authorize(ctx context.Context, req *http.Request, namespace, name string) (string, error)
+}
+
+// SubjectAccessReviewAuthorizer authenticates the bearer token of a request
+// with a TokenReview, and allows the users who can create the upload
+// subresource of the dataset according to a SubjectAccessReview.
+type SubjectAccessReviewAuthorizer struct {
+	Client client.Client
+}
+
+func (a *SubjectAccessReviewAuthorizer) Authorize(ctx context.Context, req *http.Request, namespace, name string) (string, error) {
+	token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return "", k8serrors.NewUnauthorized("a bearer token is required")
+	}
+
+	tokenReview := &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token},
+	}
+	if err := a.Client.Create(ctx, tokenReview); err != nil {
+		return "", err
+	}
+	if !tokenReview.Status.Authenticated {
+		return "", k8serrors.NewUnauthorized(lo.CoalesceOrEmpty(tokenReview.Status.Error, "the token is not authenticated"))
+	}
+
+	userInfo := tokenReview.Status.User
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace:   namespace,
+				Verb:        "create",
+				Group:       datasetv1alpha1.GroupVersion.Group,
+				Resource:    "datasets",
+				Subresource: UploadSubresource,
+				Name:        name,
+			},
+			User:   userInfo.Username,
+			Groups: userInfo.Groups,
+			UID:    userInfo.UID,
+			Extra: lo.MapValues(userInfo.Extra, func(v authenticationv1.ExtraValue, _ string) authorizationv1.ExtraValue {
+				return authorizationv1.ExtraValue(v)
+			}),
+		},
+	}
+	if err := a.Client.Create(ctx, review); err != nil {
+		return "", err
+	}
+	if !review.Status.Allowed {
+		return "", k8serrors.NewForbidden(schema.GroupResource{
+			Group:    datasetv1alpha1.GroupVersion.Group,
+			Resource: "datasets/" + UploadSubresource,
+		}, name, errors.New(lo.CoalesceOrEmpty(review.Status.Reason, "the user can not upload into the dataset")))
+	}
+
+	return userInfo.Username, nil
+}
+
+// UploadServer serves the upload API of MANUAL datasets. The requests are
+// proxied to a helper pod mounting the pvc of the dataset, which is created
+// on demand and deleted once it is idle for IdleTimeout.
+//
+//   - HEAD, PATCH and DELETE .../datasets/<name>/uploads/<id> resume, append
+//     a chunk to and abort a chunked upload
+//   - POST .../datasets/<name>/uploads/<id>/complete?path=<path>[&digest=<digest>]
+//     places the file of a chunked upload
+//   - PUT .../datasets/<name>/tar?path=<dir>[&digest=<digest>] extracts a tar
+//     stream
+//
+// The completed uploads are recorded in the status of the dataset.
+type UploadServer struct {
+	Client client.Client
+	// APIReader reads the secrets and the datasets to be patched, which are
+	// not cached.
+	APIReader   client.Reader
+	Authorizer  UploadAuthorizer
+	BindAddress string
+	CertFile    string
+	KeyFile     string
+	IdleTimeout time.Duration
+
+	receiverPort int
+	now          func() time.Time
+}
+
+//+kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;create;delete;patch
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;create;delete
+//+kubebuilder:rbac:groups=authentication.k8s.io,resources=tokenreviews,verbs=create
+//+kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+
+// NeedLeaderElection returns false, every replica of the controller serves
+// the upload API behind the service.
+func (s *UploadServer) NeedLeaderElection() bool {
+	return false
+}
+
+func (s *UploadServer) Start(ctx context.Context) error {
+	if s.CertFile == "" || s.KeyFile == "" {
+		return errors.New("the upload API is served over TLS only, the certificate and the key are required")
+	}
+	server := &http.Server{
+		Addr:              s.BindAddress,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 30 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		_ = server.Shutdown(context.Background())
+	}()
+	go s.cleanupIdleReceiversPeriodically(ctx)
+
+	log.Infof("serving the upload API of MANUAL datasets on %s", s.BindAddress)
+	err := server.ListenAndServeTLS(s.CertFile, s.KeyFile)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+
+	return err
+}
+
+func (s *UploadServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("HEAD "+uploadAPIPrefix+"/uploads/{id}", s.proxy(false))
+	mux.HandleFunc("PATCH "+uploadAPIPrefix+"/uploads/{id}", s.proxy(false))
+	mux.HandleFunc("DELETE "+uploadAPIPrefix+"/uploads/{id}", s.proxy(false))
+	mux.HandleFunc("POST "+uploadAPIPrefix+"/uploads/{id}/complete", s.proxy(true))
+	mux.HandleFunc("PUT "+uploadAPIPrefix+"/tar", s.proxy(true))
+
+	return mux
+}
+
+func (s *UploadServer) proxy(record bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		namespace, name := req.PathValue("namespace"), req.PathValue("name")
+
+		user, err := s.Authorizer.Authorize(ctx, req, namespace, name)
+		if err != nil {
+			writeUploadError(w, err)
+			return
+		}
+
+		ds := &datasetv1alpha1.Dataset{}
+		if err := s.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, ds); err != nil {
+			writeUploadError(w, err)
+			return
+		}
+		if ds.Spec.Source.Type != datasetv1alpha1.DatasetTypeManual {
+			writeUploadError(w, k8serrors.NewBadRequest(fmt.Sprintf("dataset %s/%s is of type %s, only MANUAL datasets accept uploads", namespace, name, ds.Spec.Source.Type)))
+			return
+		}
+		if ds.Status.PVCName == "" {
+			w.Header().Set("Retry-After", uploadRetryAfter)
+			http.Error(w, fmt.Sprintf("the pvc of dataset %s/%s is not ready", namespace, name), http.StatusServiceUnavailable)
+			return
+		}
+
+		pod, token, err := s.ensureReceiver(ctx, ds)
+		if err != nil {
+			log.Errorf("error ensuring the upload receiver of dataset %s/%s: %v", namespace, name, err)
+			writeUploadError(w, err)
+			return
+		}
+		if !isPodReady(pod) {
+			w.Header().Set("Retry-After", uploadRetryAfter)
+			http.Error(w, fmt.Sprintf("the upload receiver of dataset %s/%s is starting", namespace, name), http.StatusServiceUnavailable)
+			return
+		}
+		s.touchReceiver(ctx, pod)
+
+		prefix := fmt.Sprintf("/apis/%s/namespaces/%s/datasets/%s", datasetv1alpha1.GroupVersion, namespace, name)
+		receiverPath := strings.TrimPrefix(req.URL.Path, prefix)
+		proxy := &httputil.ReverseProxy{
+			Rewrite: func(pr *httputil.ProxyRequest) {
+				pr.Out.URL.Scheme = "http"
+				pr.Out.URL.Host = net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(lo.CoalesceOrEmpty(s.receiverPort, upload.ReceiverPort)))
+				pr.Out.URL.Path = receiverPath
+				pr.Out.URL.RawPath = ""
+				pr.Out.Host = ""
+				// the token of the user is not passed to the helper pod
+				pr.Out.Header.Set("Authorization", "Bearer "+token)
+			},
+			ModifyResponse: func(resp *http.Response) error {
+				if !record || resp.StatusCode != http.StatusOK {
+					return nil
+				}
+				body, err := io.ReadAll(resp.Body)
+				_ = resp.Body.Close()
+				if err != nil {
+					return err
+				}
+				resp.Body = io.NopCloser(bytes.NewReader(body))
+
+				var result upload.Result
+				if err := json.Unmarshal(body, &result); err != nil {
+					return err
+				}
+				// the content is in place already, failing to record it does
+				// not fail the upload
+				if err := s.recordUpload(ctx, ds, result, user); err != nil {
+					log.Errorf("error recording upload %s of dataset %s/%s: %v", result.Path, namespace, name, err)
+				}
+				return nil
+			},
+		}
+		proxy.ServeHTTP(w, req)
+	}
+}
+
+func (s *UploadServer) currentTime() time.Time {
+	if s.now != nil {
+		return s.now()
+	}
+	return time.Now()
+}
+
+func uploadReceiverName(ds *datasetv1alpha1.Dataset) string {
+	return fmt.Sprintf("dataset-%s-upload", ds.Name)
+}
+
+// ensureReceiver returns the helper pod of the dataset and its token, they
+// are created when missing.
+func (s *UploadServer) ensureReceiver(ctx context.Context, ds *datasetv1alpha1.Dataset) (*corev1.Pod, string, error) {
+	key := types.NamespacedName{Namespace: ds.Namespace, Name: uploadReceiverName(ds)}
+	labels := lo.Assign(ds.Labels, map[string]string{
+		constants.DatasetNameLabel:           ds.Name,
+		constants.DatasetUploadReceiverLabel: "true",
+	})
+
+	secret := &corev1.Secret{}
+	err := s.APIReader.Get(ctx, key, secret)
+	if k8serrors.IsNotFound(err) {
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            key.Name,
+				Namespace:       key.Namespace,
+				Labels:          labels,
+				OwnerReferences: datasetOwnerRef(ds),
+			},
+			Data: map[string][]byte{
+				uploadTokenKey: []byte(utils.RandomHashString()),
+			},
+		}
+		err = s.Client.Create(ctx, secret)
+		if k8serrors.IsAlreadyExists(err) {
+			err = s.APIReader.Get(ctx, key, secret)
+		}
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	token := string(secret.Data[uploadTokenKey])
+
+	pod := &corev1.Pod{}
+	err = s.Client.Get(ctx, key, pod)
+	if err == nil {
+		if pod.DeletionTimestamp == nil && pod.Status.Phase != corev1.PodFailed && pod.Status.Phase != corev1.PodSucceeded {
+			return pod, token, nil
+		}
+		// a receiver which exited is replaced once it is deleted
+		if pod.DeletionTimestamp == nil {
+			if err := s.Client.Delete(ctx, pod); err != nil && !k8serrors.IsNotFound(err) {
+				return nil, "", err
+			}
+		}
+		return pod, token, nil
+	}
+	if !k8serrors.IsNotFound(err) {
+		return nil, "", err
+	}
+
+	pod, err = uploadReceiverPod(ds, secret.Name)
+	if err != nil {
+		return nil, "", err
+	}
+	pod.Labels = labels
+	pod.Annotations = map[string]string{
+		constants.DatasetUploadLastActivityAnnotation: s.currentTime().UTC().Format(time.RFC3339),
+	}
+	if err := s.Client.Create(ctx, pod); err != nil && !k8serrors.IsAlreadyExists(err) {
+		return nil, "", err
+	}
+	log.Infof("created upload receiver %s/%s", pod.Namespace, pod.Name)
+
+	return pod, token, nil
+}
+
+// uploadReceiverPod builds the helper pod from the job template of the data
+// loader, which runs the upload receiver of the data loader instead.
+func uploadReceiverPod(ds *datasetv1alpha1.Dataset, secretName string) (*corev1.Pod, error) {
+	jobSpec, err := loaderJobSpec(ds.Spec.PodOverrides)
+	if err != nil {
+		return nil, err
+	}
+
+	podSpec := jobSpec.Template.Spec
+	container := podSpec.Containers[0]
+	container.Name = "upload-receiver"
+	container.Args = []string{
+		"upload-receiver",
+		fmt.Sprintf("--root=%s", path.Join(datasetPVCMountPath, ds.Spec.MountOptions.Path)),
+		fmt.Sprintf("--uid=%d", ds.Spec.MountOptions.UID),
+		fmt.Sprintf("--gid=%d", ds.Spec.MountOptions.GID),
+	}
+	container.Env = append(container.Env, corev1.EnvVar{
+		Name: upload.TokenEnv,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+				Key:                  uploadTokenKey,
+			},
+		},
+	})
+	container.Ports = []corev1.ContainerPort{{Name: "http", ContainerPort: upload.ReceiverPort}}
+	container.ReadinessProbe = &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			HTTPGet: &corev1.HTTPGetAction{Path: "/healthz", Port: intstr.FromInt32(upload.ReceiverPort)},
+		},
+	}
+
+	volume, volumeMount := datasetPVCVolume(ds)
+	podSpec.Volumes = append(podSpec.Volumes, volume)
+	container.VolumeMounts = append(container.VolumeMounts, volumeMount)
+
+	podSpec.Containers = []corev1.Container{container}
+	podSpec.RestartPolicy = corev1.RestartPolicyAlways
+
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            uploadReceiverName(ds),
+			Namespace:       ds.Namespace,
+			OwnerReferences: datasetOwnerRef(ds),
+		},
+		Spec: podSpec,
+	}, nil
+}
+
+func isPodReady(pod *corev1.Pod) bool {
+	if pod.DeletionTimestamp != nil || pod.Status.PodIP == "" {
+		return false
+	}
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+
+	return false
+}
+
+func lastUploadActivity(pod *corev1.Pod) time.Time {
+	t, err := time.Parse(time.RFC3339, pod.Annotations[constants.DatasetUploadLastActivityAnnotation])
+	if err != nil {
+		return pod.CreationTimestamp.Time
+	}
+	return t
+}
+
+// touchReceiver records the activity on the helper pod, at most once per
+// uploadActivityPeriod as the chunks of an upload are proxied.
+func (s *UploadServer) touchReceiver(ctx context.Context, pod *corev1.Pod) {
+	now := s.currentTime()
+	if now.Sub(lastUploadActivity(pod)) < uploadActivityPeriod {
+		return
+	}
+
+	base := pod.DeepCopy()
+	pod.Annotations = lo.Assign(pod.Annotations, map[string]string{
+		constants.DatasetUploadLastActivityAnnotation: now.UTC().Format(time.RFC3339),
+	})
+	if err := s.Client.Patch(ctx, pod, client.MergeFrom(base)); err != nil {
+		log.Warnf("error recording the activity of upload receiver %s/%s: %v", pod.Namespace, pod.Name, err)
+	}
+}
+
+func (s *UploadServer) recordUpload(ctx context.Context, ds *datasetv1alpha1.Dataset, result upload.Result, user string) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		latest := &datasetv1alpha1.Dataset{}
+		if err := s.APIReader.Get(ctx, client.ObjectKeyFromObject(ds), latest); err != nil {
+			return err
+		}
+
+		base := latest.DeepCopy()
+		latest.Status.Uploads = append(latest.Status.Uploads, datasetv1alpha1.UploadStatus{
+			Path:           result.Path,
+			Size:           result.Size,
+			Digest:         result.Digest,
+			User:           user,
+			CompletionTime: metav1.NewTime(s.currentTime()),
+		})
+		if len(latest.Status.Uploads) > keepUploads {
+			latest.Status.Uploads = latest.Status.Uploads[len(latest.Status.Uploads)-keepUploads:]
+		}
+
+		return s.Client.Status().Patch(ctx, latest, client.MergeFromWithOptions(base, client.MergeFromWithOptimisticLock{}))
+	})
+}
+
+func (s *UploadServer) cleanupIdleReceiversPeriodically(ctx context.Context) {
+	ticker := time.NewTicker(uploadActivityPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.cleanupIdleReceivers(ctx); err != nil {
+				log.Errorf("error cleaning up idle upload receivers: %v", err)
+			}
+		}
+	}
+}
+
+// cleanupIdleReceivers deletes the helper pods and their tokens which are
+// idle for IdleTimeout, uploads which are not completed stay in the pvc and
+// are resumed by a new helper pod.
+func (s *UploadServer) cleanupIdleReceivers(ctx context.Context) error {
+	pods := &corev1.PodList{}
+	if err := s.Client.List(ctx, pods, client.HasLabels{constants.DatasetUploadReceiverLabel}); err != nil {
+		return err
+	}
+
+	now := s.currentTime()
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if now.Sub(lastUploadActivity(pod)) < s.IdleTimeout {
+			continue
+		}
+
+		log.Infof("deleting idle upload receiver %s/%s", pod.Namespace, pod.Name)
+		if err := s.Client.Delete(ctx, pod); err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+		secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: pod.Namespace, Name: pod.Name}}
+		if err := s.Client.Delete(ctx, secret); err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeUploadError(w http.ResponseWriter, err error) {
+	code := http.StatusInternalServerError
+	var status k8serrors.APIStatus
+	if errors.As(err, &status) {
+		code = int(status.Status().Code)
+	}
+	http.Error(w, err.Error(), code)
+}
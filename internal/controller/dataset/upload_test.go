@@ -0,0 +1,190 @@
+package dataset
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	datasetv1alpha1 "github.com/BaizeAI/dataset/api/dataset/v1alpha1"
+	"github.com/BaizeAI/dataset/config"
+	"github.com/BaizeAI/dataset/internal/pkg/constants"
+	"github.com/BaizeAI/dataset/internal/pkg/upload"
+)
+
+type fakeUploadAuthorizer map[string]string
+
+func (a fakeUploadAuthorizer) Authorize(_ context.Context, req *http.Request, _, name string) (string, error) {
+	user, ok := a[req.Header.Get("Authorization")]
+	if !ok {
+		return "", k8serrors.NewUnauthorized("a bearer token is required")
+	}
+	if user == "" {
+		return "", k8serrors.NewForbidden(schema.GroupResource{Group: datasetv1alpha1.GroupVersion.Group, Resource: "datasets/upload"}, name, nil)
+	}
+	return user, nil
+}
+
+func TestUploadServer(t *testing.T) {
+	require.NoError(t, config.ParseConfigFromFileContent(""))
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, datasetv1alpha1.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	manual := &datasetv1alpha1.Dataset{
+		ObjectMeta: metav1.ObjectMeta{Name: "manual", Namespace: "default", UID: "uid"},
+		Spec: datasetv1alpha1.DatasetSpec{
+			Source:       datasetv1alpha1.DatasetSource{Type: datasetv1alpha1.DatasetTypeManual, URI: "manual://"},
+			MountOptions: datasetv1alpha1.MountOptions{Path: "data", UID: 1000, GID: 1000},
+			VolumeClaimRef: &datasetv1alpha1.VolumeClaimRef{
+				Name:    "shared",
+				SubPath: "team",
+			},
+		},
+		Status: datasetv1alpha1.DatasetStatus{PVCName: "shared"},
+	}
+	git := &datasetv1alpha1.Dataset{
+		ObjectMeta: metav1.ObjectMeta{Name: "git", Namespace: "default"},
+		Spec: datasetv1alpha1.DatasetSpec{
+			Source: datasetv1alpha1.DatasetSource{Type: datasetv1alpha1.DatasetTypeGit, URI: "https://github.com/BaizeAI/dataset"},
+		},
+	}
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(manual, git).
+		WithStatusSubresource(&datasetv1alpha1.Dataset{}).
+		Build()
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := &UploadServer{
+		Client:      fakeClient,
+		APIReader:   fakeClient,
+		Authorizer:  fakeUploadAuthorizer{"Bearer alice": "alice", "Bearer bob": ""},
+		IdleTimeout: 10 * time.Minute,
+		now:         func() time.Time { return now },
+	}
+	server := httptest.NewServer(s.Handler())
+	defer server.Close()
+
+	do := func(method, path, token string, body []byte, headers map[string]string) *http.Response {
+		req, err := http.NewRequest(method, server.URL+"/apis/dataset.baizeai.io/v1alpha1/namespaces/default/datasets/"+path, bytes.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+token)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_ = resp.Body.Close()
+		})
+		return resp
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodHead, "manual/uploads/a", "eve", nil, nil).StatusCode)
+	assert.Equal(t, http.StatusForbidden, do(http.MethodHead, "manual/uploads/a", "bob", nil, nil).StatusCode)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodHead, "missing/uploads/a", "alice", nil, nil).StatusCode)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodHead, "git/uploads/a", "alice", nil, nil).StatusCode)
+
+	// the helper pod is created on the first request
+	resp := do(http.MethodHead, "manual/uploads/a", "alice", nil, nil)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, "5", resp.Header.Get("Retry-After"))
+
+	key := types.NamespacedName{Namespace: "default", Name: "dataset-manual-upload"}
+	secret := &corev1.Secret{}
+	require.NoError(t, fakeClient.Get(context.Background(), key, secret))
+	token := string(secret.Data[uploadTokenKey])
+	assert.Len(t, token, 64)
+
+	pod := &corev1.Pod{}
+	require.NoError(t, fakeClient.Get(context.Background(), key, pod))
+	assert.Equal(t, "manual", pod.Labels[constants.DatasetNameLabel])
+	assert.Equal(t, "true", pod.Labels[constants.DatasetUploadReceiverLabel])
+	assert.Equal(t, "manual", pod.OwnerReferences[0].Name)
+	assert.Equal(t, corev1.RestartPolicyAlways, pod.Spec.RestartPolicy)
+	require.Len(t, pod.Spec.Containers, 1)
+	container := pod.Spec.Containers[0]
+	assert.Equal(t, []string{"upload-receiver", "--root=/baize/dataset/data/data", "--uid=1000", "--gid=1000"}, container.Args)
+	assert.Equal(t, upload.TokenEnv, container.Env[len(container.Env)-1].Name)
+	assert.Equal(t, secret.Name, container.Env[len(container.Env)-1].ValueFrom.SecretKeyRef.Name)
+	assert.Equal(t, corev1.VolumeMount{Name: "dataset-pvc", MountPath: "/baize/dataset/data", SubPath: "team"}, container.VolumeMounts[len(container.VolumeMounts)-1])
+	assert.Equal(t, "shared", pod.Spec.Volumes[len(pod.Spec.Volumes)-1].PersistentVolumeClaim.ClaimName)
+
+	// the helper pod becomes ready
+	root := t.TempDir()
+	receiver, err := upload.NewReceiver(root, token, os.Getuid(), os.Getgid())
+	require.NoError(t, err)
+	receiverServer := httptest.NewServer(receiver.Handler())
+	defer receiverServer.Close()
+	host, port, err := net.SplitHostPort(receiverServer.Listener.Addr().String())
+	require.NoError(t, err)
+	s.receiverPort, err = strconv.Atoi(port)
+	require.NoError(t, err)
+	pod.Status.PodIP = host
+	pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+	require.NoError(t, fakeClient.Status().Update(context.Background(), pod))
+
+	content := []byte("hello")
+	sum := sha256.Sum256(content)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+
+	now = now.Add(2 * time.Minute)
+	resp = do(http.MethodPatch, "manual/uploads/a", "alice", content, map[string]string{upload.OffsetHeader: "0"})
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Equal(t, "5", resp.Header.Get(upload.OffsetHeader))
+	require.NoError(t, fakeClient.Get(context.Background(), key, pod))
+	assert.Equal(t, now.Format(time.RFC3339), pod.Annotations[constants.DatasetUploadLastActivityAnnotation])
+
+	resp = do(http.MethodPost, "manual/uploads/a/complete?path=hello.txt&digest="+digest, "alice", nil, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	data, err := os.ReadFile(filepath.Join(root, "hello.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, content, data)
+
+	ds := &datasetv1alpha1.Dataset{}
+	require.NoError(t, fakeClient.Get(context.Background(), client.ObjectKeyFromObject(manual), ds))
+	require.Len(t, ds.Status.Uploads, 1)
+	assert.True(t, now.Equal(ds.Status.Uploads[0].CompletionTime.Time))
+	ds.Status.Uploads[0].CompletionTime = metav1.Time{}
+	assert.Equal(t, datasetv1alpha1.UploadStatus{
+		Path:   "hello.txt",
+		Size:   5,
+		Digest: digest,
+		User:   "alice",
+	}, ds.Status.Uploads[0])
+
+	// failed uploads are not recorded
+	resp = do(http.MethodPost, "manual/uploads/b/complete?path=missing.txt", "alice", nil, nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	require.NoError(t, fakeClient.Get(context.Background(), client.ObjectKeyFromObject(manual), ds))
+	assert.Len(t, ds.Status.Uploads, 1)
+
+	// the helper pod and its token are deleted once they are idle
+	require.NoError(t, s.cleanupIdleReceivers(context.Background()))
+	assert.NoError(t, fakeClient.Get(context.Background(), key, pod))
+	now = now.Add(11 * time.Minute)
+	require.NoError(t, s.cleanupIdleReceivers(context.Background()))
+	assert.True(t, k8serrors.IsNotFound(fakeClient.Get(context.Background(), key, pod)))
+	assert.True(t, k8serrors.IsNotFound(fakeClient.Get(context.Background(), key, secret)))
+}
@@ -0,0 +1,216 @@
+package dataset
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/samber/lo"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	datasetv1alpha1 "github.com/BaizeAI/dataset/api/dataset/v1alpha1"
+	"github.com/BaizeAI/dataset/internal/pkg/jobresult"
+	"github.com/BaizeAI/dataset/pkg/kubeutils"
+	"github.com/BaizeAI/dataset/pkg/log"
+)
+
+const (
+	condTypeVerified = "Verified"
+
+	// manifestContainerName is the name of the container recording the
+	// manifest of the data at the end of a data sync round.
+	manifestContainerName = "dataset-manifest"
+	// verifierContainerName is the name of the container of the
+	// verification jobs.
+	verifierContainerName = "dataset-verifier"
+)
+
+func genVerifyJobName(dsName string, t time.Time) string {
+	return fmt.Sprintf("dataset-%s-verify-%d", dsName, t.Unix())
+}
+
+func validateVerification(ds *datasetv1alpha1.Dataset) error {
+	if ds.Spec.VerifySchedule == nil {
+		return nil
+	}
+	if !supportPreload(ds) {
+		return fmt.Errorf("verifySchedule is not supported by datasets of type %s", ds.Spec.Source.Type)
+	}
+	if ds.Spec.VerifySchedule.Duration < time.Minute {
+		return fmt.Errorf("verifySchedule should be at least 1m, got: %s", ds.Spec.VerifySchedule.Duration)
+	}
+
+	return nil
+}
+
+// withManifestRecord makes the job record the manifest of the data once it is
+// loaded and processed, the manifest is what the dataset is verified against.
+func withManifestRecord(ds *datasetv1alpha1.Dataset, jobSpec batchv1.JobSpec, template corev1.Container) batchv1.JobSpec {
+	if ds.Spec.VerifySchedule == nil {
+		return jobSpec
+	}
+
+	podSpec := &jobSpec.Template.Spec
+	podSpec.InitContainers = append(podSpec.InitContainers, podSpec.Containers...)
+
+	_, pvcVolumeMount := datasetPVCVolume(ds)
+	container := *template.DeepCopy()
+	container.Name = manifestContainerName
+	container.VolumeMounts = append(container.VolumeMounts, pvcVolumeMount)
+	container.Args = []string{
+		"verify",
+		"--record",
+		fmt.Sprintf("--mount-path=%s", lo.CoalesceOrEmpty(ds.Spec.MountOptions.Path, "/")),
+		fmt.Sprintf("--mount-root=%s", datasetPVCMountPath),
+	}
+	podSpec.Containers = []corev1.Container{container}
+
+	return jobSpec
+}
+
+// verifyRequeueAfter returns when the dataset should be reconciled again for
+// its verification, zero when it is not verified.
+func verifyRequeueAfter(ds *datasetv1alpha1.Dataset) time.Duration {
+	if ds.Spec.VerifySchedule == nil {
+		return 0
+	}
+	if ds.Status.Verification != nil && ds.Status.Verification.JobName != "" {
+		return time.Second * 30
+	}
+
+	return max(time.Until(nextVerifyTime(ds)), time.Second)
+}
+
+func nextVerifyTime(ds *datasetv1alpha1.Dataset) time.Time {
+	last := ds.Status.LastSyncTime.Time
+	if ds.Status.Verification != nil && ds.Status.Verification.LastVerifyTime.After(last) {
+		last = ds.Status.Verification.LastVerifyTime.Time
+	}
+
+	return last.Add(ds.Spec.VerifySchedule.Duration)
+}
+
+// reconcileVerification runs a job verifying the data of a ready dataset
+// against its manifest by verifySchedule, and reports the outcome of the job
+// in the Verified condition.
+func (r *DatasetReconciler) reconcileVerification(ctx context.Context, ds *datasetv1alpha1.Dataset) error {
+	if ds.Spec.VerifySchedule == nil || kubeutils.IsDeleted(ds) {
+		return nil
+	}
+
+	if ds.Status.Verification != nil && ds.Status.Verification.JobName != "" {
+		return r.reconcileVerificationJob(ctx, ds)
+	}
+
+	if ds.Status.InProcessing || ds.Status.LastSucceedRound == 0 || ds.Status.LastSucceedRound != ds.Spec.DataSyncRound {
+		return nil
+	}
+	if ds.Status.Verification != nil && ds.Status.Verification.Round != ds.Status.LastSucceedRound {
+		// the data is loaded again along with its manifest, the outcome of the
+		// previous round is outdated
+		ds.Status.Verification = nil
+		meta.RemoveStatusCondition(&ds.Status.Conditions, condTypeVerified)
+	}
+	now := time.Now()
+	if now.Before(nextVerifyTime(ds)) {
+		return nil
+	}
+
+	jobSpec, err := loaderJobSpec(ds.Spec.PodOverrides)
+	if err != nil {
+		return err
+	}
+
+	podSpec := &jobSpec.Template.Spec
+	pvcVolume, pvcVolumeMount := datasetPVCVolume(ds)
+	podSpec.Volumes = append(podSpec.Volumes, pvcVolume)
+
+	container := &podSpec.Containers[0]
+	container.Name = verifierContainerName
+	// the data is recorded as the manifest when there is none
+	container.VolumeMounts = append(container.VolumeMounts, pvcVolumeMount)
+	args := []string{
+		"verify",
+		"--fail-on-drift=false",
+		fmt.Sprintf("--mount-path=%s", lo.CoalesceOrEmpty(ds.Spec.MountOptions.Path, "/")),
+		fmt.Sprintf("--mount-root=%s", datasetPVCMountPath),
+	}
+	if container.TerminationMessagePath != "" {
+		args = append(args, fmt.Sprintf("--termination-message-path=%s", container.TerminationMessagePath))
+	}
+	container.Args = args
+
+	jobName := genVerifyJobName(ds.Name, now)
+	if err := r.createJob(ctx, ds, jobName, jobSpec); err != nil {
+		return err
+	}
+
+	if ds.Status.Verification == nil {
+		ds.Status.Verification = &datasetv1alpha1.VerificationStatus{}
+	}
+	ds.Status.Verification.JobName = jobName
+	ds.Status.Verification.Round = ds.Status.LastSucceedRound
+
+	return nil
+}
+
+func (r *DatasetReconciler) reconcileVerificationJob(ctx context.Context, ds *datasetv1alpha1.Dataset) error {
+	verification := ds.Status.Verification
+
+	job := &batchv1.Job{}
+	err := r.Get(ctx, client.ObjectKey{Namespace: ds.Namespace, Name: verification.JobName}, job)
+	if k8serrors.IsNotFound(err) {
+		verification.JobName = ""
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	failed := lo.ContainsBy(job.Status.Conditions, func(item batchv1.JobCondition) bool {
+		return item.Type == batchv1.JobFailed && item.Status == corev1.ConditionTrue
+	})
+	if job.Status.Succeeded == 0 && !failed {
+		return nil
+	}
+
+	results, resultErr := getJobResults(ctx, r.Client, job)
+	result := results[verifierContainerName]
+	err = r.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground))
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+	verification.JobName = ""
+
+	if ds.Status.InProcessing || verification.Round != ds.Status.LastSucceedRound {
+		// the data is being loaded again, the outcome is outdated
+		return nil
+	}
+	verification.LastVerifyTime = metav1.Time{Time: time.Now()}
+	if failed {
+		ds.Status.Conditions = kubeutils.SetCondition(ds.Status.Conditions, condTypeVerified, fmt.Errorf("verification job %s failed", job.Name))
+		return nil
+	}
+	if resultErr != nil || result.Verification == nil {
+		log.Warnf("failed to get the result of job %s/%s: %v", job.Namespace, job.Name, resultErr)
+		return nil
+	}
+
+	verification.Files = int32(result.Verification.Files)
+	verification.DriftedFilesCount = int32(result.Verification.DriftedCount)
+	verification.DriftedFiles = lo.Map(result.Verification.Drifted, func(item jobresult.DriftedFile, _ int) datasetv1alpha1.DriftedFile {
+		return datasetv1alpha1.DriftedFile{Path: item.Path, Reason: item.Reason}
+	})
+	var driftErr error
+	if verification.DriftedFilesCount > 0 {
+		driftErr = fmt.Errorf("%d files drifted from the manifest of round %d", verification.DriftedFilesCount, verification.Round)
+	}
+	ds.Status.Conditions = kubeutils.SetCondition(ds.Status.Conditions, condTypeVerified, driftErr)
+
+	return nil
+}
@@ -0,0 +1,160 @@
+package dataset
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	datasetv1alpha1 "github.com/BaizeAI/dataset/api/dataset/v1alpha1"
+	"github.com/BaizeAI/dataset/config"
+	"github.com/BaizeAI/dataset/pkg/kubeutils"
+)
+
+func TestValidateVerification(t *testing.T) {
+	for _, testCase := range []struct {
+		name     string
+		source   datasetv1alpha1.DatasetType
+		schedule *metav1.Duration
+		wantErr  string
+	}{
+		{name: "not verified", source: datasetv1alpha1.DatasetTypePVC},
+		{name: "daily", source: datasetv1alpha1.DatasetTypeS3, schedule: &metav1.Duration{Duration: 24 * time.Hour}},
+		{name: "not loaded by jobs", source: datasetv1alpha1.DatasetTypeManual, schedule: &metav1.Duration{Duration: time.Hour}, wantErr: "verifySchedule is not supported by datasets of type MANUAL"},
+		{name: "too frequent", source: datasetv1alpha1.DatasetTypeS3, schedule: &metav1.Duration{Duration: time.Second}, wantErr: "verifySchedule should be at least 1m, got: 1s"},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			ds := &datasetv1alpha1.Dataset{
+				Spec: datasetv1alpha1.DatasetSpec{
+					Source:         datasetv1alpha1.DatasetSource{Type: testCase.source},
+					VerifySchedule: testCase.schedule,
+				},
+			}
+
+			err := validateVerification(ds)
+			if testCase.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, testCase.wantErr)
+			}
+		})
+	}
+}
+
+func TestDatasetReconciler_reconcileVerification(t *testing.T) {
+	require.NoError(t, config.ParseConfigFromFileContent(""))
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, datasetv1alpha1.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+	require.NoError(t, batchv1.AddToScheme(scheme))
+
+	ds := &datasetv1alpha1.Dataset{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "models",
+			Namespace: "default",
+		},
+		Spec: datasetv1alpha1.DatasetSpec{
+			Source: datasetv1alpha1.DatasetSource{
+				Type: datasetv1alpha1.DatasetTypeHuggingFace,
+				URI:  "huggingface://ns/model",
+			},
+			MountOptions:   datasetv1alpha1.MountOptions{Path: "/"},
+			DataSyncRound:  1,
+			VerifySchedule: &metav1.Duration{Duration: time.Hour},
+		},
+		Status: datasetv1alpha1.DatasetStatus{
+			PVCName: "models",
+		},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&batchv1.Job{}).Build()
+	reconciler := &DatasetReconciler{Client: fakeClient, Scheme: scheme}
+	ctx := context.Background()
+
+	// the manifest is recorded once the data is loaded
+	require.NoError(t, reconciler.reconcileJob(ctx, ds))
+	job := &batchv1.Job{}
+	require.NoError(t, fakeClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: genJobName(ds.Name, 1)}, job))
+	podSpec := job.Spec.Template.Spec
+	require.Len(t, podSpec.InitContainers, 1)
+	require.Len(t, podSpec.Containers, 1)
+	assert.Equal(t, "dataset-loader", podSpec.InitContainers[0].Name)
+	assert.Equal(t, "dataset-manifest", podSpec.Containers[0].Name)
+	assert.Equal(t, []string{"verify", "--record", "--mount-path=/", "--mount-root=/baize/dataset/data"}, podSpec.Containers[0].Args)
+
+	// the data loaded an hour ago is verified
+	ds.Status.InProcessing = false
+	ds.Status.LastSucceedRound = 1
+	ds.Status.LastSyncTime = metav1.Time{Time: time.Now().Add(-time.Hour)}
+	require.NoError(t, reconciler.reconcileVerification(ctx, ds))
+	require.NotNil(t, ds.Status.Verification)
+	jobName := ds.Status.Verification.JobName
+	require.NotEmpty(t, jobName)
+	assert.Equal(t, int32(1), ds.Status.Verification.Round)
+
+	job = &batchv1.Job{}
+	require.NoError(t, fakeClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: jobName}, job))
+	container := job.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "dataset-verifier", container.Name)
+	assert.Equal(t, []string{"verify", "--fail-on-drift=false", "--mount-path=/", "--mount-root=/baize/dataset/data"}, container.Args)
+	assert.Contains(t, container.VolumeMounts, corev1.VolumeMount{Name: "dataset-pvc", MountPath: "/baize/dataset/data"})
+
+	// the verification is running
+	require.NoError(t, reconciler.reconcileVerification(ctx, ds))
+	assert.Equal(t, jobName, ds.Status.Verification.JobName)
+	assert.Equal(t, 30*time.Second, verifyRequeueAfter(ds))
+
+	job.Status.Succeeded = 1
+	require.NoError(t, fakeClient.Status().Update(ctx, job))
+	require.NoError(t, fakeClient.Create(ctx, &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      jobName + "-abcde",
+			Namespace: "default",
+			Labels:    map[string]string{batchv1.JobNameLabel: jobName},
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name: "dataset-verifier",
+				State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+					Message: `{"verification":{"files":3,"driftedCount":2,"drifted":[` +
+						`{"path":"config.json","reason":"MODIFIED"},{"path":"tokenizer.json","reason":"MISSING"}]}}`,
+				}},
+			}},
+		},
+	}))
+
+	require.NoError(t, reconciler.reconcileVerification(ctx, ds))
+	assert.Empty(t, ds.Status.Verification.JobName)
+	assert.Equal(t, int32(3), ds.Status.Verification.Files)
+	assert.Equal(t, int32(2), ds.Status.Verification.DriftedFilesCount)
+	assert.Equal(t, []datasetv1alpha1.DriftedFile{
+		{Path: "config.json", Reason: "MODIFIED"},
+		{Path: "tokenizer.json", Reason: "MISSING"},
+	}, ds.Status.Verification.DriftedFiles)
+	assert.False(t, kubeutils.IsConditionReady(ds.Status.Conditions, condTypeVerified))
+	assert.Equal(t, "2 files drifted from the manifest of round 1", meta.FindStatusCondition(ds.Status.Conditions, condTypeVerified).Message)
+	assert.Error(t, fakeClient.Get(ctx, client.ObjectKeyFromObject(job), &batchv1.Job{}))
+
+	// the next verification is an hour later
+	require.NoError(t, reconciler.reconcileVerification(ctx, ds))
+	assert.Empty(t, ds.Status.Verification.JobName)
+	assert.InDelta(t, time.Hour, verifyRequeueAfter(ds), float64(time.Minute))
+
+	// the data loaded again is verified against its new manifest
+	ds.Spec.DataSyncRound = 2
+	ds.Status.LastSucceedRound = 2
+	ds.Status.LastSyncTime = metav1.Now()
+	require.NoError(t, reconciler.reconcileVerification(ctx, ds))
+	assert.Nil(t, ds.Status.Verification)
+	assert.Nil(t, meta.FindStatusCondition(ds.Status.Conditions, condTypeVerified))
+}
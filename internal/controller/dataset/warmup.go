@@ -0,0 +1,414 @@
+package dataset
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"path"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/samber/lo"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	datasetv1alpha1 "github.com/BaizeAI/dataset/api/dataset/v1alpha1"
+	"github.com/BaizeAI/dataset/config"
+	"github.com/BaizeAI/dataset/internal/pkg/constants"
+	"github.com/BaizeAI/dataset/pkg/kubeutils"
+	"github.com/BaizeAI/dataset/pkg/log"
+)
+
+const (
+	condTypeWarmedUp = "WarmedUp"
+
+	// warmupContainerName is the name of the container of the jobs copying
+	// the dataset onto a node.
+	warmupContainerName = "dataset-warmup"
+	// warmupMountPath is where the local storage of the node is mounted in
+	// the pods copying the dataset.
+	warmupMountPath = "/baize/dataset/warmup"
+)
+
+//+kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
+
+// nodeHash shortens the name of a node for the names of the objects of the
+// node, which are limited to 63 characters.
+func nodeHash(nodeName string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(nodeName))
+
+	return fmt.Sprintf("%08x", h.Sum32())
+}
+
+func genWarmupJobName(dsName string, round int32, nodeName string) string {
+	return fmt.Sprintf("dataset-%s-warmup-%d-%s", dsName, round, nodeHash(nodeName))
+}
+
+func warmupClaimName(dsName string, nodeName string) string {
+	return fmt.Sprintf("dataset-%s-warmup-%s", dsName, nodeHash(nodeName))
+}
+
+// warmupPath is the directory on the nodes the dataset is copied into when
+// warmup.hostPath is set.
+func warmupPath(ds *datasetv1alpha1.Dataset) string {
+	return path.Join(config.GetWarmupHostPath(), ds.Namespace, ds.Name)
+}
+
+// warmupClaimSize is the size of the local pvc of each node, defaulting to
+// the size of the pvc of the dataset.
+func warmupClaimSize(ds *datasetv1alpha1.Dataset) (resource.Quantity, bool) {
+	if ds.Spec.Warmup.Size != nil {
+		return *ds.Spec.Warmup.Size, true
+	}
+	size, ok := ds.Spec.VolumeClaimTemplate.Spec.Resources.Requests[corev1.ResourceStorage]
+
+	return size, ok
+}
+
+func validateWarmup(ds *datasetv1alpha1.Dataset) error {
+	warmup := ds.Spec.Warmup
+	if warmup == nil {
+		return nil
+	}
+	if !supportPreload(ds) {
+		return fmt.Errorf("warmup is not supported by datasets of type %s", ds.Spec.Source.Type)
+	}
+	if warmup.HostPath == (warmup.StorageClassName != nil) {
+		return fmt.Errorf("exactly one of warmup.hostPath and warmup.storageClassName must be set")
+	}
+	if warmup.HostPath && config.GetWarmupHostPath() == "" {
+		return fmt.Errorf("warmup.hostPath is not allowed, warmup_host_path is not set in the controller config")
+	}
+	if warmup.StorageClassName != nil {
+		if _, ok := warmupClaimSize(ds); !ok {
+			return fmt.Errorf("warmup.size is required when the volumeClaimTemplate of the dataset requests no storage")
+		}
+	}
+
+	return nil
+}
+
+// warmupRequeueAfter returns when the dataset should be reconciled again for
+// the copies onto the nodes, zero when none is running.
+func warmupRequeueAfter(ds *datasetv1alpha1.Dataset) time.Duration {
+	if lo.ContainsBy(ds.Status.Warmup, func(item datasetv1alpha1.WarmupNodeStatus) bool {
+		return item.JobName != ""
+	}) {
+		return time.Second * 30
+	}
+
+	return 0
+}
+
+// reconcileWarmup copies each data sync round of the dataset onto the nodes
+// selected by warmup with a job per node, like a DaemonSet running once per
+// round, and reports the copies in the WarmedUp condition.
+func (r *DatasetReconciler) reconcileWarmup(ctx context.Context, ds *datasetv1alpha1.Dataset) error {
+	if kubeutils.IsDeleted(ds) {
+		return nil
+	}
+	if ds.Spec.Warmup == nil {
+		for _, status := range ds.Status.Warmup {
+			if err := r.cleanupWarmupNode(ctx, ds, status); err != nil {
+				return err
+			}
+		}
+		ds.Status.Warmup = nil
+		meta.RemoveStatusCondition(&ds.Status.Conditions, condTypeWarmedUp)
+		return nil
+	}
+	if ds.Status.LastSucceedRound == 0 {
+		return nil
+	}
+
+	nodes := &corev1.NodeList{}
+	if err := r.List(ctx, nodes, client.MatchingLabels(ds.Spec.Warmup.NodeSelector)); err != nil {
+		return err
+	}
+	nodeNames := lo.FilterMap(nodes.Items, func(node corev1.Node, _ int) (string, bool) {
+		return node.Name, node.DeletionTimestamp == nil
+	})
+	slices.Sort(nodeNames)
+
+	statuses := make([]datasetv1alpha1.WarmupNodeStatus, 0, len(nodeNames))
+	for _, nodeName := range nodeNames {
+		status, ok := lo.Find(ds.Status.Warmup, func(item datasetv1alpha1.WarmupNodeStatus) bool {
+			return item.NodeName == nodeName
+		})
+		if !ok {
+			status = datasetv1alpha1.WarmupNodeStatus{NodeName: nodeName}
+		}
+		if err := r.reconcileWarmupNode(ctx, ds, &status); err != nil {
+			return err
+		}
+		statuses = append(statuses, status)
+	}
+	for _, status := range ds.Status.Warmup {
+		if !lo.Contains(nodeNames, status.NodeName) {
+			// the node is not selected anymore
+			if err := r.cleanupWarmupNode(ctx, ds, status); err != nil {
+				return err
+			}
+		}
+	}
+	ds.Status.Warmup = statuses
+
+	var warmupErr error
+	ready := lo.CountBy(statuses, func(item datasetv1alpha1.WarmupNodeStatus) bool {
+		return item.Round == ds.Status.LastSucceedRound && item.Phase == datasetv1alpha1.WarmupPhaseReady
+	})
+	failed := lo.FilterMap(statuses, func(item datasetv1alpha1.WarmupNodeStatus, _ int) (string, bool) {
+		return item.NodeName, item.Phase == datasetv1alpha1.WarmupPhaseFailed
+	})
+	switch {
+	case len(statuses) == 0:
+		warmupErr = fmt.Errorf("no nodes match the nodeSelector of warmup")
+	case len(failed) > 0:
+		warmupErr = fmt.Errorf("failed to copy round %d onto nodes %v", ds.Status.LastSucceedRound, failed)
+	case ready < len(statuses):
+		warmupErr = fmt.Errorf("round %d is copied onto %d of %d nodes", ds.Status.LastSucceedRound, ready, len(statuses))
+	}
+	ds.Status.Conditions = kubeutils.SetCondition(ds.Status.Conditions, condTypeWarmedUp, warmupErr)
+
+	return nil
+}
+
+// reconcileWarmupNode reports the outcome of the job copying the dataset onto
+// the node, or runs one when the last succeeded round is not copied yet. A
+// failed copy is not retried before the next round, the job retries by its
+// backoffLimit.
+func (r *DatasetReconciler) reconcileWarmupNode(ctx context.Context, ds *datasetv1alpha1.Dataset, status *datasetv1alpha1.WarmupNodeStatus) error {
+	if status.JobName != "" {
+		job := &batchv1.Job{}
+		err := r.Get(ctx, client.ObjectKey{Namespace: ds.Namespace, Name: status.JobName}, job)
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+		if err == nil {
+			if err := r.reconcileWarmupJob(ctx, job, status); err != nil || status.JobName != "" {
+				return err
+			}
+		} else {
+			// the job is gone before it was reported, it is run again
+			status.JobName = ""
+			status.Phase = ""
+		}
+	}
+
+	if ds.Status.InProcessing || (status.Round == ds.Status.LastSucceedRound && status.Phase != "") {
+		return nil
+	}
+
+	jobSpec, err := r.newWarmupJobSpec(ctx, ds, status)
+	if err != nil {
+		return err
+	}
+	jobName := genWarmupJobName(ds.Name, ds.Status.LastSucceedRound, status.NodeName)
+	if err := r.createJob(ctx, ds, jobName, jobSpec); err != nil {
+		return err
+	}
+	status.JobName = jobName
+	status.Round = ds.Status.LastSucceedRound
+	status.Phase = datasetv1alpha1.WarmupPhaseRunning
+	status.Message = ""
+	status.LastTransitionTime = metav1.Now()
+
+	return nil
+}
+
+// reconcileWarmupJob reports the outcome of the job once it finished and
+// deletes it.
+func (r *DatasetReconciler) reconcileWarmupJob(ctx context.Context, job *batchv1.Job, status *datasetv1alpha1.WarmupNodeStatus) error {
+	failed := lo.ContainsBy(job.Status.Conditions, func(item batchv1.JobCondition) bool {
+		return item.Type == batchv1.JobFailed && item.Status == corev1.ConditionTrue
+	})
+	if job.Status.Succeeded == 0 && !failed {
+		return nil
+	}
+
+	if failed {
+		status.Phase = datasetv1alpha1.WarmupPhaseFailed
+		status.Message = fmt.Sprintf("warmup job %s failed", job.Name)
+	} else {
+		status.Phase = datasetv1alpha1.WarmupPhaseReady
+		status.Message = ""
+		results, err := getJobResults(ctx, r.Client, job)
+		if result := results[warmupContainerName]; err == nil && result.Warmup != nil {
+			status.CopiedFiles = result.Warmup.CopiedFiles
+			status.CopiedBytes = result.Warmup.CopiedBytes
+		} else {
+			// the data is copied, only the numbers are not reported
+			log.Warnf("failed to get the result of job %s/%s: %v", job.Namespace, job.Name, err)
+		}
+	}
+	status.LastTransitionTime = metav1.Now()
+
+	err := r.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground))
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+	status.JobName = ""
+
+	return nil
+}
+
+// cleanupWarmupNode deletes the running job and the local pvc of a node the
+// dataset is not copied onto anymore, the directories of warmup.hostPath are
+// left on the node.
+func (r *DatasetReconciler) cleanupWarmupNode(ctx context.Context, ds *datasetv1alpha1.Dataset, status datasetv1alpha1.WarmupNodeStatus) error {
+	if status.JobName != "" {
+		job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: ds.Namespace, Name: status.JobName}}
+		err := r.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground))
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+	}
+	if status.ClaimName != "" {
+		pvc := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Namespace: ds.Namespace, Name: status.ClaimName}}
+		if err := r.Delete(ctx, pvc); err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// warmupVolume returns the local storage of the node the dataset is copied
+// into, creating the local pvc of the node if needed.
+func (r *DatasetReconciler) warmupVolume(ctx context.Context, ds *datasetv1alpha1.Dataset, status *datasetv1alpha1.WarmupNodeStatus) (corev1.VolumeSource, error) {
+	if ds.Spec.Warmup.HostPath {
+		status.Path = warmupPath(ds)
+		return corev1.VolumeSource{
+			HostPath: &corev1.HostPathVolumeSource{
+				Path: status.Path,
+				Type: lo.ToPtr(corev1.HostPathDirectoryOrCreate),
+			},
+		}, nil
+	}
+
+	size, _ := warmupClaimSize(ds)
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      warmupClaimName(ds.Name, status.NodeName),
+			Namespace: ds.Namespace,
+			Labels: map[string]string{
+				constants.DatasetNameLabel: ds.Name,
+			},
+			OwnerReferences: datasetOwnerRef(ds),
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			StorageClassName: ds.Spec.Warmup.StorageClassName,
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: size},
+			},
+		},
+	}
+	// the pvc is bound to the node by the first pod using it
+	if err := r.Create(ctx, pvc); err != nil && !k8serrors.IsAlreadyExists(err) {
+		return corev1.VolumeSource{}, err
+	}
+	status.ClaimName = pvc.Name
+
+	return corev1.VolumeSource{
+		PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+			ClaimName: pvc.Name,
+		},
+	}, nil
+}
+
+func (r *DatasetReconciler) newWarmupJobSpec(ctx context.Context, ds *datasetv1alpha1.Dataset, status *datasetv1alpha1.WarmupNodeStatus) (batchv1.JobSpec, error) {
+	jobSpec, err := loaderJobSpec(ds.Spec.PodOverrides)
+	if err != nil {
+		return jobSpec, err
+	}
+
+	warmupVolumeSource, err := r.warmupVolume(ctx, ds, status)
+	if err != nil {
+		return jobSpec, err
+	}
+
+	podSpec := &jobSpec.Template.Spec
+	// pins the pod to the node like the pods of a DaemonSet
+	podSpec.Affinity = &corev1.Affinity{
+		NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+					MatchFields: []corev1.NodeSelectorRequirement{{
+						Key:      metav1.ObjectNameField,
+						Operator: corev1.NodeSelectorOpIn,
+						Values:   []string{status.NodeName},
+					}},
+				}},
+			},
+		},
+	}
+	podSpec.Tolerations = append(podSpec.Tolerations, ds.Spec.Warmup.Tolerations...)
+	podSpec.Tolerations = append(podSpec.Tolerations, kubeutils.GetTolerationWithSeconds(ds.Spec.Warmup.TolerationSeconds)...)
+
+	pvcVolume, pvcVolumeMount := datasetPVCVolume(ds)
+	pvcVolumeMount.ReadOnly = true
+	podSpec.Volumes = append(podSpec.Volumes, pvcVolume, corev1.Volume{
+		Name:         "dataset-warmup",
+		VolumeSource: warmupVolumeSource,
+	})
+
+	container := &podSpec.Containers[0]
+	container.Name = warmupContainerName
+	if !reflect.DeepEqual(ds.Spec.DataWarmUpResources, corev1.ResourceRequirements{}) {
+		container.Resources = *ds.Spec.DataWarmUpResources.DeepCopy()
+	}
+	container.VolumeMounts = append(container.VolumeMounts, pvcVolumeMount, corev1.VolumeMount{
+		Name:      "dataset-warmup",
+		MountPath: warmupMountPath,
+	})
+	args := []string{
+		"warmup",
+		fmt.Sprintf("--mount-path=%s", lo.CoalesceOrEmpty(ds.Spec.MountOptions.Path, "/")),
+		fmt.Sprintf("--mount-root=%s", datasetPVCMountPath),
+		fmt.Sprintf("--target=%s", warmupMountPath),
+		fmt.Sprintf("--uid=%d", ds.Spec.MountOptions.UID),
+		fmt.Sprintf("--gid=%d", ds.Spec.MountOptions.GID),
+	}
+	if container.TerminationMessagePath != "" {
+		args = append(args, fmt.Sprintf("--termination-message-path=%s", container.TerminationMessagePath))
+	}
+	container.Args = args
+
+	return jobSpec, nil
+}
+
+// requestsOfWarmupNode enqueues the datasets warmed up onto the node, so that
+// the nodes joining the selection get a copy.
+func (r *DatasetReconciler) requestsOfWarmupNode(ctx context.Context, obj client.Object) []reconcile.Request {
+	datasets := &datasetv1alpha1.DatasetList{}
+	if err := r.List(ctx, datasets); err != nil {
+		log.Errorf("failed to list datasets for node %s: %v", obj.GetName(), err)
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, ds := range datasets.Items {
+		if ds.Spec.Warmup == nil {
+			continue
+		}
+		selected := labels.SelectorFromSet(ds.Spec.Warmup.NodeSelector).Matches(labels.Set(obj.GetLabels()))
+		warmedUp := lo.ContainsBy(ds.Status.Warmup, func(item datasetv1alpha1.WarmupNodeStatus) bool {
+			return item.NodeName == obj.GetName()
+		})
+		if selected || warmedUp {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&ds)})
+		}
+	}
+
+	return requests
+}
@@ -0,0 +1,260 @@
+package dataset
+
+import (
+	"context"
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	datasetv1alpha1 "github.com/BaizeAI/dataset/api/dataset/v1alpha1"
+	"github.com/BaizeAI/dataset/config"
+	"github.com/BaizeAI/dataset/pkg/kubeutils"
+)
+
+func TestValidateWarmup(t *testing.T) {
+	t.Cleanup(func() { _ = config.ParseConfigFromFileContent("") })
+
+	for _, testCase := range []struct {
+		name    string
+		config  string
+		ds      datasetv1alpha1.DatasetSpec
+		wantErr string
+	}{
+		{
+			name: "host path",
+			ds: datasetv1alpha1.DatasetSpec{
+				Source: datasetv1alpha1.DatasetSource{Type: datasetv1alpha1.DatasetTypeS3},
+				Warmup: &datasetv1alpha1.DatasetWarmup{HostPath: true},
+			},
+		},
+		{
+			name:   "host path not allowed",
+			config: `warmup_host_path: ""`,
+			ds: datasetv1alpha1.DatasetSpec{
+				Source: datasetv1alpha1.DatasetSource{Type: datasetv1alpha1.DatasetTypeS3},
+				Warmup: &datasetv1alpha1.DatasetWarmup{HostPath: true},
+			},
+			wantErr: "warmup.hostPath is not allowed, warmup_host_path is not set in the controller config",
+		},
+		{
+			name: "storage class with the size of the dataset",
+			ds: datasetv1alpha1.DatasetSpec{
+				Source: datasetv1alpha1.DatasetSource{Type: datasetv1alpha1.DatasetTypeS3},
+				VolumeClaimTemplate: corev1.PersistentVolumeClaim{Spec: corev1.PersistentVolumeClaimSpec{
+					Resources: corev1.VolumeResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("10Gi")}},
+				}},
+				Warmup: &datasetv1alpha1.DatasetWarmup{StorageClassName: lo.ToPtr("local-path")},
+			},
+		},
+		{
+			name: "storage class without size",
+			ds: datasetv1alpha1.DatasetSpec{
+				Source: datasetv1alpha1.DatasetSource{Type: datasetv1alpha1.DatasetTypeS3},
+				Warmup: &datasetv1alpha1.DatasetWarmup{StorageClassName: lo.ToPtr("local-path")},
+			},
+			wantErr: "warmup.size is required when the volumeClaimTemplate of the dataset requests no storage",
+		},
+		{
+			name: "both host path and storage class",
+			ds: datasetv1alpha1.DatasetSpec{
+				Source: datasetv1alpha1.DatasetSource{Type: datasetv1alpha1.DatasetTypeS3},
+				Warmup: &datasetv1alpha1.DatasetWarmup{HostPath: true, StorageClassName: lo.ToPtr("local-path")},
+			},
+			wantErr: "exactly one of warmup.hostPath and warmup.storageClassName must be set",
+		},
+		{
+			name: "not loaded",
+			ds: datasetv1alpha1.DatasetSpec{
+				Source: datasetv1alpha1.DatasetSource{Type: datasetv1alpha1.DatasetTypePVC},
+				Warmup: &datasetv1alpha1.DatasetWarmup{HostPath: true},
+			},
+			wantErr: "warmup is not supported by datasets of type PVC",
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			require.NoError(t, config.ParseConfigFromFileContent(lo.CoalesceOrEmpty(testCase.config, "warmup_host_path: /var/lib/datasets")))
+			err := validateWarmup(&datasetv1alpha1.Dataset{Spec: testCase.ds})
+			if testCase.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, testCase.wantErr)
+			}
+		})
+	}
+}
+
+func finishWarmupJob(t *testing.T, c client.Client, job *batchv1.Job, succeed bool, message string) {
+	require.NoError(t, c.Create(context.Background(), &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      job.Name + "-abcde",
+			Namespace: job.Namespace,
+			Labels:    map[string]string{batchv1.JobNameLabel: job.Name},
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name: warmupContainerName,
+				State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+					Message: message,
+				}},
+			}},
+		},
+	}))
+	if succeed {
+		job.Status.Succeeded = 1
+	} else {
+		job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}}
+	}
+	require.NoError(t, c.Status().Update(context.Background(), job))
+}
+
+func TestDatasetReconciler_reconcileWarmup(t *testing.T) {
+	require.NoError(t, config.ParseConfigFromFileContent("warmup_host_path: /var/lib/datasets"))
+	t.Cleanup(func() { _ = config.ParseConfigFromFileContent("") })
+
+	node := func(name string, gpu bool) *corev1.Node {
+		n := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		if gpu {
+			n.Labels = map[string]string{"gpu": "true"}
+		}
+		return n
+	}
+	ds := &datasetv1alpha1.Dataset{
+		ObjectMeta: metav1.ObjectMeta{Name: "models", Namespace: "default", UID: "uid"},
+		Spec: datasetv1alpha1.DatasetSpec{
+			Source:        datasetv1alpha1.DatasetSource{Type: datasetv1alpha1.DatasetTypeHuggingFace, URI: "huggingface://ns/model"},
+			MountOptions:  datasetv1alpha1.MountOptions{Path: "/models", UID: 1000, GID: 1000},
+			DataSyncRound: 1,
+			Warmup: &datasetv1alpha1.DatasetWarmup{
+				NodeSelector:      map[string]string{"gpu": "true"},
+				Tolerations:       []corev1.Toleration{{Key: "gpu", Operator: corev1.TolerationOpExists}},
+				TolerationSeconds: lo.ToPtr(int64(60)),
+				HostPath:          true,
+			},
+			DataWarmUpResources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+			},
+		},
+		Status: datasetv1alpha1.DatasetStatus{
+			PVCName:          "dataset-models",
+			LastSucceedRound: 1,
+		},
+	}
+	c := newReplicaTestClient(t, node("gpu-1", true), node("gpu-2", true), node("cpu-1", false))
+	r := &DatasetReconciler{Client: c, Scheme: c.Scheme()}
+	ctx := context.Background()
+
+	getJob := func(nodeName string) *batchv1.Job {
+		status, ok := lo.Find(ds.Status.Warmup, func(item datasetv1alpha1.WarmupNodeStatus) bool {
+			return item.NodeName == nodeName
+		})
+		require.True(t, ok)
+		job := &batchv1.Job{}
+		require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: ds.Namespace, Name: status.JobName}, job))
+		return job
+	}
+
+	// a job copies the round onto each selected node
+	require.NoError(t, r.reconcileWarmup(ctx, ds))
+	require.Len(t, ds.Status.Warmup, 2)
+	assert.Equal(t, "gpu-1", ds.Status.Warmup[0].NodeName)
+	assert.Equal(t, datasetv1alpha1.WarmupPhaseRunning, ds.Status.Warmup[0].Phase)
+	assert.Equal(t, "/var/lib/datasets/default/models", ds.Status.Warmup[0].Path)
+	assert.False(t, kubeutils.IsConditionReady(ds.Status.Conditions, condTypeWarmedUp))
+	assert.Positive(t, warmupRequeueAfter(ds))
+
+	job := getJob("gpu-1")
+	podSpec := job.Spec.Template.Spec
+	assert.Equal(t, []string{"gpu-1"}, podSpec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchFields[0].Values)
+	assert.Len(t, podSpec.Tolerations, 3)
+	assert.Equal(t, "/var/lib/datasets/default/models", podSpec.Volumes[1].HostPath.Path)
+	container := podSpec.Containers[0]
+	assert.Equal(t, []string{
+		"warmup",
+		"--mount-path=/models",
+		"--mount-root=/baize/dataset/data",
+		"--target=/baize/dataset/warmup",
+		"--uid=1000",
+		"--gid=1000",
+	}, container.Args)
+	assert.Equal(t, "1", container.Resources.Requests.Cpu().String())
+	assert.Contains(t, container.VolumeMounts, corev1.VolumeMount{Name: "dataset-pvc", MountPath: "/baize/dataset/data", ReadOnly: true})
+
+	// the outcome of each node is reported
+	finishWarmupJob(t, c, job, true, `{"warmup":{"files":3,"copiedFiles":2,"copiedBytes":100}}`)
+	finishWarmupJob(t, c, getJob("gpu-2"), false, "")
+	require.NoError(t, r.reconcileWarmup(ctx, ds))
+	assert.Equal(t, datasetv1alpha1.WarmupPhaseReady, ds.Status.Warmup[0].Phase)
+	assert.Equal(t, int64(2), ds.Status.Warmup[0].CopiedFiles)
+	assert.Equal(t, int64(100), ds.Status.Warmup[0].CopiedBytes)
+	assert.Empty(t, ds.Status.Warmup[0].JobName)
+	assert.Equal(t, datasetv1alpha1.WarmupPhaseFailed, ds.Status.Warmup[1].Phase)
+	assert.Empty(t, ds.Status.Warmup[1].JobName)
+	assert.Error(t, c.Get(ctx, client.ObjectKeyFromObject(job), &batchv1.Job{}))
+	assert.Zero(t, warmupRequeueAfter(ds))
+
+	// the failed node is not retried in the same round
+	require.NoError(t, r.reconcileWarmup(ctx, ds))
+	assert.Empty(t, ds.Status.Warmup[1].JobName)
+
+	// a node leaving the selection is dropped, the next round is copied onto the others
+	gpu2 := &corev1.Node{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "gpu-2"}, gpu2))
+	gpu2.Labels = nil
+	require.NoError(t, c.Update(ctx, gpu2))
+	ds.Status.LastSucceedRound = 2
+	require.NoError(t, r.reconcileWarmup(ctx, ds))
+	require.Len(t, ds.Status.Warmup, 1)
+	assert.Equal(t, int32(2), ds.Status.Warmup[0].Round)
+	assert.Equal(t, genWarmupJobName("models", 2, "gpu-1"), ds.Status.Warmup[0].JobName)
+
+	finishWarmupJob(t, c, getJob("gpu-1"), true, "")
+	require.NoError(t, r.reconcileWarmup(ctx, ds))
+	assert.True(t, kubeutils.IsConditionReady(ds.Status.Conditions, condTypeWarmedUp))
+
+	// the status is cleared once warmup is unset
+	ds.Spec.Warmup = nil
+	require.NoError(t, r.reconcileWarmup(ctx, ds))
+	assert.Empty(t, ds.Status.Warmup)
+	assert.Empty(t, lo.Filter(ds.Status.Conditions, func(item metav1.Condition, _ int) bool {
+		return item.Type == condTypeWarmedUp
+	}))
+}
+
+func TestDatasetReconciler_reconcileWarmupStorageClass(t *testing.T) {
+	require.NoError(t, config.ParseConfigFromFileContent(""))
+
+	ds := &datasetv1alpha1.Dataset{
+		ObjectMeta: metav1.ObjectMeta{Name: "models", Namespace: "default", UID: "uid"},
+		Spec: datasetv1alpha1.DatasetSpec{
+			Source:        datasetv1alpha1.DatasetSource{Type: datasetv1alpha1.DatasetTypeS3, URI: "s3://bucket/models"},
+			DataSyncRound: 1,
+			Warmup: &datasetv1alpha1.DatasetWarmup{
+				StorageClassName: lo.ToPtr("local-path"),
+				Size:             lo.ToPtr(resource.MustParse("20Gi")),
+			},
+		},
+		Status: datasetv1alpha1.DatasetStatus{PVCName: "dataset-models", LastSucceedRound: 1},
+	}
+	c := newReplicaTestClient(t, &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}})
+	r := &DatasetReconciler{Client: c, Scheme: c.Scheme()}
+	ctx := context.Background()
+
+	require.NoError(t, r.reconcileWarmup(ctx, ds))
+	require.Len(t, ds.Status.Warmup, 1)
+	claimName := warmupClaimName("models", "node-1")
+	assert.Equal(t, claimName, ds.Status.Warmup[0].ClaimName)
+	pvc := &corev1.PersistentVolumeClaim{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "default", Name: claimName}, pvc))
+	assert.Equal(t, "local-path", lo.FromPtr(pvc.Spec.StorageClassName))
+	assert.Equal(t, "20Gi", pvc.Spec.Resources.Requests.Storage().String())
+	job := &batchv1.Job{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "default", Name: ds.Status.Warmup[0].JobName}, job))
+	assert.Equal(t, claimName, job.Spec.Template.Spec.Volumes[1].PersistentVolumeClaim.ClaimName)
+}
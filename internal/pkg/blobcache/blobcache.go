@@ -0,0 +1,368 @@
+// Package blobcache is a content-addressed cache of the files loaded by the
+// data loader, shared by the datasets of a node or of a cluster through a
+// volume mounted into the data loader jobs.
+//
+// Files are keyed by the hash their data source reports before they are
+// downloaded, e.g. the SHA256 of a HuggingFace LFS file or the ETag and the
+// size of an S3 object, so that a sync restores the files it already has in
+// the cache instead of downloading them again.
+//
+//	<cache>/blobs/<xx>/<sha256 of the key>        the content of a blob
+//	<cache>/blobs/<xx>/<sha256 of the key>.used   last used time of the blob
+package blobcache
+
+import (
+	"crypto/md5" // #nosec G501
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/BaizeAI/dataset/pkg/log"
+)
+
+type LinkMode string
+
+const (
+	// LinkModeReflink clones the blobs on the file systems supporting it,
+	// e.g. XFS and Btrfs, and copies them otherwise.
+	LinkModeReflink LinkMode = "reflink"
+	// LinkModeHardlink hard links the blobs when the cache and the dataset
+	// are on the same file system, and copies them otherwise. The files share
+	// their mode and owner with the blob.
+	LinkModeHardlink LinkMode = "hardlink"
+	// LinkModeCopy copies the blobs.
+	LinkModeCopy LinkMode = "copy"
+)
+
+// ParseLinkMode parses the link mode, empty is LinkModeReflink.
+func ParseLinkMode(mode string) (LinkMode, error) {
+	switch LinkMode(mode) {
+	case "":
+		return LinkModeReflink, nil
+	case LinkModeReflink, LinkModeHardlink, LinkModeCopy:
+		return LinkMode(mode), nil
+	default:
+		return "", fmt.Errorf("unsupported blob cache link mode %q, must be one of: reflink, hardlink, copy", mode)
+	}
+}
+
+const (
+	blobsDir   = "blobs"
+	usedSuffix = ".used"
+	tmpPrefix  = ".tmp-"
+)
+
+// SHA256Key is the key of a file by the SHA256 of its content.
+func SHA256Key(sum string) string {
+	return "sha256:" + strings.ToLower(sum)
+}
+
+// MD5Key is the key of a file by the MD5 of its content.
+func MD5Key(sum string) string {
+	return "md5:" + strings.ToLower(sum)
+}
+
+// ETagKey is the key of an object of an object storage by its ETag and size,
+// the ETag of an object uploaded in multiple parts is not the hash of its
+// content but still identifies it along with the size. The content of such
+// blobs cannot be verified, so they are scoped, e.g. by the endpoint and the
+// bucket of the object, and only shared by the datasets of the same scope.
+func ETagKey(scope string, etag string, size int64) string {
+	return fmt.Sprintf("etag:%s:%s:%d", scope, strings.ToLower(strings.Trim(etag, `"`)), size)
+}
+
+// Blob is a file loaded by a sync.
+type Blob struct {
+	// Path is the path of the file relative to the directory it is loaded to.
+	Path string
+	// Key identifies the content of the file.
+	Key string
+	// Size is the size of the file, the blobs of another size are ignored.
+	// 0 is unknown, e.g. for the packages of a conda lock file.
+	Size int64
+}
+
+// Stats is what a cache did for a sync.
+type Stats struct {
+	// Hits is the number of files restored from the cache.
+	Hits int
+	// Misses is the number of files which were not in the cache.
+	Misses int
+	// HitBytes is the size of the files restored from the cache.
+	HitBytes int64
+	// StoredBytes is the size of the files stored into the cache.
+	StoredBytes int64
+	// EvictedBytes is the size of the blobs evicted from the cache.
+	EvictedBytes int64
+}
+
+// Cache is a blob cache in a directory.
+type Cache struct {
+	dir      string
+	maxSize  int64
+	linkMode LinkMode
+
+	stats Stats
+}
+
+// New returns the cache in the directory, holding up to maxSize bytes of
+// blobs once evicted. maxSize 0 is unlimited.
+func New(dir string, maxSize int64, linkMode LinkMode) (*Cache, error) {
+	if maxSize < 0 {
+		return nil, fmt.Errorf("invalid blob cache max size %d", maxSize)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, blobsDir), 0755); err != nil { // #nosec G301
+		return nil, err
+	}
+
+	return &Cache{dir: dir, maxSize: maxSize, linkMode: linkMode}, nil
+}
+
+// Stats returns what the cache did so far.
+func (c *Cache) Stats() Stats {
+	return c.stats
+}
+
+func (c *Cache) blobPath(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	name := hex.EncodeToString(sum[:])
+
+	return filepath.Join(c.dir, blobsDir, name[:2], name)
+}
+
+func (c *Cache) touch(blobPath string) {
+	usedPath := blobPath + usedSuffix
+	now := time.Now()
+	if err := os.Chtimes(usedPath, now, now); errors.Is(err, os.ErrNotExist) {
+		err = os.WriteFile(usedPath, nil, 0644) // #nosec G306
+		if err != nil {
+			log.Warnf("failed to record the use of blob %s: %v", blobPath, err)
+		}
+	}
+}
+
+// Restore links the blobs in the cache to their paths in dir, the files
+// which already exist are left as is. A blob which fails to be restored is a
+// miss, the sync downloads it.
+func (c *Cache) Restore(dir string, blobs []Blob) {
+	for _, blob := range blobs {
+		dst := filepath.Join(dir, blob.Path)
+		if _, err := os.Lstat(dst); err == nil {
+			continue
+		}
+
+		src := c.blobPath(blob.Key)
+		info, err := os.Stat(src)
+		if err != nil || (blob.Size > 0 && info.Size() != blob.Size) {
+			c.stats.Misses++
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil { // #nosec G301
+			log.Warnf("failed to restore %s from the blob cache: %v", blob.Path, err)
+			c.stats.Misses++
+			continue
+		}
+		if err := link(c.linkMode, src, dst); err != nil {
+			log.Warnf("failed to restore %s from the blob cache: %v", blob.Path, err)
+			c.stats.Misses++
+			continue
+		}
+		c.touch(src)
+		c.stats.Hits++
+		c.stats.HitBytes += info.Size()
+	}
+}
+
+// Store stores the files of the blobs loaded in dir into the cache, the
+// files of another size or hash than their blob are skipped.
+func (c *Cache) Store(dir string, blobs []Blob) {
+	for _, blob := range blobs {
+		src := filepath.Join(dir, blob.Path)
+		info, err := os.Lstat(src)
+		if err != nil || !info.Mode().IsRegular() || (blob.Size > 0 && info.Size() != blob.Size) {
+			continue
+		}
+
+		dst := c.blobPath(blob.Key)
+		if _, err := os.Stat(dst); err == nil {
+			c.touch(dst)
+			continue
+		}
+		// a file which does not match its key would be restored into the
+		// datasets of other namespaces
+		if err := verify(src, blob.Key); err != nil {
+			log.Warnf("skipped storing %s into the blob cache: %v", blob.Path, err)
+			continue
+		}
+		if err := c.put(src, dst); err != nil {
+			log.Warnf("failed to store %s into the blob cache: %v", blob.Path, err)
+			continue
+		}
+		c.touch(dst)
+		c.stats.StoredBytes += info.Size()
+	}
+}
+
+// verify checks the content of the file against the hash of the key, the
+// keys which are not hashes of the content are not checked.
+func verify(path string, key string) error {
+	var h hash.Hash
+	switch {
+	case strings.HasPrefix(key, "sha256:"):
+		h = sha256.New()
+	case strings.HasPrefix(key, "md5:"):
+		h = md5.New() // #nosec G401
+	default:
+		return nil
+	}
+
+	f, err := os.Open(path) // #nosec G304
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+	_, want, _ := strings.Cut(key, ":")
+	if got := hex.EncodeToString(h.Sum(nil)); got != want {
+		return fmt.Errorf("the hash %s of the content does not match the key %s", got, key)
+	}
+
+	return nil
+}
+
+// put links the file to the blob through a temporary file, the blobs are
+// complete once they exist.
+func (c *Cache) put(src string, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil { // #nosec G301
+		return err
+	}
+	// the jobs storing the same blob at the same time do not share the file
+	tmp := filepath.Join(filepath.Dir(dst), fmt.Sprintf("%s%s-%d-%d", tmpPrefix, filepath.Base(dst), os.Getpid(), time.Now().UnixNano()))
+	if err := link(c.linkMode, src, tmp); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	return nil
+}
+
+type cachedBlob struct {
+	path string
+	size int64
+	used time.Time
+}
+
+// Evict removes the least recently used blobs until the blobs fit in the max
+// size of the cache.
+func (c *Cache) Evict() error {
+	if c.maxSize == 0 {
+		return nil
+	}
+
+	var blobs []cachedBlob
+	var total int64
+	err := filepath.WalkDir(filepath.Join(c.dir, blobsDir), func(path string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) {
+			// removed by another job
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasSuffix(path, usedSuffix) || strings.HasPrefix(d.Name(), tmpPrefix) {
+			return nil
+		}
+		info, err := d.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		used := info.ModTime()
+		if usedInfo, err := os.Stat(path + usedSuffix); err == nil {
+			used = usedInfo.ModTime()
+		}
+		blobs = append(blobs, cachedBlob{path: path, size: info.Size(), used: used})
+		total += info.Size()
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	slices.SortFunc(blobs, func(a, b cachedBlob) int {
+		return a.used.Compare(b.used)
+	})
+	for _, blob := range blobs {
+		if total <= c.maxSize {
+			break
+		}
+		if err := os.Remove(blob.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		_ = os.Remove(blob.path + usedSuffix)
+		total -= blob.size
+		c.stats.EvictedBytes += blob.size
+	}
+
+	return nil
+}
+
+// link makes dst a file with the content of src by the link mode, keeping the
+// modification time of src so that the loaders comparing it with the data
+// source skip the file.
+func link(mode LinkMode, src string, dst string) error {
+	if mode == LinkModeHardlink {
+		if err := os.Link(src, dst); err == nil {
+			return nil
+		}
+	}
+
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if mode != LinkModeReflink || cloneFile(src, dst) != nil {
+		if err := copyFile(src, dst); err != nil {
+			return err
+		}
+	}
+
+	return os.Chtimes(dst, info.ModTime(), info.ModTime())
+}
+
+func copyFile(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644) // #nosec G302 G304
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		_ = os.Remove(dst)
+		return err
+	}
+
+	return out.Close()
+}
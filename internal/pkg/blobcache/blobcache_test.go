@@ -0,0 +1,130 @@
+package blobcache
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, path string, content string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func sha256Key(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return SHA256Key(hex.EncodeToString(sum[:]))
+}
+
+func TestParseLinkMode(t *testing.T) {
+	mode, err := ParseLinkMode("")
+	require.NoError(t, err)
+	assert.Equal(t, LinkModeReflink, mode)
+	mode, err = ParseLinkMode("hardlink")
+	require.NoError(t, err)
+	assert.Equal(t, LinkModeHardlink, mode)
+	_, err = ParseLinkMode("symlink")
+	assert.Error(t, err)
+}
+
+func TestKeys(t *testing.T) {
+	assert.Equal(t, "sha256:abcd", SHA256Key("ABCD"))
+	assert.Equal(t, "md5:abcd", MD5Key("abcd"))
+	assert.Equal(t, "etag:s3.example.com/bucket:abcd-2:10", ETagKey("s3.example.com/bucket", `"ABCD-2"`, 10))
+}
+
+func TestCache(t *testing.T) {
+	for _, mode := range []LinkMode{LinkModeReflink, LinkModeHardlink, LinkModeCopy} {
+		t.Run(string(mode), func(t *testing.T) {
+			cache, err := New(t.TempDir(), 0, mode)
+			require.NoError(t, err)
+
+			first := t.TempDir()
+			writeFile(t, filepath.Join(first, "model.safetensors"), "weights")
+			modTime := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+			require.NoError(t, os.Chtimes(filepath.Join(first, "model.safetensors"), modTime, modTime))
+			blobs := []Blob{
+				{Path: "model.safetensors", Key: sha256Key("weights"), Size: 7},
+				{Path: "missing.bin", Key: SHA256Key("bbb"), Size: 1},
+			}
+			cache.Store(first, blobs)
+			assert.Equal(t, int64(7), cache.Stats().StoredBytes)
+
+			// the same content under another name in another dataset
+			second := t.TempDir()
+			cache.Restore(second, []Blob{
+				{Path: "sub/renamed.safetensors", Key: sha256Key("weights"), Size: 7},
+				{Path: "other.bin", Key: SHA256Key("ccc"), Size: 3},
+				{Path: "resized.bin", Key: sha256Key("weights"), Size: 8},
+			})
+			stats := cache.Stats()
+			assert.Equal(t, 1, stats.Hits)
+			assert.Equal(t, 2, stats.Misses)
+			assert.Equal(t, int64(7), stats.HitBytes)
+
+			data, err := os.ReadFile(filepath.Join(second, "sub/renamed.safetensors"))
+			require.NoError(t, err)
+			assert.Equal(t, "weights", string(data))
+			info, err := os.Stat(filepath.Join(second, "sub/renamed.safetensors"))
+			require.NoError(t, err)
+			assert.True(t, info.ModTime().Equal(modTime))
+			assert.NoFileExists(t, filepath.Join(second, "other.bin"))
+			assert.NoFileExists(t, filepath.Join(second, "resized.bin"))
+
+			// the existing files are left as is
+			writeFile(t, filepath.Join(second, "existing.bin"), "local")
+			cache.Restore(second, []Blob{{Path: "existing.bin", Key: sha256Key("weights"), Size: 7}})
+			data, err = os.ReadFile(filepath.Join(second, "existing.bin"))
+			require.NoError(t, err)
+			assert.Equal(t, "local", string(data))
+			assert.Equal(t, 1, cache.Stats().Hits)
+		})
+	}
+}
+
+func TestCacheEvict(t *testing.T) {
+	cache, err := New(t.TempDir(), 10, LinkModeCopy)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "old"), "123456")
+	writeFile(t, filepath.Join(dir, "new"), "abcdef")
+	cache.Store(dir, []Blob{{Path: "old", Key: sha256Key("123456"), Size: 6}})
+	past := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(cache.blobPath(sha256Key("123456"))+usedSuffix, past, past))
+	cache.Store(dir, []Blob{{Path: "new", Key: sha256Key("abcdef"), Size: 6}})
+
+	require.NoError(t, cache.Evict())
+	assert.Equal(t, int64(6), cache.Stats().EvictedBytes)
+	assert.NoFileExists(t, cache.blobPath(sha256Key("123456")))
+	assert.FileExists(t, cache.blobPath(sha256Key("abcdef")))
+}
+
+func TestCacheStoreVerifiesContent(t *testing.T) {
+	cache, err := New(t.TempDir(), 0, LinkModeCopy)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "model.safetensors"), "poison")
+	writeFile(t, filepath.Join(dir, "package.conda"), "poison")
+	writeFile(t, filepath.Join(dir, "object.bin"), "poison")
+	md5Sum := md5.Sum([]byte("poison"))
+	cache.Store(dir, []Blob{
+		{Path: "model.safetensors", Key: sha256Key("weights"), Size: 6},
+		{Path: "package.conda", Key: MD5Key(hex.EncodeToString(md5Sum[:])), Size: 6},
+		{Path: "object.bin", Key: ETagKey("s3.example.com/bucket", "abcd-2", 6), Size: 6},
+	})
+
+	assert.NoFileExists(t, cache.blobPath(sha256Key("weights")))
+	assert.FileExists(t, cache.blobPath(MD5Key(hex.EncodeToString(md5Sum[:]))))
+	// the etags are not hashes of the content
+	assert.FileExists(t, cache.blobPath(ETagKey("s3.example.com/bucket", "abcd-2", 6)))
+	assert.Equal(t, int64(12), cache.Stats().StoredBytes)
+}
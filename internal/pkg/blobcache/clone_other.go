@@ -0,0 +1,10 @@
+//go:build !linux
+
+package blobcache
+
+import "errors"
+
+// cloneFile is only supported on Linux, the blobs are copied elsewhere.
+func cloneFile(_ string, _ string) error {
+	return errors.New("reflink is not supported")
+}
@@ -9,9 +9,30 @@ const (
 	DatasetJobCondaPipRequirementsTxtFilename   string = "requirements.txt"
 	DatasetJobCondaCondaEnvironmentYAMLPath     string = DatasetJobCondaConfigDir + "/" + DatasetJobCondaCondaEnvironmentYAMLFilename
 	DatasetJobCondaPipRequirementsTxtPath       string = DatasetJobCondaConfigDir + "/" + DatasetJobCondaPipRequirementsTxtFilename
+	DatasetJobCondaLockYAMLFilename             string = "conda-lock.yml"
+	DatasetJobCondaExplicitTxtFilename          string = "explicit.txt"
+	DatasetJobCondaLockYAMLPath                 string = DatasetJobCondaConfigDir + "/" + DatasetJobCondaLockYAMLFilename
+	DatasetJobCondaExplicitTxtPath              string = DatasetJobCondaConfigDir + "/" + DatasetJobCondaExplicitTxtFilename
+
+	DatasetJobPixiConfigDir        string = "/run/dataset/pixi"
+	DatasetJobPixiManifestFilename string = "pixi.toml"
+	DatasetJobPixiLockFilename     string = "pixi.lock"
+	DatasetJobPixiManifestPath     string = DatasetJobPixiConfigDir + "/" + DatasetJobPixiManifestFilename
+	DatasetJobPixiLockPath         string = DatasetJobPixiConfigDir + "/" + DatasetJobPixiLockFilename
+
+	DatasetJobPythonVenvConfigDir             string = "/run/dataset/venv"
+	DatasetJobPythonVenvPyprojectTomlFilename string = "pyproject.toml"
+	DatasetJobPythonVenvUvLockFilename        string = "uv.lock"
+	DatasetJobPythonVenvRequirementsTxtPath   string = DatasetJobPythonVenvConfigDir + "/" + DatasetJobCondaPipRequirementsTxtFilename
+	DatasetJobPythonVenvPyprojectTomlPath     string = DatasetJobPythonVenvConfigDir + "/" + DatasetJobPythonVenvPyprojectTomlFilename
+	DatasetJobPythonVenvUvLockPath            string = DatasetJobPythonVenvConfigDir + "/" + DatasetJobPythonVenvUvLockFilename
 
 	DatasetJobCondaMountDir = "/opt/baize-runtime-env"
 
+	// DatasetJobBlobCacheMountPath is the path to the directory where the
+	// blob cache shared by the dataset jobs is mounted.
+	DatasetJobBlobCacheMountPath string = "/run/dataset/blob-cache"
+
 	HamiVGPUTypeAnnotationName = "nvidia.com/use-gputype"
 )
 
@@ -24,4 +45,11 @@ const (
 	CondaEnvBaizeBaseBin string = CondaEnvBaizeBase + "/bin"
 
 	DatasetNameLabel = "baize.io/dataset-name"
+
+	// DatasetUploadReceiverLabel marks the helper pods receiving the uploads
+	// of MANUAL datasets.
+	DatasetUploadReceiverLabel = "baize.io/dataset-upload-receiver"
+	// DatasetUploadLastActivityAnnotation is the time of the last request
+	// proxied to a helper pod, which is deleted once it is idle.
+	DatasetUploadLastActivityAnnotation = "baize.io/dataset-upload-last-activity"
 )
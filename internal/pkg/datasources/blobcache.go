@@ -0,0 +1,78 @@
+package datasources
+
+import (
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/BaizeAI/dataset/internal/pkg/blobcache"
+)
+
+// syncDir returns the directory the loader loads toPath to.
+func syncDir(root string, toPath string) string {
+	if filepath.IsAbs(toPath) {
+		return toPath
+	}
+
+	return filepath.Join(root, toPath)
+}
+
+// fnmatch reports whether name matches the shell pattern like the fnmatch of
+// Python does, which the hub clients filter files with: '*' matches '/' too.
+func fnmatch(pattern string, name string) bool {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end == -1 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		return false
+	}
+
+	return re.MatchString(name)
+}
+
+// filterBlobs returns the blobs of the files downloaded with the include and
+// exclude patterns, so that no other file is restored from the cache.
+func filterBlobs(blobs []blobcache.Blob, include string, exclude string) []blobcache.Blob {
+	if include == "" && exclude == "" {
+		return blobs
+	}
+
+	filtered := make([]blobcache.Blob, 0, len(blobs))
+	for _, blob := range blobs {
+		p := path.Clean(blob.Path)
+		if include != "" && !fnmatch(include, p) {
+			continue
+		}
+		if exclude != "" && fnmatch(exclude, p) {
+			continue
+		}
+		filtered = append(filtered, blob)
+	}
+
+	return filtered
+}
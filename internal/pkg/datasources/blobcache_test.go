@@ -0,0 +1,43 @@
+package datasources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/BaizeAI/dataset/internal/pkg/blobcache"
+)
+
+func TestFnmatch(t *testing.T) {
+	assert.True(t, fnmatch("*.safetensors", "model.safetensors"))
+	assert.True(t, fnmatch("*.safetensors", "sub/model.safetensors"))
+	assert.True(t, fnmatch("model-0000?.bin", "model-00001.bin"))
+	assert.True(t, fnmatch("[!.]*", "model.bin"))
+	assert.False(t, fnmatch("[!.]*", ".gitattributes"))
+	assert.False(t, fnmatch("*.bin", "model.bin.json"))
+	assert.True(t, fnmatch("a+b(1).txt", "a+b(1).txt"))
+}
+
+func TestFilterBlobs(t *testing.T) {
+	blobs := []blobcache.Blob{
+		{Path: "model.safetensors"},
+		{Path: "onnx/model.onnx"},
+		{Path: "pytorch_model.bin"},
+	}
+	assert.Equal(t, blobs, filterBlobs(blobs, "", ""))
+	assert.Equal(t, []blobcache.Blob{{Path: "model.safetensors"}}, filterBlobs(blobs, "*.safetensors", ""))
+	assert.Equal(t, []blobcache.Blob{{Path: "model.safetensors"}, {Path: "pytorch_model.bin"}}, filterBlobs(blobs, "", "onnx/*"))
+}
+
+func TestExplicitBlobs(t *testing.T) {
+	explicit := `# platform: linux-64
+@EXPLICIT
+https://conda.anaconda.org/conda-forge/linux-64/python-3.12.1-hab00c5b_1_cpython.conda#a6c4bc2c0d4a4f8fa2b4d9d1e1f1a1b1
+https://conda.anaconda.org/conda-forge/noarch/pip-24.0-pyhd8ed1ab_0.conda#sha256:F0EE
+https://conda.anaconda.org/conda-forge/linux-64/unpinned-1.0-0.tar.bz2
+`
+	assert.Equal(t, []blobcache.Blob{
+		{Path: "python-3.12.1-hab00c5b_1_cpython.conda", Key: "md5:a6c4bc2c0d4a4f8fa2b4d9d1e1f1a1b1"},
+		{Path: "pip-24.0-pyhd8ed1ab_0.conda", Key: "sha256:f0ee"},
+	}, explicitBlobs(explicit))
+}
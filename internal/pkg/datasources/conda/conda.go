@@ -16,6 +16,10 @@ import (
 )
 
 type MambaCLI struct {
+	// Secrets are obscured in the logs of the commands, e.g. the tokens of
+	// the channels.
+	Secrets []string
+
 	envs map[string]string
 }
 
@@ -53,7 +57,7 @@ func (c *MambaCLI) Version(logger *logrus.Entry) (string, error) {
 	}
 
 	cmd := c.newCommand(args...)
-	output, err := utils.ExecuteCommandWithOutput(logger, cmd, []string{})
+	output, err := utils.ExecuteCommandWithOutput(logger, cmd, c.Secrets)
 	if err != nil {
 		return "", err
 	}
@@ -73,7 +77,7 @@ func (c *MambaCLI) Info(logger *logrus.Entry) (*CondaInfoOutputRaw, error) {
 	}
 
 	cmd := c.newCommand(args...)
-	output, err := utils.ExecuteCommandWithOutput(logger, cmd, []string{})
+	output, err := utils.ExecuteCommandWithOutput(logger, cmd, c.Secrets)
 	if err != nil {
 		return nil, err
 	}
@@ -99,7 +103,7 @@ func (c *MambaCLI) EnvList(logger *logrus.Entry) ([]CondaEnvListOutputEnv, error
 	}
 
 	cmd := c.newCommand(args...)
-	output, err := utils.ExecuteCommandWithOutput(logger, cmd, []string{})
+	output, err := utils.ExecuteCommandWithOutput(logger, cmd, c.Secrets)
 	if err != nil {
 		return make([]CondaEnvListOutputEnv, 0), err
 	}
@@ -127,6 +131,12 @@ func (c *MambaCLI) ConfigSetShowChannelURLs(logger *logrus.Entry) {
 	c.envs["show_channel_urls"] = "true"
 }
 
+// ConfigSetChannelPriority sets the channel priority, strict, flexible or disabled
+// Equivalent to `conda config --set channel_priority <priority>`
+func (c *MambaCLI) ConfigSetChannelPriority(logger *logrus.Entry, priority string) {
+	c.envs["channel_priority"] = priority
+}
+
 // ConfigPrependPkgsDir prepends the pkgs_dir to the conda config
 // Equivalent to `conda config --prepend pkgs_dirs <pkgsDir>`
 func (c *MambaCLI) ConfigPrependPkgsDir(logger *logrus.Entry, pkgsDir string) {
@@ -157,7 +167,7 @@ func (c *MambaCLI) CreateEnvFromFile(logger *logrus.Entry, file string) error {
 	}
 
 	cmd := c.newCommand(args...)
-	_, errBuffer, err := utils.ExecuteCommandWithAllOutput(logger, cmd, []string{})
+	_, errBuffer, err := utils.ExecuteCommandWithAllOutput(logger, cmd, c.Secrets)
 	if err != nil {
 		if c.IsPrefixAlreadyExistsError(errBuffer) {
 			return nil
@@ -179,10 +189,53 @@ func (c *MambaCLI) CleanAll(logger *logrus.Entry) error {
 	}
 
 	cmd := c.newCommand(args...)
-	err := utils.ExecuteCommand(logger, cmd, []string{})
+	err := utils.ExecuteCommand(logger, cmd, c.Secrets)
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// CreateEnvFromExplicitFile creates a new conda environment from an @EXPLICIT spec file, without solving
+// Equivalent to `conda create --prefix <prefix> --file <file> -y`
+func (c *MambaCLI) CreateEnvFromExplicitFile(logger *logrus.Entry, prefix string, file string) error {
+	args := []string{
+		"create",
+		"--prefix",
+		prefix,
+		"--file",
+		file,
+		"-y",
+	}
+
+	cmd := c.newCommand(args...)
+	return utils.ExecuteCommand(logger, cmd, c.Secrets)
+}
+
+// List returns the packages installed in a conda environment
+// Equivalent to `conda list --prefix <prefix> --json`
+func (c *MambaCLI) List(logger *logrus.Entry, prefix string) ([]CondaListOutputPackage, error) {
+	args := []string{
+		"list",
+		"--prefix",
+		prefix,
+		"--json",
+	}
+
+	cmd := c.newCommand(args...)
+	output, err := utils.ExecuteCommandWithOutput(logger, cmd, c.Secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	defer output.Reset()
+
+	var packages []CondaListOutputPackage
+	err = json.Unmarshal(output.Bytes(), &packages)
+	if err != nil {
+		return nil, err
+	}
+
+	return packages, nil
+}
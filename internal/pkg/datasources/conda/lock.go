@@ -0,0 +1,202 @@
+package conda
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const explicitHeader = "@EXPLICIT"
+
+var subdirRegexp = regexp.MustCompile(`^(noarch|(linux|osx|win|emscripten|wasi|zos)-\w+)$`)
+
+// LockFile is the unified lock file written by conda-lock, conda-lock.yml.
+//
+// About the format: https://conda.github.io/conda-lock/output/#unified-lockfile
+type LockFile struct {
+	Version  int `yaml:"version"`
+	Metadata struct {
+		Platforms []string `yaml:"platforms"`
+	} `yaml:"metadata"`
+	Package []LockedPackage `yaml:"package"`
+}
+
+type LockedPackage struct {
+	Name     string `yaml:"name"`
+	Version  string `yaml:"version"`
+	Manager  string `yaml:"manager"`
+	Platform string `yaml:"platform"`
+	URL      string `yaml:"url"`
+	Hash     struct {
+		MD5    string `yaml:"md5"`
+		SHA256 string `yaml:"sha256"`
+	} `yaml:"hash"`
+	Optional bool `yaml:"optional"`
+}
+
+func ParseLockFile(data []byte) (*LockFile, error) {
+	var lockFile LockFile
+	err := yaml.Unmarshal(data, &lockFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse conda-lock.yml: %w", err)
+	}
+	if lockFile.Version != 1 {
+		return nil, fmt.Errorf("unsupported conda-lock.yml version %d, only version 1 is supported", lockFile.Version)
+	}
+
+	return &lockFile, nil
+}
+
+/*
+Render the packages of a platform, the conda packages as an @EXPLICIT spec
+file which is installed without solving:
+
+	@EXPLICIT
+	https://conda.anaconda.org/conda-forge/linux-64/python-3.12.1-hab00c5b_1_cpython.conda#a6c4bc2c0d4a4f8fa2b4d9d1e1f1a1b1
+
+and the pip packages as a requirements.txt to be installed with --no-deps:
+
+	requests @ https://files.pythonhosted.org/packages/.../requests-2.31.0-py3-none-any.whl --hash=sha256:...
+
+Optional packages, which conda-lock only installs with extras, are skipped.
+*/
+func (f *LockFile) Render(platform string) (explicit string, pipRequirements string, err error) {
+	if !slices.Contains(f.Metadata.Platforms, platform) {
+		return "", "", fmt.Errorf("platform %s is not locked in conda-lock.yml, locked platforms are %s", platform, strings.Join(f.Metadata.Platforms, ", "))
+	}
+
+	var explicitSB, pipSB strings.Builder
+	explicitSB.WriteString(explicitHeader + "\n")
+	for _, pkg := range f.Package {
+		if pkg.Platform != platform || pkg.Optional {
+			continue
+		}
+		if pkg.URL == "" {
+			return "", "", fmt.Errorf("package %s %s has no url", pkg.Name, pkg.Version)
+		}
+
+		switch pkg.Manager {
+		case "conda":
+			switch {
+			case pkg.Hash.MD5 != "":
+				fmt.Fprintf(&explicitSB, "%s#%s\n", pkg.URL, pkg.Hash.MD5)
+			case pkg.Hash.SHA256 != "":
+				fmt.Fprintf(&explicitSB, "%s#sha256:%s\n", pkg.URL, pkg.Hash.SHA256)
+			default:
+				fmt.Fprintf(&explicitSB, "%s\n", pkg.URL)
+			}
+		case "pip":
+			fmt.Fprintf(&pipSB, "%s @ %s", pkg.Name, pkg.URL)
+			if pkg.Hash.SHA256 != "" {
+				fmt.Fprintf(&pipSB, " --hash=sha256:%s", pkg.Hash.SHA256)
+			}
+			pipSB.WriteString("\n")
+		default:
+			return "", "", fmt.Errorf("package %s %s has unsupported manager %s", pkg.Name, pkg.Version, pkg.Manager)
+		}
+	}
+
+	return explicitSB.String(), pipSB.String(), nil
+}
+
+// IsExplicitSpec reports whether content is an @EXPLICIT spec file, as written
+// by `conda list --explicit`, the header may follow comments.
+func IsExplicitSpec(content string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		return line == explicitHeader
+	}
+
+	return false
+}
+
+// CurrentPlatform returns the conda platform of the running binary, such as
+// linux-64.
+func CurrentPlatform() string {
+	arch := runtime.GOARCH
+	switch arch {
+	case "amd64":
+		arch = "64"
+	case "arm64":
+		arch = "aarch64"
+	}
+
+	return runtime.GOOS + "-" + arch
+}
+
+// Manifest lists the packages resolved in an environment, its digest
+// identifies the environment. Channels are recorded by name, so that the same
+// environment installed through different mirrors has the same digest.
+type Manifest struct {
+	Packages []ManifestPackage `json:"packages"`
+}
+
+type ManifestPackage struct {
+	Name     string `json:"name"`
+	Version  string `json:"version"`
+	Build    string `json:"build,omitempty"`
+	Channel  string `json:"channel,omitempty"`
+	Platform string `json:"platform,omitempty"`
+}
+
+func NewManifest(packages []CondaListOutputPackage) *Manifest {
+	manifest := &Manifest{Packages: make([]ManifestPackage, 0, len(packages))}
+	for _, pkg := range packages {
+		manifest.Packages = append(manifest.Packages, ManifestPackage{
+			Name:     pkg.Name,
+			Version:  pkg.Version,
+			Build:    pkg.BuildString,
+			Channel:  channelName(pkg.Channel),
+			Platform: pkg.Platform,
+		})
+	}
+	slices.SortFunc(manifest.Packages, func(a, b ManifestPackage) int {
+		return cmp.Or(
+			cmp.Compare(a.Name, b.Name),
+			cmp.Compare(a.Channel, b.Channel),
+			cmp.Compare(a.Version, b.Version),
+			cmp.Compare(a.Build, b.Build),
+		)
+	})
+
+	return manifest
+}
+
+// Marshal returns the content of the manifest file and its digest,
+// sha256:<hex>.
+func (m *Manifest) Marshal() ([]byte, string, error) {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, "", err
+	}
+	data = append(data, '\n')
+	sum := sha256.Sum256(data)
+
+	return data, "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// channelName strips the mirror from a channel URL,
+// https://mirror.example.com/conda-forge/linux-64 becomes conda-forge.
+func channelName(channel string) string {
+	_, path, ok := strings.Cut(channel, "://")
+	if !ok {
+		return channel
+	}
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) > 1 && subdirRegexp.MatchString(parts[len(parts)-1]) {
+		parts = parts[:len(parts)-1]
+	}
+
+	return parts[len(parts)-1]
+}
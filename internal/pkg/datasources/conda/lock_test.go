@@ -0,0 +1,123 @@
+package conda
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testLockFile = `version: 1
+metadata:
+  platforms:
+  - linux-64
+  - osx-arm64
+package:
+- name: python
+  version: 3.12.1
+  manager: conda
+  platform: linux-64
+  url: https://conda.anaconda.org/conda-forge/linux-64/python-3.12.1-hab00c5b_1_cpython.conda
+  hash:
+    md5: 0bab699354cbd66959550eb9b9866620
+    sha256: 7a6c6ec5b4df3d1b1f7c0d7b1c0e2a8f5c1b1e8e0f1d2c3b4a5968778695a4b3
+- name: tzdata
+  version: 2024a
+  manager: conda
+  platform: linux-64
+  url: https://conda.anaconda.org/conda-forge/noarch/tzdata-2024a-h0c530f3_0.conda
+  hash:
+    sha256: 0a82e5e3e9ab8a6b5c1e0e5b2c1a3e4f5d6c7b8a9e0f1d2c3b4a5968778695a4
+- name: python
+  version: 3.12.1
+  manager: conda
+  platform: osx-arm64
+  url: https://conda.anaconda.org/conda-forge/osx-arm64/python-3.12.1-hdf0ec26_1_cpython.conda
+  hash:
+    md5: 00f5bb7e8ea1b3d7e4d6c6e5c4b3a29e
+- name: requests
+  version: 2.31.0
+  manager: pip
+  platform: linux-64
+  url: https://files.pythonhosted.org/packages/requests-2.31.0-py3-none-any.whl
+  hash:
+    sha256: 58cd2187c01e70e6e26505bca751777aa9f2ee0b7f4300988b709f44e013003f
+- name: pytest
+  version: 8.0.0
+  manager: conda
+  platform: linux-64
+  optional: true
+  url: https://conda.anaconda.org/conda-forge/noarch/pytest-8.0.0-pyhd8ed1ab_0.conda
+  hash:
+    md5: 5ba1cc5b924226349d4a49fb547b7579
+`
+
+func TestLockFileRender(t *testing.T) {
+	lockFile, err := ParseLockFile([]byte(testLockFile))
+	require.NoError(t, err)
+
+	explicit, pipRequirements, err := lockFile.Render("linux-64")
+	require.NoError(t, err)
+	assert.Equal(t, `@EXPLICIT
+https://conda.anaconda.org/conda-forge/linux-64/python-3.12.1-hab00c5b_1_cpython.conda#0bab699354cbd66959550eb9b9866620
+https://conda.anaconda.org/conda-forge/noarch/tzdata-2024a-h0c530f3_0.conda#sha256:0a82e5e3e9ab8a6b5c1e0e5b2c1a3e4f5d6c7b8a9e0f1d2c3b4a5968778695a4
+`, explicit)
+	assert.Equal(t, "requests @ https://files.pythonhosted.org/packages/requests-2.31.0-py3-none-any.whl --hash=sha256:58cd2187c01e70e6e26505bca751777aa9f2ee0b7f4300988b709f44e013003f\n", pipRequirements)
+	assert.True(t, IsExplicitSpec(explicit))
+
+	_, _, err = lockFile.Render("win-64")
+	assert.EqualError(t, err, "platform win-64 is not locked in conda-lock.yml, locked platforms are linux-64, osx-arm64")
+
+	_, err = ParseLockFile([]byte("version: 2\n"))
+	assert.EqualError(t, err, "unsupported conda-lock.yml version 2, only version 1 is supported")
+}
+
+func TestIsExplicitSpec(t *testing.T) {
+	assert.True(t, IsExplicitSpec("# This file may be used to create an environment using:\n# platform: linux-64\n@EXPLICIT\nhttps://example.com/a.conda\n"))
+	assert.False(t, IsExplicitSpec("python=3.12\n"))
+	assert.False(t, IsExplicitSpec(""))
+}
+
+func TestManifest(t *testing.T) {
+	packages := []CondaListOutputPackage{
+		{Name: "python", Version: "3.12.1", BuildString: "hab00c5b_1_cpython", Channel: "https://mirror.example.com/conda-forge/linux-64", Platform: "linux-64"},
+		{Name: "pip", Version: "24.0", BuildString: "pyhd8ed1ab_0", Channel: "conda-forge", Platform: "noarch"},
+	}
+	data, digest, err := NewManifest(packages).Marshal()
+	require.NoError(t, err)
+	assert.Equal(t, `{
+  "packages": [
+    {
+      "name": "pip",
+      "version": "24.0",
+      "build": "pyhd8ed1ab_0",
+      "channel": "conda-forge",
+      "platform": "noarch"
+    },
+    {
+      "name": "python",
+      "version": "3.12.1",
+      "build": "hab00c5b_1_cpython",
+      "channel": "conda-forge",
+      "platform": "linux-64"
+    }
+  ]
+}
+`, string(data))
+	assert.Regexp(t, `^sha256:[0-9a-f]{64}$`, digest)
+
+	// the same packages installed through another mirror, in another order
+	_, otherDigest, err := NewManifest([]CondaListOutputPackage{
+		{Name: "pip", Version: "24.0", BuildString: "pyhd8ed1ab_0", Channel: "https://conda.anaconda.org/conda-forge/noarch", Platform: "noarch"},
+		{Name: "python", Version: "3.12.1", BuildString: "hab00c5b_1_cpython", Channel: "conda-forge", Platform: "linux-64"},
+	}).Marshal()
+	require.NoError(t, err)
+	assert.Equal(t, digest, otherDigest)
+}
+
+func TestChannelName(t *testing.T) {
+	assert.Equal(t, "conda-forge", channelName("conda-forge"))
+	assert.Equal(t, "conda-forge", channelName("https://conda.anaconda.org/conda-forge"))
+	assert.Equal(t, "conda-forge", channelName("https://mirror.example.com/anaconda/cloud/conda-forge/linux-aarch64/"))
+	assert.Equal(t, "pytorch", channelName("https://mirror.example.com/pytorch/noarch"))
+}
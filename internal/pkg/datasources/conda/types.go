@@ -221,3 +221,15 @@ type CondaConfigShowOutputRaw struct {
 	Verbosity                    int           `json:"verbosity"`
 	VerifyThreads                int           `json:"verify_threads"`
 }
+
+// Output of `conda list --json` command
+type CondaListOutputPackage struct {
+	BaseURL     string `json:"base_url"`
+	BuildNumber int    `json:"build_number"`
+	BuildString string `json:"build_string"`
+	Channel     string `json:"channel"`
+	DistName    string `json:"dist_name"`
+	Name        string `json:"name"`
+	Platform    string `json:"platform"`
+	Version     string `json:"version"`
+}
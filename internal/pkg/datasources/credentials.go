@@ -3,6 +3,7 @@ package datasources
 import (
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/BaizeAI/dataset/pkg/log"
 	"github.com/BaizeAI/dataset/pkg/utils"
@@ -19,6 +20,39 @@ type Secrets struct {
 
 	AKSKAccessKeyID     string `json:"-"`
 	AKSKSecretAccessKey string `json:"-"`
+
+	CACert  string `json:"-"`
+	TLSCert string `json:"-"`
+	TLSKey  string `json:"-"`
+
+	KerberosKeytab    string `json:"-"`
+	KerberosPrincipal string `json:"-"`
+
+	// Data holds every key of the secret, for the options which reference
+	// keys of the secret by name.
+	Data map[string]string `json:"-"`
+}
+
+// Get returns the value of a key of the secret.
+func (s Secrets) Get(key string) string {
+	if value, ok := s.Data[key]; ok {
+		return value
+	}
+
+	switch utils.SecretKey(key) {
+	case utils.SecretKeyUsername:
+		return s.Username
+	case utils.SecretKeyPassword:
+		return s.Password
+	case utils.SecretKeyToken:
+		return s.Token
+	case utils.SecretKeyAccessKey:
+		return s.AKSKAccessKeyID
+	case utils.SecretKeySecretKey:
+		return s.AKSKSecretAccessKey
+	default:
+		return ""
+	}
 }
 
 var (
@@ -30,6 +64,11 @@ var (
 		utils.SecretKeyToken,
 		utils.SecretKeyAccessKey,
 		utils.SecretKeySecretKey,
+		utils.SecretKeyCACert,
+		utils.SecretKeyTLSCert,
+		utils.SecretKeyTLSKey,
+		utils.SecretKeyKeytab,
+		utils.SecretKeyPrincipal,
 	}
 )
 
@@ -48,6 +87,23 @@ func ReadAndParseSecrets(name string) (Secrets, error) {
 		mSecrets[v] = string(secretContent)
 	}
 
+	data := make(map[string]string)
+	entries, err := os.ReadDir(name)
+	if err != nil {
+		logger.WithError(err).Debug("failed to list secret")
+	}
+	for _, entry := range entries {
+		// ..data and the timestamped directories are the internals of the secret volume
+		if strings.HasPrefix(entry.Name(), "..") {
+			continue
+		}
+		secretContent, err := os.ReadFile(filepath.Join(name, entry.Name()))
+		if err != nil {
+			continue
+		}
+		data[entry.Name()] = string(secretContent)
+	}
+
 	return Secrets{
 		Username:                mSecrets[utils.SecretKeyUsername],
 		Password:                mSecrets[utils.SecretKeyPassword],
@@ -56,5 +112,11 @@ func ReadAndParseSecrets(name string) (Secrets, error) {
 		Token:                   mSecrets[utils.SecretKeyToken],
 		AKSKAccessKeyID:         mSecrets[utils.SecretKeyAccessKey],
 		AKSKSecretAccessKey:     mSecrets[utils.SecretKeySecretKey],
+		CACert:                  mSecrets[utils.SecretKeyCACert],
+		TLSCert:                 mSecrets[utils.SecretKeyTLSCert],
+		TLSKey:                  mSecrets[utils.SecretKeyTLSKey],
+		KerberosKeytab:          mSecrets[utils.SecretKeyKeytab],
+		KerberosPrincipal:       strings.TrimSpace(mSecrets[utils.SecretKeyPrincipal]),
+		Data:                    data,
 	}, nil
 }
@@ -0,0 +1,50 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+)
+
+type clickhouseDriver struct{}
+
+func (d *clickhouseDriver) Open(opts ConnectionOptions) (*sql.DB, error) {
+	defaultPort := "9000"
+	if opts.TLS != nil {
+		defaultPort = "9440"
+	}
+
+	return clickhouse.OpenDB(&clickhouse.Options{
+		Protocol: clickhouse.Native,
+		Addr:     []string{net.JoinHostPort(opts.Host, opts.portOrDefault(defaultPort))},
+		Auth: clickhouse.Auth{
+			Database: opts.Database,
+			Username: opts.Username,
+			Password: opts.Password,
+		},
+		TLS: opts.TLS,
+	}), nil
+}
+
+func (d *clickhouseDriver) QuoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+func (d *clickhouseDriver) Placeholder(int) string {
+	return "?"
+}
+
+func (d *clickhouseDriver) Limit(query string, limit int) string {
+	return fmt.Sprintf("%s LIMIT %d", query, limit)
+}
+
+// PrimaryKey always returns no columns, the primary key of ClickHouse is a
+// sorting key which does not guarantee uniqueness and hence can not be used
+// for keyset pagination.
+func (d *clickhouseDriver) PrimaryKey(context.Context, *sql.DB, string) ([]string, error) {
+	return nil, nil
+}
@@ -0,0 +1,148 @@
+package database
+
+import (
+	"context"
+	"crypto/tls"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type Type string
+
+const (
+	TypeMySQL      Type = "mysql"
+	TypePostgreSQL Type = "postgresql"
+	TypeClickHouse Type = "clickhouse"
+	TypeSQLServer  Type = "sqlserver"
+)
+
+// ConnectionOptions describes how to reach a database server. It is
+// intentionally driver agnostic, each Driver maps it to its own DSN or
+// connector configuration.
+type ConnectionOptions struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	Database string
+	Charset  string
+
+	// TLS is nil when the connection should not be encrypted.
+	TLS *tls.Config
+}
+
+func (o ConnectionOptions) portOrDefault(port string) string {
+	if o.Port != "" {
+		return o.Port
+	}
+
+	return port
+}
+
+type Driver interface {
+	// Open returns a connection pool for the database described by opts.
+	Open(opts ConnectionOptions) (*sql.DB, error)
+	// QuoteIdentifier quotes a column or table name in the dialect of the
+	// driver.
+	QuoteIdentifier(name string) string
+	// Placeholder returns the n-th (starting from 1) bind parameter.
+	Placeholder(n int) string
+	// Limit restricts query, which always ends with an ORDER BY clause, to
+	// return at most limit rows.
+	Limit(query string, limit int) string
+	// PrimaryKey returns the primary key columns of table in key order, or no
+	// columns when the table has no primary key usable for keyset pagination.
+	PrimaryKey(ctx context.Context, db *sql.DB, table string) ([]string, error)
+}
+
+var (
+	driversLock sync.RWMutex
+	drivers     = map[Type]Driver{
+		TypeMySQL:      &mysqlDriver{},
+		TypePostgreSQL: &postgresqlDriver{},
+		TypeClickHouse: &clickhouseDriver{},
+		TypeSQLServer:  &sqlserverDriver{},
+	}
+
+	aliases = map[string]Type{
+		"postgres": TypePostgreSQL,
+		"pgsql":    TypePostgreSQL,
+		"mssql":    TypeSQLServer,
+	}
+)
+
+// Register adds or replaces the driver for typ. It is meant for drivers
+// that are not built in, for example the in-process SQLite driver used
+// in tests.
+func Register(typ Type, driver Driver) {
+	driversLock.Lock()
+	defer driversLock.Unlock()
+
+	drivers[typ] = driver
+}
+
+// ParseType normalizes the value of the type option. An empty value falls
+// back to MySQL, which was the only supported database before.
+func ParseType(s string) (Type, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return TypeMySQL, nil
+	}
+	if typ, ok := aliases[s]; ok {
+		return typ, nil
+	}
+	if _, err := GetDriver(Type(s)); err == nil {
+		return Type(s), nil
+	}
+
+	return "", fmt.Errorf("unsupported database type %s, supported types are %s", s, strings.Join(SupportedTypes(), ", "))
+}
+
+func SupportedTypes() []string {
+	driversLock.RLock()
+	defer driversLock.RUnlock()
+
+	types := make([]string, 0, len(drivers))
+	for typ := range drivers {
+		types = append(types, string(typ))
+	}
+	sort.Strings(types)
+
+	return types
+}
+
+func GetDriver(typ Type) (Driver, error) {
+	driversLock.RLock()
+	driver, ok := drivers[typ]
+	driversLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unsupported database type %s, supported types are %s", typ, strings.Join(SupportedTypes(), ", "))
+	}
+
+	return driver, nil
+}
+
+// queryStrings runs query and collects the first column of every row.
+func queryStrings(ctx context.Context, db *sql.DB, query string, args ...any) ([]string, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var res []string
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return nil, err
+		}
+		res = append(res, s)
+	}
+
+	return res, rows.Err()
+}
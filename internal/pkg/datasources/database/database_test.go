@@ -0,0 +1,192 @@
+package database
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseType(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Type
+		wantErr bool
+	}{
+		{input: "", want: TypeMySQL},
+		{input: "MySQL", want: TypeMySQL},
+		{input: "postgresql", want: TypePostgreSQL},
+		{input: "postgres", want: TypePostgreSQL},
+		{input: "ClickHouse", want: TypeClickHouse},
+		{input: "sqlserver", want: TypeSQLServer},
+		{input: "mssql", want: TypeSQLServer},
+		{input: "oracle", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseType(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestDialects(t *testing.T) {
+	query := "SELECT * FROM users ORDER BY id"
+
+	tests := []struct {
+		driver      Driver
+		quoted      string
+		placeholder string
+		limited     string
+	}{
+		{driver: &mysqlDriver{}, quoted: "`a``b`", placeholder: "?", limited: query + " LIMIT 10"},
+		{driver: &postgresqlDriver{}, quoted: "\"a`b\"", placeholder: "$2", limited: query + " LIMIT 10"},
+		{driver: &clickhouseDriver{}, quoted: "`a``b`", placeholder: "?", limited: query + " LIMIT 10"},
+		{driver: &sqlserverDriver{}, quoted: "[a`b]", placeholder: "@p2", limited: query + " OFFSET 0 ROWS FETCH NEXT 10 ROWS ONLY"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.quoted, tt.driver.QuoteIdentifier("a`b"))
+		assert.Equal(t, tt.placeholder, tt.driver.Placeholder(2))
+		assert.Equal(t, tt.limited, tt.driver.Limit(query, 10))
+	}
+
+	assert.Equal(t, `"a""b"`, (&postgresqlDriver{}).QuoteIdentifier(`a"b`))
+	assert.Equal(t, "[a]]b]", (&sqlserverDriver{}).QuoteIdentifier("a]b"))
+}
+
+func TestDriversOpen(t *testing.T) {
+	opts := ConnectionOptions{
+		Host:     "127.0.0.1",
+		Port:     "1",
+		Username: "user",
+		Password: "p@ss:word/",
+		Database: "db",
+		Charset:  "utf8mb4",
+	}
+
+	for _, typ := range []Type{TypeMySQL, TypePostgreSQL, TypeClickHouse, TypeSQLServer} {
+		t.Run(string(typ), func(t *testing.T) {
+			driver, err := GetDriver(typ)
+			require.NoError(t, err)
+
+			// opening does not connect, hence it should succeed even
+			// though nothing is listening
+			db, err := driver.Open(opts)
+			require.NoError(t, err)
+			require.NotNil(t, db)
+			assert.NoError(t, db.Close())
+		})
+	}
+}
+
+func TestParseTLSMode(t *testing.T) {
+	for input, want := range map[string]TLSMode{
+		"":            TLSModeDisable,
+		"false":       TLSModeDisable,
+		"true":        TLSModeVerifyFull,
+		"require":     TLSModeRequire,
+		"Verify-CA":   TLSModeVerifyCA,
+		"verify-full": TLSModeVerifyFull,
+	} {
+		got, err := ParseTLSMode(input)
+		require.NoError(t, err)
+		assert.Equal(t, want, got, input)
+	}
+
+	_, err := ParseTLSMode("prefer")
+	assert.Error(t, err)
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	caPEM := generateCA(t)
+
+	config, err := NewTLSConfig(TLSOptions{Mode: TLSModeDisable})
+	require.NoError(t, err)
+	assert.Nil(t, config)
+
+	config, err = NewTLSConfig(TLSOptions{Mode: TLSModeRequire})
+	require.NoError(t, err)
+	assert.True(t, config.InsecureSkipVerify)
+	assert.Nil(t, config.RootCAs)
+
+	config, err = NewTLSConfig(TLSOptions{Mode: TLSModeVerifyFull, ServerName: "db.example.com", CACert: caPEM})
+	require.NoError(t, err)
+	assert.False(t, config.InsecureSkipVerify)
+	assert.Equal(t, "db.example.com", config.ServerName)
+	assert.NotNil(t, config.RootCAs)
+
+	_, err = NewTLSConfig(TLSOptions{Mode: TLSModeVerifyFull, CACert: "not a certificate"})
+	assert.ErrorContains(t, err, "failed to parse CA certificate")
+
+	_, err = NewTLSConfig(TLSOptions{Mode: TLSModeVerifyFull, ClientCert: "invalid", ClientKey: "invalid"})
+	assert.ErrorContains(t, err, "failed to load client certificate")
+}
+
+func TestNewTLSConfigVerifyCA(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	serverCAPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+	otherCAPEM := generateCA(t)
+
+	dial := func(caPEM string) error {
+		config, err := NewTLSConfig(TLSOptions{
+			Mode: TLSModeVerifyCA,
+			// does not match the certificate of the test server, which
+			// must be tolerated by verify-ca
+			ServerName: "unrelated.example.com",
+			CACert:     caPEM,
+		})
+		require.NoError(t, err)
+
+		conn, err := tls.Dial("tcp", server.Listener.Addr().String(), config)
+		if err != nil {
+			return err
+		}
+
+		return conn.Close()
+	}
+
+	assert.NoError(t, dial(serverCAPEM))
+	assert.Error(t, dial(otherCAPEM))
+}
+
+func generateCA(t *testing.T) string {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "dataset-test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
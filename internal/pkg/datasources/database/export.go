@@ -0,0 +1,389 @@
+package database
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+const DefaultBatchSize = 10000
+
+type Pagination string
+
+const (
+	// PaginationKeyset reads the table in batches ordered by its primary key,
+	// every batch starts after the last key of the previous one.
+	PaginationKeyset Pagination = "keyset"
+	// PaginationSnapshot reads the whole result set with a single query, which
+	// sees a consistent snapshot of the table. It is used for custom queries,
+	// and tables without a primary key.
+	PaginationSnapshot Pagination = "snapshot"
+)
+
+type ExportOptions struct {
+	// Table is the table to export, optionally qualified with its schema. It
+	// is ignored when Query is set.
+	Table string
+	// Query is a custom query whose result set is exported instead of a table.
+	Query string
+	// Columns restricts the exported columns, all columns are exported when
+	// empty.
+	Columns []string
+	// Where is an additional SQL condition the exported rows must match.
+	Where string
+	// Watermark enables incremental export, only the rows whose watermark
+	// column is greater than the value of the watermark are exported.
+	Watermark *Watermark
+	Format    Format
+	BatchSize int
+}
+
+type Watermark struct {
+	Column string `json:"column"`
+	Kind   Kind   `json:"kind,omitempty"`
+	// Value is the greatest watermark exported so far, nil when nothing was
+	// exported yet.
+	Value any `json:"value,omitempty"`
+}
+
+// Schema describes an exported table, it is written next to the exported
+// data as a sidecar file.
+type Schema struct {
+	Table      string     `json:"table,omitempty"`
+	Query      string     `json:"query,omitempty"`
+	Format     Format     `json:"format"`
+	Pagination Pagination `json:"pagination"`
+	PrimaryKey []string   `json:"primaryKey,omitempty"`
+	Watermark  *Watermark `json:"watermark,omitempty"`
+	Rows       int64      `json:"rows"`
+	Columns    []Column   `json:"columns"`
+}
+
+// Export streams all rows of a table, or of a custom query, to out in the
+// requested format.
+func Export(ctx context.Context, db *sql.DB, driver Driver, out io.Writer, opts ExportOptions) (*Schema, error) {
+	if opts.Format == "" {
+		opts.Format = FormatCSV
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+
+	e := &exporter{
+		db:     db,
+		driver: driver,
+		out:    out,
+		opts:   opts,
+		schema: &Schema{
+			Format:     opts.Format,
+			Pagination: PaginationSnapshot,
+		},
+	}
+	if err := e.prepare(ctx); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if e.schema.Pagination == PaginationKeyset {
+		err = e.exportKeyset(ctx)
+	} else {
+		_, err = e.query(ctx, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := e.writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return e.schema, nil
+}
+
+type exporter struct {
+	db     *sql.DB
+	driver Driver
+	out    io.Writer
+	opts   ExportOptions
+	schema *Schema
+
+	// source is the quoted table, or the custom query.
+	source     string
+	projection string
+	orderBy    []string
+	// since is the watermark the export started from, while the watermark
+	// of the schema moves forward as rows are exported.
+	since any
+
+	writer RowWriter
+	// keyIndexes are the positions of the primary key columns in a row.
+	keyIndexes     []int
+	watermarkIndex int
+}
+
+func (e *exporter) prepare(ctx context.Context) error {
+	e.projection = "*"
+	if len(e.opts.Columns) > 0 {
+		quoted := make([]string, len(e.opts.Columns))
+		for i, column := range e.opts.Columns {
+			if err := ValidateIdentifier(column, false); err != nil {
+				return err
+			}
+			quoted[i] = e.driver.QuoteIdentifier(column)
+		}
+		e.projection = strings.Join(quoted, ", ")
+	}
+
+	if e.opts.Watermark != nil {
+		watermark := *e.opts.Watermark
+		if err := ValidateIdentifier(watermark.Column, false); err != nil {
+			return err
+		}
+		if watermark.Value != nil {
+			value, err := normalize(watermark.Kind, watermark.Value)
+			if err != nil {
+				return fmt.Errorf("invalid watermark %v of column %s: %w", watermark.Value, watermark.Column, err)
+			}
+			watermark.Value = value
+			e.since = value
+		}
+		e.schema.Watermark = &watermark
+	}
+
+	if e.opts.Query != "" {
+		e.schema.Query = e.opts.Query
+		e.source = "(" + e.opts.Query + ") q"
+		return nil
+	}
+
+	if err := ValidateIdentifier(e.opts.Table, true); err != nil {
+		return err
+	}
+	e.schema.Table = e.opts.Table
+	e.source = QuoteQualifiedIdentifier(e.driver, e.opts.Table)
+
+	primaryKey, err := e.driver.PrimaryKey(ctx, e.db, e.opts.Table)
+	if err != nil {
+		return fmt.Errorf("failed to get primary key of %s: %w", e.opts.Table, err)
+	}
+	e.schema.PrimaryKey = primaryKey
+	// keyset pagination is only possible when the primary key is exported
+	for _, column := range primaryKey {
+		if len(e.opts.Columns) > 0 && !slices.Contains(e.opts.Columns, column) {
+			e.orderBy = nil
+			return nil
+		}
+		e.orderBy = append(e.orderBy, e.driver.QuoteIdentifier(column))
+	}
+	if len(e.orderBy) > 0 {
+		e.schema.Pagination = PaginationKeyset
+	}
+
+	return nil
+}
+
+func (e *exporter) exportKeyset(ctx context.Context) error {
+	var lastKey []any
+	for {
+		key, err := e.query(ctx, lastKey)
+		if err != nil {
+			return err
+		}
+		if key == nil {
+			return nil
+		}
+		lastKey = key
+	}
+}
+
+// selectQuery builds the query of a batch, lastKey is the primary key of the
+// last row of the previous batch.
+func (e *exporter) selectQuery(lastKey []any) (string, []any) {
+	var (
+		conditions []string
+		args       []any
+	)
+	if e.opts.Where != "" {
+		conditions = append(conditions, "("+e.opts.Where+")")
+	}
+	if e.since != nil {
+		args = append(args, e.since)
+		conditions = append(conditions, fmt.Sprintf("%s > %s", e.driver.QuoteIdentifier(e.schema.Watermark.Column), e.driver.Placeholder(len(args))))
+	}
+	if lastKey != nil {
+		var condition string
+		condition, args = e.keysetCondition(lastKey, args)
+		conditions = append(conditions, "("+condition+")")
+	}
+
+	// a custom query is run as is when possible, since wrapping it limits
+	// the syntax, e.g. SQL Server does not allow ORDER BY in subqueries
+	if e.opts.Query != "" && e.projection == "*" && len(conditions) == 0 {
+		return e.opts.Query, nil
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s", e.projection, e.source)
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	if e.schema.Pagination == PaginationKeyset {
+		query = e.driver.Limit(query+" ORDER BY "+strings.Join(e.orderBy, ", "), e.opts.BatchSize)
+	}
+
+	return query, args
+}
+
+// keysetCondition matches the rows after lastKey in the order of the primary
+// key, it is the expanded form of (a, b) > (?, ?), since row value
+// comparison is not supported by all databases:
+//
+//	(a > ?) OR (a = ? AND b > ?)
+func (e *exporter) keysetCondition(lastKey []any, args []any) (string, []any) {
+	var conditions []string
+	for i := range e.orderBy {
+		var terms []string
+		for j := 0; j <= i; j++ {
+			args = append(args, lastKey[j])
+			operator := "="
+			if j == i {
+				operator = ">"
+			}
+			terms = append(terms, fmt.Sprintf("%s %s %s", e.orderBy[j], operator, e.driver.Placeholder(len(args))))
+		}
+		conditions = append(conditions, "("+strings.Join(terms, " AND ")+")")
+	}
+
+	return strings.Join(conditions, " OR "), args
+}
+
+// query writes a batch and returns the primary key of its last row, the key
+// is only returned when a full batch was written.
+func (e *exporter) query(ctx context.Context, lastKey []any) ([]any, error) {
+	query, args := e.selectQuery(lastKey)
+	rows, err := e.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s: %w", e.name(), err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	if e.writer == nil {
+		if err := e.init(rows); err != nil {
+			return nil, err
+		}
+	}
+
+	values := make([]any, len(e.schema.Columns))
+	pointers := make([]any, len(values))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	normalized := make([]any, len(values))
+	var count int
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+		for i, v := range values {
+			column := e.schema.Columns[i]
+			normalized[i], err = normalize(column.Kind, v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value of column %s: %w", column.Name, err)
+			}
+		}
+		if err := e.writer.Write(normalized); err != nil {
+			return nil, err
+		}
+		if watermark := e.schema.Watermark; watermark != nil {
+			value := normalized[e.watermarkIndex]
+			if value != nil && (watermark.Value == nil || compare(value, watermark.Value) > 0) {
+				watermark.Value = value
+			}
+		}
+		count++
+		e.schema.Rows++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if e.schema.Pagination != PaginationKeyset || count < e.opts.BatchSize {
+		return nil, nil
+	}
+
+	key := make([]any, len(e.keyIndexes))
+	for i, index := range e.keyIndexes {
+		key[i] = normalized[index]
+	}
+
+	return key, nil
+}
+
+func (e *exporter) name() string {
+	if e.opts.Query != "" {
+		return "query"
+	}
+	return e.opts.Table
+}
+
+func (e *exporter) init(rows *sql.Rows) error {
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return err
+	}
+	e.schema.Columns = columnsOf(types)
+
+	if e.schema.Pagination == PaginationKeyset {
+		for _, key := range e.schema.PrimaryKey {
+			index := e.columnIndex(key)
+			if index < 0 {
+				return fmt.Errorf("primary key column %s of %s is not selected", key, e.name())
+			}
+			e.keyIndexes = append(e.keyIndexes, index)
+		}
+	}
+
+	if watermark := e.schema.Watermark; watermark != nil {
+		e.watermarkIndex = e.columnIndex(watermark.Column)
+		if e.watermarkIndex < 0 {
+			return fmt.Errorf("watermark column %s of %s is not selected", watermark.Column, e.name())
+		}
+		kind := e.schema.Columns[e.watermarkIndex].Kind
+		switch {
+		case kind != KindInt && kind != KindFloat && kind != KindTimestamp && kind != KindString:
+			return fmt.Errorf("watermark column %s must be a number, timestamp or string, got %s", watermark.Column, kind)
+		case watermark.Kind != "" && watermark.Kind != kind:
+			return fmt.Errorf("kind of watermark column %s changed from %s to %s", watermark.Column, watermark.Kind, kind)
+		}
+		watermark.Kind = kind
+	}
+
+	e.writer, err = NewRowWriter(e.opts.Format, e.out, e.schema.Columns)
+	return err
+}
+
+func (e *exporter) columnIndex(name string) int {
+	return slices.IndexFunc(e.schema.Columns, func(column Column) bool {
+		return column.Name == name
+	})
+}
+
+// compare compares two normalized values of the same kind.
+func compare(a, b any) int {
+	switch a := a.(type) {
+	case int64:
+		return cmp.Compare(a, b.(int64))
+	case float64:
+		return cmp.Compare(a, b.(float64))
+	case time.Time:
+		return a.Compare(b.(time.Time))
+	case string:
+		return strings.Compare(a, b.(string))
+	default:
+		return 0
+	}
+}
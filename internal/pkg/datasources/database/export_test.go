@@ -0,0 +1,396 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+// sqliteDriver runs the exporter against an in-process database, Database is
+// the path of the database file.
+type sqliteDriver struct{}
+
+func (d *sqliteDriver) Open(opts ConnectionOptions) (*sql.DB, error) {
+	return sql.Open("sqlite", opts.Database)
+}
+
+func (d *sqliteDriver) QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (d *sqliteDriver) Placeholder(int) string {
+	return "?"
+}
+
+func (d *sqliteDriver) Limit(query string, limit int) string {
+	return fmt.Sprintf("%s LIMIT %d", query, limit)
+}
+
+func (d *sqliteDriver) PrimaryKey(ctx context.Context, db *sql.DB, table string) ([]string, error) {
+	return queryStrings(ctx, db, "SELECT name FROM pragma_table_info(?) WHERE pk > 0 ORDER BY pk", table)
+}
+
+func newSQLite(t *testing.T, statements ...string) *sql.DB {
+	t.Helper()
+
+	db, err := (&sqliteDriver{}).Open(ConnectionOptions{Database: filepath.Join(t.TempDir(), "test.db")})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	for _, statement := range statements {
+		_, err := db.Exec(statement)
+		require.NoError(t, err)
+	}
+
+	return db
+}
+
+var usersTable = []string{
+	"CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, score REAL, active BOOLEAN, avatar BLOB, created_at DATETIME)",
+	`INSERT INTO users VALUES
+		(1, 'alice', 1.5, 1, x'0102', '2024-01-02 03:04:05'),
+		(2, 'comma, "quote"' || char(10) || 'newline', NULL, 0, NULL, NULL),
+		(3, '', 3, NULL, x'ff', '2024-01-02T03:04:05Z')`,
+}
+
+func TestExportCSV(t *testing.T) {
+	db := newSQLite(t, usersTable...)
+
+	var buf bytes.Buffer
+	schema, err := Export(context.Background(), db, &sqliteDriver{}, &buf, ExportOptions{Table: "users", BatchSize: 2})
+	require.NoError(t, err)
+
+	assert.Equal(t, FormatCSV, schema.Format)
+	assert.Equal(t, PaginationKeyset, schema.Pagination)
+	assert.Equal(t, []string{"id"}, schema.PrimaryKey)
+	assert.Equal(t, int64(3), schema.Rows)
+	assert.Equal(t, []Kind{KindInt, KindString, KindFloat, KindBool, KindBytes, KindTimestamp}, kindsOf(schema.Columns))
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"id", "name", "score", "active", "avatar", "created_at"},
+		{"1", "alice", "1.5", "true", "AQI=", "2024-01-02T03:04:05Z"},
+		{"2", "comma, \"quote\"\nnewline", "", "false", "", ""},
+		{"3", "", "3", "", "/w==", "2024-01-02T03:04:05Z"},
+	}, records)
+}
+
+func TestExportJSONL(t *testing.T) {
+	db := newSQLite(t, usersTable...)
+
+	var buf bytes.Buffer
+	_, err := Export(context.Background(), db, &sqliteDriver{}, &buf, ExportOptions{Table: "users", Format: FormatJSONL})
+	require.NoError(t, err)
+
+	assert.Equal(t, `{"id":1,"name":"alice","score":1.5,"active":true,"avatar":"AQI=","created_at":"2024-01-02T03:04:05Z"}
+{"id":2,"name":"comma, \"quote\"\nnewline","score":null,"active":false,"avatar":null,"created_at":null}
+{"id":3,"name":"","score":3,"active":null,"avatar":"/w==","created_at":"2024-01-02T03:04:05Z"}
+`, buf.String())
+}
+
+func TestExportParquet(t *testing.T) {
+	db := newSQLite(t, usersTable...)
+
+	var buf bytes.Buffer
+	schema, err := Export(context.Background(), db, &sqliteDriver{}, &buf, ExportOptions{Table: "users", Format: FormatParquet})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), schema.Rows)
+
+	type user struct {
+		ID        *int64     `parquet:"id"`
+		Name      *string    `parquet:"name"`
+		Score     *float64   `parquet:"score"`
+		Active    *bool      `parquet:"active"`
+		Avatar    []byte     `parquet:"avatar,optional"`
+		CreatedAt *time.Time `parquet:"created_at,timestamp(microsecond)"`
+	}
+	reader := parquet.NewGenericReader[user](bytes.NewReader(buf.Bytes()))
+	defer func() {
+		_ = reader.Close()
+	}()
+	assert.Equal(t, int64(3), reader.NumRows())
+
+	users := make([]user, 3)
+	n, err := reader.Read(users)
+	if err != nil {
+		require.ErrorIs(t, err, io.EOF)
+	}
+	require.Equal(t, 3, n)
+
+	assert.Equal(t, int64(1), *users[0].ID)
+	assert.Equal(t, "alice", *users[0].Name)
+	assert.Equal(t, 1.5, *users[0].Score)
+	assert.True(t, *users[0].Active)
+	assert.Equal(t, []byte{1, 2}, users[0].Avatar)
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), users[0].CreatedAt.UTC())
+
+	assert.Equal(t, "comma, \"quote\"\nnewline", *users[1].Name)
+	assert.Nil(t, users[1].Score)
+	assert.Nil(t, users[1].Avatar)
+	assert.Nil(t, users[1].CreatedAt)
+	assert.Nil(t, users[2].Active)
+}
+
+func TestExportKeysetCompositeKey(t *testing.T) {
+	statements := []string{"CREATE TABLE events (tenant TEXT, seq INTEGER, payload TEXT, PRIMARY KEY (tenant, seq))"}
+	for _, tenant := range []string{"b", "a", "c"} {
+		for seq := 3; seq > 0; seq-- {
+			statements = append(statements, fmt.Sprintf("INSERT INTO events VALUES ('%s', %d, '%s%d')", tenant, seq, tenant, seq))
+		}
+	}
+	db := newSQLite(t, statements...)
+
+	for _, batchSize := range []int{1, 2, 3, 4, 9, 100} {
+		t.Run(fmt.Sprint(batchSize), func(t *testing.T) {
+			var buf bytes.Buffer
+			schema, err := Export(context.Background(), db, &sqliteDriver{}, &buf, ExportOptions{Table: "events", Format: FormatJSONL, BatchSize: batchSize})
+			require.NoError(t, err)
+			assert.Equal(t, []string{"tenant", "seq"}, schema.PrimaryKey)
+			assert.Equal(t, int64(9), schema.Rows)
+
+			var payloads []string
+			for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+				payloads = append(payloads, line[strings.LastIndex(line, ":")+2:len(line)-2])
+			}
+			assert.Equal(t, []string{"a1", "a2", "a3", "b1", "b2", "b3", "c1", "c2", "c3"}, payloads)
+		})
+	}
+}
+
+func TestExportSnapshot(t *testing.T) {
+	db := newSQLite(t,
+		"CREATE TABLE logs (message TEXT)",
+		"INSERT INTO logs VALUES ('a'), ('b'), ('a')",
+	)
+
+	var buf bytes.Buffer
+	schema, err := Export(context.Background(), db, &sqliteDriver{}, &buf, ExportOptions{Table: "logs", BatchSize: 1})
+	require.NoError(t, err)
+	assert.Equal(t, PaginationSnapshot, schema.Pagination)
+	assert.Empty(t, schema.PrimaryKey)
+	assert.Equal(t, int64(3), schema.Rows)
+	assert.Equal(t, "message\na\nb\na\n", buf.String())
+}
+
+func TestExportEmptyTable(t *testing.T) {
+	db := newSQLite(t, "CREATE TABLE empty (id INTEGER PRIMARY KEY, name TEXT)")
+
+	var buf bytes.Buffer
+	schema, err := Export(context.Background(), db, &sqliteDriver{}, &buf, ExportOptions{Table: "empty"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), schema.Rows)
+	assert.Len(t, schema.Columns, 2)
+	assert.Equal(t, "id,name\n", buf.String())
+
+	_, err = Export(context.Background(), db, &sqliteDriver{}, &buf, ExportOptions{Table: "missing"})
+	assert.Error(t, err)
+}
+
+func TestKeysetCondition(t *testing.T) {
+	e := &exporter{driver: &postgresqlDriver{}, orderBy: []string{`"a"`}}
+
+	where, args := e.keysetCondition([]any{1}, nil)
+	assert.Equal(t, `("a" > $1)`, where)
+	assert.Equal(t, []any{1}, args)
+
+	e.orderBy = []string{`"a"`, `"b"`, `"c"`}
+	where, args = e.keysetCondition([]any{1, "x", 3}, []any{"watermark"})
+	assert.Equal(t, `("a" > $2) OR ("a" = $3 AND "b" > $4) OR ("a" = $5 AND "b" = $6 AND "c" > $7)`, where)
+	assert.Equal(t, []any{"watermark", 1, 1, "x", 1, "x", 3}, args)
+}
+
+func TestExportProjectionAndWhere(t *testing.T) {
+	db := newSQLite(t,
+		`CREATE TABLE "order" (id INTEGER PRIMARY KEY, item TEXT, amount INTEGER)`,
+		`INSERT INTO "order" VALUES (1, 'a', 10), (2, 'b', 20), (3, 'c', 30), (4, 'd', 40)`,
+	)
+
+	var buf bytes.Buffer
+	schema, err := Export(context.Background(), db, &sqliteDriver{}, &buf, ExportOptions{
+		Table:     "order",
+		Columns:   []string{"id", "item"},
+		Where:     "amount > 10 OR item = 'a'",
+		BatchSize: 1,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, PaginationKeyset, schema.Pagination)
+	assert.Equal(t, "id,item\n1,a\n2,b\n3,c\n4,d\n", buf.String())
+
+	// the primary key is not exported, hence keyset pagination is not possible
+	buf.Reset()
+	schema, err = Export(context.Background(), db, &sqliteDriver{}, &buf, ExportOptions{
+		Table:     "order",
+		Columns:   []string{"item"},
+		Where:     "amount >= 30",
+		BatchSize: 1,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, PaginationSnapshot, schema.Pagination)
+	assert.Equal(t, "item\nc\nd\n", buf.String())
+}
+
+func TestExportQuery(t *testing.T) {
+	db := newSQLite(t,
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)",
+		"CREATE TABLE orders (id INTEGER PRIMARY KEY, user_id INTEGER, amount INTEGER)",
+		"INSERT INTO users VALUES (1, 'alice'), (2, 'bob')",
+		"INSERT INTO orders VALUES (1, 1, 10), (2, 1, 5), (3, 2, 7)",
+	)
+	query := "SELECT u.name AS name, SUM(o.amount) AS total, MAX(o.id) AS last_order FROM users u JOIN orders o ON o.user_id = u.id GROUP BY u.name ORDER BY u.name"
+
+	var buf bytes.Buffer
+	schema, err := Export(context.Background(), db, &sqliteDriver{}, &buf, ExportOptions{Query: query})
+	require.NoError(t, err)
+	assert.Equal(t, query, schema.Query)
+	assert.Empty(t, schema.Table)
+	assert.Equal(t, PaginationSnapshot, schema.Pagination)
+	assert.Equal(t, "name,total,last_order\nalice,15,2\nbob,7,3\n", buf.String())
+
+	buf.Reset()
+	_, err = Export(context.Background(), db, &sqliteDriver{}, &buf, ExportOptions{Query: query, Columns: []string{"name"}, Where: "total > 10"})
+	require.NoError(t, err)
+	assert.Equal(t, "name\nalice\n", buf.String())
+}
+
+func TestExportWatermark(t *testing.T) {
+	db := newSQLite(t,
+		"CREATE TABLE events (id INTEGER PRIMARY KEY, version INTEGER, payload TEXT)",
+		"INSERT INTO events VALUES (1, 3, 'a'), (2, 1, 'b'), (3, NULL, 'c')",
+	)
+	export := func(watermark *Watermark) (*Schema, string) {
+		var buf bytes.Buffer
+		schema, err := Export(context.Background(), db, &sqliteDriver{}, &buf, ExportOptions{Table: "events", Watermark: watermark, BatchSize: 2})
+		require.NoError(t, err)
+		return schema, buf.String()
+	}
+
+	schema, content := export(&Watermark{Column: "version"})
+	assert.Equal(t, "id,version,payload\n1,3,a\n2,1,b\n3,,c\n", content)
+	assert.Equal(t, &Watermark{Column: "version", Kind: KindInt, Value: int64(3)}, schema.Watermark)
+
+	_, err := db.Exec("INSERT INTO events VALUES (4, 5, 'd'), (5, 3, 'e'), (6, 4, 'f')")
+	require.NoError(t, err)
+
+	// the watermark as read back from the state file
+	schema, content = export(&Watermark{Column: "version", Kind: KindInt, Value: json.Number("3")})
+	assert.Equal(t, "id,version,payload\n4,5,d\n6,4,f\n", content)
+	assert.Equal(t, int64(2), schema.Rows)
+	assert.Equal(t, int64(5), schema.Watermark.Value)
+
+	schema, content = export(schema.Watermark)
+	assert.Equal(t, "id,version,payload\n", content)
+	assert.Equal(t, int64(5), schema.Watermark.Value)
+
+	var buf bytes.Buffer
+	_, err = Export(context.Background(), db, &sqliteDriver{}, &buf, ExportOptions{Table: "events", Watermark: &Watermark{Column: "version", Kind: KindTimestamp}})
+	assert.ErrorContains(t, err, "kind of watermark column version changed from timestamp to int")
+
+	_, err = Export(context.Background(), db, &sqliteDriver{}, &buf, ExportOptions{Table: "events", Columns: []string{"id"}, Watermark: &Watermark{Column: "version"}})
+	assert.ErrorContains(t, err, "watermark column version of events is not selected")
+}
+
+func TestExportInvalidIdentifiers(t *testing.T) {
+	db := newSQLite(t, "CREATE TABLE users (id INTEGER PRIMARY KEY)")
+
+	for _, opts := range []ExportOptions{
+		{Table: "users; DROP TABLE users"},
+		{Table: "users", Columns: []string{"id", "1=1"}},
+		{Table: "users", Watermark: &Watermark{Column: "id)"}},
+	} {
+		var buf bytes.Buffer
+		_, err := Export(context.Background(), db, &sqliteDriver{}, &buf, opts)
+		assert.ErrorContains(t, err, "invalid identifier")
+	}
+}
+
+func TestValidateIdentifier(t *testing.T) {
+	for _, name := range []string{"users", "_users", "Users2", "user$", "用户"} {
+		assert.NoError(t, ValidateIdentifier(name, false), name)
+	}
+	assert.NoError(t, ValidateIdentifier("public.users", true))
+
+	for _, name := range []string{"", "1users", "users;", "users name", "`users`", "public.users", strings.Repeat("a", 129)} {
+		assert.Error(t, ValidateIdentifier(name, false), name)
+	}
+	for _, name := range []string{"public.", ".users", "a.b;c"} {
+		assert.Error(t, ValidateIdentifier(name, true), name)
+	}
+
+	assert.Equal(t, "`db`.`users`", QuoteQualifiedIdentifier(&mysqlDriver{}, "db.users"))
+	assert.Equal(t, "[dbo].[users]", QuoteQualifiedIdentifier(&sqlserverDriver{}, "dbo.users"))
+}
+
+func TestParseFormat(t *testing.T) {
+	for input, want := range map[string]Format{
+		"":        FormatCSV,
+		"CSV":     FormatCSV,
+		"parquet": FormatParquet,
+		"jsonl":   FormatJSONL,
+		"ndjson":  FormatJSONL,
+	} {
+		got, err := ParseFormat(input)
+		require.NoError(t, err)
+		assert.Equal(t, want, got, input)
+	}
+
+	_, err := ParseFormat("xlsx")
+	assert.ErrorContains(t, err, "unsupported export format xlsx")
+}
+
+func TestNormalize(t *testing.T) {
+	value := int32(7)
+	var nilPointer *int32
+
+	tests := []struct {
+		kind    Kind
+		input   any
+		want    any
+		wantErr bool
+	}{
+		{kind: KindInt, input: &value, want: int64(7)},
+		{kind: KindInt, input: nilPointer, want: nil},
+		{kind: KindInt, input: []byte("42"), want: int64(42)},
+		{kind: KindInt, input: uint64(1 << 63), wantErr: true},
+		{kind: KindFloat, input: "1.25", want: 1.25},
+		{kind: KindBool, input: int64(0), want: false},
+		{kind: KindTimestamp, input: "2024-01-02 03:04:05", want: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{kind: KindTimestamp, input: "yesterday", wantErr: true},
+		{kind: KindBytes, input: "ab", want: []byte("ab")},
+		{kind: KindString, input: []byte("ab"), want: "ab"},
+		{kind: KindString, input: int64(1), want: "1"},
+		{kind: KindString, input: sql.NullString{}, want: nil},
+	}
+	for _, tt := range tests {
+		got, err := normalize(tt.kind, tt.input)
+		if tt.wantErr {
+			assert.Error(t, err, "%s %v", tt.kind, tt.input)
+			continue
+		}
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, got, "%s %v", tt.kind, tt.input)
+	}
+}
+
+func kindsOf(columns []Column) []Kind {
+	kinds := make([]Kind, len(columns))
+	for i, column := range columns {
+		kinds[i] = column.Kind
+	}
+	return kinds
+}
@@ -0,0 +1,400 @@
+package database
+
+import (
+	"bufio"
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Format string
+
+const (
+	FormatCSV     Format = "csv"
+	FormatParquet Format = "parquet"
+	FormatJSONL   Format = "jsonl"
+)
+
+// ParseFormat normalizes the value of the exportFormat option, an empty value
+// falls back to CSV.
+func ParseFormat(s string) (Format, error) {
+	switch format := Format(strings.ToLower(strings.TrimSpace(s))); format {
+	case "":
+		return FormatCSV, nil
+	case "ndjson":
+		return FormatJSONL, nil
+	case FormatCSV, FormatParquet, FormatJSONL:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unsupported export format %s, must be one of csv, parquet, jsonl", s)
+	}
+}
+
+// Kind is the type every value of a column is normalized to before being
+// written, regardless of the database it comes from.
+type Kind string
+
+const (
+	KindString    Kind = "string"
+	KindInt       Kind = "int"
+	KindFloat     Kind = "float"
+	KindBool      Kind = "bool"
+	KindTimestamp Kind = "timestamp"
+	KindBytes     Kind = "bytes"
+)
+
+type Column struct {
+	Name         string `json:"name"`
+	DatabaseType string `json:"databaseType"`
+	Kind         Kind   `json:"kind"`
+	Nullable     *bool  `json:"nullable,omitempty"`
+}
+
+func columnsOf(types []*sql.ColumnType) []Column {
+	columns := make([]Column, len(types))
+	for i, typ := range types {
+		columns[i] = Column{
+			Name:         typ.Name(),
+			DatabaseType: typ.DatabaseTypeName(),
+			Kind:         kindOf(typ.DatabaseTypeName(), typ.ScanType()),
+		}
+		if nullable, ok := typ.Nullable(); ok {
+			columns[i].Nullable = &nullable
+		}
+	}
+
+	return columns
+}
+
+var (
+	timeType      = reflect.TypeFor[time.Time]()
+	nullTimeType  = reflect.TypeFor[sql.NullTime]()
+	nullIntTypes  = []reflect.Type{reflect.TypeFor[sql.NullInt64](), reflect.TypeFor[sql.NullInt32](), reflect.TypeFor[sql.NullInt16](), reflect.TypeFor[sql.NullByte]()}
+	nullFloatType = reflect.TypeFor[sql.NullFloat64]()
+	nullBoolType  = reflect.TypeFor[sql.NullBool]()
+)
+
+func kindOf(databaseType string, scanType reflect.Type) Kind {
+	for scanType != nil && scanType.Kind() == reflect.Pointer {
+		scanType = scanType.Elem()
+	}
+	if scanType != nil {
+		if kind, ok := kindOfScanType(scanType); ok {
+			return kind
+		}
+	}
+
+	// drivers which do not know the type of a column ahead of the values,
+	// such as SQLite, scan it as text, or not at all
+	typ := strings.ToUpper(databaseType)
+	switch typ {
+	case "DATE", "DATETIME", "DATETIME2", "SMALLDATETIME", "DATETIMEOFFSET", "TIMESTAMP", "TIMESTAMPTZ":
+		return KindTimestamp
+	case "INTEGER", "INT", "BIGINT", "SMALLINT", "TINYINT", "MEDIUMINT", "INT2", "INT4", "INT8":
+		return KindInt
+	case "REAL", "FLOAT", "DOUBLE", "DOUBLE PRECISION", "FLOAT4", "FLOAT8":
+		return KindFloat
+	case "BOOLEAN", "BOOL":
+		return KindBool
+	}
+	for _, binary := range []string{"BLOB", "BINARY", "BYTEA", "IMAGE"} {
+		if strings.Contains(typ, binary) {
+			return KindBytes
+		}
+	}
+
+	return KindString
+}
+
+func kindOfScanType(scanType reflect.Type) (Kind, bool) {
+	switch scanType {
+	case timeType, nullTimeType:
+		return KindTimestamp, true
+	case nullFloatType:
+		return KindFloat, true
+	case nullBoolType:
+		return KindBool, true
+	}
+	for _, typ := range nullIntTypes {
+		if scanType == typ {
+			return KindInt, true
+		}
+	}
+
+	switch scanType.Kind() {
+	case reflect.Bool:
+		return KindBool, true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return KindInt, true
+	case reflect.Float32, reflect.Float64:
+		return KindFloat, true
+	}
+
+	return "", false
+}
+
+// normalize converts a value scanned from the database to the Go type of
+// kind: nil, string, int64, float64, bool, time.Time or []byte.
+func normalize(kind Kind, v any) (any, error) {
+	if valuer, ok := v.(driver.Valuer); ok {
+		var err error
+		v, err = valuer.Value()
+		if err != nil {
+			return nil, err
+		}
+	}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil, nil
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return nil, nil
+	}
+	v = rv.Interface()
+
+	var (
+		text   string
+		isText = true
+	)
+	switch {
+	case rv.Kind() == reflect.String:
+		text = rv.String()
+	case rv.Type() == reflect.TypeFor[[]byte]():
+		if kind == KindBytes {
+			return rv.Bytes(), nil
+		}
+		text = string(rv.Bytes())
+	default:
+		isText = false
+	}
+
+	switch kind {
+	case KindInt:
+		switch {
+		case rv.CanInt():
+			return rv.Int(), nil
+		case rv.CanUint():
+			if rv.Uint() > math.MaxInt64 {
+				return nil, fmt.Errorf("value %d overflows int64", rv.Uint())
+			}
+			return int64(rv.Uint()), nil // #nosec G115
+		case rv.Kind() == reflect.Bool:
+			return boolToInt(rv.Bool()), nil
+		case isText:
+			return strconv.ParseInt(text, 10, 64)
+		}
+	case KindFloat:
+		switch {
+		case rv.CanFloat():
+			return rv.Float(), nil
+		case rv.CanInt():
+			return float64(rv.Int()), nil
+		case rv.CanUint():
+			return float64(rv.Uint()), nil
+		case isText:
+			return strconv.ParseFloat(text, 64)
+		}
+	case KindBool:
+		switch {
+		case rv.Kind() == reflect.Bool:
+			return rv.Bool(), nil
+		case rv.CanInt():
+			return rv.Int() != 0, nil
+		case rv.CanUint():
+			return rv.Uint() != 0, nil
+		case isText:
+			return strconv.ParseBool(text)
+		}
+	case KindTimestamp:
+		switch value := v.(type) {
+		case time.Time:
+			return value, nil
+		case sql.NullTime:
+			if !value.Valid {
+				return nil, nil
+			}
+			return value.Time, nil
+		}
+		if isText {
+			return parseTime(text)
+		}
+	case KindBytes:
+		if isText {
+			return []byte(text), nil
+		}
+	default:
+		switch value := v.(type) {
+		case time.Time:
+			return value.Format(time.RFC3339Nano), nil
+		case fmt.Stringer:
+			return value.String(), nil
+		}
+		if isText {
+			return text, nil
+		}
+		return fmt.Sprint(v), nil
+	}
+
+	return nil, fmt.Errorf("can not convert %T to %s", v, kind)
+}
+
+func boolToInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	time.DateOnly,
+}
+
+func parseTime(s string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		t, err := time.Parse(layout, s)
+		if err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("can not parse %q as time", s)
+}
+
+// RowWriter writes normalized rows in one of the export formats.
+type RowWriter interface {
+	Write(values []any) error
+	// Close flushes buffered rows, it does not close the underlying writer.
+	Close() error
+}
+
+func NewRowWriter(format Format, w io.Writer, columns []Column) (RowWriter, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, columns)
+	case FormatJSONL:
+		return newJSONLWriter(w, columns), nil
+	case FormatParquet:
+		return newParquetWriter(w, columns)
+	default:
+		return nil, fmt.Errorf("unsupported export format %s", format)
+	}
+}
+
+// csvWriter writes RFC 4180 CSV with a header row. NULL is written as an
+// empty field, bytes are base64 encoded.
+type csvWriter struct {
+	w      *csv.Writer
+	record []string
+}
+
+func newCSVWriter(w io.Writer, columns []Column) (*csvWriter, error) {
+	res := &csvWriter{
+		w:      csv.NewWriter(w),
+		record: make([]string, len(columns)),
+	}
+	for i, column := range columns {
+		res.record[i] = column.Name
+	}
+	if err := res.w.Write(res.record); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (c *csvWriter) Write(values []any) error {
+	for i, v := range values {
+		c.record[i] = formatText(v)
+	}
+
+	return c.w.Write(c.record)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func formatText(v any) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case int64:
+		return strconv.FormatInt(value, 10)
+	case float64:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	case bool:
+		return strconv.FormatBool(value)
+	case time.Time:
+		return value.Format(time.RFC3339Nano)
+	case []byte:
+		return base64.StdEncoding.EncodeToString(value)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// jsonlWriter writes one JSON object per line, keeping the order of the
+// columns. NULL is written as null, bytes are base64 encoded.
+type jsonlWriter struct {
+	w    *bufio.Writer
+	keys [][]byte
+	buf  bytes.Buffer
+}
+
+func newJSONLWriter(w io.Writer, columns []Column) *jsonlWriter {
+	res := &jsonlWriter{
+		w:    bufio.NewWriter(w),
+		keys: make([][]byte, len(columns)),
+	}
+	for i, column := range columns {
+		// marshaling a string never fails
+		res.keys[i], _ = json.Marshal(column.Name)
+	}
+
+	return res
+}
+
+func (j *jsonlWriter) Write(values []any) error {
+	j.buf.Reset()
+	j.buf.WriteByte('{')
+	for i, v := range values {
+		if i > 0 {
+			j.buf.WriteByte(',')
+		}
+		j.buf.Write(j.keys[i])
+		j.buf.WriteByte(':')
+		value, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("failed to marshal column %s: %w", j.keys[i], err)
+		}
+		j.buf.Write(value)
+	}
+	j.buf.WriteString("}\n")
+
+	_, err := j.w.Write(j.buf.Bytes())
+	return err
+}
+
+func (j *jsonlWriter) Close() error {
+	return j.w.Flush()
+}
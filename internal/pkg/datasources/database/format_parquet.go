@@ -0,0 +1,109 @@
+package database
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// parquetWriter writes a Parquet file whose schema is derived from the kinds
+// of the columns, every column is optional so that NULLs are preserved.
+type parquetWriter struct {
+	w *parquet.Writer
+	// leaves maps the position of a column in the result set to the index of
+	// its leaf in the schema, which orders the fields by name.
+	leaves []int
+	kinds  []Kind
+	row    parquet.Row
+}
+
+func newParquetWriter(w io.Writer, columns []Column) (*parquetWriter, error) {
+	group := make(parquet.Group, len(columns))
+	for _, column := range columns {
+		if _, ok := group[column.Name]; ok {
+			return nil, fmt.Errorf("duplicate column %s is not supported by parquet", column.Name)
+		}
+		group[column.Name] = parquet.Optional(parquetNode(column.Kind))
+	}
+	schema := parquet.NewSchema("row", group)
+
+	res := &parquetWriter{
+		w:      parquet.NewWriter(w, schema, parquet.Compression(&parquet.Snappy)),
+		leaves: make([]int, len(columns)),
+		kinds:  make([]Kind, len(columns)),
+		row:    make(parquet.Row, len(columns)),
+	}
+	for i, column := range columns {
+		leaf, ok := schema.Lookup(column.Name)
+		if !ok {
+			return nil, fmt.Errorf("column %s not found in parquet schema", column.Name)
+		}
+		res.leaves[i] = leaf.ColumnIndex
+		res.kinds[i] = column.Kind
+	}
+
+	return res, nil
+}
+
+func parquetNode(kind Kind) parquet.Node {
+	switch kind {
+	case KindInt:
+		return parquet.Int(64)
+	case KindFloat:
+		return parquet.Leaf(parquet.DoubleType)
+	case KindBool:
+		return parquet.Leaf(parquet.BooleanType)
+	case KindTimestamp:
+		return parquet.Timestamp(parquet.Microsecond)
+	case KindBytes:
+		return parquet.Leaf(parquet.ByteArrayType)
+	default:
+		return parquet.String()
+	}
+}
+
+func (p *parquetWriter) Write(values []any) error {
+	for i, v := range values {
+		value, err := parquetValue(p.kinds[i], v)
+		if err != nil {
+			return err
+		}
+		definitionLevel := 1
+		if value.IsNull() {
+			definitionLevel = 0
+		}
+		p.row[p.leaves[i]] = value.Level(0, definitionLevel, p.leaves[i])
+	}
+
+	_, err := p.w.WriteRows([]parquet.Row{p.row})
+	return err
+}
+
+func parquetValue(kind Kind, v any) (parquet.Value, error) {
+	if v == nil {
+		return parquet.NullValue(), nil
+	}
+
+	switch value := v.(type) {
+	case int64:
+		return parquet.Int64Value(value), nil
+	case float64:
+		return parquet.DoubleValue(value), nil
+	case bool:
+		return parquet.BooleanValue(value), nil
+	case time.Time:
+		return parquet.Int64Value(value.UnixMicro()), nil
+	case []byte:
+		return parquet.ByteArrayValue(value), nil
+	case string:
+		return parquet.ByteArrayValue([]byte(value)), nil
+	default:
+		return parquet.Value{}, fmt.Errorf("can not write %T as parquet %s", v, kind)
+	}
+}
+
+func (p *parquetWriter) Close() error {
+	return p.w.Close()
+}
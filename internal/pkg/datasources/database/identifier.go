@@ -0,0 +1,39 @@
+package database
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const maxIdentifierLength = 128
+
+var identifierRegexp = regexp.MustCompile(`^[\p{L}_][\p{L}\p{N}_$]*$`)
+
+// ValidateIdentifier checks that name is a plain column or table name, or a
+// schema qualified table name such as public.users when qualified is true.
+// Identifiers are always quoted, validating them additionally rejects names
+// which are most likely a mistake, such as SQL fragments.
+func ValidateIdentifier(name string, qualified bool) error {
+	parts := []string{name}
+	if qualified {
+		parts = strings.Split(name, ".")
+	}
+	for _, part := range parts {
+		if len(part) > maxIdentifierLength || !identifierRegexp.MatchString(part) {
+			return fmt.Errorf("invalid identifier %q, must start with a letter or underscore and only contain letters, digits, underscores or $", name)
+		}
+	}
+
+	return nil
+}
+
+// QuoteQualifiedIdentifier quotes every part of a schema qualified name.
+func QuoteQualifiedIdentifier(driver Driver, name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = driver.QuoteIdentifier(part)
+	}
+
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,60 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+type mysqlDriver struct{}
+
+func (d *mysqlDriver) Open(opts ConnectionOptions) (*sql.DB, error) {
+	cfg := mysql.NewConfig()
+	cfg.User = opts.Username
+	cfg.Passwd = opts.Password
+	cfg.Net = "tcp"
+	cfg.Addr = net.JoinHostPort(opts.Host, opts.portOrDefault("3306"))
+	cfg.DBName = opts.Database
+	cfg.ParseTime = true
+	cfg.TLS = opts.TLS
+	if opts.Charset != "" {
+		err := cfg.Apply(mysql.Charset(opts.Charset, ""))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	connector, err := mysql.NewConnector(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return sql.OpenDB(connector), nil
+}
+
+func (d *mysqlDriver) QuoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+func (d *mysqlDriver) Placeholder(int) string {
+	return "?"
+}
+
+func (d *mysqlDriver) Limit(query string, limit int) string {
+	return fmt.Sprintf("%s LIMIT %d", query, limit)
+}
+
+func (d *mysqlDriver) PrimaryKey(ctx context.Context, db *sql.DB, table string) ([]string, error) {
+	schema, name := "", table
+	if i := strings.LastIndex(table, "."); i >= 0 {
+		schema, name = table[:i], table[i+1:]
+	}
+
+	return queryStrings(ctx, db, `SELECT COLUMN_NAME FROM information_schema.KEY_COLUMN_USAGE
+WHERE TABLE_SCHEMA = COALESCE(NULLIF(?, ''), DATABASE()) AND TABLE_NAME = ? AND CONSTRAINT_NAME = 'PRIMARY'
+ORDER BY ORDINAL_POSITION`, schema, name)
+}
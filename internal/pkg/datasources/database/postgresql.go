@@ -0,0 +1,61 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+type postgresqlDriver struct{}
+
+func (d *postgresqlDriver) Open(opts ConnectionOptions) (*sql.DB, error) {
+	u := url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(opts.Username, opts.Password),
+		Host:   net.JoinHostPort(opts.Host, opts.portOrDefault("5432")),
+		Path:   "/" + opts.Database,
+	}
+	query := url.Values{}
+	// TLS is configured below with the tls.Config, rather than letting pgx
+	// derive it from sslmode, in order to share the same semantics and CA
+	// handling with the other drivers.
+	query.Set("sslmode", "disable")
+	if opts.Charset != "" {
+		query.Set("client_encoding", opts.Charset)
+	}
+	u.RawQuery = query.Encode()
+
+	cfg, err := pgx.ParseConfig(u.String())
+	if err != nil {
+		return nil, err
+	}
+	cfg.TLSConfig = opts.TLS
+	cfg.Fallbacks = nil
+
+	return stdlib.OpenDB(*cfg), nil
+}
+
+func (d *postgresqlDriver) QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (d *postgresqlDriver) Placeholder(n int) string {
+	return fmt.Sprintf("$%d", n)
+}
+
+func (d *postgresqlDriver) Limit(query string, limit int) string {
+	return fmt.Sprintf("%s LIMIT %d", query, limit)
+}
+
+func (d *postgresqlDriver) PrimaryKey(ctx context.Context, db *sql.DB, table string) ([]string, error) {
+	return queryStrings(ctx, db, `SELECT a.attname FROM pg_index i
+JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey)
+WHERE i.indrelid = $1::regclass AND i.indisprimary
+ORDER BY array_position(i.indkey::int2[], a.attnum)`, table)
+}
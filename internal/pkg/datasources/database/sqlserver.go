@@ -0,0 +1,65 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	mssql "github.com/microsoft/go-mssqldb"
+	"github.com/microsoft/go-mssqldb/msdsn"
+)
+
+type sqlserverDriver struct{}
+
+func (d *sqlserverDriver) Open(opts ConnectionOptions) (*sql.DB, error) {
+	port, err := strconv.ParseUint(opts.portOrDefault("1433"), 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port %s: %w", opts.Port, err)
+	}
+
+	u := url.URL{
+		Scheme: "sqlserver",
+		User:   url.UserPassword(opts.Username, opts.Password),
+		Host:   fmt.Sprintf("%s:%d", opts.Host, port),
+	}
+	query := url.Values{}
+	query.Set("database", opts.Database)
+	query.Set("encrypt", "disable")
+	u.RawQuery = query.Encode()
+
+	cfg, err := msdsn.Parse(u.String())
+	if err != nil {
+		return nil, err
+	}
+	if opts.TLS != nil {
+		cfg.Encryption = msdsn.EncryptionRequired
+		cfg.TLSConfig = opts.TLS
+	}
+
+	return sql.OpenDB(mssql.NewConnectorConfig(cfg)), nil
+}
+
+func (d *sqlserverDriver) QuoteIdentifier(name string) string {
+	return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+}
+
+func (d *sqlserverDriver) Placeholder(n int) string {
+	return fmt.Sprintf("@p%d", n)
+}
+
+// Limit relies on OFFSET ... FETCH, which is only allowed after an ORDER BY
+// clause in SQL Server.
+func (d *sqlserverDriver) Limit(query string, limit int) string {
+	return fmt.Sprintf("%s OFFSET 0 ROWS FETCH NEXT %d ROWS ONLY", query, limit)
+}
+
+func (d *sqlserverDriver) PrimaryKey(ctx context.Context, db *sql.DB, table string) ([]string, error) {
+	return queryStrings(ctx, db, `SELECT c.name FROM sys.indexes i
+JOIN sys.index_columns ic ON ic.object_id = i.object_id AND ic.index_id = i.index_id
+JOIN sys.columns c ON c.object_id = ic.object_id AND c.column_id = ic.column_id
+WHERE i.is_primary_key = 1 AND i.object_id = OBJECT_ID(@p1)
+ORDER BY ic.key_ordinal`, table)
+}
@@ -0,0 +1,111 @@
+package database
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"strings"
+)
+
+type TLSMode string
+
+const (
+	// TLSModeDisable connects without TLS.
+	TLSModeDisable TLSMode = "disable"
+	// TLSModeRequire encrypts the connection but does not verify the server
+	// certificate.
+	TLSModeRequire TLSMode = "require"
+	// TLSModeVerifyCA verifies that the server certificate is signed by a
+	// trusted CA, but does not check the host name.
+	TLSModeVerifyCA TLSMode = "verify-ca"
+	// TLSModeVerifyFull verifies both the certificate chain and the host name.
+	TLSModeVerifyFull TLSMode = "verify-full"
+)
+
+func ParseTLSMode(s string) (TLSMode, error) {
+	switch mode := TLSMode(strings.ToLower(strings.TrimSpace(s))); mode {
+	case "", "false":
+		return TLSModeDisable, nil
+	case "true":
+		return TLSModeVerifyFull, nil
+	case TLSModeDisable, TLSModeRequire, TLSModeVerifyCA, TLSModeVerifyFull:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid tls mode %s, must be one of disable, require, verify-ca, verify-full", s)
+	}
+}
+
+type TLSOptions struct {
+	Mode       TLSMode
+	ServerName string
+
+	// PEM encoded CA bundle used to verify the server certificate, the
+	// system pool is used when empty.
+	CACert string
+	// PEM encoded client certificate and key for mutual TLS, both optional.
+	ClientCert string
+	ClientKey  string
+}
+
+// NewTLSConfig builds the tls.Config shared by all drivers, it returns nil
+// when TLS is disabled.
+func NewTLSConfig(opts TLSOptions) (*tls.Config, error) {
+	if opts.Mode == "" || opts.Mode == TLSModeDisable {
+		return nil, nil
+	}
+
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: opts.ServerName,
+	}
+
+	if strings.TrimSpace(opts.CACert) != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(opts.CACert)) {
+			return nil, fmt.Errorf("failed to parse CA certificate, no PEM encoded certificates found")
+		}
+
+		config.RootCAs = pool
+	}
+
+	if opts.ClientCert != "" || opts.ClientKey != "" {
+		cert, err := tls.X509KeyPair([]byte(opts.ClientCert), []byte(opts.ClientKey))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate, err: %w", err)
+		}
+
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	switch opts.Mode {
+	case TLSModeRequire:
+		config.InsecureSkipVerify = true // #nosec G402
+	case TLSModeVerifyCA:
+		// crypto/tls can only verify the chain and the host name together,
+		// hence verify the chain by ourselves and skip the built-in check.
+		config.InsecureSkipVerify = true // #nosec G402
+		config.VerifyConnection = verifyChainOnly(config.RootCAs)
+	}
+
+	return config, nil
+}
+
+func verifyChainOnly(roots *x509.CertPool) func(tls.ConnectionState) error {
+	return func(state tls.ConnectionState) error {
+		if len(state.PeerCertificates) == 0 {
+			return fmt.Errorf("server did not present a certificate")
+		}
+
+		intermediates := x509.NewCertPool()
+		for _, cert := range state.PeerCertificates[1:] {
+			intermediates.AddCert(cert)
+		}
+
+		_, err := state.PeerCertificates[0].Verify(x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+		})
+
+		return err
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"net/url"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"strings"
 
@@ -13,9 +14,11 @@ import (
 	"github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v3"
 
+	"github.com/BaizeAI/dataset/internal/pkg/blobcache"
 	"github.com/BaizeAI/dataset/internal/pkg/constants"
 	"github.com/BaizeAI/dataset/internal/pkg/datasources/conda"
 	"github.com/BaizeAI/dataset/internal/pkg/datasources/pip"
+	"github.com/BaizeAI/dataset/internal/pkg/jobresult"
 	"github.com/BaizeAI/dataset/pkg/log"
 	"github.com/BaizeAI/dataset/pkg/utils"
 )
@@ -27,10 +30,37 @@ type CondaLoaderOptions struct {
 	PipExtraIndexURL        string `json:"pipExtraIndexUrl"`
 	CondaEnvironmentYmlPath string `json:"condaEnvironmentYmlPath"`
 	PipRequirementsTxtPath  string `json:"pipRequirementsTxtPath"`
+	CondaLockYmlPath        string `json:"condaLockYmlPath"`
+	CondaExplicitTxtPath    string `json:"condaExplicitTxtPath"`
 	CondaPrefixDir          string `json:"condaPrefixDir"`
+	// Platform of the packages installed from conda-lock.yml, defaults to the
+	// platform of the data loader, e.g. linux-64.
+	Platform string `json:"platform"`
+
+	// PipExtraIndexURLs are comma separated, in addition to PipExtraIndexURL.
+	PipExtraIndexURLs string `json:"pipExtraIndexUrls"`
+	// PipTrustedHosts are comma separated, in addition to the hosts of the
+	// indexes which are always trusted.
+	PipTrustedHosts string `json:"pipTrustedHosts"`
+	// PipIndexAuth are the credentials of the indexes, see parseURLAuths.
+	PipIndexAuth string `json:"pipIndexAuth"`
+	// Channels are comma separated, and replace the channels of environment.yml.
+	Channels string `json:"channels"`
+	// ChannelPriority is strict, flexible or disabled.
+	ChannelPriority string `json:"channelPriority"`
+	// ChannelAuth are the credentials of the channels, see parseURLAuths.
+	ChannelAuth string `json:"channelAuth"`
 
 	condaEnvironmentYml string
 	pipRequirementsTxt  string
+	condaLockYml        string
+	condaExplicitTxt    string
+
+	pipExtraIndexURLs []string
+	pipTrustedHosts   []string
+	pipIndexAuths     []urlAuth
+	channels          []string
+	channelAuths      []urlAuth
 
 	prefixingPkgsDir string
 	prefixingEnvsDir string
@@ -59,12 +89,41 @@ func (o *CondaLoaderOptions) parseOptionsFromOptions(rawOptions map[string]strin
 		return CondaLoaderOptions{}, fmt.Errorf("missing required options %s", strings.Join(required, ", "))
 	}
 
+	switch loaderOptions.ChannelPriority {
+	case "", "strict", "flexible", "disabled":
+	default:
+		return CondaLoaderOptions{}, fmt.Errorf("invalid channelPriority %s, only strict, flexible and disabled are supported", loaderOptions.ChannelPriority)
+	}
+	if loaderOptions.PipExtraIndexURLs != "" {
+		loaderOptions.pipExtraIndexURLs = splitAndTrim(loaderOptions.PipExtraIndexURLs)
+	}
+	if loaderOptions.PipTrustedHosts != "" {
+		loaderOptions.pipTrustedHosts = splitAndTrim(loaderOptions.PipTrustedHosts)
+	}
+	if loaderOptions.Channels != "" {
+		loaderOptions.channels = splitAndTrim(loaderOptions.Channels)
+	}
+	loaderOptions.pipIndexAuths, err = parseURLAuths(loaderOptions.PipIndexAuth)
+	if err != nil {
+		return CondaLoaderOptions{}, fmt.Errorf("invalid pipIndexAuth: %w", err)
+	}
+	loaderOptions.channelAuths, err = parseURLAuths(loaderOptions.ChannelAuth)
+	if err != nil {
+		return CondaLoaderOptions{}, fmt.Errorf("invalid channelAuth: %w", err)
+	}
+
 	if loaderOptions.CondaEnvironmentYmlPath == "" {
 		loaderOptions.CondaEnvironmentYmlPath = constants.DatasetJobCondaCondaEnvironmentYAMLPath
 	}
 	if loaderOptions.PipRequirementsTxtPath == "" {
 		loaderOptions.PipRequirementsTxtPath = constants.DatasetJobCondaPipRequirementsTxtPath
 	}
+	if loaderOptions.CondaLockYmlPath == "" {
+		loaderOptions.CondaLockYmlPath = constants.DatasetJobCondaLockYAMLPath
+	}
+	if loaderOptions.CondaExplicitTxtPath == "" {
+		loaderOptions.CondaExplicitTxtPath = constants.DatasetJobCondaExplicitTxtPath
+	}
 	if loaderOptions.CondaPrefixDir == "" {
 		loaderOptions.CondaPrefixDir = constants.DatasetJobCondaMountDir
 	}
@@ -82,15 +141,54 @@ func (o *CondaLoaderOptions) envPrefix() string {
 	return filepath.Join(o.prefixingEnvsDir, o.Name)
 }
 
+func (o *CondaLoaderOptions) platform() string {
+	return lo.CoalesceOrEmpty(o.Platform, conda.CurrentPlatform())
+}
+
+// manifestPath is where the manifest of the resolved packages is written, next
+// to the env in the mount root.
+func (o *CondaLoaderOptions) manifestPath() string {
+	return filepath.Join(o.finalEnvsDir, o.Name+".manifest.json")
+}
+
+func (o *CondaLoaderOptions) locked() bool {
+	return o.condaLockYml != "" || o.condaExplicitTxt != ""
+}
+
 func (o *CondaLoaderOptions) extraIndexURLs() []string {
-	if o.PipExtraIndexURL == "" {
-		return make([]string, 0)
+	extraIndexURLs := make([]string, 0, 1+len(o.pipExtraIndexURLs))
+	if o.PipExtraIndexURL != "" {
+		extraIndexURLs = append(extraIndexURLs, o.PipExtraIndexURL)
+	}
+	extraIndexURLs = append(extraIndexURLs, o.pipExtraIndexURLs...)
+
+	return lo.Map(extraIndexURLs, func(extraIndexURL string, _ int) string {
+		return withIndexAuth(o.pipIndexAuths, extraIndexURL)
+	})
+}
+
+func (o *CondaLoaderOptions) indexURL() string {
+	if o.PipIndexURL == "" {
+		return ""
+	}
+
+	return withIndexAuth(o.pipIndexAuths, o.PipIndexURL)
+}
+
+// secrets are the credentials of the channels and indexes, to be obscured.
+func (o *CondaLoaderOptions) secrets() []string {
+	var secrets []string
+	for _, auth := range append(o.channelAuths, o.pipIndexAuths...) {
+		secrets = append(secrets, auth.secrets()...)
 	}
 
-	return []string{o.PipExtraIndexURL}
+	return secrets
 }
 
-var _ Loader = &CondaLoader{}
+var (
+	_ ResultLoader    = &CondaLoader{}
+	_ BlobCacheLoader = &CondaLoader{}
+)
 
 type CondaLoader struct {
 	Options Options
@@ -98,6 +196,8 @@ type CondaLoader struct {
 	loaderOptions CondaLoaderOptions
 	mamba         *conda.MambaCLI
 	pip           *pip.PipCLI
+	result        jobresult.Result
+	blobCache     *blobcache.Cache
 }
 
 func NewCondaLoader(datasourceOption map[string]string, options Options, secrets Secrets) (*CondaLoader, error) {
@@ -109,15 +209,63 @@ func NewCondaLoader(datasourceOption map[string]string, options Options, secrets
 		return nil, err
 	}
 
+	for i := range loaderOptions.channelAuths {
+		err = loaderOptions.channelAuths[i].resolve(secrets)
+		if err != nil {
+			return nil, fmt.Errorf("invalid channelAuth: %w", err)
+		}
+	}
+	for i := range loaderOptions.pipIndexAuths {
+		err = loaderOptions.pipIndexAuths[i].resolve(secrets)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pipIndexAuth: %w", err)
+		}
+	}
+
 	loader.loaderOptions = loaderOptions
 
 	loader.mamba = conda.NewMambaCLI()
+	loader.mamba.Secrets = loader.loaderOptions.secrets()
 	loader.pip = pip.NewPipCLIWithCondaEnv(loader.loaderOptions.envPrefix())
+	loader.pip.Secrets = loader.loaderOptions.secrets()
 	loader.tryReadFile()
 
 	return loader, nil
 }
 
+func (l *CondaLoader) SetBlobCache(cache *blobcache.Cache) {
+	l.blobCache = cache
+}
+
+// explicitBlobs returns the package tarballs of an @EXPLICIT spec file keyed
+// by their MD5 or SHA256, as they are downloaded into the pkgs dir. Only the
+// packages of locked environments are known before they are installed.
+func explicitBlobs(explicit string) []blobcache.Blob {
+	var blobs []blobcache.Blob
+	for _, line := range strings.Split(explicit, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "@") {
+			continue
+		}
+		rawURL, hash, ok := strings.Cut(line, "#")
+		if !ok || hash == "" {
+			continue
+		}
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			continue
+		}
+
+		key := blobcache.MD5Key(hash)
+		if sum, ok := strings.CutPrefix(hash, "sha256:"); ok {
+			key = blobcache.SHA256Key(sum)
+		}
+		blobs = append(blobs, blobcache.Blob{Path: path.Base(u.Path), Key: key})
+	}
+
+	return blobs
+}
+
 func (l *CondaLoader) tryReadFile() {
 	logger := log.WithFields(logrus.Fields{
 		"condaEnvironmentYmlPath": l.loaderOptions.condaEnvironmentYml,
@@ -139,6 +287,29 @@ func (l *CondaLoader) tryReadFile() {
 	if err == nil {
 		l.loaderOptions.pipRequirementsTxt = string(pipRequirementsTxt)
 	}
+
+	// lock files are optional, only mounted when supplied
+	condaLockYml, err := os.ReadFile(l.loaderOptions.CondaLockYmlPath)
+	if err != nil && !os.IsNotExist(err) {
+		logger.WithError(err).Error("Failed to read conda-lock.yml")
+	}
+	if err == nil {
+		l.loaderOptions.condaLockYml = string(condaLockYml)
+	}
+
+	condaExplicitTxt, err := os.ReadFile(l.loaderOptions.CondaExplicitTxtPath)
+	if err != nil && !os.IsNotExist(err) {
+		logger.WithError(err).Error("Failed to read conda explicit spec")
+	}
+	if err == nil {
+		l.loaderOptions.condaExplicitTxt = string(condaExplicitTxt)
+	}
+}
+
+// Result reports the digest of the manifest of the packages resolved in the
+// environment.
+func (l *CondaLoader) Result() jobresult.Result {
+	return l.result
 }
 
 func (l *CondaLoader) writeTemp(logger *logrus.Entry, fileName string, content []byte) (string, func(), error) {
@@ -201,6 +372,7 @@ func normalizeEnvironmentYaml(
 	pythonVersion,
 	pipIndexURL string,
 	pipExtraIndexURL []string,
+	pipTrustedHosts []string,
 	prefix string,
 ) (map[string]any, error) {
 	if environment == nil {
@@ -220,7 +392,7 @@ func normalizeEnvironmentYaml(
 	environment["default_threads"] = 4
 
 	// remove python=* and pip=* and assign python and pip with specified version and pipIndexURL
-	return assignEssentialDependencies(environment, pythonVersion, pipIndexURL, pipExtraIndexURL)
+	return assignEssentialDependencies(environment, pythonVersion, pipIndexURL, pipExtraIndexURL, pipTrustedHosts)
 }
 
 // In order to persist the --extra-index-url in the environment.yml, and allow users to use and share the
@@ -236,7 +408,7 @@ func normalizeEnvironmentYaml(
 //
 // python - How to specify pip --extra-index-url in environment.yml? - Stack Overflow
 // https://stackoverflow.com/questions/73287475/how-to-specify-pip-extra-index-url-in-environment-yml
-func assignPipArgumentsIfHaveAny(dependencies []any, pipIndexURL string, pipExtraIndexURLs []string) ([]any, error) {
+func assignPipArgumentsIfHaveAny(dependencies []any, pipIndexURL string, pipExtraIndexURLs []string, pipTrustedHosts []string) ([]any, error) {
 	// 1 reserved arguments for --index-url
 	// multiplied by 2 for --trusted-host diverged from --index-url and --extra-index-url
 	// therefore: [(--index-url), (--trusted-host), (--extra-index-url), (--trusted-host), (--extra-index-url), (--trusted-host)...]
@@ -269,6 +441,7 @@ func assignPipArgumentsIfHaveAny(dependencies []any, pipIndexURL string, pipExtr
 		}
 	}
 
+	trustedHosts = appendTrustedHosts(trustedHosts, pipTrustedHosts)
 	pipArguments = append(pipArguments, lo.Map(trustedHosts, func(trustedHost string, _ int) string {
 		return exec.Command("--trusted-host", trustedHost).String()
 	})...)
@@ -285,7 +458,7 @@ func assignPipArgumentsIfHaveAny(dependencies []any, pipIndexURL string, pipExtr
 }
 
 // subtle utility function to assign python and pip with specified version and pipIndexURL
-func assignEssentialDependencies(environment map[string]any, pythonVersion, pipIndexURL string, pipExtraIndexURL []string) (map[string]any, error) {
+func assignEssentialDependencies(environment map[string]any, pythonVersion, pipIndexURL string, pipExtraIndexURL []string, pipTrustedHosts []string) (map[string]any, error) {
 	defaultDependenciesItems := []any{
 		"python=" + pythonVersion, // required
 		"pip",                     // required
@@ -299,7 +472,7 @@ func assignEssentialDependencies(environment map[string]any, pythonVersion, pipI
 	if !ok {
 		// fallback to default dependencies
 		dependencies := defaultDependenciesItems
-		dependencies, err := assignPipArgumentsIfHaveAny(dependencies, pipIndexURL, pipExtraIndexURL)
+		dependencies, err := assignPipArgumentsIfHaveAny(dependencies, pipIndexURL, pipExtraIndexURL, pipTrustedHosts)
 		if err != nil {
 			return nil, err
 		}
@@ -329,7 +502,7 @@ func assignEssentialDependencies(environment map[string]any, pythonVersion, pipI
 	})
 
 	dependencies = append(dependencies, defaultDependenciesItems...)
-	dependencies, err := assignPipArgumentsIfHaveAny(dependencies, pipIndexURL, pipExtraIndexURL)
+	dependencies, err := assignPipArgumentsIfHaveAny(dependencies, pipIndexURL, pipExtraIndexURL, pipTrustedHosts)
 	if err != nil {
 		return nil, err
 	}
@@ -354,14 +527,14 @@ Render pip.conf
 About pip.conf: https://pip.pypa.io/en/stable/topics/configuration/#configuration
 About repeatable options: https://pip.pypa.io/en/stable/topics/configuration/#repeatable-options
 */
-func renderPipConfig(pipIndexURL string, pipExtraIndexURLs []string) (string, error) {
+func renderPipConfig(pipIndexURL string, pipExtraIndexURLs []string, pipTrustedHosts []string) (string, error) {
 	pipExtraIndexURLs = lo.Filter(pipExtraIndexURLs, func(extraIndexURL string, _ int) bool {
 		return extraIndexURL != ""
 	})
 
 	var sb strings.Builder
 	sb.WriteString("[global]\n")
-	if pipIndexURL == "" && len(pipExtraIndexURLs) == 0 {
+	if pipIndexURL == "" && len(pipExtraIndexURLs) == 0 && len(pipTrustedHosts) == 0 {
 		return sb.String(), nil
 	}
 
@@ -404,6 +577,7 @@ func renderPipConfig(pipIndexURL string, pipExtraIndexURLs []string) (string, er
 			)
 		}
 	}
+	trustedHosts = appendTrustedHosts(trustedHosts, pipTrustedHosts)
 	if len(trustedHosts) > 0 {
 		if len(trustedHosts) == 1 {
 			fmt.Fprintf(&sb, "trusted-host = %s\n", trustedHosts[0])
@@ -415,6 +589,17 @@ func renderPipConfig(pipIndexURL string, pipExtraIndexURLs []string) (string, er
 	return sb.String(), nil
 }
 
+// appendTrustedHosts appends the hosts which are not trusted yet.
+func appendTrustedHosts(trustedHosts []string, hosts []string) []string {
+	for _, host := range hosts {
+		if !lo.Contains(trustedHosts, host) {
+			trustedHosts = append(trustedHosts, host)
+		}
+	}
+
+	return trustedHosts
+}
+
 func (l *CondaLoader) cleanupNonExistingSymlinks(logger *logrus.Entry) error {
 	// The reason why we need to clean up non-existing symlinks is that the rclone may fail to copy the symlinks
 	// if the target file does not exist. For example, the following error may occur:
@@ -497,7 +682,12 @@ func (l *CondaLoader) moveToMountRoot(logger *logrus.Entry) error {
 //   - conda config --prepend pkgs_dirs /opt/baize-runtime-env/conda/pkgs
 //   - conda config --prepend envs_dirs /opt/baize-runtime-env/conda/envs
 //
-// if environment.yml exists:
+// if conda-lock.yml or an @EXPLICIT spec file exists:
+//   - render the explicit spec of the platform from conda-lock.yml
+//   - conda create --prefix <env> --file explicit.txt -y
+//   - pip install --no-deps the pip packages of conda-lock.yml
+//
+// else if environment.yml exists:
 //   - read environment.yml
 //   - normalize environment.yml
 //   - conda env create -f environment.yml
@@ -514,6 +704,7 @@ func (l *CondaLoader) moveToMountRoot(logger *logrus.Entry) error {
 // finalize the conda environment:
 //   - mv /opt/baize-runtime-env/conda/pkgs ${mount-root}/conda/pkgs
 //   - mv /opt/baize-runtime-env/conda/envs ${mount-root}/conda/envs
+//   - conda list --json, written to ${mount-root}/conda/envs/<name>.manifest.json
 func (l *CondaLoader) Sync(_ string, _ string) error {
 	logger := log.WithFields(logrus.Fields{
 		"type":                        TypeConda,
@@ -539,6 +730,10 @@ func (l *CondaLoader) Sync(_ string, _ string) error {
 		l.mamba.ConfigPrependPkgsDir(logger, l.loaderOptions.prefixingPkgsDir)
 		// Configure conda envs dir
 		l.mamba.ConfigPrependEnvsDir(logger, l.loaderOptions.prefixingEnvsDir)
+		// Configure conda channel priority
+		if l.loaderOptions.ChannelPriority != "" {
+			l.mamba.ConfigSetChannelPriority(logger, l.loaderOptions.ChannelPriority)
+		}
 	}
 
 	// Get conda info
@@ -555,7 +750,88 @@ func (l *CondaLoader) Sync(_ string, _ string) error {
 		return err
 	}
 
+	if l.loaderOptions.locked() {
+		err = l.createEnvFromLock(logger)
+	} else {
+		err = l.createEnvFromEnvironmentYaml(logger)
+	}
+	if err != nil {
+		return err
+	}
+
+	if l.loaderOptions.pipRequirementsTxt != "" {
+		if l.loaderOptions.PipIndexURL != "" || len(l.loaderOptions.extraIndexURLs()) > 0 || len(l.loaderOptions.pipTrustedHosts) > 0 {
+			pipConfig, err := renderPipConfig(
+				l.loaderOptions.indexURL(),
+				l.loaderOptions.extraIndexURLs(),
+				l.loaderOptions.pipTrustedHosts,
+			)
+			if err != nil {
+				logger.WithError(err).Error("Failed to render pip config")
+				return err
+			}
+
+			pipConfigFilePath, cleanup, err := l.writeTemp(logger, "pip.conf", []byte(pipConfig))
+			if err != nil {
+				logger.WithError(err).Error("Failed to write temp pip config file")
+				return err
+			}
+			defer cleanup()
+
+			l.pip.ConfigFilePath = pipConfigFilePath
+		}
+
+		requirementsFilePath, cleanup, err := l.writeTemp(logger, "requirements.txt", []byte(l.loaderOptions.pipRequirementsTxt))
+		if err != nil {
+			logger.WithError(err).Error("Failed to write temp requirements file")
+			return err
+		}
+		defer cleanup()
+
+		// Install requirements
+		err = l.pip.InstallWithRequirementsTxt(logger, requirementsFilePath)
+		if err != nil {
+			logger.WithError(err).Error("Failed to install requirements")
+			return err
+		}
+	}
+
+	err = l.mamba.CleanAll(logger)
+	if err != nil {
+		logger.WithError(err).Error("Failed to cleanup all packages, index cache, and tarballs, etc.")
+		return err
+	}
+
+	err = l.cleanupNonExistingSymlinks(logger)
+	if err != nil {
+		logger.WithError(err).Error("Failed to cleanup non-existing symlinks")
+		return err
+	}
+
+	packages, err := l.mamba.List(logger, l.loaderOptions.envPrefix())
+	if err != nil {
+		logger.WithError(err).Error("Failed to list packages of conda env")
+		return err
+	}
+
+	err = l.moveToMountRoot(logger)
+	if err != nil {
+		logger.WithError(err).Error("Failed to move conda envs and pkgs to mount root")
+		return err
+	}
+
+	err = l.writeManifest(logger, packages)
+	if err != nil {
+		logger.WithError(err).Error("Failed to write manifest of conda env")
+		return err
+	}
+
+	return nil
+}
+
+func (l *CondaLoader) createEnvFromEnvironmentYaml(logger *logrus.Entry) error {
 	var environment map[string]any
+	var err error
 
 	if l.loaderOptions.condaEnvironmentYml != "" {
 		err = yaml.Unmarshal([]byte(l.loaderOptions.condaEnvironmentYml), &environment)
@@ -568,19 +844,31 @@ func (l *CondaLoader) Sync(_ string, _ string) error {
 	} else {
 		environment = make(map[string]any)
 	}
+	if len(l.loaderOptions.channels) > 0 {
+		environment["channels"] = lo.ToAnySlice(l.loaderOptions.channels)
+	}
 
 	environment, err = normalizeEnvironmentYaml(
 		environment,
 		l.loaderOptions.Name,
 		l.loaderOptions.PythonVersion,
-		l.loaderOptions.PipIndexURL,
+		l.loaderOptions.indexURL(),
 		l.loaderOptions.extraIndexURLs(),
+		l.loaderOptions.pipTrustedHosts,
 		l.loaderOptions.envPrefix(),
 	)
 	if err != nil {
 		logger.WithError(err).Error("Failed to normalize environment")
 		return err
 	}
+	if channels, ok := environment["channels"].([]any); ok {
+		environment["channels"] = lo.Map(channels, func(channel any, _ int) any {
+			if channelStr, ok := channel.(string); ok {
+				return withChannelAuth(l.loaderOptions.channelAuths, channelStr)
+			}
+			return channel
+		})
+	}
 
 	environmentYAMLData, err := yaml.Marshal(environment)
 	if err != nil {
@@ -588,7 +876,7 @@ func (l *CondaLoader) Sync(_ string, _ string) error {
 		return err
 	}
 
-	fmt.Printf("new modified environment:\n%s\n", lo.Must(yaml.Marshal(environment)))
+	fmt.Printf("new modified environment:\n%s\n", utils.ObscureString(string(environmentYAMLData), l.loaderOptions.secrets()))
 
 	environmentFilePath, cleanup, err := l.writeTemp(logger, "environment.yml", environmentYAMLData)
 	if err != nil {
@@ -603,59 +891,109 @@ func (l *CondaLoader) Sync(_ string, _ string) error {
 		return err
 	}
 
-	if l.loaderOptions.pipRequirementsTxt != "" {
-		if l.loaderOptions.PipIndexURL != "" || l.loaderOptions.PipExtraIndexURL != "" {
-			pipConfig, err := renderPipConfig(
-				l.loaderOptions.PipIndexURL,
-				l.loaderOptions.extraIndexURLs(),
-			)
-			if err != nil {
-				logger.WithError(err).Error("Failed to render pip config")
-				return err
-			}
-
-			pipConfigFilePath, cleanup, err := l.writeTemp(logger, "pip.conf", []byte(pipConfig))
-			if err != nil {
-				logger.WithError(err).Error("Failed to write temp pip config file")
-				return err
-			}
-			defer cleanup()
+	return nil
+}
 
-			l.pip.ConfigFilePath = pipConfigFilePath
-		}
+// createEnvFromLock installs the packages pinned by conda-lock.yml or by an
+// @EXPLICIT spec file without solving, pip packages of conda-lock.yml are
+// installed without their dependencies since those are locked as well.
+func (l *CondaLoader) createEnvFromLock(logger *logrus.Entry) error {
+	if l.loaderOptions.condaLockYml != "" && l.loaderOptions.condaExplicitTxt != "" {
+		return fmt.Errorf("only one of %s and %s can be supplied", constants.DatasetJobCondaLockYAMLFilename, constants.DatasetJobCondaExplicitTxtFilename)
+	}
+	if l.loaderOptions.condaEnvironmentYml != "" {
+		logger.Warn("environment.yml is ignored when installing from a lock file")
+	}
 
-		requirementsFilePath, cleanup, err := l.writeTemp(logger, "requirements.txt", []byte(l.loaderOptions.pipRequirementsTxt))
+	explicit := l.loaderOptions.condaExplicitTxt
+	var pipRequirements string
+	if l.loaderOptions.condaLockYml != "" {
+		lockFile, err := conda.ParseLockFile([]byte(l.loaderOptions.condaLockYml))
 		if err != nil {
-			logger.WithError(err).Error("Failed to write temp requirements file")
 			return err
 		}
-		defer cleanup()
 
-		// Install requirements
-		err = l.pip.InstallWithRequirementsTxt(logger, requirementsFilePath)
+		explicit, pipRequirements, err = lockFile.Render(l.loaderOptions.platform())
 		if err != nil {
-			logger.WithError(err).Error("Failed to install requirements")
 			return err
 		}
+	} else if !conda.IsExplicitSpec(explicit) {
+		return fmt.Errorf("%s is not an @EXPLICIT spec file", constants.DatasetJobCondaExplicitTxtFilename)
 	}
 
-	err = l.mamba.CleanAll(logger)
+	fmt.Printf("locked packages:\n%s\n", explicit)
+
+	// conda installs the tarballs restored from the blob cache into the pkgs
+	// dir as their checksum matches, they are stored before conda clean
+	var blobs []blobcache.Blob
+	if l.blobCache != nil {
+		blobs = explicitBlobs(explicit)
+		l.blobCache.Restore(l.loaderOptions.prefixingPkgsDir, blobs)
+	}
+
+	explicit = withExplicitAuth(l.loaderOptions.channelAuths, explicit)
+	explicitFilePath, cleanup, err := l.writeTemp(logger, "explicit.txt", []byte(explicit))
 	if err != nil {
-		logger.WithError(err).Error("Failed to cleanup all packages, index cache, and tarballs, etc.")
+		logger.WithError(err).Error("Failed to write temp explicit spec file")
 		return err
 	}
+	defer cleanup()
 
-	err = l.cleanupNonExistingSymlinks(logger)
+	err = l.mamba.CreateEnvFromExplicitFile(logger, l.loaderOptions.envPrefix(), explicitFilePath)
 	if err != nil {
-		logger.WithError(err).Error("Failed to cleanup non-existing symlinks")
+		logger.WithError(err).Error("Failed to create conda env from explicit spec file")
 		return err
 	}
 
-	err = l.moveToMountRoot(logger)
+	if l.blobCache != nil {
+		l.blobCache.Store(l.loaderOptions.prefixingPkgsDir, blobs)
+	}
+
+	if pipRequirements == "" {
+		return nil
+	}
+
+	pipRequirements = withPipRequirementsAuth(l.loaderOptions.pipIndexAuths, pipRequirements)
+	requirementsFilePath, cleanupRequirements, err := l.writeTemp(logger, "requirements.lock.txt", []byte(pipRequirements))
+	if err != nil {
+		logger.WithError(err).Error("Failed to write temp requirements file")
+		return err
+	}
+	defer cleanupRequirements()
+
+	err = l.pip.InstallWithRequirementsTxt(logger, requirementsFilePath, "--no-deps")
+	if err != nil {
+		logger.WithError(err).Error("Failed to install locked pip packages")
+		return err
+	}
+
+	return nil
+}
+
+// writeManifest writes the manifest of the packages resolved in the env next
+// to it, its digest is reported as the result of the sync.
+func (l *CondaLoader) writeManifest(logger *logrus.Entry, packages []conda.CondaListOutputPackage) error {
+	data, digest, err := conda.NewManifest(packages).Marshal()
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(l.loaderOptions.finalEnvsDir, 0755) // #nosec G301
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(l.loaderOptions.manifestPath(), data, 0644) // #nosec G306
 	if err != nil {
-		logger.WithError(err).Error("Failed to move conda envs and pkgs to mount root")
 		return err
 	}
 
+	logger.WithFields(logrus.Fields{
+		"manifest": l.loaderOptions.manifestPath(),
+		"digest":   digest,
+		"packages": len(packages),
+	}).Info("wrote manifest of conda env")
+	l.result = jobresult.Result{Digest: digest}
+
 	return nil
 }
@@ -0,0 +1,195 @@
+package datasources
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+type urlAuthType string
+
+const (
+	urlAuthTypeToken urlAuthType = "token"
+	urlAuthTypeBasic urlAuthType = "basic"
+)
+
+// pipTokenUsername is the username sent along with a token to pip indexes,
+// it is what PyPI compatible registries such as GitLab and devpi expect.
+const pipTokenUsername = "__token__"
+
+// urlAuth is the credential of the channels or indexes whose URL starts with
+// prefix. Credentials are referenced by the keys of the dataset secret, so
+// that several channels can be authenticated with different tokens.
+type urlAuth struct {
+	prefix string
+	typ    urlAuthType
+	// keys of the secret, the token, or the username and the password
+	keys []string
+
+	token    string
+	username string
+	password string
+}
+
+/*
+parseURLAuths parses comma separated credentials of channels or indexes:
+
+	https://conda.example.com/private=token,https://pypi.example.com=basic:pypi-user:pypi-password
+
+token reads the token key of the secret, token:<key> another key. basic reads
+the username and password keys of the secret, basic:<username key>:<password
+key> other keys.
+*/
+func parseURLAuths(s string) ([]urlAuth, error) {
+	var auths []urlAuth
+	for _, item := range splitAndTrim(s) {
+		prefix, spec, ok := strings.Cut(item, "=")
+		prefix = strings.TrimRight(strings.TrimSpace(prefix), "/")
+		if !ok || prefix == "" {
+			return nil, fmt.Errorf("invalid auth %q, expected <url>=token[:<secret key>] or <url>=basic[:<username key>:<password key>]", item)
+		}
+		u, err := url.Parse(prefix)
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			return nil, fmt.Errorf("invalid auth %q, %s is not an http or https URL", item, prefix)
+		}
+
+		parts := strings.Split(strings.TrimSpace(spec), ":")
+		auth := urlAuth{prefix: prefix, typ: urlAuthType(parts[0])}
+		switch {
+		case auth.typ == urlAuthTypeToken && len(parts) == 1:
+			auth.keys = []string{"token"}
+		case auth.typ == urlAuthTypeToken && len(parts) == 2:
+			auth.keys = parts[1:]
+		case auth.typ == urlAuthTypeBasic && len(parts) == 1:
+			auth.keys = []string{"username", "password"}
+		case auth.typ == urlAuthTypeBasic && len(parts) == 3:
+			auth.keys = parts[1:]
+		default:
+			return nil, fmt.Errorf("invalid auth %q, expected <url>=token[:<secret key>] or <url>=basic[:<username key>:<password key>]", item)
+		}
+		auths = append(auths, auth)
+	}
+
+	return auths, nil
+}
+
+// resolve reads the credentials from the secret.
+func (a *urlAuth) resolve(secrets Secrets) error {
+	values := make([]string, 0, len(a.keys))
+	for _, key := range a.keys {
+		value := strings.TrimSpace(secrets.Get(key))
+		if value == "" {
+			return fmt.Errorf("secret key %s of the %s auth of %s is missing or empty", key, a.typ, a.prefix)
+		}
+		values = append(values, value)
+	}
+
+	switch a.typ {
+	case urlAuthTypeToken:
+		a.token = values[0]
+	case urlAuthTypeBasic:
+		a.username, a.password = values[0], values[1]
+	}
+
+	return nil
+}
+
+func (a urlAuth) matches(rawURL string) bool {
+	return rawURL == a.prefix || strings.HasPrefix(rawURL, a.prefix+"/")
+}
+
+func (a urlAuth) secrets() []string {
+	secrets := obscureSecrets(a.token, a.password)
+	// userinfo is escaped differently from queries
+	for _, s := range []string{a.token, a.password} {
+		if s != "" {
+			secrets = append(secrets, url.User(s).String())
+		}
+	}
+
+	return secrets
+}
+
+// withChannelAuth returns the channel URL with the credentials conda
+// understands, /t/<token> after the host for tokens, or the userinfo.
+func withChannelAuth(auths []urlAuth, channel string) string {
+	for _, auth := range auths {
+		if !auth.matches(channel) {
+			continue
+		}
+		u, err := url.Parse(channel)
+		if err != nil {
+			return channel
+		}
+		switch auth.typ {
+		case urlAuthTypeToken:
+			u.Path = "/t/" + auth.token + u.Path
+			u.RawPath = ""
+		case urlAuthTypeBasic:
+			u.User = url.UserPassword(auth.username, auth.password)
+		}
+		return u.String()
+	}
+
+	return channel
+}
+
+// withIndexAuth returns the index URL with the credentials in the userinfo,
+// as pip expects them.
+func withIndexAuth(auths []urlAuth, index string) string {
+	for _, auth := range auths {
+		if !auth.matches(index) {
+			continue
+		}
+		u, err := url.Parse(index)
+		if err != nil {
+			return index
+		}
+		switch auth.typ {
+		case urlAuthTypeToken:
+			u.User = url.UserPassword(pipTokenUsername, auth.token)
+		case urlAuthTypeBasic:
+			u.User = url.UserPassword(auth.username, auth.password)
+		}
+		return u.String()
+	}
+
+	return index
+}
+
+// withExplicitAuth applies the channel credentials to the package URLs of an
+// @EXPLICIT spec.
+func withExplicitAuth(channelAuths []urlAuth, explicit string) string {
+	lines := strings.Split(explicit, "\n")
+	for i, line := range lines {
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "@") {
+			continue
+		}
+		pkgURL, hash, hasHash := strings.Cut(line, "#")
+		lines[i] = withChannelAuth(channelAuths, pkgURL)
+		if hasHash {
+			lines[i] += "#" + hash
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// withPipRequirementsAuth applies the index credentials to the pip packages of
+// conda-lock.yml, written as <name> @ <url> --hash=sha256:<hash>.
+func withPipRequirementsAuth(indexAuths []urlAuth, requirements string) string {
+	lines := strings.Split(requirements, "\n")
+	for i, line := range lines {
+		name, rest, ok := strings.Cut(line, " @ ")
+		if !ok {
+			continue
+		}
+		pkgURL, hash, hasHash := strings.Cut(rest, " ")
+		lines[i] = name + " @ " + withIndexAuth(indexAuths, pkgURL)
+		if hasHash {
+			lines[i] += " " + hash
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -2,18 +2,22 @@ package datasources
 
 import (
 	"bytes"
+	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"net/url"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"strconv"
+	"slices"
 	"strings"
+	"time"
 
-	"github.com/BaizeAI/dataset/pkg/log"
-
+	"github.com/samber/lo"
 	"github.com/sirupsen/logrus"
+
+	"github.com/BaizeAI/dataset/internal/pkg/datasources/database"
+	"github.com/BaizeAI/dataset/pkg/log"
 )
 
 var _ Loader = &ModelDatabaseLoader{}
@@ -35,29 +39,139 @@ func NewModelDatabaseLoader(datasourceOptions map[string]string, options Options
 	res.modelDatabaseOptions = parsedOpts
 	res.modelDatabaseOptions.Username = strings.TrimSpace(secrets.Username)
 	res.modelDatabaseOptions.Password = strings.TrimSpace(secrets.Password)
+	res.modelDatabaseOptions.caCert = secrets.CACert
+	res.modelDatabaseOptions.tlsCert = secrets.TLSCert
+	res.modelDatabaseOptions.tlsKey = secrets.TLSKey
 
 	return res, nil
 }
 
 type ModelDatabaseLoaderOptions struct {
-	Username string   `json:"username"`
-	Password string   `json:"password"`
-	Host     string   `json:"host"`
-	Port     string   `json:"port"`
-	Dbname   string   `json:"dbname"`
-	Tables   []string `json:"-"`
-	Charset  string   `json:"charset"`
+	Type          string   `json:"type"`
+	Username      string   `json:"username"`
+	Password      string   `json:"password"`
+	Host          string   `json:"host"`
+	Port          string   `json:"port"`
+	Dbname        string   `json:"dbname"`
+	Tables        []string `json:"-"`
+	Charset       string   `json:"charset"`
+	TLS           string   `json:"tls"`
+	TLSServerName string   `json:"tlsServerName"`
+	ExportFormat  string   `json:"exportFormat"`
+	BatchSize     int      `json:"batchSize,string"`
+	SyncMode      string   `json:"syncMode"`
+
+	exports      []databaseExport
+	databaseType database.Type
+	exportFormat database.Format
+	tlsMode      database.TLSMode
+	caCert       string
+	tlsCert      string
+	tlsKey       string
+}
+
+// databaseExport is a table, or a named query, to export. Apart from tables,
+// the following options can be suffixed with .<table or query name> in order
+// to only apply to it:
+//
+//	query.<name>: a custom query, exported as <dbName>.<name>, query is a
+//	shorthand for query.query
+//	columns: the columns to export, separated by comma
+//	where: an SQL condition the exported rows must match
+//	watermarkColumn: a monotonically increasing column, only the rows whose
+//	watermark is greater than the last exported one are exported
+type databaseExport struct {
+	name            string
+	table           string
+	query           string
+	columns         []string
+	where           string
+	watermarkColumn string
+}
+
+const defaultQueryName = "query"
+
+func parseDatabaseExports(options map[string]string, tables []string) ([]databaseExport, error) {
+	queries := make(map[string]string)
+	for key, value := range options {
+		name, ok := strings.CutPrefix(key, "query.")
+		if key == "query" {
+			name, ok = defaultQueryName, true
+		}
+		if !ok || strings.TrimSpace(value) == "" {
+			continue
+		}
+		if err := database.ValidateIdentifier(name, false); err != nil {
+			return nil, fmt.Errorf("invalid query name: %w", err)
+		}
+		queries[name] = strings.TrimSpace(value)
+	}
+	if len(tables) == 0 && len(queries) == 0 {
+		return nil, fmt.Errorf("no table or query specified")
+	}
+
+	// option returns the value of key specific to name, or the one shared by
+	// all tables and queries
+	option := func(key, name string) string {
+		if value, ok := options[key+"."+name]; ok {
+			return strings.TrimSpace(value)
+		}
+		return strings.TrimSpace(options[key])
+	}
+
+	var exports []databaseExport
+	for _, table := range tables {
+		if err := database.ValidateIdentifier(table, true); err != nil {
+			return nil, fmt.Errorf("invalid table: %w", err)
+		}
+		if _, ok := queries[table]; ok {
+			return nil, fmt.Errorf("query %s conflicts with the table of the same name", table)
+		}
+		exports = append(exports, databaseExport{name: table, table: table})
+	}
+	for _, name := range lo.Keys(queries) {
+		exports = append(exports, databaseExport{name: name, query: queries[name]})
+	}
+	// the order of map keys is random
+	slices.SortStableFunc(exports[len(tables):], func(a, b databaseExport) int {
+		return strings.Compare(a.name, b.name)
+	})
+
+	for i := range exports {
+		export := &exports[i]
+		if columns := option("columns", export.name); columns != "" {
+			export.columns = lo.Map(strings.Split(columns, ","), func(column string, _ int) string {
+				return strings.TrimSpace(column)
+			})
+			for _, column := range export.columns {
+				if err := database.ValidateIdentifier(column, false); err != nil {
+					return nil, fmt.Errorf("invalid column of %s: %w", export.name, err)
+				}
+			}
+		}
+		export.where = option("where", export.name)
+		export.watermarkColumn = option("watermarkColumn", export.name)
+		if export.watermarkColumn != "" {
+			if err := database.ValidateIdentifier(export.watermarkColumn, false); err != nil {
+				return nil, fmt.Errorf("invalid watermark column of %s: %w", export.name, err)
+			}
+		}
+	}
+
+	return exports, nil
 }
 
 func (d *ModelDatabaseLoader) convertDatabaseOptions(options map[string]string) (ModelDatabaseLoaderOptions, error) {
 	var mdbOptions ModelDatabaseLoaderOptions
-	rawTables := strings.TrimSpace(options["tables"])
-	if rawTables == "" {
-		return mdbOptions, fmt.Errorf("no table specified")
+	var tables []string
+	if rawTables := strings.TrimSpace(options["tables"]); rawTables != "" {
+		tables = lo.Map(strings.Split(rawTables, ","), func(table string, _ int) string {
+			return strings.TrimSpace(table)
+		})
 	}
-	tables := strings.Split(rawTables, ",")
-	if len(tables) == 0 {
-		return mdbOptions, fmt.Errorf("no table specified")
+	exports, err := parseDatabaseExports(options, tables)
+	if err != nil {
+		return mdbOptions, err
 	}
 	jsonContent, err := json.Marshal(options)
 	if err != nil {
@@ -68,9 +182,61 @@ func (d *ModelDatabaseLoader) convertDatabaseOptions(options map[string]string)
 		return ModelDatabaseLoaderOptions{}, err
 	}
 	mdbOptions.Tables = tables
+	mdbOptions.exports = exports
+	mdbOptions.databaseType, err = database.ParseType(mdbOptions.Type)
+	if err != nil {
+		return ModelDatabaseLoaderOptions{}, err
+	}
+	mdbOptions.tlsMode, err = database.ParseTLSMode(mdbOptions.TLS)
+	if err != nil {
+		return ModelDatabaseLoaderOptions{}, err
+	}
+	mdbOptions.exportFormat, err = database.ParseFormat(mdbOptions.ExportFormat)
+	if err != nil {
+		return ModelDatabaseLoaderOptions{}, err
+	}
+	if err := validateSyncMode(mdbOptions.SyncMode); err != nil {
+		return ModelDatabaseLoaderOptions{}, err
+	}
+	mdbOptions.SyncMode = lo.CoalesceOrEmpty(mdbOptions.SyncMode, syncModeSync)
+	if mdbOptions.BatchSize < 0 {
+		return ModelDatabaseLoaderOptions{}, fmt.Errorf("invalid batchSize %d, must be positive", mdbOptions.BatchSize)
+	}
 	return mdbOptions, nil
 }
 
+// connectionOptions resolves the options used to connect to the database,
+// host and port fall back to the ones in database://<host>:<port> when they
+// are not specified through options.
+func (d *ModelDatabaseLoader) connectionOptions(parsedURL *url.URL) (database.ConnectionOptions, error) {
+	option := d.modelDatabaseOptions
+	host := lo.CoalesceOrEmpty(option.Host, parsedURL.Hostname())
+	if host == "" {
+		return database.ConnectionOptions{}, fmt.Errorf("no database host specified")
+	}
+
+	tlsConfig, err := database.NewTLSConfig(database.TLSOptions{
+		Mode:       option.tlsMode,
+		ServerName: lo.CoalesceOrEmpty(option.TLSServerName, host),
+		CACert:     option.caCert,
+		ClientCert: option.tlsCert,
+		ClientKey:  option.tlsKey,
+	})
+	if err != nil {
+		return database.ConnectionOptions{}, err
+	}
+
+	return database.ConnectionOptions{
+		Host:     host,
+		Port:     lo.CoalesceOrEmpty(option.Port, parsedURL.Port()),
+		Username: option.Username,
+		Password: option.Password,
+		Database: option.Dbname,
+		Charset:  option.Charset,
+		TLS:      tlsConfig,
+	}, nil
+}
+
 func (d *ModelDatabaseLoader) Sync(fromURI string, toPath string) error {
 	parsedURL, err := url.Parse(d.Options.URI)
 	if err != nil {
@@ -83,123 +249,218 @@ func (d *ModelDatabaseLoader) Sync(fromURI string, toPath string) error {
 	logger := log.WithFields(logrus.Fields{
 		"fromURI":          fromURI,
 		"type":             TypeDatabase,
+		"databaseType":     d.modelDatabaseOptions.databaseType,
+		"tls":              d.modelDatabaseOptions.tlsMode,
+		"exportFormat":     d.modelDatabaseOptions.exportFormat,
+		"syncMode":         d.modelDatabaseOptions.SyncMode,
 		"toPath":           toPath,
 		"workingDirectory": d.Options.Root,
 	})
-	for _, table := range d.modelDatabaseOptions.Tables {
-		err := d.sync(logger, table)
-		if err != nil {
-			return err
-		}
-	}
-	return nil
-}
-func (d *ModelDatabaseLoader) sync(logger *logrus.Entry, tableName string) error {
-	option := d.modelDatabaseOptions
-	batchSize := 10000
-	dbHost := option.Host
-	dbPort := option.Port
-	dbUser := option.Username
-	dbPass := option.Password
-	dbName := option.Dbname
-	outputFile := filepath.Join(d.Options.Root, fmt.Sprintf("%s.%s.%s", dbName, tableName, "csv"))
-	// 1. Get total row count
-	logger.Infof("fetching total row count for table: %s...", tableName)
-	totalRows, err := getTotalRows(dbHost, dbPort, dbUser, dbPass, dbName, tableName)
-	if err != nil {
-		logger.Errorf("error getting total rows: %v", err)
+
+	driver, err := database.GetDriver(d.modelDatabaseOptions.databaseType)
+	if err != nil {
 		return err
 	}
-	logger.Infof("total rows: %d\n", totalRows)
-
-	// 2. Open output file (Create or Truncate)
-	f, err := os.Create(outputFile)
+	connOptions, err := d.connectionOptions(parsedURL)
 	if err != nil {
-		logger.Errorf("error creating file: %v", err)
 		return err
 	}
+	db, err := driver.Open(connOptions)
+	if err != nil {
+		return fmt.Errorf("failed to open %s database: %w", d.modelDatabaseOptions.databaseType, err)
+	}
 	defer func() {
-		if err := f.Close(); err != nil {
-			logger.Printf("failed to close file: %v", err)
+		if err := db.Close(); err != nil {
+			logger.Warnf("failed to close database: %v", err)
 		}
 	}()
 
-	// 3. Batch Export
-	for offset := 0; offset < totalRows; offset += batchSize {
-		currentDone := offset + batchSize
-		if currentDone > totalRows {
-			currentDone = totalRows
-		}
-		logger.Infof("export progress: %d / %d ...", currentDone, totalRows)
-		query := fmt.Sprintf("SELECT * FROM %s LIMIT %d OFFSET %d;", tableName, batchSize, offset)
-		skipHeader := offset > 0
-		// -N skips headers
-		data, err := runMySQL(dbHost, dbPort, dbUser, dbPass, dbName, query, skipHeader)
+	ctx := context.Background()
+	err = db.PingContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s database at %s: %w", d.modelDatabaseOptions.databaseType, connOptions.Host, err)
+	}
+
+	targetDir := filepath.Join(d.Options.Root, toPath)
+	err = os.MkdirAll(targetDir, 0755) // #nosec G301
+	if err != nil {
+		return err
+	}
+
+	for _, export := range d.modelDatabaseOptions.exports {
+		err := d.sync(ctx, logger, db, driver, targetDir, export)
 		if err != nil {
-			logger.Errorf("failed to get MySQL data at offset %d: %v", offset, err)
-			return err
-		}
-		s := formatTSVtoCSV(data)
-		if _, err := f.WriteString(s); err != nil {
-			logger.Errorf("error when writing offset %d to csv: %v", offset, err)
 			return err
 		}
 	}
 
-	logger.Infof("export '%s.%s' to '%s' completed successfully!", dbName, tableName, outputFile)
+	if d.modelDatabaseOptions.SyncMode == syncModeSync {
+		// remove the exports of tables and queries which are not exported
+		// anymore, the other files in the directory are not the loader's
+		return pruneExtraneous(logger, targetDir, func(rel string) bool {
+			return d.isExported(rel) || !d.isExportFile(rel)
+		})
+	}
 	return nil
 }
 
-// runMySQL executes the mysql command and returns stdout as a string
-func runMySQL(host, port, user, pass, db, query string, skipHeader bool) (string, error) {
-	args := []string{
-		"-h" + host,
-		"-P" + port,
-		"-u" + user,
-		"-D" + db,
-		"-e", query,
-	}
-	if skipHeader {
-		args = append(args, "-N")
+// isExported reports whether a file, relative to the target directory, is
+// written by one of the exports.
+func (d *ModelDatabaseLoader) isExported(rel string) bool {
+	for _, export := range d.modelDatabaseOptions.exports {
+		prefix := fmt.Sprintf("%s.%s", d.modelDatabaseOptions.Dbname, export.name)
+		switch rel {
+		case fmt.Sprintf("%s.%s", prefix, d.modelDatabaseOptions.exportFormat), prefix + ".schema.json", prefix + ".watermark.json":
+			return true
+		}
+		if export.watermarkColumn != "" && strings.HasPrefix(rel, prefix+".part-") {
+			return true
+		}
 	}
 
-	cmd := exec.Command("mysql", args...)
+	return false
+}
+
+// isExportFile reports whether a file, relative to the target directory, is
+// named like the files written by the exports of the database, of the current
+// exports or not.
+func (d *ModelDatabaseLoader) isExportFile(rel string) bool {
+	if strings.Contains(rel, "/") || !strings.HasPrefix(rel, d.modelDatabaseOptions.Dbname+".") {
+		return false
+	}
+	for _, suffix := range []string{".schema.json", ".watermark.json", "." + string(database.FormatCSV), "." + string(database.FormatParquet), "." + string(database.FormatJSONL)} {
+		if strings.HasSuffix(rel, suffix) {
+			return true
+		}
+	}
 
-	// Use environment variable to hide password warning
-	cmd.Env = append(os.Environ(), "MYSQL_PWD="+pass)
+	return false
+}
 
-	var out bytes.Buffer
-	var stderr bytes.Buffer
-	cmd.Stdout = &out
-	cmd.Stderr = &stderr
+// watermarkState is persisted in <dbName>.<name>.watermark.json for
+// incremental exports.
+type watermarkState struct {
+	database.Watermark
+	// Parts is the number of parts exported so far.
+	Parts int `json:"parts"`
+}
 
-	err := cmd.Run()
+func readWatermarkState(logger *logrus.Entry, path string, column string) (*watermarkState, error) {
+	state := &watermarkState{Watermark: database.Watermark{Column: column}}
+	content, err := os.ReadFile(path)
 	if err != nil {
-		return "", fmt.Errorf("%v: %s", err, stderr.String())
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	if err := decoder.Decode(state); err != nil {
+		return nil, fmt.Errorf("failed to parse watermark state %s: %w", path, err)
+	}
+	if state.Column != column {
+		// the parts exported so far are kept, a new part with all rows is
+		// exported
+		logger.Warnf("watermark column changed from %s to %s, exporting all rows", state.Column, column)
+		state.Watermark = database.Watermark{Column: column}
 	}
 
-	return out.String(), nil
+	return state, nil
 }
 
-// getTotalRows gets the count as an integer
-func getTotalRows(host, port, user, pass, db, table string) (int, error) {
-	query := fmt.Sprintf("SELECT COUNT(*) FROM %s;", table)
-	res, err := runMySQL(host, port, user, pass, db, query, true)
+// sync exports a table, or a query, to <dbName>.<name>.<format>, along with
+// its schema in <dbName>.<name>.schema.json. The export is written to a
+// temporary file first, so that an interrupted export never replaces a
+// complete one.
+//
+// Incremental exports write the new rows of every sync to a new part
+// <dbName>.<name>.part-<n>.<format>. The watermark state is only updated
+// once the part is written, an interrupted sync exports the same part again.
+func (d *ModelDatabaseLoader) sync(ctx context.Context, logger *logrus.Entry, db *sql.DB, driver database.Driver, targetDir string, export databaseExport) error {
+	prefix := filepath.Join(targetDir, fmt.Sprintf("%s.%s", d.modelDatabaseOptions.Dbname, export.name))
+	outputFile := fmt.Sprintf("%s.%s", prefix, d.modelDatabaseOptions.exportFormat)
+	schemaFile := prefix + ".schema.json"
+	stateFile := prefix + ".watermark.json"
+	logger = logger.WithField("export", export.name)
+
+	var state *watermarkState
+	if export.watermarkColumn != "" {
+		var err error
+		state, err = readWatermarkState(logger, stateFile, export.watermarkColumn)
+		if err != nil {
+			return err
+		}
+		outputFile = fmt.Sprintf("%s.part-%05d.%s", prefix, state.Parts, d.modelDatabaseOptions.exportFormat)
+		logger = logger.WithField("watermark", state.Value)
+	}
+
+	logger.Infof("exporting %s to %s...", export.name, outputFile)
+	f, err := os.CreateTemp(targetDir, filepath.Base(outputFile)+".*.tmp")
 	if err != nil {
-		return 0, err
+		return err
 	}
+	defer func() {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+	}()
 
-	count, err := strconv.Atoi(strings.TrimSpace(res))
+	start := time.Now()
+	exportOptions := database.ExportOptions{
+		Table:     export.table,
+		Query:     export.query,
+		Columns:   export.columns,
+		Where:     export.where,
+		Format:    d.modelDatabaseOptions.exportFormat,
+		BatchSize: d.modelDatabaseOptions.BatchSize,
+	}
+	if state != nil {
+		exportOptions.Watermark = &state.Watermark
+	}
+	schema, err := database.Export(ctx, db, driver, f, exportOptions)
 	if err != nil {
-		return 0, fmt.Errorf("failed to parse count: %v", err)
+		return fmt.Errorf("failed to export %s: %w", export.name, err)
+	}
+	if state != nil && schema.Rows == 0 {
+		logger.Infof("no new rows of %s since the last export", export.name)
+		return nil
 	}
-	return count, nil
+	// CreateTemp creates the file with 0600
+	if err := f.Chmod(0644); err != nil { // #nosec G302
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if err := writeJSONFile(schemaFile, schema); err != nil {
+		return err
+	}
+	if err := os.Rename(f.Name(), outputFile); err != nil {
+		return err
+	}
+	if state != nil {
+		state.Watermark = *schema.Watermark
+		state.Parts++
+		if err := writeJSONFile(stateFile, state); err != nil {
+			return err
+		}
+	}
+
+	logger.WithFields(logrus.Fields{
+		"rows":       schema.Rows,
+		"pagination": schema.Pagination,
+		"duration":   time.Since(start).String(),
+	}).Infof("export '%s.%s' to '%s' completed successfully!", d.modelDatabaseOptions.Dbname, export.name, outputFile)
+	return nil
 }
 
-// formatTSVtoCSV converts Tab-Separated MySQL output to Comma-Separated
-func formatTSVtoCSV(tsv string) string {
-	if tsv == "" {
-		return ""
+func writeJSONFile(path string, v any) error {
+	content, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
 	}
-	return strings.ReplaceAll(tsv, "\t", ",")
+
+	return os.WriteFile(path, content, 0644) // #nosec G306
 }
@@ -1,11 +1,90 @@
 package datasources
 
 import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/samber/lo"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+
+	"github.com/BaizeAI/dataset/internal/pkg/datasources/database"
 )
 
+// sqliteDriver is an in-process stand-in for the real database drivers,
+// Host is the directory and Database the name of the database file.
+type sqliteDriver struct{}
+
+func (d *sqliteDriver) Open(opts database.ConnectionOptions) (*sql.DB, error) {
+	return sql.Open("sqlite", filepath.Join(opts.Host, opts.Database))
+}
+
+func (d *sqliteDriver) QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (d *sqliteDriver) Placeholder(int) string {
+	return "?"
+}
+
+func (d *sqliteDriver) Limit(query string, limit int) string {
+	return fmt.Sprintf("%s LIMIT %d", query, limit)
+}
+
+func (d *sqliteDriver) PrimaryKey(ctx context.Context, db *sql.DB, table string) ([]string, error) {
+	rows, err := db.QueryContext(ctx, "SELECT name FROM pragma_table_info(?) WHERE pk > 0 ORDER BY pk", table)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var columns []string
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			return nil, err
+		}
+		columns = append(columns, column)
+	}
+
+	return columns, rows.Err()
+}
+
+const typeSQLite database.Type = "sqlite"
+
+func init() {
+	database.Register(typeSQLite, &sqliteDriver{})
+}
+
+func newSQLiteDatabase(t *testing.T, statements ...string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	db, err := sql.Open("sqlite", filepath.Join(dir, "test.db"))
+	require.NoError(t, err)
+	defer func() {
+		_ = db.Close()
+	}()
+
+	for _, statement := range statements {
+		_, err := db.Exec(statement)
+		require.NoError(t, err)
+	}
+
+	return dir
+}
+
 func TestNewModelDatabaseLoader(t *testing.T) {
 	t.Parallel()
 
@@ -137,97 +216,363 @@ func TestModelDatabaseLoader_convertDatabaseOptions(t *testing.T) {
 	}
 }
 
-func Test_formatTSVtoCSV(t *testing.T) {
+func TestModelDatabaseLoader_convertDatabaseOptionsTypeAndTLS(t *testing.T) {
 	t.Parallel()
 
-	tests := []struct {
-		name     string
-		input    string
-		expected string
-	}{
-		{
-			name:     "empty string",
-			input:    "",
-			expected: "",
-		},
-		{
-			name:     "single line TSV",
-			input:    "col1\tcol2\tcol3",
-			expected: "col1,col2,col3",
-		},
-		{
-			name:     "multiple lines TSV",
-			input:    "col1\tcol2\tcol3\nval1\tval2\tval3",
-			expected: "col1,col2,col3\nval1,val2,val3",
-		},
-		{
-			name:     "TSV with special characters",
-			input:    "name\tage\tcity\nJohn Doe\t25\tNew York\nJane\t30\tLos Angeles",
-			expected: "name,age,city\nJohn Doe,25,New York\nJane,30,Los Angeles",
-		},
-	}
+	loader := &ModelDatabaseLoader{}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			t.Parallel()
+	result, err := loader.convertDatabaseOptions(map[string]string{"tables": "users"})
+	require.NoError(t, err)
+	assert.Equal(t, database.TypeMySQL, result.databaseType)
+	assert.Equal(t, database.TLSModeDisable, result.tlsMode)
 
-			result := formatTSVtoCSV(tt.input)
-			assert.Equal(t, tt.expected, result)
-		})
-	}
+	result, err = loader.convertDatabaseOptions(map[string]string{
+		"tables": "users",
+		"type":   "PostgreSQL",
+		"tls":    "verify-full",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, database.TypePostgreSQL, result.databaseType)
+	assert.Equal(t, database.TLSModeVerifyFull, result.tlsMode)
+
+	_, err = loader.convertDatabaseOptions(map[string]string{"tables": "users", "type": "oracle"})
+	assert.ErrorContains(t, err, "unsupported database type oracle")
+
+	_, err = loader.convertDatabaseOptions(map[string]string{"tables": "users", "tls": "maybe"})
+	assert.ErrorContains(t, err, "invalid tls mode")
+
+	result, err = loader.convertDatabaseOptions(map[string]string{"tables": "users", "exportFormat": "Parquet", "batchSize": "500"})
+	require.NoError(t, err)
+	assert.Equal(t, database.FormatParquet, result.exportFormat)
+	assert.Equal(t, 500, result.BatchSize)
+
+	_, err = loader.convertDatabaseOptions(map[string]string{"tables": "users", "exportFormat": "xlsx"})
+	assert.ErrorContains(t, err, "unsupported export format")
+
+	_, err = loader.convertDatabaseOptions(map[string]string{"tables": "users", "batchSize": "-1"})
+	assert.ErrorContains(t, err, "invalid batchSize")
 }
 
-// Mock functions for testing runMySQL and getTotalRows that depend on external MySQL
-func Test_runMySQL_Mock(t *testing.T) {
+func TestModelDatabaseLoader_connectionOptions(t *testing.T) {
 	t.Parallel()
 
-	// Since runMySQL requires an actual MySQL connection, we'll test the error case
-	// by using a non-existent host/port combination
+	loader, err := NewModelDatabaseLoader(map[string]string{
+		"tables": "users",
+		"type":   "postgresql",
+		"dbName": "testdb",
+		"tls":    "require",
+	}, Options{URI: "database://pg.example.com:15432"}, Secrets{Username: "user\n", Password: "pass"})
+	require.NoError(t, err)
+
+	connOptions, err := loader.connectionOptions(lo.Must(url.Parse(loader.Options.URI)))
+	require.NoError(t, err)
+	assert.Equal(t, "pg.example.com", connOptions.Host)
+	assert.Equal(t, "15432", connOptions.Port)
+	assert.Equal(t, "testdb", connOptions.Database)
+	require.NotNil(t, connOptions.TLS)
+	assert.Equal(t, "pg.example.com", connOptions.TLS.ServerName)
 
-	_, err := runMySQL("nonexistent-host", "12345", "user", "pass", "db", "SELECT 1", false)
-	assert.Error(t, err)
+	loader.modelDatabaseOptions.Host = "override.example.com"
+	loader.modelDatabaseOptions.caCert = "invalid"
+	_, err = loader.connectionOptions(lo.Must(url.Parse(loader.Options.URI)))
+	assert.ErrorContains(t, err, "failed to parse CA certificate")
 }
 
-func Test_getTotalRows_Mock(t *testing.T) {
+func TestModelDatabaseLoader_Sync(t *testing.T) {
 	t.Parallel()
 
-	// Since getTotalRows requires an actual MySQL connection, we'll test the error case
-	_, err := getTotalRows("nonexistent-host", "12345", "user", "pass", "db", "table")
-	assert.Error(t, err)
+	dir := newSQLiteDatabase(t,
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, note TEXT)",
+		"INSERT INTO users (id, name, note) VALUES (1, 'alice', 'likes, commas'), (2, 'bob', NULL)",
+		"CREATE TABLE empty (id INTEGER PRIMARY KEY)",
+	)
+	root := t.TempDir()
+
+	loader, err := NewModelDatabaseLoader(map[string]string{
+		"type":   string(typeSQLite),
+		"host":   dir,
+		"dbName": "test.db",
+		"tables": "users,empty",
+	}, Options{Type: TypeDatabase, URI: "database://localhost", Root: root}, Secrets{})
+	require.NoError(t, err)
+	require.NoError(t, loader.Sync(loader.Options.URI, "."))
+
+	f, err := os.Open(filepath.Join(root, "test.db.users.csv"))
+	require.NoError(t, err)
+	defer func() {
+		_ = f.Close()
+	}()
+	records, err := csv.NewReader(f).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"id", "name", "note"},
+		{"1", "alice", "likes, commas"},
+		{"2", "bob", ""},
+	}, records)
+
+	var schema database.Schema
+	require.NoError(t, json.Unmarshal(lo.Must(os.ReadFile(filepath.Join(root, "test.db.users.schema.json"))), &schema))
+	assert.Equal(t, "users", schema.Table)
+	assert.Equal(t, database.FormatCSV, schema.Format)
+	assert.Equal(t, database.PaginationKeyset, schema.Pagination)
+	assert.Equal(t, int64(2), schema.Rows)
+	assert.Len(t, schema.Columns, 3)
+
+	assert.Equal(t, "id\n", string(lo.Must(os.ReadFile(filepath.Join(root, "test.db.empty.csv")))))
+	assert.FileExists(t, filepath.Join(root, "test.db.empty.schema.json"))
+
+	// no temporary files are left behind
+	entries, err := os.ReadDir(root)
+	require.NoError(t, err)
+	assert.Len(t, entries, 4)
 }
 
-// Test helper function formatTSVtoCSV with more edge cases
-func Test_formatTSVtoCSV_EdgeCases(t *testing.T) {
+func TestModelDatabaseLoader_SyncJSONL(t *testing.T) {
 	t.Parallel()
 
-	tests := []struct {
-		name     string
-		input    string
-		expected string
+	dir := newSQLiteDatabase(t,
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)",
+		"INSERT INTO users (id, name) VALUES (1, 'alice'), (2, NULL), (3, 'carol')",
+	)
+	root := t.TempDir()
+
+	loader, err := NewModelDatabaseLoader(map[string]string{
+		"type":         string(typeSQLite),
+		"host":         dir,
+		"dbName":       "test.db",
+		"tables":       "users",
+		"exportFormat": "jsonl",
+		"batchSize":    "2",
+	}, Options{Type: TypeDatabase, URI: "database://localhost", Root: root}, Secrets{})
+	require.NoError(t, err)
+	require.NoError(t, loader.Sync(loader.Options.URI, "."))
+
+	assert.Equal(t, `{"id":1,"name":"alice"}
+{"id":2,"name":null}
+{"id":3,"name":"carol"}
+`, string(lo.Must(os.ReadFile(filepath.Join(root, "test.db.users.jsonl")))))
+}
+
+func TestModelDatabaseLoader_SyncFailure(t *testing.T) {
+	t.Parallel()
+
+	dir := newSQLiteDatabase(t)
+	root := t.TempDir()
+
+	loader, err := NewModelDatabaseLoader(map[string]string{
+		"type":   string(typeSQLite),
+		"host":   dir,
+		"dbName": "test.db",
+		"tables": "missing",
+	}, Options{Type: TypeDatabase, URI: "database://localhost", Root: root}, Secrets{})
+	require.NoError(t, err)
+	assert.ErrorContains(t, loader.Sync(loader.Options.URI, "."), "failed to export missing")
+
+	entries, err := os.ReadDir(root)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestModelDatabaseLoader_SyncUnreachable(t *testing.T) {
+	t.Parallel()
+
+	loader, err := NewModelDatabaseLoader(map[string]string{
+		"host":   "127.0.0.1",
+		"port":   "1",
+		"tables": "users",
+	}, Options{Type: TypeDatabase, URI: "database://127.0.0.1:1", Root: t.TempDir()}, Secrets{})
+	require.NoError(t, err)
+
+	err = loader.Sync(loader.Options.URI, ".")
+	assert.ErrorContains(t, err, "failed to connect to mysql database")
+}
+
+func TestParseDatabaseExports(t *testing.T) {
+	t.Parallel()
+
+	exports, err := parseDatabaseExports(map[string]string{
+		"query":                  "SELECT 1",
+		"query.daily":            " SELECT * FROM sales ",
+		"query.empty":            "",
+		"columns":                "id, name",
+		"columns.daily":          "day,total",
+		"where.users":            "deleted_at IS NULL",
+		"watermarkColumn":        "updated_at",
+		"watermarkColumn.orders": "",
+	}, []string{"users", "public.orders"})
+	require.NoError(t, err)
+	assert.Equal(t, []databaseExport{
+		{name: "users", table: "users", columns: []string{"id", "name"}, where: "deleted_at IS NULL", watermarkColumn: "updated_at"},
+		{name: "public.orders", table: "public.orders", columns: []string{"id", "name"}, watermarkColumn: "updated_at"},
+		{name: "daily", query: "SELECT * FROM sales", columns: []string{"day", "total"}, watermarkColumn: "updated_at"},
+		{name: "query", query: "SELECT 1", columns: []string{"id", "name"}, watermarkColumn: "updated_at"},
+	}, exports)
+
+	exports, err = parseDatabaseExports(map[string]string{"query": "SELECT 1"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []databaseExport{{name: "query", query: "SELECT 1"}}, exports)
+
+	for _, tt := range []struct {
+		options map[string]string
+		tables  []string
+		err     string
 	}{
-		{
-			name:     "TSV with empty values",
-			input:    "col1\tcol2\tcol3\nval1\t\tval3",
-			expected: "col1,col2,col3\nval1,,val3",
-		},
-		{
-			name:     "TSV with tabs in values",
-			input:    "col1\tcol2\tcol3\nval1\tval\t2\tval3",
-			expected: "col1,col2,col3\nval1,val,2,val3",
-		},
-		{
-			name:     "TSV with newlines in values",
-			input:    "col1\tcol2\tcol3\nval1\tval\n2\tval3",
-			expected: "col1,col2,col3\nval1,val\n2,val3",
-		},
+		{options: map[string]string{}, err: "no table or query specified"},
+		{tables: []string{"users; DROP TABLE users"}, err: "invalid table"},
+		{tables: []string{"users"}, options: map[string]string{"columns": "id,*"}, err: "invalid column of users"},
+		{tables: []string{"users"}, options: map[string]string{"watermarkColumn.users": "a b"}, err: "invalid watermark column of users"},
+		{options: map[string]string{"query.a-b": "SELECT 1"}, err: "invalid query name"},
+		{tables: []string{"users"}, options: map[string]string{"query.users": "SELECT 1"}, err: "conflicts with the table"},
+	} {
+		_, err := parseDatabaseExports(tt.options, tt.tables)
+		assert.ErrorContains(t, err, tt.err)
 	}
+}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			t.Parallel()
+func TestModelDatabaseLoader_SyncQuery(t *testing.T) {
+	t.Parallel()
+
+	dir := newSQLiteDatabase(t,
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, deleted INTEGER)",
+		"INSERT INTO users VALUES (1, 'alice', 0), (2, 'bob', 1), (3, 'carol', 0)",
+	)
+	root := t.TempDir()
+
+	loader, err := NewModelDatabaseLoader(map[string]string{
+		"type":          string(typeSQLite),
+		"host":          dir,
+		"dbName":        "test.db",
+		"tables":        "users",
+		"columns.users": "name",
+		"where.users":   "deleted = 0",
+		"query.counts":  "SELECT deleted, COUNT(*) AS n FROM users GROUP BY deleted ORDER BY deleted",
+	}, Options{Type: TypeDatabase, URI: "database://localhost", Root: root}, Secrets{})
+	require.NoError(t, err)
+	require.NoError(t, loader.Sync(loader.Options.URI, "."))
+
+	assert.Equal(t, "name\nalice\ncarol\n", string(lo.Must(os.ReadFile(filepath.Join(root, "test.db.users.csv")))))
+	assert.Equal(t, "deleted,n\n0,2\n1,1\n", string(lo.Must(os.ReadFile(filepath.Join(root, "test.db.counts.csv")))))
+
+	var schema database.Schema
+	require.NoError(t, json.Unmarshal(lo.Must(os.ReadFile(filepath.Join(root, "test.db.counts.schema.json"))), &schema))
+	assert.Equal(t, "SELECT deleted, COUNT(*) AS n FROM users GROUP BY deleted ORDER BY deleted", schema.Query)
+}
+
+func TestModelDatabaseLoader_SyncIncremental(t *testing.T) {
+	t.Parallel()
+
+	dir := newSQLiteDatabase(t,
+		"CREATE TABLE events (id INTEGER PRIMARY KEY, seq INTEGER)",
+		"INSERT INTO events VALUES (1, 10), (2, 20)",
+	)
+	root := t.TempDir()
+	options := map[string]string{
+		"type":            string(typeSQLite),
+		"host":            dir,
+		"dbName":          "test.db",
+		"tables":          "events",
+		"watermarkColumn": "seq",
+	}
+	sync := func() {
+		loader, err := NewModelDatabaseLoader(options, Options{Type: TypeDatabase, URI: "database://localhost", Root: root}, Secrets{})
+		require.NoError(t, err)
+		require.NoError(t, loader.Sync(loader.Options.URI, "."))
+	}
+	readState := func() watermarkState {
+		var state watermarkState
+		require.NoError(t, json.Unmarshal(lo.Must(os.ReadFile(filepath.Join(root, "test.db.events.watermark.json"))), &state))
+		return state
+	}
 
-			result := formatTSVtoCSV(tt.input)
-			assert.Equal(t, tt.expected, result)
+	sync()
+	assert.Equal(t, "id,seq\n1,10\n2,20\n", string(lo.Must(os.ReadFile(filepath.Join(root, "test.db.events.part-00000.csv")))))
+	state := readState()
+	assert.Equal(t, 1, state.Parts)
+	assert.Equal(t, "seq", state.Column)
+	assert.Equal(t, database.KindInt, state.Kind)
+	assert.EqualValues(t, 20, state.Value)
+
+	// nothing changed
+	sync()
+	assert.NoFileExists(t, filepath.Join(root, "test.db.events.part-00001.csv"))
+	assert.Equal(t, 1, readState().Parts)
+
+	db, err := sql.Open("sqlite", filepath.Join(dir, "test.db"))
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO events VALUES (3, 30), (4, 5)")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	sync()
+	assert.Equal(t, "id,seq\n3,30\n", string(lo.Must(os.ReadFile(filepath.Join(root, "test.db.events.part-00001.csv")))))
+	state = readState()
+	assert.Equal(t, 2, state.Parts)
+	assert.EqualValues(t, 30, state.Value)
+
+	// changing the watermark column exports all rows again to a new part
+	options["watermarkColumn"] = "id"
+	sync()
+	assert.Equal(t, "id,seq\n1,10\n2,20\n3,30\n4,5\n", string(lo.Must(os.ReadFile(filepath.Join(root, "test.db.events.part-00002.csv")))))
+	state = readState()
+	assert.Equal(t, "id", state.Column)
+	assert.EqualValues(t, 4, state.Value)
+}
+
+func TestModelDatabaseLoader_SyncToPath(t *testing.T) {
+	t.Parallel()
+
+	dir := newSQLiteDatabase(t,
+		"CREATE TABLE users (id INTEGER PRIMARY KEY)",
+		"CREATE TABLE events (id INTEGER PRIMARY KEY)",
+		"INSERT INTO events VALUES (1), (2)",
+	)
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "data", "stale"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "data", "test.db.removed.csv"), nil, 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "data", "notes.txt"), nil, 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "data", ".dataset-manifest.json"), nil, 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "other.csv"), nil, 0600))
+
+	sync := func(options map[string]string) []string {
+		options["type"] = string(typeSQLite)
+		options["host"] = dir
+		options["dbName"] = "test.db"
+		loader, err := NewModelDatabaseLoader(options, Options{Type: TypeDatabase, URI: "database://localhost", Root: root}, Secrets{})
+		require.NoError(t, err)
+		require.NoError(t, loader.Sync(loader.Options.URI, "data"))
+
+		entries, err := os.ReadDir(filepath.Join(root, "data"))
+		require.NoError(t, err)
+		return lo.Map(entries, func(entry os.DirEntry, _ int) string {
+			return entry.Name()
 		})
 	}
+
+	assert.Equal(t, []string{
+		".dataset-manifest.json",
+		"notes.txt",
+		"stale",
+		"test.db.events.part-00000.csv",
+		"test.db.events.schema.json",
+		"test.db.events.watermark.json",
+		"test.db.removed.csv",
+		"test.db.users.csv",
+		"test.db.users.schema.json",
+	}, sync(map[string]string{"tables": "users,events", "syncMode": "copy", "watermarkColumn.events": "id"}))
+
+	// the parts of incremental exports are kept, and the files which are not
+	// exports of the database survive the sync
+	assert.Equal(t, []string{
+		".dataset-manifest.json",
+		"notes.txt",
+		"stale",
+		"test.db.events.part-00000.csv",
+		"test.db.events.schema.json",
+		"test.db.events.watermark.json",
+	}, sync(map[string]string{"tables": "events", "watermarkColumn": "id"}))
+	// files outside of the target path are left untouched
+	assert.FileExists(t, filepath.Join(root, "other.csv"))
+
+	_, err := NewModelDatabaseLoader(map[string]string{"tables": "users", "syncMode": "mirror"}, Options{}, Secrets{})
+	assert.ErrorContains(t, err, "invalid syncMode")
 }
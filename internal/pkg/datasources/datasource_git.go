@@ -18,11 +18,19 @@ import (
 
 	"golang.org/x/crypto/ssh"
 
+	"github.com/BaizeAI/dataset/internal/pkg/jobresult"
 	"github.com/BaizeAI/dataset/pkg/log"
 	"github.com/BaizeAI/dataset/pkg/utils"
 )
 
-var _ Loader = &GitLoader{}
+var _ Exporter = &GitLoader{}
+
+const (
+	gitExportDefaultBranch        = "main"
+	gitExportDefaultCommitMessage = "Export dataset"
+	gitExportDefaultAuthorName    = "dataset-loader"
+	gitExportDefaultAuthorEmail   = "dataset-loader@baizeai.io"
+)
 
 type GitLoader struct {
 	Options Options
@@ -54,6 +62,11 @@ type GitLoaderOptions struct {
 	Depth      string `json:"depth"`
 	Submodules string `json:"submodules"`
 
+	// options of exports
+	CommitMessage string `json:"commitMessage"`
+	AuthorName    string `json:"authorName"`
+	AuthorEmail   string `json:"authorEmail"`
+
 	depth                   int64
 	username                string
 	password                string
@@ -676,23 +689,34 @@ func (d *GitLoader) syncWithPull(logger *logrus.Entry, _ string, alteredFromURI
 	return nil
 }
 
-func (d *GitLoader) Sync(fromURI string, toPath string) error {
+// prepareCredentials returns uri with the credentials of the secrets, and
+// places the ssh private key for the git commands.
+func (d *GitLoader) prepareCredentials(uri string) (string, error) {
 	var err error
 
-	alteredFromURI := fromURI
+	alteredURI := uri
 	if d.gitOptions.token != "" {
-		alteredFromURI = d.alterFromURIForToken(fromURI, d.gitOptions.token)
+		alteredURI = d.alterFromURIForToken(uri, d.gitOptions.token)
 	}
 	if d.gitOptions.username != "" {
-		alteredFromURI = d.alterFromURIForUsernameAndPasswordAccess(fromURI, d.gitOptions.username, d.gitOptions.password)
+		alteredURI = d.alterFromURIForUsernameAndPasswordAccess(uri, d.gitOptions.username, d.gitOptions.password)
 	}
 	if d.gitOptions.sshPrivateKey != "" {
 		d.gitOptions.sshPrivateKeyFullPath, err = preparePrivateKeyToSSHDir(d.gitOptions.sshPrivateKey, d.gitOptions.sshPrivateKeyPassphrase)
 		if err != nil {
-			return err
+			return alteredURI, err
 		}
 	}
 
+	return alteredURI, nil
+}
+
+func (d *GitLoader) Sync(fromURI string, toPath string) error {
+	alteredFromURI, err := d.prepareCredentials(fromURI)
+	if err != nil {
+		return err
+	}
+
 	logger := log.WithFields(logrus.Fields{
 		"fromURI":                     utils.ObscureString(fromURI, d.secrets()),
 		"alteredFromURI":              utils.ObscureString(alteredFromURI, d.secrets()),
@@ -723,3 +747,104 @@ func (d *GitLoader) Sync(fromURI string, toPath string) error {
 
 	return d.syncWithPull(logger, fromURI, alteredFromURI, toPath, finalizedGitDir)
 }
+
+// exportCommand runs git with a temporary git directory and the exported
+// directory as the work tree, the dataset itself is not modified.
+func (d *GitLoader) exportCommand(gitDir string, workTree string, args ...string) *exec.Cmd {
+	cmd := exec.Command("git", append([]string{"-c", "safe.directory=*"}, args...)...)
+	cmd.Dir = workTree
+	cmd.Env = os.Environ()
+	cmd.Env = append(cmd.Env, "GIT_DIR="+gitDir, "GIT_WORK_TREE="+workTree, "GIT_TERMINAL_PROMPT=0")
+	if d.gitOptions.sshPrivateKey != "" {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("GIT_SSH_COMMAND=ssh -o StrictHostKeyChecking=no -i %s", d.gitOptions.sshPrivateKeyFullPath))
+	}
+
+	return cmd
+}
+
+// Export commits the content of fromPath on top of the branch of the
+// repository and pushes it, the tree of the commit mirrors fromPath and the
+// branch is created when it does not exist. The result reports the commit of
+// the branch, nothing is pushed when the content is unchanged.
+func (d *GitLoader) Export(fromPath string, toURI string) (jobresult.Result, error) {
+	alteredToURI, err := d.prepareCredentials(toURI)
+	if err != nil {
+		return jobresult.Result{}, err
+	}
+
+	branch := lo.CoalesceOrEmpty(d.gitOptions.Branch, gitExportDefaultBranch)
+	logger := log.WithFields(logrus.Fields{
+		"fromPath":     fromPath,
+		"type":         TypeGit,
+		"toURI":        utils.ObscureString(toURI, d.secrets()),
+		"alteredToURI": utils.ObscureString(alteredToURI, d.secrets()),
+		"branch":       branch,
+	})
+
+	gitDir, err := os.MkdirTemp("", "dataset-export-*.git")
+	if err != nil {
+		return jobresult.Result{}, err
+	}
+	defer func() {
+		_ = os.RemoveAll(gitDir)
+	}()
+
+	run := func(args ...string) (string, error) {
+		cmd := d.exportCommand(gitDir, fromPath, args...)
+		out, err := utils.ExecuteCommandWithOutput(logger.WithField("command", utils.ObscureString(cmd.String(), d.secrets())), cmd, d.secrets())
+		if err != nil {
+			return "", fmt.Errorf("failed to export data with git command %s, err: %w", utils.ObscureString(cmd.String(), d.secrets()), err)
+		}
+		return strings.TrimSpace(out.String()), nil
+	}
+
+	if _, err := run("init", "--quiet"); err != nil {
+		return jobresult.Result{}, err
+	}
+	remoteRef, err := run("ls-remote", alteredToURI, "refs/heads/"+branch)
+	if err != nil {
+		return jobresult.Result{}, err
+	}
+	if remoteRef != "" {
+		logger.Debugf("committing on top of %s", remoteRef)
+		if _, err := run("fetch", "--quiet", "--depth=1", alteredToURI, "refs/heads/"+branch); err != nil {
+			return jobresult.Result{}, err
+		}
+		// the index is the tree of the branch, the work tree is the dataset
+		if _, err := run("reset", "--quiet", "--mixed", "FETCH_HEAD"); err != nil {
+			return jobresult.Result{}, err
+		}
+	}
+	if _, err := run("add", "--all"); err != nil {
+		return jobresult.Result{}, err
+	}
+
+	changed := remoteRef == ""
+	if !changed {
+		cmd := d.exportCommand(gitDir, fromPath, "diff", "--cached", "--quiet")
+		changed = cmd.Run() != nil
+	}
+	if changed {
+		_, err := run(
+			"-c", "user.name="+lo.CoalesceOrEmpty(d.gitOptions.AuthorName, gitExportDefaultAuthorName),
+			"-c", "user.email="+lo.CoalesceOrEmpty(d.gitOptions.AuthorEmail, gitExportDefaultAuthorEmail),
+			"commit", "--quiet", "--allow-empty", "--message", lo.CoalesceOrEmpty(d.gitOptions.CommitMessage, gitExportDefaultCommitMessage),
+		)
+		if err != nil {
+			return jobresult.Result{}, err
+		}
+		if _, err := run("push", "--quiet", alteredToURI, "HEAD:refs/heads/"+branch); err != nil {
+			return jobresult.Result{}, err
+		}
+	} else {
+		logger.Info("the content is unchanged, nothing is pushed")
+	}
+
+	revision, err := run("rev-parse", "HEAD")
+	if err != nil {
+		return jobresult.Result{}, err
+	}
+	logger.Infof("exported %s to branch %s at %s", fromPath, branch, revision)
+
+	return jobresult.Result{Revision: revision}, nil
+}
@@ -416,3 +416,38 @@ func TestGitLoader(t *testing.T) {
 		}, bbs)
 	})
 }
+
+func TestGitLoaderExport(t *testing.T) {
+	remoteDir, branch := createBareGitRemote(t)
+	rootDir := t.TempDir()
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(rootDir, "gitconfig"))
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	exportDir := filepath.Join(rootDir, "export")
+	require.NoError(t, os.MkdirAll(filepath.Join(exportDir, "sub"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(exportDir, "sub", "data.txt"), []byte("exported\n"), 0600))
+
+	loader, err := NewGitLoader(map[string]string{"branch": branch, "commitMessage": "export data"}, Options{Root: rootDir}, Secrets{})
+	require.NoError(t, err)
+	result, err := loader.Export(exportDir, remoteDir)
+	require.NoError(t, err)
+	assert.Equal(t, strings.TrimSpace(runGit(t, remoteDir, "rev-parse", branch)), result.Revision)
+	assert.Equal(t, "export data\n", runGit(t, remoteDir, "log", "-1", "--format=%s", branch))
+	// the branch mirrors the content, files missing from it are deleted
+	assert.Equal(t, "sub/data.txt\n", runGit(t, remoteDir, "ls-tree", "-r", "--name-only", branch))
+	assert.Equal(t, "2\n", runGit(t, remoteDir, "rev-list", "--count", branch))
+	assert.NoDirExists(t, filepath.Join(exportDir, ".git"))
+
+	// nothing is pushed when the content is unchanged
+	again, err := loader.Export(exportDir, remoteDir)
+	require.NoError(t, err)
+	assert.Equal(t, result.Revision, again.Revision)
+
+	// a new branch starts with the content as its root commit
+	loader, err = NewGitLoader(map[string]string{"branch": "exported"}, Options{Root: rootDir}, Secrets{})
+	require.NoError(t, err)
+	result, err = loader.Export(exportDir, remoteDir)
+	require.NoError(t, err)
+	assert.Equal(t, strings.TrimSpace(runGit(t, remoteDir, "rev-parse", "exported")), result.Revision)
+	assert.Equal(t, "1\n", runGit(t, remoteDir, "rev-list", "--count", "exported"))
+}
@@ -2,14 +2,20 @@ package datasources
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/url"
+	"os"
 	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
 
-	"github.com/BaizeAI/dataset/pkg/log"
-
+	"github.com/samber/lo"
 	"github.com/sirupsen/logrus"
+
+	"github.com/BaizeAI/dataset/pkg/log"
 )
 
 var _ Loader = &ModelHadoopLoader{}
@@ -29,11 +35,35 @@ func NewModelHadoopLoader(datasourceOptions map[string]string, options Options,
 
 	res.Options = options
 	res.modelHadoopOptions = parsedOpts
+	res.modelHadoopOptions.SyncMode = lo.CoalesceOrEmpty(parsedOpts.SyncMode, syncModeSync)
+	res.modelHadoopOptions.Concurrency = lo.CoalesceOrEmpty(parsedOpts.Concurrency, defaultWebHDFSConcurrency)
+	res.modelHadoopOptions.keytab = secrets.KerberosKeytab
+	res.modelHadoopOptions.principal = secrets.KerberosPrincipal
+	res.modelHadoopOptions.caCert = secrets.CACert
+	if res.modelHadoopOptions.keytab != "" {
+		if _, _, err := parsePrincipal(res.modelHadoopOptions.principal); err != nil {
+			return nil, err
+		}
+	}
 	return res, nil
 }
 
 type ModelHadoopLoaderOptions struct {
 	SourcePath string `json:"sourcePath"`
+	SyncMode   string `json:"syncMode"`
+	Username   string `json:"username"`
+
+	// Include, Exclude and Concurrency are only supported by webhdfs.
+	Include     string `json:"include"`
+	Exclude     string `json:"exclude"`
+	Concurrency int    `json:"concurrency,string"`
+
+	includePatterns []string
+	excludePatterns []string
+
+	keytab    string
+	principal string
+	caCert    string
 }
 
 func (d *ModelHadoopLoader) convertHadoopOptions(options map[string]string) (ModelHadoopLoaderOptions, error) {
@@ -49,6 +79,20 @@ func (d *ModelHadoopLoader) convertHadoopOptions(options map[string]string) (Mod
 	if hadoopOptions.SourcePath == "" {
 		return ModelHadoopLoaderOptions{}, fmt.Errorf("sourcePath option is required and must not be empty")
 	}
+	if err := validateSyncMode(hadoopOptions.SyncMode); err != nil {
+		return ModelHadoopLoaderOptions{}, err
+	}
+	if hadoopOptions.Concurrency < 0 {
+		return ModelHadoopLoaderOptions{}, fmt.Errorf("concurrency must not be negative, got %d", hadoopOptions.Concurrency)
+	}
+	hadoopOptions.includePatterns, err = parseGlobPatterns(hadoopOptions.Include)
+	if err != nil {
+		return ModelHadoopLoaderOptions{}, err
+	}
+	hadoopOptions.excludePatterns, err = parseGlobPatterns(hadoopOptions.Exclude)
+	if err != nil {
+		return ModelHadoopLoaderOptions{}, err
+	}
 	return hadoopOptions, nil
 }
 
@@ -57,8 +101,8 @@ func (d *ModelHadoopLoader) Sync(fromURI string, toPath string) error {
 	if err != nil {
 		return err
 	}
-	if parsedURL.Scheme != "hdfs" {
-		return fmt.Errorf("invalid scheme %s, only hdfs is supported", parsedURL.Scheme)
+	if parsedURL.Scheme != "hdfs" && parsedURL.Scheme != "webhdfs" && parsedURL.Scheme != "swebhdfs" {
+		return fmt.Errorf("invalid scheme %s, only hdfs, webhdfs and swebhdfs are supported", parsedURL.Scheme)
 	}
 
 	logger := log.WithFields(logrus.Fields{
@@ -67,10 +111,35 @@ func (d *ModelHadoopLoader) Sync(fromURI string, toPath string) error {
 		"toPath":           toPath,
 		"workingDirectory": d.Options.Root,
 		"sourcePath":       d.modelHadoopOptions.SourcePath,
+		"syncMode":         d.modelHadoopOptions.SyncMode,
+		"kerberos":         d.modelHadoopOptions.keytab != "",
 	})
+
+	targetDir := filepath.Join(d.Options.Root, toPath)
+	err = os.MkdirAll(targetDir, 0755) // #nosec G301
+	if err != nil {
+		return err
+	}
+
+	if parsedURL.Scheme != "hdfs" {
+		return d.syncWebHDFS(context.Background(), logger, parsedURL, targetDir)
+	}
+	if d.modelHadoopOptions.Include != "" || d.modelHadoopOptions.Exclude != "" {
+		return fmt.Errorf("include and exclude options are only supported with webhdfs:// and swebhdfs:// URIs")
+	}
+
+	var env []string
+	if d.modelHadoopOptions.keytab != "" {
+		var cleanup func()
+		env, cleanup, err = d.kinit(logger)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+	}
+
 	// Adding "--" is to prevent injection, and when overwriting a file, the absence of the "-f" option will be treated as a failure.
-	// #nosec G204
-	cmd := exec.Command("hdfs", "dfs", "-get", "-f", "--", d.modelHadoopOptions.SourcePath, d.Options.Root)
+	cmd := hdfsCommand(env, "dfs", "-get", "-f", "--", d.modelHadoopOptions.SourcePath, targetDir)
 	var out bytes.Buffer
 	var stderr bytes.Buffer
 	cmd.Stdout = &out
@@ -82,5 +151,111 @@ func (d *ModelHadoopLoader) Sync(fromURI string, toPath string) error {
 		return fmt.Errorf("%v: %s", err, stderr.String())
 	}
 	logger.Infof("get data from hdfs success, command output: %s", out.String())
+
+	if d.modelHadoopOptions.SyncMode == syncModeSync {
+		return d.prune(logger, env, targetDir)
+	}
 	return nil
 }
+
+func hdfsCommand(env []string, args ...string) *exec.Cmd {
+	cmd := exec.Command("hdfs", args...) // #nosec G204
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	return cmd
+}
+
+// kinit obtains a ticket for the principal with the keytab of the secret, the
+// ticket is kept in a private credential cache which the returned environment
+// points hdfs to. cleanup removes the keytab and the cache.
+func (d *ModelHadoopLoader) kinit(logger *logrus.Entry) (env []string, cleanup func(), err error) {
+	dir, err := os.MkdirTemp("", "krb5-")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup = func() {
+		_ = os.RemoveAll(dir)
+	}
+
+	keytabPath := filepath.Join(dir, "keytab")
+	if err := os.WriteFile(keytabPath, []byte(d.modelHadoopOptions.keytab), 0600); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	ccache := "FILE:" + filepath.Join(dir, "ccache")
+
+	// the principal was validated by parsePrincipal, it can not be taken as a flag
+	// #nosec G204
+	cmd := exec.Command("kinit", "-k", "-t", keytabPath, "-c", ccache, d.modelHadoopOptions.principal)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		cleanup()
+		logger.Errorf("running kinit failed: %v", err)
+		return nil, nil, fmt.Errorf("failed to kinit as %s: %v: %s", d.modelHadoopOptions.principal, err, stderr.String())
+	}
+	logger.Infof("obtained kerberos ticket for %s", d.modelHadoopOptions.principal)
+
+	return []string{"KRB5CCNAME=" + ccache}, cleanup, nil
+}
+
+// parsePrincipal splits a Kerberos principal such as user/host@REALM into its
+// name and realm, the realm is empty when the principal has none.
+func parsePrincipal(principal string) (name, realm string, err error) {
+	name = principal
+	if i := strings.LastIndex(principal, "@"); i >= 0 {
+		name, realm = principal[:i], principal[i+1:]
+	}
+	if name == "" || strings.HasPrefix(name, "-") || strings.ContainsAny(principal, " \t\r\n") {
+		return "", "", fmt.Errorf("invalid kerberos principal %q, principal in the secret is required along with keytab", principal)
+	}
+
+	return name, realm, nil
+}
+
+// prune removes the local files which do not exist under sourcePath anymore,
+// hdfs dfs -get copies sourcePath to <targetDir>/<base name of sourcePath>.
+func (d *ModelHadoopLoader) prune(logger *logrus.Entry, env []string, targetDir string) error {
+	sourcePath := strings.TrimSuffix(d.modelHadoopOptions.SourcePath, "/")
+	if strings.ContainsAny(sourcePath, "*?[{") {
+		logger.Warnf("sourcePath %s contains glob patterns, skipped pruning", sourcePath)
+		return nil
+	}
+	localDir := filepath.Join(targetDir, path.Base(sourcePath))
+	stat, err := os.Stat(localDir)
+	if err != nil || !stat.IsDir() {
+		// a single file was copied, there is nothing to prune
+		return nil
+	}
+
+	cmd := hdfsCommand(env, "dfs", "-ls", "-R", "-C", "--", sourcePath)
+	var out bytes.Buffer
+	var stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+
+	err = cmd.Run()
+	if err != nil {
+		logger.Errorf("running command failed: %v", err)
+		return fmt.Errorf("failed to list %s: %v: %s", sourcePath, err, stderr.String())
+	}
+
+	existing := make(map[string]bool)
+	for _, line := range strings.Split(out.String(), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		rel, ok := strings.CutPrefix(line, sourcePath+"/")
+		if !ok {
+			logger.Warnf("listed path %s is not under %s, skipped pruning", line, sourcePath)
+			return nil
+		}
+		existing[rel] = true
+	}
+
+	return pruneExtraneous(logger, localDir, func(rel string) bool {
+		return existing[rel]
+	})
+}
@@ -1,9 +1,22 @@
 package datasources
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/BaizeAI/dataset/internal/pkg/datasources/webhdfs"
 )
 
 func TestModelHadoopLoader(t *testing.T) {
@@ -90,7 +103,7 @@ func TestModelHadoopLoader(t *testing.T) {
 
 		err = hadoopLoader.Sync("http://example.com/path", "/tmp/output")
 		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "invalid scheme http, only hdfs is supported")
+		assert.Contains(t, err.Error(), "invalid scheme http, only hdfs, webhdfs and swebhdfs are supported")
 	})
 
 	t.Run("Sync with malformed URI", func(t *testing.T) {
@@ -110,4 +123,352 @@ func TestModelHadoopLoader(t *testing.T) {
 		err = hadoopLoader.Sync("://invalid-uri", "/tmp/output")
 		assert.Error(t, err)
 	})
+	t.Run("NewModelHadoopLoader with invalid syncMode", func(t *testing.T) {
+		_, err := NewModelHadoopLoader(
+			map[string]string{
+				"sourcePath": "/hdfs/source/path",
+				"syncMode":   "mirror",
+			},
+			Options{Type: TypeHadoop, URI: "hdfs://namenode:9000"},
+			Secrets{},
+		)
+		assert.ErrorContains(t, err, "invalid syncMode 'mirror'")
+	})
+}
+
+func TestModelHadoopLoader_Sync(t *testing.T) {
+	root := t.TempDir()
+	localDir := filepath.Join(root, "data", "source")
+	// the files hdfs dfs -get would have copied, along with stale ones
+	for _, file := range []string{"a.txt", "sub/b.txt", "stale.txt", "stale/c.txt"} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(localDir, file)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(localDir, file), nil, 0600))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(root, "outside.txt"), nil, 0600))
+
+	hadoopLoader, err := NewModelHadoopLoader(
+		map[string]string{"sourcePath": "/hdfs/source/"},
+		Options{Type: TypeHadoop, URI: "hdfs://namenode:9000", Root: root},
+		Secrets{},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "sync", hadoopLoader.modelHadoopOptions.SyncMode)
+
+	fakeHdfs := fakeCommand{
+		t:   t,
+		cmd: "hdfs",
+		outputs: []out{
+			{stdout: "", exit: 0},
+			{stdout: "/hdfs/source/a.txt\n/hdfs/source/sub\n/hdfs/source/sub/b.txt\n", exit: 0},
+		},
+	}
+	defer func() {
+		assert.NoError(t, fakeHdfs.Clean())
+	}()
+	fakeHdfs.WithContext(func() {
+		require.NoError(t, hadoopLoader.Sync("hdfs://namenode:9000", "data"))
+	})
+
+	inputs := fakeHdfs.GetAllInputs()
+	require.Len(t, inputs, 2)
+	assert.Equal(t, "dfs -get -f -- /hdfs/source/ "+filepath.Join(root, "data"), strings.TrimSpace(string(inputs[0])))
+	assert.Equal(t, "dfs -ls -R -C -- /hdfs/source", strings.TrimSpace(string(inputs[1])))
+
+	assert.FileExists(t, filepath.Join(localDir, "a.txt"))
+	assert.FileExists(t, filepath.Join(localDir, "sub", "b.txt"))
+	assert.NoFileExists(t, filepath.Join(localDir, "stale.txt"))
+	assert.NoDirExists(t, filepath.Join(localDir, "stale"))
+	assert.FileExists(t, filepath.Join(root, "outside.txt"))
+}
+
+func TestModelHadoopLoader_SyncCopy(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "source"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "source", "stale.txt"), nil, 0600))
+
+	hadoopLoader, err := NewModelHadoopLoader(
+		map[string]string{"sourcePath": "/hdfs/source", "syncMode": "copy"},
+		Options{Type: TypeHadoop, URI: "hdfs://namenode:9000", Root: root},
+		Secrets{},
+	)
+	require.NoError(t, err)
+
+	fakeHdfs := fakeCommand{
+		t:       t,
+		cmd:     "hdfs",
+		outputs: []out{{stdout: "", exit: 0}},
+	}
+	defer func() {
+		assert.NoError(t, fakeHdfs.Clean())
+	}()
+	fakeHdfs.WithContext(func() {
+		require.NoError(t, hadoopLoader.Sync("hdfs://namenode:9000", "."))
+	})
+
+	inputs := fakeHdfs.GetAllInputs()
+	require.Len(t, inputs, 1)
+	assert.Equal(t, "dfs -get -f -- /hdfs/source "+root, strings.TrimSpace(string(inputs[0])))
+	assert.FileExists(t, filepath.Join(root, "source", "stale.txt"))
+}
+
+func TestModelHadoopLoader_Kerberos(t *testing.T) {
+	root := t.TempDir()
+	hadoopLoader, err := NewModelHadoopLoader(
+		map[string]string{"sourcePath": "/hdfs/source", "syncMode": "copy"},
+		Options{Type: TypeHadoop, URI: "hdfs://namenode:9000", Root: root},
+		Secrets{KerberosKeytab: "keytab-content", KerberosPrincipal: "loader/host@EXAMPLE.COM"},
+	)
+	require.NoError(t, err)
+
+	fakeKinit := fakeCommand{
+		t:       t,
+		cmd:     "kinit",
+		outputs: []out{{stdout: "", exit: 0}},
+	}
+	fakeHdfs := fakeCommand{
+		t:       t,
+		cmd:     "hdfs",
+		outputs: []out{{stdout: "", exit: 0}},
+	}
+	defer func() {
+		assert.NoError(t, fakeKinit.Clean())
+		assert.NoError(t, fakeHdfs.Clean())
+	}()
+	fakeKinit.WithContext(func() {
+		fakeHdfs.WithContext(func() {
+			require.NoError(t, hadoopLoader.Sync("hdfs://namenode:9000", "."))
+		})
+	})
+
+	inputs := fakeKinit.GetAllInputs()
+	require.Len(t, inputs, 1)
+	matches := regexp.MustCompile(`^-k -t (\S+)/keytab -c FILE:(\S+)/ccache loader/host@EXAMPLE.COM$`).FindStringSubmatch(strings.TrimSpace(string(inputs[0])))
+	require.Len(t, matches, 3)
+	assert.Equal(t, matches[1], matches[2])
+	// the keytab is removed once the sync finished
+	assert.NoDirExists(t, matches[1])
+	assert.Len(t, fakeHdfs.GetAllInputs(), 1)
+
+	t.Run("kinit failure", func(t *testing.T) {
+		fakeKinit := fakeCommand{
+			t:       t,
+			cmd:     "kinit",
+			outputs: []out{{stderr: "kinit: Preauthentication failed", exit: 1}},
+		}
+		defer func() {
+			assert.NoError(t, fakeKinit.Clean())
+		}()
+		fakeKinit.WithContext(func() {
+			err = hadoopLoader.Sync("hdfs://namenode:9000", ".")
+		})
+		assert.ErrorContains(t, err, "failed to kinit as loader/host@EXAMPLE.COM")
+		assert.ErrorContains(t, err, "Preauthentication failed")
+	})
+
+	t.Run("webhdfs loads krb5.conf", func(t *testing.T) {
+		t.Setenv("KRB5_CONFIG", filepath.Join(root, "missing-krb5.conf"))
+		hadoopLoader, err := NewModelHadoopLoader(
+			map[string]string{"sourcePath": "/hdfs/source"},
+			Options{Type: TypeHadoop, URI: "webhdfs://namenode", Root: root},
+			Secrets{KerberosKeytab: "keytab-content", KerberosPrincipal: "loader@EXAMPLE.COM"},
+		)
+		require.NoError(t, err)
+		assert.ErrorContains(t, hadoopLoader.Sync("webhdfs://namenode", "."), "failed to load kerberos config "+filepath.Join(root, "missing-krb5.conf"))
+	})
+
+	t.Run("principal is required", func(t *testing.T) {
+		for _, principal := range []string{"", "-x@EXAMPLE.COM", "user name"} {
+			_, err := NewModelHadoopLoader(
+				map[string]string{"sourcePath": "/hdfs/source"},
+				Options{Type: TypeHadoop, URI: "hdfs://namenode:9000"},
+				Secrets{KerberosKeytab: "keytab-content", KerberosPrincipal: principal},
+			)
+			assert.ErrorContains(t, err, "invalid kerberos principal", principal)
+		}
+	})
+}
+
+type fakeWebHDFSFile struct {
+	content string
+	modTime time.Time
+}
+
+// newFakeWebHDFS serves files through the WebHDFS REST API, OPEN redirects to
+// a DataNode like a NameNode does. It returns the number of opened files.
+func newFakeWebHDFS(t *testing.T, files map[string]fakeWebHDFSFile) (*httptest.Server, *atomic.Int32) {
+	opened := new(atomic.Int32)
+	statusOf := func(p string) (webhdfs.FileStatus, bool) {
+		if file, ok := files[p]; ok {
+			return webhdfs.FileStatus{PathSuffix: path.Base(p), Type: webhdfs.TypeFile, Length: int64(len(file.content)), ModificationTime: file.modTime.UnixMilli()}, true
+		}
+		for name := range files {
+			if strings.HasPrefix(name, p+"/") {
+				return webhdfs.FileStatus{PathSuffix: path.Base(p), Type: webhdfs.TypeDirectory}, true
+			}
+		}
+		return webhdfs.FileStatus{}, false
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/webhdfs/v1/", func(w http.ResponseWriter, r *http.Request) {
+		p := strings.TrimPrefix(r.URL.Path, "/webhdfs/v1")
+		status, ok := statusOf(p)
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string]any{"RemoteException": map[string]string{"exception": "FileNotFoundException", "message": p}})
+			return
+		}
+		switch r.URL.Query().Get("op") {
+		case "GETFILESTATUS":
+			status.PathSuffix = ""
+			_ = json.NewEncoder(w).Encode(map[string]any{"FileStatus": status})
+		case "LISTSTATUS":
+			seen := make(map[string]bool)
+			var statuses []webhdfs.FileStatus
+			for name := range files {
+				rel, ok := strings.CutPrefix(name, p+"/")
+				if !ok {
+					continue
+				}
+				child, _, _ := strings.Cut(rel, "/")
+				if !seen[child] {
+					seen[child] = true
+					status, _ := statusOf(p + "/" + child)
+					statuses = append(statuses, status)
+				}
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"FileStatuses": map[string]any{"FileStatus": statuses}})
+		case "OPEN":
+			http.Redirect(w, r, "/datanode"+p, http.StatusTemporaryRedirect)
+		}
+	})
+	mux.HandleFunc("/datanode/", func(w http.ResponseWriter, r *http.Request) {
+		opened.Add(1)
+		_, _ = w.Write([]byte(files[strings.TrimPrefix(r.URL.Path, "/datanode")].content))
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server, opened
+}
+
+func TestModelHadoopLoader_WebHDFS(t *testing.T) {
+	modTime := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	server, opened := newFakeWebHDFS(t, map[string]fakeWebHDFSFile{
+		"/hdfs/source/a.txt":              {content: "a", modTime: modTime},
+		"/hdfs/source/b.parquet":          {content: "bb", modTime: modTime},
+		"/hdfs/source/sub/c.txt":          {content: "ccc", modTime: modTime},
+		"/hdfs/source/logs/d.txt":         {content: "dddd", modTime: modTime},
+		"/hdfs/source/sub/deep/e.parquet": {content: "eeeee", modTime: modTime},
+	})
+	uri := strings.Replace(server.URL, "http://", "webhdfs://", 1)
+
+	root := t.TempDir()
+	localDir := filepath.Join(root, "data", "source")
+	require.NoError(t, os.MkdirAll(filepath.Join(localDir, "stale"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(localDir, "stale", "f.txt"), nil, 0600))
+	require.NoError(t, os.MkdirAll(filepath.Join(localDir, "logs"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(localDir, "logs", "d.txt"), nil, 0600))
+
+	hadoopLoader, err := NewModelHadoopLoader(
+		map[string]string{
+			"sourcePath":  "/hdfs/source",
+			"include":     "*.txt, sub/deep",
+			"exclude":     "logs",
+			"concurrency": "2",
+		},
+		Options{Type: TypeHadoop, URI: uri, Root: root},
+		Secrets{},
+	)
+	require.NoError(t, err)
+	require.NoError(t, hadoopLoader.Sync(uri, "data"))
+
+	for file, content := range map[string]string{"a.txt": "a", "sub/c.txt": "ccc", "sub/deep/e.parquet": "eeeee"} {
+		data, err := os.ReadFile(filepath.Join(localDir, file))
+		require.NoError(t, err)
+		assert.Equal(t, content, string(data))
+		stat, err := os.Stat(filepath.Join(localDir, file))
+		require.NoError(t, err)
+		assert.True(t, stat.ModTime().Equal(modTime))
+	}
+	assert.NoFileExists(t, filepath.Join(localDir, "b.parquet"))
+	assert.NoDirExists(t, filepath.Join(localDir, "logs"))
+	assert.NoDirExists(t, filepath.Join(localDir, "stale"))
+	assert.Equal(t, int32(3), opened.Load())
+
+	// unchanged files are not downloaded again
+	require.NoError(t, hadoopLoader.Sync(uri, "data"))
+	assert.Equal(t, int32(3), opened.Load())
+
+	t.Run("single file", func(t *testing.T) {
+		hadoopLoader, err := NewModelHadoopLoader(
+			map[string]string{"sourcePath": "/hdfs/source/b.parquet"},
+			Options{Type: TypeHadoop, URI: uri, Root: root},
+			Secrets{},
+		)
+		require.NoError(t, err)
+		require.NoError(t, hadoopLoader.Sync(uri, "file"))
+
+		data, err := os.ReadFile(filepath.Join(root, "file", "b.parquet"))
+		require.NoError(t, err)
+		assert.Equal(t, "bb", string(data))
+	})
+
+	t.Run("missing source", func(t *testing.T) {
+		hadoopLoader, err := NewModelHadoopLoader(
+			map[string]string{"sourcePath": "/hdfs/missing"},
+			Options{Type: TypeHadoop, URI: uri, Root: root},
+			Secrets{},
+		)
+		require.NoError(t, err)
+		assert.ErrorContains(t, hadoopLoader.Sync(uri, "missing"), "FileNotFoundException")
+	})
+
+	t.Run("filters require webhdfs", func(t *testing.T) {
+		hadoopLoader, err := NewModelHadoopLoader(
+			map[string]string{"sourcePath": "/hdfs/source", "include": "*.txt"},
+			Options{Type: TypeHadoop, URI: "hdfs://namenode:9000", Root: root},
+			Secrets{},
+		)
+		require.NoError(t, err)
+		assert.ErrorContains(t, hadoopLoader.Sync("hdfs://namenode:9000", "."), "only supported with webhdfs://")
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		_, err := NewModelHadoopLoader(
+			map[string]string{"sourcePath": "/hdfs/source", "include": "[a"},
+			Options{Type: TypeHadoop, URI: uri},
+			Secrets{},
+		)
+		assert.ErrorContains(t, err, "invalid glob pattern")
+
+		_, err = NewModelHadoopLoader(
+			map[string]string{"sourcePath": "/hdfs/source", "concurrency": "-1"},
+			Options{Type: TypeHadoop, URI: uri},
+			Secrets{},
+		)
+		assert.ErrorContains(t, err, "concurrency must not be negative")
+	})
+}
+
+func TestMatchAnyGlob(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		rel      string
+		want     bool
+	}{
+		{[]string{"*.txt"}, "a.txt", true},
+		{[]string{"*.txt"}, "sub/a.txt", true},
+		{[]string{"*.txt"}, "a.parquet", false},
+		{[]string{"logs"}, "logs/a.txt", true},
+		{[]string{"logs"}, "sub/logs/a.txt", true},
+		{[]string{"sub/*.txt"}, "sub/a.txt", true},
+		{[]string{"sub/*.txt"}, "other/sub/a.txt", false},
+		{[]string{"sub/*"}, "sub/deep/a.txt", true},
+		{[]string{"a.txt", "b.txt"}, "b.txt", true},
+		{nil, "a.txt", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, matchAnyGlob(tt.patterns, tt.rel), "%v %s", tt.patterns, tt.rel)
+	}
 }
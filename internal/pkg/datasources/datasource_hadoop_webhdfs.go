@@ -0,0 +1,272 @@
+package datasources
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	krb5client "github.com/jcmturner/gokrb5/v8/client"
+	"github.com/jcmturner/gokrb5/v8/config"
+	"github.com/jcmturner/gokrb5/v8/keytab"
+	"github.com/samber/lo"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/BaizeAI/dataset/internal/pkg/datasources/webhdfs"
+)
+
+const (
+	defaultWebHDFSConcurrency = 4
+	defaultWebHDFSPort        = "9870"
+	defaultSWebHDFSPort       = "9871"
+	defaultKrb5ConfigPath     = "/etc/krb5.conf"
+)
+
+type webHDFSFile struct {
+	// rel is the path relative to the local directory, separated by slashes.
+	rel    string
+	remote string
+	status webhdfs.FileStatus
+}
+
+// syncWebHDFS downloads sourcePath through the WebHDFS REST API of the
+// NameNode, or of an HttpFS gateway, without the Hadoop client. Like hdfs dfs
+// -get, sourcePath is copied to <targetDir>/<base name of sourcePath>.
+func (d *ModelHadoopLoader) syncWebHDFS(ctx context.Context, logger *logrus.Entry, u *url.URL, targetDir string) error {
+	client, err := d.webHDFSClient(u)
+	if err != nil {
+		return err
+	}
+
+	sourcePath := path.Clean("/" + d.modelHadoopOptions.SourcePath)
+	status, err := client.GetFileStatus(ctx, sourcePath)
+	if err != nil {
+		return err
+	}
+	if status.Type != webhdfs.TypeDirectory {
+		file := webHDFSFile{rel: path.Base(sourcePath), remote: sourcePath, status: *status}
+		return d.downloadWebHDFSFiles(ctx, logger, client, targetDir, []webHDFSFile{file})
+	}
+
+	localDir := targetDir
+	if sourcePath != "/" {
+		localDir = filepath.Join(targetDir, path.Base(sourcePath))
+	}
+	var files []webHDFSFile
+	keep := make(map[string]bool)
+	err = client.Walk(ctx, sourcePath, func(rel string, status webhdfs.FileStatus) error {
+		if status.Type != webhdfs.TypeFile || !d.modelHadoopOptions.selected(rel) {
+			return nil
+		}
+		files = append(files, webHDFSFile{rel: rel, remote: path.Join(sourcePath, rel), status: status})
+		for p := rel; p != "."; p = path.Dir(p) {
+			keep[p] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	logger.Infof("found %d files to download under %s", len(files), sourcePath)
+
+	if err := d.downloadWebHDFSFiles(ctx, logger, client, localDir, files); err != nil {
+		return err
+	}
+
+	if d.modelHadoopOptions.SyncMode == syncModeSync {
+		return pruneExtraneous(logger, localDir, func(rel string) bool {
+			return keep[rel]
+		})
+	}
+	return nil
+}
+
+func (d *ModelHadoopLoader) downloadWebHDFSFiles(ctx context.Context, logger *logrus.Entry, client *webhdfs.Client, localDir string, files []webHDFSFile) error {
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(d.modelHadoopOptions.Concurrency)
+	for _, file := range files {
+		g.Go(func() error {
+			return downloadWebHDFSFile(ctx, logger, client, filepath.Join(localDir, filepath.FromSlash(file.rel)), file)
+		})
+	}
+
+	return g.Wait()
+}
+
+// downloadWebHDFSFile downloads a file unless the local copy has the same
+// size and modification time, the modification time of the remote file is
+// kept to detect unchanged files on the next sync.
+func downloadWebHDFSFile(ctx context.Context, logger *logrus.Entry, client *webhdfs.Client, localPath string, file webHDFSFile) error {
+	modTime := time.UnixMilli(file.status.ModificationTime)
+	if stat, err := os.Stat(localPath); err == nil && stat.Mode().IsRegular() && stat.Size() == file.status.Length && stat.ModTime().Equal(modTime) {
+		logger.Debugf("%s is up to date, skipped", file.remote)
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil { // #nosec G301
+		return err
+	}
+	body, err := client.Open(ctx, file.remote)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = body.Close()
+	}()
+
+	tmp, err := os.CreateTemp(filepath.Dir(localPath), "."+filepath.Base(localPath)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	n, err := io.Copy(tmp, body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", file.remote, err)
+	}
+	if n != file.status.Length {
+		return fmt.Errorf("failed to download %s: got %d bytes, expected %d", file.remote, n, file.status.Length)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil { // #nosec G302
+		return err
+	}
+	if err := os.Chtimes(tmp.Name(), modTime, modTime); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), localPath); err != nil {
+		return err
+	}
+	logger.Infof("downloaded %s (%d bytes)", file.remote, n)
+
+	return nil
+}
+
+// webHDFSClient returns a client of the NameNode or HttpFS gateway of the
+// webhdfs:// or swebhdfs:// URI, which defaults to the ports of the NameNode.
+func (d *ModelHadoopLoader) webHDFSClient(u *url.URL) (*webhdfs.Client, error) {
+	scheme, port := "http", defaultWebHDFSPort
+	if u.Scheme == "swebhdfs" {
+		scheme, port = "https", defaultSWebHDFSPort
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if strings.TrimSpace(d.modelHadoopOptions.caCert) != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(d.modelHadoopOptions.caCert)) {
+			return nil, fmt.Errorf("failed to parse CA certificate, no PEM encoded certificates found")
+		}
+		transport.TLSClientConfig = &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			RootCAs:    pool,
+		}
+	}
+
+	opts := webhdfs.Options{
+		Username:  d.modelHadoopOptions.Username,
+		Transport: transport,
+	}
+	if d.modelHadoopOptions.keytab != "" {
+		kerberos, err := d.kerberosLogin()
+		if err != nil {
+			return nil, err
+		}
+		opts.Kerberos = kerberos
+	}
+
+	return webhdfs.New(scheme+"://"+host, opts)
+}
+
+// kerberosLogin logs in with the keytab of the secret, the krb5.conf mounted
+// by the controller is used, or the one KRB5_CONFIG points to.
+func (d *ModelHadoopLoader) kerberosLogin() (*krb5client.Client, error) {
+	configPath := lo.CoalesceOrEmpty(os.Getenv("KRB5_CONFIG"), defaultKrb5ConfigPath)
+	krb5conf, err := config.Load(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kerberos config %s: %w", configPath, err)
+	}
+
+	kt := keytab.New()
+	if err := kt.Unmarshal([]byte(d.modelHadoopOptions.keytab)); err != nil {
+		return nil, fmt.Errorf("failed to parse keytab: %w", err)
+	}
+
+	name, realm, err := parsePrincipal(d.modelHadoopOptions.principal)
+	if err != nil {
+		return nil, err
+	}
+	realm = lo.CoalesceOrEmpty(realm, krb5conf.LibDefaults.DefaultRealm)
+
+	client := krb5client.NewWithKeytab(name, realm, kt, krb5conf, krb5client.DisablePAFXFAST(true))
+	if err := client.Login(); err != nil {
+		return nil, fmt.Errorf("failed to login as %s: %w", d.modelHadoopOptions.principal, err)
+	}
+
+	return client, nil
+}
+
+// selected reports whether a file, given by its path relative to sourcePath,
+// matches the include patterns and none of the exclude patterns.
+func (o ModelHadoopLoaderOptions) selected(rel string) bool {
+	if len(o.includePatterns) > 0 && !matchAnyGlob(o.includePatterns, rel) {
+		return false
+	}
+	return !matchAnyGlob(o.excludePatterns, rel)
+}
+
+// parseGlobPatterns parses comma separated glob patterns.
+func parseGlobPatterns(s string) ([]string, error) {
+	var patterns []string
+	for _, pattern := range strings.Split(s, ",") {
+		pattern = strings.Trim(strings.TrimSpace(pattern), "/")
+		if pattern == "" {
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid glob pattern %q: %w", pattern, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+
+	return patterns, nil
+}
+
+// matchAnyGlob reports whether any pattern matches rel or one of its parent
+// directories, a pattern without a slash matches any path component, so that
+// *.parquet matches a/b.parquet and logs matches everything under logs.
+func matchAnyGlob(patterns []string, rel string) bool {
+	components := strings.Split(rel, "/")
+	for _, pattern := range patterns {
+		for i, component := range components {
+			if ok, _ := path.Match(pattern, strings.Join(components[:i+1], "/")); ok {
+				return true
+			}
+			if strings.Contains(pattern, "/") {
+				continue
+			}
+			if ok, _ := path.Match(pattern, component); ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
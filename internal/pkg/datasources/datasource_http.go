@@ -55,10 +55,7 @@ type HTTPLoaderOptions struct {
 }
 
 func (d *HTTPLoader) validateOptions(options HTTPLoaderOptions) error {
-	if options.SyncMode != "" && options.SyncMode != "sync" && options.SyncMode != "copy" {
-		return fmt.Errorf("invalid syncMode '%s', must be 'sync' or 'copy'", options.SyncMode)
-	}
-	return nil
+	return validateSyncMode(options.SyncMode)
 }
 
 func (d *HTTPLoader) configTouch() error {
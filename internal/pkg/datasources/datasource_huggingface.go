@@ -1,6 +1,7 @@
 package datasources
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/url"
@@ -8,18 +9,28 @@ import (
 	"os/exec"
 	"strings"
 
+	"github.com/samber/lo"
 	"github.com/sirupsen/logrus"
 
+	"github.com/BaizeAI/dataset/internal/pkg/blobcache"
+	"github.com/BaizeAI/dataset/internal/pkg/jobresult"
+	"github.com/BaizeAI/dataset/pkg/datasource/huggingface"
 	"github.com/BaizeAI/dataset/pkg/log"
 	"github.com/BaizeAI/dataset/pkg/utils"
 )
 
-var _ Loader = &HuggingFaceLoader{}
+var (
+	_ Exporter        = &HuggingFaceLoader{}
+	_ BlobCacheLoader = &HuggingFaceLoader{}
+)
+
+const huggingFaceExportDefaultRevision = "main"
 
 type HuggingFaceLoader struct {
 	Options Options
 
 	huggingFaceOptions HuggingFaceLoaderOptions
+	blobCache          *blobcache.Cache
 }
 
 func NewHuggingFaceLoader(datasourceOptions map[string]string, options Options, secrets Secrets) (*HuggingFaceLoader, error) {
@@ -49,6 +60,9 @@ type HuggingFaceLoaderOptions struct {
 	Include  string `json:"include"`
 	Exclude  string `json:"exclude"`
 
+	// options of exports
+	CommitMessage string `json:"commitMessage"`
+
 	token string
 }
 
@@ -145,16 +159,72 @@ func (d *HuggingFaceLoader) whoAmI(logger *logrus.Entry) (string, error) {
 	return outputString, nil
 }
 
-func (d *HuggingFaceLoader) Sync(fromURI string, toPath string) error {
-	parsedURL, err := url.Parse(d.Options.URI)
+func (d *HuggingFaceLoader) repoName(uri string) (string, error) {
+	parsedURL, err := url.Parse(uri)
 	if err != nil {
-		return err
+		return "", err
 	}
 	if parsedURL.Scheme != "huggingface" {
-		return fmt.Errorf("invalid scheme %s, only huggingface is supported", parsedURL.Scheme)
+		return "", fmt.Errorf("invalid scheme %s, only huggingface is supported", parsedURL.Scheme)
+	}
+
+	return parsedURL.Host + parsedURL.Path, nil
+}
+
+func (d *HuggingFaceLoader) authorize(logger *logrus.Entry, token string) error {
+	_, err := d.env(logger)
+	if err != nil {
+		return err
+	}
+
+	if token != "" {
+		err = d.login(logger, token)
+		if err != nil {
+			return err
+		}
+
+		whoAmI, err := d.whoAmI(logger)
+		if err != nil {
+			return err
+		}
+
+		logger.Debugf("huggingface-cli executed with authorized login handle as: %s", whoAmI)
+	}
+
+	return nil
+}
+
+func (d *HuggingFaceLoader) SetBlobCache(cache *blobcache.Cache) {
+	d.blobCache = cache
+}
+
+// blobs returns the LFS files of the repository to download, the other files
+// are small and have no SHA256.
+func (d *HuggingFaceLoader) blobs(logger *logrus.Entry, token string, repoName string, repoType string) []blobcache.Blob {
+	// the default revision is downloaded
+	files, err := huggingface.NewHfAPIClientWithEndpoint(d.huggingFaceOptions.Endpoint).ListRepoFiles(context.Background(), token, repoType, repoName, huggingFaceExportDefaultRevision)
+	if err != nil {
+		logger.Warnf("failed to list the files of %s, the blob cache is not used: %v", repoName, err)
+		return nil
+	}
+
+	blobs := make([]blobcache.Blob, 0, len(files))
+	for _, file := range files {
+		if file.LFS == nil || file.LFS.OID == "" {
+			continue
+		}
+		blobs = append(blobs, blobcache.Blob{Path: file.Path, Key: blobcache.SHA256Key(file.LFS.OID), Size: file.LFS.Size})
+	}
+
+	return filterBlobs(blobs, d.huggingFaceOptions.Include, d.huggingFaceOptions.Exclude)
+}
+
+func (d *HuggingFaceLoader) Sync(fromURI string, toPath string) error {
+	repoName, err := d.repoName(d.Options.URI)
+	if err != nil {
+		return err
 	}
 
-	repoName := parsedURL.Host + parsedURL.Path
 	repoType := d.mapRepoTypeEnumStringToHuggingFaceRepoType(d.huggingFaceOptions.RepoType)
 
 	logger := log.WithFields(logrus.Fields{
@@ -175,23 +245,17 @@ func (d *HuggingFaceLoader) Sync(fromURI string, toPath string) error {
 
 	logger.Debugf("performing huggingface-cli download command to pull data from %s to %s", fromURI, toPath)
 
-	_, err = d.env(logger)
+	err = d.authorize(logger, token)
 	if err != nil {
 		return err
 	}
 
-	if d.huggingFaceOptions.token != "" {
-		err = d.login(logger, token)
-		if err != nil {
-			return err
-		}
-
-		whoAmI, err := d.whoAmI(logger)
-		if err != nil {
-			return err
-		}
-
-		logger.Debugf("huggingface-cli executed with authorized login handle as: %s", whoAmI)
+	// huggingface-cli skips the files restored from the blob cache as their
+	// SHA256 matches
+	var blobs []blobcache.Blob
+	if d.blobCache != nil && !d.huggingFaceOptions.Offline {
+		blobs = d.blobs(logger, token, repoName, repoType)
+		d.blobCache.Restore(syncDir(d.Options.Root, toPath), blobs)
 	}
 
 	args := []string{
@@ -238,5 +302,89 @@ func (d *HuggingFaceLoader) Sync(fromURI string, toPath string) error {
 	}
 	logger.Debugf("huggingface-cli download command output: %s", outBuffer.String())
 
+	if d.blobCache != nil {
+		d.blobCache.Store(syncDir(d.Options.Root, toPath), blobs)
+	}
+
 	return nil
 }
+
+// Export uploads the content of fromPath to the root of the repository as a
+// commit on the revision, the result reports the commit of the revision
+// after the upload.
+func (d *HuggingFaceLoader) Export(fromPath string, toURI string) (jobresult.Result, error) {
+	repoName, err := d.repoName(toURI)
+	if err != nil {
+		return jobresult.Result{}, err
+	}
+
+	repoType := d.mapRepoTypeEnumStringToHuggingFaceRepoType(d.huggingFaceOptions.RepoType)
+	revision := lo.CoalesceOrEmpty(d.huggingFaceOptions.Revision, huggingFaceExportDefaultRevision)
+
+	logger := log.WithFields(logrus.Fields{
+		"fromPath": fromPath,
+		"type":     TypeHuggingFace,
+		"toURI":    toURI,
+		"repoName": repoName,
+		"revision": revision,
+		"repoType": repoType,
+		"endpoint": d.huggingFaceOptions.Endpoint,
+		"include":  d.huggingFaceOptions.Include,
+		"exclude":  d.huggingFaceOptions.Exclude,
+	})
+
+	token := strings.TrimSpace(d.huggingFaceOptions.token)
+
+	err = d.authorize(logger, token)
+	if err != nil {
+		return jobresult.Result{}, err
+	}
+
+	args := []string{
+		"upload",
+		repoName,
+		fromPath,
+		".",
+		"--revision",
+		revision,
+	}
+	if repoType != "" {
+		args = append(args, "--repo-type", repoType)
+	}
+	if d.huggingFaceOptions.CommitMessage != "" {
+		args = append(args, "--commit-message", d.huggingFaceOptions.CommitMessage)
+	}
+	if d.huggingFaceOptions.Include != "" {
+		args = append(args, "--include", d.huggingFaceOptions.Include)
+	}
+	if d.huggingFaceOptions.Exclude != "" {
+		args = append(args, "--exclude", d.huggingFaceOptions.Exclude)
+	}
+
+	cmd := exec.Command("huggingface-cli", args...)
+
+	logger = logger.WithField("command", cmd.String())
+	logger.Debug("executing command to upload data with huggingface-cli")
+
+	cmd.Env = os.Environ()
+	cmd.Env = append(cmd.Env, "HF_HUB_VERBOSITY=debug")
+	cmd.Env = append(cmd.Env, "DO_NOT_TRACK=1") // https://consoledonottrack.com/
+
+	if d.huggingFaceOptions.Endpoint != "" {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("HF_ENDPOINT=%s", d.huggingFaceOptions.Endpoint))
+	}
+
+	outBuffer, errBuffer, err := utils.ExecuteCommandWithAllOutput(logger, cmd, []string{token})
+	if err != nil {
+		logger.Errorf("huggingface-cli upload command error: %s", errBuffer)
+		return jobresult.Result{}, fmt.Errorf("failed to export data from %s to %s with huggingface-cli command %s, err: %s", fromPath, toURI, utils.ObscureString(cmd.String(), []string{token}), err)
+	}
+	logger.Debugf("huggingface-cli upload command output: %s", outBuffer.String())
+
+	repoInfo, err := huggingface.NewHfAPIClientWithEndpoint(d.huggingFaceOptions.Endpoint).RepoInfo(context.Background(), token, repoType, repoName, revision)
+	if err != nil {
+		return jobresult.Result{}, fmt.Errorf("failed to get the revision %s of %s: %w", revision, repoName, err)
+	}
+
+	return jobresult.Result{Revision: repoInfo.SHA}, nil
+}
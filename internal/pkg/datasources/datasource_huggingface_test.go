@@ -2,6 +2,8 @@
 package datasources
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"strings"
 	"testing"
@@ -70,3 +72,44 @@ func TestHuggingFaceLoader(t *testing.T) {
 	assert.Equal(t, string(bbs[2]), "whoami\n")
 	assert.Equal(t, string(bbs[3]), strings.Join([]string{"download", "ns/model", "--local-dir", huggingFaceDir, "--resume-download"}, " ")+"\n")
 }
+
+func TestHuggingFaceLoaderExport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/api/datasets/ns/data/revision/main", req.URL.Path)
+		assert.Equal(t, "Bearer test-token", req.Header.Get("Authorization"))
+		_, _ = rw.Write([]byte(`{"id":"ns/data","sha":"0123abcd"}`))
+	}))
+	defer server.Close()
+
+	loader, err := NewHuggingFaceLoader(map[string]string{
+		"endpoint":      server.URL,
+		"repoType":      "DATASET",
+		"commitMessage": "export data",
+	}, Options{}, Secrets{
+		Token: "test-token",
+	})
+	require.NoError(t, err)
+	fakeHF := fakeCommand{
+		t:   t,
+		cmd: "huggingface-cli",
+		outputs: []out{
+			{stdout: "env"},
+			{stdout: "login"},
+			{stdout: "whoami"},
+			{stdout: "upload"},
+		},
+	}
+	defer func() {
+		assert.NoError(t, fakeHF.Clean())
+	}()
+
+	exportDir := t.TempDir()
+	fakeHF.WithContext(func() {
+		result, err := loader.Export(exportDir, "huggingface://ns/data")
+		require.NoError(t, err)
+		assert.Equal(t, "0123abcd", result.Revision)
+	})
+	bbs := fakeHF.GetAllInputs()
+	require.Len(t, bbs, 4)
+	assert.Equal(t, strings.Join([]string{"upload", "ns/data", exportDir, ".", "--revision", "main", "--repo-type", "dataset", "--commit-message", "export data"}, " ")+"\n", string(bbs[3]))
+}
@@ -1,6 +1,7 @@
 package datasources
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/url"
@@ -8,18 +9,28 @@ import (
 	"os/exec"
 	"strings"
 
+	"github.com/samber/lo"
 	"github.com/sirupsen/logrus"
 
+	"github.com/BaizeAI/dataset/internal/pkg/blobcache"
+	"github.com/BaizeAI/dataset/internal/pkg/jobresult"
+	"github.com/BaizeAI/dataset/pkg/datasource/modelscope"
 	"github.com/BaizeAI/dataset/pkg/log"
 	"github.com/BaizeAI/dataset/pkg/utils"
 )
 
-var _ Loader = &ModelScopeLoader{}
+var (
+	_ Exporter        = &ModelScopeLoader{}
+	_ BlobCacheLoader = &ModelScopeLoader{}
+)
+
+const modelScopeExportDefaultRevision = "master"
 
 type ModelScopeLoader struct {
 	Options Options
 
 	modelScopeOptions ModelScopeLoaderOptions
+	blobCache         *blobcache.Cache
 }
 
 func NewModelScopeLoader(datasourceOptions map[string]string, options Options, secrets Secrets) (*ModelScopeLoader, error) {
@@ -42,6 +53,9 @@ type ModelScopeLoaderOptions struct {
 	Include  string `json:"include"`
 	Exclude  string `json:"exclude"`
 
+	// options of exports
+	CommitMessage string `json:"commitMessage"`
+
 	token string
 }
 
@@ -89,16 +103,52 @@ func (d *ModelScopeLoader) login(logger *logrus.Entry, token string) error {
 	return nil
 }
 
-func (d *ModelScopeLoader) Sync(fromURI string, toPath string) error {
-	parsedURL, err := url.Parse(d.Options.URI)
+func (d *ModelScopeLoader) repoName(uri string) (string, error) {
+	parsedURL, err := url.Parse(uri)
 	if err != nil {
-		return err
+		return "", err
 	}
 	if parsedURL.Scheme != "modelscope" {
-		return fmt.Errorf("invalid scheme %s, only modelscope is supported", parsedURL.Scheme)
+		return "", fmt.Errorf("invalid scheme %s, only modelscope is supported", parsedURL.Scheme)
+	}
+
+	return parsedURL.Host + parsedURL.Path, nil
+}
+
+func (d *ModelScopeLoader) SetBlobCache(cache *blobcache.Cache) {
+	d.blobCache = cache
+}
+
+// blobs returns the files of the model to download with their SHA256, the
+// files of datasets are not listed by the hub.
+func (d *ModelScopeLoader) blobs(logger *logrus.Entry, repoName string, repoType string) []blobcache.Blob {
+	if repoType == "dataset" {
+		return nil
+	}
+
+	// the default revision is downloaded
+	files, err := modelscope.NewHubAPIClient().ListModelFiles(context.Background(), repoName, modelScopeExportDefaultRevision)
+	if err != nil {
+		logger.Warnf("failed to list the files of %s, the blob cache is not used: %v", repoName, err)
+		return nil
 	}
 
-	repoName := parsedURL.Host + parsedURL.Path
+	blobs := make([]blobcache.Blob, 0, len(files))
+	for _, file := range files {
+		if file.Sha256 == "" {
+			continue
+		}
+		blobs = append(blobs, blobcache.Blob{Path: file.Path, Key: blobcache.SHA256Key(file.Sha256), Size: file.Size})
+	}
+
+	return filterBlobs(blobs, d.modelScopeOptions.Include, d.modelScopeOptions.Exclude)
+}
+
+func (d *ModelScopeLoader) Sync(fromURI string, toPath string) error {
+	repoName, err := d.repoName(d.Options.URI)
+	if err != nil {
+		return err
+	}
 	repoType := d.mapRepoTypeEnumStringToModelScopeRepoType(d.modelScopeOptions.RepoType)
 
 	logger := log.WithFields(logrus.Fields{
@@ -124,6 +174,12 @@ func (d *ModelScopeLoader) Sync(fromURI string, toPath string) error {
 		}
 	}
 
+	var blobs []blobcache.Blob
+	if d.blobCache != nil {
+		blobs = d.blobs(logger, repoName, repoType)
+		d.blobCache.Restore(syncDir(d.Options.Root, toPath), blobs)
+	}
+
 	args := []string{
 		"download",
 		repoName,
@@ -158,5 +214,80 @@ func (d *ModelScopeLoader) Sync(fromURI string, toPath string) error {
 
 	logger.Debugf("modelscope download command output: %s", outBuffer.String())
 
+	if d.blobCache != nil {
+		d.blobCache.Store(syncDir(d.Options.Root, toPath), blobs)
+	}
+
 	return nil
 }
+
+// Export uploads the content of fromPath to the root of the repository as a
+// commit on the revision. The hub does not report the commit of an upload,
+// the result reports the uploaded revision.
+func (d *ModelScopeLoader) Export(fromPath string, toURI string) (jobresult.Result, error) {
+	repoName, err := d.repoName(toURI)
+	if err != nil {
+		return jobresult.Result{}, err
+	}
+
+	repoType := d.mapRepoTypeEnumStringToModelScopeRepoType(d.modelScopeOptions.RepoType)
+	revision := lo.CoalesceOrEmpty(d.modelScopeOptions.Revision, modelScopeExportDefaultRevision)
+
+	logger := log.WithFields(logrus.Fields{
+		"fromPath": fromPath,
+		"type":     TypeModelScope,
+		"toURI":    toURI,
+		"repoName": repoName,
+		"revision": revision,
+		"repoType": repoType,
+		"include":  d.modelScopeOptions.Include,
+		"exclude":  d.modelScopeOptions.Exclude,
+	})
+
+	token := strings.TrimSpace(d.modelScopeOptions.token)
+
+	if token != "" {
+		err = d.login(logger, token)
+		if err != nil {
+			return jobresult.Result{}, err
+		}
+	}
+
+	args := []string{
+		"upload",
+		repoName,
+		fromPath,
+		"--revision",
+		revision,
+	}
+	if repoType != "" {
+		args = append(args, "--repo-type", repoType)
+	}
+	if d.modelScopeOptions.CommitMessage != "" {
+		args = append(args, "--commit-message", d.modelScopeOptions.CommitMessage)
+	}
+	if d.modelScopeOptions.Include != "" {
+		args = append(args, "--include", d.modelScopeOptions.Include)
+	}
+	if d.modelScopeOptions.Exclude != "" {
+		args = append(args, "--exclude", d.modelScopeOptions.Exclude)
+	}
+
+	cmd := exec.Command("modelscope", args...)
+
+	logger = logger.WithField("command", cmd.String())
+	logger.Debug("executing command to upload data with modelscope")
+
+	cmd.Env = os.Environ()
+	cmd.Env = append(cmd.Env, "DO_NOT_TRACK=1") // https://consoledonottrack.com/
+
+	outBuffer, errBuffer, err := utils.ExecuteCommandWithAllOutput(logger, cmd, []string{token})
+	if err != nil {
+		logger.Errorf("modelscope upload command error: %s", errBuffer)
+		return jobresult.Result{}, fmt.Errorf("failed to export data from %s to %s with modelscope command %s, err: %s", fromPath, toURI, utils.ObscureString(cmd.String(), []string{token}), err)
+	}
+
+	logger.Debugf("modelscope upload command output: %s", outBuffer.String())
+
+	return jobresult.Result{Revision: revision}, nil
+}
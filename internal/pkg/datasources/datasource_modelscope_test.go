@@ -56,3 +56,32 @@ func TestModelScopeLoader(t *testing.T) {
 	assert.Equal(t, string(bbs[0]), "login --token test-token\n")
 	assert.Equal(t, string(bbs[1]), strings.Join([]string{"download", "ns/model", "--local_dir", modelScopeDir}, " ")+"\n")
 }
+
+func TestModelScopeLoaderExport(t *testing.T) {
+	loader, err := NewModelScopeLoader(map[string]string{"repoType": "MODEL"}, Options{}, Secrets{
+		Token: "test-token",
+	})
+	require.NoError(t, err)
+	fakeMS := fakeCommand{
+		t:   t,
+		cmd: "modelscope",
+		outputs: []out{
+			{stdout: "login"},
+			{stdout: "upload"},
+		},
+	}
+	defer func() {
+		assert.NoError(t, fakeMS.Clean())
+	}()
+
+	exportDir := t.TempDir()
+	fakeMS.WithContext(func() {
+		result, err := loader.Export(exportDir, "modelscope://ns/model")
+		require.NoError(t, err)
+		assert.Equal(t, "master", result.Revision)
+	})
+	bbs := fakeMS.GetAllInputs()
+	require.Len(t, bbs, 2)
+	assert.Equal(t, "login --token test-token\n", string(bbs[0]))
+	assert.Equal(t, strings.Join([]string{"upload", "ns/model", exportDir, "--revision", "master", "--repo-type", "model"}, " ")+"\n", string(bbs[1]))
+}
@@ -0,0 +1,325 @@
+package datasources
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/samber/lo"
+	"github.com/sirupsen/logrus"
+
+	"github.com/BaizeAI/dataset/internal/pkg/jobresult"
+	"github.com/BaizeAI/dataset/pkg/log"
+	"github.com/BaizeAI/dataset/pkg/utils"
+)
+
+const (
+	// ociTitleAnnotation is the file name of a layer pushed by ORAS.
+	ociTitleAnnotation = "org.opencontainers.image.title"
+	// orasUnpackAnnotation marks the layers of ORAS which are directories
+	// packed as tar archives, named by ociTitleAnnotation.
+	orasUnpackAnnotation = "io.deis.oras.content.unpack"
+	// modelPackFilepathAnnotation is the file path of a layer of a ModelPack
+	// artifact.
+	modelPackFilepathAnnotation = "org.cncf.model.filepath"
+
+	// dockerConfigJSONKey is the key of the secrets of the
+	// kubernetes.io/dockerconfigjson type.
+	dockerConfigJSONKey = ".dockerconfigjson"
+)
+
+var _ ResultLoader = &OCILoader{}
+
+type OCILoader struct {
+	Options Options
+
+	ociOptions OCILoaderOptions
+	secrets    Secrets
+	digest     string
+}
+
+type OCILoaderOptions struct {
+	// Platform is selected from image indexes, defaults to linux and the
+	// architecture of the data loader.
+	Platform string `json:"platform"`
+	// Raw places every layer as a file, without extracting the tar archives.
+	Raw bool `json:"raw,string"`
+	// PlainHTTP pulls from registries which do not serve HTTPS.
+	PlainHTTP bool   `json:"plainHttp,string"`
+	SyncMode  string `json:"syncMode"`
+
+	platform *v1.Platform
+}
+
+func NewOCILoader(datasourceOptions map[string]string, options Options, secrets Secrets) (*OCILoader, error) {
+	res := new(OCILoader)
+	jsonContent, err := json.Marshal(datasourceOptions)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(jsonContent, &res.ociOptions)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateSyncMode(res.ociOptions.SyncMode); err != nil {
+		return nil, err
+	}
+
+	res.ociOptions.SyncMode = lo.CoalesceOrEmpty(res.ociOptions.SyncMode, syncModeSync)
+	res.ociOptions.platform, err = v1.ParsePlatform(lo.CoalesceOrEmpty(res.ociOptions.Platform, "linux/"+runtime.GOARCH))
+	if err != nil {
+		return nil, fmt.Errorf("invalid platform %s: %w", res.ociOptions.Platform, err)
+	}
+	res.Options = options
+	res.secrets = secrets
+
+	return res, nil
+}
+
+// parseOCIReference parses oci://<registry>/<repository>[:<tag>][@<digest>],
+// the digest is pulled and verified when both the tag and the digest are set.
+func parseOCIReference(uri string, plainHTTP bool) (name.Reference, error) {
+	ref, ok := strings.CutPrefix(uri, "oci://")
+	if !ok {
+		return nil, fmt.Errorf("invalid uri %s, expected oci://<registry>/<repository>[:<tag>][@<digest>]", uri)
+	}
+
+	var opts []name.Option
+	if plainHTTP {
+		opts = append(opts, name.Insecure)
+	}
+
+	return name.ParseReference(ref, opts...)
+}
+
+func (d *OCILoader) Sync(fromURI string, toPath string) error {
+	ref, err := parseOCIReference(d.Options.URI, d.ociOptions.PlainHTTP)
+	if err != nil {
+		return err
+	}
+
+	logger := log.WithFields(logrus.Fields{
+		"fromURI":          fromURI,
+		"type":             TypeOCI,
+		"toPath":           toPath,
+		"workingDirectory": d.Options.Root,
+		"reference":        ref.String(),
+		"platform":         d.ociOptions.platform.String(),
+		"syncMode":         d.ociOptions.SyncMode,
+	})
+
+	targetDir := filepath.Join(d.Options.Root, toPath)
+	err = os.MkdirAll(targetDir, 0755) // #nosec G301
+	if err != nil {
+		return err
+	}
+
+	opts, err := d.remoteOptions(context.Background(), ref.Context().Registry)
+	if err != nil {
+		return err
+	}
+
+	desc, err := remote.Get(ref, opts...)
+	if err != nil {
+		return fmt.Errorf("failed to get manifest of %s: %w", ref, err)
+	}
+	if digest, ok := ref.(name.Digest); ok && desc.Digest.String() != digest.DigestStr() {
+		return fmt.Errorf("digest of %s is %s, expected %s", ref, desc.Digest, digest.DigestStr())
+	}
+
+	// the manifest of the platform is selected from indexes
+	img, err := desc.Image()
+	if err != nil {
+		return fmt.Errorf("failed to get image of %s: %w", ref, err)
+	}
+	manifest, err := img.Manifest()
+	if err != nil {
+		return err
+	}
+	imgDigest, err := img.Digest()
+	if err != nil {
+		return err
+	}
+	logger.Infof("pulling %d layers of %s", len(manifest.Layers), imgDigest)
+
+	keep := make(map[string]bool)
+	for _, layerDesc := range manifest.Layers {
+		paths, err := d.pullLayer(logger, img, layerDesc, targetDir)
+		if err != nil {
+			return fmt.Errorf("failed to pull layer %s: %w", layerDesc.Digest, err)
+		}
+		for _, p := range paths {
+			for ; p != "."; p = path.Dir(p) {
+				keep[p] = true
+			}
+		}
+	}
+
+	if d.ociOptions.SyncMode == syncModeSync {
+		err = pruneExtraneous(logger, targetDir, func(rel string) bool {
+			return keep[rel]
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	d.digest = imgDigest.String()
+	logger.Infof("pulled %s", d.digest)
+
+	return nil
+}
+
+// Result reports the digest of the pulled manifest, which is the manifest of
+// the platform for image indexes.
+func (d *OCILoader) Result() jobresult.Result {
+	return jobresult.Result{Digest: d.digest}
+}
+
+func (d *OCILoader) remoteOptions(ctx context.Context, registry name.Registry) ([]remote.Option, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if strings.TrimSpace(d.secrets.CACert) != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(d.secrets.CACert)) {
+			return nil, fmt.Errorf("failed to parse CA certificate, no PEM encoded certificates found")
+		}
+		transport.TLSClientConfig = &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			RootCAs:    pool,
+		}
+	}
+
+	auth, err := registryAuthenticator(d.secrets, registry.RegistryStr())
+	if err != nil {
+		return nil, err
+	}
+
+	return []remote.Option{
+		remote.WithContext(ctx),
+		remote.WithTransport(transport),
+		remote.WithAuth(auth),
+		remote.WithPlatform(*d.ociOptions.platform),
+	}, nil
+}
+
+// dockerConfigJSON is the docker config file, the auth fields are decoded
+// into the username and password by authn.AuthConfig.
+type dockerConfigJSON struct {
+	Auths map[string]authn.AuthConfig `json:"auths"`
+}
+
+// registryAuthenticator returns the credentials of the registry from the
+// .dockerconfigjson of the secret, or from its username and password, or
+// token, keys. Registries are pulled anonymously without credentials.
+func registryAuthenticator(secrets Secrets, registry string) (authn.Authenticator, error) {
+	if dockerConfig, ok := secrets.Data[dockerConfigJSONKey]; ok {
+		var config dockerConfigJSON
+		if err := json.Unmarshal([]byte(dockerConfig), &config); err != nil {
+			return nil, fmt.Errorf("failed to parse %s of the secret: %w", dockerConfigJSONKey, err)
+		}
+		for server, auth := range config.Auths {
+			if dockerConfigServerHost(server) != registry {
+				continue
+			}
+			return authn.FromConfig(auth), nil
+		}
+	}
+
+	username := strings.TrimSpace(secrets.Username)
+	password := strings.TrimSpace(secrets.Password)
+	token := strings.TrimSpace(secrets.Token)
+	switch {
+	case username != "" && password != "":
+		return &authn.Basic{Username: username, Password: password}, nil
+	case username != "" && token != "":
+		return &authn.Basic{Username: username, Password: token}, nil
+	case token != "":
+		return &authn.Bearer{Token: token}, nil
+	default:
+		return authn.Anonymous, nil
+	}
+}
+
+// dockerConfigServerHost returns the host of the keys of docker config
+// files, which are hosts or URLs such as https://index.docker.io/v1/.
+func dockerConfigServerHost(server string) string {
+	server = strings.TrimPrefix(strings.TrimPrefix(server, "https://"), "http://")
+	host, _, _ := strings.Cut(server, "/")
+	if host == "docker.io" {
+		return name.DefaultRegistry
+	}
+
+	return host
+}
+
+// pullLayer places a layer into targetDir, and returns the paths it wrote,
+// relative to targetDir and separated by slashes. Layers named by the title
+// annotation of ORAS are files unless ORAS packed a directory, other tar
+// layers such as the ones of images and ModelPack artifacts are extracted,
+// the others are placed as blobs/<algorithm>/<hex>.
+func (d *OCILoader) pullLayer(logger *logrus.Entry, img v1.Image, desc v1.Descriptor, targetDir string) ([]string, error) {
+	layer, err := img.LayerByDigest(desc.Digest)
+	if err != nil {
+		return nil, err
+	}
+
+	title := desc.Annotations[ociTitleAnnotation]
+	extract := !d.ociOptions.Raw && isTarMediaType(string(desc.MediaType)) &&
+		(title == "" || desc.Annotations[orasUnpackAnnotation] == "true")
+	if extract {
+		logger.Infof("extracting layer %s (%s, %d bytes)", desc.Digest, desc.MediaType, desc.Size)
+		// layers are decompressed according to their content, and their
+		// digests are verified once they are read to the end
+		rc, err := layer.Uncompressed()
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			_ = rc.Close()
+		}()
+
+		return utils.ExtractTar(logger, rc, targetDir)
+	}
+
+	rel := title
+	if rel == "" {
+		rel = desc.Annotations[modelPackFilepathAnnotation]
+	}
+	if rel == "" {
+		rel = path.Join("blobs", desc.Digest.Algorithm, desc.Digest.Hex)
+	}
+	rel, err = utils.CleanRelativePath(rel)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("placing layer %s (%s, %d bytes) as %s", desc.Digest, desc.MediaType, desc.Size, rel)
+	// compressed blobs are verified against the digest of the descriptor
+	rc, err := layer.Compressed()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rc.Close()
+	}()
+
+	return []string{rel}, utils.WriteFileAtomic(filepath.Join(targetDir, filepath.FromSlash(rel)), rc, 0644)
+}
+
+func isTarMediaType(mediaType string) bool {
+	return strings.HasSuffix(mediaType, ".tar") ||
+		strings.Contains(mediaType, ".tar+") ||
+		strings.Contains(mediaType, ".tar.")
+}
@@ -0,0 +1,237 @@
+package datasources
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/pem"
+	"io"
+	stdlog "log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testTarGz(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+	return buf.Bytes()
+}
+
+// testArtifact is a model artifact with a tar layer, a ModelPack raw layer, a
+// file pushed by ORAS and an untitled blob.
+func testArtifact(t *testing.T) (v1.Image, v1.Layer) {
+	untitled := static.NewLayer([]byte("untitled"), "application/octet-stream")
+	img, err := mutate.Append(empty.Image,
+		mutate.Addendum{Layer: static.NewLayer(testTarGz(t, map[string]string{"model/config.json": "{}"}), types.OCILayer)},
+		mutate.Addendum{
+			Layer:       static.NewLayer([]byte("weights"), "application/vnd.cncf.model.weight.v1.raw"),
+			Annotations: map[string]string{modelPackFilepathAnnotation: "model/weights.bin"},
+		},
+		mutate.Addendum{
+			Layer:       static.NewLayer([]byte("readme"), types.OCIUncompressedLayer),
+			Annotations: map[string]string{ociTitleAnnotation: "README.md"},
+		},
+		mutate.Addendum{Layer: untitled},
+	)
+	require.NoError(t, err)
+	img = mutate.MediaType(img, types.OCIManifestSchema1)
+	img = mutate.ConfigMediaType(img, types.OCIConfigJSON)
+	return img, untitled
+}
+
+func pushTestImage(t *testing.T, ref string, img v1.Image, opts ...remote.Option) v1.Hash {
+	parsed, err := name.ParseReference(ref)
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(parsed, img, opts...))
+	digest, err := img.Digest()
+	require.NoError(t, err)
+	return digest
+}
+
+func TestOCILoader(t *testing.T) {
+	var corruptPath string
+	reg := registry.New(registry.Logger(stdlog.New(io.Discard, "", 0)))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && corruptPath != "" && r.URL.Path == corruptPath {
+			_, _ = w.Write([]byte("tampered"))
+			return
+		}
+		reg.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	img, untitled := testArtifact(t)
+	digest := pushTestImage(t, host+"/models/llm:v1", img)
+	untitledDigest, err := untitled.Digest()
+	require.NoError(t, err)
+
+	newLoader := func(t *testing.T, uri string, options map[string]string) (*OCILoader, string) {
+		root := t.TempDir()
+		loader, err := NewOCILoader(options, Options{Type: TypeOCI, URI: uri, Root: root}, Secrets{})
+		require.NoError(t, err)
+		return loader, root
+	}
+
+	t.Run("pull by tag", func(t *testing.T) {
+		loader, root := newLoader(t, "oci://"+host+"/models/llm:v1", nil)
+		require.NoError(t, os.WriteFile(filepath.Join(root, "stale"), nil, 0600))
+
+		require.NoError(t, loader.Sync("", ""))
+		files := map[string]string{
+			"model/config.json": "{}",
+			"model/weights.bin": "weights",
+			"README.md":         "readme",
+			filepath.Join("blobs", "sha256", untitledDigest.Hex): "untitled",
+		}
+		for p, content := range files {
+			data, err := os.ReadFile(filepath.Join(root, p))
+			require.NoError(t, err)
+			assert.Equal(t, content, string(data))
+		}
+		assert.NoFileExists(t, filepath.Join(root, "stale"))
+		assert.Equal(t, digest.String(), loader.Result().Digest)
+	})
+
+	t.Run("pull by digest", func(t *testing.T) {
+		loader, root := newLoader(t, "oci://"+host+"/models/llm:v1@"+digest.String(), map[string]string{"raw": "true", "syncMode": "copy"})
+		require.NoError(t, os.WriteFile(filepath.Join(root, "kept"), nil, 0600))
+
+		require.NoError(t, loader.Sync("", ""))
+		layers, err := img.Layers()
+		require.NoError(t, err)
+		tarDigest, err := layers[0].Digest()
+		require.NoError(t, err)
+		assert.FileExists(t, filepath.Join(root, "blobs", "sha256", tarDigest.Hex))
+		assert.NoFileExists(t, filepath.Join(root, "model", "config.json"))
+		assert.FileExists(t, filepath.Join(root, "kept"))
+
+		loader, _ = newLoader(t, "oci://"+host+"/models/llm@sha256:"+strings.Repeat("0", 64), nil)
+		assert.ErrorContains(t, loader.Sync("", ""), "failed to get manifest")
+	})
+
+	t.Run("tampered blob", func(t *testing.T) {
+		corruptPath = "/v2/models/llm/blobs/" + untitledDigest.String()
+		defer func() {
+			corruptPath = ""
+		}()
+		loader, _ := newLoader(t, "oci://"+host+"/models/llm:v1", nil)
+		err := loader.Sync("", "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), untitledDigest.String())
+	})
+
+	t.Run("platform of index", func(t *testing.T) {
+		arm, err := mutate.Append(empty.Image, mutate.Addendum{Layer: static.NewLayer(testTarGz(t, map[string]string{"arch": "arm64"}), types.OCILayer)})
+		require.NoError(t, err)
+		armDigest, err := arm.Digest()
+		require.NoError(t, err)
+		amd, err := mutate.Append(empty.Image, mutate.Addendum{Layer: static.NewLayer(testTarGz(t, map[string]string{"arch": "amd64"}), types.OCILayer)})
+		require.NoError(t, err)
+		index := mutate.AppendManifests(mutate.IndexMediaType(empty.Index, types.OCIImageIndex),
+			mutate.IndexAddendum{Add: amd, Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: "amd64"}}},
+			mutate.IndexAddendum{Add: arm, Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: "arm64"}}},
+		)
+		ref, err := name.ParseReference(host + "/images/multi:v1")
+		require.NoError(t, err)
+		require.NoError(t, remote.WriteIndex(ref, index))
+
+		loader, root := newLoader(t, "oci://"+host+"/images/multi:v1", map[string]string{"platform": "linux/arm64"})
+		require.NoError(t, loader.Sync("", ""))
+		data, err := os.ReadFile(filepath.Join(root, "arch"))
+		require.NoError(t, err)
+		assert.Equal(t, "arm64", string(data))
+		assert.Equal(t, armDigest.String(), loader.Result().Digest)
+	})
+}
+
+func TestOCILoaderAuthAndCA(t *testing.T) {
+	reg := registry.New(registry.Logger(stdlog.New(io.Discard, "", 0)))
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != "robot" || password != "s3cret" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		reg.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "https://")
+
+	img, _ := testArtifact(t)
+	pushTestImage(t, host+"/models/llm:v1", img,
+		remote.WithTransport(server.Client().Transport),
+		remote.WithAuth(&authn.Basic{Username: "robot", Password: "s3cret"}),
+	)
+	caCert := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+
+	pull := func(secrets Secrets) error {
+		loader, err := NewOCILoader(nil, Options{Type: TypeOCI, URI: "oci://" + host + "/models/llm:v1", Root: t.TempDir()}, secrets)
+		require.NoError(t, err)
+		return loader.Sync("", "")
+	}
+
+	assert.ErrorContains(t, pull(Secrets{Username: "robot", Password: "s3cret"}), "certificate")
+	assert.ErrorContains(t, pull(Secrets{CACert: caCert}), "401 Unauthorized")
+	assert.NoError(t, pull(Secrets{CACert: caCert, Username: "robot", Password: "s3cret"}))
+	assert.NoError(t, pull(Secrets{CACert: caCert, Data: map[string]string{
+		dockerConfigJSONKey: `{"auths":{"https://` + host + `/v1/":{"auth":"cm9ib3Q6czNjcmV0"}}}`,
+	}}))
+}
+
+func TestRegistryAuthenticator(t *testing.T) {
+	auth, err := registryAuthenticator(Secrets{Data: map[string]string{
+		dockerConfigJSONKey: `{"auths":{"https://index.docker.io/v1/":{"username":"user","password":"pass"},"registry.example.com":{"auth":"cm9ib3Q6czNjcmV0"}}}`,
+	}}, "registry.example.com")
+	require.NoError(t, err)
+	config, err := auth.Authorization()
+	require.NoError(t, err)
+	assert.Equal(t, "robot", config.Username)
+	assert.Equal(t, "s3cret", config.Password)
+
+	auth, err = registryAuthenticator(Secrets{Data: map[string]string{
+		dockerConfigJSONKey: `{"auths":{"docker.io":{"username":"user","password":"pass"}}}`,
+	}}, name.DefaultRegistry)
+	require.NoError(t, err)
+	config, err = auth.Authorization()
+	require.NoError(t, err)
+	assert.Equal(t, "user", config.Username)
+
+	auth, err = registryAuthenticator(Secrets{Token: "tok"}, "registry.example.com")
+	require.NoError(t, err)
+	config, err = auth.Authorization()
+	require.NoError(t, err)
+	assert.Equal(t, "tok", config.RegistryToken)
+
+	auth, err = registryAuthenticator(Secrets{}, "registry.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, authn.Anonymous, auth)
+
+	_, err = registryAuthenticator(Secrets{Data: map[string]string{dockerConfigJSONKey: "{"}}, "registry.example.com")
+	assert.ErrorContains(t, err, "failed to parse .dockerconfigjson of the secret")
+}
@@ -1,15 +1,384 @@
 package datasources
 
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/samber/lo"
+	"github.com/sirupsen/logrus"
+
+	"github.com/BaizeAI/dataset/internal/pkg/constants"
+	"github.com/BaizeAI/dataset/internal/pkg/datasources/pixi"
+	"github.com/BaizeAI/dataset/pkg/log"
+	"github.com/BaizeAI/dataset/pkg/utils"
+)
+
+type PixiLoaderOptions struct {
+	Name        string `json:"name"`
+	Environment string `json:"environment"`
+	// Frozen installs pixi.lock as is, by default pixi.lock must satisfy
+	// pixi.toml when it is supplied.
+	Frozen bool `json:"frozen,string"`
+	// Channels replaces the channels of the workspace, comma separated.
+	Channels string `json:"channels"`
+	// ChannelMirrors is a comma separated list of <channel>=<mirror>, a
+	// channel can be repeated to configure several mirrors.
+	ChannelMirrors     string `json:"channelMirrors"`
+	PypiIndexURL       string `json:"pypiIndexUrl"`
+	PypiExtraIndexURLs string `json:"pypiExtraIndexUrls"`
+
+	PixiTomlPath  string `json:"pixiTomlPath"`
+	PixiLockPath  string `json:"pixiLockPath"`
+	PixiPrefixDir string `json:"pixiPrefixDir"`
+
+	pixiToml string
+	pixiLock string
+
+	channels       []string
+	channelMirrors map[string][]string
+
+	// the workspace is installed into prefixingDir, which is where the
+	// volume is mounted when the environment is used, then copied to finalDir
+	prefixingDir string
+	finalDir     string
+}
+
+func (o *PixiLoaderOptions) parseOptionsFromOptions(rawOptions map[string]string, options Options) (PixiLoaderOptions, error) {
+	jsonContent, err := json.Marshal(rawOptions)
+	if err != nil {
+		return PixiLoaderOptions{}, err
+	}
+
+	var loaderOptions PixiLoaderOptions
+	err = json.Unmarshal(jsonContent, &loaderOptions)
+	if err != nil {
+		return PixiLoaderOptions{}, err
+	}
+
+	if loaderOptions.Name == "" && options.URI != "" {
+		// pixi://<name>
+		parsedURI, err := url.Parse(options.URI)
+		if err != nil {
+			return PixiLoaderOptions{}, err
+		}
+		loaderOptions.Name = parsedURI.Host
+	}
+	if loaderOptions.Name == "" {
+		return PixiLoaderOptions{}, fmt.Errorf("missing required options --options name=<env-name>")
+	}
+	if strings.ContainsAny(loaderOptions.Name, `/\`) || loaderOptions.Name == "." || loaderOptions.Name == ".." {
+		return PixiLoaderOptions{}, fmt.Errorf("invalid name %s, must not contain path separators", loaderOptions.Name)
+	}
+
+	loaderOptions.Environment = lo.CoalesceOrEmpty(loaderOptions.Environment, "default")
+	loaderOptions.PixiTomlPath = lo.CoalesceOrEmpty(loaderOptions.PixiTomlPath, constants.DatasetJobPixiManifestPath)
+	loaderOptions.PixiLockPath = lo.CoalesceOrEmpty(loaderOptions.PixiLockPath, constants.DatasetJobPixiLockPath)
+	loaderOptions.PixiPrefixDir = lo.CoalesceOrEmpty(loaderOptions.PixiPrefixDir, constants.DatasetJobCondaMountDir)
+
+	loaderOptions.channels = splitAndTrim(loaderOptions.Channels)
+	loaderOptions.channelMirrors = make(map[string][]string)
+	for _, mirror := range splitAndTrim(loaderOptions.ChannelMirrors) {
+		channel, mirrorURL, ok := strings.Cut(mirror, "=")
+		if !ok || channel == "" || mirrorURL == "" {
+			return PixiLoaderOptions{}, fmt.Errorf("invalid channel mirror %q, must be <channel>=<mirror>", mirror)
+		}
+		loaderOptions.channelMirrors[channel] = append(loaderOptions.channelMirrors[channel], mirrorURL)
+	}
+
+	loaderOptions.prefixingDir = filepath.Join(loaderOptions.PixiPrefixDir, loaderOptions.Name, "pixi")
+	loaderOptions.finalDir = filepath.Join(options.Root, "pixi")
+
+	return loaderOptions, nil
+}
+
+// lockMode returns how pixi.lock is installed, the environment is solved
+// when there is no pixi.lock.
+func (o *PixiLoaderOptions) lockMode() (pixi.LockMode, error) {
+	switch {
+	case o.pixiLock == "" && o.Frozen:
+		return "", fmt.Errorf("frozen requires pixi.lock, set the pixiLock option")
+	case o.pixiLock == "":
+		return pixi.LockModeNone, nil
+	case o.Frozen:
+		return pixi.LockModeFrozen, nil
+	default:
+		return pixi.LockModeLocked, nil
+	}
+}
+
+func splitAndTrim(s string) []string {
+	return lo.Compact(lo.Map(strings.Split(s, ","), func(item string, _ int) string {
+		return strings.TrimSpace(item)
+	}))
+}
+
 var _ Loader = &PixiLoader{}
 
 type PixiLoader struct {
 	Options Options
+
+	loaderOptions PixiLoaderOptions
+	pixi          *pixi.PixiCLI
 }
 
 func NewPixiLoader(datasourceOption map[string]string, options Options, secrets Secrets) (*PixiLoader, error) {
-	return &PixiLoader{}, nil
+	loader := new(PixiLoader)
+	loader.Options = options
+
+	loaderOptions, err := loader.loaderOptions.parseOptionsFromOptions(datasourceOption, options)
+	if err != nil {
+		return nil, err
+	}
+
+	loader.loaderOptions = loaderOptions
+	loader.pixi = pixi.NewPixiCLI()
+	loader.tryReadFile()
+
+	return loader, nil
 }
 
-func (l *PixiLoader) Sync(fromURI string, toPath string) error {
+func (l *PixiLoader) tryReadFile() {
+	logger := log.WithFields(logrus.Fields{
+		"pixiTomlPath": l.loaderOptions.PixiTomlPath,
+		"pixiLockPath": l.loaderOptions.PixiLockPath,
+	})
+
+	pixiToml, err := os.ReadFile(l.loaderOptions.PixiTomlPath)
+	if err != nil {
+		logger.WithError(err).Error("Failed to read pixi.toml")
+	} else {
+		l.loaderOptions.pixiToml = string(pixiToml)
+	}
+
+	pixiLock, err := os.ReadFile(l.loaderOptions.PixiLockPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		logger.WithError(err).Error("Failed to read pixi.lock")
+	} else if err == nil {
+		l.loaderOptions.pixiLock = string(pixiLock)
+	}
+}
+
+// overrideChannels replaces the channels of the workspace in pixi.toml,
+// which is the [workspace] table, or [project] for older manifests.
+func overrideChannels(pixiToml string, channels []string) (string, error) {
+	if len(channels) == 0 {
+		return pixiToml, nil
+	}
+
+	var manifest map[string]any
+	err := toml.Unmarshal([]byte(pixiToml), &manifest)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse pixi.toml: %w", err)
+	}
+
+	table := "workspace"
+	if _, ok := manifest[table]; !ok {
+		table = "project"
+	}
+	workspace, ok := manifest[table].(map[string]any)
+	if !ok {
+		return "", fmt.Errorf("pixi.toml has no [workspace] table")
+	}
+	workspace["channels"] = channels
+
+	data, err := toml.Marshal(manifest)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+/*
+Render the config of the workspace, which pixi reads from .pixi/config.toml:
+
+	[mirrors]
+	"https://conda.anaconda.org/conda-forge" = ["https://mirror.example.com/conda-forge"]
+
+	[pypi-config]
+	index-url = "https://mirror.example.com/simple"
+	extra-index-urls = ["https://sub.example.com/simple"]
+
+About the config: https://pixi.sh/latest/reference/pixi_configuration/
+*/
+func renderPixiConfig(mirrors map[string][]string, pypiIndexURL string, pypiExtraIndexURLs []string) (string, error) {
+	type pypiConfig struct {
+		IndexURL       string   `toml:"index-url,omitempty"`
+		ExtraIndexURLs []string `toml:"extra-index-urls,omitempty"`
+	}
+	config := struct {
+		Mirrors    map[string][]string `toml:"mirrors,omitempty"`
+		PypiConfig *pypiConfig         `toml:"pypi-config,omitempty"`
+	}{
+		Mirrors: mirrors,
+	}
+	if pypiIndexURL != "" || len(pypiExtraIndexURLs) > 0 {
+		config.PypiConfig = &pypiConfig{
+			IndexURL:       pypiIndexURL,
+			ExtraIndexURLs: pypiExtraIndexURLs,
+		}
+	}
+
+	data, err := toml.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// writeWorkspace writes pixi.toml, pixi.lock and the config of the workspace
+// into prefixingDir, leftovers of previous installs are removed.
+func (l *PixiLoader) writeWorkspace(logger *logrus.Entry) error {
+	pixiToml, err := overrideChannels(l.loaderOptions.pixiToml, l.loaderOptions.channels)
+	if err != nil {
+		logger.WithError(err).Error("Failed to override channels")
+		return err
+	}
+
+	pixiConfig, err := renderPixiConfig(
+		l.loaderOptions.channelMirrors,
+		l.loaderOptions.PypiIndexURL,
+		splitAndTrim(l.loaderOptions.PypiExtraIndexURLs),
+	)
+	if err != nil {
+		logger.WithError(err).Error("Failed to render pixi config")
+		return err
+	}
+
+	err = os.RemoveAll(l.loaderOptions.prefixingDir)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Join(l.loaderOptions.prefixingDir, ".pixi"), 0755)
+	if err != nil {
+		return err
+	}
+
+	files := map[string]string{
+		constants.DatasetJobPixiManifestFilename: pixiToml,
+		filepath.Join(".pixi", "config.toml"):    pixiConfig,
+	}
+	if l.loaderOptions.pixiLock != "" {
+		files[constants.DatasetJobPixiLockFilename] = l.loaderOptions.pixiLock
+	}
+	for name, content := range files {
+		err = os.WriteFile(filepath.Join(l.loaderOptions.prefixingDir, name), []byte(content), 0644) // #nosec G306
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (l *PixiLoader) moveToMountRoot(logger *logrus.Entry) error {
+	err := os.MkdirAll(filepath.Dir(l.loaderOptions.finalDir), 0755)
+	if err != nil {
+		logger.WithError(err).Error("Failed to create pixi dir")
+		return err
+	}
+
+	err = os.RemoveAll(l.loaderOptions.finalDir)
+	if err != nil {
+		logger.WithError(err).Error("Failed to remove pixi dir")
+		return err
+	}
+
+	cmd := exec.Command("rclone",
+		"copyto",
+		l.loaderOptions.prefixingDir,
+		l.loaderOptions.finalDir,
+		"--copy-links",
+	) // #nosec G204
+
+	err = utils.ExecuteCommand(logger, cmd, []string{})
+	if err != nil {
+		logger.WithError(err).Error("Failed to move pixi workspace to mount root")
+		return err
+	}
+
+	return nil
+}
+
+// Workflow overview:
+//   - pixi --version
+//   - write pixi.toml, pixi.lock and .pixi/config.toml into /opt/baize-runtime-env/<name>/pixi
+//   - pixi install --manifest-path pixi.toml --environment <environment> [--locked|--frozen]
+//
+// finalize the pixi workspace, the environment is relocated by mounting the
+// volume at /opt/baize-runtime-env/<name> where it was installed:
+//   - mv /opt/baize-runtime-env/<name>/pixi ${mount-root}/pixi
+func (l *PixiLoader) Sync(_ string, _ string) error {
+	logger := log.WithFields(logrus.Fields{
+		"type":        TypePixi,
+		"root":        l.Options.Root,
+		"envName":     l.loaderOptions.Name,
+		"environment": l.loaderOptions.Environment,
+	})
+
+	pixiVersion, err := l.pixi.Version(logger)
+	if err != nil {
+		logger.WithError(err).Error("Failed to get pixi version")
+		return err
+	}
+
+	logger.WithField("pixiVersion", pixiVersion).Info("Pixi version")
+
+	if l.loaderOptions.pixiToml == "" {
+		return fmt.Errorf("pixi.toml is required, set the pixiToml option")
+	}
+	lockMode, err := l.loaderOptions.lockMode()
+	if err != nil {
+		return err
+	}
+
+	err = l.writeWorkspace(logger)
+	if err != nil {
+		logger.WithError(err).Error("Failed to write pixi workspace")
+		return err
+	}
+
+	// the packages are cached outside of the workspace, so that they are not
+	// copied to the volume
+	cacheDir, err := os.MkdirTemp("", "dataset-job-pixi-cache-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.RemoveAll(cacheDir)
+	}()
+	l.pixi.CacheDir = cacheDir
+
+	err = l.pixi.Install(
+		logger,
+		filepath.Join(l.loaderOptions.prefixingDir, constants.DatasetJobPixiManifestFilename),
+		l.loaderOptions.Environment,
+		lockMode,
+	)
+	if err != nil {
+		logger.WithError(err).Error("Failed to install pixi environment")
+		return err
+	}
+
+	err = utils.CleanupNotExistingSymlinks(logger, l.loaderOptions.prefixingDir)
+	if err != nil {
+		logger.WithError(err).Error("Failed to cleanup non-existing symlinks")
+		return err
+	}
+
+	err = l.moveToMountRoot(logger)
+	if err != nil {
+		logger.WithError(err).Error("Failed to move pixi workspace to mount root")
+		return err
+	}
+
 	return nil
 }
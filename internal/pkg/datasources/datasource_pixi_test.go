@@ -1 +1,221 @@
 package datasources
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/BaizeAI/dataset/internal/pkg/constants"
+)
+
+const testPixiToml = `[workspace]
+name = "test-env"
+channels = ["conda-forge"]
+platforms = ["linux-64"]
+
+[dependencies]
+python = "3.12.*"
+`
+
+func TestPixiParseOptionsFromOptions(t *testing.T) {
+	l := new(PixiLoader)
+	_, err := l.loaderOptions.parseOptionsFromOptions(map[string]string{}, Options{})
+	assert.EqualError(t, err, "missing required options --options name=<env-name>")
+
+	_, err = l.loaderOptions.parseOptionsFromOptions(map[string]string{"name": "../env"}, Options{})
+	assert.ErrorContains(t, err, "invalid name ../env")
+
+	_, err = l.loaderOptions.parseOptionsFromOptions(map[string]string{"name": "env", "channelMirrors": "conda-forge"}, Options{})
+	assert.ErrorContains(t, err, `invalid channel mirror "conda-forge"`)
+
+	options, err := l.loaderOptions.parseOptionsFromOptions(map[string]string{
+		"channels":       "conda-forge, pytorch",
+		"channelMirrors": "https://conda.anaconda.org/conda-forge=https://m1.example.com/conda-forge,https://conda.anaconda.org/conda-forge=https://m2.example.com/conda-forge",
+	}, Options{URI: "pixi://test-env", Root: "/data"})
+	require.NoError(t, err)
+	assert.Equal(t, PixiLoaderOptions{
+		Name:           "test-env",
+		Environment:    "default",
+		Channels:       "conda-forge, pytorch",
+		ChannelMirrors: "https://conda.anaconda.org/conda-forge=https://m1.example.com/conda-forge,https://conda.anaconda.org/conda-forge=https://m2.example.com/conda-forge",
+		PixiTomlPath:   constants.DatasetJobPixiManifestPath,
+		PixiLockPath:   constants.DatasetJobPixiLockPath,
+		PixiPrefixDir:  constants.DatasetJobCondaMountDir,
+		channels:       []string{"conda-forge", "pytorch"},
+		channelMirrors: map[string][]string{
+			"https://conda.anaconda.org/conda-forge": {"https://m1.example.com/conda-forge", "https://m2.example.com/conda-forge"},
+		},
+		prefixingDir: filepath.Join(constants.DatasetJobCondaMountDir, "test-env", "pixi"),
+		finalDir:     "/data/pixi",
+	}, options)
+}
+
+func TestRenderPixiConfig(t *testing.T) {
+	config, err := renderPixiConfig(nil, "", nil)
+	require.NoError(t, err)
+	assert.Empty(t, config)
+
+	config, err = renderPixiConfig(
+		map[string][]string{"https://conda.anaconda.org/conda-forge": {"https://mirror.example.com/conda-forge"}},
+		"https://mirror.example.com/simple",
+		[]string{"https://sub.example.com/simple"},
+	)
+	require.NoError(t, err)
+
+	var parsed map[string]any
+	require.NoError(t, toml.Unmarshal([]byte(config), &parsed))
+	assert.Equal(t, map[string]any{
+		"mirrors": map[string]any{
+			"https://conda.anaconda.org/conda-forge": []any{"https://mirror.example.com/conda-forge"},
+		},
+		"pypi-config": map[string]any{
+			"index-url":        "https://mirror.example.com/simple",
+			"extra-index-urls": []any{"https://sub.example.com/simple"},
+		},
+	}, parsed)
+}
+
+func TestOverrideChannels(t *testing.T) {
+	manifest, err := overrideChannels(testPixiToml, nil)
+	require.NoError(t, err)
+	assert.Equal(t, testPixiToml, manifest)
+
+	manifest, err = overrideChannels(testPixiToml, []string{"https://mirror.example.com/conda-forge"})
+	require.NoError(t, err)
+	var parsed map[string]any
+	require.NoError(t, toml.Unmarshal([]byte(manifest), &parsed))
+	assert.Equal(t, []any{"https://mirror.example.com/conda-forge"}, parsed["workspace"].(map[string]any)["channels"])
+	assert.Equal(t, "3.12.*", parsed["dependencies"].(map[string]any)["python"])
+
+	_, err = overrideChannels("[dependencies]\n", []string{"conda-forge"})
+	assert.ErrorContains(t, err, "pixi.toml has no [workspace] table")
+}
+
+func TestPixiSync(t *testing.T) {
+	newLoader := func(t *testing.T, lock string, rawOptions map[string]string) (*PixiLoader, string) {
+		tempDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(tempDir, "pixi.toml"), []byte(testPixiToml), 0600))
+		if lock != "" {
+			require.NoError(t, os.WriteFile(filepath.Join(tempDir, "pixi.lock"), []byte(lock), 0600))
+		}
+		options := map[string]string{
+			"pixiTomlPath":  filepath.Join(tempDir, "pixi.toml"),
+			"pixiLockPath":  filepath.Join(tempDir, "pixi.lock"),
+			"pixiPrefixDir": tempDir,
+		}
+		for k, v := range rawOptions {
+			options[k] = v
+		}
+		loader, err := NewPixiLoader(options, Options{URI: "pixi://test-env", Root: filepath.Join(tempDir, "root")}, Secrets{})
+		require.NoError(t, err)
+		return loader, tempDir
+	}
+
+	t.Run("locked", func(t *testing.T) {
+		loader, tempDir := newLoader(t, "version: 6\n", map[string]string{
+			"channelMirrors": "https://conda.anaconda.org/conda-forge=https://mirror.example.com/conda-forge",
+		})
+		// leftovers of a previous install are removed
+		workspaceDir := filepath.Join(tempDir, "test-env", "pixi")
+		require.NoError(t, os.MkdirAll(workspaceDir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(workspaceDir, "stale"), nil, 0600))
+
+		fakePixi := fakeCommand{
+			t:       t,
+			cmd:     "pixi",
+			outputs: []out{{stdout: "pixi 0.50.0"}, {stdout: "installed"}},
+		}
+		fakeRclone := fakeCommand{
+			t:       t,
+			cmd:     "rclone",
+			outputs: []out{{}},
+		}
+		defer func() {
+			assert.NoError(t, fakePixi.Clean())
+			assert.NoError(t, fakeRclone.Clean())
+		}()
+		fakePixi.WithContext(func() {
+			fakeRclone.WithContext(func() {
+				require.NoError(t, loader.Sync("", ""))
+			})
+		})
+
+		assert.Equal(t, [][]byte{
+			[]byte("--version\n"),
+			[]byte("install --manifest-path " + filepath.Join(workspaceDir, "pixi.toml") + " --environment default --locked\n"),
+		}, fakePixi.GetAllInputs())
+		assert.Equal(t, [][]byte{
+			[]byte("copyto " + workspaceDir + " " + filepath.Join(tempDir, "root", "pixi") + " --copy-links\n"),
+		}, fakeRclone.GetAllInputs())
+
+		assert.NoFileExists(t, filepath.Join(workspaceDir, "stale"))
+		manifest, err := os.ReadFile(filepath.Join(workspaceDir, "pixi.toml"))
+		require.NoError(t, err)
+		assert.Equal(t, testPixiToml, string(manifest))
+		lock, err := os.ReadFile(filepath.Join(workspaceDir, "pixi.lock"))
+		require.NoError(t, err)
+		assert.Equal(t, "version: 6\n", string(lock))
+		config, err := os.ReadFile(filepath.Join(workspaceDir, ".pixi", "config.toml"))
+		require.NoError(t, err)
+		assert.Contains(t, string(config), "https://mirror.example.com/conda-forge")
+	})
+
+	t.Run("frozen", func(t *testing.T) {
+		loader, tempDir := newLoader(t, "version: 6\n", map[string]string{"frozen": "true", "environment": "cuda"})
+		fakePixi := fakeCommand{
+			t:       t,
+			cmd:     "pixi",
+			outputs: []out{{stdout: "pixi 0.50.0"}, {stdout: "installed"}},
+		}
+		fakeRclone := fakeCommand{
+			t:       t,
+			cmd:     "rclone",
+			outputs: []out{{}},
+		}
+		defer func() {
+			assert.NoError(t, fakePixi.Clean())
+			assert.NoError(t, fakeRclone.Clean())
+		}()
+		fakePixi.WithContext(func() {
+			fakeRclone.WithContext(func() {
+				require.NoError(t, loader.Sync("", ""))
+			})
+		})
+
+		inputs := fakePixi.GetAllInputs()
+		require.Len(t, inputs, 2)
+		assert.Equal(t, "install --manifest-path "+filepath.Join(tempDir, "test-env", "pixi", "pixi.toml")+" --environment cuda --frozen\n", string(inputs[1]))
+	})
+
+	t.Run("solved without pixi.lock", func(t *testing.T) {
+		loader, _ := newLoader(t, "", nil)
+		mode, err := loader.loaderOptions.lockMode()
+		require.NoError(t, err)
+		assert.Equal(t, "", string(mode))
+
+		loader, _ = newLoader(t, "", map[string]string{"frozen": "true"})
+		_, err = loader.loaderOptions.lockMode()
+		assert.ErrorContains(t, err, "frozen requires pixi.lock")
+	})
+
+	t.Run("pixi.toml is required", func(t *testing.T) {
+		loader, tempDir := newLoader(t, "", nil)
+		loader.loaderOptions.pixiToml = ""
+		fakePixi := fakeCommand{
+			t:       t,
+			cmd:     "pixi",
+			outputs: []out{{stdout: "pixi 0.50.0"}},
+		}
+		defer func() {
+			assert.NoError(t, fakePixi.Clean())
+		}()
+		fakePixi.WithContext(func() {
+			assert.ErrorContains(t, loader.Sync("", ""), "pixi.toml is required")
+		})
+		assert.NoDirExists(t, filepath.Join(tempDir, "test-env"))
+	})
+}
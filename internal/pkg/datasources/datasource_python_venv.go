@@ -0,0 +1,401 @@
+package datasources
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/sirupsen/logrus"
+
+	"github.com/BaizeAI/dataset/internal/pkg/constants"
+	"github.com/BaizeAI/dataset/internal/pkg/datasources/pip"
+	"github.com/BaizeAI/dataset/internal/pkg/datasources/uv"
+	"github.com/BaizeAI/dataset/pkg/log"
+	"github.com/BaizeAI/dataset/pkg/utils"
+)
+
+type PythonVenvLoaderOptions struct {
+	Name string `json:"name"`
+	// Python is the interpreter of the venv, a version such as 3.12 which uv
+	// installs into the volume, or the absolute path of an interpreter of the
+	// image. Defaults to the latest version managed by uv.
+	Python            string `json:"python"`
+	PipIndexURL       string `json:"pipIndexUrl"`
+	PipExtraIndexURLs string `json:"pipExtraIndexUrls"`
+	PipTrustedHosts   string `json:"pipTrustedHosts"`
+	PipIndexAuth      string `json:"pipIndexAuth"`
+
+	PipRequirementsTxtPath string `json:"pipRequirementsTxtPath"`
+	PyprojectTomlPath      string `json:"pyprojectTomlPath"`
+	UvLockPath             string `json:"uvLockPath"`
+	VenvPrefixDir          string `json:"venvPrefixDir"`
+
+	pipRequirementsTxt string
+	pyprojectToml      string
+	uvLock             string
+
+	pipExtraIndexURLs []string
+	pipTrustedHosts   []string
+	pipIndexAuths     []urlAuth
+
+	// the venv and the interpreters are installed into prefixingDir, which is
+	// where the volume is mounted when the venv is used, then copied to
+	// finalDir
+	prefixingDir string
+	finalDir     string
+}
+
+func (o *PythonVenvLoaderOptions) parseOptionsFromOptions(rawOptions map[string]string, options Options) (PythonVenvLoaderOptions, error) {
+	jsonContent, err := json.Marshal(rawOptions)
+	if err != nil {
+		return PythonVenvLoaderOptions{}, err
+	}
+
+	var loaderOptions PythonVenvLoaderOptions
+	err = json.Unmarshal(jsonContent, &loaderOptions)
+	if err != nil {
+		return PythonVenvLoaderOptions{}, err
+	}
+
+	if loaderOptions.Name == "" && options.URI != "" {
+		// venv://<name>
+		parsedURI, err := url.Parse(options.URI)
+		if err != nil {
+			return PythonVenvLoaderOptions{}, err
+		}
+		loaderOptions.Name = parsedURI.Host
+	}
+	if loaderOptions.Name == "" {
+		return PythonVenvLoaderOptions{}, fmt.Errorf("missing required options --options name=<env-name>")
+	}
+	if strings.ContainsAny(loaderOptions.Name, `/\`) || loaderOptions.Name == "." || loaderOptions.Name == ".." {
+		return PythonVenvLoaderOptions{}, fmt.Errorf("invalid name %s, must not contain path separators", loaderOptions.Name)
+	}
+
+	loaderOptions.PipRequirementsTxtPath = lo.CoalesceOrEmpty(loaderOptions.PipRequirementsTxtPath, constants.DatasetJobPythonVenvRequirementsTxtPath)
+	loaderOptions.PyprojectTomlPath = lo.CoalesceOrEmpty(loaderOptions.PyprojectTomlPath, constants.DatasetJobPythonVenvPyprojectTomlPath)
+	loaderOptions.UvLockPath = lo.CoalesceOrEmpty(loaderOptions.UvLockPath, constants.DatasetJobPythonVenvUvLockPath)
+	loaderOptions.VenvPrefixDir = lo.CoalesceOrEmpty(loaderOptions.VenvPrefixDir, constants.DatasetJobCondaMountDir)
+
+	if loaderOptions.PipExtraIndexURLs != "" {
+		loaderOptions.pipExtraIndexURLs = splitAndTrim(loaderOptions.PipExtraIndexURLs)
+	}
+	if loaderOptions.PipTrustedHosts != "" {
+		loaderOptions.pipTrustedHosts = splitAndTrim(loaderOptions.PipTrustedHosts)
+	}
+	loaderOptions.pipIndexAuths, err = parseURLAuths(loaderOptions.PipIndexAuth)
+	if err != nil {
+		return PythonVenvLoaderOptions{}, fmt.Errorf("invalid pipIndexAuth: %w", err)
+	}
+
+	loaderOptions.prefixingDir = filepath.Join(loaderOptions.VenvPrefixDir, loaderOptions.Name)
+	loaderOptions.finalDir = options.Root
+
+	return loaderOptions, nil
+}
+
+func (o *PythonVenvLoaderOptions) venvDir() string {
+	return filepath.Join(o.prefixingDir, "venv")
+}
+
+func (o *PythonVenvLoaderOptions) pythonInstallDir() string {
+	return filepath.Join(o.prefixingDir, "python")
+}
+
+// managedPython reports whether the interpreter is installed by uv, rather
+// than taken from the image.
+func (o *PythonVenvLoaderOptions) managedPython() bool {
+	return !filepath.IsAbs(o.Python)
+}
+
+func (o *PythonVenvLoaderOptions) indexURL() string {
+	if o.PipIndexURL == "" {
+		return ""
+	}
+
+	return withIndexAuth(o.pipIndexAuths, o.PipIndexURL)
+}
+
+func (o *PythonVenvLoaderOptions) extraIndexURLs() []string {
+	return lo.Map(o.pipExtraIndexURLs, func(extraIndexURL string, _ int) string {
+		return withIndexAuth(o.pipIndexAuths, extraIndexURL)
+	})
+}
+
+func (o *PythonVenvLoaderOptions) secrets() []string {
+	var secrets []string
+	for _, auth := range o.pipIndexAuths {
+		secrets = append(secrets, auth.secrets()...)
+	}
+
+	return secrets
+}
+
+var _ Loader = &PythonVenvLoader{}
+
+type PythonVenvLoader struct {
+	Options Options
+
+	loaderOptions PythonVenvLoaderOptions
+	uv            *uv.UvCLI
+	pip           *pip.PipCLI
+}
+
+func NewPythonVenvLoader(datasourceOption map[string]string, options Options, secrets Secrets) (*PythonVenvLoader, error) {
+	loader := new(PythonVenvLoader)
+	loader.Options = options
+
+	loaderOptions, err := loader.loaderOptions.parseOptionsFromOptions(datasourceOption, options)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range loaderOptions.pipIndexAuths {
+		err = loaderOptions.pipIndexAuths[i].resolve(secrets)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pipIndexAuth: %w", err)
+		}
+	}
+
+	loader.loaderOptions = loaderOptions
+
+	loader.uv = uv.NewUvCLI()
+	loader.uv.PythonInstallDir = loader.loaderOptions.pythonInstallDir()
+	loader.uv.OnlyManagedPython = loader.loaderOptions.managedPython()
+	loader.uv.IndexURL = loader.loaderOptions.indexURL()
+	loader.uv.ExtraIndexURLs = loader.loaderOptions.extraIndexURLs()
+	loader.uv.InsecureHosts = loader.loaderOptions.pipTrustedHosts
+	loader.uv.Secrets = loader.loaderOptions.secrets()
+	loader.pip = pip.NewPipCLIWithVenv(loader.loaderOptions.venvDir())
+	loader.pip.Secrets = loader.loaderOptions.secrets()
+	loader.tryReadFile()
+
+	return loader, nil
+}
+
+func (l *PythonVenvLoader) tryReadFile() {
+	files := map[string]*string{
+		l.loaderOptions.PipRequirementsTxtPath: &l.loaderOptions.pipRequirementsTxt,
+		l.loaderOptions.PyprojectTomlPath:      &l.loaderOptions.pyprojectToml,
+		l.loaderOptions.UvLockPath:             &l.loaderOptions.uvLock,
+	}
+	for path, content := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				log.WithField("path", path).WithError(err).Error("Failed to read file")
+			}
+			continue
+		}
+		*content = string(data)
+	}
+}
+
+// resolveProject writes pyproject.toml and uv.lock into a temporary project
+// directory, and resolves them into a requirements.txt with hashes which
+// pins every package.
+func (l *PythonVenvLoader) resolveProject(logger *logrus.Entry, tempDir string) (string, error) {
+	projectDir := filepath.Join(tempDir, "project")
+	err := os.MkdirAll(projectDir, 0755) // #nosec G301
+	if err != nil {
+		return "", err
+	}
+
+	pyprojectTomlPath := filepath.Join(projectDir, constants.DatasetJobPythonVenvPyprojectTomlFilename)
+	err = os.WriteFile(pyprojectTomlPath, []byte(l.loaderOptions.pyprojectToml), 0644) // #nosec G306
+	if err != nil {
+		return "", err
+	}
+
+	requirementsTxtPath := filepath.Join(tempDir, "requirements.lock.txt")
+	if l.loaderOptions.uvLock == "" {
+		err = l.uv.Compile(logger, pyprojectTomlPath, filepath.Join(l.loaderOptions.venvDir(), "bin", "python"), requirementsTxtPath)
+		if err != nil {
+			logger.WithError(err).Error("Failed to resolve pyproject.toml")
+			return "", err
+		}
+
+		return requirementsTxtPath, nil
+	}
+
+	err = os.WriteFile(filepath.Join(projectDir, constants.DatasetJobPythonVenvUvLockFilename), []byte(l.loaderOptions.uvLock), 0644) // #nosec G306
+	if err != nil {
+		return "", err
+	}
+	err = l.uv.Export(logger, projectDir, requirementsTxtPath)
+	if err != nil {
+		logger.WithError(err).Error("Failed to export uv.lock")
+		return "", err
+	}
+
+	return requirementsTxtPath, nil
+}
+
+func (l *PythonVenvLoader) moveToMountRoot(logger *logrus.Entry) error {
+	dirs := []string{"venv"}
+	if l.loaderOptions.managedPython() {
+		dirs = append(dirs, "python")
+	}
+
+	for _, dir := range dirs {
+		finalDir := filepath.Join(l.loaderOptions.finalDir, dir)
+		err := os.MkdirAll(filepath.Dir(finalDir), 0755)
+		if err != nil {
+			logger.WithError(err).Error("Failed to create mount root")
+			return err
+		}
+
+		err = os.RemoveAll(finalDir)
+		if err != nil {
+			logger.WithError(err).Errorf("Failed to remove %s dir", dir)
+			return err
+		}
+
+		// symlinks are kept, the venv links to the interpreters by absolute
+		// paths which are valid once the volume is mounted at prefixingDir
+		cmd := exec.Command("rclone",
+			"copyto",
+			filepath.Join(l.loaderOptions.prefixingDir, dir),
+			finalDir,
+			"--links",
+		) // #nosec G204
+
+		err = utils.ExecuteCommand(logger, cmd, []string{})
+		if err != nil {
+			logger.WithError(err).Errorf("Failed to move %s to mount root", dir)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Workflow overview:
+//   - uv --version
+//   - uv venv /opt/baize-runtime-env/<name>/venv --python <python> --seed --relocatable,
+//     the interpreter is installed into /opt/baize-runtime-env/<name>/python unless it is a path
+//
+// if pyproject.toml exists:
+//   - uv export --frozen with uv.lock, or uv pip compile without, into a requirements.txt with hashes
+//   - pip install --no-deps -r requirements.txt
+//
+// if requirements.txt exists:
+//   - pip install -r requirements.txt
+//
+// finalize the venv, which is used by mounting the volume at
+// /opt/baize-runtime-env/<name> where it was installed:
+//   - mv /opt/baize-runtime-env/<name>/venv ${mount-root}/venv
+//   - mv /opt/baize-runtime-env/<name>/python ${mount-root}/python
+func (l *PythonVenvLoader) Sync(_ string, _ string) error {
+	logger := log.WithFields(logrus.Fields{
+		"type":    TypePythonVenv,
+		"root":    l.Options.Root,
+		"envName": l.loaderOptions.Name,
+		"python":  l.loaderOptions.Python,
+	})
+
+	uvVersion, err := l.uv.Version(logger)
+	if err != nil {
+		logger.WithError(err).Error("Failed to get uv version")
+		return err
+	}
+
+	logger.WithField("uvVersion", uvVersion).Info("uv version")
+
+	if l.loaderOptions.uvLock != "" && l.loaderOptions.pyprojectToml == "" {
+		return fmt.Errorf("uv.lock requires pyproject.toml, set the pyprojectToml option")
+	}
+	if l.loaderOptions.pipRequirementsTxt == "" && l.loaderOptions.pyprojectToml == "" {
+		return fmt.Errorf("requirements.txt or pyproject.toml is required, set the pipRequirementsTxt or pyprojectToml option")
+	}
+
+	// leftovers of previous installs are removed
+	err = os.RemoveAll(l.loaderOptions.prefixingDir)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(l.loaderOptions.prefixingDir, 0755) // #nosec G301
+	if err != nil {
+		return err
+	}
+
+	tempDir, err := os.MkdirTemp("", "dataset-job-python-venv-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.RemoveAll(tempDir)
+	}()
+	l.uv.CacheDir = filepath.Join(tempDir, "cache")
+
+	err = l.uv.Venv(logger, l.loaderOptions.venvDir(), l.loaderOptions.Python)
+	if err != nil {
+		logger.WithError(err).Error("Failed to create venv")
+		return err
+	}
+
+	if l.loaderOptions.PipIndexURL != "" || len(l.loaderOptions.pipExtraIndexURLs) > 0 || len(l.loaderOptions.pipTrustedHosts) > 0 {
+		pipConfig, err := renderPipConfig(
+			l.loaderOptions.indexURL(),
+			l.loaderOptions.extraIndexURLs(),
+			l.loaderOptions.pipTrustedHosts,
+		)
+		if err != nil {
+			logger.WithError(err).Error("Failed to render pip config")
+			return err
+		}
+
+		l.pip.ConfigFilePath = filepath.Join(tempDir, "pip.conf")
+		err = os.WriteFile(l.pip.ConfigFilePath, []byte(pipConfig), 0600)
+		if err != nil {
+			return err
+		}
+	}
+
+	if l.loaderOptions.pyprojectToml != "" {
+		requirementsTxtPath, err := l.resolveProject(logger, tempDir)
+		if err != nil {
+			return err
+		}
+
+		err = l.pip.InstallWithRequirementsTxt(logger, requirementsTxtPath, "--no-deps")
+		if err != nil {
+			logger.WithError(err).Error("Failed to install locked requirements")
+			return err
+		}
+	}
+
+	if l.loaderOptions.pipRequirementsTxt != "" {
+		requirementsTxtPath := filepath.Join(tempDir, constants.DatasetJobCondaPipRequirementsTxtFilename)
+		err = os.WriteFile(requirementsTxtPath, []byte(l.loaderOptions.pipRequirementsTxt), 0644) // #nosec G306
+		if err != nil {
+			return err
+		}
+
+		err = l.pip.InstallWithRequirementsTxt(logger, requirementsTxtPath, "--no-cache-dir")
+		if err != nil {
+			logger.WithError(err).Error("Failed to install requirements")
+			return err
+		}
+	}
+
+	err = utils.CleanupNotExistingSymlinks(logger, l.loaderOptions.prefixingDir)
+	if err != nil {
+		logger.WithError(err).Error("Failed to cleanup non-existing symlinks")
+		return err
+	}
+
+	err = l.moveToMountRoot(logger)
+	if err != nil {
+		logger.WithError(err).Error("Failed to move venv to mount root")
+		return err
+	}
+
+	return nil
+}
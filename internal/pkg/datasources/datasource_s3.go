@@ -1,6 +1,8 @@
 package datasources
 
 import (
+	"crypto/md5" // #nosec G501
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"github.com/samber/lo"
@@ -8,20 +10,28 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/sirupsen/logrus"
 
+	"github.com/BaizeAI/dataset/internal/pkg/blobcache"
+	"github.com/BaizeAI/dataset/internal/pkg/jobresult"
+	"github.com/BaizeAI/dataset/internal/pkg/replica"
 	"github.com/BaizeAI/dataset/pkg/log"
 	"github.com/BaizeAI/dataset/pkg/utils"
 )
 
-var _ Loader = &S3Loader{}
+var (
+	_ Exporter        = &S3Loader{}
+	_ BlobCacheLoader = &S3Loader{}
+)
 
 type S3Loader struct {
 	Options Options
 
 	s3Options S3LoaderOptions
+	blobCache *blobcache.Cache
 }
 
 func NewS3Loader(datasourceOptions map[string]string, options Options, secrets Secrets) (*S3Loader, error) {
@@ -36,6 +46,9 @@ func NewS3Loader(datasourceOptions map[string]string, options Options, secrets S
 	s3.s3Options.accessKeyID = secrets.AKSKAccessKeyID
 	s3.s3Options.secretAccessKey = secrets.AKSKSecretAccessKey
 	s3.s3Options.SyncMode = lo.CoalesceOrEmpty(datasourceOptions["syncMode"], "sync")
+	// objects of the bucket which are not in the dataset are only deleted
+	// when asked for explicitly
+	s3.s3Options.exportMode = lo.CoalesceOrEmpty(datasourceOptions["syncMode"], "copy")
 
 	err = s3.validateOptions(s3Options)
 	if err != nil {
@@ -50,7 +63,11 @@ type S3LoaderOptions struct {
 	Region   string `json:"region"`
 	Endpoint string `json:"endpoint"`
 	SyncMode string `json:"syncMode"`
+	// ReplicaRound is the round of the replica store at the uri to load,
+	// instead of the whole store.
+	ReplicaRound string `json:"replicaRound"`
 
+	exportMode      string
 	accessKeyID     string
 	secretAccessKey string
 }
@@ -75,8 +92,8 @@ func (d *S3Loader) validateOptions(options S3LoaderOptions) error {
 		return fmt.Errorf("--options region <region> is required for AWS provider")
 	}
 
-	if options.SyncMode != "" && options.SyncMode != "sync" && options.SyncMode != "copy" {
-		return fmt.Errorf("invalid syncMode '%s', must be 'sync' or 'copy'", options.SyncMode)
+	if err := validateSyncMode(options.SyncMode); err != nil {
+		return err
 	}
 
 	return nil
@@ -164,17 +181,89 @@ func (d *S3Loader) configCreate(configName string) error {
 	return nil
 }
 
-func (d *S3Loader) Sync(fromURI string, toPath string) error {
-	parsedURL, err := url.Parse(d.Options.URI)
+func (d *S3Loader) parseURI(uri string) (string, string, error) {
+	parsedURL, err := url.Parse(uri)
 	if err != nil {
-		return err
+		return "", "", err
 	}
 	if parsedURL.Scheme != "s3" {
-		return fmt.Errorf("invalid scheme %s, only s3 is supported", parsedURL.Scheme)
+		return "", "", fmt.Errorf("invalid scheme %s, only s3 is supported", parsedURL.Scheme)
 	}
 
-	bucket := parsedURL.Host
-	objectDir := strings.TrimPrefix(parsedURL.Path, "/")
+	return parsedURL.Host, strings.TrimPrefix(parsedURL.Path, "/"), nil
+}
+
+// syncURI returns the uri to load, the round of a replica store when the
+// replicaRound option is set.
+func (d *S3Loader) syncURI() (string, error) {
+	if d.s3Options.ReplicaRound == "" {
+		return d.Options.URI, nil
+	}
+	round, err := strconv.ParseInt(d.s3Options.ReplicaRound, 10, 32)
+	if err != nil || round <= 0 {
+		return "", fmt.Errorf("invalid replicaRound %s, must be a positive integer", d.s3Options.ReplicaRound)
+	}
+
+	return replica.RoundURI(d.Options.URI, int32(round)), nil
+}
+
+func (d *S3Loader) SetBlobCache(cache *blobcache.Cache) {
+	d.blobCache = cache
+}
+
+type rcloneListItem struct {
+	Path   string            `json:"Path"`
+	Size   int64             `json:"Size"`
+	Hashes map[string]string `json:"Hashes"`
+}
+
+// blobs returns the objects under the remote keyed by the MD5 rclone reports,
+// which is the ETag of the objects uploaded in a single part. The other
+// ETags are not hashes of the content, those objects are only shared with the
+// datasets of the same bucket. The objects without are not cached.
+func (d *S3Loader) blobs(logger *logrus.Entry, remote string, bucket string, env []string, secrets []string) []blobcache.Blob {
+	cmd := exec.Command("rclone", "lsjson", remote, "--recursive", "--files-only", "--hash", "--hash-type", "md5")
+	cmd.Dir = d.Options.Root
+	cmd.Env = env
+
+	outBuffer, errBuffer, err := utils.ExecuteCommandWithAllOutput(logger, cmd, secrets)
+	if err != nil {
+		logger.Warnf("failed to list the objects of %s, the blob cache is not used: %s", remote, errBuffer)
+		return nil
+	}
+
+	var items []rcloneListItem
+	if err := json.Unmarshal(outBuffer.Bytes(), &items); err != nil {
+		logger.Warnf("failed to parse the objects of %s, the blob cache is not used: %v", remote, err)
+		return nil
+	}
+
+	scope := lo.CoalesceOrEmpty(d.s3Options.Endpoint, "s3.amazonaws.com") + "/" + bucket
+	blobs := make([]blobcache.Blob, 0, len(items))
+	for _, item := range items {
+		etag := item.Hashes["md5"]
+		if etag == "" || item.Size == 0 {
+			continue
+		}
+		key := blobcache.ETagKey(scope, etag, item.Size)
+		if sum, err := hex.DecodeString(etag); err == nil && len(sum) == md5.Size {
+			key = blobcache.MD5Key(etag)
+		}
+		blobs = append(blobs, blobcache.Blob{Path: item.Path, Key: key, Size: item.Size})
+	}
+
+	return blobs
+}
+
+func (d *S3Loader) Sync(fromURI string, toPath string) error {
+	uri, err := d.syncURI()
+	if err != nil {
+		return err
+	}
+	bucket, objectDir, err := d.parseURI(uri)
+	if err != nil {
+		return err
+	}
 
 	logger := log.WithFields(logrus.Fields{
 		"fromURI":          fromURI,
@@ -206,10 +295,25 @@ func (d *S3Loader) Sync(fromURI string, toPath string) error {
 	}
 
 	syncMode := d.s3Options.SyncMode
+	remote := filepath.Join(fmt.Sprintf("%s:%s", configName, bucket), objectDir)
+
+	env := os.Environ()
+	if accessKeyID != "" && secretAccessKey != "" {
+		env = append(env, fmt.Sprintf("RCLONE_S3_ACCESS_KEY_ID=%s", accessKeyID))
+		env = append(env, fmt.Sprintf("RCLONE_S3_SECRET_ACCESS_KEY=%s", secretAccessKey))
+	}
+
+	// rclone skips the files restored from the blob cache as their size and
+	// MD5 match
+	var blobs []blobcache.Blob
+	if d.blobCache != nil {
+		blobs = d.blobs(logger, remote, bucket, env, []string{accessKeyID, secretAccessKey})
+		d.blobCache.Restore(syncDir(d.Options.Root, toPath), blobs)
+	}
 
 	args := []string{
 		syncMode,
-		filepath.Join(fmt.Sprintf("%s:%s", configName, bucket), objectDir),
+		remote,
 		toPath,
 	}
 
@@ -220,12 +324,7 @@ func (d *S3Loader) Sync(fromURI string, toPath string) error {
 	logger = logger.WithField("command", cmd.String())
 	logger.Debug("executing command to copy data")
 
-	cmd.Env = os.Environ()
-
-	if accessKeyID != "" && secretAccessKey != "" {
-		cmd.Env = append(cmd.Env, fmt.Sprintf("RCLONE_S3_ACCESS_KEY_ID=%s", accessKeyID))
-		cmd.Env = append(cmd.Env, fmt.Sprintf("RCLONE_S3_SECRET_ACCESS_KEY=%s", secretAccessKey))
-	}
+	cmd.Env = env
 
 	outBuffer, errBuffer, err := utils.ExecuteCommandWithAllOutput(logger, cmd, []string{accessKeyID, secretAccessKey})
 
@@ -235,5 +334,63 @@ func (d *S3Loader) Sync(fromURI string, toPath string) error {
 	}
 	logger.Debugf("rclone copy command output: %s", outBuffer.String())
 
+	if d.blobCache != nil {
+		d.blobCache.Store(syncDir(d.Options.Root, toPath), blobs)
+	}
+
 	return nil
 }
+
+// Export copies the content of fromPath to the prefix of the bucket. S3 has no
+// revisions, the result is empty.
+func (d *S3Loader) Export(fromPath string, toURI string) (jobresult.Result, error) {
+	bucket, objectDir, err := d.parseURI(d.Options.URI)
+	if err != nil {
+		return jobresult.Result{}, err
+	}
+
+	logger := log.WithFields(logrus.Fields{
+		"fromPath":   fromPath,
+		"type":       TypeS3,
+		"toURI":      toURI,
+		"provider":   d.s3Options.Provider,
+		"region":     d.s3Options.Region,
+		"endpoint":   d.s3Options.Endpoint,
+		"bucket":     bucket,
+		"objectDir":  objectDir,
+		"exportMode": d.s3Options.exportMode,
+	})
+
+	accessKeyID := strings.TrimSpace(d.s3Options.accessKeyID)
+	secretAccessKey := strings.TrimSpace(d.s3Options.secretAccessKey)
+
+	err = d.configTouch()
+	if err != nil {
+		return jobresult.Result{}, err
+	}
+
+	configName := fmt.Sprintf("baize-data-loader-export-config-%s", utils.RandomHashString(8))
+	err = d.configCreate(configName)
+	if err != nil {
+		return jobresult.Result{}, err
+	}
+
+	cmd := exec.Command("rclone", d.s3Options.exportMode, fromPath, filepath.Join(fmt.Sprintf("%s:%s", configName, bucket), objectDir), "-vvv")
+	cmd.Env = os.Environ()
+	if accessKeyID != "" && secretAccessKey != "" {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("RCLONE_S3_ACCESS_KEY_ID=%s", accessKeyID))
+		cmd.Env = append(cmd.Env, fmt.Sprintf("RCLONE_S3_SECRET_ACCESS_KEY=%s", secretAccessKey))
+	}
+
+	logger = logger.WithField("command", cmd.String())
+	logger.Debug("executing command to export data")
+
+	outBuffer, errBuffer, err := utils.ExecuteCommandWithAllOutput(logger, cmd, []string{accessKeyID, secretAccessKey})
+	if err != nil {
+		logger.Errorf("rclone export command error: %s", errBuffer)
+		return jobresult.Result{}, fmt.Errorf("failed to export data from %s to %s with rclone command %s, err: %s", fromPath, toURI, utils.ObscureString(cmd.String(), []string{accessKeyID, secretAccessKey}), err)
+	}
+	logger.Debugf("rclone export command output: %s", outBuffer.String())
+
+	return jobresult.Result{}, nil
+}
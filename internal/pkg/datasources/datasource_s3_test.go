@@ -63,3 +63,17 @@ func TestS3Loader(t *testing.T) {
 	assert.True(t, strings.HasPrefix(string(bbs[1]), "config create"))
 	assert.True(t, strings.HasPrefix(string(bbs[2]), "sync"))
 }
+
+func TestS3LoaderSyncURI(t *testing.T) {
+	loader, err := NewS3Loader(map[string]string{
+		"replicaRound": "3",
+	}, Options{URI: "s3://replicas/models"}, Secrets{})
+	assert.NoError(t, err)
+	uri, err := loader.syncURI()
+	assert.NoError(t, err)
+	assert.Equal(t, "s3://replicas/models/rounds/3", uri)
+
+	loader.s3Options.ReplicaRound = "0"
+	_, err = loader.syncURI()
+	assert.ErrorContains(t, err, "invalid replicaRound")
+}
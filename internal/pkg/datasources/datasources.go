@@ -2,6 +2,9 @@ package datasources
 
 import (
 	"os"
+
+	"github.com/BaizeAI/dataset/internal/pkg/blobcache"
+	"github.com/BaizeAI/dataset/internal/pkg/jobresult"
 )
 
 type Options struct {
@@ -20,3 +23,25 @@ type Options struct {
 type Loader interface {
 	Sync(fromURI string, toPath string) error
 }
+
+// Exporter is implemented by the loaders which can push the content of a
+// dataset back to their data source, in the reverse direction of Sync.
+type Exporter interface {
+	Loader
+	Export(fromPath string, toURI string) (jobresult.Result, error)
+}
+
+// ResultLoader is implemented by the loaders which report a result of the
+// sync to the controller, Result is called after a successful Sync.
+type ResultLoader interface {
+	Loader
+	Result() jobresult.Result
+}
+
+// BlobCacheLoader is implemented by the loaders which restore the files they
+// load from a blob cache shared by datasets before downloading the rest, and
+// store the downloaded files into it. SetBlobCache is called before Sync.
+type BlobCacheLoader interface {
+	Loader
+	SetBlobCache(cache *blobcache.Cache)
+}
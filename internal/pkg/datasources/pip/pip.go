@@ -32,6 +32,9 @@ type PipCLI struct {
 	ConfigFilePath string
 	Bin            string
 	EnvPath        string
+	// Secrets are obscured in the logs of the commands, e.g. the credentials
+	// of the indexes.
+	Secrets []string
 }
 
 func NewPipCLIWithCondaEnv(envPrefix string) *PipCLI {
@@ -41,6 +44,13 @@ func NewPipCLIWithCondaEnv(envPrefix string) *PipCLI {
 	}
 }
 
+func NewPipCLIWithVenv(venvDir string) *PipCLI {
+	return &PipCLI{
+		Bin:     filepath.Join(venvDir, "bin", "pip"),
+		EnvPath: filepath.Join(venvDir, "bin"),
+	}
+}
+
 func (p *PipCLI) bin() string {
 	if p.Bin != "" {
 		return p.Bin
@@ -58,7 +68,7 @@ func (p *PipCLI) Version(logger *logrus.Entry) (string, error) {
 	cmd := exec.Command(p.bin(), args...) // #nosec G204
 	cmd.Env = os.Environ()
 
-	output, err := utils.ExecuteCommandWithOutput(logger, cmd, []string{})
+	output, err := utils.ExecuteCommandWithOutput(logger, cmd, p.Secrets)
 	if err != nil {
 		return "", err
 	}
@@ -69,13 +79,14 @@ func (p *PipCLI) Version(logger *logrus.Entry) (string, error) {
 	return outputString, nil
 }
 
-// Equivalent to `pip install -r requirements.txt`
-func (p *PipCLI) InstallWithRequirementsTxt(logger *logrus.Entry, requirementsTxt string) error {
+// Equivalent to `pip install -r requirements.txt [extraArgs...]`
+func (p *PipCLI) InstallWithRequirementsTxt(logger *logrus.Entry, requirementsTxt string, extraArgs ...string) error {
 	args := []string{
 		"install",
 		"-r",
 		requirementsTxt,
 	}
+	args = append(args, extraArgs...)
 
 	cmd := exec.Command(p.bin(), args...) // #nosec G204
 	cmd.Env = lo.Filter(os.Environ(), func(item string, index int) bool {
@@ -88,7 +99,7 @@ func (p *PipCLI) InstallWithRequirementsTxt(logger *logrus.Entry, requirementsTx
 		logger = logger.WithField("PIP_CONFIG_FILE", p.ConfigFilePath)
 	}
 
-	_, err := utils.ExecuteCommandWithOutput(logger, cmd, []string{})
+	_, err := utils.ExecuteCommandWithOutput(logger, cmd, p.Secrets)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,75 @@
+package pixi
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/BaizeAI/dataset/pkg/utils"
+)
+
+type LockMode string
+
+const (
+	// LockModeNone solves the environment and updates pixi.lock.
+	LockModeNone LockMode = ""
+	// LockModeLocked installs pixi.lock, and fails when it does not satisfy
+	// the manifest.
+	LockModeLocked LockMode = "locked"
+	// LockModeFrozen installs pixi.lock as is, without checking it against
+	// the manifest.
+	LockModeFrozen LockMode = "frozen"
+)
+
+type PixiCLI struct {
+	// CacheDir is where pixi caches the downloaded packages, the default
+	// cache of the user is used when empty.
+	CacheDir string
+}
+
+func NewPixiCLI() *PixiCLI {
+	return &PixiCLI{}
+}
+
+func (c *PixiCLI) newCommand(args ...string) *exec.Cmd {
+	cmd := exec.Command("pixi", args...)
+	cmd.Env = os.Environ()
+	cmd.Env = append(cmd.Env, "PIXI_NO_PROGRESS=true")
+	if c.CacheDir != "" {
+		cmd.Env = append(cmd.Env, "PIXI_CACHE_DIR="+c.CacheDir)
+	}
+
+	return cmd
+}
+
+// Version returns the version of pixi
+// Equivalent to `pixi --version`
+func (c *PixiCLI) Version(logger *logrus.Entry) (string, error) {
+	cmd := c.newCommand("--version")
+	output, err := utils.ExecuteCommandWithOutput(logger, cmd, []string{})
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(output.String()), nil
+}
+
+// Install installs an environment of a workspace
+// Equivalent to `pixi install --manifest-path <manifestPath> --environment <environment> [--locked|--frozen]`
+func (c *PixiCLI) Install(logger *logrus.Entry, manifestPath, environment string, lockMode LockMode) error {
+	args := []string{
+		"install",
+		"--manifest-path",
+		manifestPath,
+		"--environment",
+		environment,
+	}
+	if lockMode != LockModeNone {
+		args = append(args, "--"+string(lockMode))
+	}
+
+	cmd := c.newCommand(args...)
+	return utils.ExecuteCommand(logger, cmd, []string{})
+}
@@ -0,0 +1,49 @@
+package datasources
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	syncModeSync = "sync"
+	syncModeCopy = "copy"
+)
+
+func validateSyncMode(syncMode string) error {
+	if syncMode != "" && syncMode != syncModeSync && syncMode != syncModeCopy {
+		return fmt.Errorf("invalid syncMode '%s', must be 'sync' or 'copy'", syncMode)
+	}
+
+	return nil
+}
+
+// pruneExtraneous removes the files and directories under root for which keep
+// returns false, keep is called with paths relative to root, separated by
+// slashes. A directory which is not kept is removed with all its content.
+// lost+found of the volume is always kept.
+func pruneExtraneous(logger *logrus.Entry, root string, keep func(rel string) bool) error {
+	return fs.WalkDir(os.DirFS(root), ".", func(rel string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if rel == "." || rel == "lost+found" || keep(rel) {
+			return nil
+		}
+
+		path := filepath.Join(root, rel)
+		logger.Infof("pruning %s, which does not exist in the source anymore", path)
+		if err := os.RemoveAll(path); err != nil {
+			return fmt.Errorf("failed to prune %s: %w", path, err)
+		}
+		if entry.IsDir() {
+			return fs.SkipDir
+		}
+
+		return nil
+	})
+}
@@ -11,11 +11,15 @@ const (
 	TypeModelScope  Type = "MODEL_SCOPE"
 	TypeDatabase    Type = "DATABASE"
 	TypeHadoop      Type = "HADOOP"
+	TypePixi        Type = "PIXI"
+	TypePythonVenv  Type = "PYTHON_VENV"
+	TypeOCI         Type = "OCI"
 )
 
 var (
 	SupportedTypesString = []string{string(TypeS3), string(TypeGit), string(TypeHTTP), string(TypeConda),
-		string(TypeHuggingFace), string(TypeModelScope), string(TypeDatabase), string(TypeHadoop)}
+		string(TypeHuggingFace), string(TypeModelScope), string(TypeDatabase), string(TypeHadoop), string(TypePixi),
+		string(TypePythonVenv), string(TypeOCI)}
 	SupportedTypes = []Type{TypeS3, TypeGit, TypeHTTP, TypeConda, TypeHuggingFace, TypeModelScope,
-		TypeDatabase, TypeHadoop}
+		TypeDatabase, TypeHadoop, TypePixi, TypePythonVenv, TypeOCI}
 )
@@ -0,0 +1,130 @@
+package uv
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/BaizeAI/dataset/pkg/utils"
+)
+
+type UvCLI struct {
+	// PythonInstallDir is where uv installs the Python interpreters it
+	// manages, the default directory of the user is used when empty.
+	PythonInstallDir string
+	// OnlyManagedPython makes uv use the interpreters it manages only, and
+	// never the ones of the system.
+	OnlyManagedPython bool
+	// CacheDir is where uv caches the downloaded packages, the default cache
+	// of the user is used when empty.
+	CacheDir string
+
+	// IndexURL replaces PyPI, ExtraIndexURLs are used in addition to it.
+	IndexURL       string
+	ExtraIndexURLs []string
+	// InsecureHosts are the hosts whose certificates are not verified.
+	InsecureHosts []string
+
+	// Secrets are obscured in the logs of the commands, e.g. the credentials
+	// of the indexes.
+	Secrets []string
+}
+
+func NewUvCLI() *UvCLI {
+	return &UvCLI{}
+}
+
+func (c *UvCLI) newCommand(args ...string) *exec.Cmd {
+	cmd := exec.Command("uv", args...)
+	cmd.Env = os.Environ()
+	cmd.Env = append(cmd.Env, "UV_NO_PROGRESS=1")
+	if c.PythonInstallDir != "" {
+		cmd.Env = append(cmd.Env, "UV_PYTHON_INSTALL_DIR="+c.PythonInstallDir)
+	}
+	if c.OnlyManagedPython {
+		cmd.Env = append(cmd.Env, "UV_PYTHON_PREFERENCE=only-managed")
+	}
+	if c.CacheDir != "" {
+		cmd.Env = append(cmd.Env, "UV_CACHE_DIR="+c.CacheDir)
+	}
+	if c.IndexURL != "" {
+		cmd.Env = append(cmd.Env, "UV_DEFAULT_INDEX="+c.IndexURL)
+	}
+	if len(c.ExtraIndexURLs) > 0 {
+		cmd.Env = append(cmd.Env, "UV_INDEX="+strings.Join(c.ExtraIndexURLs, " "))
+	}
+	if len(c.InsecureHosts) > 0 {
+		cmd.Env = append(cmd.Env, "UV_INSECURE_HOST="+strings.Join(c.InsecureHosts, " "))
+	}
+
+	return cmd
+}
+
+// Version returns the version of uv
+// Equivalent to `uv --version`
+func (c *UvCLI) Version(logger *logrus.Entry) (string, error) {
+	cmd := c.newCommand("--version")
+	output, err := utils.ExecuteCommandWithOutput(logger, cmd, c.Secrets)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(output.String()), nil
+}
+
+// Venv creates a relocatable virtual environment with pip installed
+// Equivalent to `uv venv <prefix> [--python <python>] --seed --relocatable`
+func (c *UvCLI) Venv(logger *logrus.Entry, prefix, python string) error {
+	args := []string{
+		"venv",
+		prefix,
+	}
+	if python != "" {
+		args = append(args, "--python", python)
+	}
+	args = append(args, "--seed", "--relocatable")
+
+	cmd := c.newCommand(args...)
+	return utils.ExecuteCommand(logger, cmd, c.Secrets)
+}
+
+// Export exports the packages locked by the uv.lock of a project as a
+// requirements.txt with hashes, the project itself is not exported
+// Equivalent to `uv export --project <projectDir> --frozen --no-emit-project --format requirements-txt --output-file <output>`
+func (c *UvCLI) Export(logger *logrus.Entry, projectDir, output string) error {
+	args := []string{
+		"export",
+		"--project",
+		projectDir,
+		"--frozen",
+		"--no-emit-project",
+		"--format",
+		"requirements-txt",
+		"--output-file",
+		output,
+	}
+
+	cmd := c.newCommand(args...)
+	return utils.ExecuteCommand(logger, cmd, c.Secrets)
+}
+
+// Compile resolves the dependencies of a pyproject.toml or requirements.in
+// into a requirements.txt with hashes, for the interpreter of the venv
+// Equivalent to `uv pip compile <input> --python <python> --generate-hashes --output-file <output>`
+func (c *UvCLI) Compile(logger *logrus.Entry, input, python, output string) error {
+	args := []string{
+		"pip",
+		"compile",
+		input,
+		"--python",
+		python,
+		"--generate-hashes",
+		"--output-file",
+		output,
+	}
+
+	cmd := c.newCommand(args...)
+	return utils.ExecuteCommand(logger, cmd, c.Secrets)
+}
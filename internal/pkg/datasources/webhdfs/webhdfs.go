@@ -0,0 +1,226 @@
+package webhdfs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	krb5client "github.com/jcmturner/gokrb5/v8/client"
+	"github.com/jcmturner/gokrb5/v8/spnego"
+)
+
+const (
+	TypeFile      = "FILE"
+	TypeDirectory = "DIRECTORY"
+	TypeSymlink   = "SYMLINK"
+)
+
+// FileStatus is the status of a file or directory as returned by the
+// GETFILESTATUS and LISTSTATUS operations.
+type FileStatus struct {
+	// PathSuffix is the name of the entry in a listing, it is empty for
+	// GETFILESTATUS.
+	PathSuffix string `json:"pathSuffix"`
+	Type       string `json:"type"`
+	Length     int64  `json:"length"`
+	// ModificationTime is in milliseconds since the epoch.
+	ModificationTime int64 `json:"modificationTime"`
+}
+
+// RemoteError is the RemoteException returned by the NameNode or HttpFS.
+type RemoteError struct {
+	StatusCode    int    `json:"-"`
+	Exception     string `json:"exception"`
+	JavaClassName string `json:"javaClassName"`
+	Message       string `json:"message"`
+}
+
+func (e *RemoteError) Error() string {
+	if e.Exception == "" {
+		return fmt.Sprintf("webhdfs request failed with status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("%s: %s", e.Exception, e.Message)
+}
+
+func IsNotFound(err error) bool {
+	var remoteErr *RemoteError
+	return errors.As(err, &remoteErr) && (remoteErr.StatusCode == http.StatusNotFound || remoteErr.Exception == "FileNotFoundException")
+}
+
+type Options struct {
+	// Username is sent as user.name with simple authentication, it is
+	// ignored when Kerberos is set.
+	Username string
+	// Kerberos authenticates the requests with SPNEGO, the client must be
+	// logged in.
+	Kerberos *krb5client.Client
+	// Transport is used for all requests, http.DefaultTransport when nil.
+	Transport http.RoundTripper
+}
+
+// Client talks to the WebHDFS REST API of a NameNode, or to an HttpFS
+// gateway which serves the same API.
+type Client struct {
+	baseURL *url.URL
+	opts    Options
+}
+
+// New returns a client for baseURL, which is the http(s)://host:port of the
+// NameNode or HttpFS gateway.
+func New(baseURL string, opts Options) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid scheme %s, only http and https are supported", u.Scheme)
+	}
+	if opts.Transport == nil {
+		opts.Transport = http.DefaultTransport
+	}
+
+	return &Client{baseURL: u, opts: opts}, nil
+}
+
+func (c *Client) GetFileStatus(ctx context.Context, p string) (*FileStatus, error) {
+	var res struct {
+		FileStatus FileStatus `json:"FileStatus"`
+	}
+	if err := c.getJSON(ctx, p, "GETFILESTATUS", &res); err != nil {
+		return nil, err
+	}
+
+	return &res.FileStatus, nil
+}
+
+func (c *Client) ListStatus(ctx context.Context, p string) ([]FileStatus, error) {
+	var res struct {
+		FileStatuses struct {
+			FileStatus []FileStatus `json:"FileStatus"`
+		} `json:"FileStatuses"`
+	}
+	if err := c.getJSON(ctx, p, "LISTSTATUS", &res); err != nil {
+		return nil, err
+	}
+
+	return res.FileStatuses.FileStatus, nil
+}
+
+// Walk calls fn for every file and directory under root, rel is the path
+// relative to root separated by slashes. Directories are listed before their
+// content, and their content is skipped when fn returns SkipDir.
+func (c *Client) Walk(ctx context.Context, root string, fn func(rel string, status FileStatus) error) error {
+	return c.walk(ctx, root, "", fn)
+}
+
+// SkipDir is returned by the function passed to Walk to skip a directory.
+var SkipDir = errors.New("skip this directory")
+
+func (c *Client) walk(ctx context.Context, root, dir string, fn func(rel string, status FileStatus) error) error {
+	statuses, err := c.ListStatus(ctx, path.Join(root, dir))
+	if err != nil {
+		return err
+	}
+	for _, status := range statuses {
+		rel := path.Join(dir, status.PathSuffix)
+		err := fn(rel, status)
+		if status.Type != TypeDirectory {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if errors.Is(err, SkipDir) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := c.walk(ctx, root, rel, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Open returns the content of a file, the NameNode redirects the request to
+// a DataNode which is followed.
+func (c *Client) Open(ctx context.Context, p string) (io.ReadCloser, error) {
+	resp, err := c.do(ctx, p, "OPEN")
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
+func (c *Client) getJSON(ctx context.Context, p, op string, v any) error {
+	resp, err := c.do(ctx, p, op)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response of %s %s: %w", op, p, err)
+	}
+
+	return nil
+}
+
+func (c *Client) do(ctx context.Context, p, op string) (*http.Response, error) {
+	if !strings.HasPrefix(p, "/") {
+		return nil, fmt.Errorf("path %s must be absolute", p)
+	}
+
+	u := *c.baseURL
+	u.Path = path.Join(u.Path, "/webhdfs/v1", p)
+	if strings.HasSuffix(p, "/") {
+		u.Path += "/"
+	}
+	query := url.Values{"op": {op}}
+	if c.opts.Kerberos == nil && c.opts.Username != "" {
+		query.Set("user.name", c.opts.Username)
+	}
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp *http.Response
+	if c.opts.Kerberos != nil {
+		// the SPNEGO client keeps per request state, it can not be shared
+		// between concurrent requests
+		resp, err = spnego.NewClient(c.opts.Kerberos, &http.Client{Transport: c.opts.Transport}, "").Do(req)
+	} else {
+		resp, err = (&http.Client{Transport: c.opts.Transport}).Do(req)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+
+		var res struct {
+			RemoteException RemoteError `json:"RemoteException"`
+		}
+		_ = json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&res)
+		res.RemoteException.StatusCode = resp.StatusCode
+		return nil, fmt.Errorf("failed to %s %s: %w", op, p, &res.RemoteException)
+	}
+
+	return resp, nil
+}
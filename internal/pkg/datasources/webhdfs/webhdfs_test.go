@@ -0,0 +1,109 @@
+package webhdfs
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/webhdfs/v1/", func(w http.ResponseWriter, r *http.Request) {
+		p := strings.TrimPrefix(r.URL.Path, "/webhdfs/v1")
+		assert.Equal(t, "hdfs", r.URL.Query().Get("user.name"))
+		switch op := r.URL.Query().Get("op"); {
+		case op == "GETFILESTATUS" && p == "/data":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"FileStatus": FileStatus{Type: TypeDirectory},
+			})
+		case op == "LISTSTATUS" && p == "/data":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"FileStatuses": map[string]any{"FileStatus": []FileStatus{
+					{PathSuffix: "a b.txt", Type: TypeFile, Length: 5},
+					{PathSuffix: "sub", Type: TypeDirectory},
+				}},
+			})
+		case op == "LISTSTATUS" && p == "/data/sub":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"FileStatuses": map[string]any{"FileStatus": []FileStatus{
+					{PathSuffix: "c.txt", Type: TypeFile, Length: 3},
+				}},
+			})
+		case op == "OPEN" && p == "/data/a b.txt":
+			// the NameNode redirects reads to a DataNode
+			http.Redirect(w, r, "/datanode?file=a", http.StatusTemporaryRedirect)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"RemoteException": RemoteError{
+					Exception:     "FileNotFoundException",
+					JavaClassName: "java.io.FileNotFoundException",
+					Message:       "File " + p + " does not exist.",
+				},
+			})
+		}
+	})
+	mux.HandleFunc("/datanode", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hello"))
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClient(t *testing.T) {
+	server := newTestServer(t)
+	client, err := New(server.URL, Options{Username: "hdfs"})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	status, err := client.GetFileStatus(ctx, "/data")
+	require.NoError(t, err)
+	assert.Equal(t, TypeDirectory, status.Type)
+
+	var walked []string
+	err = client.Walk(ctx, "/data", func(rel string, status FileStatus) error {
+		walked = append(walked, rel)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a b.txt", "sub", "sub/c.txt"}, walked)
+
+	walked = nil
+	err = client.Walk(ctx, "/data", func(rel string, status FileStatus) error {
+		walked = append(walked, rel)
+		if status.Type == TypeDirectory {
+			return SkipDir
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a b.txt", "sub"}, walked)
+
+	body, err := client.Open(ctx, "/data/a b.txt")
+	require.NoError(t, err)
+	content, err := io.ReadAll(body)
+	require.NoError(t, err)
+	require.NoError(t, body.Close())
+	assert.Equal(t, "hello", string(content))
+
+	_, err = client.GetFileStatus(ctx, "/missing")
+	assert.True(t, IsNotFound(err))
+	assert.ErrorContains(t, err, "FileNotFoundException: File /missing does not exist.")
+
+	_, err = client.GetFileStatus(ctx, "relative")
+	assert.ErrorContains(t, err, "must be absolute")
+}
+
+func TestNew(t *testing.T) {
+	_, err := New("webhdfs://namenode:9870", Options{})
+	assert.ErrorContains(t, err, "invalid scheme webhdfs")
+}
@@ -0,0 +1,107 @@
+package jobresult
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// DefaultPath is the default terminationMessagePath of containers.
+const DefaultPath = "/dev/termination-log"
+
+// Result is what the data loader reports about a sync round or an export. It
+// is written to the termination message of the data loader container, where
+// the controller reads it once the job succeeded.
+type Result struct {
+	// Digest identifies the loaded content, e.g. sha256:<hex> of the manifest
+	// of the packages of an environment.
+	Digest string `json:"digest,omitempty"`
+	// Revision identifies what an export pushed, e.g. the commit of a Git
+	// branch or of a HuggingFace repo.
+	Revision string `json:"revision,omitempty"`
+	// PostProcess is the outcome of each post process step run, in order.
+	PostProcess []StepResult `json:"postProcess,omitempty"`
+	// Verification is the outcome of verifying the data against its manifest.
+	Verification *Verification `json:"verification,omitempty"`
+	// Round is the round of the primary dataset published to, or read from
+	// the head of a replica store.
+	Round int32 `json:"round,omitempty"`
+	// BlobCache is what the blob cache did for the sync, if any.
+	BlobCache *BlobCache `json:"blobCache,omitempty"`
+	// Warmup is what the copy of the dataset onto a node did.
+	Warmup *Warmup `json:"warmup,omitempty"`
+}
+
+// Warmup is what the copy of the dataset onto a node did, the files already
+// on the node are not copied again.
+type Warmup struct {
+	Files        int64 `json:"files,omitempty"`
+	CopiedFiles  int64 `json:"copiedFiles,omitempty"`
+	CopiedBytes  int64 `json:"copiedBytes,omitempty"`
+	RemovedFiles int64 `json:"removedFiles,omitempty"`
+}
+
+// BlobCache is what the blob cache shared by the datasets did for a sync.
+type BlobCache struct {
+	Hits         int   `json:"hits,omitempty"`
+	Misses       int   `json:"misses,omitempty"`
+	HitBytes     int64 `json:"hitBytes,omitempty"`
+	StoredBytes  int64 `json:"storedBytes,omitempty"`
+	EvictedBytes int64 `json:"evictedBytes,omitempty"`
+}
+
+// StepResult is the outcome of a post process step.
+type StepResult struct {
+	Name    string `json:"name"`
+	Succeed bool   `json:"succeed,omitempty"`
+	// Message is the error of a failed step or a summary of what the step did.
+	Message string `json:"message,omitempty"`
+}
+
+// Verification is the outcome of verifying the data against its manifest.
+type Verification struct {
+	// Files is the number of files of the data.
+	Files int `json:"files"`
+	// Recorded is true when there was no manifest, the data is recorded as
+	// the manifest instead of being verified.
+	Recorded bool `json:"recorded,omitempty"`
+	// DriftedCount is the number of files which drifted from the manifest.
+	DriftedCount int `json:"driftedCount,omitempty"`
+	// Drifted is the first of the drifted files, as many as fit in the
+	// termination message.
+	Drifted []DriftedFile `json:"drifted,omitempty"`
+}
+
+type DriftedFile struct {
+	Path string `json:"path"`
+	// Reason is MODIFIED, MISSING or ADDED.
+	Reason string `json:"reason"`
+}
+
+func (r Result) IsZero() bool {
+	return r.Digest == "" && r.Revision == "" && len(r.PostProcess) == 0 && r.Verification == nil && r.Round == 0 && r.BlobCache == nil && r.Warmup == nil
+}
+
+// Write writes the result to the termination message file at path.
+func Write(path string, result Result) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644) // #nosec G306
+}
+
+// Parse parses the termination message of the data loader container, an
+// empty message is an empty result.
+func Parse(message string) (Result, error) {
+	var result Result
+	if message == "" {
+		return result, nil
+	}
+	if err := json.Unmarshal([]byte(message), &result); err != nil {
+		return Result{}, fmt.Errorf("invalid result %q: %w", message, err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,30 @@
+package jobresult
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteAndParse(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "termination-log")
+	require.NoError(t, Write(path, Result{Digest: "sha256:abc"}))
+
+	message, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"digest":"sha256:abc"}`, string(message))
+
+	result, err := Parse(string(message))
+	require.NoError(t, err)
+	assert.Equal(t, Result{Digest: "sha256:abc"}, result)
+
+	result, err = Parse("")
+	require.NoError(t, err)
+	assert.True(t, result.IsZero())
+
+	_, err = Parse("OOMKilled")
+	assert.ErrorContains(t, err, `invalid result "OOMKilled"`)
+}
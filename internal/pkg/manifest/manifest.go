@@ -0,0 +1,168 @@
+package manifest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/BaizeAI/dataset/pkg/utils"
+)
+
+// Filename is the name of the manifest at the root of the dataset, it is not
+// part of the manifest itself.
+const Filename = ".dataset-manifest.json"
+
+const (
+	DriftModified = "MODIFIED"
+	DriftMissing  = "MISSING"
+	DriftAdded    = "ADDED"
+)
+
+// Manifest records the content of a dataset, by which the dataset is verified
+// later.
+type Manifest struct {
+	Files []File `json:"files"`
+}
+
+// File is a regular file or a symbolic link of the dataset.
+type File struct {
+	// Path is slash separated and relative to the dataset.
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256,omitempty"`
+	// Link is the target of a symbolic link, which is not followed.
+	Link string `json:"link,omitempty"`
+}
+
+type Drift struct {
+	Path   string
+	Reason string
+}
+
+// Build walks the dataset at root and computes the size and SHA256 of each
+// regular file, sorted by path.
+func Build(root string) (*Manifest, error) {
+	m := &Manifest{Files: make([]File, 0)}
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || rel == Filename {
+			return nil
+		}
+
+		file := File{Path: filepath.ToSlash(rel)}
+		switch {
+		case d.Type()&fs.ModeSymlink != 0:
+			file.Link, err = os.Readlink(p)
+			if err != nil {
+				return err
+			}
+		case d.Type().IsRegular():
+			file.Size, file.SHA256, err = sha256File(p)
+			if err != nil {
+				return err
+			}
+		default:
+			return nil
+		}
+		m.Files = append(m.Files, file)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(m.Files, func(i, j int) bool {
+		return m.Files[i].Path < m.Files[j].Path
+	})
+
+	return m, nil
+}
+
+func sha256File(p string) (int64, string, error) {
+	f, err := os.Open(p) // #nosec G304
+	if err != nil {
+		return 0, "", err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return 0, "", err
+	}
+
+	return n, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Read reads the manifest of the dataset at root, the error wraps
+// os.ErrNotExist when the dataset has no manifest.
+func Read(root string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(root, Filename)) // #nosec G304
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %w", Filename, err)
+	}
+
+	return m, nil
+}
+
+// Write writes the manifest of the dataset at root.
+func Write(root string, m *Manifest) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	return utils.WriteFileAtomic(filepath.Join(root, Filename), bytes.NewReader(data), 0644)
+}
+
+// Compare returns the files of actual which drifted from recorded, sorted by
+// path.
+func Compare(recorded, actual *Manifest) []Drift {
+	files := make(map[string]File, len(recorded.Files))
+	for _, file := range recorded.Files {
+		files[file.Path] = file
+	}
+
+	var drifts []Drift
+	for _, file := range actual.Files {
+		recordedFile, ok := files[file.Path]
+		if !ok {
+			drifts = append(drifts, Drift{Path: file.Path, Reason: DriftAdded})
+			continue
+		}
+		delete(files, file.Path)
+		if recordedFile != file {
+			drifts = append(drifts, Drift{Path: file.Path, Reason: DriftModified})
+		}
+	}
+	for p := range files {
+		drifts = append(drifts, Drift{Path: p, Reason: DriftMissing})
+	}
+
+	sort.Slice(drifts, func(i, j int) bool {
+		return drifts[i].Path < drifts[j].Path
+	})
+
+	return drifts
+}
@@ -0,0 +1,52 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildAndCompare(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "sub"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "a.txt"), []byte("a"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "sub/b.txt"), []byte("bb"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "sub/c.txt"), []byte("c"), 0644))
+	require.NoError(t, os.Symlink("sub/b.txt", filepath.Join(root, "link")))
+
+	_, err := Read(root)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	recorded, err := Build(root)
+	require.NoError(t, err)
+	assert.Equal(t, []File{
+		{Path: "a.txt", Size: 1, SHA256: "ca978112ca1bbdcafac231b39a23dc4da786eff8147c4e72b9807785afee48bb"},
+		{Path: "link", Link: "sub/b.txt"},
+		{Path: "sub/b.txt", Size: 2, SHA256: "3b64db95cb55c763391c707108489ae18b4112d783300de38e033b4c98c3deaf"},
+		{Path: "sub/c.txt", Size: 1, SHA256: "2e7d2c03a9507ae265ecf5b5356885a53393a2029d241394997265a1a25aefc6"},
+	}, recorded.Files)
+	require.NoError(t, Write(root, recorded))
+
+	// the manifest is not part of itself
+	read, err := Read(root)
+	require.NoError(t, err)
+	assert.Equal(t, recorded, read)
+	actual, err := Build(root)
+	require.NoError(t, err)
+	assert.Empty(t, Compare(read, actual))
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "a.txt"), []byte("b"), 0644))
+	require.NoError(t, os.Remove(filepath.Join(root, "sub/c.txt")))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "sub/d.txt"), nil, 0644))
+
+	actual, err = Build(root)
+	require.NoError(t, err)
+	assert.Equal(t, []Drift{
+		{Path: "a.txt", Reason: DriftModified},
+		{Path: "sub/c.txt", Reason: DriftMissing},
+		{Path: "sub/d.txt", Reason: DriftAdded},
+	}, Compare(read, actual))
+}
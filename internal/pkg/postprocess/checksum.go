@@ -0,0 +1,105 @@
+package postprocess
+
+import (
+	"bufio"
+	"crypto/md5"  // #nosec G501
+	"crypto/sha1" // #nosec G505
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BaizeAI/dataset/pkg/utils"
+)
+
+func newHash(algorithm string) (func() hash.Hash, error) {
+	switch strings.ToLower(algorithm) {
+	case "", "sha256":
+		return sha256.New, nil
+	case "sha512":
+		return sha512.New, nil
+	case "sha1":
+		return sha1.New, nil // #nosec G401
+	case "md5":
+		return md5.New, nil // #nosec G401
+	default:
+		return nil, fmt.Errorf("unsupported checksum algorithm %s, must be one of sha256, sha512, sha1, md5", algorithm)
+	}
+}
+
+// checksum verifies the files listed in the manifest at the path, in the
+// format of the output of sha256sum, the files are relative to the directory
+// of the manifest. The options are:
+// - algorithm: sha256(default), sha512, sha1 or md5
+func checksum(root string, step Step) (string, error) {
+	manifest, err := resolvePath(root, step.Path)
+	if err != nil {
+		return "", err
+	}
+	hashFunc, err := newHash(step.Options["algorithm"])
+	if err != nil {
+		return "", err
+	}
+
+	f, err := os.Open(manifest) // #nosec G304
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	var verified int
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		expected, name, ok := strings.Cut(line, " ")
+		if !ok {
+			return "", fmt.Errorf("invalid line %q of the manifest", line)
+		}
+		// the binary mode of sha256sum marks the names with *
+		name = strings.TrimPrefix(strings.TrimLeft(name, " "), "*")
+		rel, err := utils.CleanRelativePath(name)
+		if err != nil {
+			return "", err
+		}
+
+		actual, err := fileChecksum(filepath.Join(filepath.Dir(manifest), filepath.FromSlash(rel)), hashFunc())
+		if err != nil {
+			return "", err
+		}
+		if !strings.EqualFold(actual, expected) {
+			return "", fmt.Errorf("checksum of %s mismatched, expected %s, got %s", rel, expected, actual)
+		}
+		verified++
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("verified %d files", verified), nil
+}
+
+func fileChecksum(p string, h hash.Hash) (string, error) {
+	f, err := os.Open(p) // #nosec G304
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
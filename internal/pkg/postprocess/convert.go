@@ -0,0 +1,134 @@
+package postprocess
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/samber/lo"
+	"github.com/sirupsen/logrus"
+)
+
+// convert converts the files matching the path to another format, next to
+// them with the extension of the format. The options are:
+// - from: the format of the files, only csv is supported
+// - to: the format to convert to, only parquet is supported
+// - remove: "true" removes the files once converted
+func convert(logger *logrus.Entry, root string, step Step) (string, error) {
+	from := strings.ToLower(lo.CoalesceOrEmpty(step.Options["from"], "csv"))
+	to := strings.ToLower(lo.CoalesceOrEmpty(step.Options["to"], "parquet"))
+	if from != "csv" || to != "parquet" {
+		return "", fmt.Errorf("converting from %s to %s is not supported, only csv to parquet is", from, to)
+	}
+
+	files, err := glob(root, step.Path, false)
+	if err != nil {
+		return "", err
+	}
+
+	var rows int
+	for _, file := range files {
+		target := strings.TrimSuffix(file, filepath.Ext(file)) + "." + to
+		logger.Infof("converting %s to %s", file, target)
+		n, err := convertCSVToParquet(file, target)
+		if err != nil {
+			return "", fmt.Errorf("failed to convert %s: %w", filepath.Base(file), err)
+		}
+		rows += n
+
+		if step.Options["remove"] == "true" {
+			if err := os.Remove(file); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	return fmt.Sprintf("converted %d rows of %d files", rows, len(files)), nil
+}
+
+// convertCSVToParquet writes the rows of a CSV file with a header to a
+// Parquet file with a string column by field of the header, empty fields are
+// NULLs.
+func convertCSVToParquet(source, target string) (int, error) {
+	in, err := os.Open(source) // #nosec G304
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = in.Close()
+	}()
+
+	r := csv.NewReader(in)
+	header, err := r.Read()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read the header: %w", err)
+	}
+	group := make(parquet.Group, len(header))
+	for _, name := range header {
+		if _, ok := group[name]; ok {
+			return 0, fmt.Errorf("duplicate column %s is not supported by parquet", name)
+		}
+		group[name] = parquet.Optional(parquet.String())
+	}
+	schema := parquet.NewSchema("row", group)
+	leaves := make([]int, len(header))
+	for i, name := range header {
+		leaf, ok := schema.Lookup(name)
+		if !ok {
+			return 0, fmt.Errorf("column %s not found in parquet schema", name)
+		}
+		leaves[i] = leaf.ColumnIndex
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".*")
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	w := parquet.NewWriter(tmp, schema, parquet.Compression(&parquet.Snappy))
+	row := make(parquet.Row, len(header))
+	var rows int
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			_ = tmp.Close()
+			return 0, err
+		}
+		for i, field := range record {
+			if field == "" {
+				row[leaves[i]] = parquet.NullValue().Level(0, 0, leaves[i])
+			} else {
+				row[leaves[i]] = parquet.ByteArrayValue([]byte(field)).Level(0, 1, leaves[i])
+			}
+		}
+		if _, err := w.WriteRows([]parquet.Row{row}); err != nil {
+			_ = tmp.Close()
+			return 0, err
+		}
+		rows++
+	}
+
+	err = w.Close()
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil { // #nosec G302
+		return 0, err
+	}
+
+	return rows, os.Rename(tmp.Name(), target)
+}
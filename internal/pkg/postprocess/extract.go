@@ -0,0 +1,165 @@
+package postprocess
+
+import (
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/BaizeAI/dataset/pkg/utils"
+)
+
+// extract extracts the tar, tar.gz and zip archives matching the path. The
+// options are:
+// - destination: the directory relative to the dataset to extract into,
+// defaults to the directory of each archive
+// - remove: "true" removes the archives once extracted
+func extract(logger *logrus.Entry, root string, step Step) (string, error) {
+	archives, err := glob(root, step.Path, false)
+	if err != nil {
+		return "", err
+	}
+
+	var extracted int
+	for _, archive := range archives {
+		destination := filepath.Dir(archive)
+		if step.Options["destination"] != "" {
+			destination, err = resolvePath(root, step.Options["destination"])
+			if err != nil {
+				return "", err
+			}
+		}
+		if err := os.MkdirAll(destination, 0755); err != nil { // #nosec G301
+			return "", err
+		}
+
+		logger.Infof("extracting %s into %s", archive, destination)
+		n, err := extractArchive(logger, archive, destination)
+		if err != nil {
+			return "", fmt.Errorf("failed to extract %s: %w", filepath.Base(archive), err)
+		}
+		extracted += n
+
+		if step.Options["remove"] == "true" {
+			if err := os.Remove(archive); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	return fmt.Sprintf("extracted %d files from %d archives", extracted, len(archives)), nil
+}
+
+func extractArchive(logger *logrus.Entry, archive, destination string) (int, error) {
+	name := strings.ToLower(filepath.Base(archive))
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return extractZip(logger, archive, destination)
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		f, err := os.Open(archive) // #nosec G304
+		if err != nil {
+			return 0, err
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+		gr, err := gzip.NewReader(f)
+		if err != nil {
+			return 0, err
+		}
+		paths, err := utils.ExtractTar(logger, gr, destination)
+		return len(paths), err
+	case strings.HasSuffix(name, ".tar"):
+		f, err := os.Open(archive) // #nosec G304
+		if err != nil {
+			return 0, err
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+		paths, err := utils.ExtractTar(logger, f, destination)
+		return len(paths), err
+	default:
+		return 0, fmt.Errorf("unsupported archive format, must be one of .tar, .tar.gz, .tgz, .zip")
+	}
+}
+
+// extractZip extracts the directories and regular files of a zip archive,
+// the entries are confined to the destination.
+func extractZip(logger *logrus.Entry, archive, destination string) (int, error) {
+	zr, err := zip.OpenReader(archive)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = zr.Close()
+	}()
+	root, err := os.OpenRoot(destination)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = root.Close()
+	}()
+
+	var extracted int
+	for _, f := range zr.File {
+		if path.Clean("/"+f.Name) == "/" {
+			continue
+		}
+		rel, err := utils.CleanRelativePath(f.Name)
+		if err != nil {
+			return extracted, err
+		}
+
+		mode := f.Mode()
+		switch {
+		case mode.IsDir():
+			if err := root.MkdirAll(rel, 0755); err != nil { // #nosec G301
+				return extracted, err
+			}
+		case mode.IsRegular():
+			if dir := path.Dir(rel); dir != "." {
+				if err := root.MkdirAll(dir, 0755); err != nil { // #nosec G301
+					return extracted, err
+				}
+			}
+			if err := extractZipFile(root, rel, f); err != nil {
+				return extracted, err
+			}
+			extracted++
+		default:
+			logger.Debugf("skipping %s of mode %s", f.Name, mode)
+		}
+	}
+
+	return extracted, nil
+}
+
+func extractZipFile(root *os.Root, rel string, f *zip.File) error {
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+
+	_ = root.RemoveAll(rel)
+	w, err := root.OpenFile(rel, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, f.Mode().Perm()|0600)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, r) // #nosec G110
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
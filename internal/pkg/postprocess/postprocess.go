@@ -0,0 +1,144 @@
+package postprocess
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/sirupsen/logrus"
+
+	"github.com/BaizeAI/dataset/internal/pkg/jobresult"
+)
+
+type Type string
+
+const (
+	TypeExtract  Type = "EXTRACT"
+	TypeChecksum Type = "CHECKSUM"
+	TypeConvert  Type = "CONVERT"
+	TypeValidate Type = "VALIDATE"
+	TypeDelete   Type = "DELETE"
+)
+
+// maxMessageLength keeps the results of all the steps within the size limit of
+// the termination message.
+const maxMessageLength = 200
+
+// Step is a built-in post process step run by the data loader once the data
+// is loaded, the controller passes the steps of the dataset as JSON.
+type Step struct {
+	Name string `json:"name"`
+	Type Type   `json:"type"`
+	// Path is relative to the dataset, it is a glob pattern for the steps
+	// which accept several files.
+	Path    string            `json:"path,omitempty"`
+	Options map[string]string `json:"options,omitempty"`
+}
+
+// Run runs the steps in order on the dataset at root, it stops at the first
+// step which failed. The results of the steps run are returned either way.
+func Run(logger *logrus.Entry, root string, steps []Step) ([]jobresult.StepResult, error) {
+	results := make([]jobresult.StepResult, 0, len(steps))
+	for _, step := range steps {
+		stepLogger := logger.WithFields(logrus.Fields{
+			"step": step.Name,
+			"type": step.Type,
+			"path": step.Path,
+		})
+		stepLogger.Info("running post process step")
+
+		message, err := runStep(stepLogger, root, step)
+		if err != nil {
+			results = append(results, jobresult.StepResult{
+				Name:    step.Name,
+				Message: truncate(err.Error()),
+			})
+			return results, fmt.Errorf("post process step %s failed: %w", step.Name, err)
+		}
+
+		stepLogger.Info(message)
+		results = append(results, jobresult.StepResult{
+			Name:    step.Name,
+			Succeed: true,
+			Message: truncate(message),
+		})
+	}
+
+	return results, nil
+}
+
+func runStep(logger *logrus.Entry, root string, step Step) (string, error) {
+	switch step.Type {
+	case TypeExtract:
+		return extract(logger, root, step)
+	case TypeChecksum:
+		return checksum(root, step)
+	case TypeConvert:
+		return convert(logger, root, step)
+	case TypeValidate:
+		return validate(root, step)
+	case TypeDelete:
+		return deleteMatched(logger, root, step)
+	default:
+		return "", fmt.Errorf("post process step type %s is not supported", step.Type)
+	}
+}
+
+// resolvePath returns the path of p relative to the dataset at root, p must
+// not escape the dataset.
+func resolvePath(root, p string) (string, error) {
+	if filepath.IsAbs(p) {
+		return "", fmt.Errorf("path %s must be relative to the dataset", p)
+	}
+	if lo.Contains(strings.Split(filepath.ToSlash(p), "/"), "..") {
+		return "", fmt.Errorf("path %s must not contain ..", p)
+	}
+
+	return filepath.Join(root, p), nil
+}
+
+// glob returns the paths of the dataset at root matching the pattern, at
+// least one path must match unless allowEmpty.
+func glob(root, pattern string, allowEmpty bool) ([]string, error) {
+	p, err := resolvePath(root, pattern)
+	if err != nil {
+		return nil, err
+	}
+	matches, err := filepath.Glob(p)
+	if err != nil {
+		return nil, fmt.Errorf("invalid path pattern %s: %w", pattern, err)
+	}
+	if len(matches) == 0 && !allowEmpty {
+		return nil, fmt.Errorf("no files match %s", pattern)
+	}
+
+	return matches, nil
+}
+
+func deleteMatched(logger *logrus.Entry, root string, step Step) (string, error) {
+	if strings.Trim(filepath.Clean(step.Path), "/") == "." {
+		return "", fmt.Errorf("path is required to delete files")
+	}
+	matches, err := glob(root, step.Path, true)
+	if err != nil {
+		return "", err
+	}
+	for _, match := range matches {
+		logger.Debugf("deleting %s", match)
+		if err := os.RemoveAll(match); err != nil {
+			return "", err
+		}
+	}
+
+	return fmt.Sprintf("deleted %d paths", len(matches)), nil
+}
+
+func truncate(message string) string {
+	if len(message) <= maxMessageLength {
+		return message
+	}
+
+	return message[:maxMessageLength-3] + "..."
+}
@@ -0,0 +1,181 @@
+package postprocess
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/BaizeAI/dataset/internal/pkg/jobresult"
+)
+
+func writeFile(t *testing.T, p string, data []byte) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+	require.NoError(t, os.WriteFile(p, data, 0644))
+}
+
+func tarGz(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	gw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gw)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+	return buf.Bytes()
+}
+
+func zipped(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func safetensors(t *testing.T, tensors map[string]int64, dataSize int) []byte {
+	t.Helper()
+	header := "{"
+	var offset int64
+	for name, size := range tensors {
+		if header != "{" {
+			header += ","
+		}
+		header += fmt.Sprintf(`%q:{"dtype":"F32","shape":[%d],"data_offsets":[%d,%d]}`, name, size/4, offset, offset+size)
+		offset += size
+	}
+	header += "}"
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, binary.Write(buf, binary.LittleEndian, uint64(len(header))))
+	buf.WriteString(header)
+	buf.Write(make([]byte, dataSize))
+	return buf.Bytes()
+}
+
+func TestRun(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "raw/a.tar.gz"), tarGz(t, map[string]string{"a/1.txt": "1"}))
+	writeFile(t, filepath.Join(root, "raw/b.zip"), zipped(t, map[string]string{"b/2.txt": "2", "b/sub/": ""}))
+	writeFile(t, filepath.Join(root, "tables/users.csv"), []byte("id,name\n1,alice\n2,\n"))
+	writeFile(t, filepath.Join(root, "tmp/cache.bin"), []byte("cache"))
+
+	sum := sha256.Sum256([]byte("1"))
+	writeFile(t, filepath.Join(root, "SHA256SUMS"), []byte(hex.EncodeToString(sum[:])+"  data/a/1.txt\n"))
+
+	model := filepath.Join(root, "model")
+	writeFile(t, filepath.Join(model, "model-00001-of-00002.safetensors"), safetensors(t, map[string]int64{"a": 8}, 8))
+	writeFile(t, filepath.Join(model, "model-00002-of-00002.safetensors"), safetensors(t, map[string]int64{"b": 4, "c": 4}, 8))
+	writeFile(t, filepath.Join(model, "model.safetensors.index.json"), []byte(`{"weight_map":{
+		"a":"model-00001-of-00002.safetensors",
+		"b":"model-00002-of-00002.safetensors",
+		"c":"model-00002-of-00002.safetensors"
+	}}`))
+
+	results, err := Run(logrus.NewEntry(logrus.New()), root, []Step{
+		{Name: "extract", Type: TypeExtract, Path: "raw/*", Options: map[string]string{"destination": "data", "remove": "true"}},
+		{Name: "checksum", Type: TypeChecksum, Path: "SHA256SUMS"},
+		{Name: "convert", Type: TypeConvert, Path: "tables/*.csv", Options: map[string]string{"remove": "true"}},
+		{Name: "validate", Type: TypeValidate, Path: "model"},
+		{Name: "cleanup", Type: TypeDelete, Path: "tmp"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []jobresult.StepResult{
+		{Name: "extract", Succeed: true, Message: "extracted 2 files from 2 archives"},
+		{Name: "checksum", Succeed: true, Message: "verified 1 files"},
+		{Name: "convert", Succeed: true, Message: "converted 2 rows of 1 files"},
+		{Name: "validate", Succeed: true, Message: "validated 2 safetensors files"},
+		{Name: "cleanup", Succeed: true, Message: "deleted 1 paths"},
+	}, results)
+
+	assert.FileExists(t, filepath.Join(root, "data/a/1.txt"))
+	assert.FileExists(t, filepath.Join(root, "data/b/2.txt"))
+	assert.DirExists(t, filepath.Join(root, "data/b/sub"))
+	assert.NoFileExists(t, filepath.Join(root, "raw/a.tar.gz"))
+	assert.NoFileExists(t, filepath.Join(root, "tables/users.csv"))
+	assert.NoDirExists(t, filepath.Join(root, "tmp"))
+
+	type user struct {
+		ID   *string `parquet:"id"`
+		Name *string `parquet:"name"`
+	}
+	users, err := parquet.ReadFile[user](filepath.Join(root, "tables/users.parquet"))
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	assert.Equal(t, "alice", *users[0].Name)
+	assert.Equal(t, "2", *users[1].ID)
+	assert.Nil(t, users[1].Name)
+}
+
+func TestRunFailed(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "data.txt"), []byte("data"))
+	writeFile(t, filepath.Join(root, "SHA256SUMS"), []byte("0000  data.txt\n"))
+
+	results, err := Run(logrus.NewEntry(logrus.New()), root, []Step{
+		{Name: "checksum", Type: TypeChecksum, Path: "SHA256SUMS"},
+		{Name: "cleanup", Type: TypeDelete, Path: "data.txt"},
+	})
+	require.EqualError(t, err, "post process step checksum failed: checksum of data.txt mismatched, expected 0000, got "+
+		"3a6eb0790f39ac87c94f3856b2dd2c5d110e6811602261a9a923d3bb23adc8b7")
+	require.Len(t, results, 1)
+	assert.False(t, results[0].Succeed)
+	// the steps after the failed one are not run
+	assert.FileExists(t, filepath.Join(root, "data.txt"))
+}
+
+func TestStepErrors(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "archive.rar"), []byte("rar"))
+	writeFile(t, filepath.Join(root, "evil.zip"), zipped(t, map[string]string{"../evil.txt": "evil"}))
+	writeFile(t, filepath.Join(root, "truncated.safetensors"), safetensors(t, map[string]int64{"a": 8}, 4))
+	writeFile(t, filepath.Join(root, "model/model-00001-of-00001.safetensors"), safetensors(t, map[string]int64{"a": 4}, 4))
+	writeFile(t, filepath.Join(root, "model/model.safetensors.index.json"), []byte(`{"weight_map":{"b":"model-00001-of-00001.safetensors"}}`))
+
+	for _, testCase := range []struct {
+		name    string
+		step    Step
+		wantErr string
+	}{
+		{name: "escaping path", step: Step{Type: TypeDelete, Path: "../other"}, wantErr: "path ../other must not contain .."},
+		{name: "absolute path", step: Step{Type: TypeExtract, Path: "/data"}, wantErr: "path /data must be relative to the dataset"},
+		{name: "deleting the dataset", step: Step{Type: TypeDelete, Path: "./"}, wantErr: "path is required to delete files"},
+		{name: "nothing matched", step: Step{Type: TypeExtract, Path: "*.tar"}, wantErr: "no files match *.tar"},
+		{name: "unsupported archive", step: Step{Type: TypeExtract, Path: "archive.rar"}, wantErr: "failed to extract archive.rar: unsupported archive format, must be one of .tar, .tar.gz, .tgz, .zip"},
+		{name: "zip slip", step: Step{Type: TypeExtract, Path: "evil.zip"}, wantErr: `failed to extract evil.zip: invalid path "../evil.txt", it must be inside the dataset`},
+		{name: "unsupported algorithm", step: Step{Type: TypeChecksum, Path: "SHA256SUMS", Options: map[string]string{"algorithm": "crc32"}}, wantErr: "unsupported checksum algorithm crc32, must be one of sha256, sha512, sha1, md5"},
+		{name: "unsupported conversion", step: Step{Type: TypeConvert, Path: "*.json", Options: map[string]string{"from": "json"}}, wantErr: "converting from json to parquet is not supported, only csv to parquet is"},
+		{name: "truncated safetensors", step: Step{Type: TypeValidate, Path: "truncated.safetensors"}, wantErr: "data of tensor a of truncated.safetensors is out of the file, the file may be truncated"},
+		{name: "missing tensor", step: Step{Type: TypeValidate, Path: "model"}, wantErr: "tensor b is not found in shard model-00001-of-00001.safetensors"},
+		{name: "container", step: Step{Type: "CONTAINER"}, wantErr: "post process step type CONTAINER is not supported"},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := runStep(logrus.NewEntry(logrus.New()), root, testCase.step)
+			assert.EqualError(t, err, testCase.wantErr)
+		})
+	}
+	assert.NoFileExists(t, filepath.Join(filepath.Dir(root), "evil.txt"))
+}
@@ -0,0 +1,195 @@
+package postprocess
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+
+	"github.com/BaizeAI/dataset/pkg/utils"
+)
+
+// maxSafetensorsHeaderSize is the limit of the size of the header of a
+// safetensors file of the reference implementation.
+const maxSafetensorsHeaderSize = 100 << 20
+
+// validate checks the files matching the path are well-formed. The options
+// are:
+// - format: the format of the files, only safetensors is supported
+//
+// For safetensors, a directory is validated by its *.safetensors.index.json
+// indexes, each of which must list shards containing the tensors mapped to
+// them, or by its *.safetensors files when it has no index.
+func validate(root string, step Step) (string, error) {
+	format := strings.ToLower(lo.CoalesceOrEmpty(step.Options["format"], "safetensors"))
+	if format != "safetensors" {
+		return "", fmt.Errorf("validating %s files is not supported, only safetensors is", format)
+	}
+
+	matches, err := glob(root, step.Path, false)
+	if err != nil {
+		return "", err
+	}
+
+	var files int
+	for _, match := range matches {
+		n, err := validateSafetensors(match)
+		if err != nil {
+			return "", err
+		}
+		files += n
+	}
+
+	return fmt.Sprintf("validated %d safetensors files", files), nil
+}
+
+func validateSafetensors(p string) (int, error) {
+	stat, err := os.Stat(p)
+	if err != nil {
+		return 0, err
+	}
+	if !stat.IsDir() {
+		if strings.HasSuffix(p, ".index.json") {
+			return validateSafetensorsIndex(p)
+		}
+		_, err := readSafetensorsHeader(p)
+		return 1, err
+	}
+
+	indexes, err := filepath.Glob(filepath.Join(p, "*.safetensors.index.json"))
+	if err != nil {
+		return 0, err
+	}
+	if len(indexes) > 0 {
+		var files int
+		for _, index := range indexes {
+			n, err := validateSafetensorsIndex(index)
+			if err != nil {
+				return 0, err
+			}
+			files += n
+		}
+		return files, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(p, "*.safetensors"))
+	if err != nil {
+		return 0, err
+	}
+	if len(files) == 0 {
+		return 0, fmt.Errorf("no safetensors files found in %s", filepath.Base(p))
+	}
+	for _, file := range files {
+		if _, err := readSafetensorsHeader(file); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(files), nil
+}
+
+// validateSafetensorsIndex checks the shards of a sharded model exist and
+// contain the tensors the index maps to them.
+func validateSafetensorsIndex(p string) (int, error) {
+	data, err := os.ReadFile(p) // #nosec G304
+	if err != nil {
+		return 0, err
+	}
+	var index struct {
+		WeightMap map[string]string `json:"weight_map"`
+	}
+	if err := json.Unmarshal(data, &index); err != nil {
+		return 0, fmt.Errorf("invalid index %s: %w", filepath.Base(p), err)
+	}
+	if len(index.WeightMap) == 0 {
+		return 0, fmt.Errorf("index %s has no weight_map", filepath.Base(p))
+	}
+
+	shards := make(map[string][]string)
+	for tensor, shard := range index.WeightMap {
+		shards[shard] = append(shards[shard], tensor)
+	}
+	names := lo.Keys(shards)
+	sort.Strings(names)
+	for _, shard := range names {
+		rel, err := utils.CleanRelativePath(shard)
+		if err != nil {
+			return 0, err
+		}
+		tensors, err := readSafetensorsHeader(filepath.Join(filepath.Dir(p), filepath.FromSlash(rel)))
+		if err != nil {
+			return 0, err
+		}
+		for _, tensor := range shards[shard] {
+			if _, ok := tensors[tensor]; !ok {
+				return 0, fmt.Errorf("tensor %s is not found in shard %s", tensor, shard)
+			}
+		}
+	}
+
+	return len(shards), nil
+}
+
+type safetensorsTensor struct {
+	DType       string   `json:"dtype"`
+	Shape       []int64  `json:"shape"`
+	DataOffsets [2]int64 `json:"data_offsets"`
+}
+
+// readSafetensorsHeader parses the header of a safetensors file and checks
+// the data of the tensors is within the file.
+func readSafetensorsHeader(p string) (map[string]safetensorsTensor, error) {
+	f, err := os.Open(p) // #nosec G304
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	name := filepath.Base(p)
+	var size uint64
+	if err := binary.Read(f, binary.LittleEndian, &size); err != nil {
+		return nil, fmt.Errorf("invalid safetensors file %s: %w", name, err)
+	}
+	if size > maxSafetensorsHeaderSize || int64(size) > stat.Size()-8 {
+		return nil, fmt.Errorf("invalid safetensors file %s: header size %d is out of range", name, size)
+	}
+	header := make([]byte, size)
+	if _, err := io.ReadFull(f, header); err != nil {
+		return nil, fmt.Errorf("invalid safetensors file %s: %w", name, err)
+	}
+
+	var entries map[string]json.RawMessage
+	if err := json.Unmarshal(header, &entries); err != nil {
+		return nil, fmt.Errorf("invalid safetensors header of %s: %w", name, err)
+	}
+	dataSize := stat.Size() - 8 - int64(size)
+	tensors := make(map[string]safetensorsTensor, len(entries))
+	for key, raw := range entries {
+		if key == "__metadata__" {
+			continue
+		}
+		var tensor safetensorsTensor
+		if err := json.Unmarshal(raw, &tensor); err != nil {
+			return nil, fmt.Errorf("invalid tensor %s of %s: %w", key, name, err)
+		}
+		begin, end := tensor.DataOffsets[0], tensor.DataOffsets[1]
+		if begin < 0 || begin > end || end > dataSize {
+			return nil, fmt.Errorf("data of tensor %s of %s is out of the file, the file may be truncated", key, name)
+		}
+		tensors[key] = tensor
+	}
+
+	return tensors, nil
+}
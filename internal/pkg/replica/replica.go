@@ -0,0 +1,71 @@
+// Package replica defines the layout of the stores datasets are replicated
+// through across clusters. The primary cluster publishes each round of a
+// dataset into the store, the secondary clusters load the rounds from it.
+//
+//	<store>/head/replica.json    the last published round
+//	<store>/rounds/<round>/...   the content of a round, with its manifest
+package replica
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// HeadFilename is the name of the head in the head directory of the
+	// store.
+	HeadFilename = "replica.json"
+
+	headDir   = "head"
+	roundsDir = "rounds"
+)
+
+// Head is the last round published to the store, it is written after the
+// content of the round so the rounds it points to are complete.
+type Head struct {
+	Round int32 `json:"round"`
+	// Digest is the digest of the content of the round reported by the data
+	// loader of the primary dataset, if any.
+	Digest string `json:"digest,omitempty"`
+	// ManifestSHA256 is the SHA256 of the manifest of the round.
+	ManifestSHA256 string    `json:"manifestSHA256"`
+	PublishTime    time.Time `json:"publishTime"`
+}
+
+// HeadURI returns the uri of the head directory of the store.
+func HeadURI(store string) string {
+	return strings.TrimSuffix(store, "/") + "/" + headDir
+}
+
+// RoundURI returns the uri of the content of the round in the store.
+func RoundURI(store string, round int32) string {
+	return fmt.Sprintf("%s/%s/%d", strings.TrimSuffix(store, "/"), roundsDir, round)
+}
+
+// WriteHead writes the head into the directory.
+func WriteHead(dir string, head Head) error {
+	data, err := json.Marshal(head)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(dir, HeadFilename), data, 0644) // #nosec G306
+}
+
+// ReadHead reads the head from the directory.
+func ReadHead(dir string) (*Head, error) {
+	data, err := os.ReadFile(filepath.Join(dir, HeadFilename))
+	if err != nil {
+		return nil, err
+	}
+	head := &Head{}
+	if err := json.Unmarshal(data, head); err != nil {
+		return nil, fmt.Errorf("invalid replica head: %w", err)
+	}
+
+	return head, nil
+}
@@ -0,0 +1,37 @@
+package replica
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestURIs(t *testing.T) {
+	assert.Equal(t, "s3://bucket/replicas/models/head", HeadURI("s3://bucket/replicas/models/"))
+	assert.Equal(t, "s3://bucket/replicas/models/rounds/3", RoundURI("s3://bucket/replicas/models", 3))
+}
+
+func TestHead(t *testing.T) {
+	dir := t.TempDir()
+	_, err := ReadHead(dir)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	head := Head{
+		Round:          3,
+		Digest:         "sha256:abc",
+		ManifestSHA256: "def",
+		PublishTime:    time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	require.NoError(t, WriteHead(dir, head))
+	read, err := ReadHead(dir)
+	require.NoError(t, err)
+	assert.Equal(t, head, *read)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, HeadFilename), []byte("{"), 0644))
+	_, err = ReadHead(dir)
+	assert.ErrorContains(t, err, "invalid replica head")
+}
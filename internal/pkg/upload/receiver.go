@@ -0,0 +1,427 @@
+package upload
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/BaizeAI/dataset/pkg/log"
+	"github.com/BaizeAI/dataset/pkg/utils"
+)
+
+const (
+	// OffsetHeader is the number of bytes of a chunked upload which are
+	// received, a chunk must start at this offset.
+	OffsetHeader = "Upload-Offset"
+	// TokenEnv is the environment variable of the bearer token the
+	// receiver accepts.
+	TokenEnv = "DATASET_UPLOAD_TOKEN"
+	// ReceiverPort is the port the receiver listens on in the helper pod.
+	ReceiverPort = 8080
+	// DigestAlgorithm prefixes the digests of the uploads.
+	DigestAlgorithm = "sha256"
+
+	// stagingDir keeps the unfinished uploads in the dataset, so they are
+	// moved into place by renaming them.
+	stagingDir = ".dataset-uploads"
+)
+
+var (
+	uploadIDRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+)
+
+// Result describes the content placed into the dataset by an upload.
+type Result struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	Digest string `json:"digest"`
+}
+
+// Receiver writes uploads into the directory of the dataset, it runs in the
+// helper pod mounting the PVC of the dataset.
+//
+// Files are uploaded in chunks, PATCH /uploads/{id} appends the body at the
+// offset of the Upload-Offset header, HEAD /uploads/{id} returns the offset to
+// resume from, and POST /uploads/{id}/complete?path=<path>[&digest=<digest>]
+// moves the file to its path once the digest is verified. PUT
+// /tar?path=<dir>[&digest=<digest>] extracts a tar stream, which is gzipped
+// when the Content-Encoding is gzip, into a directory of the dataset.
+type Receiver struct {
+	root  string
+	token string
+	uid   int
+	gid   int
+
+	locks  uploadLocks
+	logger *logrus.Entry
+}
+
+// uploadLocks serializes the requests of each upload, the lock of an upload
+// is removed once none of its requests is in flight, e.g. it is completed or
+// aborted.
+type uploadLocks struct {
+	mu    sync.Mutex
+	locks map[string]*uploadLock
+}
+
+type uploadLock struct {
+	sync.Mutex
+	refs int
+}
+
+// lock locks the upload of the id and returns the function unlocking it.
+func (l *uploadLocks) lock(id string) func() {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = map[string]*uploadLock{}
+	}
+	lock, ok := l.locks[id]
+	if !ok {
+		lock = &uploadLock{}
+		l.locks[id] = lock
+	}
+	lock.refs++
+	l.mu.Unlock()
+
+	lock.Lock()
+	return func() {
+		lock.Unlock()
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		lock.refs--
+		if lock.refs == 0 {
+			delete(l.locks, id)
+		}
+	}
+}
+
+func NewReceiver(root, token string, uid, gid int) (*Receiver, error) {
+	if token == "" {
+		return nil, fmt.Errorf("the token of the receiver is required")
+	}
+	if err := os.MkdirAll(path.Join(root, stagingDir), 0700); err != nil {
+		return nil, err
+	}
+
+	return &Receiver{
+		root:   root,
+		token:  token,
+		uid:    uid,
+		gid:    gid,
+		logger: log.WithField("component", "upload-receiver"),
+	}, nil
+}
+
+func (r *Receiver) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("HEAD /uploads/{id}", r.authorized(r.handleOffset))
+	mux.HandleFunc("PATCH /uploads/{id}", r.authorized(r.handleChunk))
+	mux.HandleFunc("DELETE /uploads/{id}", r.authorized(r.handleAbort))
+	mux.HandleFunc("POST /uploads/{id}/complete", r.authorized(r.handleComplete))
+	mux.HandleFunc("PUT /tar", r.authorized(r.handleTar))
+
+	return mux
+}
+
+func (r *Receiver) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(r.token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if id := req.PathValue("id"); id != "" {
+			if !uploadIDRegexp.MatchString(id) {
+				http.Error(w, fmt.Sprintf("invalid upload id %q", id), http.StatusBadRequest)
+				return
+			}
+			defer r.locks.lock(id)()
+		}
+
+		next(w, req)
+	}
+}
+
+func (r *Receiver) stagingPath(id string) string {
+	return path.Join(r.root, stagingDir, id)
+}
+
+func (r *Receiver) handleOffset(w http.ResponseWriter, req *http.Request) {
+	stat, err := os.Stat(r.stagingPath(req.PathValue("id")))
+	if errors.Is(err, os.ErrNotExist) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set(OffsetHeader, strconv.FormatInt(stat.Size(), 10))
+	w.WriteHeader(http.StatusOK)
+}
+
+func (r *Receiver) handleChunk(w http.ResponseWriter, req *http.Request) {
+	offset, err := strconv.ParseInt(req.Header.Get(OffsetHeader), 10, 64)
+	if err != nil || offset < 0 {
+		http.Error(w, fmt.Sprintf("invalid %s header", OffsetHeader), http.StatusBadRequest)
+		return
+	}
+
+	f, err := os.OpenFile(r.stagingPath(req.PathValue("id")), os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	stat, err := f.Stat()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if stat.Size() != offset {
+		w.Header().Set(OffsetHeader, strconv.FormatInt(stat.Size(), 10))
+		http.Error(w, fmt.Sprintf("the upload is at offset %d, got a chunk at offset %d", stat.Size(), offset), http.StatusConflict)
+		return
+	}
+
+	// a chunk which is interrupted is kept, the client resumes from the
+	// offset it reached
+	n, err := io.Copy(io.NewOffsetWriter(f, offset), req.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set(OffsetHeader, strconv.FormatInt(offset+n, 10))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (r *Receiver) handleAbort(w http.ResponseWriter, req *http.Request) {
+	err := os.Remove(r.stagingPath(req.PathValue("id")))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (r *Receiver) handleComplete(w http.ResponseWriter, req *http.Request) {
+	rel, err := cleanTargetPath(req.URL.Query().Get("path"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	staging := r.stagingPath(req.PathValue("id"))
+	f, err := os.Open(staging)
+	if errors.Is(err, os.ErrNotExist) {
+		http.Error(w, fmt.Sprintf("upload %s is not found", req.PathValue("id")), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	_ = f.Close()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	digest := formatDigest(h)
+	if expected := req.URL.Query().Get("digest"); expected != "" && expected != digest {
+		// the content is corrupted, the client has to upload it again
+		_ = os.Remove(staging)
+		http.Error(w, fmt.Sprintf("digest mismatch, expected %s, got %s", expected, digest), http.StatusUnprocessableEntity)
+		return
+	}
+
+	err = r.place(path.Join(stagingDir, req.PathValue("id")), rel)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	r.logger.Infof("placed upload %s as %s (%d bytes, %s)", req.PathValue("id"), rel, size, digest)
+
+	writeResult(w, Result{Path: rel, Size: size, Digest: digest})
+}
+
+func (r *Receiver) handleTar(w http.ResponseWriter, req *http.Request) {
+	rel := "."
+	if p := req.URL.Query().Get("path"); p != "" && path.Clean("/"+p) != "/" {
+		var err error
+		rel, err = cleanTargetPath(p)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	staging, err := os.MkdirTemp(path.Join(r.root, stagingDir), "tar-")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer func() {
+		_ = os.RemoveAll(staging)
+	}()
+
+	h := sha256.New()
+	counter := &countingWriter{}
+	body := io.TeeReader(req.Body, io.MultiWriter(h, counter))
+	var tr io.Reader = body
+	if req.Header.Get("Content-Encoding") == "gzip" {
+		gr, err := gzip.NewReader(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer func() {
+			_ = gr.Close()
+		}()
+		tr = gr
+	}
+	_, err = utils.ExtractTar(r.logger, tr, staging)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to extract the tar stream: %s", err), http.StatusBadRequest)
+		return
+	}
+	// the digest covers the whole stream as it is sent
+	_, err = io.Copy(io.Discard, body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	digest := formatDigest(h)
+	if expected := req.URL.Query().Get("digest"); expected != "" && expected != digest {
+		http.Error(w, fmt.Sprintf("digest mismatch, expected %s, got %s", expected, digest), http.StatusUnprocessableEntity)
+		return
+	}
+
+	err = r.placeDir(path.Join(stagingDir, path.Base(staging)), rel)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	r.logger.Infof("extracted tar stream into %s (%d bytes, %s)", rel, counter.n, digest)
+
+	writeResult(w, Result{Path: rel, Size: counter.n, Digest: digest})
+}
+
+// place moves src to dst, both are relative to the root and links are not
+// followed out of it.
+func (r *Receiver) place(src, dst string) error {
+	root, err := os.OpenRoot(r.root)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = root.Close()
+	}()
+
+	if dir := path.Dir(dst); dir != "." {
+		if err := root.MkdirAll(dir, 0755); err != nil { // #nosec G301
+			return err
+		}
+	}
+	_ = root.RemoveAll(dst)
+	if err := root.Rename(src, dst); err != nil {
+		return err
+	}
+	if err := root.Chmod(dst, 0644); err != nil {
+		return err
+	}
+
+	return root.Lchown(dst, r.uid, r.gid)
+}
+
+// placeDir merges the content of the directory src into dst, existing files
+// are replaced.
+func (r *Receiver) placeDir(src, dst string) error {
+	root, err := os.OpenRoot(r.root)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = root.Close()
+	}()
+
+	return fs.WalkDir(root.FS(), src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		target := path.Join(dst, strings.TrimPrefix(strings.TrimPrefix(p, src), "/"))
+		if p != src {
+			if _, err := cleanTargetPath(target); err != nil {
+				return err
+			}
+		}
+		if d.IsDir() {
+			if err := root.MkdirAll(target, 0755); err != nil { // #nosec G301
+				return err
+			}
+			return root.Lchown(target, r.uid, r.gid)
+		}
+		_ = root.RemoveAll(target)
+		if err := root.Rename(p, target); err != nil {
+			return err
+		}
+
+		return root.Lchown(target, r.uid, r.gid)
+	})
+}
+
+// cleanTargetPath returns the path relative to the root of a file of an
+// upload, which must not be in the staging directory.
+func cleanTargetPath(p string) (string, error) {
+	rel, err := utils.CleanRelativePath(p)
+	if err != nil {
+		return "", err
+	}
+	if rel == stagingDir || strings.HasPrefix(rel, stagingDir+"/") {
+		return "", fmt.Errorf("invalid path %q, %s is reserved for the uploads in progress", p, stagingDir)
+	}
+
+	return rel, nil
+}
+
+func formatDigest(h hash.Hash) string {
+	return DigestAlgorithm + ":" + hex.EncodeToString(h.Sum(nil))
+}
+
+func writeResult(w http.ResponseWriter, result Result) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(result)
+}
+
+type countingWriter struct {
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return len(p), nil
+}
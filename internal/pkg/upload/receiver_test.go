@@ -0,0 +1,164 @@
+package upload
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testDigest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return DigestAlgorithm + ":" + hex.EncodeToString(sum[:])
+}
+
+func TestReceiver(t *testing.T) {
+	root := t.TempDir()
+	receiver, err := NewReceiver(root, "secret", os.Getuid(), os.Getgid())
+	require.NoError(t, err)
+	server := httptest.NewServer(receiver.Handler())
+	defer server.Close()
+
+	do := func(method, path string, body []byte, headers map[string]string) *http.Response {
+		req, err := http.NewRequest(method, server.URL+path, bytes.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer secret")
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_ = resp.Body.Close()
+		})
+		return resp
+	}
+	decode := func(resp *http.Response) Result {
+		var result Result
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		return result
+	}
+
+	t.Run("unauthorized", func(t *testing.T) {
+		resp, err := http.Head(server.URL + "/uploads/a")
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		resp, err = http.Get(server.URL + "/healthz")
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("chunked upload", func(t *testing.T) {
+		content := []byte("hello, dataset")
+
+		assert.Equal(t, http.StatusNotFound, do(http.MethodHead, "/uploads/file-1", nil, nil).StatusCode)
+		assert.Equal(t, http.StatusBadRequest, do(http.MethodHead, "/uploads/a.b", nil, nil).StatusCode)
+
+		resp := do(http.MethodPatch, "/uploads/file-1", content[:5], map[string]string{OffsetHeader: "0"})
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		assert.Equal(t, "5", resp.Header.Get(OffsetHeader))
+
+		// a chunk which is sent again is rejected with the offset to resume from
+		resp = do(http.MethodPatch, "/uploads/file-1", content[:5], map[string]string{OffsetHeader: "0"})
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+		assert.Equal(t, "5", resp.Header.Get(OffsetHeader))
+
+		resp = do(http.MethodHead, "/uploads/file-1", nil, nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "5", resp.Header.Get(OffsetHeader))
+
+		resp = do(http.MethodPatch, "/uploads/file-1", content[5:], map[string]string{OffsetHeader: "5"})
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		assert.Equal(t, strconv.Itoa(len(content)), resp.Header.Get(OffsetHeader))
+
+		resp = do(http.MethodPost, "/uploads/file-1/complete?path=../evil", nil, nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		resp = do(http.MethodPost, "/uploads/file-1/complete?path=.dataset-uploads/x", nil, nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp = do(http.MethodPost, "/uploads/file-1/complete?path=dir/hello.txt&digest="+testDigest(content), nil, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, Result{Path: "dir/hello.txt", Size: int64(len(content)), Digest: testDigest(content)}, decode(resp))
+		data, err := os.ReadFile(filepath.Join(root, "dir", "hello.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, content, data)
+		assert.NoFileExists(t, filepath.Join(root, stagingDir, "file-1"))
+	})
+
+	t.Run("digest mismatch", func(t *testing.T) {
+		resp := do(http.MethodPatch, "/uploads/file-2", []byte("corrupted"), map[string]string{OffsetHeader: "0"})
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+		resp = do(http.MethodPost, "/uploads/file-2/complete?path=file&digest="+testDigest([]byte("expected")), nil, nil)
+		assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+		assert.NoFileExists(t, filepath.Join(root, "file"))
+		assert.NoFileExists(t, filepath.Join(root, stagingDir, "file-2"))
+	})
+
+	t.Run("abort", func(t *testing.T) {
+		resp := do(http.MethodPatch, "/uploads/file-3", []byte("data"), map[string]string{OffsetHeader: "0"})
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/uploads/file-3", nil, nil).StatusCode)
+		assert.NoFileExists(t, filepath.Join(root, stagingDir, "file-3"))
+
+		// the locks of the uploads completed or aborted are removed
+		assert.Eventually(t, func() bool {
+			receiver.locks.mu.Lock()
+			defer receiver.locks.mu.Unlock()
+			return len(receiver.locks.locks) == 0
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("tar stream", func(t *testing.T) {
+		var buf bytes.Buffer
+		gw := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gw)
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: "sub/", Typeflag: tar.TypeDir, Mode: 0755}))
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: "sub/a.txt", Typeflag: tar.TypeReg, Mode: 0644, Size: 1}))
+		_, err := tw.Write([]byte("a"))
+		require.NoError(t, err)
+		require.NoError(t, tw.Close())
+		require.NoError(t, gw.Close())
+
+		// existing files of the target directory are kept
+		require.NoError(t, os.MkdirAll(filepath.Join(root, "extracted", "sub"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(root, "extracted", "sub", "b.txt"), nil, 0600))
+
+		resp := do(http.MethodPut, "/tar?path=extracted&digest="+testDigest([]byte("other")), buf.Bytes(), map[string]string{"Content-Encoding": "gzip"})
+		assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+		assert.NoFileExists(t, filepath.Join(root, "extracted", "sub", "a.txt"))
+
+		resp = do(http.MethodPut, "/tar?path=extracted&digest="+testDigest(buf.Bytes()), buf.Bytes(), map[string]string{"Content-Encoding": "gzip"})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, Result{Path: "extracted", Size: int64(buf.Len()), Digest: testDigest(buf.Bytes())}, decode(resp))
+		data, err := os.ReadFile(filepath.Join(root, "extracted", "sub", "a.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, "a", string(data))
+		assert.FileExists(t, filepath.Join(root, "extracted", "sub", "b.txt"))
+
+		entries, err := os.ReadDir(filepath.Join(root, stagingDir))
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+
+		resp = do(http.MethodPut, "/tar", []byte("not a tar"), nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		body, _ := io.ReadAll(resp.Body)
+		assert.Contains(t, string(body), "failed to extract the tar stream")
+	})
+}
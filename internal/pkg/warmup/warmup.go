@@ -0,0 +1,190 @@
+// Package warmup copies a dataset onto the local storage of a node, the files
+// which are already there with the same size and modification time are kept.
+package warmup
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/BaizeAI/dataset/pkg/utils"
+)
+
+// Stats is what a mirror did.
+type Stats struct {
+	// Files is the number of files of the dataset.
+	Files int64
+	// CopiedFiles is the number of files which were missing or changed.
+	CopiedFiles int64
+	// CopiedBytes is the size of the copied files.
+	CopiedBytes int64
+	// RemovedFiles is the number of files and directories which are not in
+	// the dataset anymore.
+	RemovedFiles int64
+}
+
+// Options of a mirror.
+type Options struct {
+	// UID and GID own the copied files, -1 keeps the owner of the process.
+	UID int
+	GID int
+}
+
+// Mirror makes dst a copy of src, the files of dst which are not in src are
+// removed.
+func Mirror(logger *logrus.Entry, src string, dst string, opts Options) (Stats, error) {
+	var stats Stats
+	if err := os.MkdirAll(dst, 0755); err != nil { // #nosec G301
+		return stats, err
+	}
+
+	err := filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case d.IsDir():
+			return mirrorDir(target, info, opts)
+		case d.Type()&fs.ModeSymlink != 0:
+			return mirrorSymlink(p, target, opts)
+		case info.Mode().IsRegular():
+			stats.Files++
+			copied, err := mirrorFile(p, target, info, opts)
+			if err != nil {
+				return err
+			}
+			if copied {
+				stats.CopiedFiles++
+				stats.CopiedBytes += info.Size()
+			}
+		default:
+			logger.WithField("path", rel).Warn("skipped a file which is neither a directory, a symlink nor a regular file")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return stats, err
+	}
+
+	// removes what is not in the dataset anymore
+	err = filepath.WalkDir(dst, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dst, p)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		srcInfo, err := os.Lstat(filepath.Join(src, rel))
+		if err == nil && (srcInfo.IsDir() == d.IsDir()) {
+			return nil
+		}
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		if err := os.RemoveAll(p); err != nil {
+			return err
+		}
+		logger.WithField("path", rel).Debug("removed a file which is not in the dataset anymore")
+		stats.RemovedFiles++
+		if d.IsDir() {
+			return filepath.SkipDir
+		}
+
+		return nil
+	})
+
+	return stats, err
+}
+
+func chown(p string, opts Options) error {
+	if opts.UID < 0 && opts.GID < 0 {
+		return nil
+	}
+
+	return os.Lchown(p, opts.UID, opts.GID)
+}
+
+func mirrorDir(target string, info fs.FileInfo, opts Options) error {
+	targetInfo, err := os.Lstat(target)
+	if err == nil && !targetInfo.IsDir() {
+		if err := os.Remove(target); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(target, info.Mode().Perm()); err != nil {
+		return err
+	}
+	if err := os.Chmod(target, info.Mode().Perm()); err != nil {
+		return err
+	}
+
+	return chown(target, opts)
+}
+
+func mirrorSymlink(p string, target string, opts Options) error {
+	link, err := os.Readlink(p)
+	if err != nil {
+		return err
+	}
+	if existing, err := os.Readlink(target); err == nil && existing == link {
+		return nil
+	}
+	if err := os.RemoveAll(target); err != nil {
+		return err
+	}
+	if err := os.Symlink(link, target); err != nil {
+		return err
+	}
+
+	return chown(target, opts)
+}
+
+// mirrorFile copies the file unless the target has the same size and
+// modification time, and returns whether it is copied.
+func mirrorFile(p string, target string, info fs.FileInfo, opts Options) (bool, error) {
+	targetInfo, err := os.Lstat(target)
+	if err == nil && targetInfo.Mode().IsRegular() && targetInfo.Size() == info.Size() && targetInfo.ModTime().Equal(info.ModTime()) {
+		return false, nil
+	}
+	if err == nil && targetInfo.IsDir() {
+		if err := os.RemoveAll(target); err != nil {
+			return false, err
+		}
+	}
+
+	f, err := os.Open(p) // #nosec G304
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = f.Close() }()
+
+	if err := utils.WriteFileAtomic(target, f, info.Mode().Perm()); err != nil {
+		return false, err
+	}
+	if err := chown(target, opts); err != nil {
+		return false, err
+	}
+
+	return true, os.Chtimes(target, info.ModTime(), info.ModTime())
+}
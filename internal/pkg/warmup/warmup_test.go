@@ -0,0 +1,52 @@
+package warmup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/BaizeAI/dataset/pkg/log"
+)
+
+func writeFile(t *testing.T, path string, content string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func TestMirror(t *testing.T) {
+	src := t.TempDir()
+	dst := filepath.Join(t.TempDir(), "node")
+	writeFile(t, filepath.Join(src, "model.safetensors"), "weights")
+	writeFile(t, filepath.Join(src, "configs/config.json"), "{}")
+	require.NoError(t, os.Symlink("configs/config.json", filepath.Join(src, "config.json")))
+	opts := Options{UID: -1, GID: -1}
+	logger := log.WithField("action", "warmup")
+
+	stats, err := Mirror(logger, src, dst, opts)
+	require.NoError(t, err)
+	assert.Equal(t, Stats{Files: 2, CopiedFiles: 2, CopiedBytes: 9}, stats)
+	data, err := os.ReadFile(filepath.Join(dst, "config.json"))
+	require.NoError(t, err)
+	assert.Equal(t, "{}", string(data))
+
+	// the unchanged files are not copied again
+	stats, err = Mirror(logger, src, dst, opts)
+	require.NoError(t, err)
+	assert.Equal(t, Stats{Files: 2}, stats)
+
+	writeFile(t, filepath.Join(src, "model.safetensors"), "new weights")
+	require.NoError(t, os.RemoveAll(filepath.Join(src, "configs")))
+	require.NoError(t, os.Remove(filepath.Join(src, "config.json")))
+	writeFile(t, filepath.Join(dst, "stale/file"), "stale")
+	stats, err = Mirror(logger, src, dst, opts)
+	require.NoError(t, err)
+	assert.Equal(t, Stats{Files: 1, CopiedFiles: 1, CopiedBytes: 11, RemovedFiles: 3}, stats)
+	data, err = os.ReadFile(filepath.Join(dst, "model.safetensors"))
+	require.NoError(t, err)
+	assert.Equal(t, "new weights", string(data))
+	assert.NoDirExists(t, filepath.Join(dst, "configs"))
+	assert.NoDirExists(t, filepath.Join(dst, "stale"))
+}
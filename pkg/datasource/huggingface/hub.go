@@ -6,7 +6,10 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 )
 
@@ -15,6 +18,10 @@ const (
 	HubAPIEndpointDomain = "huggingface.co"
 
 	hubAPIEndpointPathWhoAmI = "/api/whoami-v2"
+	// /api/{models,datasets}/{repo_id}/revision/{revision}
+	hubAPIEndpointPathRepoRevision = "/api/%ss/%s/revision/%s"
+	// /api/{models,datasets}/{repo_id}/tree/{revision}
+	hubAPIEndpointPathRepoTree = "/api/%ss/%s/tree/%s?recursive=true"
 )
 
 type HfAPIAccessToken struct {
@@ -41,6 +48,26 @@ type HfAPIWhoAmIResponse struct {
 	Type          string                  `json:"type"`
 }
 
+type HfAPIRepoInfo struct {
+	ID  string `json:"id"`
+	SHA string `json:"sha"`
+}
+
+// HfAPIRepoFile is a file of a repository, files stored with Git LFS have
+// the SHA256 of their content in LFS.
+type HfAPIRepoFile struct {
+	Type string            `json:"type"`
+	Path string            `json:"path"`
+	Size int64             `json:"size"`
+	OID  string            `json:"oid"`
+	LFS  *HfAPIRepoFileLFS `json:"lfs,omitempty"`
+}
+
+type HfAPIRepoFileLFS struct {
+	OID  string `json:"oid"`
+	Size int64  `json:"size"`
+}
+
 type HfAPIErrorResponse struct {
 	Error string `json:"error"`
 }
@@ -77,6 +104,15 @@ func NewHfAPIClient() *HfAPIClient {
 	}
 }
 
+// NewHfAPIClientWithEndpoint creates a new HfAPIClient for the hub served at
+// endpoint, the default hub is used when endpoint is empty.
+func NewHfAPIClientWithEndpoint(endpoint string) *HfAPIClient {
+	c := NewHfAPIClient()
+	c.apiEndpoint = strings.TrimSuffix(endpoint, "/")
+
+	return c
+}
+
 func (c *HfAPIClient) endpoint() string {
 	if c.apiEndpoint == "" {
 		return HubAPIEndpointScheme + HubAPIEndpointDomain
@@ -130,6 +166,132 @@ func (c *HfAPIClient) WhoAmI(ctx context.Context, token string) (*HfAPIWhoAmIRes
 	return &whoAmIResponse, nil
 }
 
+// RepoInfo returns the repository at the given revision, repoType is either
+// model or dataset and defaults to model.
+//
+// Source code: https://github.com/huggingface/huggingface_hub/blob/8d1ffc6d78827aa18c4fec3f73843ac7bb64a153/src/huggingface_hub/hf_api.py#L2440-L2504
+func (c *HfAPIClient) RepoInfo(ctx context.Context, token string, repoType string, repoID string, revision string) (*HfAPIRepoInfo, error) {
+	if repoType == "" {
+		repoType = "model"
+	}
+
+	req, err := http.NewRequest(http.MethodGet, c.endpoint()+fmt.Sprintf(hubAPIEndpointPathRepoRevision, repoType, repoID, url.PathEscape(revision)), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req = req.WithContext(ctx)
+	req.Header = c.buildHfHeaders(token)
+	if token == "" {
+		req.Header.Del("Authorization")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	bodyBuffer := new(bytes.Buffer)
+	_, err = bodyBuffer.ReadFrom(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var errResponse HfAPIErrorResponse
+	err = json.Unmarshal(bodyBuffer.Bytes(), &errResponse)
+	if err != nil {
+		return nil, err
+	}
+	if errResponse.Error != "" {
+		return nil, &HfAPIError{errResponse}
+	}
+
+	var repoInfo HfAPIRepoInfo
+	err = json.Unmarshal(bodyBuffer.Bytes(), &repoInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	return &repoInfo, nil
+}
+
+// ListRepoFiles returns the files of the repository at the given revision,
+// following the pages of the tree, repoType is either model or dataset and
+// defaults to model.
+func (c *HfAPIClient) ListRepoFiles(ctx context.Context, token string, repoType string, repoID string, revision string) ([]HfAPIRepoFile, error) {
+	if repoType == "" {
+		repoType = "model"
+	}
+
+	var files []HfAPIRepoFile
+	next := c.endpoint() + fmt.Sprintf(hubAPIEndpointPathRepoTree, repoType, repoID, url.PathEscape(revision))
+	for next != "" {
+		req, err := http.NewRequest(http.MethodGet, next, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		req = req.WithContext(ctx)
+		req.Header = c.buildHfHeaders(token)
+		if token == "" {
+			req.Header.Del("Authorization")
+		}
+
+		resp, err := c.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		bodyBuffer := new(bytes.Buffer)
+		_, err = bodyBuffer.ReadFrom(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			var errResponse HfAPIErrorResponse
+			_ = json.Unmarshal(bodyBuffer.Bytes(), &errResponse)
+			if errResponse.Error == "" {
+				errResponse.Error = fmt.Sprintf("unexpected status %s", resp.Status)
+			}
+			return nil, &HfAPIError{errResponse}
+		}
+
+		var page []HfAPIRepoFile
+		err = json.Unmarshal(bodyBuffer.Bytes(), &page)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range page {
+			if file.Type == "file" {
+				files = append(files, file)
+			}
+		}
+
+		next = nextPageURL(resp.Header.Get("Link"))
+	}
+
+	return files, nil
+}
+
+// nextPageURL returns the url of the next page in the Link header, e.g.
+// <https://huggingface.co/api/models/gpt2/tree/main?cursor=xxx>; rel="next".
+func nextPageURL(link string) string {
+	for _, part := range strings.Split(link, ",") {
+		target, params, ok := strings.Cut(strings.TrimSpace(part), ";")
+		if ok && strings.Contains(params, `rel="next"`) {
+			return strings.Trim(strings.TrimSpace(target), "<>")
+		}
+	}
+
+	return ""
+}
+
 // Documentations: https://huggingface.co/docs/huggingface_hub/quick-start#authentication
 // Source code: https://github.com/huggingface/huggingface_hub/blob/8d1ffc6d78827aa18c4fec3f73843ac7bb64a153/src/huggingface_hub/hf_api.py#L1609-L1629
 // References:
@@ -107,3 +107,66 @@ func TestWhoAmI(t *testing.T) {
 		assert.Equal(t, "Invalid username or password.", errResp.HfAPIErrorResponse.Error)
 	})
 }
+
+func TestRepoInfo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.URL.EscapedPath() {
+		case "/api/datasets/ns/data/revision/refs%2Fpr%2F1":
+			assert.Equal(t, "Bearer token", req.Header.Get("Authorization"))
+			_, _ = rw.Write([]byte(`{"id":"ns/data","sha":"abc"}`))
+		case "/api/models/ns/model/revision/main":
+			assert.Empty(t, req.Header.Get("Authorization"))
+			_, _ = rw.Write([]byte(`{"id":"ns/model","sha":"def"}`))
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+			_, _ = rw.Write([]byte(`{"error":"Revision Not Found"}`))
+		}
+	}))
+	defer server.Close()
+
+	c := NewHfAPIClientWithEndpoint(server.URL + "/")
+
+	repoInfo, err := c.RepoInfo(context.Background(), "token", "dataset", "ns/data", "refs/pr/1")
+	require.NoError(t, err)
+	assert.Equal(t, &HfAPIRepoInfo{ID: "ns/data", SHA: "abc"}, repoInfo)
+
+	repoInfo, err = c.RepoInfo(context.Background(), "", "", "ns/model", "main")
+	require.NoError(t, err)
+	assert.Equal(t, "def", repoInfo.SHA)
+
+	_, err = c.RepoInfo(context.Background(), "", "", "ns/model", "missing")
+	assert.True(t, IsHfAPIError(err))
+	assert.EqualError(t, err, "Revision Not Found")
+}
+
+func TestListRepoFiles(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/api/models/ns/model/tree/main":
+			assert.Equal(t, "true", req.URL.Query().Get("recursive"))
+			if req.URL.Query().Get("cursor") == "" {
+				rw.Header().Set("Link", "<"+server.URL+`/api/models/ns/model/tree/main?recursive=true&cursor=2>; rel="next"`)
+				_, _ = rw.Write([]byte(`[{"type":"directory","path":"sub"},{"type":"file","path":"config.json","size":12,"oid":"abc"}]`))
+				return
+			}
+			_, _ = rw.Write([]byte(`[{"type":"file","path":"sub/model.safetensors","size":100,"oid":"def","lfs":{"oid":"0123","size":100}}]`))
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+			_, _ = rw.Write([]byte(`{"error":"Repository not found"}`))
+		}
+	}))
+	defer server.Close()
+
+	c := NewHfAPIClientWithEndpoint(server.URL)
+
+	files, err := c.ListRepoFiles(context.Background(), "", "", "ns/model", "main")
+	require.NoError(t, err)
+	assert.Equal(t, []HfAPIRepoFile{
+		{Type: "file", Path: "config.json", Size: 12, OID: "abc"},
+		{Type: "file", Path: "sub/model.safetensors", Size: 100, OID: "def", LFS: &HfAPIRepoFileLFS{OID: "0123", Size: 100}},
+	}, files)
+
+	_, err = c.ListRepoFiles(context.Background(), "", "dataset", "ns/missing", "main")
+	assert.EqualError(t, err, "Repository not found")
+}
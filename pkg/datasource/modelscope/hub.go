@@ -6,7 +6,9 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/url"
 )
 
 type HubAPIBaseResponse[T any] struct {
@@ -24,6 +26,19 @@ type HubAPILoginResponse struct {
 	WorkNo      string `json:"WorkNo"`
 }
 
+type HubAPIModelFilesResponse struct {
+	Files []HubAPIModelFile `json:"Files"`
+}
+
+// HubAPIModelFile is a file or a directory of a model repository.
+type HubAPIModelFile struct {
+	Name   string `json:"Name"`
+	Path   string `json:"Path"`
+	Type   string `json:"Type"`
+	Size   int64  `json:"Size"`
+	Sha256 string `json:"Sha256"`
+}
+
 type HubAPIError struct {
 	HubAPIBaseResponse[any]
 }
@@ -42,6 +57,8 @@ const (
 	HubAPIEndpointDomain = "www.modelscope.cn"
 
 	hubAPIEndpointPathLogin = "/api/v1/login"
+	// /api/v1/models/{model_id}/repo/files
+	hubAPIEndpointPathModelFiles = "/api/v1/models/%s/repo/files?Revision=%s&Recursive=True"
 )
 
 //counterfeiter:generate -o fake/hub.go --fake-name FakeHubAPI . HubAPI
@@ -122,3 +139,46 @@ func (c *HubAPIClient) Login(ctx context.Context, token string) (*HubAPIBaseResp
 
 	return &response, nil
 }
+
+// ListModelFiles returns the files of the model at the given revision, the
+// directories are skipped.
+func (c *HubAPIClient) ListModelFiles(ctx context.Context, modelID string, revision string) ([]HubAPIModelFile, error) {
+	req, err := http.NewRequest(http.MethodGet, c.endpoint()+fmt.Sprintf(hubAPIEndpointPathModelFiles, modelID, url.QueryEscape(revision)), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req = req.WithContext(ctx)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	var response HubAPIBaseResponse[HubAPIModelFilesResponse]
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	if err != nil {
+		return nil, err
+	}
+	if !response.Success || response.Data == nil {
+		return nil, &HubAPIError{HubAPIBaseResponse: HubAPIBaseResponse[any]{
+			Code:      response.Code,
+			Message:   response.Message,
+			RequestID: response.RequestID,
+			Success:   response.Success,
+		}}
+	}
+
+	files := make([]HubAPIModelFile, 0, len(response.Data.Files))
+	for _, file := range response.Data.Files {
+		if file.Type != "tree" {
+			files = append(files, file)
+		}
+	}
+
+	return files, nil
+}
@@ -100,3 +100,30 @@ func TestLogin(t *testing.T) {
 		assert.False(t, errResp.Success)
 	})
 }
+
+func TestListModelFiles(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/api/v1/models/ns/model/repo/files" {
+			_, _ = rw.Write([]byte(`{"Code":10010205001,"Message":"model not found","Success":false}`))
+			return
+		}
+		assert.Equal(t, "master", req.URL.Query().Get("Revision"))
+		_, _ = rw.Write([]byte(`{"Code":200,"Data":{"Files":[
+			{"Name":"sub","Path":"sub","Type":"tree"},
+			{"Name":"model.bin","Path":"sub/model.bin","Type":"blob","Size":100,"Sha256":"0123"}
+		]},"Success":true}`))
+	}))
+	defer server.Close()
+
+	c := NewHubAPIClient()
+	c.apiEndpoint = server.URL
+	c.client = server.Client()
+
+	files, err := c.ListModelFiles(context.Background(), "ns/model", "master")
+	require.NoError(t, err)
+	assert.Equal(t, []HubAPIModelFile{{Name: "model.bin", Path: "sub/model.bin", Type: "blob", Size: 100, Sha256: "0123"}}, files)
+
+	_, err = c.ListModelFiles(context.Background(), "ns/missing", "master")
+	assert.True(t, IsHubAPIError(err))
+	assert.EqualError(t, err, "model not found")
+}
@@ -12,4 +12,9 @@ const (
 	SecretKeyToken                SecretKey = "token"
 	SecretKeyAccessKey            SecretKey = "access-key"
 	SecretKeySecretKey            SecretKey = "secret-key"
+	SecretKeyCACert               SecretKey = "ca.crt"
+	SecretKeyTLSCert              SecretKey = "tls.crt"
+	SecretKeyTLSKey               SecretKey = "tls.key"
+	SecretKeyKeytab               SecretKey = "keytab"
+	SecretKeyPrincipal            SecretKey = "principal"
 )
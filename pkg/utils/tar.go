@@ -0,0 +1,177 @@
+package utils
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// CleanRelativePath returns the slash separated path relative to the target
+// directory of a path of a layer or tar archive, which must not escape it.
+func CleanRelativePath(p string) (string, error) {
+	rel := path.Clean(strings.TrimLeft(strings.ReplaceAll(p, "\\", "/"), "/"))
+	if rel == "." || rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", fmt.Errorf("invalid path %q, it must be inside the dataset", p)
+	}
+
+	return rel, nil
+}
+
+// ExtractTar extracts a tar archive into targetDir, the whiteouts of image
+// layers remove the files of the previous layers. Links are confined to
+// targetDir.
+func ExtractTar(logger *logrus.Entry, r io.Reader, targetDir string) ([]string, error) {
+	root, err := os.OpenRoot(targetDir)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = root.Close()
+	}()
+
+	var paths []string
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if header.Typeflag == tar.TypeDir && path.Clean("/"+header.Name) == "/" {
+			continue
+		}
+		rel, err := CleanRelativePath(header.Name)
+		if err != nil {
+			return nil, err
+		}
+		dir, base := path.Split(rel)
+		if base == ".wh..wh..opq" {
+			if err := removeDirContent(root, path.Clean(dir)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if removed, ok := strings.CutPrefix(base, ".wh."); ok {
+			if err := root.RemoveAll(path.Join(dir, removed)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if dir != "" {
+			if err := root.MkdirAll(path.Clean(dir), 0755); err != nil { // #nosec G301
+				return nil, err
+			}
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := root.MkdirAll(rel, 0755); err != nil { // #nosec G301
+				return nil, err
+			}
+		case tar.TypeReg:
+			if err := extractTarFile(root, rel, tr, header.FileInfo().Mode().Perm()); err != nil {
+				return nil, err
+			}
+		case tar.TypeSymlink:
+			_ = root.RemoveAll(rel)
+			if err := root.Symlink(header.Linkname, rel); err != nil {
+				return nil, err
+			}
+		case tar.TypeLink:
+			target, err := CleanRelativePath(header.Linkname)
+			if err != nil {
+				return nil, err
+			}
+			_ = root.RemoveAll(rel)
+			if err := root.Link(target, rel); err != nil {
+				return nil, err
+			}
+		default:
+			logger.Debugf("skipping %s of type %c", header.Name, header.Typeflag)
+			continue
+		}
+		paths = append(paths, rel)
+	}
+
+	// the remaining padding is read for the digest to be verified
+	_, err = io.Copy(io.Discard, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return paths, nil
+}
+
+func extractTarFile(root *os.Root, rel string, r io.Reader, perm os.FileMode) error {
+	_ = root.RemoveAll(rel)
+	f, err := root.OpenFile(rel, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm|0600)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r) // #nosec G110
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+func removeDirContent(root *os.Root, dir string) error {
+	f, err := root.Open(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	names, err := f.Readdirnames(-1)
+	_ = f.Close()
+	if err != nil {
+		return err
+	}
+	for _, n := range names {
+		if err := root.RemoveAll(path.Join(dir, n)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WriteFileAtomic writes the content of r to a temporary file next to p,
+// which replaces p once r is read to the end without errors.
+func WriteFileAtomic(p string, r io.Reader, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil { // #nosec G301
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(p), "."+filepath.Base(p)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	_, err = io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), p)
+}
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/BaizeAI/dataset/pkg/log"
+)
+
+func TestExtractTar(t *testing.T) {
+	build := func(headers ...*tar.Header) *bytes.Reader {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		for _, header := range headers {
+			require.NoError(t, tw.WriteHeader(header))
+			if header.Size > 0 {
+				_, err := tw.Write(bytes.Repeat([]byte("x"), int(header.Size)))
+				require.NoError(t, err)
+			}
+		}
+		require.NoError(t, tw.Close())
+		return bytes.NewReader(buf.Bytes())
+	}
+
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "opaque"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "opaque", "old"), nil, 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "removed"), nil, 0600))
+
+	paths, err := ExtractTar(log.WithField("test", "test"), build(
+		&tar.Header{Name: "./", Typeflag: tar.TypeDir, Mode: 0755},
+		&tar.Header{Name: "./dir/file", Typeflag: tar.TypeReg, Mode: 0644, Size: 3},
+		&tar.Header{Name: "dir/link", Typeflag: tar.TypeSymlink, Linkname: "file"},
+		&tar.Header{Name: "opaque/.wh..wh..opq", Typeflag: tar.TypeReg},
+		&tar.Header{Name: ".wh.removed", Typeflag: tar.TypeReg},
+	), root)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dir/file", "dir/link"}, paths)
+	data, err := os.ReadFile(filepath.Join(root, "dir", "link"))
+	require.NoError(t, err)
+	assert.Equal(t, "xxx", string(data))
+	assert.NoFileExists(t, filepath.Join(root, "opaque", "old"))
+	assert.NoFileExists(t, filepath.Join(root, "removed"))
+
+	_, err = ExtractTar(log.WithField("test", "test"), build(&tar.Header{Name: "../evil", Typeflag: tar.TypeReg, Mode: 0644}), root)
+	assert.ErrorContains(t, err, `invalid path "../evil"`)
+
+	// files are not written through links which escape the dataset
+	_, err = ExtractTar(log.WithField("test", "test"), build(
+		&tar.Header{Name: "escape", Typeflag: tar.TypeSymlink, Linkname: "/tmp"},
+		&tar.Header{Name: "escape/evil", Typeflag: tar.TypeReg, Mode: 0644, Size: 1},
+	), root)
+	assert.Error(t, err)
+}